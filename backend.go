@@ -4,6 +4,14 @@ import "time"
 
 //go:generate counterfeiter . Backend
 
+// Backend is the container implementation a server drives.
+//
+// A backend that cannot perform an operation at all, e.g. because the host
+// lacks the kernel feature it needs, must fail it with an
+// UnsupportedOperationError naming the operation, rather than a generic
+// error, so that clients can tell it apart from the operation failing.
+// Likewise, a write that fails because a container is out of disk quota, be
+// it a stream in or a process's Wait, must fail with a QuotaExceededError.
 type Backend interface {
 	Client
 
@@ -11,4 +19,26 @@ type Backend interface {
 	Stop()
 
 	GraceTime(Container) time.Duration
+
+	// ContainerForHostPID returns the handle of the container that the
+	// process with the given host PID runs in, or a HostPIDNotFoundError if
+	// it does not run in any container.
+	ContainerForHostPID(pid int) (string, error)
+
+	// OOMEvents returns the OOM kills in containers as the memory cgroup
+	// reports them. The server calls it when a client first watches for
+	// them, and again only if that fails. The channel is closed when the
+	// backend stops. A backend which cannot watch for OOM kills fails with an
+	// UnsupportedOperationError.
+	OOMEvents() (<-chan OOMEvent, error)
+}
+
+// OOMEvent reports a process killed because its container ran out of memory.
+type OOMEvent struct {
+	Handle string    `json:"handle"`
+	Time   time.Time `json:"time"`
+
+	// ProcessID is the ID of the killed process if it was run through garden,
+	// and empty otherwise.
+	ProcessID string `json:"process_id,omitempty"`
 }
@@ -17,12 +17,38 @@ type Client interface {
 	// * None.
 	Capacity() (Capacity, error)
 
+	// Features reports which optional capabilities the server's backend
+	// supports, so that clients can avoid relying on ones it lacks.
+	//
+	// Errors:
+	// * None.
+	Features() (FeatureSet, error)
+
+	// ValidateRootFS checks, without creating a container, that a rootfs
+	// could be used to create one: that it is a well-formed path or image URI,
+	// as for ContainerSpec.Image, that a path is a directory with the
+	// structure of a root filesystem, and that an image can be resolved. An
+	// empty rootfs, meaning the default one, is valid.
+	//
+	// Errors:
+	// * garden.InvalidRootFSError saying what is wrong with the rootfs.
+	// * garden.UnsupportedOperationError when the rootfs is an image, or has
+	//   a scheme other than docker://, but the backend cannot tell whether it
+	//   can be used.
+	ValidateRootFS(rootfs string) error
+
 	// Create creates a new container.
 	//
 	// Errors:
 	// * When the handle, if specified, is already taken.
-	// * When one of the bind_mount paths does not exist.
+	// * garden.BindMountError when one of the bind_mount paths does not exist or,
+	//   for a read-only mount, cannot be read, or when a mount is read-only but
+	//   the backend cannot enforce it (see FeatureSet.ReadOnlyBindMounts).
 	// * When resource allocations fail (subnet, user ID, etc).
+	// * garden.DrainingError when the server is draining and not accepting
+	//   new containers.
+	// * garden.MaxContainersReachedError when the server already has its
+	//   maximum number of containers.
 	Create(ContainerSpec) (Container, error)
 
 	// Destroy destroys a container.
@@ -31,7 +57,8 @@ type Client interface {
 	// its filesystem is removed, and all references to its handle are removed.
 	//
 	// All resources that have been acquired during the lifetime of the container are released.
-	// Examples of these resources are its subnet, its UID, and ports that were redirected to the container.
+	// Examples of these resources are its subnet, its UID, ports that were redirected to the container,
+	// and its scratch volumes.
 	//
 	// TODO: list the resources that can be acquired during the lifetime of a container.
 	//
@@ -39,7 +66,17 @@ type Client interface {
 	// * TODO.
 	Destroy(handle string) error
 
-	// Containers lists all containers filtered by Properties (which are ANDed together).
+	// Rename changes the handle of an existing container. Any state the server
+	// keeps for the container, such as its grace time, follows it to the new
+	// handle.
+	//
+	// Errors:
+	// * Container not found.
+	// * garden.HandleConflictError when newHandle is already in use.
+	Rename(oldHandle, newHandle string) error
+
+	// Containers lists all containers filtered by Properties (which are ANDed together),
+	// sorted by handle.
 	//
 	// Errors:
 	// * None.
@@ -96,6 +133,33 @@ type ContainerSpec struct {
 	// * "docker://index.docker.io/busybox"
 	Image ImageRef `json:"image,omitempty"`
 
+	// CloneFrom, if specified, is the handle of an existing container whose root
+	// file system is cloned, copy-on-write, to be the root file system of the
+	// new container. It cannot be combined with RootFSPath or Image.
+	//
+	// The clone is a snapshot taken during creation: later changes to either
+	// container's file system are not seen by the other, and the source
+	// container can be destroyed without affecting the clone. The source
+	// cannot be destroyed while the clone is being taken.
+	//
+	// An error is returned if:
+	// * the source container does not exist, or
+	// * the backend does not support cloning (see FeatureSet.Clone).
+	CloneFrom string `json:"clone_from,omitempty"`
+
+	// RootFSLayers, if specified, assemble the container's root file system as
+	// an overlay of read-only lower layers under a writable upper layer, so
+	// that many containers can share a base image without copying it. It
+	// cannot be combined with RootFSPath, Image or CloneFrom.
+	//
+	// An error is returned if:
+	// * no lower layer is given,
+	// * a layer is not an absolute path to an existing host directory,
+	// * two layers overlap, i.e. one is the same as or inside another, or
+	// * the backend does not support layered root file systems (see
+	//   FeatureSet.RootFSLayers).
+	RootFSLayers *RootFSLayers `json:"rootfs_layers,omitempty"`
+
 	// * bind_mounts: a list of mount point descriptions which will result in corresponding mount
 	// points being created in the container's file system.
 	//
@@ -104,6 +168,17 @@ type ContainerSpec struct {
 	// * one or more of the mount points cannot be created.
 	BindMounts []BindMount `json:"bind_mounts,omitempty"`
 
+	// ScratchVolumes is a list of ephemeral volumes to be mounted into the
+	// container's file system, separate from its root file system. They are
+	// created empty and are removed when the container is destroyed, whether
+	// explicitly or by reaping.
+	//
+	// An error is returned if:
+	// * a volume's path is not absolute or is used by another volume or bind mount,
+	// * a volume has no size limit, or
+	// * the backend does not support scratch volumes (see FeatureSet.ScratchVolumes).
+	ScratchVolumes []ScratchVolume `json:"scratch_volumes,omitempty"`
+
 	// Network determines the subnet and IP address of a container.
 	//
 	// If not specified, a /30 subnet is allocated from a default network pool.
@@ -144,9 +219,51 @@ type ContainerSpec struct {
 	// user in the container is mapped to a non-root user in the host. Defaults to false.
 	Privileged bool `json:"privileged,omitempty"`
 
+	// UIDMappings and GIDMappings configure the container's user namespace,
+	// e.g. mapping root in the container to an unprivileged user on the host.
+	// If they are not specified the backend's default mapping is used. They
+	// cannot be specified for a privileged container.
+	UIDMappings []IDMapping `json:"uid_mappings,omitempty"`
+	GIDMappings []IDMapping `json:"gid_mappings,omitempty"`
+
 	// Limits to be applied to the newly created container.
 	Limits Limits `json:"limits,omitempty"`
 
+	// Sysctls are kernel parameters set in the container's namespaces once
+	// they have been created, e.g. "net.core.somaxconn": "1024". Only
+	// namespaced parameters can be set: those under net. and fs.mqueue., and
+	// the System V IPC parameters kernel.shmall, kernel.shmmax,
+	// kernel.shmmni, kernel.shm_rmid_forced, kernel.msgmax, kernel.msgmnb,
+	// kernel.msgmni and kernel.sem. Any other parameter would change the
+	// host, and is rejected.
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+
+	// DNSServers are the IP addresses of the nameservers written to the
+	// container's /etc/resolv.conf, in order of preference. If none are given
+	// the backend's default resolvers are used.
+	DNSServers []string `json:"dns_servers,omitempty"`
+
+	// DNSSearch are the domains written to the search line of the
+	// container's /etc/resolv.conf, used to resolve unqualified names.
+	// Containers with DNS settings cannot be created on a backend which does
+	// not report FeatureSet.DNS.
+	DNSSearch []string `json:"dns_search,omitempty"`
+
+	// CgroupParent, if specified, places the container's cgroups under the
+	// given parent instead of the backend's default, so that its usage is
+	// accounted to that part of the host's hierarchy. It is either a systemd
+	// slice, e.g. "tenants-acme.slice", or an absolute cgroupfs path, e.g.
+	// "/tenants/acme". The backend creates the parent if it does not exist.
+	// Containers with a cgroup parent cannot be created on a backend which
+	// does not report FeatureSet.CgroupParent.
+	CgroupParent string `json:"cgroup_parent,omitempty"`
+
+	// Syslog has the server forward the output of every process run in the
+	// container through it to a syslog endpoint, unless the process names
+	// its own. The server keeps one connection to each endpoint for the
+	// container, for as long as the container exists.
+	Syslog *SyslogSpec `json:"syslog,omitempty"`
+
 	// Whitelist outbound network traffic.
 	//
 	// If the configuration directive deny_networks is not used,
@@ -167,6 +284,14 @@ type ContainerSpec struct {
 	NetIn []NetIn `json:"netin,omitempty"`
 }
 
+// IDMapping maps a contiguous range of user or group IDs in a container's
+// user namespace to a range of IDs on the host.
+type IDMapping struct {
+	ContainerID uint32 `json:"container_id"`
+	HostID      uint32 `json:"host_id"`
+	Size        uint32 `json:"size"`
+}
+
 type ImageRef struct {
 	URI      string `json:"uri,omitempty"`
 	Username string `json:"username,omitempty"`
@@ -179,6 +304,18 @@ type Limits struct {
 	Disk      DiskLimits      `json:"disk_limits,omitempty"`
 	Memory    MemoryLimits    `json:"memory_limits,omitempty"`
 	Pid       PidLimits       `json:"pid_limits,omitempty"`
+	IO        IOLimits        `json:"io_limits,omitempty"`
+}
+
+// LimitsUpdate is a change to some of a container's limits. Limits left nil
+// are not changed.
+type LimitsUpdate struct {
+	Bandwidth *BandwidthLimits `json:"bandwidth_limits,omitempty"`
+	CPU       *CPULimits       `json:"cpu_limits,omitempty"`
+	Disk      *DiskLimits      `json:"disk_limits,omitempty"`
+	Memory    *MemoryLimits    `json:"memory_limits,omitempty"`
+	Pid       *PidLimits       `json:"pid_limits,omitempty"`
+	IO        *IOLimits        `json:"io_limits,omitempty"`
 }
 
 // BindMount specifies parameters for a single mount point.
@@ -191,7 +328,8 @@ type BindMount struct {
 	// SrcPath contains the path of the directory to be mounted.
 	SrcPath string `json:"src_path,omitempty"`
 
-	// DstPath contains the path of the mount point in the container. If the
+	// DstPath contains the path of the mount point in the container. It
+	// must be absolute and must not contain any ".." elements. If the
 	// directory does not exist, it is created.
 	DstPath string `json:"dst_path,omitempty"`
 
@@ -205,6 +343,50 @@ type BindMount struct {
 	// If origin is "Host", src_path denotes a path in the host.
 	// If origin is "Container", src_path denotes a path in the container.
 	Origin BindMountOrigin `json:"origin,omitempty"`
+
+	// Propagation must be either "Private", "Shared" or "Slave". Alternatively,
+	// propagation may be omitted and defaults to "Private".
+	// If propagation is "Private", mounts and unmounts under the mount point
+	// are seen by neither the host nor the container.
+	// If propagation is "Shared", they are seen by both.
+	// If propagation is "Slave", the container sees those made on the host,
+	// but the host does not see those made in the container.
+	Propagation BindMountPropagation `json:"propagation,omitempty"`
+}
+
+// RootFSLayers are the host directories assembled into an overlay to be a
+// container's root file system.
+type RootFSLayers struct {
+	// Lower are the read-only layers, uppermost first. They are never written
+	// to, so may be shared by any number of containers.
+	Lower []string `json:"lower,omitempty"`
+
+	// Upper is the writable layer holding the container's changes. If it is
+	// not specified, the backend creates an empty one which is removed when
+	// the container is destroyed, whether explicitly or by reaping. An upper
+	// layer that is specified belongs to the caller: it is left in place when
+	// the container is destroyed.
+	Upper string `json:"upper,omitempty"`
+}
+
+// ScratchVolume specifies an ephemeral volume for a container.
+//
+// Writes which would take a volume over its size limit fail with ENOSPC
+// inside the container; the container and its processes are otherwise
+// unaffected. A memory-backed volume also counts towards the container's
+// memory limit.
+type ScratchVolume struct {
+	// Path is the absolute path of the mount point in the container. If the
+	// directory does not exist, it is created.
+	Path string `json:"path,omitempty"`
+
+	// SizeInBytes is the maximum size of the volume's contents.
+	SizeInBytes uint64 `json:"size_in_bytes,omitempty"`
+
+	// Medium is what holds the volume's contents: ScratchVolumeMediumDisk,
+	// the default, for storage on the host set aside for the volume, or
+	// ScratchVolumeMediumMemory for a tmpfs.
+	Medium ScratchVolumeMedium `json:"medium,omitempty"`
 }
 
 type Capacity struct {
@@ -213,6 +395,96 @@ type Capacity struct {
 	MaxContainers uint64 `json:"max_containers,omitempty"`
 }
 
+// FeatureSet describes the optional capabilities of a backend. A capability
+// that is not supported is reported as false.
+type FeatureSet struct {
+	// PidsLimit reports whether Limits.Pid is enforced.
+	PidsLimit bool `json:"pids_limit,omitempty"`
+
+	// IOLimits reports whether Limits.IO is enforced.
+	IOLimits bool `json:"io_limits,omitempty"`
+
+	// SwapLimit reports whether memory limits also bound swap usage.
+	SwapLimit bool `json:"swap_limit,omitempty"`
+
+	// UserNamespaces reports whether unprivileged containers can be created.
+	UserNamespaces bool `json:"user_namespaces,omitempty"`
+
+	// Freezer reports whether containers can be frozen.
+	Freezer bool `json:"freezer,omitempty"`
+
+	// Clone reports whether ContainerSpec.CloneFrom is supported.
+	Clone bool `json:"clone,omitempty"`
+
+	// RootFSLayers reports whether ContainerSpec.RootFSLayers is supported.
+	RootFSLayers bool `json:"rootfs_layers,omitempty"`
+
+	// Checkpoint reports whether containers can be checkpointed and restored.
+	Checkpoint bool `json:"checkpoint,omitempty"`
+
+	// CgroupParent reports whether ContainerSpec.CgroupParent is supported.
+	CgroupParent bool `json:"cgroup_parent,omitempty"`
+
+	// ScratchVolumes reports whether ContainerSpec.ScratchVolumes is supported.
+	ScratchVolumes bool `json:"scratch_volumes,omitempty"`
+
+	// DNS reports whether ContainerSpec.DNSServers and DNSSearch are
+	// supported.
+	DNS bool `json:"dns,omitempty"`
+
+	// ListByState reports whether containers can be listed by state. It is
+	// reported by the server rather than the backend: a server which does
+	// not report it would take the state for a property to filter by.
+	ListByState bool `json:"list_by_state,omitempty"`
+
+	// ListPaging reports whether containers can be listed a page at a time.
+	// Like ListByState, it is reported by the server: a server which does
+	// not report it would take the limit and cursor for properties.
+	ListPaging bool `json:"list_paging,omitempty"`
+
+	// ReadOnlyBindMounts reports whether bind mounts with BindMountModeRO are
+	// guaranteed to reject writes from inside the container. Containers with
+	// read-only bind mounts cannot be created on a backend that does not.
+	ReadOnlyBindMounts bool `json:"read_only_bind_mounts,omitempty"`
+}
+
+// SelftestResult reports the outcome of a server self-test, which creates a
+// throwaway container, runs a process in it and destroys it again.
+type SelftestResult struct {
+	Succeeded bool           `json:"succeeded"`
+	Steps     []SelftestStep `json:"steps"`
+}
+
+// SelftestStep is a single step of a self-test. Steps after a failed one are
+// not attempted, except that the container is always destroyed once created.
+type SelftestStep struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// HealthState is the overall state of a server's backend.
+type HealthState string
+
+const (
+	HealthStateHealthy  HealthState = "healthy"
+	HealthStateDegraded HealthState = "degraded"
+)
+
+// HealthStatus reports the outcome of a server's health checks of its
+// backend. The backend is degraded if any check failed.
+type HealthStatus struct {
+	State  HealthState   `json:"state"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+// HealthCheck is a single health check, and how long it took.
+type HealthCheck struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
 type Properties map[string]string
 
 type BindMountMode uint8
@@ -224,3 +496,14 @@ type BindMountOrigin uint8
 
 const BindMountOriginHost BindMountOrigin = 0
 const BindMountOriginContainer BindMountOrigin = 1
+
+type BindMountPropagation uint8
+
+const BindMountPropagationPrivate BindMountPropagation = 0
+const BindMountPropagationShared BindMountPropagation = 1
+const BindMountPropagationSlave BindMountPropagation = 2
+
+type ScratchVolumeMedium uint8
+
+const ScratchVolumeMediumDisk ScratchVolumeMedium = 0
+const ScratchVolumeMediumMemory ScratchVolumeMedium = 1
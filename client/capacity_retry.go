@@ -0,0 +1,68 @@
+package client
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/garden"
+)
+
+type capacityRetryingClient struct {
+	Client
+
+	maxRetries   int
+	pollInterval time.Duration
+}
+
+// NewCapacityRetrying wraps client so that a Create which fails with
+// garden.InsufficientCapacityError is retried once the server reports
+// headroom again. Capacity is polled every pollInterval, at most maxRetries
+// times, before the last error is returned to the caller.
+func NewCapacityRetrying(client Client, maxRetries int, pollInterval time.Duration) Client {
+	return &capacityRetryingClient{
+		Client: client,
+
+		maxRetries:   maxRetries,
+		pollInterval: pollInterval,
+	}
+}
+
+func (client *capacityRetryingClient) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	container, err := client.Client.Create(spec)
+
+	for retries := 0; isInsufficientCapacity(err) && retries < client.maxRetries; retries++ {
+		time.Sleep(client.pollInterval)
+
+		if !client.hasHeadroom() {
+			continue
+		}
+
+		container, err = client.Client.Create(spec)
+	}
+
+	return container, err
+}
+
+func (client *capacityRetryingClient) hasHeadroom() bool {
+	capacity, err := client.Capacity()
+	if err != nil {
+		return false
+	}
+
+	// the server doesn't advertise a limit, so the only way to find out is to
+	// try again
+	if capacity.MaxContainers == 0 {
+		return true
+	}
+
+	containers, err := client.Containers(nil)
+	if err != nil {
+		return false
+	}
+
+	return uint64(len(containers)) < capacity.MaxContainers
+}
+
+func isInsufficientCapacity(err error) bool {
+	_, ok := err.(garden.InsufficientCapacityError)
+	return ok
+}
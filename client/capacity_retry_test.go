@@ -0,0 +1,120 @@
+package client_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/garden"
+	. "code.cloudfoundry.org/garden/client"
+	"code.cloudfoundry.org/garden/client/connection/connectionfakes"
+)
+
+var _ = Describe("CapacityRetrying", func() {
+	var (
+		client         Client
+		fakeConnection *connectionfakes.FakeConnection
+		fullErr        error
+	)
+
+	BeforeEach(func() {
+		fakeConnection = new(connectionfakes.FakeConnection)
+		fullErr = garden.NewInsufficientCapacityError("host is full")
+
+		fakeConnection.CapacityReturns(garden.Capacity{MaxContainers: 2}, nil)
+	})
+
+	JustBeforeEach(func() {
+		client = NewCapacityRetrying(New(fakeConnection), 3, time.Millisecond)
+	})
+
+	Context("when the create succeeds", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateReturns("some-handle", nil)
+		})
+
+		It("does not retry", func() {
+			container, err := client.Create(garden.ContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.Handle()).To(Equal("some-handle"))
+
+			Expect(fakeConnection.CreateCallCount()).To(Equal(1))
+			Expect(fakeConnection.CapacityCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the create fails with a different error", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateReturns("", errors.New("oh no!"))
+		})
+
+		It("returns the error without retrying", func() {
+			_, err := client.Create(garden.ContainerSpec{})
+			Expect(err).To(MatchError("oh no!"))
+
+			Expect(fakeConnection.CreateCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the host is momentarily full", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateStub = func(spec garden.ContainerSpec) (string, error) {
+				if fakeConnection.CreateCallCount() == 1 {
+					return "", fullErr
+				}
+
+				return spec.Handle, nil
+			}
+
+			fakeConnection.ListStub = func(garden.Properties) ([]string, error) {
+				if fakeConnection.ListCallCount() == 1 {
+					return []string{"a", "b"}, nil
+				}
+
+				return []string{"a"}, nil
+			}
+		})
+
+		It("retries the create once headroom appears", func() {
+			container, err := client.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.Handle()).To(Equal("some-handle"))
+
+			Expect(fakeConnection.ListCallCount()).To(Equal(2))
+			Expect(fakeConnection.CreateCallCount()).To(Equal(2))
+			Expect(fakeConnection.CreateArgsForCall(1)).To(Equal(garden.ContainerSpec{Handle: "some-handle"}))
+		})
+	})
+
+	Context("when the host stays full", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateReturns("", fullErr)
+			fakeConnection.ListReturns([]string{"a", "b"}, nil)
+		})
+
+		It("gives up after the configured number of retries", func() {
+			_, err := client.Create(garden.ContainerSpec{})
+			Expect(err).To(Equal(fullErr))
+
+			Expect(fakeConnection.CapacityCallCount()).To(Equal(3))
+			Expect(fakeConnection.CreateCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the server does not advertise a container limit", func() {
+		BeforeEach(func() {
+			fakeConnection.CapacityReturns(garden.Capacity{}, nil)
+			fakeConnection.CreateReturns("", fullErr)
+		})
+
+		It("retries the create on every poll", func() {
+			_, err := client.Create(garden.ContainerSpec{})
+			Expect(err).To(Equal(fullErr))
+
+			Expect(fakeConnection.ListCallCount()).To(Equal(0))
+			Expect(fakeConnection.CreateCallCount()).To(Equal(4))
+		})
+	})
+})
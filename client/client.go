@@ -27,6 +27,14 @@ func (client *client) Capacity() (garden.Capacity, error) {
 	return client.connection.Capacity()
 }
 
+func (client *client) Features() (garden.FeatureSet, error) {
+	return client.connection.Features()
+}
+
+func (client *client) ValidateRootFS(rootfs string) error {
+	return client.connection.ValidateRootFS(rootfs)
+}
+
 func (client *client) Create(spec garden.ContainerSpec) (garden.Container, error) {
 	handle, err := client.connection.Create(spec)
 	if err != nil {
@@ -56,6 +64,10 @@ func (client *client) Destroy(handle string) error {
 	return err
 }
 
+func (client *client) Rename(oldHandle, newHandle string) error {
+	return client.connection.Rename(oldHandle, newHandle)
+}
+
 func (client *client) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
 	return client.connection.BulkInfo(handles)
 }
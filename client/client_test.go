@@ -57,6 +57,31 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("Features", func() {
+		BeforeEach(func() {
+			fakeConnection.FeaturesReturns(garden.FeatureSet{UserNamespaces: true}, nil)
+		})
+
+		It("sends a features request and returns the features", func() {
+			features, err := client.Features()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(features).Should(Equal(garden.FeatureSet{UserNamespaces: true}))
+		})
+
+		Context("when getting features fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.FeaturesReturns(garden.FeatureSet{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := client.Features()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("BulkInfo", func() {
 		expectedBulkInfo := map[string]garden.ContainerInfoEntry{
 			"handle1": garden.ContainerInfoEntry{
@@ -230,6 +255,30 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("Rename", func() {
+		It("sends a rename request", func() {
+			err := client.Rename("some-handle", "some-new-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			oldHandle, newHandle := fakeConnection.RenameArgsForCall(0)
+			Ω(oldHandle).Should(Equal("some-handle"))
+			Ω(newHandle).Should(Equal("some-new-handle"))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.RenameReturns(disaster)
+			})
+
+			It("returns it", func() {
+				err := client.Rename("some-handle", "some-new-handle")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Lookup", func() {
 		It("sends a list request", func() {
 			fakeConnection.ListReturns([]string{"some-handle", "some-other-handle"}, nil)
@@ -0,0 +1,105 @@
+package connection
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// connectTimeout bounds both dialling the proxy and waiting for it to open
+// the tunnel.
+const connectTimeout = 2 * time.Second
+
+// ProxyError is returned when an HTTP proxy refuses to open a tunnel to the
+// server.
+type ProxyError struct {
+	Proxy  string
+	Status string
+}
+
+func (err ProxyError) Error() string {
+	return fmt.Sprintf("proxy %s refused to connect: %s", err.Proxy, err.Status)
+}
+
+// connectProxyDialer returns a DialerFunc which reaches address through a
+// tunnel opened with an HTTP CONNECT request to proxy. Credentials in the
+// proxy's URL are sent as basic auth. A proxy without a port is dialled on
+// port 80.
+func connectProxyDialer(proxy *url.URL, address string) DialerFunc {
+	proxyAddress := proxy.Host
+	if proxy.Port() == "" {
+		proxyAddress = net.JoinHostPort(proxy.Hostname(), "80")
+	}
+
+	return func(string, string) (net.Conn, error) {
+		conn, err := net.DialTimeout("tcp", proxyAddress, connectTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		tunnel, err := openTunnel(conn, proxy, address)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return tunnel, nil
+	}
+}
+
+func openTunnel(conn net.Conn, proxy *url.URL, address string) (net.Conn, error) {
+	conn.SetDeadline(time.Now().Add(connectTimeout))
+
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := request.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+
+	response, err := http.ReadResponse(br, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, ProxyError{Proxy: proxy.Host, Status: response.Status}
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	// the server may already have sent something through the tunnel, which
+	// would be stuck in the reader's buffer
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
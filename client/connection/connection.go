@@ -3,13 +3,19 @@ package connection
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/garden"
@@ -28,34 +34,190 @@ type Connection interface {
 
 	Capacity() (garden.Capacity, error)
 
+	// SetDrainMode stops the server creating containers while draining is
+	// true, so that the host can be decommissioned without disturbing the
+	// containers already on it. Creates fail with a garden.DrainingError.
+	SetDrainMode(draining bool) error
+
+	// WatchCapacity streams the host's capacity, starting with its current
+	// value and then again each time it changes as a container is created or
+	// destroyed. The channel is closed when the returned func is called, or
+	// when the connection to the server is lost.
+	WatchCapacity() (<-chan garden.Capacity, func(), error)
+
+	// WatchOOMs streams the OOM kills in every container as the server's
+	// backend reports them. The channel is closed when the returned func is
+	// called, when the backend stops reporting them, or when the connection
+	// to the server is lost. A backend which cannot watch for OOM kills fails
+	// it with a garden.UnsupportedOperationError.
+	WatchOOMs() (<-chan garden.OOMEvent, func(), error)
+
+	Features() (garden.FeatureSet, error)
+
+	ValidateRootFS(rootfs string) error
+
+	// RawServerInfo returns what the server reports about itself, keyed by
+	// "ping", "capacity" and "features", decoded as generic JSON rather than
+	// into garden's types, so that even an incompatible server's responses
+	// can be read, e.g. to attach to a bug report. A response which could not
+	// be fetched is replaced by its error message. It only fails if none
+	// could be fetched.
+	RawServerInfo() (map[string]interface{}, error)
+
+	// Selftest has the server create a throwaway container, run a process in
+	// it and destroy it, reporting how each step went. A failed step is part
+	// of the result rather than an error.
+	Selftest() (garden.SelftestResult, error)
+
+	// Health runs the server's cheap checks of its backend: its own ping,
+	// reporting capacity and listing containers. A degraded backend is
+	// reported in the status rather than as an error, which is only returned
+	// if the checks could not be run at all.
+	Health() (garden.HealthStatus, error)
+
+	// ContainerForHostPID returns the handle of the container that a process,
+	// identified by its PID on the host, runs in. A HostPIDNotFoundError is
+	// returned if it does not run in any container.
+	ContainerForHostPID(pid int) (string, error)
+
 	Create(spec garden.ContainerSpec) (string, error)
 	List(properties garden.Properties) ([]string, error)
 
+	// ListByState lists the handles of the containers in the given state. It
+	// fails with an UnsupportedOperationError if the server does not report
+	// FeatureSet.ListByState.
+	ListByState(state garden.ContainerState) ([]string, error)
+
+	// ListPage lists at most limit of the handles of the containers matching
+	// the properties, in order, starting after the page whose next cursor is
+	// given. An empty cursor starts from the beginning. The returned cursor
+	// fetches the following page, and is empty once there are none left. It
+	// fails with an UnsupportedOperationError if the server does not report
+	// FeatureSet.ListPaging.
+	ListPage(properties garden.Properties, cursor string, limit int) ([]string, string, error)
+
 	// Destroys the container with the given handle. If the container cannot be
 	// found, garden.ContainerNotFoundError is returned. If deletion fails for another
 	// reason, another error type is returned.
 	Destroy(handle string) error
 
+	// DestroyByProperties destroys every container which has all of the given
+	// properties, in a single request. It returns the handles of the
+	// containers destroyed and an error for each container which could not
+	// be; the error return is for the request as a whole. At least one
+	// property must be given.
+	DestroyByProperties(properties garden.Properties) ([]string, map[string]error, error)
+
+	Rename(oldHandle, newHandle string) error
+
 	Stop(handle string, kill bool) error
+	Checkpoint(handle, imagePath string) error
+	Restore(handle, imagePath string) error
+	Sync(handle string) error
 
 	Info(handle string) (garden.ContainerInfo, error)
+
+	// InfoFields returns the container's info with only the named fields
+	// (e.g. "State", "ContainerIP") populated. All other fields are left as
+	// their zero value.
+	InfoFields(handle string, fields []string) (garden.ContainerInfo, error)
+
+	// InfoIfChanged returns the container's info, unless its Version is still
+	// the given one, in which case it returns false and no info.
+	InfoIfChanged(handle, version string) (garden.ContainerInfo, bool, error)
+
 	BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error)
 	BulkMetrics(handles []string) (map[string]garden.ContainerMetricsEntry, error)
 
+	// ListPortMappings returns the host to container port mappings of every
+	// container, keyed by handle.
+	ListPortMappings() (map[string][]garden.PortMapping, error)
+
+	// SetPropertyForAll sets a property on each of the given containers in a
+	// single request. The returned map holds an error for each container the
+	// property could not be set on; the error return is for the request as a
+	// whole.
+	SetPropertyForAll(name string, value string, handles []string) (map[string]error, error)
+
 	StreamIn(handle string, spec garden.StreamInSpec) error
+
+	// StreamInContext is StreamIn, aborting the upload when ctx is done, in
+	// which case it returns ctx's error. The server does not roll back an
+	// aborted stream in: whatever was extracted before it was aborted is left
+	// in place.
+	StreamInContext(ctx context.Context, handle string, spec garden.StreamInSpec) error
+
 	StreamOut(handle string, spec garden.StreamOutSpec) (io.ReadCloser, error)
 
+	// StreamOutFiltered is StreamOut, as the container's default user, with
+	// the server leaving out of the tar every entry which does not match one
+	// of the include globs, as matched by path.Match against the entry's
+	// name. A pattern matching a directory includes everything under it. If
+	// nothing matches, the tar is empty; with no patterns, nothing is left
+	// out.
+	StreamOutFiltered(handle string, srcPath string, include []string) (io.ReadCloser, error)
+
+	// StreamOutputLog streams the named output log of a container, as written
+	// by a process run with ProcessSpec.OutputLog.
+	StreamOutputLog(handle string, name string) (io.ReadCloser, error)
+
+	// WriteFile writes content to a single file at path in a container,
+	// creating its parent directories as needed, without the caller having to
+	// wrap it in a tar stream as StreamIn requires. The file is owned by the
+	// container's default user. Content larger than the server allows is
+	// refused with an InvalidRequestError.
+	WriteFile(handle string, path string, mode os.FileMode, content io.Reader) error
+
+	// ReadFile streams the raw contents of the single file at path in a
+	// container. It fails with a garden.FileNotFoundError if there is no such
+	// file, a garden.IsADirectoryError if path is a directory, or a
+	// garden.InvalidRequestError if path is anything else which is not a
+	// regular file, such as a symlink.
+	ReadFile(handle string, path string) (io.ReadCloser, error)
+
 	CurrentBandwidthLimits(handle string) (garden.BandwidthLimits, error)
 	CurrentCPULimits(handle string) (garden.CPULimits, error)
 	CurrentDiskLimits(handle string) (garden.DiskLimits, error)
 	CurrentMemoryLimits(handle string) (garden.MemoryLimits, error)
+	CurrentIOLimits(handle string) (garden.IOLimits, error)
+	LimitAll(handle string, limits garden.LimitsUpdate) (garden.Limits, error)
+
+	// BoostLimits raises a container's CPU or memory limits, or both, for the
+	// given duration, after which the server restores their previous values.
+	// Only non-zero CPU and memory limits may be given. Boosting a container
+	// that is already boosted replaces the boost and restarts its duration,
+	// but still restores the limits from before the first boost. A limit set
+	// with LimitAll while boosted is not restored. The boost is dropped if the
+	// container is destroyed, and ended early if the server stops.
+	BoostLimits(handle string, limits garden.Limits, duration time.Duration) error
 
 	Run(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 	Attach(handle string, processID string, io garden.ProcessIO) (garden.Process, error)
 
+	// AttachAll streams the output of every process in the container, each
+	// chunk tagged with the process and stream it came from. Processes
+	// running when it is called are included, as are those run through the
+	// server afterwards; a process's output ends with a chunk reporting its
+	// exit status. Output is dropped if it is not read fast enough. The
+	// channel is closed when the returned func is called, when the container
+	// is destroyed, or when the connection to the server is lost.
+	AttachAll(handle string) (<-chan garden.TaggedOutput, func(), error)
+
 	NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error)
 	NetOut(handle string, rule garden.NetOutRule) error
 	BulkNetOut(handle string, rules []garden.NetOutRule) error
+	NetworkPolicy(handle string) (garden.NetworkPolicy, error)
+	SetNetworkPolicy(handle string, policy garden.NetworkPolicy) error
+
+	// AllowTraffic allows traffic from one container to another by adding a
+	// rule for the destination's IP to the source's network policy.
+	// DenyTraffic removes that rule again, which denies the traffic only if
+	// the source's policy denies traffic by default; a rule with the same
+	// effect set by other means is left alone. The rule is also removed when
+	// the destination is destroyed. Containers sharing a subnet are not
+	// filtered, and so cannot be kept apart this way.
+	AllowTraffic(srcHandle string, dstHandle string) error
+	DenyTraffic(srcHandle string, dstHandle string) error
 
 	SetGraceTime(handle string, graceTime time.Duration) error
 
@@ -64,18 +226,87 @@ type Connection interface {
 	SetProperty(handle string, name string, value string) error
 
 	Metrics(handle string) (garden.Metrics, error)
+
+	// ResourceUsage returns the container's current usage of CPU, memory,
+	// disk and bandwidth, each alongside its limit, in one call.
+	ResourceUsage(handle string) (garden.ResourceUsage, error)
+	ProcessStats(handle string) (map[uint32]garden.ProcessStat, error)
+
+	// ProcessStatus reports whether a process run through the server is still
+	// running, or its exit status if it has exited, without attaching to it.
+	// Exit statuses are kept for a while after the process exits; after that,
+	// or for a process the server did not run, a ProcessNotFoundError is
+	// returned.
+	ProcessStatus(handle string, processID string) (garden.ProcessStatus, error)
+
+	// ProcessLogsSince streams the output a process run through the server
+	// wrote after the given time, stdout and stderr interleaved. Only the most
+	// recent output is kept, as much as the process's OutputBufferSize, and
+	// only for a while after the process exits. A zero since returns all the
+	// output kept.
+	ProcessLogsSince(handle string, processID string, since time.Time) (io.ReadCloser, error)
+
+	// ProcessLogs returns the output a process run through the server wrote
+	// from the cursor on, along with the cursor to pass next time to fetch
+	// only newer output. Unlike ProcessLogsSince, it neither misses nor
+	// repeats output however the client's and server's clocks compare. A zero
+	// cursor returns all the output kept.
+	ProcessLogs(handle string, processID string, cursor uint64) (garden.ProcessLog, error)
+
+	// ProcessEnv returns the environment a process run through the server was
+	// started with: its container's environment, overridden by the process's
+	// own. The server may mask the values of sensitive variables. As with
+	// ProcessStatus, it is only kept for a while after the process exits.
+	ProcessEnv(handle string, processID string) ([]string, error)
+
+	// Spec returns the spec the container was created with, as it was
+	// requested, with its handle and grace time filled in. The image's
+	// password is never returned, and the server may mask the values of
+	// sensitive environment variables. It fails for a container the server
+	// did not create, or whose spec it has not kept across a restart.
+	Spec(handle string) (garden.ContainerSpec, error)
+
+	// WaitForProcesses blocks until every process in the container has
+	// exited, including any started while it waits. If they have not all
+	// exited within the timeout it returns a garden.ProcessesRunningError
+	// naming those still running. A zero timeout waits for as long as it
+	// takes.
+	WaitForProcesses(handle string, timeout time.Duration) error
+
+	// AllEgressRules returns the egress policy in effect for every container
+	// on the host, keyed by handle, as its NetworkPolicy reports it: the
+	// rules applied at creation, with NetOut and BulkNetOut, and with
+	// SetNetworkPolicy, AllowTraffic and DenyTraffic.
+	AllEgressRules() (map[string]garden.ContainerEgressRules, error)
+
+	// ProcessAttachments lists the client connections currently streaming
+	// output from the container's processes, including the connection of
+	// whichever client ran the process, oldest first.
+	ProcessAttachments(handle string) ([]garden.Attachment, error)
 	RemoveProperty(handle string, name string) error
+
+	// DoRequest sends a request to any route registered in routes.Routes,
+	// including ones this interface has no typed method for. The body, if
+	// any, is sent as JSON. A response with a non-2xx status is returned as
+	// the garden error it carries; otherwise the caller must close the
+	// response body.
+	DoRequest(route string, params rata.Params, query url.Values, body io.Reader) (*http.Response, error)
 }
 
 //go:generate counterfeiter . HijackStreamer
 type HijackStreamer interface {
 	Stream(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (io.ReadCloser, error)
 	Hijack(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (net.Conn, *bufio.Reader, error)
+	Do(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (*http.Response, error)
 }
 
 type connection struct {
 	hijacker HijackStreamer
 	log      lager.Logger
+
+	// unary is used for calls that send a single request and read back a
+	// single JSON response; streaming calls always go through hijacker
+	unary HijackStreamer
 }
 
 type Error struct {
@@ -104,6 +335,7 @@ func NewWithDialerAndLogger(dialer DialerFunc, log lager.Logger) Connection {
 func NewWithHijacker(hijacker HijackStreamer, log lager.Logger) Connection {
 	return &connection{
 		hijacker: hijacker,
+		unary:    hijacker,
 		log:      log,
 	}
 }
@@ -112,6 +344,10 @@ func (c *connection) Ping() error {
 	return c.do(routes.Ping, nil, &struct{}{}, nil, nil)
 }
 
+func (c *connection) SetDrainMode(draining bool) error {
+	return c.do(routes.SetDrainMode, draining, &struct{}{}, nil, nil)
+}
+
 func (c *connection) Capacity() (garden.Capacity, error) {
 	capacity := garden.Capacity{}
 	err := c.do(routes.Capacity, nil, &capacity, nil, nil)
@@ -122,6 +358,196 @@ func (c *connection) Capacity() (garden.Capacity, error) {
 	return capacity, nil
 }
 
+func (c *connection) ContainerForHostPID(pid int) (string, error) {
+	res := struct {
+		Handle string
+	}{}
+
+	err := c.do(routes.ContainerForHostPID, nil, &res, rata.Params{"pid": strconv.Itoa(pid)}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Handle, nil
+}
+
+func (c *connection) Features() (garden.FeatureSet, error) {
+	features := garden.FeatureSet{}
+	err := c.do(routes.Features, nil, &features, nil, nil)
+	if err != nil {
+		return garden.FeatureSet{}, err
+	}
+
+	return features, nil
+}
+
+// requireFeature fails with an UnsupportedOperationError for the operation
+// unless the server reports supporting it, for a request which a server that
+// does not would misread rather than refuse.
+func (c *connection) requireFeature(operation string, supported func(garden.FeatureSet) bool) error {
+	features, err := c.Features()
+	if _, ok := err.(garden.UnsupportedOperationError); ok {
+		return garden.UnsupportedOperationError{Operation: operation}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !supported(features) {
+		return garden.UnsupportedOperationError{Operation: operation}
+	}
+
+	return nil
+}
+
+// rawServerInfoRoutes are the routes whose responses make up RawServerInfo.
+var rawServerInfoRoutes = []struct {
+	key   string
+	route string
+}{
+	{"ping", routes.Ping},
+	{"capacity", routes.Capacity},
+	{"features", routes.Features},
+}
+
+func (c *connection) RawServerInfo() (map[string]interface{}, error) {
+	info := map[string]interface{}{}
+
+	var firstErr error
+	fetched := 0
+	for _, r := range rawServerInfoRoutes {
+		var res interface{}
+		if err := c.do(r.route, nil, &res, nil, nil); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			info[r.key] = err.Error()
+			continue
+		}
+
+		info[r.key] = res
+		fetched++
+	}
+
+	if fetched == 0 {
+		return nil, firstErr
+	}
+
+	return info, nil
+}
+
+func (c *connection) Health() (garden.HealthStatus, error) {
+	status := garden.HealthStatus{}
+	err := c.do(routes.Health, nil, &status, nil, nil)
+	if degraded, ok := err.(garden.BackendDegradedError); ok {
+		return degraded.Health, nil
+	}
+
+	if err != nil {
+		return garden.HealthStatus{}, err
+	}
+
+	return status, nil
+}
+
+func (c *connection) ValidateRootFS(rootfs string) error {
+	return c.do(routes.ValidateRootFS, nil, &struct{}{}, nil, url.Values{
+		"rootfs": []string{rootfs},
+	})
+}
+
+func (c *connection) Selftest() (garden.SelftestResult, error) {
+	result := garden.SelftestResult{}
+	err := c.do(routes.Selftest, nil, &result, nil, nil)
+	if err != nil {
+		return garden.SelftestResult{}, err
+	}
+
+	return result, nil
+}
+
+func (c *connection) WatchCapacity() (<-chan garden.Capacity, func(), error) {
+	conn, br, err := c.hijacker.Hijack(routes.WatchCapacity, nil, nil, nil, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	capacities := make(chan garden.Capacity)
+	stopped, stop := stoppable(conn)
+
+	go func() {
+		defer close(capacities)
+		defer stop()
+
+		decoder := json.NewDecoder(br)
+
+		for {
+			var capacity garden.Capacity
+			if err := decoder.Decode(&capacity); err != nil {
+				return
+			}
+
+			select {
+			case capacities <- capacity:
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	return capacities, stop, nil
+}
+
+// stoppable returns a func which stops a stream by closing its hijacked
+// connection, and a channel closed once it has been called, so that the
+// goroutine reading the stream stops waiting for its receiver to take what
+// it has read.
+func stoppable(conn net.Conn) (<-chan struct{}, func()) {
+	stopped := make(chan struct{})
+
+	var once sync.Once
+	return stopped, func() {
+		once.Do(func() {
+			close(stopped)
+			conn.Close()
+		})
+	}
+}
+
+func (c *connection) WatchOOMs() (<-chan garden.OOMEvent, func(), error) {
+	conn, br, err := c.hijacker.Hijack(routes.WatchOOMs, nil, nil, nil, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan garden.OOMEvent)
+	stopped, stop := stoppable(conn)
+
+	go func() {
+		defer close(events)
+		defer stop()
+
+		decoder := json.NewDecoder(br)
+
+		for {
+			var event garden.OOMEvent
+			if err := decoder.Decode(&event); err != nil {
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	return events, stop, nil
+}
+
 func (c *connection) Create(spec garden.ContainerSpec) (string, error) {
 	res := struct {
 		Handle string `json:"handle"`
@@ -149,6 +575,46 @@ func (c *connection) Stop(handle string, kill bool) error {
 	)
 }
 
+func (c *connection) Checkpoint(handle, imagePath string) error {
+	return c.do(
+		routes.Checkpoint,
+		map[string]string{
+			"image_path": imagePath,
+		},
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) Restore(handle, imagePath string) error {
+	return c.do(
+		routes.Restore,
+		map[string]string{
+			"image_path": imagePath,
+		},
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) Sync(handle string) error {
+	return c.do(
+		routes.Sync,
+		nil,
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
 func (c *connection) Destroy(handle string) error {
 	return c.do(
 		routes.Destroy,
@@ -161,6 +627,47 @@ func (c *connection) Destroy(handle string) error {
 	)
 }
 
+func (c *connection) DestroyByProperties(properties garden.Properties) ([]string, map[string]error, error) {
+	var res struct {
+		Destroyed []string                 `json:"destroyed"`
+		Errors    map[string]*garden.Error `json:"errors"`
+	}
+
+	err := c.do(
+		routes.DestroyByProperties,
+		map[string]interface{}{
+			"properties": properties,
+		},
+		&res,
+		nil,
+		nil,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	errs := make(map[string]error, len(res.Errors))
+	for handle, handleErr := range res.Errors {
+		errs[handle] = handleErr.Err
+	}
+
+	return res.Destroyed, errs, nil
+}
+
+func (c *connection) Rename(oldHandle, newHandle string) error {
+	return c.do(
+		routes.Rename,
+		map[string]string{
+			"handle": newHandle,
+		},
+		&struct{}{},
+		rata.Params{
+			"handle": oldHandle,
+		},
+		nil,
+	)
+}
+
 func (c *connection) Run(handle string, spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
 	reqBody := new(bytes.Buffer)
 
@@ -175,7 +682,7 @@ func (c *connection) Run(handle string, spec garden.ProcessSpec, processIO garde
 		rata.Params{
 			"handle": handle,
 		},
-		nil,
+		processQuery(processIO),
 		"application/json",
 	)
 	if err != nil {
@@ -195,7 +702,7 @@ func (c *connection) Attach(handle string, processID string, processIO garden.Pr
 			"handle": handle,
 			"pid":    processID,
 		},
-		nil,
+		processQuery(processIO),
 		"",
 	)
 	if err != nil {
@@ -205,17 +712,78 @@ func (c *connection) Attach(handle string, processID string, processIO garden.Pr
 	return c.streamProcess(handle, processIO, hijackedConn, hijackedResponseReader)
 }
 
+func (c *connection) AttachAll(handle string) (<-chan garden.TaggedOutput, func(), error) {
+	conn, br, err := c.hijacker.Hijack(
+		routes.AttachAll,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+		"",
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := make(chan garden.TaggedOutput)
+	stopped, stop := stoppable(conn)
+
+	go func() {
+		defer close(output)
+		defer stop()
+
+		decoder := json.NewDecoder(br)
+
+		for {
+			var chunk garden.TaggedOutput
+			if err := decoder.Decode(&chunk); err != nil {
+				return
+			}
+
+			select {
+			case output <- chunk:
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	return output, stop, nil
+}
+
+// processQuery asks the server for a control channel alongside the process's
+// streams when the caller wants to receive its events, and for the
+// backpressure the caller wants on its output.
+func processQuery(processIO garden.ProcessIO) url.Values {
+	query := url.Values{}
+
+	if processIO.Events != nil {
+		query.Set("control", "true")
+	}
+
+	if processIO.Backpressure != "" {
+		query.Set("backpressure", string(processIO.Backpressure))
+	}
+
+	if len(query) == 0 {
+		return nil
+	}
+
+	return query
+}
+
 func (c *connection) streamProcess(handle string, processIO garden.ProcessIO, hijackedConn net.Conn, hijackedResponseReader *bufio.Reader) (garden.Process, error) {
-	decoder := json.NewDecoder(hijackedResponseReader)
+	codec := transport.NewProcessStreamCodec(hijackedResponseReader, hijackedConn)
 
-	payload := &transport.ProcessPayload{}
-	if err := decoder.Decode(payload); err != nil {
+	payload, err := codec.Decode()
+	if err != nil {
 		return nil, err
 	}
 
 	processPipeline := &processStream{
 		processID: payload.ProcessID,
-		conn:      hijackedConn,
+		codec:     codec,
 	}
 
 	hijack := func(streamType string) (net.Conn, io.Reader, error) {
@@ -235,7 +803,7 @@ func (c *connection) streamProcess(handle string, processIO garden.ProcessIO, hi
 	}
 
 	process := newProcess(payload.ProcessID, processPipeline)
-	streamHandler := newStreamHandler(c.log)
+	streamHandler := newStreamHandler(c.log, processIO.CoupledStreams)
 	streamHandler.streamIn(processPipeline, processIO.Stdin)
 
 	var stdoutConn net.Conn
@@ -279,7 +847,7 @@ func (c *connection) streamProcess(handle string, processIO garden.ProcessIO, hi
 			defer stderrConn.Close()
 		}
 
-		exitCode, err := streamHandler.wait(decoder)
+		exitCode, err := streamHandler.wait(codec, processIO.Events)
 		process.exited(exitCode, err)
 	}()
 
@@ -322,6 +890,60 @@ func (c *connection) BulkNetOut(handle string, rules []garden.NetOutRule) error
 	)
 }
 
+func (c *connection) NetworkPolicy(handle string) (garden.NetworkPolicy, error) {
+	var policy garden.NetworkPolicy
+
+	err := c.do(
+		routes.NetworkPolicy,
+		nil,
+		&policy,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	return policy, err
+}
+
+func (c *connection) SetNetworkPolicy(handle string, policy garden.NetworkPolicy) error {
+	return c.do(
+		routes.SetNetworkPolicy,
+		policy,
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) AllowTraffic(srcHandle string, dstHandle string) error {
+	return c.do(
+		routes.AllowTraffic,
+		nil,
+		&struct{}{},
+		rata.Params{
+			"handle": srcHandle,
+			"peer":   dstHandle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) DenyTraffic(srcHandle string, dstHandle string) error {
+	return c.do(
+		routes.DenyTraffic,
+		nil,
+		&struct{}{},
+		rata.Params{
+			"handle": srcHandle,
+			"peer":   dstHandle,
+		},
+		nil,
+	)
+}
+
 func (c *connection) NetOut(handle string, rule garden.NetOutRule) error {
 	return c.do(
 		routes.NetOut,
@@ -457,26 +1079,119 @@ func (c *connection) CurrentMemoryLimits(handle string) (garden.MemoryLimits, er
 	return res, err
 }
 
+func (c *connection) CurrentIOLimits(handle string) (garden.IOLimits, error) {
+	res := garden.IOLimits{}
+
+	err := c.do(
+		routes.CurrentIOLimits,
+		nil,
+		&res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	return res, err
+}
+
+func (c *connection) LimitAll(handle string, limits garden.LimitsUpdate) (garden.Limits, error) {
+	res := garden.Limits{}
+
+	err := c.do(
+		routes.LimitAll,
+		limits,
+		&res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	return res, err
+}
+
+func (c *connection) BoostLimits(handle string, limits garden.Limits, duration time.Duration) error {
+	return c.do(
+		routes.BoostLimits,
+		map[string]interface{}{
+			"limits":   limits,
+			"duration": duration,
+		},
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
 func (c *connection) StreamIn(handle string, spec garden.StreamInSpec) error {
+	return c.StreamInContext(context.Background(), handle, spec)
+}
+
+func (c *connection) StreamInContext(ctx context.Context, handle string, spec garden.StreamInSpec) error {
+	query := url.Values{
+		"user":        []string{spec.User},
+		"destination": []string{spec.Path},
+	}
+	if spec.Owner != "" {
+		query.Set("owner", spec.Owner)
+	}
+
+	tarStream := spec.TarStream
+	if ctx.Done() != nil && tarStream != nil {
+		cancellable := cancellableReader(ctx, tarStream)
+		defer cancellable.Close()
+
+		tarStream = cancellable
+	}
+
 	body, err := c.hijacker.Stream(
 		routes.StreamIn,
-		spec.TarStream,
+		tarStream,
 		rata.Params{
 			"handle": handle,
 		},
-		url.Values{
-			"user":        []string{spec.User},
-			"destination": []string{spec.Path},
-		},
+		query,
 		"application/x-tar",
 	)
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		return err
 	}
 
 	return body.Close()
 }
 
+// cancellableReader returns a reader of r which fails with ctx's error as
+// soon as ctx is done, even while a read of r is blocked, so that a request
+// sending it as its body is aborted. Closing the returned reader stops the
+// copying from r.
+func cancellableReader(ctx context.Context, r io.Reader) *io.PipeReader {
+	pr, pw := io.Pipe()
+	copied := make(chan struct{})
+
+	go func() {
+		_, err := io.Copy(pw, r)
+		pw.CloseWithError(err)
+		close(copied)
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			pr.CloseWithError(ctx.Err())
+		case <-copied:
+		}
+	}()
+
+	return pr
+}
+
 func (c *connection) StreamOut(handle string, spec garden.StreamOutSpec) (io.ReadCloser, error) {
 	return c.hijacker.Stream(
 		routes.StreamOut,
@@ -492,6 +1207,68 @@ func (c *connection) StreamOut(handle string, spec garden.StreamOutSpec) (io.Rea
 	)
 }
 
+func (c *connection) StreamOutFiltered(handle string, srcPath string, include []string) (io.ReadCloser, error) {
+	return c.hijacker.Stream(
+		routes.StreamOut,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		url.Values{
+			"source":  []string{srcPath},
+			"include": include,
+		},
+		"",
+	)
+}
+
+func (c *connection) StreamOutputLog(handle string, name string) (io.ReadCloser, error) {
+	return c.hijacker.Stream(
+		routes.StreamOutputLog,
+		nil,
+		rata.Params{
+			"handle": handle,
+			"name":   name,
+		},
+		nil,
+		"",
+	)
+}
+
+func (c *connection) WriteFile(handle string, path string, mode os.FileMode, content io.Reader) error {
+	body, err := c.hijacker.Stream(
+		routes.WriteFile,
+		content,
+		rata.Params{
+			"handle": handle,
+		},
+		url.Values{
+			"path": []string{path},
+			"mode": []string{strconv.FormatUint(uint64(mode.Perm()), 8)},
+		},
+		"application/octet-stream",
+	)
+	if err != nil {
+		return err
+	}
+
+	return body.Close()
+}
+
+func (c *connection) ReadFile(handle string, path string) (io.ReadCloser, error) {
+	return c.hijacker.Stream(
+		routes.ReadFile,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		url.Values{
+			"path": []string{path},
+		},
+		"",
+	)
+}
+
 func (c *connection) List(filterProperties garden.Properties) ([]string, error) {
 	values := url.Values{}
 	for name, val := range filterProperties {
@@ -515,6 +1292,64 @@ func (c *connection) List(filterProperties garden.Properties) ([]string, error)
 	return res.Handles, nil
 }
 
+func (c *connection) ListByState(state garden.ContainerState) ([]string, error) {
+	err := c.requireFeature("ListByState", func(features garden.FeatureSet) bool {
+		return features.ListByState
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &struct {
+		Handles []string
+	}{}
+
+	if err := c.do(
+		routes.List,
+		nil,
+		&res,
+		nil,
+		url.Values{transport.ListStateFilter: []string{string(state)}},
+	); err != nil {
+		return nil, err
+	}
+
+	return res.Handles, nil
+}
+
+func (c *connection) ListPage(filterProperties garden.Properties, cursor string, limit int) ([]string, string, error) {
+	err := c.requireFeature("ListPage", func(features garden.FeatureSet) bool {
+		return features.ListPaging
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	values := url.Values{}
+	for name, val := range filterProperties {
+		values[name] = []string{val}
+	}
+
+	values.Set(transport.ListLimit, strconv.Itoa(limit))
+	if cursor != "" {
+		values.Set(transport.ListCursor, cursor)
+	}
+
+	res := &transport.ListResponse{}
+
+	if err := c.do(
+		routes.List,
+		nil,
+		&res,
+		nil,
+		values,
+	); err != nil {
+		return nil, "", err
+	}
+
+	return res.Handles, res.NextCursor, nil
+}
+
 func (c *connection) SetGraceTime(handle string, graceTime time.Duration) error {
 	return c.do(routes.SetGraceTime, graceTime, &struct{}{}, rata.Params{"handle": handle}, nil)
 }
@@ -531,6 +1366,12 @@ func (c *connection) Metrics(handle string) (garden.Metrics, error) {
 	return res, err
 }
 
+func (c *connection) ResourceUsage(handle string) (garden.ResourceUsage, error) {
+	res := garden.ResourceUsage{}
+	err := c.do(routes.ResourceUsage, nil, &res, rata.Params{"handle": handle}, nil)
+	return res, err
+}
+
 func (c *connection) Info(handle string) (garden.ContainerInfo, error) {
 	res := garden.ContainerInfo{}
 
@@ -542,6 +1383,38 @@ func (c *connection) Info(handle string) (garden.ContainerInfo, error) {
 	return res, nil
 }
 
+func (c *connection) InfoFields(handle string, fields []string) (garden.ContainerInfo, error) {
+	res := garden.ContainerInfo{}
+	queryParams := url.Values{
+		"fields": []string{strings.Join(fields, ",")},
+	}
+
+	err := c.do(routes.Info, nil, &res, rata.Params{"handle": handle}, queryParams)
+	if err != nil {
+		return garden.ContainerInfo{}, err
+	}
+
+	return res, nil
+}
+
+func (c *connection) InfoIfChanged(handle, version string) (garden.ContainerInfo, bool, error) {
+	res := garden.ContainerInfo{}
+	queryParams := url.Values{
+		"if_none_match": []string{version},
+	}
+
+	err := c.do(routes.Info, nil, &res, rata.Params{"handle": handle}, queryParams)
+	if err == errNotModified {
+		return garden.ContainerInfo{}, false, nil
+	}
+
+	if err != nil {
+		return garden.ContainerInfo{}, false, err
+	}
+
+	return res, true, nil
+}
+
 func (c *connection) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
 	res := make(map[string]garden.ContainerInfoEntry)
 	queryParams := url.Values{
@@ -551,6 +1424,158 @@ func (c *connection) BulkInfo(handles []string) (map[string]garden.ContainerInfo
 	return res, err
 }
 
+func (c *connection) ProcessStats(handle string) (map[uint32]garden.ProcessStat, error) {
+	res := map[uint32]garden.ProcessStat{}
+	err := c.do(routes.ProcessStats, nil, &res, rata.Params{"handle": handle}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *connection) SetPropertyForAll(name string, value string, handles []string) (map[string]error, error) {
+	res := map[string]*garden.Error{}
+
+	err := c.do(
+		routes.SetPropertyForAll,
+		map[string]interface{}{
+			"value":   value,
+			"handles": handles,
+		},
+		&res,
+		rata.Params{
+			"key": name,
+		},
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make(map[string]error, len(res))
+	for handle, handleErr := range res {
+		errs[handle] = handleErr.Err
+	}
+
+	return errs, nil
+}
+
+func (c *connection) ProcessStatus(handle string, processID string) (garden.ProcessStatus, error) {
+	var res garden.ProcessStatus
+	err := c.do(routes.ProcessStatus, nil, &res, rata.Params{"handle": handle, "pid": processID}, nil)
+	if err != nil {
+		return garden.ProcessStatus{}, err
+	}
+
+	return res, nil
+}
+
+func (c *connection) ProcessLogsSince(handle string, processID string, since time.Time) (io.ReadCloser, error) {
+	var query url.Values
+	if !since.IsZero() {
+		query = url.Values{"since": []string{since.Format(time.RFC3339Nano)}}
+	}
+
+	var res garden.ProcessLog
+	err := c.do(routes.ProcessLogs, nil, &res, rata.Params{"handle": handle, "pid": processID}, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(res.Output)), nil
+}
+
+func (c *connection) ProcessLogs(handle string, processID string, cursor uint64) (garden.ProcessLog, error) {
+	var query url.Values
+	if cursor != 0 {
+		query = url.Values{"cursor": []string{strconv.FormatUint(cursor, 10)}}
+	}
+
+	var res garden.ProcessLog
+	err := c.do(routes.ProcessLogs, nil, &res, rata.Params{"handle": handle, "pid": processID}, query)
+	if err != nil {
+		return garden.ProcessLog{}, err
+	}
+
+	return res, nil
+}
+
+func (c *connection) ProcessEnv(handle string, processID string) ([]string, error) {
+	var res []string
+	err := c.do(routes.ProcessEnv, nil, &res, rata.Params{"handle": handle, "pid": processID}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *connection) Spec(handle string) (garden.ContainerSpec, error) {
+	var spec garden.ContainerSpec
+	err := c.do(routes.Spec, nil, &spec, rata.Params{"handle": handle}, nil)
+	if err != nil {
+		return garden.ContainerSpec{}, err
+	}
+
+	return spec, nil
+}
+
+func (c *connection) WaitForProcesses(handle string, timeout time.Duration) error {
+	query := url.Values{}
+	if timeout > 0 {
+		query.Set("timeout", timeout.String())
+	}
+
+	// the wait may well outlast a request timeout, so it is made as a
+	// streaming call rather than a unary one
+	body, err := c.hijacker.Stream(
+		routes.WaitForProcesses,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		query,
+		"",
+	)
+	if err != nil {
+		return err
+	}
+
+	return body.Close()
+}
+
+func (c *connection) AllEgressRules() (map[string]garden.ContainerEgressRules, error) {
+	res := map[string]garden.ContainerEgressRules{}
+	err := c.do(routes.AllEgressRules, nil, &res, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *connection) ProcessAttachments(handle string) ([]garden.Attachment, error) {
+	var res []garden.Attachment
+	err := c.do(routes.ProcessAttachments, nil, &res, rata.Params{"handle": handle}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *connection) ListPortMappings() (map[string][]garden.PortMapping, error) {
+	res := make(map[string][]garden.PortMapping)
+
+	err := c.do(routes.ListPortMappings, nil, &res, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (c *connection) BulkMetrics(handles []string) (map[string]garden.ContainerMetricsEntry, error) {
 	res := make(map[string]garden.ContainerMetricsEntry)
 	queryParams := url.Values{
@@ -560,6 +1585,15 @@ func (c *connection) BulkMetrics(handles []string) (map[string]garden.ContainerM
 	return res, err
 }
 
+func (c *connection) DoRequest(route string, params rata.Params, query url.Values, body io.Reader) (*http.Response, error) {
+	contentType := ""
+	if body != nil {
+		contentType = "application/json"
+	}
+
+	return c.hijacker.Do(route, body, params, query, contentType)
+}
+
 func (c *connection) do(
 	handler string,
 	req, res interface{},
@@ -584,7 +1618,7 @@ func (c *connection) do(
 		contentType = "application/json"
 	}
 
-	response, err := c.hijacker.Stream(
+	response, err := c.unary.Stream(
 		handler,
 		body,
 		params,
@@ -597,5 +1631,10 @@ func (c *connection) do(
 
 	defer response.Close()
 
-	return json.NewDecoder(response).Decode(res)
+	err = json.NewDecoder(response).Decode(res)
+
+	// read up to EOF so that a keepalive connection can be reused
+	io.Copy(ioutil.Discard, response)
+
+	return err
 }
@@ -0,0 +1,85 @@
+package connection_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"code.cloudfoundry.org/garden/client/connection"
+	"code.cloudfoundry.org/lager/lagertest"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func BenchmarkSetPropertyWithoutConnectionReuse(b *testing.B) {
+	benchmarkSetProperty(b, 1, newConnection)
+}
+
+func BenchmarkSetPropertyWithConnectionReuse(b *testing.B) {
+	benchmarkSetProperty(b, 1, newConnectionWithReuse)
+}
+
+func BenchmarkSetPropertyWithHTTP2(b *testing.B) {
+	benchmarkSetProperty(b, 1, newConnectionWithHTTP2)
+}
+
+func BenchmarkSetPropertyBurstWithoutConnectionReuse(b *testing.B) {
+	benchmarkSetProperty(b, 10, newConnection)
+}
+
+func BenchmarkSetPropertyBurstWithConnectionReuse(b *testing.B) {
+	benchmarkSetProperty(b, 10, newConnectionWithReuse)
+}
+
+func BenchmarkSetPropertyBurstWithHTTP2(b *testing.B) {
+	benchmarkSetProperty(b, 10, newConnectionWithHTTP2)
+}
+
+func newConnection(address string) connection.Connection {
+	return connection.NewWithLogger("tcp", address, lagertest.NewTestLogger("bench"))
+}
+
+func newConnectionWithReuse(address string) connection.Connection {
+	return connection.NewWithOptions("tcp", address, lagertest.NewTestLogger("bench"), connection.WithConnectionReuse())
+}
+
+func newConnectionWithHTTP2(address string) connection.Connection {
+	return connection.NewWithOptions("tcp", address, lagertest.NewTestLogger("bench"), connection.WithHTTP2())
+}
+
+// benchmarkSetProperty measures 100 SetProperty calls, the pattern of tooling
+// that annotates containers with many properties, made by as many concurrent
+// callers as given. The server speaks both HTTP/1 and HTTP/2, as the garden
+// server does.
+func benchmarkSetProperty(b *testing.B, callers int, newConnection func(address string) connection.Connection) {
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}\n"))
+	}), &http2.Server{}))
+	defer server.Close()
+
+	conn := newConnection(server.Listener.Addr().String())
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		wg := new(sync.WaitGroup)
+
+		for c := 0; c < callers; c++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < 100/callers; j++ {
+					if err := conn.SetProperty("some-handle", "some-property", "some-value"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			}()
+		}
+
+		wg.Wait()
+	}
+}
@@ -3,6 +3,7 @@ package connection
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"time"
 
 	"code.cloudfoundry.org/garden"
@@ -17,12 +19,21 @@ import (
 	"github.com/tedsuo/rata"
 )
 
+// errNotModified is returned for a conditional request whose resource has
+// not changed, which has no body to decode.
+var errNotModified = errors.New("not modified")
+
 type DialerFunc func(network, address string) (net.Conn, error)
 
 type hijackable struct {
 	req               *rata.RequestGenerator
 	noKeepaliveClient *http.Client
+	keepaliveClient   *http.Client
 	dialer            DialerFunc
+
+	// setupTimeout bounds how long Hijack waits for the server to respond;
+	// zero means no limit
+	setupTimeout time.Duration
 }
 
 func NewHijackStreamer(network, address string) HijackStreamer {
@@ -44,6 +55,63 @@ func NewHijackStreamerWithDialer(dialFunc DialerFunc) HijackStreamer {
 	}
 }
 
+// NewKeepaliveHijackStreamer returns a HijackStreamer whose Stream calls
+// reuse idle connections to the server instead of dialling a new one per
+// request. Callers must read each response to the end and close it for its
+// connection to be reused.
+func NewKeepaliveHijackStreamer(network, address string) HijackStreamer {
+	return NewKeepaliveHijackStreamerWithDialer(func(string, string) (net.Conn, error) {
+		return net.DialTimeout(network, address, 2*time.Second)
+	})
+}
+
+func NewKeepaliveHijackStreamerWithDialer(dialFunc DialerFunc) HijackStreamer {
+	h := NewHijackStreamerWithDialer(dialFunc).(*hijackable)
+	h.keepaliveClient = &http.Client{
+		Transport: &http.Transport{
+			Dial:                dialFunc,
+			MaxIdleConnsPerHost: 1,
+		},
+	}
+
+	return h
+}
+
+// NewHTTP2HijackStreamer returns a HijackStreamer whose Stream calls are
+// multiplexed over a single HTTP/2 connection to a server which speaks it,
+// falling back to reusing idle HTTP/1 connections, as a keepalive
+// HijackStreamer does, to one which does not. Hijack calls always take over a
+// connection of their own, which HTTP/2 cannot give them.
+func NewHTTP2HijackStreamer(network, address string) HijackStreamer {
+	return NewHTTP2HijackStreamerWithDialer(func(string, string) (net.Conn, error) {
+		return net.DialTimeout(network, address, 2*time.Second)
+	})
+}
+
+func NewHTTP2HijackStreamerWithDialer(dialFunc DialerFunc) HijackStreamer {
+	h := NewHijackStreamerWithDialer(dialFunc).(*hijackable)
+	h.keepaliveClient = &http.Client{
+		Transport: newHTTP2Transport(dialFunc, &http.Transport{
+			Dial:                dialFunc,
+			MaxIdleConnsPerHost: 1,
+		}),
+	}
+
+	return h
+}
+
+// NewHijackStreamerWithTimeout returns a HijackStreamer that gives up on a
+// server that has not responded within timeout. Only the setup of a stream is
+// bounded: once the response headers have arrived, or the connection has been
+// hijacked, data may flow for as long as it takes.
+func NewHijackStreamerWithTimeout(network, address string, timeout time.Duration) HijackStreamer {
+	h := NewHijackStreamer(network, address).(*hijackable)
+	h.setupTimeout = timeout
+	h.noKeepaliveClient.Transport.(*http.Transport).ResponseHeaderTimeout = timeout
+
+	return h
+}
+
 func (h *hijackable) Hijack(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (net.Conn, *bufio.Reader, error) {
 	request, err := h.req.CreateRequest(handler, params, body)
 	if err != nil {
@@ -63,6 +131,10 @@ func (h *hijackable) Hijack(handler string, body io.Reader, params rata.Params,
 		return nil, nil, err
 	}
 
+	if h.setupTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(h.setupTimeout))
+	}
+
 	client := httputil.NewClientConn(conn, nil)
 
 	httpResp, err := client.Do(request)
@@ -81,6 +153,14 @@ func (h *hijackable) Hijack(handler string, body io.Reader, params rata.Params,
 		var result garden.Error
 		err = json.Unmarshal(errRespBytes, &result)
 		if err != nil {
+			if httpResp.StatusCode == http.StatusTooManyRequests {
+				return nil, nil, rateLimitedError(handler, httpResp)
+			}
+
+			if isUnknownRoute(httpResp) {
+				return nil, nil, garden.UnsupportedOperationError{Operation: handler}
+			}
+
 			return nil, nil, fmt.Errorf("Backend error: Exit status: %d, Body: %s, error reading response body: %s", httpResp.StatusCode, string(errRespBytes), err)
 		}
 
@@ -89,10 +169,23 @@ func (h *hijackable) Hijack(handler string, body io.Reader, params rata.Params,
 
 	hijackedConn, hijackedResponseReader := client.Hijack()
 
+	if h.setupTimeout > 0 {
+		hijackedConn.SetDeadline(time.Time{})
+	}
+
 	return hijackedConn, hijackedResponseReader, nil
 }
 
 func (c *hijackable) Stream(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (io.ReadCloser, error) {
+	httpResp, err := c.Do(handler, body, params, query, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return httpResp.Body, nil
+}
+
+func (c *hijackable) Do(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (*http.Response, error) {
 	request, err := c.req.CreateRequest(handler, params, body)
 	if err != nil {
 		return nil, err
@@ -106,22 +199,59 @@ func (c *hijackable) Stream(handler string, body io.Reader, params rata.Params,
 		request.URL.RawQuery = query.Encode()
 	}
 
-	httpResp, err := c.noKeepaliveClient.Do(request)
+	httpClient := c.noKeepaliveClient
+	if c.keepaliveClient != nil {
+		httpClient = c.keepaliveClient
+	}
+
+	httpResp, err := httpClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
 
+	if httpResp.StatusCode == http.StatusNotModified {
+		httpResp.Body.Close()
+		return nil, errNotModified
+	}
+
 	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
 		defer httpResp.Body.Close()
+		defer io.Copy(ioutil.Discard, httpResp.Body)
 
 		var result garden.Error
 		err := json.NewDecoder(httpResp.Body).Decode(&result)
 		if err != nil {
+			if httpResp.StatusCode == http.StatusTooManyRequests {
+				return nil, rateLimitedError(handler, httpResp)
+			}
+
+			if isUnknownRoute(httpResp) {
+				return nil, garden.UnsupportedOperationError{Operation: handler}
+			}
+
 			return nil, fmt.Errorf("bad response: %s", err)
 		}
 
 		return nil, result.Err
 	}
 
-	return httpResp.Body, nil
+	return httpResp, nil
+}
+
+// rateLimitedError describes a 429 response whose body is not a garden error,
+// e.g. one sent by a proxy in front of the server, from its Retry-After.
+func rateLimitedError(handler string, resp *http.Response) error {
+	seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+
+	return garden.RateLimitedError{
+		Route:      handler,
+		RetryAfter: time.Duration(seconds) * time.Second,
+	}
+}
+
+// isUnknownRoute reports whether a response whose body is not a garden error
+// came from a server which has no such route, i.e. one too old to support the
+// operation. Garden's own not found errors always carry a garden error body.
+func isUnknownRoute(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed
 }
@@ -3,6 +3,7 @@ package connection_test
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"time"
@@ -20,10 +22,13 @@ import (
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/ghttp"
 	"github.com/tedsuo/rata"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"code.cloudfoundry.org/garden"
 	. "code.cloudfoundry.org/garden/client/connection"
 	"code.cloudfoundry.org/garden/client/connection/connectionfakes"
+	"code.cloudfoundry.org/garden/routes"
 	"code.cloudfoundry.org/garden/transport"
 )
 
@@ -200,6 +205,278 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Getting features", func() {
+		Context("when the response is successful", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/features"),
+						ghttp.RespondWith(200, marshalProto(&garden.FeatureSet{
+							PidsLimit: true,
+							SwapLimit: true,
+						}))))
+			})
+
+			It("should return the backend's features", func() {
+				features, err := connection.Features()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(features).Should(Equal(garden.FeatureSet{
+					PidsLimit: true,
+					SwapLimit: true,
+				}))
+			})
+		})
+
+		Context("when the request fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/features"),
+						ghttp.RespondWith(500, "")))
+			})
+
+			It("should return an error", func() {
+				_, err := connection.Features()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Looking up the container of a host pid", func() {
+		Context("when the response is successful", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/host_pids/1234/container"),
+						ghttp.RespondWith(200, `{"Handle":"foo-handle"}`)))
+			})
+
+			It("returns the container's handle", func() {
+				Ω(connection.ContainerForHostPID(1234)).Should(Equal("foo-handle"))
+			})
+		})
+
+		Context("when no container owns the pid", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/host_pids/1234/container"),
+						ghttp.RespondWith(404, `{"Type":"HostPIDNotFoundError","Message":"no container owns host pid: 1234","PID":1234}`)))
+			})
+
+			It("returns a HostPIDNotFoundError", func() {
+				_, err := connection.ContainerForHostPID(1234)
+				Ω(err).Should(Equal(garden.HostPIDNotFoundError{PID: 1234}))
+			})
+		})
+	})
+
+	Describe("Running a self-test", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/selftest"),
+					ghttp.RespondWith(200, `{"succeeded":false,"steps":[{"name":"create","duration":1000},{"name":"run","duration":2000,"error":"oh no!"},{"name":"destroy","duration":3000}]}`)))
+		})
+
+		It("returns the server's result", func() {
+			result, err := connection.Selftest()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(result).Should(Equal(garden.SelftestResult{
+				Succeeded: false,
+				Steps: []garden.SelftestStep{
+					{Name: "create", Duration: 1000},
+					{Name: "run", Duration: 2000, Error: "oh no!"},
+					{Name: "destroy", Duration: 3000},
+				},
+			}))
+		})
+	})
+
+	Describe("Checking the backend's health", func() {
+		It("returns the status of a healthy backend", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/health"),
+					ghttp.RespondWith(200, `{"state":"healthy","checks":[{"name":"ping","duration":1000}]}`)))
+
+			status, err := connection.Health()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(status).Should(Equal(garden.HealthStatus{
+				State:  garden.HealthStateHealthy,
+				Checks: []garden.HealthCheck{{Name: "ping", Duration: 1000}},
+			}))
+		})
+
+		It("returns the status of a degraded backend rather than an error", func() {
+			degraded := garden.HealthStatus{
+				State:  garden.HealthStateDegraded,
+				Checks: []garden.HealthCheck{{Name: "ping", Duration: 1000, Error: "oh no!"}},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/health"),
+					ghttp.RespondWith(503, marshalProto(garden.Error{Err: garden.BackendDegradedError{Health: degraded}}))))
+
+			status, err := connection.Health()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status).Should(Equal(degraded))
+		})
+	})
+
+	Describe("Validating a rootfs", func() {
+		It("sends the rootfs to be validated", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/rootfs/validate", "rootfs=docker%3A%2F%2F%2Fbusybox%231.36"),
+					ghttp.RespondWith(200, "{}")))
+
+			Ω(connection.ValidateRootFS("docker:///busybox#1.36")).Should(Succeed())
+		})
+
+		It("returns the reason the rootfs is invalid", func() {
+			invalid := garden.InvalidRootFSError{RootFS: "/some/rootfs", Reason: "does not exist"}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/rootfs/validate", "rootfs=%2Fsome%2Frootfs"),
+					ghttp.RespondWith(400, marshalProto(garden.Error{Err: invalid}))))
+
+			Ω(connection.ValidateRootFS("/some/rootfs")).Should(Equal(invalid))
+		})
+	})
+
+	Describe("Watching capacity", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/capacity/watch"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						transport.WriteMessage(conn, garden.Capacity{MaxContainers: 1})
+						transport.WriteMessage(conn, garden.Capacity{MaxContainers: 2})
+					},
+				),
+			)
+		})
+
+		It("streams each capacity sent by the server and closes when it disconnects", func() {
+			capacities, _, err := connection.WatchCapacity()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(capacities).Should(Receive(Equal(garden.Capacity{MaxContainers: 1})))
+			Eventually(capacities).Should(Receive(Equal(garden.Capacity{MaxContainers: 2})))
+			Eventually(capacities).Should(BeClosed())
+		})
+	})
+
+	Describe("Stopping watching capacity", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/capacity/watch"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						for {
+							if err := transport.WriteMessage(conn, garden.Capacity{MaxContainers: 1}); err != nil {
+								return
+							}
+						}
+					},
+				),
+			)
+		})
+
+		It("closes the channel, even though it is not being read", func() {
+			capacities, stop, err := connection.WatchCapacity()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(capacities).Should(Receive())
+
+			stop()
+
+			Eventually(capacities).Should(BeClosed())
+		})
+	})
+
+	Describe("Watching OOM kills", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ooms/watch"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						transport.WriteMessage(conn, garden.OOMEvent{Handle: "foo", ProcessID: "some-process"})
+					},
+				),
+			)
+		})
+
+		It("streams each OOM kill sent by the server and closes when it disconnects", func() {
+			events, _, err := connection.WatchOOMs()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive(Equal(garden.OOMEvent{Handle: "foo", ProcessID: "some-process"})))
+			Eventually(events).Should(BeClosed())
+		})
+	})
+
+	Describe("Stopping watching OOM kills", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ooms/watch"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						for {
+							if err := transport.WriteMessage(conn, garden.OOMEvent{Handle: "foo"}); err != nil {
+								return
+							}
+						}
+					},
+				),
+			)
+		})
+
+		It("closes the channel, even though it is not being read", func() {
+			events, stop, err := connection.WatchOOMs()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive())
+
+			stop()
+
+			Eventually(events).Should(BeClosed())
+		})
+	})
+
 	Describe("Creating", func() {
 		var spec garden.ContainerSpec
 
@@ -223,6 +500,36 @@ var _ = Describe("Connection", func() {
 			})
 		})
 
+		Context("when the server reports insufficient capacity", func() {
+			JustBeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers"),
+					ghttp.RespondWith(500, `{"Type":"InsufficientCapacityError","Message":"host is full"}`)))
+			})
+
+			It("returns an InsufficientCapacityError", func() {
+				_, err := connection.Create(garden.ContainerSpec{})
+				Ω(err).Should(MatchError(garden.InsufficientCapacityError{Cause: "host is full"}))
+			})
+		})
+
+		Context("when the server rejects a bind mount", func() {
+			JustBeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers"),
+					ghttp.RespondWith(400, `{"Type":"BindMountError","Message":"bind mount /src -> /dst: source path does not exist","BindMount":{"SrcPath":"/src","DstPath":"/dst","Cause":"source path does not exist"}}`)))
+			})
+
+			It("returns a BindMountError", func() {
+				_, err := connection.Create(garden.ContainerSpec{})
+				Ω(err).Should(MatchError(garden.BindMountError{
+					SrcPath: "/src",
+					DstPath: "/dst",
+					Cause:   "source path does not exist",
+				}))
+			})
+		})
+
 		Context("with a fully specified ContainerSpec", func() {
 			BeforeEach(func() {
 				spec = garden.ContainerSpec{
@@ -244,6 +551,12 @@ var _ = Describe("Connection", func() {
 							Origin:  garden.BindMountOriginContainer,
 						},
 					},
+					UIDMappings: []garden.IDMapping{
+						{ContainerID: 0, HostID: 100000, Size: 65536},
+					},
+					GIDMappings: []garden.IDMapping{
+						{ContainerID: 0, HostID: 100000, Size: 65536},
+					},
 					Properties: map[string]string{
 						"foo": "bar",
 					},
@@ -289,6 +602,39 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Renaming", func() {
+		Context("when renaming succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/rename"),
+						verifyRequestBody(map[string]interface{}{
+							"handle": "bar",
+						}, make(map[string]interface{})),
+						ghttp.RespondWith(200, "{}")))
+			})
+
+			It("should rename the container", func() {
+				err := connection.Rename("foo", "bar")
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when the new handle is already taken", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/rename"),
+						ghttp.RespondWith(409, `{ "Type": "HandleConflictError", "Handle" : "bar"}`)))
+			})
+
+			It("returns a HandleConflictError", func() {
+				err := connection.Rename("foo", "bar")
+				Ω(err).Should(MatchError(garden.HandleConflictError{Handle: "bar"}))
+			})
+		})
+	})
+
 	Describe("Stopping", func() {
 		BeforeEach(func() {
 			server.AppendHandlers(
@@ -306,6 +652,104 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Checkpointing", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo/checkpoint"),
+					verifyRequestBody(map[string]interface{}{
+						"image_path": "/var/checkpoints/foo",
+					}, make(map[string]interface{})),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should checkpoint the container", func() {
+			err := connection.Checkpoint("foo", "/var/checkpoints/foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Restoring", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo/restore"),
+					verifyRequestBody(map[string]interface{}{
+						"image_path": "/var/checkpoints/foo",
+					}, make(map[string]interface{})),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should restore the container", func() {
+			err := connection.Restore("foo", "/var/checkpoints/foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Syncing", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo/sync"),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should sync the container", func() {
+			err := connection.Sync("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("setting several limits at once", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/limits"),
+					ghttp.VerifyJSON(`{"cpu_limits":{"limit_in_shares":10},"memory_limits":{"limit_in_bytes":1024}}`),
+					ghttp.RespondWith(200, marshalProto(&garden.Limits{
+						CPU:    garden.CPULimits{LimitInShares: 10},
+						Disk:   garden.DiskLimits{ByteHard: 4096},
+						Memory: garden.MemoryLimits{LimitInBytes: 1024},
+					})),
+				),
+			)
+		})
+
+		It("sends only the limits being set and returns the limits in effect", func() {
+			limits, err := connection.LimitAll("foo", garden.LimitsUpdate{
+				CPU:    &garden.CPULimits{LimitInShares: 10},
+				Memory: &garden.MemoryLimits{LimitInBytes: 1024},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(limits).Should(Equal(garden.Limits{
+				CPU:    garden.CPULimits{LimitInShares: 10},
+				Disk:   garden.DiskLimits{ByteHard: 4096},
+				Memory: garden.MemoryLimits{LimitInBytes: 1024},
+			}))
+		})
+	})
+
+	Describe("boosting limits", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo/limits/boost"),
+					ghttp.VerifyJSONRepresenting(map[string]interface{}{
+						"limits":   garden.Limits{CPU: garden.CPULimits{LimitInShares: 1024}},
+						"duration": time.Minute,
+					}),
+					ghttp.RespondWith(200, "{}"),
+				),
+			)
+		})
+
+		It("sends the limits and how long to boost them for", func() {
+			err := connection.BoostLimits("foo", garden.Limits{CPU: garden.CPULimits{LimitInShares: 1024}}, time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Describe("fetching limit info", func() {
 		Describe("getting memory limits", func() {
 			BeforeEach(func() {
@@ -326,27 +770,50 @@ var _ = Describe("Connection", func() {
 			})
 		})
 
-		Describe("getting cpu limits", func() {
+		Describe("getting IO limits", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo/limits/cpu"),
-						ghttp.RespondWith(200, marshalProto(&garden.CPULimits{
-							LimitInShares: 40,
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/io"),
+						ghttp.RespondWith(200, marshalProto(&garden.IOLimits{
+							ReadBytesPerSecond: 1024,
+							WriteIOPS:          40,
 						})),
 					),
 				)
 			})
 
-			It("gets the cpu limit", func() {
-				limits, err := connection.CurrentCPULimits("foo")
+			It("gets the IO limits", func() {
+				currentLimits, err := connection.CurrentIOLimits("foo")
 				Ω(err).ShouldNot(HaveOccurred())
-
-				Ω(limits.LimitInShares).Should(BeNumerically("==", 40))
+				Ω(currentLimits).Should(Equal(garden.IOLimits{
+					ReadBytesPerSecond: 1024,
+					WriteIOPS:          40,
+				}))
 			})
 		})
 
-		Describe("getting bandwidth limits", func() {
+		Describe("getting cpu limits", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/cpu"),
+						ghttp.RespondWith(200, marshalProto(&garden.CPULimits{
+							LimitInShares: 40,
+						})),
+					),
+				)
+			})
+
+			It("gets the cpu limit", func() {
+				limits, err := connection.CurrentCPULimits("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(limits.LimitInShares).Should(BeNumerically("==", 40))
+			})
+		})
+
+		Describe("getting bandwidth limits", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
@@ -428,6 +895,70 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("NetworkPolicy", func() {
+		policy := garden.NetworkPolicy{
+			DefaultAction: garden.NetworkActionDeny,
+			Rules: []garden.NetOutRule{
+				{Protocol: garden.ProtocolTCP, Ports: []garden.PortRange{garden.PortRangeFromPort(443)}},
+			},
+		}
+
+		Context("when fetching the policy", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/net/policy"),
+						ghttp.RespondWith(200, marshalProto(policy))))
+			})
+
+			It("returns the policy", func() {
+				Ω(connection.NetworkPolicy("foo-handle")).Should(Equal(policy))
+			})
+		})
+
+		Context("when setting the policy", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/net/policy"),
+						verifyRequestBody(&policy, &garden.NetworkPolicy{}),
+						ghttp.RespondWith(200, "{}")))
+			})
+
+			It("sends the whole policy over the wire", func() {
+				Ω(connection.SetNetworkPolicy("foo-handle", policy)).Should(Succeed())
+			})
+		})
+	})
+
+	Describe("Traffic between containers", func() {
+		Context("when allowing traffic", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/net/peers/bar-handle"),
+						ghttp.RespondWith(200, "{}")))
+			})
+
+			It("names the source and destination containers", func() {
+				Ω(connection.AllowTraffic("foo-handle", "bar-handle")).Should(Succeed())
+			})
+		})
+
+		Context("when denying traffic", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/containers/foo-handle/net/peers/bar-handle"),
+						ghttp.RespondWith(200, "{}")))
+			})
+
+			It("names the source and destination containers", func() {
+				Ω(connection.DenyTraffic("foo-handle", "bar-handle")).Should(Succeed())
+			})
+		})
+	})
+
 	Describe("Listing containers", func() {
 		BeforeEach(func() {
 			server.AppendHandlers(
@@ -448,6 +979,196 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Listing containers by state", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/features"),
+					ghttp.RespondWith(200, `{"list_by_state": true}`)),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers", "garden.state=stopped"),
+					ghttp.RespondWith(200, marshalProto(&struct {
+						Handles []string `json:"handles"`
+					}{
+						[]string{"container1"},
+					}))))
+		})
+
+		It("should return the list of containers in that state", func() {
+			handles, err := connection.ListByState(garden.ContainerStateStopped)
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handles).Should(Equal([]string{"container1"}))
+		})
+
+		Context("when the server does not report listing by state", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/features"),
+					ghttp.RespondWith(200, `{}`)))
+			})
+
+			It("fails without listing, as the server would take the state for a property", func() {
+				_, err := connection.ListByState(garden.ContainerStateStopped)
+				Ω(err).Should(Equal(garden.UnsupportedOperationError{Operation: "ListByState"}))
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when the server cannot report its features", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/features"),
+					ghttp.RespondWith(404, "404 page not found")))
+			})
+
+			It("fails without listing", func() {
+				_, err := connection.ListByState(garden.ContainerStateStopped)
+				Ω(err).Should(Equal(garden.UnsupportedOperationError{Operation: "ListByState"}))
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Setting drain mode", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/drain"),
+					ghttp.VerifyJSON("true"),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("asks the server to drain", func() {
+			Ω(connection.SetDrainMode(true)).Should(Succeed())
+		})
+	})
+
+	Describe("Creating while the server is draining", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers"),
+					ghttp.RespondWith(503, `{"Type":"DrainingError","Message":"server is draining: no new containers can be created"}`)))
+		})
+
+		It("returns a DrainingError", func() {
+			_, err := connection.Create(garden.ContainerSpec{})
+			Ω(err).Should(Equal(garden.DrainingError{}))
+		})
+	})
+
+	Describe("Listing containers a page at a time", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/features"),
+					ghttp.RespondWith(200, `{"list_paging": true}`)),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers", "foo=bar&garden.cursor=c29tZS1jdXJzb3I&garden.limit=2"),
+					ghttp.RespondWith(200, `{"Handles":["container1","container2"],"NextCursor":"Y29udGFpbmVyMg"}`)))
+		})
+
+		It("should return the page of containers and the cursor of the next", func() {
+			handles, next, err := connection.ListPage(garden.Properties{"foo": "bar"}, "c29tZS1jdXJzb3I", 2)
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handles).Should(Equal([]string{"container1", "container2"}))
+			Ω(next).Should(Equal("Y29udGFpbmVyMg"))
+		})
+
+		Context("when the server does not report listing a page at a time", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/features"),
+					ghttp.RespondWith(200, `{}`)))
+			})
+
+			It("fails without listing, as the server would take the limit and cursor for properties", func() {
+				_, _, err := connection.ListPage(garden.Properties{"foo": "bar"}, "c29tZS1jdXJzb3I", 2)
+				Ω(err).Should(Equal(garden.UnsupportedOperationError{Operation: "ListPage"}))
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("being rate limited", func() {
+		Context("when the server reports a RateLimitedError", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers"),
+						ghttp.RespondWith(429, `{"Type":"RateLimitedError","RateLimited":{"Route":"List","RetryAfter":500000000}}`)))
+			})
+
+			It("returns it", func() {
+				_, err := connection.List(nil)
+				Ω(err).Should(Equal(garden.RateLimitedError{Route: "List", RetryAfter: 500 * time.Millisecond}))
+			})
+		})
+
+		Context("when a 429 does not carry a garden error", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers"),
+						ghttp.RespondWith(429, "slow down", http.Header{"Retry-After": []string{"3"}})))
+			})
+
+			It("returns a RateLimitedError from the Retry-After header", func() {
+				_, err := connection.List(nil)
+				Ω(err).Should(Equal(garden.RateLimitedError{Route: "List", RetryAfter: 3 * time.Second}))
+			})
+		})
+	})
+
+	Describe("unsupported operations", func() {
+		Context("when the server reports an UnsupportedOperationError", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits"),
+						ghttp.RespondWith(501, `{"Type":"UnsupportedOperationError","Message":"operation not supported: pids limit","Operation":"pids limit"}`)))
+			})
+
+			It("returns it", func() {
+				_, err := connection.LimitAll("foo", garden.LimitsUpdate{})
+				Ω(err).Should(Equal(garden.UnsupportedOperationError{Operation: "pids limit"}))
+			})
+		})
+
+		Context("when the server does not have the route at all", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/features"),
+						ghttp.RespondWith(404, "404 page not found")))
+			})
+
+			It("returns an UnsupportedOperationError for the route", func() {
+				_, err := connection.Features()
+				Ω(err).Should(Equal(garden.UnsupportedOperationError{Operation: "Features"}))
+			})
+		})
+
+		Context("when a process route is missing", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo/processes"),
+						ghttp.RespondWith(405, "")))
+			})
+
+			It("returns an UnsupportedOperationError for the route", func() {
+				_, err := connection.Run("foo", garden.ProcessSpec{}, garden.ProcessIO{})
+				Ω(err).Should(Equal(garden.UnsupportedOperationError{Operation: "Run"}))
+			})
+		})
+	})
+
 	Describe("Getting container properties", func() {
 		handle := "container-handle"
 		var status int
@@ -524,297 +1245,887 @@ var _ = Describe("Connection", func() {
 
 	})
 
-	Describe("Getting container metrics", func() {
-		handle := "container-handle"
-		metrics := garden.Metrics{
-			MemoryStat: garden.ContainerMemoryStat{
-				Cache:                   1,
-				Rss:                     2,
-				MappedFile:              3,
-				Pgpgin:                  4,
-				Pgpgout:                 5,
-				Swap:                    6,
-				Pgfault:                 7,
-				Pgmajfault:              8,
-				InactiveAnon:            9,
-				ActiveAnon:              10,
-				InactiveFile:            11,
-				ActiveFile:              12,
-				Unevictable:             13,
-				HierarchicalMemoryLimit: 14,
-				HierarchicalMemswLimit:  15,
-				TotalCache:              16,
-				TotalRss:                17,
-				TotalMappedFile:         18,
-				TotalPgpgin:             19,
-				TotalPgpgout:            20,
-				TotalSwap:               21,
-				TotalPgfault:            22,
-				TotalPgmajfault:         23,
-				TotalInactiveAnon:       24,
-				TotalActiveAnon:         25,
-				TotalInactiveFile:       26,
-				TotalActiveFile:         27,
-				TotalUnevictable:        28,
-				TotalUsageTowardLimit:   7, // TotalRss+(TotalCache-TotalInactiveFile)
-			},
-			CPUStat: garden.ContainerCPUStat{
-				Usage:  1,
-				User:   2,
-				System: 3,
-			},
-
-			DiskStat: garden.ContainerDiskStat{
-				TotalBytesUsed:      11,
-				TotalInodesUsed:     12,
-				ExclusiveBytesUsed:  13,
-				ExclusiveInodesUsed: 14,
-			},
-		}
-		var status int
-
-		BeforeEach(func() {
-			status = 200
-		})
-
-		JustBeforeEach(func() {
-			server.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", fmt.Sprintf("/containers/%s/metrics", handle)),
-					ghttp.RespondWith(status, marshalProto(metrics))))
-		})
-
-		It("returns the MemoryStat, CPUStat and DiskStat", func() {
-			returnedMetrics, err := connection.Metrics(handle)
+	Describe("DoRequest", func() {
+		Context("when the server responds successfully", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/properties/some-property", "a=b"),
+						ghttp.VerifyContentType("application/json"),
+						ghttp.VerifyJSON(`{"value":"some-value"}`),
+						ghttp.RespondWith(200, `{"ok":true}`)))
+			})
+
+			It("returns the raw response for the route", func() {
+				response, err := connection.DoRequest(
+					routes.SetProperty,
+					rata.Params{"handle": "foo", "key": "some-property"},
+					url.Values{"a": []string{"b"}},
+					strings.NewReader(`{"value":"some-value"}`),
+				)
+				Ω(err).ShouldNot(HaveOccurred())
+				defer response.Body.Close()
 
-			Ω(err).ShouldNot(HaveOccurred())
-			Ω(returnedMetrics).Should(Equal(metrics))
+				Ω(response.StatusCode).Should(Equal(200))
+				Ω(ioutil.ReadAll(response.Body)).Should(MatchJSON(`{"ok":true}`))
+			})
 		})
 
-		Context("when getting container metrics fails", func() {
+		Context("when the server responds with an error", func() {
 			BeforeEach(func() {
-				status = 400
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/info"),
+						ghttp.RespondWith(404, `{"Type":"ContainerNotFoundError","Handle":"foo"}`)))
+			})
+
+			It("returns the garden error", func() {
+				_, err := connection.DoRequest(routes.Info, rata.Params{"handle": "foo"}, nil, nil)
+				Ω(err).Should(MatchError(garden.ContainerNotFoundError{Handle: "foo"}))
 			})
+		})
 
+		Context("when the route does not exist", func() {
 			It("returns an error", func() {
-				_, err := connection.Metrics(handle)
+				_, err := connection.DoRequest("NoSuchRoute", nil, nil, nil)
 				Ω(err).Should(HaveOccurred())
 			})
 		})
 	})
 
-	Describe("Setting the grace time", func() {
-		var (
-			status    int
-			handle    string
-			graceTime time.Duration
-		)
+	Describe("reusing connections for unary calls", func() {
+		var remoteAddrs []string
 
 		BeforeEach(func() {
-			handle = "container-handle"
-			graceTime = time.Second * 5
-			status = 200
+			remoteAddrs = []string{}
+
+			recordRemoteAddr := func(w http.ResponseWriter, r *http.Request) {
+				remoteAddrs = append(remoteAddrs, r.RemoteAddr)
+			}
+
+			for i := 0; i < 3; i++ {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/properties/some-property"),
+						recordRemoteAddr,
+						ghttp.RespondWith(200, "{}")))
+			}
+		})
+
+		It("dials a new connection for every call by default", func() {
+			for i := 0; i < 3; i++ {
+				Ω(connection.SetProperty("foo", "some-property", "some-value")).Should(Succeed())
+			}
+
+			Ω(remoteAddrs).Should(HaveLen(3))
+			Ω(remoteAddrs[1]).ShouldNot(Equal(remoteAddrs[0]))
+			Ω(remoteAddrs[2]).ShouldNot(Equal(remoteAddrs[1]))
+		})
+
+		Context("when connection reuse is enabled", func() {
+			JustBeforeEach(func() {
+				connection = NewWithOptions(network, address, lagertest.NewTestLogger("test-connection"), WithConnectionReuse())
+			})
+
+			It("sends sequential calls over the same connection", func() {
+				for i := 0; i < 3; i++ {
+					Ω(connection.SetProperty("foo", "some-property", "some-value")).Should(Succeed())
+				}
+
+				Ω(remoteAddrs).Should(HaveLen(3))
+				Ω(remoteAddrs[1]).Should(Equal(remoteAddrs[0]))
+				Ω(remoteAddrs[2]).Should(Equal(remoteAddrs[0]))
+			})
 		})
+	})
 
+	Describe("multiplexing unary calls over HTTP/2", func() {
 		JustBeforeEach(func() {
-			server.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("PUT", fmt.Sprintf("/containers/%s/grace_time", handle)),
-					// interface{} confusion: the JSON decoder decodes numberics to float64...
-					verifyRequestBody(float64(graceTime), float64(0)),
-					ghttp.RespondWith(status, "{}"),
-				),
-			)
+			connection = NewWithOptions(network, address, lagertest.NewTestLogger("test-connection"), WithHTTP2())
 		})
 
-		It("send SetGraceTime request", func() {
-			Ω(connection.SetGraceTime(handle, graceTime)).Should(Succeed())
+		Context("when the server speaks HTTP/2", func() {
+			var (
+				h2Server *httptest.Server
+				protos   chan string
+				addrs    chan string
+			)
+
+			BeforeEach(func() {
+				protos = make(chan string, 10)
+				addrs = make(chan string, 10)
+
+				h2Server = httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					protos <- r.Proto
+					addrs <- r.RemoteAddr
+					w.Write([]byte("{}"))
+				}), &http2.Server{}))
+
+				address = h2Server.Listener.Addr().String()
+			})
+
+			AfterEach(func() {
+				h2Server.Close()
+			})
+
+			It("sends every call over the same HTTP/2 connection", func() {
+				for i := 0; i < 3; i++ {
+					Ω(connection.SetProperty("foo", "some-property", "some-value")).Should(Succeed())
+				}
+
+				Ω(protos).Should(HaveLen(3))
+				Ω(addrs).Should(HaveLen(3))
+
+				firstAddr := <-addrs
+				for i := 0; i < 3; i++ {
+					Ω(<-protos).Should(Equal("HTTP/2.0"))
+				}
+				for i := 1; i < 3; i++ {
+					Ω(<-addrs).Should(Equal(firstAddr))
+				}
+			})
 		})
 
-		Context("when setting grace time fails", func() {
+		Context("when the server only speaks HTTP/1", func() {
 			BeforeEach(func() {
-				status = 400
+				// the server sees the HTTP/2 preface as a request, which it
+				// rejects
+				server.RouteToHandler("PRI", "*", ghttp.RespondWith(http.StatusNotFound, ""))
+				server.RouteToHandler("PUT", "/containers/foo/properties/some-property", ghttp.RespondWith(200, "{}"))
 			})
 
-			It("returns an error", func() {
-				Ω(connection.SetGraceTime(handle, graceTime)).ShouldNot(Succeed())
+			It("falls back to HTTP/1", func() {
+				for i := 0; i < 2; i++ {
+					Ω(connection.SetProperty("foo", "some-property", "some-value")).Should(Succeed())
+				}
+
+				puts := 0
+				for _, request := range server.ReceivedRequests() {
+					if request.Method == "PUT" {
+						Ω(request.ProtoMajor).Should(Equal(1))
+						puts++
+					}
+				}
+				Ω(puts).Should(Equal(2))
 			})
 		})
 	})
 
-	Describe("Getting container info", func() {
-		var infoResponse garden.ContainerInfo
+	Describe("pinning connections to a local address", func() {
+		var opts []Option
+
+		BeforeEach(func() {
+			opts = nil
+		})
 
 		JustBeforeEach(func() {
-			infoResponse = garden.ContainerInfo{
-				State: "chilling out",
-				Events: []string{
-					"maxing",
-					"relaxing all cool",
-				},
-				HostIP:        "host-ip",
-				ContainerIP:   "container-ip",
-				ContainerPath: "container-path",
-				ProcessIDs:    []string{"process-handle-1", "process-handle-2"},
-				Properties: garden.Properties{
-					"prop-key": "prop-value",
-				},
-				MappedPorts: []garden.PortMapping{
-					{HostPort: 1234, ContainerPort: 5678},
-					{HostPort: 1235, ContainerPort: 5679},
-				},
-			}
+			// an address that is not on this host, so that dialling from it
+			// fails if and only if it is used
+			localAddr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1")}
+			connection = NewWithOptions(network, address, lagertest.NewTestLogger("test-connection"), append(opts, WithLocalAddr(localAddr))...)
+		})
 
-			server.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/containers/some-handle/info"),
-					ghttp.RespondWith(200, marshalProto(infoResponse))))
+		It("dials unary calls from the local address", func() {
+			err := connection.SetProperty("foo", "some-property", "some-value")
+			Ω(err).Should(MatchError(ContainSubstring("192.0.2.1")))
+
+			Ω(server.ReceivedRequests()).Should(BeEmpty())
 		})
 
-		It("should return the container's info", func() {
-			info, err := connection.Info("some-handle")
-			Ω(err).ShouldNot(HaveOccurred())
+		It("dials streaming calls from the local address", func() {
+			_, err := connection.StreamOutputLog("foo", "job.log")
+			Ω(err).Should(MatchError(ContainSubstring("192.0.2.1")))
 
-			Ω(info).Should(Equal(infoResponse))
+			Ω(server.ReceivedRequests()).Should(BeEmpty())
+		})
+
+		Context("along with other options", func() {
+			BeforeEach(func() {
+				opts = []Option{WithHTTP2(), WithRequestTimeout(time.Second)}
+			})
+
+			It("still dials from the local address", func() {
+				err := connection.SetProperty("foo", "some-property", "some-value")
+				Ω(err).Should(MatchError(ContainSubstring("192.0.2.1")))
+
+				Ω(server.ReceivedRequests()).Should(BeEmpty())
+			})
 		})
 	})
 
-	Describe("BulkInfo", func() {
+	Describe("connecting through a proxy", func() {
+		var (
+			proxyListener net.Listener
+			connects      chan *http.Request
+			refuse        bool
+		)
 
-		expectedBulkInfo := map[string]garden.ContainerInfoEntry{
-			"handle1": garden.ContainerInfoEntry{
-				Info: garden.ContainerInfo{
-					State: "container1state",
-				},
-			},
-			"handle2": garden.ContainerInfoEntry{
-				Info: garden.ContainerInfo{
-					State: "container2state",
-				},
-			},
-		}
+		BeforeEach(func() {
+			refuse = false
+			connects = make(chan *http.Request, 10)
 
-		handles := []string{"handle1", "handle2"}
-		queryParams := "handles=" + strings.Join(handles, "%2C")
+			var err error
+			proxyListener, err = net.Listen("tcp", "127.0.0.1:0")
+			Ω(err).ShouldNot(HaveOccurred())
 
-		Context("when the response is successful", func() {
-			JustBeforeEach(func() {
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/bulk_info", queryParams),
-						ghttp.RespondWith(200, marshalProto(expectedBulkInfo))))
-			})
+			go func() {
+				for {
+					conn, err := proxyListener.Accept()
+					if err != nil {
+						return
+					}
 
-			It("returns info about containers", func() {
-				bulkInfo, err := connection.BulkInfo(handles)
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(bulkInfo).Should(Equal(expectedBulkInfo))
-			})
+					go func() {
+						defer conn.Close()
+
+						request, err := http.ReadRequest(bufio.NewReader(conn))
+						if err != nil {
+							return
+						}
+						connects <- request
+
+						if refuse {
+							fmt.Fprint(conn, "HTTP/1.1 403 Forbidden\r\n\r\n")
+							return
+						}
+
+						upstream, err := net.Dial("tcp", request.Host)
+						if err != nil {
+							return
+						}
+						defer upstream.Close()
+
+						fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+
+						go io.Copy(upstream, conn)
+						io.Copy(conn, upstream)
+					}()
+				}
+			}()
 		})
 
-		Context("when the request fails", func() {
-			JustBeforeEach(func() {
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/bulk_info", queryParams),
-						ghttp.RespondWith(500, ""),
-					),
-				)
-			})
+		AfterEach(func() {
+			proxyListener.Close()
+		})
 
-			It("returns the error", func() {
-				_, err := connection.BulkInfo(handles)
-				Ω(err).Should(HaveOccurred())
-			})
+		JustBeforeEach(func() {
+			proxy := &url.URL{
+				Scheme: "http",
+				User:   url.UserPassword("some-user", "some-password"),
+				Host:   proxyListener.Addr().String(),
+			}
+
+			connection = NewWithOptions("tcp", address, lagertest.NewTestLogger("test-connection"), WithProxy(proxy))
 		})
 
-		Context("when a container is in error state", func() {
-			It("returns the error for the container", func() {
+		It("tunnels unary calls to the server", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ping"),
+					ghttp.RespondWith(200, "{}"),
+				),
+			)
 
-				expectedBulkInfo := map[string]garden.ContainerInfoEntry{
-					"error": garden.ContainerInfoEntry{
-						Err: &garden.Error{Err: errors.New("Oopps")},
-					},
-					"success": garden.ContainerInfoEntry{
-						Info: garden.ContainerInfo{
-							State: "container2state",
-						},
+			Ω(connection.Ping()).Should(Succeed())
+
+			var connect *http.Request
+			Eventually(connects).Should(Receive(&connect))
+			Ω(connect.Method).Should(Equal("CONNECT"))
+			Ω(connect.Host).Should(Equal(address))
+
+			user, password, ok := (&http.Request{Header: http.Header{
+				"Authorization": connect.Header["Proxy-Authorization"],
+			}}).BasicAuth()
+			Ω(ok).Should(BeTrue())
+			Ω(user).Should(Equal("some-user"))
+			Ω(password).Should(Equal("some-password"))
+		})
+
+		It("tunnels hijacked calls to the server", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/process-handle"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, br, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+						defer conn.Close()
+
+						transport.WriteMessage(conn, map[string]interface{}{
+							"process_id": "process-handle",
+							"stream_id":  "123",
+						})
+
+						var payload map[string]interface{}
+						json.NewDecoder(br).Decode(&payload)
 					},
-				}
+				),
+			)
 
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/bulk_info", queryParams),
-						ghttp.RespondWith(200, marshalProto(expectedBulkInfo))))
+			process, err := connection.Attach("foo-handle", "process-handle", garden.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(process.ID()).Should(Equal("process-handle"))
 
-				bulkInfo, err := connection.BulkInfo(handles)
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(bulkInfo).Should(Equal(expectedBulkInfo))
+			Eventually(connects).Should(Receive())
+		})
+
+		Context("when the proxy refuses the tunnel", func() {
+			BeforeEach(func() {
+				refuse = true
+			})
+
+			It("returns a ProxyError without reaching the server", func() {
+				err := connection.Ping()
+				Ω(err).Should(MatchError(ContainSubstring("403 Forbidden")))
+
+				Ω(server.ReceivedRequests()).Should(BeEmpty())
 			})
 		})
 	})
 
-	Describe("BulkMetrics", func() {
+	Describe("timing out requests", func() {
+		var release chan struct{}
 
-		expectedBulkMetrics := map[string]garden.ContainerMetricsEntry{
-			"handle1": garden.ContainerMetricsEntry{
-				Metrics: garden.Metrics{
-					DiskStat: garden.ContainerDiskStat{
-						TotalInodesUsed:     4,
-						TotalBytesUsed:      3,
-						ExclusiveBytesUsed:  2,
-						ExclusiveInodesUsed: 1,
-					},
-				},
-			},
-			"handle2": garden.ContainerMetricsEntry{
-				Metrics: garden.Metrics{
-					DiskStat: garden.ContainerDiskStat{
-						TotalInodesUsed:     5,
-						TotalBytesUsed:      6,
-						ExclusiveBytesUsed:  7,
-						ExclusiveInodesUsed: 8,
-					},
-				},
-			},
-		}
+		BeforeEach(func() {
+			release = make(chan struct{})
+		})
 
-		handles := []string{"handle1", "handle2"}
-		queryParams := "handles=" + strings.Join(handles, "%2C")
+		JustBeforeEach(func() {
+			connection = NewWithOptions(network, address, lagertest.NewTestLogger("test-connection"), WithRequestTimeout(100*time.Millisecond))
+		})
 
-		Context("when the response is successful", func() {
-			JustBeforeEach(func() {
+		AfterEach(func() {
+			close(release)
+		})
+
+		hang := func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}
+
+		Context("when the server does not answer a unary call", func() {
+			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/bulk_metrics", queryParams),
-						ghttp.RespondWith(200, marshalProto(expectedBulkMetrics))))
+						ghttp.VerifyRequest("PUT", "/containers/foo/properties/some-property"),
+						hang,
+					),
+				)
 			})
 
-			It("returns info about containers", func() {
-				bulkMetrics, err := connection.BulkMetrics(handles)
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(bulkMetrics).Should(Equal(expectedBulkMetrics))
+			It("gives up after the timeout", func() {
+				errs := make(chan error, 1)
+				go func() {
+					errs <- connection.SetProperty("foo", "some-property", "some-value")
+				}()
+
+				Eventually(errs).Should(Receive(HaveOccurred()))
 			})
 		})
 
-		Context("when the request fails", func() {
-			JustBeforeEach(func() {
+		Context("when the server does not answer a hijacked call", func() {
+			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/bulk_metrics", queryParams),
-						ghttp.RespondWith(500, ""),
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/process-handle"),
+						hang,
 					),
 				)
 			})
 
-			It("returns the error", func() {
-				_, err := connection.BulkMetrics(handles)
-				Ω(err).Should(HaveOccurred())
+			It("gives up after the timeout", func() {
+				errs := make(chan error, 1)
+				go func() {
+					_, err := connection.Attach("foo-handle", "process-handle", garden.ProcessIO{})
+					errs <- err
+				}()
+
+				Eventually(errs).Should(Receive(HaveOccurred()))
 			})
 		})
 
-		Context("when a container has an error", func() {
-			It("returns the error for the container", func() {
+		Context("when a stream takes longer than the timeout once set up", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "user=frank&source=%2Fbar"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+							w.(http.Flusher).Flush()
+
+							time.Sleep(300 * time.Millisecond)
+							w.Write([]byte("hello-world!"))
+						},
+					),
+				)
+			})
+
+			It("does not cut it off", func() {
+				reader, err := connection.StreamOut("foo-handle", garden.StreamOutSpec{User: "frank", Path: "/bar"})
+				Ω(err).ShouldNot(HaveOccurred())
+				defer reader.Close()
+
+				readBytes, err := ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(readBytes).Should(Equal([]byte("hello-world!")))
+			})
+		})
+	})
+
+	Describe("Getting container metrics", func() {
+		handle := "container-handle"
+		metrics := garden.Metrics{
+			MemoryStat: garden.ContainerMemoryStat{
+				Cache:                   1,
+				Rss:                     2,
+				MappedFile:              3,
+				Pgpgin:                  4,
+				Pgpgout:                 5,
+				Swap:                    6,
+				Pgfault:                 7,
+				Pgmajfault:              8,
+				InactiveAnon:            9,
+				ActiveAnon:              10,
+				InactiveFile:            11,
+				ActiveFile:              12,
+				Unevictable:             13,
+				HierarchicalMemoryLimit: 14,
+				HierarchicalMemswLimit:  15,
+				TotalCache:              16,
+				TotalRss:                17,
+				TotalMappedFile:         18,
+				TotalPgpgin:             19,
+				TotalPgpgout:            20,
+				TotalSwap:               21,
+				TotalPgfault:            22,
+				TotalPgmajfault:         23,
+				TotalInactiveAnon:       24,
+				TotalActiveAnon:         25,
+				TotalInactiveFile:       26,
+				TotalActiveFile:         27,
+				TotalUnevictable:        28,
+				TotalUsageTowardLimit:   7, // TotalRss+(TotalCache-TotalInactiveFile)
+			},
+			CPUStat: garden.ContainerCPUStat{
+				Usage:  1,
+				User:   2,
+				System: 3,
+			},
+
+			DiskStat: garden.ContainerDiskStat{
+				TotalBytesUsed:      11,
+				TotalInodesUsed:     12,
+				ExclusiveBytesUsed:  13,
+				ExclusiveInodesUsed: 14,
+			},
+		}
+		var status int
+
+		BeforeEach(func() {
+			status = 200
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", fmt.Sprintf("/containers/%s/metrics", handle)),
+					ghttp.RespondWith(status, marshalProto(metrics))))
+		})
+
+		It("returns the MemoryStat, CPUStat and DiskStat", func() {
+			returnedMetrics, err := connection.Metrics(handle)
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(returnedMetrics).Should(Equal(metrics))
+		})
+
+		Context("when getting container metrics fails", func() {
+			BeforeEach(func() {
+				status = 400
+			})
+
+			It("returns an error", func() {
+				_, err := connection.Metrics(handle)
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Getting resource usage", func() {
+		usage := garden.ResourceUsage{
+			CPU: garden.CPUUsage{
+				Usage:  garden.ContainerCPUStat{Usage: 1},
+				Limits: garden.CPULimits{LimitInShares: 512},
+			},
+			Bandwidth: garden.BandwidthUsage{
+				Usage:  garden.ContainerNetworkStat{RxBytes: 100},
+				Limits: garden.BandwidthLimits{RateInBytesPerSecond: 1000},
+			},
+		}
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/usage"),
+					ghttp.RespondWith(200, marshalProto(usage))))
+		})
+
+		It("returns the usage and limits of each resource", func() {
+			Ω(connection.ResourceUsage("foo")).Should(Equal(usage))
+		})
+	})
+
+	Describe("Getting process stats", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/process_stats"),
+					ghttp.RespondWith(200, `{"1":{"CPUUsage":10,"CPUUser":6,"CPUSystem":4,"MemoryRSS":1024}}`)))
+		})
+
+		It("returns the stats keyed by pid", func() {
+			stats, err := connection.ProcessStats("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(stats).Should(Equal(map[uint32]garden.ProcessStat{
+				1: {CPUUsage: 10, CPUUser: 6, CPUSystem: 4, MemoryRSS: 1024},
+			}))
+		})
+	})
+
+	Describe("Setting the grace time", func() {
+		var (
+			status    int
+			handle    string
+			graceTime time.Duration
+		)
+
+		BeforeEach(func() {
+			handle = "container-handle"
+			graceTime = time.Second * 5
+			status = 200
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", fmt.Sprintf("/containers/%s/grace_time", handle)),
+					// interface{} confusion: the JSON decoder decodes numberics to float64...
+					verifyRequestBody(float64(graceTime), float64(0)),
+					ghttp.RespondWith(status, "{}"),
+				),
+			)
+		})
+
+		It("send SetGraceTime request", func() {
+			Ω(connection.SetGraceTime(handle, graceTime)).Should(Succeed())
+		})
+
+		Context("when setting grace time fails", func() {
+			BeforeEach(func() {
+				status = 400
+			})
+
+			It("returns an error", func() {
+				Ω(connection.SetGraceTime(handle, graceTime)).ShouldNot(Succeed())
+			})
+		})
+	})
+
+	Describe("Getting container info", func() {
+		var infoResponse garden.ContainerInfo
+
+		JustBeforeEach(func() {
+			infoResponse = garden.ContainerInfo{
+				State: "chilling out",
+				Events: []string{
+					"maxing",
+					"relaxing all cool",
+				},
+				HostIP:        "host-ip",
+				ContainerIP:   "container-ip",
+				ContainerPath: "container-path",
+				ProcessIDs:    []string{"process-handle-1", "process-handle-2"},
+				Properties: garden.Properties{
+					"prop-key": "prop-value",
+				},
+				MappedPorts: []garden.PortMapping{
+					{HostPort: 1234, ContainerPort: 5678},
+					{HostPort: 1235, ContainerPort: 5679},
+				},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/some-handle/info"),
+					ghttp.RespondWith(200, marshalProto(infoResponse))))
+		})
+
+		It("should return the container's info", func() {
+			info, err := connection.Info("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info).Should(Equal(infoResponse))
+		})
+	})
+
+	Describe("Getting a subset of container info", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/some-handle/info", "fields=State%2CContainerIP"),
+					ghttp.RespondWith(200, `{"State":"active","ContainerIP":"container-ip"}`)))
+		})
+
+		It("requests only the given fields", func() {
+			info, err := connection.InfoFields("some-handle", []string{"State", "ContainerIP"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info).Should(Equal(garden.ContainerInfo{
+				State:       "active",
+				ContainerIP: "container-ip",
+			}))
+		})
+	})
+
+	Describe("Getting container info if it has changed", func() {
+		Context("when the version has not changed", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/some-handle/info", "if_none_match=some-version"),
+						ghttp.RespondWith(304, "")))
+			})
+
+			It("returns no info", func() {
+				info, changed, err := connection.InfoIfChanged("some-handle", "some-version")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(changed).Should(BeFalse())
+				Ω(info).Should(BeZero())
+			})
+		})
+
+		Context("when the version has changed", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/some-handle/info", "if_none_match=some-version"),
+						ghttp.RespondWith(200, `{"State":"stopped","Version":"some-other-version"}`)))
+			})
+
+			It("returns the info", func() {
+				info, changed, err := connection.InfoIfChanged("some-handle", "some-version")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(changed).Should(BeTrue())
+				Ω(info).Should(Equal(garden.ContainerInfo{
+					State:   "stopped",
+					Version: "some-other-version",
+				}))
+			})
+		})
+	})
+
+	Describe("BulkInfo", func() {
+
+		expectedBulkInfo := map[string]garden.ContainerInfoEntry{
+			"handle1": garden.ContainerInfoEntry{
+				Info: garden.ContainerInfo{
+					State: "container1state",
+				},
+			},
+			"handle2": garden.ContainerInfoEntry{
+				Info: garden.ContainerInfo{
+					State: "container2state",
+				},
+			},
+		}
+
+		handles := []string{"handle1", "handle2"}
+		queryParams := "handles=" + strings.Join(handles, "%2C")
+
+		Context("when the response is successful", func() {
+			JustBeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/bulk_info", queryParams),
+						ghttp.RespondWith(200, marshalProto(expectedBulkInfo))))
+			})
+
+			It("returns info about containers", func() {
+				bulkInfo, err := connection.BulkInfo(handles)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(bulkInfo).Should(Equal(expectedBulkInfo))
+			})
+		})
+
+		Context("when the request fails", func() {
+			JustBeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/bulk_info", queryParams),
+						ghttp.RespondWith(500, ""),
+					),
+				)
+			})
+
+			It("returns the error", func() {
+				_, err := connection.BulkInfo(handles)
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("when a container is in error state", func() {
+			It("returns the error for the container", func() {
+
+				expectedBulkInfo := map[string]garden.ContainerInfoEntry{
+					"error": garden.ContainerInfoEntry{
+						Err: &garden.Error{Err: errors.New("Oopps")},
+					},
+					"success": garden.ContainerInfoEntry{
+						Info: garden.ContainerInfo{
+							State: "container2state",
+						},
+					},
+				}
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/bulk_info", queryParams),
+						ghttp.RespondWith(200, marshalProto(expectedBulkInfo))))
+
+				bulkInfo, err := connection.BulkInfo(handles)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(bulkInfo).Should(Equal(expectedBulkInfo))
+			})
+		})
+	})
+
+	Describe("ListPortMappings", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/port_mappings"),
+					ghttp.RespondWith(200, `{"handle1":[{"HostPort":61001,"ContainerPort":8080}],"handle2":[]}`)))
+		})
+
+		It("returns the port mappings keyed by handle", func() {
+			mappings, err := connection.ListPortMappings()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(mappings).Should(Equal(map[string][]garden.PortMapping{
+				"handle1": {{HostPort: 61001, ContainerPort: 8080}},
+				"handle2": {},
+			}))
+		})
+	})
+
+	Describe("SetPropertyForAll", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/bulk_properties/deployment_id"),
+					ghttp.VerifyJSONRepresenting(map[string]interface{}{
+						"value":   "some-deployment",
+						"handles": []string{"handle1", "handle2"},
+					}),
+					ghttp.RespondWith(200, `{"handle2":{"Type":"ContainerNotFoundError","Message":"unknown handle: handle2","Handle":"handle2"}}`)))
+		})
+
+		It("returns the error for each container the property could not be set on", func() {
+			errs, err := connection.SetPropertyForAll("deployment_id", "some-deployment", []string{"handle1", "handle2"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(errs).Should(Equal(map[string]error{
+				"handle2": garden.ContainerNotFoundError{Handle: "handle2"},
+			}))
+		})
+	})
+
+	Describe("DestroyByProperties", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/bulk_destroy"),
+					ghttp.VerifyJSONRepresenting(map[string]interface{}{
+						"properties": map[string]string{"app": "some-app"},
+					}),
+					ghttp.RespondWith(200, `{"destroyed":["handle1"],"errors":{"handle2":{"Type":"ContainerNotFoundError","Message":"unknown handle: handle2","Handle":"handle2"}}}`)))
+		})
+
+		It("returns the destroyed handles and the error for each container that could not be destroyed", func() {
+			destroyed, errs, err := connection.DestroyByProperties(garden.Properties{"app": "some-app"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(destroyed).Should(Equal([]string{"handle1"}))
+			Ω(errs).Should(Equal(map[string]error{
+				"handle2": garden.ContainerNotFoundError{Handle: "handle2"},
+			}))
+		})
+	})
+
+	Describe("BulkMetrics", func() {
+
+		expectedBulkMetrics := map[string]garden.ContainerMetricsEntry{
+			"handle1": garden.ContainerMetricsEntry{
+				Metrics: garden.Metrics{
+					DiskStat: garden.ContainerDiskStat{
+						TotalInodesUsed:     4,
+						TotalBytesUsed:      3,
+						ExclusiveBytesUsed:  2,
+						ExclusiveInodesUsed: 1,
+					},
+				},
+			},
+			"handle2": garden.ContainerMetricsEntry{
+				Metrics: garden.Metrics{
+					DiskStat: garden.ContainerDiskStat{
+						TotalInodesUsed:     5,
+						TotalBytesUsed:      6,
+						ExclusiveBytesUsed:  7,
+						ExclusiveInodesUsed: 8,
+					},
+				},
+			},
+		}
+
+		handles := []string{"handle1", "handle2"}
+		queryParams := "handles=" + strings.Join(handles, "%2C")
+
+		Context("when the response is successful", func() {
+			JustBeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/bulk_metrics", queryParams),
+						ghttp.RespondWith(200, marshalProto(expectedBulkMetrics))))
+			})
+
+			It("returns info about containers", func() {
+				bulkMetrics, err := connection.BulkMetrics(handles)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(bulkMetrics).Should(Equal(expectedBulkMetrics))
+			})
+		})
+
+		Context("when the request fails", func() {
+			JustBeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/bulk_metrics", queryParams),
+						ghttp.RespondWith(500, ""),
+					),
+				)
+			})
+
+			It("returns the error", func() {
+				_, err := connection.BulkMetrics(handles)
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("when a container has an error", func() {
+			It("returns the error for the container", func() {
 
 				errorBulkMetrics := map[string]garden.ContainerMetricsEntry{
 					"error": garden.ContainerMetricsEntry{
@@ -826,136 +2137,628 @@ var _ = Describe("Connection", func() {
 								TotalInodesUsed: 1,
 							},
 						},
-					},
-				}
+					},
+				}
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/bulk_metrics", queryParams),
+						ghttp.RespondWith(200, marshalProto(errorBulkMetrics))))
+
+				bulkMetrics, err := connection.BulkMetrics(handles)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(bulkMetrics).To(Equal(errorBulkMetrics))
+			})
+		})
+	})
+
+	Describe("Reading a single file", func() {
+		Context("when the file exists", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/file", "path=%2Fetc%2Fapp.conf"),
+						ghttp.RespondWith(200, "key=value"),
+					),
+				)
+			})
+
+			It("returns its raw contents", func() {
+				content, err := connection.ReadFile("foo-handle", "/etc/app.conf")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(ioutil.ReadAll(content)).Should(Equal([]byte("key=value")))
+			})
+		})
+
+		Context("when the path is a directory", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/file", "path=%2Fetc"),
+						ghttp.RespondWith(400, `{"Type":"IsADirectoryError","Message":"is a directory: /etc","Path":"/etc"}`),
+					),
+				)
+			})
+
+			It("returns an IsADirectoryError", func() {
+				_, err := connection.ReadFile("foo-handle", "/etc")
+				Ω(err).Should(Equal(garden.IsADirectoryError{Path: "/etc"}))
+			})
+		})
+	})
+
+	Describe("Streaming in", func() {
+		Context("when streaming in succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=alice&destination=%2Fbar"),
+						func(w http.ResponseWriter, r *http.Request) {
+							body, err := ioutil.ReadAll(r.Body)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(string(body)).Should(Equal("chunk-1chunk-2"))
+						},
+					),
+				)
+			})
+
+			It("tells garden.to stream, and then streams the content as a series of chunks", func() {
+				buffer := bytes.NewBufferString("chunk-1chunk-2")
+
+				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "alice", Path: "/bar", TarStream: buffer})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when the context is cancelled during the upload", func() {
+			var received chan struct{}
+
+			BeforeEach(func() {
+				received = make(chan struct{})
 
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/bulk_metrics", queryParams),
-						ghttp.RespondWith(200, marshalProto(errorBulkMetrics))))
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=alice&destination=%2Fbar"),
+						func(w http.ResponseWriter, r *http.Request) {
+							chunk := make([]byte, len("chunk-1"))
+							_, err := io.ReadFull(r.Body, chunk)
+							Ω(err).ShouldNot(HaveOccurred())
+							close(received)
 
-				bulkMetrics, err := connection.BulkMetrics(handles)
+							ioutil.ReadAll(r.Body)
+						},
+					),
+				)
+			})
+
+			It("aborts the upload and returns the context's error", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				tarStream, tarStreamW := io.Pipe()
+				defer tarStreamW.Close()
+
+				go tarStreamW.Write([]byte("chunk-1"))
+				go func() {
+					<-received
+					cancel()
+				}()
+
+				err := connection.StreamInContext(ctx, "foo-handle", garden.StreamInSpec{User: "alice", Path: "/bar", TarStream: tarStream})
+				Ω(err).Should(Equal(context.Canceled))
+			})
+		})
+
+		Context("when writing a single file", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/file", "path=%2Fetc%2Fapp.conf&mode=640"),
+						func(w http.ResponseWriter, r *http.Request) {
+							body, err := ioutil.ReadAll(r.Body)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(string(body)).Should(Equal("key=value"))
+						},
+					),
+				)
+			})
+
+			It("streams the raw content with its path and mode", func() {
+				err := connection.WriteFile("foo-handle", "/etc/app.conf", 0640, bytes.NewBufferString("key=value"))
 				Ω(err).ShouldNot(HaveOccurred())
-				Ω(bulkMetrics).To(Equal(errorBulkMetrics))
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when an owner is given", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=alice&owner=1000%3A1001&destination=%2Fbar"),
+						ghttp.RespondWith(200, ""),
+					),
+				)
+			})
+
+			It("sends it along with the stream", func() {
+				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "alice", Owner: "1000:1001", Path: "/bar", TarStream: new(bytes.Buffer)})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when streaming in returns an error response", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=bob&destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+					),
+				)
+			})
+
+			It("returns an error on close", func() {
+				buffer := bytes.NewBufferString("chunk-1chunk-2")
+				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "bob", Path: "/bar", TarStream: buffer})
+				Ω(err).Should(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when the container's disk quota is exceeded", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=bob&destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInsufficientStorage, `{"Type":"QuotaExceededError","Message":"container foo-handle exceeded its byte quota","Handle":"foo-handle","Quota":"byte"}`),
+					),
+				)
+			})
+
+			It("returns a QuotaExceededError", func() {
+				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "bob", Path: "/bar", TarStream: bytes.NewBufferString("chunk")})
+				Ω(err).Should(Equal(garden.QuotaExceededError{Handle: "foo-handle", Quota: garden.DiskQuotaBytes}))
+			})
+		})
+
+		Context("when streaming in fails hard", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=bob&destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+						func(w http.ResponseWriter, r *http.Request) {
+							server.CloseClientConnections()
+						},
+					),
+				)
+			})
+
+			It("returns an error on close", func() {
+				buffer := bytes.NewBufferString("chunk-1chunk-2")
+
+				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "bob", Path: "/bar", TarStream: buffer})
+				Ω(err).Should(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Streaming Out", func() {
+		Context("when streaming succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "user=frank&source=%2Fbar"),
+						ghttp.RespondWith(200, "hello-world!"),
+					),
+				)
+			})
+
+			It("asks garden.for the given file, then reads its content", func() {
+				reader, err := connection.StreamOut("foo-handle", garden.StreamOutSpec{User: "frank", Path: "/bar"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				readBytes, err := ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(readBytes).Should(Equal([]byte("hello-world!")))
+
+				reader.Close()
+			})
+		})
+
+		Context("when include patterns are given", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "include=%2A.log&include=app&source=%2Fbar"),
+						ghttp.RespondWith(200, "filtered"),
+					),
+				)
+			})
+
+			It("asks the server to filter the tar", func() {
+				reader, err := connection.StreamOutFiltered("foo-handle", "/bar", []string{"*.log", "app"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				readBytes, err := ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(readBytes).Should(Equal([]byte("filtered")))
+
+				reader.Close()
+			})
+		})
+
+		Context("when streaming fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "user=deandra&source=%2Fbar"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.Header().Set("Content-Length", "500")
+						},
+					),
+				)
+			})
+
+			It("asks garden.for the given file, then reads its content", func() {
+				reader, err := connection.StreamOut("foo-handle", garden.StreamOutSpec{User: "deandra", Path: "/bar"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = ioutil.ReadAll(reader)
+				reader.Close()
+				Ω(err).Should(HaveOccurred())
 			})
 		})
 	})
 
-	Describe("Streaming in", func() {
-		Context("when streaming in succeeds", func() {
+	Describe("Getting a process's status", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/process-handle/status"),
+					ghttp.RespondWith(200, `{"state":"exited","exit_status":42}`)))
+		})
+
+		It("returns the status", func() {
+			status, err := connection.ProcessStatus("foo-handle", "process-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status).Should(Equal(garden.ProcessStatus{
+				State:      garden.ProcessStateExited,
+				ExitStatus: 42,
+			}))
+		})
+	})
+
+	Describe("Attaching to the output of every process", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/output"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						transport.WriteMessage(conn, garden.TaggedOutput{ProcessID: "p1", Source: garden.OutputSourceStdout, Data: []byte("hello")})
+						transport.WriteMessage(conn, garden.TaggedOutput{ProcessID: "p1", Exited: true, ExitStatus: 3})
+					},
+				),
+			)
+		})
+
+		It("streams each tagged chunk sent by the server and closes when it disconnects", func() {
+			output, _, err := connection.AttachAll("foo-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(output).Should(Receive(Equal(garden.TaggedOutput{ProcessID: "p1", Source: garden.OutputSourceStdout, Data: []byte("hello")})))
+			Eventually(output).Should(Receive(Equal(garden.TaggedOutput{ProcessID: "p1", Exited: true, ExitStatus: 3})))
+			Eventually(output).Should(BeClosed())
+		})
+	})
+
+	Describe("Stopping attaching to the output of every process", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/output"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						for {
+							if err := transport.WriteMessage(conn, garden.TaggedOutput{ProcessID: "p1", Data: []byte("hello")}); err != nil {
+								return
+							}
+						}
+					},
+				),
+			)
+		})
+
+		It("closes the channel, even though it is not being read", func() {
+			output, stop, err := connection.AttachAll("foo-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(output).Should(Receive())
+
+			stop()
+
+			Eventually(output).Should(BeClosed())
+		})
+	})
+
+	Describe("Listing a container's process attachments", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/attachments"),
+					ghttp.RespondWith(200, `[{"process_id":"process-handle","stream_id":"1","remote_addr":"10.0.0.1:1234","attached_at":"2016-01-02T03:04:05Z"}]`)))
+		})
+
+		It("returns the attachments", func() {
+			attachments, err := connection.ProcessAttachments("foo-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(attachments).Should(Equal([]garden.Attachment{
+				{
+					ProcessID:  "process-handle",
+					StreamID:   "1",
+					RemoteAddr: "10.0.0.1:1234",
+					AttachedAt: time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC),
+				},
+			}))
+		})
+	})
+
+	Describe("Streaming an output log", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/output_logs/job.log"),
+					ghttp.RespondWith(200, "hello\n"),
+				),
+			)
+		})
+
+		It("streams the log's content", func() {
+			reader, err := connection.StreamOutputLog("foo-handle", "job.log")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer reader.Close()
+
+			Ω(ioutil.ReadAll(reader)).Should(Equal([]byte("hello\n")))
+		})
+	})
+
+	Describe("Listing the egress rules of every container", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/net/out"),
+					ghttp.RespondWith(200, `{"foo-handle":{"container_ip":"10.0.0.2","default_action":"deny","rules":[{"protocol":1}]}}`),
+				),
+			)
+		})
+
+		It("returns each container's policy and IP", func() {
+			rules, err := connection.AllEgressRules()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rules).Should(Equal(map[string]garden.ContainerEgressRules{
+				"foo-handle": {
+					ContainerIP:   "10.0.0.2",
+					DefaultAction: garden.NetworkActionDeny,
+					Rules:         []garden.NetOutRule{{Protocol: garden.ProtocolTCP}},
+				},
+			}))
+		})
+	})
+
+	Describe("Fetching the raw server info", func() {
+		Context("when every response can be fetched", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=alice&destination=%2Fbar"),
-						func(w http.ResponseWriter, r *http.Request) {
-							body, err := ioutil.ReadAll(r.Body)
-							Ω(err).ShouldNot(HaveOccurred())
-
-							Ω(string(body)).Should(Equal("chunk-1chunk-2"))
-						},
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.RespondWith(200, "{}"),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/capacity"),
+						ghttp.RespondWith(200, `{"memory_in_bytes": 1024, "max_containers": "lots"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/features"),
+						ghttp.RespondWith(200, `{"clone": true, "some_future_feature": {"enabled": true}}`),
 					),
 				)
 			})
 
-			It("tells garden.to stream, and then streams the content as a series of chunks", func() {
-				buffer := bytes.NewBufferString("chunk-1chunk-2")
-
-				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "alice", Path: "/bar", TarStream: buffer})
+			It("returns them as generic JSON, even where they would not decode into garden's types", func() {
+				info, err := connection.RawServerInfo()
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+				Ω(info).Should(Equal(map[string]interface{}{
+					"ping":     map[string]interface{}{},
+					"capacity": map[string]interface{}{"memory_in_bytes": float64(1024), "max_containers": "lots"},
+					"features": map[string]interface{}{"clone": true, "some_future_feature": map[string]interface{}{"enabled": true}},
+				}))
 			})
 		})
 
-		Context("when streaming in returns an error response", func() {
+		Context("when some responses cannot be fetched", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=bob&destination=%2Fbar"),
-						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.RespondWith(200, "{}"),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/capacity"),
+						ghttp.RespondWith(500, "not json"),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/features"),
+						ghttp.RespondWith(200, "{}"),
 					),
 				)
 			})
 
-			It("returns an error on close", func() {
-				buffer := bytes.NewBufferString("chunk-1chunk-2")
-				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "bob", Path: "/bar", TarStream: buffer})
-				Ω(err).Should(HaveOccurred())
+			It("replaces them with their error", func() {
+				info, err := connection.RawServerInfo()
+				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+				Ω(info["capacity"]).Should(ContainSubstring("bad response"))
+				Ω(info["features"]).Should(Equal(map[string]interface{}{}))
 			})
 		})
 
-		Context("when streaming in fails hard", func() {
+		Context("when no response can be fetched", func() {
 			BeforeEach(func() {
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "user=bob&destination=%2Fbar"),
-						ghttp.RespondWith(http.StatusInternalServerError, "no."),
-						func(w http.ResponseWriter, r *http.Request) {
-							server.CloseClientConnections()
-						},
-					),
-				)
+				server.Close()
 			})
 
-			It("returns an error on close", func() {
-				buffer := bytes.NewBufferString("chunk-1chunk-2")
-
-				err := connection.StreamIn("foo-handle", garden.StreamInSpec{User: "bob", Path: "/bar", TarStream: buffer})
+			It("returns an error", func() {
+				_, err := connection.RawServerInfo()
 				Ω(err).Should(HaveOccurred())
-
-				Ω(server.ReceivedRequests()).Should(HaveLen(1))
 			})
 		})
 	})
 
-	Describe("Streaming Out", func() {
-		Context("when streaming succeeds", func() {
+	Describe("Inspecting a process's environment", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/process-handle/env"),
+					ghttp.RespondWith(200, marshalProto([]string{"PATH=/bin", "DEBUG=1"})),
+				),
+			)
+		})
+
+		It("returns the process's environment", func() {
+			env, err := connection.ProcessEnv("foo-handle", "process-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(env).Should(Equal([]string{"PATH=/bin", "DEBUG=1"}))
+		})
+	})
+
+	Describe("Getting the spec a container was created with", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/spec"),
+					ghttp.RespondWith(200, marshalProto(garden.ContainerSpec{
+						Handle:     "foo-handle",
+						RootFSPath: "docker:///busybox",
+						Properties: garden.Properties{"app": "web"},
+					})),
+				),
+			)
+		})
+
+		It("returns the spec", func() {
+			spec, err := connection.Spec("foo-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(spec).Should(Equal(garden.ContainerSpec{
+				Handle:     "foo-handle",
+				RootFSPath: "docker:///busybox",
+				Properties: garden.Properties{"app": "web"},
+			}))
+		})
+	})
+
+	Describe("Waiting for every process in a container to exit", func() {
+		Context("when they all exit", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "user=frank&source=%2Fbar"),
-						ghttp.RespondWith(200, "hello-world!"),
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/wait", "timeout=1m30s"),
+						ghttp.RespondWith(200, "{}"),
 					),
 				)
 			})
 
-			It("asks garden.for the given file, then reads its content", func() {
-				reader, err := connection.StreamOut("foo-handle", garden.StreamOutSpec{User: "frank", Path: "/bar"})
-				Ω(err).ShouldNot(HaveOccurred())
-
-				readBytes, err := ioutil.ReadAll(reader)
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(readBytes).Should(Equal([]byte("hello-world!")))
-
-				reader.Close()
+			It("returns no error", func() {
+				Ω(connection.WaitForProcesses("foo-handle", 90*time.Second)).Should(Succeed())
 			})
 		})
 
-		Context("when streaming fails", func() {
+		Context("when some are still running after the timeout", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "user=deandra&source=%2Fbar"),
-						func(w http.ResponseWriter, r *http.Request) {
-							w.Header().Set("Content-Length", "500")
-						},
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/wait", "timeout=1s"),
+						ghttp.RespondWith(http.StatusRequestTimeout, marshalProto(garden.Error{Err: garden.ProcessesRunningError{
+							Handle:     "foo-handle",
+							ProcessIDs: []string{"process-handle"},
+						}})),
 					),
 				)
 			})
 
-			It("asks garden.for the given file, then reads its content", func() {
-				reader, err := connection.StreamOut("foo-handle", garden.StreamOutSpec{User: "deandra", Path: "/bar"})
-				Ω(err).ShouldNot(HaveOccurred())
-
-				_, err = ioutil.ReadAll(reader)
-				reader.Close()
-				Ω(err).Should(HaveOccurred())
+			It("returns a ProcessesRunningError", func() {
+				err := connection.WaitForProcesses("foo-handle", time.Second)
+				Ω(err).Should(Equal(garden.ProcessesRunningError{
+					Handle:     "foo-handle",
+					ProcessIDs: []string{"process-handle"},
+				}))
 			})
 		})
 	})
 
+	Describe("Fetching a process's logs since a time", func() {
+		since := time.Date(2016, 1, 2, 3, 4, 5, 600, time.UTC)
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/process-handle/logs", "since=2016-01-02T03:04:05.0000006Z"),
+					ghttp.RespondWith(200, `{"output":"aGVsbG8K","cursor":5}`),
+				),
+			)
+		})
+
+		It("streams the logs written since then", func() {
+			logs, err := connection.ProcessLogsSince("foo-handle", "process-handle", since)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer logs.Close()
+
+			Ω(ioutil.ReadAll(logs)).Should(Equal([]byte("hello\n")))
+		})
+	})
+
+	Describe("Fetching a process's logs from a cursor", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/process-handle/logs", "cursor=3"),
+					ghttp.RespondWith(200, `{"output":"aGVsbG8K","cursor":5,"truncated":true}`),
+				),
+			)
+		})
+
+		It("returns the logs written from then on, and the next cursor", func() {
+			log, err := connection.ProcessLogs("foo-handle", "process-handle", 3)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(log).Should(Equal(garden.ProcessLog{
+				Output:    []byte("hello\n"),
+				Cursor:    5,
+				Truncated: true,
+			}))
+		})
+	})
+
 	Describe("Running", func() {
 		var (
 			spec         garden.ProcessSpec
@@ -965,11 +2768,13 @@ var _ = Describe("Connection", func() {
 		Context("when streaming succeeds to completion", func() {
 			BeforeEach(func() {
 				spec = garden.ProcessSpec{
-					Path:   "lol",
-					Args:   []string{"arg1", "arg2"},
-					Dir:    "/some/dir",
-					User:   "root",
-					Limits: resourceLimits,
+					Path:                "lol",
+					Args:                []string{"arg1", "arg2"},
+					Dir:                 "/some/dir",
+					User:                "root",
+					SupplementaryGroups: []int{10, 44},
+					Limits:              resourceLimits,
+					Capabilities:        []string{"NET_ADMIN"},
 				}
 				stdInContent = make(chan string)
 
@@ -1371,7 +3176,7 @@ var _ = Describe("Connection", func() {
 			})
 
 			Describe("waiting on the process", func() {
-				It("returns an error", func() {
+				It("returns ErrDisconnected", func() {
 					process, err := connection.Run("foo-handle", garden.ProcessSpec{
 						Path: "lol",
 						Args: []string{"arg1", "arg2"},
@@ -1381,11 +3186,87 @@ var _ = Describe("Connection", func() {
 					Ω(err).ShouldNot(HaveOccurred())
 
 					_, err = process.Wait()
-					Ω(err).Should(HaveOccurred())
+					Ω(err).Should(Equal(ErrDisconnected))
 				})
 			})
 		})
 
+		Context("when the connection closes immediately after sending the exit status", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							transport.WriteMessage(conn, map[string]interface{}{
+								"process_id": "process-handle",
+								"stream_id":  "123",
+							})
+							transport.WriteMessage(conn, map[string]interface{}{
+								"process_id":  "process-handle",
+								"exit_status": 7,
+							})
+
+							conn.Close()
+						},
+					),
+					emptyStdoutStream("foo-handle", "process-handle", 123),
+					emptyStderrStream("foo-handle", "process-handle", 123),
+				)
+			})
+
+			It("returns the exit status without an error", func() {
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{
+					Path: "lol",
+				}, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(7))
+			})
+		})
+
+		Context("when the connection is closed in the middle of a payload", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							transport.WriteMessage(conn, map[string]interface{}{
+								"process_id": "process-handle",
+								"stream_id":  "123",
+							})
+							conn.Write([]byte(`{"process_id":"process-handle","exit_st`))
+
+							conn.Close()
+						},
+					),
+					emptyStdoutStream("foo-handle", "process-handle", 123),
+					emptyStderrStream("foo-handle", "process-handle", 123),
+				)
+			})
+
+			It("returns a decode error", func() {
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{
+					Path: "lol",
+				}, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = process.Wait()
+				Ω(err).Should(MatchError(ContainSubstring("connection: decode failed")))
+			})
+		})
+
 		Context("when the connection returns an error payload", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
@@ -1588,6 +3469,110 @@ var _ = Describe("Connection", func() {
 			})
 		})
 
+		Context("when writing the process's stdout fails", func() {
+			var (
+				stdinPayloads chan map[string]interface{}
+				exit          chan struct{}
+				stdoutDrained chan struct{}
+			)
+
+			BeforeEach(func() {
+				stdinPayloads = make(chan map[string]interface{}, 2)
+				exit = make(chan struct{})
+				stdoutDrained = make(chan struct{})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/process-handle"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+							defer conn.Close()
+
+							transport.WriteMessage(conn, map[string]interface{}{
+								"process_id": "process-handle",
+								"stream_id":  "123",
+							})
+
+							go func() {
+								decoder := json.NewDecoder(br)
+								for {
+									var payload map[string]interface{}
+									if err := decoder.Decode(&payload); err != nil {
+										return
+									}
+
+									stdinPayloads <- payload
+								}
+							}()
+
+							<-exit
+
+							transport.WriteMessage(conn, map[string]interface{}{
+								"process_id":  "process-handle",
+								"exit_status": 0,
+							})
+						},
+					),
+					stdoutStream("foo-handle", "process-handle", 123, func(conn net.Conn) {
+						// more than the socket buffers hold, so that this only
+						// finishes if the client keeps reading
+						chunk := bytes.Repeat([]byte("x"), 64*1024)
+						for i := 0; i < 64; i++ {
+							conn.Write(chunk)
+						}
+
+						close(stdoutDrained)
+					}),
+					stderrStream("foo-handle", "process-handle", 123, func(conn net.Conn) {
+						conn.Write([]byte("stderr data"))
+					}),
+				)
+			})
+
+			AfterEach(func() {
+				close(exit)
+			})
+
+			It("keeps reading stdout, and keeps delivering stdin and stderr", func() {
+				stdinR, stdinW := io.Pipe()
+				stderr := gbytes.NewBuffer()
+
+				_, err := connection.Attach("foo-handle", "process-handle", garden.ProcessIO{
+					Stdin:  stdinR,
+					Stdout: failingWriter{},
+					Stderr: stderr,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(stdoutDrained).Should(BeClosed())
+				Eventually(stderr).Should(gbytes.Say("stderr data"))
+
+				stdinW.Write([]byte("more stdin"))
+				Eventually(stdinPayloads).Should(Receive(HaveKeyWithValue("data", "more stdin")))
+			})
+
+			Context("when the streams are coupled", func() {
+				It("closes the process's stdin", func() {
+					stdinR, _ := io.Pipe()
+
+					_, err := connection.Attach("foo-handle", "process-handle", garden.ProcessIO{
+						Stdin:          stdinR,
+						Stdout:         failingWriter{},
+						CoupledStreams: true,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(stdinPayloads).Should(Receive(Equal(map[string]interface{}{
+						"process_id": "process-handle",
+						"source":     float64(transport.Stdin),
+					})))
+				})
+			})
+		})
+
 		Context("when the connection returns an error payload", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
@@ -1731,6 +3716,12 @@ func marshalProto(messages ...interface{}) string {
 	return result.String()
 }
 
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("failed to write")
+}
+
 func emptyStdoutStream(handle, processid string, attachid int) http.HandlerFunc {
 	return stdoutStream(handle, processid, attachid, func(net.Conn) {})
 }
@@ -2,12 +2,17 @@
 package connectionfakes
 
 import (
+	"context"
 	"io"
+	"net/http"
+	"net/url"
+	"os"
 	"sync"
 	"time"
 
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/garden/client/connection"
+	"github.com/tedsuo/rata"
 )
 
 type FakeConnection struct {
@@ -24,6 +29,75 @@ type FakeConnection struct {
 		result1 garden.Capacity
 		result2 error
 	}
+	SetDrainModeStub        func(draining bool) error
+	setDrainModeMutex       sync.RWMutex
+	setDrainModeArgsForCall []struct {
+		draining bool
+	}
+	setDrainModeReturns struct {
+		result1 error
+	}
+	WatchCapacityStub        func() (<-chan garden.Capacity, func(), error)
+	watchCapacityMutex       sync.RWMutex
+	watchCapacityArgsForCall []struct{}
+	watchCapacityReturns     struct {
+		result1 <-chan garden.Capacity
+		result2 func()
+		result3 error
+	}
+	WatchOOMsStub        func() (<-chan garden.OOMEvent, func(), error)
+	watchOOMsMutex       sync.RWMutex
+	watchOOMsArgsForCall []struct{}
+	watchOOMsReturns     struct {
+		result1 <-chan garden.OOMEvent
+		result2 func()
+		result3 error
+	}
+	FeaturesStub        func() (garden.FeatureSet, error)
+	featuresMutex       sync.RWMutex
+	featuresArgsForCall []struct{}
+	featuresReturns     struct {
+		result1 garden.FeatureSet
+		result2 error
+	}
+	ValidateRootFSStub        func(rootfs string) error
+	validateRootFSMutex       sync.RWMutex
+	validateRootFSArgsForCall []struct {
+		rootfs string
+	}
+	validateRootFSReturns struct {
+		result1 error
+	}
+	RawServerInfoStub        func() (map[string]interface{}, error)
+	rawServerInfoMutex       sync.RWMutex
+	rawServerInfoArgsForCall []struct{}
+	rawServerInfoReturns     struct {
+		result1 map[string]interface{}
+		result2 error
+	}
+	SelftestStub        func() (garden.SelftestResult, error)
+	selftestMutex       sync.RWMutex
+	selftestArgsForCall []struct{}
+	selftestReturns     struct {
+		result1 garden.SelftestResult
+		result2 error
+	}
+	HealthStub        func() (garden.HealthStatus, error)
+	healthMutex       sync.RWMutex
+	healthArgsForCall []struct{}
+	healthReturns     struct {
+		result1 garden.HealthStatus
+		result2 error
+	}
+	ContainerForHostPIDStub        func(pid int) (string, error)
+	containerForHostPIDMutex       sync.RWMutex
+	containerForHostPIDArgsForCall []struct {
+		pid int
+	}
+	containerForHostPIDReturns struct {
+		result1 string
+		result2 error
+	}
 	CreateStub        func(spec garden.ContainerSpec) (string, error)
 	createMutex       sync.RWMutex
 	createArgsForCall []struct {
@@ -42,6 +116,27 @@ type FakeConnection struct {
 		result1 []string
 		result2 error
 	}
+	ListByStateStub        func(state garden.ContainerState) ([]string, error)
+	listByStateMutex       sync.RWMutex
+	listByStateArgsForCall []struct {
+		state garden.ContainerState
+	}
+	listByStateReturns struct {
+		result1 []string
+		result2 error
+	}
+	ListPageStub        func(properties garden.Properties, cursor string, limit int) ([]string, string, error)
+	listPageMutex       sync.RWMutex
+	listPageArgsForCall []struct {
+		properties garden.Properties
+		cursor     string
+		limit      int
+	}
+	listPageReturns struct {
+		result1 []string
+		result2 string
+		result3 error
+	}
 	DestroyStub        func(handle string) error
 	destroyMutex       sync.RWMutex
 	destroyArgsForCall []struct {
@@ -50,6 +145,25 @@ type FakeConnection struct {
 	destroyReturns struct {
 		result1 error
 	}
+	DestroyByPropertiesStub        func(properties garden.Properties) ([]string, map[string]error, error)
+	destroyByPropertiesMutex       sync.RWMutex
+	destroyByPropertiesArgsForCall []struct {
+		properties garden.Properties
+	}
+	destroyByPropertiesReturns struct {
+		result1 []string
+		result2 map[string]error
+		result3 error
+	}
+	RenameStub        func(oldHandle, newHandle string) error
+	renameMutex       sync.RWMutex
+	renameArgsForCall []struct {
+		oldHandle string
+		newHandle string
+	}
+	renameReturns struct {
+		result1 error
+	}
 	StopStub        func(handle string, kill bool) error
 	stopMutex       sync.RWMutex
 	stopArgsForCall []struct {
@@ -59,6 +173,32 @@ type FakeConnection struct {
 	stopReturns struct {
 		result1 error
 	}
+	CheckpointStub        func(handle, imagePath string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		handle    string
+		imagePath string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
+	RestoreStub        func(handle, imagePath string) error
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		handle    string
+		imagePath string
+	}
+	restoreReturns struct {
+		result1 error
+	}
+	SyncStub        func(handle string) error
+	syncMutex       sync.RWMutex
+	syncArgsForCall []struct {
+		handle string
+	}
+	syncReturns struct {
+		result1 error
+	}
 	InfoStub        func(handle string) (garden.ContainerInfo, error)
 	infoMutex       sync.RWMutex
 	infoArgsForCall []struct {
@@ -68,6 +208,27 @@ type FakeConnection struct {
 		result1 garden.ContainerInfo
 		result2 error
 	}
+	InfoFieldsStub        func(handle string, fields []string) (garden.ContainerInfo, error)
+	infoFieldsMutex       sync.RWMutex
+	infoFieldsArgsForCall []struct {
+		handle string
+		fields []string
+	}
+	infoFieldsReturns struct {
+		result1 garden.ContainerInfo
+		result2 error
+	}
+	InfoIfChangedStub        func(handle, version string) (garden.ContainerInfo, bool, error)
+	infoIfChangedMutex       sync.RWMutex
+	infoIfChangedArgsForCall []struct {
+		handle  string
+		version string
+	}
+	infoIfChangedReturns struct {
+		result1 garden.ContainerInfo
+		result2 bool
+		result3 error
+	}
 	BulkInfoStub        func(handles []string) (map[string]garden.ContainerInfoEntry, error)
 	bulkInfoMutex       sync.RWMutex
 	bulkInfoArgsForCall []struct {
@@ -86,6 +247,24 @@ type FakeConnection struct {
 		result1 map[string]garden.ContainerMetricsEntry
 		result2 error
 	}
+	ListPortMappingsStub        func() (map[string][]garden.PortMapping, error)
+	listPortMappingsMutex       sync.RWMutex
+	listPortMappingsArgsForCall []struct{}
+	listPortMappingsReturns     struct {
+		result1 map[string][]garden.PortMapping
+		result2 error
+	}
+	SetPropertyForAllStub        func(name string, value string, handles []string) (map[string]error, error)
+	setPropertyForAllMutex       sync.RWMutex
+	setPropertyForAllArgsForCall []struct {
+		name    string
+		value   string
+		handles []string
+	}
+	setPropertyForAllReturns struct {
+		result1 map[string]error
+		result2 error
+	}
 	StreamInStub        func(handle string, spec garden.StreamInSpec) error
 	streamInMutex       sync.RWMutex
 	streamInArgsForCall []struct {
@@ -95,6 +274,16 @@ type FakeConnection struct {
 	streamInReturns struct {
 		result1 error
 	}
+	StreamInContextStub        func(ctx context.Context, handle string, spec garden.StreamInSpec) error
+	streamInContextMutex       sync.RWMutex
+	streamInContextArgsForCall []struct {
+		ctx    context.Context
+		handle string
+		spec   garden.StreamInSpec
+	}
+	streamInContextReturns struct {
+		result1 error
+	}
 	StreamOutStub        func(handle string, spec garden.StreamOutSpec) (io.ReadCloser, error)
 	streamOutMutex       sync.RWMutex
 	streamOutArgsForCall []struct {
@@ -105,6 +294,48 @@ type FakeConnection struct {
 		result1 io.ReadCloser
 		result2 error
 	}
+	StreamOutFilteredStub        func(handle string, srcPath string, include []string) (io.ReadCloser, error)
+	streamOutFilteredMutex       sync.RWMutex
+	streamOutFilteredArgsForCall []struct {
+		handle  string
+		srcPath string
+		include []string
+	}
+	streamOutFilteredReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	StreamOutputLogStub        func(handle string, name string) (io.ReadCloser, error)
+	streamOutputLogMutex       sync.RWMutex
+	streamOutputLogArgsForCall []struct {
+		handle string
+		name   string
+	}
+	streamOutputLogReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	WriteFileStub        func(handle string, path string, mode os.FileMode, content io.Reader) error
+	writeFileMutex       sync.RWMutex
+	writeFileArgsForCall []struct {
+		handle  string
+		path    string
+		mode    os.FileMode
+		content io.Reader
+	}
+	writeFileReturns struct {
+		result1 error
+	}
+	ReadFileStub        func(handle string, path string) (io.ReadCloser, error)
+	readFileMutex       sync.RWMutex
+	readFileArgsForCall []struct {
+		handle string
+		path   string
+	}
+	readFileReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
 	CurrentBandwidthLimitsStub        func(handle string) (garden.BandwidthLimits, error)
 	currentBandwidthLimitsMutex       sync.RWMutex
 	currentBandwidthLimitsArgsForCall []struct {
@@ -141,6 +372,35 @@ type FakeConnection struct {
 		result1 garden.MemoryLimits
 		result2 error
 	}
+	CurrentIOLimitsStub        func(handle string) (garden.IOLimits, error)
+	currentIOLimitsMutex       sync.RWMutex
+	currentIOLimitsArgsForCall []struct {
+		handle string
+	}
+	currentIOLimitsReturns struct {
+		result1 garden.IOLimits
+		result2 error
+	}
+	LimitAllStub        func(handle string, limits garden.LimitsUpdate) (garden.Limits, error)
+	limitAllMutex       sync.RWMutex
+	limitAllArgsForCall []struct {
+		handle string
+		limits garden.LimitsUpdate
+	}
+	limitAllReturns struct {
+		result1 garden.Limits
+		result2 error
+	}
+	BoostLimitsStub        func(handle string, limits garden.Limits, duration time.Duration) error
+	boostLimitsMutex       sync.RWMutex
+	boostLimitsArgsForCall []struct {
+		handle   string
+		limits   garden.Limits
+		duration time.Duration
+	}
+	boostLimitsReturns struct {
+		result1 error
+	}
 	RunStub        func(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
@@ -163,6 +423,16 @@ type FakeConnection struct {
 		result1 garden.Process
 		result2 error
 	}
+	AttachAllStub        func(handle string) (<-chan garden.TaggedOutput, func(), error)
+	attachAllMutex       sync.RWMutex
+	attachAllArgsForCall []struct {
+		handle string
+	}
+	attachAllReturns struct {
+		result1 <-chan garden.TaggedOutput
+		result2 func()
+		result3 error
+	}
 	NetInStub        func(handle string, hostPort, containerPort uint32) (uint32, uint32, error)
 	netInMutex       sync.RWMutex
 	netInArgsForCall []struct {
@@ -193,6 +463,42 @@ type FakeConnection struct {
 	bulkNetOutReturns struct {
 		result1 error
 	}
+	NetworkPolicyStub        func(handle string) (garden.NetworkPolicy, error)
+	networkPolicyMutex       sync.RWMutex
+	networkPolicyArgsForCall []struct {
+		handle string
+	}
+	networkPolicyReturns struct {
+		result1 garden.NetworkPolicy
+		result2 error
+	}
+	SetNetworkPolicyStub        func(handle string, policy garden.NetworkPolicy) error
+	setNetworkPolicyMutex       sync.RWMutex
+	setNetworkPolicyArgsForCall []struct {
+		handle string
+		policy garden.NetworkPolicy
+	}
+	setNetworkPolicyReturns struct {
+		result1 error
+	}
+	AllowTrafficStub        func(srcHandle string, dstHandle string) error
+	allowTrafficMutex       sync.RWMutex
+	allowTrafficArgsForCall []struct {
+		srcHandle string
+		dstHandle string
+	}
+	allowTrafficReturns struct {
+		result1 error
+	}
+	DenyTrafficStub        func(srcHandle string, dstHandle string) error
+	denyTrafficMutex       sync.RWMutex
+	denyTrafficArgsForCall []struct {
+		srcHandle string
+		dstHandle string
+	}
+	denyTrafficReturns struct {
+		result1 error
+	}
 	SetGraceTimeStub        func(handle string, graceTime time.Duration) error
 	setGraceTimeMutex       sync.RWMutex
 	setGraceTimeArgsForCall []struct {
@@ -240,6 +546,100 @@ type FakeConnection struct {
 		result1 garden.Metrics
 		result2 error
 	}
+	ResourceUsageStub        func(handle string) (garden.ResourceUsage, error)
+	resourceUsageMutex       sync.RWMutex
+	resourceUsageArgsForCall []struct {
+		handle string
+	}
+	resourceUsageReturns struct {
+		result1 garden.ResourceUsage
+		result2 error
+	}
+	ProcessStatsStub        func(handle string) (map[uint32]garden.ProcessStat, error)
+	processStatsMutex       sync.RWMutex
+	processStatsArgsForCall []struct {
+		handle string
+	}
+	processStatsReturns struct {
+		result1 map[uint32]garden.ProcessStat
+		result2 error
+	}
+	ProcessStatusStub        func(handle string, processID string) (garden.ProcessStatus, error)
+	processStatusMutex       sync.RWMutex
+	processStatusArgsForCall []struct {
+		handle    string
+		processID string
+	}
+	processStatusReturns struct {
+		result1 garden.ProcessStatus
+		result2 error
+	}
+	ProcessLogsSinceStub        func(handle string, processID string, since time.Time) (io.ReadCloser, error)
+	processLogsSinceMutex       sync.RWMutex
+	processLogsSinceArgsForCall []struct {
+		handle    string
+		processID string
+		since     time.Time
+	}
+	processLogsSinceReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	ProcessLogsStub        func(handle string, processID string, cursor uint64) (garden.ProcessLog, error)
+	processLogsMutex       sync.RWMutex
+	processLogsArgsForCall []struct {
+		handle    string
+		processID string
+		cursor    uint64
+	}
+	processLogsReturns struct {
+		result1 garden.ProcessLog
+		result2 error
+	}
+	ProcessEnvStub        func(handle string, processID string) ([]string, error)
+	processEnvMutex       sync.RWMutex
+	processEnvArgsForCall []struct {
+		handle    string
+		processID string
+	}
+	processEnvReturns struct {
+		result1 []string
+		result2 error
+	}
+	SpecStub        func(handle string) (garden.ContainerSpec, error)
+	specMutex       sync.RWMutex
+	specArgsForCall []struct {
+		handle string
+	}
+	specReturns struct {
+		result1 garden.ContainerSpec
+		result2 error
+	}
+	WaitForProcessesStub        func(handle string, timeout time.Duration) error
+	waitForProcessesMutex       sync.RWMutex
+	waitForProcessesArgsForCall []struct {
+		handle  string
+		timeout time.Duration
+	}
+	waitForProcessesReturns struct {
+		result1 error
+	}
+	AllEgressRulesStub        func() (map[string]garden.ContainerEgressRules, error)
+	allEgressRulesMutex       sync.RWMutex
+	allEgressRulesArgsForCall []struct{}
+	allEgressRulesReturns     struct {
+		result1 map[string]garden.ContainerEgressRules
+		result2 error
+	}
+	ProcessAttachmentsStub        func(handle string) ([]garden.Attachment, error)
+	processAttachmentsMutex       sync.RWMutex
+	processAttachmentsArgsForCall []struct {
+		handle string
+	}
+	processAttachmentsReturns struct {
+		result1 []garden.Attachment
+		result2 error
+	}
 	RemovePropertyStub        func(handle string, name string) error
 	removePropertyMutex       sync.RWMutex
 	removePropertyArgsForCall []struct {
@@ -249,6 +649,18 @@ type FakeConnection struct {
 	removePropertyReturns struct {
 		result1 error
 	}
+	DoRequestStub        func(route string, params rata.Params, query url.Values, body io.Reader) (*http.Response, error)
+	doRequestMutex       sync.RWMutex
+	doRequestArgsForCall []struct {
+		route  string
+		params rata.Params
+		query  url.Values
+		body   io.Reader
+	}
+	doRequestReturns struct {
+		result1 *http.Response
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -304,6 +716,264 @@ func (fake *FakeConnection) CapacityReturns(result1 garden.Capacity, result2 err
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) SetDrainMode(draining bool) error {
+	fake.setDrainModeMutex.Lock()
+	fake.setDrainModeArgsForCall = append(fake.setDrainModeArgsForCall, struct {
+		draining bool
+	}{draining})
+	fake.recordInvocation("SetDrainMode", []interface{}{draining})
+	fake.setDrainModeMutex.Unlock()
+	if fake.SetDrainModeStub != nil {
+		return fake.SetDrainModeStub(draining)
+	} else {
+		return fake.setDrainModeReturns.result1
+	}
+}
+
+func (fake *FakeConnection) SetDrainModeCallCount() int {
+	fake.setDrainModeMutex.RLock()
+	defer fake.setDrainModeMutex.RUnlock()
+	return len(fake.setDrainModeArgsForCall)
+}
+
+func (fake *FakeConnection) SetDrainModeArgsForCall(i int) bool {
+	fake.setDrainModeMutex.RLock()
+	defer fake.setDrainModeMutex.RUnlock()
+	return fake.setDrainModeArgsForCall[i].draining
+}
+
+func (fake *FakeConnection) SetDrainModeReturns(result1 error) {
+	fake.SetDrainModeStub = nil
+	fake.setDrainModeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) WatchCapacity() (<-chan garden.Capacity, func(), error) {
+	fake.watchCapacityMutex.Lock()
+	fake.watchCapacityArgsForCall = append(fake.watchCapacityArgsForCall, struct{}{})
+	fake.recordInvocation("WatchCapacity", []interface{}{})
+	fake.watchCapacityMutex.Unlock()
+	if fake.WatchCapacityStub != nil {
+		return fake.WatchCapacityStub()
+	} else {
+		return fake.watchCapacityReturns.result1, fake.watchCapacityReturns.result2, fake.watchCapacityReturns.result3
+	}
+}
+
+func (fake *FakeConnection) WatchCapacityCallCount() int {
+	fake.watchCapacityMutex.RLock()
+	defer fake.watchCapacityMutex.RUnlock()
+	return len(fake.watchCapacityArgsForCall)
+}
+
+func (fake *FakeConnection) WatchCapacityReturns(result1 <-chan garden.Capacity, result2 func(), result3 error) {
+	fake.WatchCapacityStub = nil
+	fake.watchCapacityReturns = struct {
+		result1 <-chan garden.Capacity
+		result2 func()
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeConnection) WatchOOMs() (<-chan garden.OOMEvent, func(), error) {
+	fake.watchOOMsMutex.Lock()
+	fake.watchOOMsArgsForCall = append(fake.watchOOMsArgsForCall, struct{}{})
+	fake.recordInvocation("WatchOOMs", []interface{}{})
+	fake.watchOOMsMutex.Unlock()
+	if fake.WatchOOMsStub != nil {
+		return fake.WatchOOMsStub()
+	} else {
+		return fake.watchOOMsReturns.result1, fake.watchOOMsReturns.result2, fake.watchOOMsReturns.result3
+	}
+}
+
+func (fake *FakeConnection) WatchOOMsCallCount() int {
+	fake.watchOOMsMutex.RLock()
+	defer fake.watchOOMsMutex.RUnlock()
+	return len(fake.watchOOMsArgsForCall)
+}
+
+func (fake *FakeConnection) WatchOOMsReturns(result1 <-chan garden.OOMEvent, result2 func(), result3 error) {
+	fake.WatchOOMsStub = nil
+	fake.watchOOMsReturns = struct {
+		result1 <-chan garden.OOMEvent
+		result2 func()
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeConnection) Features() (garden.FeatureSet, error) {
+	fake.featuresMutex.Lock()
+	fake.featuresArgsForCall = append(fake.featuresArgsForCall, struct{}{})
+	fake.recordInvocation("Features", []interface{}{})
+	fake.featuresMutex.Unlock()
+	if fake.FeaturesStub != nil {
+		return fake.FeaturesStub()
+	} else {
+		return fake.featuresReturns.result1, fake.featuresReturns.result2
+	}
+}
+
+func (fake *FakeConnection) FeaturesCallCount() int {
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	return len(fake.featuresArgsForCall)
+}
+
+func (fake *FakeConnection) FeaturesReturns(result1 garden.FeatureSet, result2 error) {
+	fake.FeaturesStub = nil
+	fake.featuresReturns = struct {
+		result1 garden.FeatureSet
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ValidateRootFS(rootfs string) error {
+	fake.validateRootFSMutex.Lock()
+	fake.validateRootFSArgsForCall = append(fake.validateRootFSArgsForCall, struct {
+		rootfs string
+	}{rootfs})
+	fake.recordInvocation("ValidateRootFS", []interface{}{rootfs})
+	fake.validateRootFSMutex.Unlock()
+	if fake.ValidateRootFSStub != nil {
+		return fake.ValidateRootFSStub(rootfs)
+	} else {
+		return fake.validateRootFSReturns.result1
+	}
+}
+
+func (fake *FakeConnection) ValidateRootFSCallCount() int {
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
+	return len(fake.validateRootFSArgsForCall)
+}
+
+func (fake *FakeConnection) ValidateRootFSArgsForCall(i int) string {
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
+	return fake.validateRootFSArgsForCall[i].rootfs
+}
+
+func (fake *FakeConnection) ValidateRootFSReturns(result1 error) {
+	fake.ValidateRootFSStub = nil
+	fake.validateRootFSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) RawServerInfo() (map[string]interface{}, error) {
+	fake.rawServerInfoMutex.Lock()
+	fake.rawServerInfoArgsForCall = append(fake.rawServerInfoArgsForCall, struct{}{})
+	fake.recordInvocation("RawServerInfo", []interface{}{})
+	fake.rawServerInfoMutex.Unlock()
+	if fake.RawServerInfoStub != nil {
+		return fake.RawServerInfoStub()
+	} else {
+		return fake.rawServerInfoReturns.result1, fake.rawServerInfoReturns.result2
+	}
+}
+
+func (fake *FakeConnection) RawServerInfoCallCount() int {
+	fake.rawServerInfoMutex.RLock()
+	defer fake.rawServerInfoMutex.RUnlock()
+	return len(fake.rawServerInfoArgsForCall)
+}
+
+func (fake *FakeConnection) RawServerInfoReturns(result1 map[string]interface{}, result2 error) {
+	fake.RawServerInfoStub = nil
+	fake.rawServerInfoReturns = struct {
+		result1 map[string]interface{}
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Selftest() (garden.SelftestResult, error) {
+	fake.selftestMutex.Lock()
+	fake.selftestArgsForCall = append(fake.selftestArgsForCall, struct{}{})
+	fake.recordInvocation("Selftest", []interface{}{})
+	fake.selftestMutex.Unlock()
+	if fake.SelftestStub != nil {
+		return fake.SelftestStub()
+	} else {
+		return fake.selftestReturns.result1, fake.selftestReturns.result2
+	}
+}
+
+func (fake *FakeConnection) SelftestCallCount() int {
+	fake.selftestMutex.RLock()
+	defer fake.selftestMutex.RUnlock()
+	return len(fake.selftestArgsForCall)
+}
+
+func (fake *FakeConnection) SelftestReturns(result1 garden.SelftestResult, result2 error) {
+	fake.SelftestStub = nil
+	fake.selftestReturns = struct {
+		result1 garden.SelftestResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Health() (garden.HealthStatus, error) {
+	fake.healthMutex.Lock()
+	fake.healthArgsForCall = append(fake.healthArgsForCall, struct{}{})
+	fake.recordInvocation("Health", []interface{}{})
+	fake.healthMutex.Unlock()
+	if fake.HealthStub != nil {
+		return fake.HealthStub()
+	} else {
+		return fake.healthReturns.result1, fake.healthReturns.result2
+	}
+}
+
+func (fake *FakeConnection) HealthCallCount() int {
+	fake.healthMutex.RLock()
+	defer fake.healthMutex.RUnlock()
+	return len(fake.healthArgsForCall)
+}
+
+func (fake *FakeConnection) HealthReturns(result1 garden.HealthStatus, result2 error) {
+	fake.HealthStub = nil
+	fake.healthReturns = struct {
+		result1 garden.HealthStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ContainerForHostPID(pid int) (string, error) {
+	fake.containerForHostPIDMutex.Lock()
+	fake.containerForHostPIDArgsForCall = append(fake.containerForHostPIDArgsForCall, struct {
+		pid int
+	}{pid})
+	fake.recordInvocation("ContainerForHostPID", []interface{}{pid})
+	fake.containerForHostPIDMutex.Unlock()
+	if fake.ContainerForHostPIDStub != nil {
+		return fake.ContainerForHostPIDStub(pid)
+	} else {
+		return fake.containerForHostPIDReturns.result1, fake.containerForHostPIDReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ContainerForHostPIDCallCount() int {
+	fake.containerForHostPIDMutex.RLock()
+	defer fake.containerForHostPIDMutex.RUnlock()
+	return len(fake.containerForHostPIDArgsForCall)
+}
+
+func (fake *FakeConnection) ContainerForHostPIDArgsForCall(i int) int {
+	fake.containerForHostPIDMutex.RLock()
+	defer fake.containerForHostPIDMutex.RUnlock()
+	return fake.containerForHostPIDArgsForCall[i].pid
+}
+
+func (fake *FakeConnection) ContainerForHostPIDReturns(result1 string, result2 error) {
+	fake.ContainerForHostPIDStub = nil
+	fake.containerForHostPIDReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) Create(spec garden.ContainerSpec) (string, error) {
 	fake.createMutex.Lock()
 	fake.createArgsForCall = append(fake.createArgsForCall, struct {
@@ -372,6 +1042,77 @@ func (fake *FakeConnection) ListReturns(result1 []string, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) ListByState(state garden.ContainerState) ([]string, error) {
+	fake.listByStateMutex.Lock()
+	fake.listByStateArgsForCall = append(fake.listByStateArgsForCall, struct {
+		state garden.ContainerState
+	}{state})
+	fake.recordInvocation("ListByState", []interface{}{state})
+	fake.listByStateMutex.Unlock()
+	if fake.ListByStateStub != nil {
+		return fake.ListByStateStub(state)
+	} else {
+		return fake.listByStateReturns.result1, fake.listByStateReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ListByStateCallCount() int {
+	fake.listByStateMutex.RLock()
+	defer fake.listByStateMutex.RUnlock()
+	return len(fake.listByStateArgsForCall)
+}
+
+func (fake *FakeConnection) ListByStateArgsForCall(i int) garden.ContainerState {
+	fake.listByStateMutex.RLock()
+	defer fake.listByStateMutex.RUnlock()
+	return fake.listByStateArgsForCall[i].state
+}
+
+func (fake *FakeConnection) ListByStateReturns(result1 []string, result2 error) {
+	fake.ListByStateStub = nil
+	fake.listByStateReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ListPage(properties garden.Properties, cursor string, limit int) ([]string, string, error) {
+	fake.listPageMutex.Lock()
+	fake.listPageArgsForCall = append(fake.listPageArgsForCall, struct {
+		properties garden.Properties
+		cursor     string
+		limit      int
+	}{properties, cursor, limit})
+	fake.recordInvocation("ListPage", []interface{}{properties, cursor, limit})
+	fake.listPageMutex.Unlock()
+	if fake.ListPageStub != nil {
+		return fake.ListPageStub(properties, cursor, limit)
+	} else {
+		return fake.listPageReturns.result1, fake.listPageReturns.result2, fake.listPageReturns.result3
+	}
+}
+
+func (fake *FakeConnection) ListPageCallCount() int {
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
+	return len(fake.listPageArgsForCall)
+}
+
+func (fake *FakeConnection) ListPageArgsForCall(i int) (garden.Properties, string, int) {
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
+	return fake.listPageArgsForCall[i].properties, fake.listPageArgsForCall[i].cursor, fake.listPageArgsForCall[i].limit
+}
+
+func (fake *FakeConnection) ListPageReturns(result1 []string, result2 string, result3 error) {
+	fake.ListPageStub = nil
+	fake.listPageReturns = struct {
+		result1 []string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeConnection) Destroy(handle string) error {
 	fake.destroyMutex.Lock()
 	fake.destroyArgsForCall = append(fake.destroyArgsForCall, struct {
@@ -405,6 +1146,75 @@ func (fake *FakeConnection) DestroyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) DestroyByProperties(properties garden.Properties) ([]string, map[string]error, error) {
+	fake.destroyByPropertiesMutex.Lock()
+	fake.destroyByPropertiesArgsForCall = append(fake.destroyByPropertiesArgsForCall, struct {
+		properties garden.Properties
+	}{properties})
+	fake.recordInvocation("DestroyByProperties", []interface{}{properties})
+	fake.destroyByPropertiesMutex.Unlock()
+	if fake.DestroyByPropertiesStub != nil {
+		return fake.DestroyByPropertiesStub(properties)
+	} else {
+		return fake.destroyByPropertiesReturns.result1, fake.destroyByPropertiesReturns.result2, fake.destroyByPropertiesReturns.result3
+	}
+}
+
+func (fake *FakeConnection) DestroyByPropertiesCallCount() int {
+	fake.destroyByPropertiesMutex.RLock()
+	defer fake.destroyByPropertiesMutex.RUnlock()
+	return len(fake.destroyByPropertiesArgsForCall)
+}
+
+func (fake *FakeConnection) DestroyByPropertiesArgsForCall(i int) garden.Properties {
+	fake.destroyByPropertiesMutex.RLock()
+	defer fake.destroyByPropertiesMutex.RUnlock()
+	return fake.destroyByPropertiesArgsForCall[i].properties
+}
+
+func (fake *FakeConnection) DestroyByPropertiesReturns(result1 []string, result2 map[string]error, result3 error) {
+	fake.DestroyByPropertiesStub = nil
+	fake.destroyByPropertiesReturns = struct {
+		result1 []string
+		result2 map[string]error
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeConnection) Rename(oldHandle string, newHandle string) error {
+	fake.renameMutex.Lock()
+	fake.renameArgsForCall = append(fake.renameArgsForCall, struct {
+		oldHandle string
+		newHandle string
+	}{oldHandle, newHandle})
+	fake.recordInvocation("Rename", []interface{}{oldHandle, newHandle})
+	fake.renameMutex.Unlock()
+	if fake.RenameStub != nil {
+		return fake.RenameStub(oldHandle, newHandle)
+	} else {
+		return fake.renameReturns.result1
+	}
+}
+
+func (fake *FakeConnection) RenameCallCount() int {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return len(fake.renameArgsForCall)
+}
+
+func (fake *FakeConnection) RenameArgsForCall(i int) (string, string) {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return fake.renameArgsForCall[i].oldHandle, fake.renameArgsForCall[i].newHandle
+}
+
+func (fake *FakeConnection) RenameReturns(result1 error) {
+	fake.RenameStub = nil
+	fake.renameReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConnection) Stop(handle string, kill bool) error {
 	fake.stopMutex.Lock()
 	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
@@ -439,6 +1249,107 @@ func (fake *FakeConnection) StopReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) Checkpoint(handle string, imagePath string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		handle    string
+		imagePath string
+	}{handle, imagePath})
+	fake.recordInvocation("Checkpoint", []interface{}{handle, imagePath})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(handle, imagePath)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeConnection) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeConnection) CheckpointArgsForCall(i int) (string, string) {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].handle, fake.checkpointArgsForCall[i].imagePath
+}
+
+func (fake *FakeConnection) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) Restore(handle string, imagePath string) error {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		handle    string
+		imagePath string
+	}{handle, imagePath})
+	fake.recordInvocation("Restore", []interface{}{handle, imagePath})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(handle, imagePath)
+	} else {
+		return fake.restoreReturns.result1
+	}
+}
+
+func (fake *FakeConnection) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeConnection) RestoreArgsForCall(i int) (string, string) {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].handle, fake.restoreArgsForCall[i].imagePath
+}
+
+func (fake *FakeConnection) RestoreReturns(result1 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) Sync(handle string) error {
+	fake.syncMutex.Lock()
+	fake.syncArgsForCall = append(fake.syncArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("Sync", []interface{}{handle})
+	fake.syncMutex.Unlock()
+	if fake.SyncStub != nil {
+		return fake.SyncStub(handle)
+	} else {
+		return fake.syncReturns.result1
+	}
+}
+
+func (fake *FakeConnection) SyncCallCount() int {
+	fake.syncMutex.RLock()
+	defer fake.syncMutex.RUnlock()
+	return len(fake.syncArgsForCall)
+}
+
+func (fake *FakeConnection) SyncArgsForCall(i int) string {
+	fake.syncMutex.RLock()
+	defer fake.syncMutex.RUnlock()
+	return fake.syncArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) SyncReturns(result1 error) {
+	fake.SyncStub = nil
+	fake.syncReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConnection) Info(handle string) (garden.ContainerInfo, error) {
 	fake.infoMutex.Lock()
 	fake.infoArgsForCall = append(fake.infoArgsForCall, struct {
@@ -473,6 +1384,82 @@ func (fake *FakeConnection) InfoReturns(result1 garden.ContainerInfo, result2 er
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) InfoFields(handle string, fields []string) (garden.ContainerInfo, error) {
+	var fieldsCopy []string
+	if fields != nil {
+		fieldsCopy = make([]string, len(fields))
+		copy(fieldsCopy, fields)
+	}
+	fake.infoFieldsMutex.Lock()
+	fake.infoFieldsArgsForCall = append(fake.infoFieldsArgsForCall, struct {
+		handle string
+		fields []string
+	}{handle, fieldsCopy})
+	fake.recordInvocation("InfoFields", []interface{}{handle, fieldsCopy})
+	fake.infoFieldsMutex.Unlock()
+	if fake.InfoFieldsStub != nil {
+		return fake.InfoFieldsStub(handle, fields)
+	} else {
+		return fake.infoFieldsReturns.result1, fake.infoFieldsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) InfoFieldsCallCount() int {
+	fake.infoFieldsMutex.RLock()
+	defer fake.infoFieldsMutex.RUnlock()
+	return len(fake.infoFieldsArgsForCall)
+}
+
+func (fake *FakeConnection) InfoFieldsArgsForCall(i int) (string, []string) {
+	fake.infoFieldsMutex.RLock()
+	defer fake.infoFieldsMutex.RUnlock()
+	return fake.infoFieldsArgsForCall[i].handle, fake.infoFieldsArgsForCall[i].fields
+}
+
+func (fake *FakeConnection) InfoFieldsReturns(result1 garden.ContainerInfo, result2 error) {
+	fake.InfoFieldsStub = nil
+	fake.infoFieldsReturns = struct {
+		result1 garden.ContainerInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) InfoIfChanged(handle string, version string) (garden.ContainerInfo, bool, error) {
+	fake.infoIfChangedMutex.Lock()
+	fake.infoIfChangedArgsForCall = append(fake.infoIfChangedArgsForCall, struct {
+		handle  string
+		version string
+	}{handle, version})
+	fake.recordInvocation("InfoIfChanged", []interface{}{handle, version})
+	fake.infoIfChangedMutex.Unlock()
+	if fake.InfoIfChangedStub != nil {
+		return fake.InfoIfChangedStub(handle, version)
+	} else {
+		return fake.infoIfChangedReturns.result1, fake.infoIfChangedReturns.result2, fake.infoIfChangedReturns.result3
+	}
+}
+
+func (fake *FakeConnection) InfoIfChangedCallCount() int {
+	fake.infoIfChangedMutex.RLock()
+	defer fake.infoIfChangedMutex.RUnlock()
+	return len(fake.infoIfChangedArgsForCall)
+}
+
+func (fake *FakeConnection) InfoIfChangedArgsForCall(i int) (string, string) {
+	fake.infoIfChangedMutex.RLock()
+	defer fake.infoIfChangedMutex.RUnlock()
+	return fake.infoIfChangedArgsForCall[i].handle, fake.infoIfChangedArgsForCall[i].version
+}
+
+func (fake *FakeConnection) InfoIfChangedReturns(result1 garden.ContainerInfo, result2 bool, result3 error) {
+	fake.InfoIfChangedStub = nil
+	fake.infoIfChangedReturns = struct {
+		result1 garden.ContainerInfo
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeConnection) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
 	var handlesCopy []string
 	if handles != nil {
@@ -551,6 +1538,73 @@ func (fake *FakeConnection) BulkMetricsReturns(result1 map[string]garden.Contain
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) ListPortMappings() (map[string][]garden.PortMapping, error) {
+	fake.listPortMappingsMutex.Lock()
+	fake.listPortMappingsArgsForCall = append(fake.listPortMappingsArgsForCall, struct{}{})
+	fake.recordInvocation("ListPortMappings", []interface{}{})
+	fake.listPortMappingsMutex.Unlock()
+	if fake.ListPortMappingsStub != nil {
+		return fake.ListPortMappingsStub()
+	} else {
+		return fake.listPortMappingsReturns.result1, fake.listPortMappingsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ListPortMappingsCallCount() int {
+	fake.listPortMappingsMutex.RLock()
+	defer fake.listPortMappingsMutex.RUnlock()
+	return len(fake.listPortMappingsArgsForCall)
+}
+
+func (fake *FakeConnection) ListPortMappingsReturns(result1 map[string][]garden.PortMapping, result2 error) {
+	fake.ListPortMappingsStub = nil
+	fake.listPortMappingsReturns = struct {
+		result1 map[string][]garden.PortMapping
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) SetPropertyForAll(name string, value string, handles []string) (map[string]error, error) {
+	var handlesCopy []string
+	if handles != nil {
+		handlesCopy = make([]string, len(handles))
+		copy(handlesCopy, handles)
+	}
+	fake.setPropertyForAllMutex.Lock()
+	fake.setPropertyForAllArgsForCall = append(fake.setPropertyForAllArgsForCall, struct {
+		name    string
+		value   string
+		handles []string
+	}{name, value, handlesCopy})
+	fake.recordInvocation("SetPropertyForAll", []interface{}{name, value, handlesCopy})
+	fake.setPropertyForAllMutex.Unlock()
+	if fake.SetPropertyForAllStub != nil {
+		return fake.SetPropertyForAllStub(name, value, handles)
+	} else {
+		return fake.setPropertyForAllReturns.result1, fake.setPropertyForAllReturns.result2
+	}
+}
+
+func (fake *FakeConnection) SetPropertyForAllCallCount() int {
+	fake.setPropertyForAllMutex.RLock()
+	defer fake.setPropertyForAllMutex.RUnlock()
+	return len(fake.setPropertyForAllArgsForCall)
+}
+
+func (fake *FakeConnection) SetPropertyForAllArgsForCall(i int) (string, string, []string) {
+	fake.setPropertyForAllMutex.RLock()
+	defer fake.setPropertyForAllMutex.RUnlock()
+	return fake.setPropertyForAllArgsForCall[i].name, fake.setPropertyForAllArgsForCall[i].value, fake.setPropertyForAllArgsForCall[i].handles
+}
+
+func (fake *FakeConnection) SetPropertyForAllReturns(result1 map[string]error, result2 error) {
+	fake.SetPropertyForAllStub = nil
+	fake.setPropertyForAllReturns = struct {
+		result1 map[string]error
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) StreamIn(handle string, spec garden.StreamInSpec) error {
 	fake.streamInMutex.Lock()
 	fake.streamInArgsForCall = append(fake.streamInArgsForCall, struct {
@@ -585,6 +1639,41 @@ func (fake *FakeConnection) StreamInReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) StreamInContext(ctx context.Context, handle string, spec garden.StreamInSpec) error {
+	fake.streamInContextMutex.Lock()
+	fake.streamInContextArgsForCall = append(fake.streamInContextArgsForCall, struct {
+		ctx    context.Context
+		handle string
+		spec   garden.StreamInSpec
+	}{ctx, handle, spec})
+	fake.recordInvocation("StreamInContext", []interface{}{ctx, handle, spec})
+	fake.streamInContextMutex.Unlock()
+	if fake.StreamInContextStub != nil {
+		return fake.StreamInContextStub(ctx, handle, spec)
+	} else {
+		return fake.streamInContextReturns.result1
+	}
+}
+
+func (fake *FakeConnection) StreamInContextCallCount() int {
+	fake.streamInContextMutex.RLock()
+	defer fake.streamInContextMutex.RUnlock()
+	return len(fake.streamInContextArgsForCall)
+}
+
+func (fake *FakeConnection) StreamInContextArgsForCall(i int) (context.Context, string, garden.StreamInSpec) {
+	fake.streamInContextMutex.RLock()
+	defer fake.streamInContextMutex.RUnlock()
+	return fake.streamInContextArgsForCall[i].ctx, fake.streamInContextArgsForCall[i].handle, fake.streamInContextArgsForCall[i].spec
+}
+
+func (fake *FakeConnection) StreamInContextReturns(result1 error) {
+	fake.StreamInContextStub = nil
+	fake.streamInContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConnection) StreamOut(handle string, spec garden.StreamOutSpec) (io.ReadCloser, error) {
 	fake.streamOutMutex.Lock()
 	fake.streamOutArgsForCall = append(fake.streamOutArgsForCall, struct {
@@ -612,9 +1701,156 @@ func (fake *FakeConnection) StreamOutArgsForCall(i int) (string, garden.StreamOu
 	return fake.streamOutArgsForCall[i].handle, fake.streamOutArgsForCall[i].spec
 }
 
-func (fake *FakeConnection) StreamOutReturns(result1 io.ReadCloser, result2 error) {
-	fake.StreamOutStub = nil
-	fake.streamOutReturns = struct {
+func (fake *FakeConnection) StreamOutReturns(result1 io.ReadCloser, result2 error) {
+	fake.StreamOutStub = nil
+	fake.streamOutReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) StreamOutFiltered(handle string, srcPath string, include []string) (io.ReadCloser, error) {
+	var includeCopy []string
+	if include != nil {
+		includeCopy = make([]string, len(include))
+		copy(includeCopy, include)
+	}
+	fake.streamOutFilteredMutex.Lock()
+	fake.streamOutFilteredArgsForCall = append(fake.streamOutFilteredArgsForCall, struct {
+		handle  string
+		srcPath string
+		include []string
+	}{handle, srcPath, includeCopy})
+	fake.recordInvocation("StreamOutFiltered", []interface{}{handle, srcPath, includeCopy})
+	fake.streamOutFilteredMutex.Unlock()
+	if fake.StreamOutFilteredStub != nil {
+		return fake.StreamOutFilteredStub(handle, srcPath, include)
+	} else {
+		return fake.streamOutFilteredReturns.result1, fake.streamOutFilteredReturns.result2
+	}
+}
+
+func (fake *FakeConnection) StreamOutFilteredCallCount() int {
+	fake.streamOutFilteredMutex.RLock()
+	defer fake.streamOutFilteredMutex.RUnlock()
+	return len(fake.streamOutFilteredArgsForCall)
+}
+
+func (fake *FakeConnection) StreamOutFilteredArgsForCall(i int) (string, string, []string) {
+	fake.streamOutFilteredMutex.RLock()
+	defer fake.streamOutFilteredMutex.RUnlock()
+	return fake.streamOutFilteredArgsForCall[i].handle, fake.streamOutFilteredArgsForCall[i].srcPath, fake.streamOutFilteredArgsForCall[i].include
+}
+
+func (fake *FakeConnection) StreamOutFilteredReturns(result1 io.ReadCloser, result2 error) {
+	fake.StreamOutFilteredStub = nil
+	fake.streamOutFilteredReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) StreamOutputLog(handle string, name string) (io.ReadCloser, error) {
+	fake.streamOutputLogMutex.Lock()
+	fake.streamOutputLogArgsForCall = append(fake.streamOutputLogArgsForCall, struct {
+		handle string
+		name   string
+	}{handle, name})
+	fake.recordInvocation("StreamOutputLog", []interface{}{handle, name})
+	fake.streamOutputLogMutex.Unlock()
+	if fake.StreamOutputLogStub != nil {
+		return fake.StreamOutputLogStub(handle, name)
+	} else {
+		return fake.streamOutputLogReturns.result1, fake.streamOutputLogReturns.result2
+	}
+}
+
+func (fake *FakeConnection) StreamOutputLogCallCount() int {
+	fake.streamOutputLogMutex.RLock()
+	defer fake.streamOutputLogMutex.RUnlock()
+	return len(fake.streamOutputLogArgsForCall)
+}
+
+func (fake *FakeConnection) StreamOutputLogArgsForCall(i int) (string, string) {
+	fake.streamOutputLogMutex.RLock()
+	defer fake.streamOutputLogMutex.RUnlock()
+	return fake.streamOutputLogArgsForCall[i].handle, fake.streamOutputLogArgsForCall[i].name
+}
+
+func (fake *FakeConnection) StreamOutputLogReturns(result1 io.ReadCloser, result2 error) {
+	fake.StreamOutputLogStub = nil
+	fake.streamOutputLogReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) WriteFile(handle string, path string, mode os.FileMode, content io.Reader) error {
+	fake.writeFileMutex.Lock()
+	fake.writeFileArgsForCall = append(fake.writeFileArgsForCall, struct {
+		handle  string
+		path    string
+		mode    os.FileMode
+		content io.Reader
+	}{handle, path, mode, content})
+	fake.recordInvocation("WriteFile", []interface{}{handle, path, mode, content})
+	fake.writeFileMutex.Unlock()
+	if fake.WriteFileStub != nil {
+		return fake.WriteFileStub(handle, path, mode, content)
+	} else {
+		return fake.writeFileReturns.result1
+	}
+}
+
+func (fake *FakeConnection) WriteFileCallCount() int {
+	fake.writeFileMutex.RLock()
+	defer fake.writeFileMutex.RUnlock()
+	return len(fake.writeFileArgsForCall)
+}
+
+func (fake *FakeConnection) WriteFileArgsForCall(i int) (string, string, os.FileMode, io.Reader) {
+	fake.writeFileMutex.RLock()
+	defer fake.writeFileMutex.RUnlock()
+	return fake.writeFileArgsForCall[i].handle, fake.writeFileArgsForCall[i].path, fake.writeFileArgsForCall[i].mode, fake.writeFileArgsForCall[i].content
+}
+
+func (fake *FakeConnection) WriteFileReturns(result1 error) {
+	fake.WriteFileStub = nil
+	fake.writeFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) ReadFile(handle string, path string) (io.ReadCloser, error) {
+	fake.readFileMutex.Lock()
+	fake.readFileArgsForCall = append(fake.readFileArgsForCall, struct {
+		handle string
+		path   string
+	}{handle, path})
+	fake.recordInvocation("ReadFile", []interface{}{handle, path})
+	fake.readFileMutex.Unlock()
+	if fake.ReadFileStub != nil {
+		return fake.ReadFileStub(handle, path)
+	} else {
+		return fake.readFileReturns.result1, fake.readFileReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ReadFileCallCount() int {
+	fake.readFileMutex.RLock()
+	defer fake.readFileMutex.RUnlock()
+	return len(fake.readFileArgsForCall)
+}
+
+func (fake *FakeConnection) ReadFileArgsForCall(i int) (string, string) {
+	fake.readFileMutex.RLock()
+	defer fake.readFileMutex.RUnlock()
+	return fake.readFileArgsForCall[i].handle, fake.readFileArgsForCall[i].path
+}
+
+func (fake *FakeConnection) ReadFileReturns(result1 io.ReadCloser, result2 error) {
+	fake.ReadFileStub = nil
+	fake.readFileReturns = struct {
 		result1 io.ReadCloser
 		result2 error
 	}{result1, result2}
@@ -756,6 +1992,110 @@ func (fake *FakeConnection) CurrentMemoryLimitsReturns(result1 garden.MemoryLimi
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) CurrentIOLimits(handle string) (garden.IOLimits, error) {
+	fake.currentIOLimitsMutex.Lock()
+	fake.currentIOLimitsArgsForCall = append(fake.currentIOLimitsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("CurrentIOLimits", []interface{}{handle})
+	fake.currentIOLimitsMutex.Unlock()
+	if fake.CurrentIOLimitsStub != nil {
+		return fake.CurrentIOLimitsStub(handle)
+	} else {
+		return fake.currentIOLimitsReturns.result1, fake.currentIOLimitsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) CurrentIOLimitsCallCount() int {
+	fake.currentIOLimitsMutex.RLock()
+	defer fake.currentIOLimitsMutex.RUnlock()
+	return len(fake.currentIOLimitsArgsForCall)
+}
+
+func (fake *FakeConnection) CurrentIOLimitsArgsForCall(i int) string {
+	fake.currentIOLimitsMutex.RLock()
+	defer fake.currentIOLimitsMutex.RUnlock()
+	return fake.currentIOLimitsArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) CurrentIOLimitsReturns(result1 garden.IOLimits, result2 error) {
+	fake.CurrentIOLimitsStub = nil
+	fake.currentIOLimitsReturns = struct {
+		result1 garden.IOLimits
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) LimitAll(handle string, limits garden.LimitsUpdate) (garden.Limits, error) {
+	fake.limitAllMutex.Lock()
+	fake.limitAllArgsForCall = append(fake.limitAllArgsForCall, struct {
+		handle string
+		limits garden.LimitsUpdate
+	}{handle, limits})
+	fake.recordInvocation("LimitAll", []interface{}{handle, limits})
+	fake.limitAllMutex.Unlock()
+	if fake.LimitAllStub != nil {
+		return fake.LimitAllStub(handle, limits)
+	} else {
+		return fake.limitAllReturns.result1, fake.limitAllReturns.result2
+	}
+}
+
+func (fake *FakeConnection) LimitAllCallCount() int {
+	fake.limitAllMutex.RLock()
+	defer fake.limitAllMutex.RUnlock()
+	return len(fake.limitAllArgsForCall)
+}
+
+func (fake *FakeConnection) LimitAllArgsForCall(i int) (string, garden.LimitsUpdate) {
+	fake.limitAllMutex.RLock()
+	defer fake.limitAllMutex.RUnlock()
+	return fake.limitAllArgsForCall[i].handle, fake.limitAllArgsForCall[i].limits
+}
+
+func (fake *FakeConnection) LimitAllReturns(result1 garden.Limits, result2 error) {
+	fake.LimitAllStub = nil
+	fake.limitAllReturns = struct {
+		result1 garden.Limits
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) BoostLimits(handle string, limits garden.Limits, duration time.Duration) error {
+	fake.boostLimitsMutex.Lock()
+	fake.boostLimitsArgsForCall = append(fake.boostLimitsArgsForCall, struct {
+		handle   string
+		limits   garden.Limits
+		duration time.Duration
+	}{handle, limits, duration})
+	fake.recordInvocation("BoostLimits", []interface{}{handle, limits, duration})
+	fake.boostLimitsMutex.Unlock()
+	if fake.BoostLimitsStub != nil {
+		return fake.BoostLimitsStub(handle, limits, duration)
+	} else {
+		return fake.boostLimitsReturns.result1
+	}
+}
+
+func (fake *FakeConnection) BoostLimitsCallCount() int {
+	fake.boostLimitsMutex.RLock()
+	defer fake.boostLimitsMutex.RUnlock()
+	return len(fake.boostLimitsArgsForCall)
+}
+
+func (fake *FakeConnection) BoostLimitsArgsForCall(i int) (string, garden.Limits, time.Duration) {
+	fake.boostLimitsMutex.RLock()
+	defer fake.boostLimitsMutex.RUnlock()
+	return fake.boostLimitsArgsForCall[i].handle, fake.boostLimitsArgsForCall[i].limits, fake.boostLimitsArgsForCall[i].duration
+}
+
+func (fake *FakeConnection) BoostLimitsReturns(result1 error) {
+	fake.BoostLimitsStub = nil
+	fake.boostLimitsReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConnection) Run(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
 	fake.runMutex.Lock()
 	fake.runArgsForCall = append(fake.runArgsForCall, struct {
@@ -828,6 +2168,41 @@ func (fake *FakeConnection) AttachReturns(result1 garden.Process, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) AttachAll(handle string) (<-chan garden.TaggedOutput, func(), error) {
+	fake.attachAllMutex.Lock()
+	fake.attachAllArgsForCall = append(fake.attachAllArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("AttachAll", []interface{}{handle})
+	fake.attachAllMutex.Unlock()
+	if fake.AttachAllStub != nil {
+		return fake.AttachAllStub(handle)
+	} else {
+		return fake.attachAllReturns.result1, fake.attachAllReturns.result2, fake.attachAllReturns.result3
+	}
+}
+
+func (fake *FakeConnection) AttachAllCallCount() int {
+	fake.attachAllMutex.RLock()
+	defer fake.attachAllMutex.RUnlock()
+	return len(fake.attachAllArgsForCall)
+}
+
+func (fake *FakeConnection) AttachAllArgsForCall(i int) string {
+	fake.attachAllMutex.RLock()
+	defer fake.attachAllMutex.RUnlock()
+	return fake.attachAllArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) AttachAllReturns(result1 <-chan garden.TaggedOutput, result2 func(), result3 error) {
+	fake.AttachAllStub = nil
+	fake.attachAllReturns = struct {
+		result1 <-chan garden.TaggedOutput
+		result2 func()
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeConnection) NetIn(handle string, hostPort uint32, containerPort uint32) (uint32, uint32, error) {
 	fake.netInMutex.Lock()
 	fake.netInArgsForCall = append(fake.netInArgsForCall, struct {
@@ -938,6 +2313,142 @@ func (fake *FakeConnection) BulkNetOutReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) NetworkPolicy(handle string) (garden.NetworkPolicy, error) {
+	fake.networkPolicyMutex.Lock()
+	fake.networkPolicyArgsForCall = append(fake.networkPolicyArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("NetworkPolicy", []interface{}{handle})
+	fake.networkPolicyMutex.Unlock()
+	if fake.NetworkPolicyStub != nil {
+		return fake.NetworkPolicyStub(handle)
+	} else {
+		return fake.networkPolicyReturns.result1, fake.networkPolicyReturns.result2
+	}
+}
+
+func (fake *FakeConnection) NetworkPolicyCallCount() int {
+	fake.networkPolicyMutex.RLock()
+	defer fake.networkPolicyMutex.RUnlock()
+	return len(fake.networkPolicyArgsForCall)
+}
+
+func (fake *FakeConnection) NetworkPolicyArgsForCall(i int) string {
+	fake.networkPolicyMutex.RLock()
+	defer fake.networkPolicyMutex.RUnlock()
+	return fake.networkPolicyArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) NetworkPolicyReturns(result1 garden.NetworkPolicy, result2 error) {
+	fake.NetworkPolicyStub = nil
+	fake.networkPolicyReturns = struct {
+		result1 garden.NetworkPolicy
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) SetNetworkPolicy(handle string, policy garden.NetworkPolicy) error {
+	fake.setNetworkPolicyMutex.Lock()
+	fake.setNetworkPolicyArgsForCall = append(fake.setNetworkPolicyArgsForCall, struct {
+		handle string
+		policy garden.NetworkPolicy
+	}{handle, policy})
+	fake.recordInvocation("SetNetworkPolicy", []interface{}{handle, policy})
+	fake.setNetworkPolicyMutex.Unlock()
+	if fake.SetNetworkPolicyStub != nil {
+		return fake.SetNetworkPolicyStub(handle, policy)
+	} else {
+		return fake.setNetworkPolicyReturns.result1
+	}
+}
+
+func (fake *FakeConnection) SetNetworkPolicyCallCount() int {
+	fake.setNetworkPolicyMutex.RLock()
+	defer fake.setNetworkPolicyMutex.RUnlock()
+	return len(fake.setNetworkPolicyArgsForCall)
+}
+
+func (fake *FakeConnection) SetNetworkPolicyArgsForCall(i int) (string, garden.NetworkPolicy) {
+	fake.setNetworkPolicyMutex.RLock()
+	defer fake.setNetworkPolicyMutex.RUnlock()
+	return fake.setNetworkPolicyArgsForCall[i].handle, fake.setNetworkPolicyArgsForCall[i].policy
+}
+
+func (fake *FakeConnection) SetNetworkPolicyReturns(result1 error) {
+	fake.SetNetworkPolicyStub = nil
+	fake.setNetworkPolicyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) AllowTraffic(srcHandle string, dstHandle string) error {
+	fake.allowTrafficMutex.Lock()
+	fake.allowTrafficArgsForCall = append(fake.allowTrafficArgsForCall, struct {
+		srcHandle string
+		dstHandle string
+	}{srcHandle, dstHandle})
+	fake.recordInvocation("AllowTraffic", []interface{}{srcHandle, dstHandle})
+	fake.allowTrafficMutex.Unlock()
+	if fake.AllowTrafficStub != nil {
+		return fake.AllowTrafficStub(srcHandle, dstHandle)
+	} else {
+		return fake.allowTrafficReturns.result1
+	}
+}
+
+func (fake *FakeConnection) AllowTrafficCallCount() int {
+	fake.allowTrafficMutex.RLock()
+	defer fake.allowTrafficMutex.RUnlock()
+	return len(fake.allowTrafficArgsForCall)
+}
+
+func (fake *FakeConnection) AllowTrafficArgsForCall(i int) (string, string) {
+	fake.allowTrafficMutex.RLock()
+	defer fake.allowTrafficMutex.RUnlock()
+	return fake.allowTrafficArgsForCall[i].srcHandle, fake.allowTrafficArgsForCall[i].dstHandle
+}
+
+func (fake *FakeConnection) AllowTrafficReturns(result1 error) {
+	fake.AllowTrafficStub = nil
+	fake.allowTrafficReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) DenyTraffic(srcHandle string, dstHandle string) error {
+	fake.denyTrafficMutex.Lock()
+	fake.denyTrafficArgsForCall = append(fake.denyTrafficArgsForCall, struct {
+		srcHandle string
+		dstHandle string
+	}{srcHandle, dstHandle})
+	fake.recordInvocation("DenyTraffic", []interface{}{srcHandle, dstHandle})
+	fake.denyTrafficMutex.Unlock()
+	if fake.DenyTrafficStub != nil {
+		return fake.DenyTrafficStub(srcHandle, dstHandle)
+	} else {
+		return fake.denyTrafficReturns.result1
+	}
+}
+
+func (fake *FakeConnection) DenyTrafficCallCount() int {
+	fake.denyTrafficMutex.RLock()
+	defer fake.denyTrafficMutex.RUnlock()
+	return len(fake.denyTrafficArgsForCall)
+}
+
+func (fake *FakeConnection) DenyTrafficArgsForCall(i int) (string, string) {
+	fake.denyTrafficMutex.RLock()
+	defer fake.denyTrafficMutex.RUnlock()
+	return fake.denyTrafficArgsForCall[i].srcHandle, fake.denyTrafficArgsForCall[i].dstHandle
+}
+
+func (fake *FakeConnection) DenyTrafficReturns(result1 error) {
+	fake.DenyTrafficStub = nil
+	fake.denyTrafficReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConnection) SetGraceTime(handle string, graceTime time.Duration) error {
 	fake.setGraceTimeMutex.Lock()
 	fake.setGraceTimeArgsForCall = append(fake.setGraceTimeArgsForCall, struct {
@@ -1110,6 +2621,344 @@ func (fake *FakeConnection) MetricsReturns(result1 garden.Metrics, result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) ResourceUsage(handle string) (garden.ResourceUsage, error) {
+	fake.resourceUsageMutex.Lock()
+	fake.resourceUsageArgsForCall = append(fake.resourceUsageArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("ResourceUsage", []interface{}{handle})
+	fake.resourceUsageMutex.Unlock()
+	if fake.ResourceUsageStub != nil {
+		return fake.ResourceUsageStub(handle)
+	} else {
+		return fake.resourceUsageReturns.result1, fake.resourceUsageReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ResourceUsageCallCount() int {
+	fake.resourceUsageMutex.RLock()
+	defer fake.resourceUsageMutex.RUnlock()
+	return len(fake.resourceUsageArgsForCall)
+}
+
+func (fake *FakeConnection) ResourceUsageArgsForCall(i int) string {
+	fake.resourceUsageMutex.RLock()
+	defer fake.resourceUsageMutex.RUnlock()
+	return fake.resourceUsageArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) ResourceUsageReturns(result1 garden.ResourceUsage, result2 error) {
+	fake.ResourceUsageStub = nil
+	fake.resourceUsageReturns = struct {
+		result1 garden.ResourceUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ProcessStats(handle string) (map[uint32]garden.ProcessStat, error) {
+	fake.processStatsMutex.Lock()
+	fake.processStatsArgsForCall = append(fake.processStatsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("ProcessStats", []interface{}{handle})
+	fake.processStatsMutex.Unlock()
+	if fake.ProcessStatsStub != nil {
+		return fake.ProcessStatsStub(handle)
+	} else {
+		return fake.processStatsReturns.result1, fake.processStatsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessStatsCallCount() int {
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
+	return len(fake.processStatsArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessStatsArgsForCall(i int) string {
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
+	return fake.processStatsArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) ProcessStatsReturns(result1 map[uint32]garden.ProcessStat, result2 error) {
+	fake.ProcessStatsStub = nil
+	fake.processStatsReturns = struct {
+		result1 map[uint32]garden.ProcessStat
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ProcessStatus(handle string, processID string) (garden.ProcessStatus, error) {
+	fake.processStatusMutex.Lock()
+	fake.processStatusArgsForCall = append(fake.processStatusArgsForCall, struct {
+		handle    string
+		processID string
+	}{handle, processID})
+	fake.recordInvocation("ProcessStatus", []interface{}{handle, processID})
+	fake.processStatusMutex.Unlock()
+	if fake.ProcessStatusStub != nil {
+		return fake.ProcessStatusStub(handle, processID)
+	} else {
+		return fake.processStatusReturns.result1, fake.processStatusReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessStatusCallCount() int {
+	fake.processStatusMutex.RLock()
+	defer fake.processStatusMutex.RUnlock()
+	return len(fake.processStatusArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessStatusArgsForCall(i int) (string, string) {
+	fake.processStatusMutex.RLock()
+	defer fake.processStatusMutex.RUnlock()
+	return fake.processStatusArgsForCall[i].handle, fake.processStatusArgsForCall[i].processID
+}
+
+func (fake *FakeConnection) ProcessStatusReturns(result1 garden.ProcessStatus, result2 error) {
+	fake.ProcessStatusStub = nil
+	fake.processStatusReturns = struct {
+		result1 garden.ProcessStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ProcessLogsSince(handle string, processID string, since time.Time) (io.ReadCloser, error) {
+	fake.processLogsSinceMutex.Lock()
+	fake.processLogsSinceArgsForCall = append(fake.processLogsSinceArgsForCall, struct {
+		handle    string
+		processID string
+		since     time.Time
+	}{handle, processID, since})
+	fake.recordInvocation("ProcessLogsSince", []interface{}{handle, processID, since})
+	fake.processLogsSinceMutex.Unlock()
+	if fake.ProcessLogsSinceStub != nil {
+		return fake.ProcessLogsSinceStub(handle, processID, since)
+	} else {
+		return fake.processLogsSinceReturns.result1, fake.processLogsSinceReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessLogsSinceCallCount() int {
+	fake.processLogsSinceMutex.RLock()
+	defer fake.processLogsSinceMutex.RUnlock()
+	return len(fake.processLogsSinceArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessLogsSinceArgsForCall(i int) (string, string, time.Time) {
+	fake.processLogsSinceMutex.RLock()
+	defer fake.processLogsSinceMutex.RUnlock()
+	return fake.processLogsSinceArgsForCall[i].handle, fake.processLogsSinceArgsForCall[i].processID, fake.processLogsSinceArgsForCall[i].since
+}
+
+func (fake *FakeConnection) ProcessLogsSinceReturns(result1 io.ReadCloser, result2 error) {
+	fake.ProcessLogsSinceStub = nil
+	fake.processLogsSinceReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ProcessLogs(handle string, processID string, cursor uint64) (garden.ProcessLog, error) {
+	fake.processLogsMutex.Lock()
+	fake.processLogsArgsForCall = append(fake.processLogsArgsForCall, struct {
+		handle    string
+		processID string
+		cursor    uint64
+	}{handle, processID, cursor})
+	fake.recordInvocation("ProcessLogs", []interface{}{handle, processID, cursor})
+	fake.processLogsMutex.Unlock()
+	if fake.ProcessLogsStub != nil {
+		return fake.ProcessLogsStub(handle, processID, cursor)
+	} else {
+		return fake.processLogsReturns.result1, fake.processLogsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessLogsCallCount() int {
+	fake.processLogsMutex.RLock()
+	defer fake.processLogsMutex.RUnlock()
+	return len(fake.processLogsArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessLogsArgsForCall(i int) (string, string, uint64) {
+	fake.processLogsMutex.RLock()
+	defer fake.processLogsMutex.RUnlock()
+	return fake.processLogsArgsForCall[i].handle, fake.processLogsArgsForCall[i].processID, fake.processLogsArgsForCall[i].cursor
+}
+
+func (fake *FakeConnection) ProcessLogsReturns(result1 garden.ProcessLog, result2 error) {
+	fake.ProcessLogsStub = nil
+	fake.processLogsReturns = struct {
+		result1 garden.ProcessLog
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ProcessEnv(handle string, processID string) ([]string, error) {
+	fake.processEnvMutex.Lock()
+	fake.processEnvArgsForCall = append(fake.processEnvArgsForCall, struct {
+		handle    string
+		processID string
+	}{handle, processID})
+	fake.recordInvocation("ProcessEnv", []interface{}{handle, processID})
+	fake.processEnvMutex.Unlock()
+	if fake.ProcessEnvStub != nil {
+		return fake.ProcessEnvStub(handle, processID)
+	} else {
+		return fake.processEnvReturns.result1, fake.processEnvReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessEnvCallCount() int {
+	fake.processEnvMutex.RLock()
+	defer fake.processEnvMutex.RUnlock()
+	return len(fake.processEnvArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessEnvArgsForCall(i int) (string, string) {
+	fake.processEnvMutex.RLock()
+	defer fake.processEnvMutex.RUnlock()
+	return fake.processEnvArgsForCall[i].handle, fake.processEnvArgsForCall[i].processID
+}
+
+func (fake *FakeConnection) ProcessEnvReturns(result1 []string, result2 error) {
+	fake.ProcessEnvStub = nil
+	fake.processEnvReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Spec(handle string) (garden.ContainerSpec, error) {
+	fake.specMutex.Lock()
+	fake.specArgsForCall = append(fake.specArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("Spec", []interface{}{handle})
+	fake.specMutex.Unlock()
+	if fake.SpecStub != nil {
+		return fake.SpecStub(handle)
+	} else {
+		return fake.specReturns.result1, fake.specReturns.result2
+	}
+}
+
+func (fake *FakeConnection) SpecCallCount() int {
+	fake.specMutex.RLock()
+	defer fake.specMutex.RUnlock()
+	return len(fake.specArgsForCall)
+}
+
+func (fake *FakeConnection) SpecArgsForCall(i int) string {
+	fake.specMutex.RLock()
+	defer fake.specMutex.RUnlock()
+	return fake.specArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) SpecReturns(result1 garden.ContainerSpec, result2 error) {
+	fake.SpecStub = nil
+	fake.specReturns = struct {
+		result1 garden.ContainerSpec
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) WaitForProcesses(handle string, timeout time.Duration) error {
+	fake.waitForProcessesMutex.Lock()
+	fake.waitForProcessesArgsForCall = append(fake.waitForProcessesArgsForCall, struct {
+		handle  string
+		timeout time.Duration
+	}{handle, timeout})
+	fake.recordInvocation("WaitForProcesses", []interface{}{handle, timeout})
+	fake.waitForProcessesMutex.Unlock()
+	if fake.WaitForProcessesStub != nil {
+		return fake.WaitForProcessesStub(handle, timeout)
+	} else {
+		return fake.waitForProcessesReturns.result1
+	}
+}
+
+func (fake *FakeConnection) WaitForProcessesCallCount() int {
+	fake.waitForProcessesMutex.RLock()
+	defer fake.waitForProcessesMutex.RUnlock()
+	return len(fake.waitForProcessesArgsForCall)
+}
+
+func (fake *FakeConnection) WaitForProcessesArgsForCall(i int) (string, time.Duration) {
+	fake.waitForProcessesMutex.RLock()
+	defer fake.waitForProcessesMutex.RUnlock()
+	return fake.waitForProcessesArgsForCall[i].handle, fake.waitForProcessesArgsForCall[i].timeout
+}
+
+func (fake *FakeConnection) WaitForProcessesReturns(result1 error) {
+	fake.WaitForProcessesStub = nil
+	fake.waitForProcessesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) AllEgressRules() (map[string]garden.ContainerEgressRules, error) {
+	fake.allEgressRulesMutex.Lock()
+	fake.allEgressRulesArgsForCall = append(fake.allEgressRulesArgsForCall, struct{}{})
+	fake.recordInvocation("AllEgressRules", []interface{}{})
+	fake.allEgressRulesMutex.Unlock()
+	if fake.AllEgressRulesStub != nil {
+		return fake.AllEgressRulesStub()
+	} else {
+		return fake.allEgressRulesReturns.result1, fake.allEgressRulesReturns.result2
+	}
+}
+
+func (fake *FakeConnection) AllEgressRulesCallCount() int {
+	fake.allEgressRulesMutex.RLock()
+	defer fake.allEgressRulesMutex.RUnlock()
+	return len(fake.allEgressRulesArgsForCall)
+}
+
+func (fake *FakeConnection) AllEgressRulesReturns(result1 map[string]garden.ContainerEgressRules, result2 error) {
+	fake.AllEgressRulesStub = nil
+	fake.allEgressRulesReturns = struct {
+		result1 map[string]garden.ContainerEgressRules
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ProcessAttachments(handle string) ([]garden.Attachment, error) {
+	fake.processAttachmentsMutex.Lock()
+	fake.processAttachmentsArgsForCall = append(fake.processAttachmentsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.recordInvocation("ProcessAttachments", []interface{}{handle})
+	fake.processAttachmentsMutex.Unlock()
+	if fake.ProcessAttachmentsStub != nil {
+		return fake.ProcessAttachmentsStub(handle)
+	} else {
+		return fake.processAttachmentsReturns.result1, fake.processAttachmentsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessAttachmentsCallCount() int {
+	fake.processAttachmentsMutex.RLock()
+	defer fake.processAttachmentsMutex.RUnlock()
+	return len(fake.processAttachmentsArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessAttachmentsArgsForCall(i int) string {
+	fake.processAttachmentsMutex.RLock()
+	defer fake.processAttachmentsMutex.RUnlock()
+	return fake.processAttachmentsArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) ProcessAttachmentsReturns(result1 []garden.Attachment, result2 error) {
+	fake.ProcessAttachmentsStub = nil
+	fake.processAttachmentsReturns = struct {
+		result1 []garden.Attachment
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) RemoveProperty(handle string, name string) error {
 	fake.removePropertyMutex.Lock()
 	fake.removePropertyArgsForCall = append(fake.removePropertyArgsForCall, struct {
@@ -1144,6 +2993,43 @@ func (fake *FakeConnection) RemovePropertyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) DoRequest(route string, params rata.Params, query url.Values, body io.Reader) (*http.Response, error) {
+	fake.doRequestMutex.Lock()
+	fake.doRequestArgsForCall = append(fake.doRequestArgsForCall, struct {
+		route  string
+		params rata.Params
+		query  url.Values
+		body   io.Reader
+	}{route, params, query, body})
+	fake.recordInvocation("DoRequest", []interface{}{route, params, query, body})
+	fake.doRequestMutex.Unlock()
+	if fake.DoRequestStub != nil {
+		return fake.DoRequestStub(route, params, query, body)
+	} else {
+		return fake.doRequestReturns.result1, fake.doRequestReturns.result2
+	}
+}
+
+func (fake *FakeConnection) DoRequestCallCount() int {
+	fake.doRequestMutex.RLock()
+	defer fake.doRequestMutex.RUnlock()
+	return len(fake.doRequestArgsForCall)
+}
+
+func (fake *FakeConnection) DoRequestArgsForCall(i int) (string, rata.Params, url.Values, io.Reader) {
+	fake.doRequestMutex.RLock()
+	defer fake.doRequestMutex.RUnlock()
+	return fake.doRequestArgsForCall[i].route, fake.doRequestArgsForCall[i].params, fake.doRequestArgsForCall[i].query, fake.doRequestArgsForCall[i].body
+}
+
+func (fake *FakeConnection) DoRequestReturns(result1 *http.Response, result2 error) {
+	fake.DoRequestStub = nil
+	fake.doRequestReturns = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -1151,24 +3037,74 @@ func (fake *FakeConnection) Invocations() map[string][][]interface{} {
 	defer fake.pingMutex.RUnlock()
 	fake.capacityMutex.RLock()
 	defer fake.capacityMutex.RUnlock()
+	fake.setDrainModeMutex.RLock()
+	defer fake.setDrainModeMutex.RUnlock()
+	fake.watchCapacityMutex.RLock()
+	defer fake.watchCapacityMutex.RUnlock()
+	fake.watchOOMsMutex.RLock()
+	defer fake.watchOOMsMutex.RUnlock()
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
+	fake.rawServerInfoMutex.RLock()
+	defer fake.rawServerInfoMutex.RUnlock()
+	fake.selftestMutex.RLock()
+	defer fake.selftestMutex.RUnlock()
+	fake.healthMutex.RLock()
+	defer fake.healthMutex.RUnlock()
+	fake.containerForHostPIDMutex.RLock()
+	defer fake.containerForHostPIDMutex.RUnlock()
 	fake.createMutex.RLock()
 	defer fake.createMutex.RUnlock()
 	fake.listMutex.RLock()
 	defer fake.listMutex.RUnlock()
+	fake.listByStateMutex.RLock()
+	defer fake.listByStateMutex.RUnlock()
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
 	fake.destroyMutex.RLock()
 	defer fake.destroyMutex.RUnlock()
+	fake.destroyByPropertiesMutex.RLock()
+	defer fake.destroyByPropertiesMutex.RUnlock()
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
 	fake.stopMutex.RLock()
 	defer fake.stopMutex.RUnlock()
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	fake.syncMutex.RLock()
+	defer fake.syncMutex.RUnlock()
 	fake.infoMutex.RLock()
 	defer fake.infoMutex.RUnlock()
+	fake.infoFieldsMutex.RLock()
+	defer fake.infoFieldsMutex.RUnlock()
+	fake.infoIfChangedMutex.RLock()
+	defer fake.infoIfChangedMutex.RUnlock()
 	fake.bulkInfoMutex.RLock()
 	defer fake.bulkInfoMutex.RUnlock()
 	fake.bulkMetricsMutex.RLock()
 	defer fake.bulkMetricsMutex.RUnlock()
+	fake.listPortMappingsMutex.RLock()
+	defer fake.listPortMappingsMutex.RUnlock()
+	fake.setPropertyForAllMutex.RLock()
+	defer fake.setPropertyForAllMutex.RUnlock()
 	fake.streamInMutex.RLock()
 	defer fake.streamInMutex.RUnlock()
+	fake.streamInContextMutex.RLock()
+	defer fake.streamInContextMutex.RUnlock()
 	fake.streamOutMutex.RLock()
 	defer fake.streamOutMutex.RUnlock()
+	fake.streamOutFilteredMutex.RLock()
+	defer fake.streamOutFilteredMutex.RUnlock()
+	fake.streamOutputLogMutex.RLock()
+	defer fake.streamOutputLogMutex.RUnlock()
+	fake.writeFileMutex.RLock()
+	defer fake.writeFileMutex.RUnlock()
+	fake.readFileMutex.RLock()
+	defer fake.readFileMutex.RUnlock()
 	fake.currentBandwidthLimitsMutex.RLock()
 	defer fake.currentBandwidthLimitsMutex.RUnlock()
 	fake.currentCPULimitsMutex.RLock()
@@ -1177,16 +3113,32 @@ func (fake *FakeConnection) Invocations() map[string][][]interface{} {
 	defer fake.currentDiskLimitsMutex.RUnlock()
 	fake.currentMemoryLimitsMutex.RLock()
 	defer fake.currentMemoryLimitsMutex.RUnlock()
+	fake.currentIOLimitsMutex.RLock()
+	defer fake.currentIOLimitsMutex.RUnlock()
+	fake.limitAllMutex.RLock()
+	defer fake.limitAllMutex.RUnlock()
+	fake.boostLimitsMutex.RLock()
+	defer fake.boostLimitsMutex.RUnlock()
 	fake.runMutex.RLock()
 	defer fake.runMutex.RUnlock()
 	fake.attachMutex.RLock()
 	defer fake.attachMutex.RUnlock()
+	fake.attachAllMutex.RLock()
+	defer fake.attachAllMutex.RUnlock()
 	fake.netInMutex.RLock()
 	defer fake.netInMutex.RUnlock()
 	fake.netOutMutex.RLock()
 	defer fake.netOutMutex.RUnlock()
 	fake.bulkNetOutMutex.RLock()
 	defer fake.bulkNetOutMutex.RUnlock()
+	fake.networkPolicyMutex.RLock()
+	defer fake.networkPolicyMutex.RUnlock()
+	fake.setNetworkPolicyMutex.RLock()
+	defer fake.setNetworkPolicyMutex.RUnlock()
+	fake.allowTrafficMutex.RLock()
+	defer fake.allowTrafficMutex.RUnlock()
+	fake.denyTrafficMutex.RLock()
+	defer fake.denyTrafficMutex.RUnlock()
 	fake.setGraceTimeMutex.RLock()
 	defer fake.setGraceTimeMutex.RUnlock()
 	fake.propertiesMutex.RLock()
@@ -1197,8 +3149,30 @@ func (fake *FakeConnection) Invocations() map[string][][]interface{} {
 	defer fake.setPropertyMutex.RUnlock()
 	fake.metricsMutex.RLock()
 	defer fake.metricsMutex.RUnlock()
+	fake.resourceUsageMutex.RLock()
+	defer fake.resourceUsageMutex.RUnlock()
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
+	fake.processStatusMutex.RLock()
+	defer fake.processStatusMutex.RUnlock()
+	fake.processLogsSinceMutex.RLock()
+	defer fake.processLogsSinceMutex.RUnlock()
+	fake.processLogsMutex.RLock()
+	defer fake.processLogsMutex.RUnlock()
+	fake.processEnvMutex.RLock()
+	defer fake.processEnvMutex.RUnlock()
+	fake.specMutex.RLock()
+	defer fake.specMutex.RUnlock()
+	fake.waitForProcessesMutex.RLock()
+	defer fake.waitForProcessesMutex.RUnlock()
+	fake.allEgressRulesMutex.RLock()
+	defer fake.allEgressRulesMutex.RUnlock()
+	fake.processAttachmentsMutex.RLock()
+	defer fake.processAttachmentsMutex.RUnlock()
 	fake.removePropertyMutex.RLock()
 	defer fake.removePropertyMutex.RUnlock()
+	fake.doRequestMutex.RLock()
+	defer fake.doRequestMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -5,6 +5,7 @@ import (
 	"bufio"
 	"io"
 	"net"
+	"net/http"
 	"net/url"
 	"sync"
 
@@ -40,6 +41,19 @@ type FakeHijackStreamer struct {
 		result2 *bufio.Reader
 		result3 error
 	}
+	DoStub        func(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (*http.Response, error)
+	doMutex       sync.RWMutex
+	doArgsForCall []struct {
+		handler     string
+		body        io.Reader
+		params      rata.Params
+		query       url.Values
+		contentType string
+	}
+	doReturns struct {
+		result1 *http.Response
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -121,6 +135,44 @@ func (fake *FakeHijackStreamer) HijackReturns(result1 net.Conn, result2 *bufio.R
 	}{result1, result2, result3}
 }
 
+func (fake *FakeHijackStreamer) Do(handler string, body io.Reader, params rata.Params, query url.Values, contentType string) (*http.Response, error) {
+	fake.doMutex.Lock()
+	fake.doArgsForCall = append(fake.doArgsForCall, struct {
+		handler     string
+		body        io.Reader
+		params      rata.Params
+		query       url.Values
+		contentType string
+	}{handler, body, params, query, contentType})
+	fake.recordInvocation("Do", []interface{}{handler, body, params, query, contentType})
+	fake.doMutex.Unlock()
+	if fake.DoStub != nil {
+		return fake.DoStub(handler, body, params, query, contentType)
+	} else {
+		return fake.doReturns.result1, fake.doReturns.result2
+	}
+}
+
+func (fake *FakeHijackStreamer) DoCallCount() int {
+	fake.doMutex.RLock()
+	defer fake.doMutex.RUnlock()
+	return len(fake.doArgsForCall)
+}
+
+func (fake *FakeHijackStreamer) DoArgsForCall(i int) (string, io.Reader, rata.Params, url.Values, string) {
+	fake.doMutex.RLock()
+	defer fake.doMutex.RUnlock()
+	return fake.doArgsForCall[i].handler, fake.doArgsForCall[i].body, fake.doArgsForCall[i].params, fake.doArgsForCall[i].query, fake.doArgsForCall[i].contentType
+}
+
+func (fake *FakeHijackStreamer) DoReturns(result1 *http.Response, result2 error) {
+	fake.DoStub = nil
+	fake.doReturns = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeHijackStreamer) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -128,6 +180,8 @@ func (fake *FakeHijackStreamer) Invocations() map[string][][]interface{} {
 	defer fake.streamMutex.RUnlock()
 	fake.hijackMutex.RLock()
 	defer fake.hijackMutex.RUnlock()
+	fake.doMutex.RLock()
+	defer fake.doMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -0,0 +1,92 @@
+package connection
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// http2ProbeTimeout bounds how long the server is given to answer in HTTP/2
+// before it is taken to speak only HTTP/1.
+const http2ProbeTimeout = 2 * time.Second
+
+// http2Transport sends requests multiplexed over a single HTTP/2 connection
+// to a server which speaks it, and over HTTP/1 to one which does not. There
+// is no TLS, and so no ALPN, to negotiate with, so the first request probes
+// the server by opening an HTTP/2 connection with prior knowledge and
+// pinging it; the answer is remembered from then on. A server which only
+// speaks HTTP/1 sees the probe as a PRI request, which it rejects.
+type http2Transport struct {
+	dialer DialerFunc
+	http1  http.RoundTripper
+	http2  *http2.Transport
+
+	mu         sync.Mutex
+	negotiated bool
+	supported  bool
+}
+
+func newHTTP2Transport(dialer DialerFunc, http1 http.RoundTripper) *http2Transport {
+	return &http2Transport{
+		dialer: dialer,
+		http1:  http1,
+		http2: &http2.Transport{
+			// the requests are plain http://, which is spoken in HTTP/2 over
+			// the unencrypted connection the dialer returns
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer(network, addr)
+			},
+		},
+	}
+}
+
+func (t *http2Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if t.speaksHTTP2() {
+		return t.http2.RoundTrip(request)
+	}
+
+	return t.http1.RoundTrip(request)
+}
+
+// speaksHTTP2 reports whether the server speaks HTTP/2, probing it if that is
+// not yet known. Calls wait for a probe in progress rather than start their
+// own. A server which cannot be reached is probed again next time.
+func (t *http2Transport) speaksHTTP2() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.negotiated {
+		conn, err := t.dialer("tcp", "api") // net/addr don't matter here
+		if err != nil {
+			return false
+		}
+
+		t.negotiated = true
+		t.supported = t.probe(conn)
+	}
+
+	return t.supported
+}
+
+func (t *http2Transport) probe(conn net.Conn) bool {
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(http2ProbeTimeout))
+
+	clientConn, err := t.http2.NewClientConn(conn)
+	if err != nil {
+		return false
+	}
+	defer clientConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), http2ProbeTimeout)
+	defer cancel()
+
+	return clientConn.Ping(ctx) == nil
+}
@@ -1,64 +1,29 @@
 package connection
 
 import (
-	"net"
-	"sync"
-
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/garden/transport"
 )
 
 type processStream struct {
 	processID string
-	conn      net.Conn
-
-	sync.Mutex
+	codec     *transport.ProcessStreamCodec
 }
 
 func (s *processStream) Write(data []byte) (int, error) {
-	d := string(data)
-	stdin := transport.Stdin
-	return len(data), s.sendPayload(transport.ProcessPayload{
-		ProcessID: s.processID,
-		Source:    &stdin,
-		Data:      &d,
-	})
+	return len(data), s.codec.EncodeStdin(s.processID, data)
 }
 
 func (s *processStream) Close() error {
-	stdin := transport.Stdin
-	return s.sendPayload(transport.ProcessPayload{
-		ProcessID: s.processID,
-		Source:    &stdin,
-	})
+	return s.codec.EncodeStdinEOF(s.processID)
 }
 
 func (s *processStream) SetTTY(spec garden.TTYSpec) error {
-	return s.sendPayload(&transport.ProcessPayload{
-		ProcessID: s.processID,
-		TTY:       &spec,
-	})
+	return s.codec.EncodeTTY(s.processID, spec)
 }
 
 func (s *processStream) Signal(signal garden.Signal) error {
-	return s.sendPayload(&transport.ProcessPayload{
-		ProcessID: s.processID,
-		Signal:    &signal,
-	})
-}
-
-func (s *processStream) sendPayload(payload interface{}) error {
-	s.Lock()
-
-	err := transport.WriteMessage(s.conn, payload)
-	if err != nil {
-		s.Unlock()
-		return err
-	}
-
-	s.Unlock()
-
-	return nil
+	return s.codec.EncodeSignal(s.processID, signal)
 }
 
 func (s *processStream) ProcessID() string {
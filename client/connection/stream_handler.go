@@ -1,72 +1,171 @@
 package connection
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"sync"
 
+	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/garden/transport"
 	"code.cloudfoundry.org/lager"
 )
 
+// errStreamsTornDown stops a stream once another stream of a process with
+// coupled streams has failed.
+var errStreamsTornDown = errors.New("connection: process streams torn down")
+
 type hijackFunc func(streamType string) (net.Conn, io.Reader, error)
 
+// streamHandler copies a process's stdin to it and its output from it. The
+// streams fail independently, unless they are coupled, in which case the
+// first failure tears down the others: the process's stdin is closed and any
+// further output is discarded.
 type streamHandler struct {
 	log lager.Logger
 	wg  *sync.WaitGroup
+
+	coupled  bool
+	stdin    io.WriteCloser
+	torn     chan struct{}
+	tearOnce sync.Once
 }
 
-func newStreamHandler(log lager.Logger) *streamHandler {
+func newStreamHandler(log lager.Logger, coupled bool) *streamHandler {
 	return &streamHandler{
-		log: log,
-		wg:  new(sync.WaitGroup),
+		log:     log,
+		wg:      new(sync.WaitGroup),
+		coupled: coupled,
+		torn:    make(chan struct{}),
 	}
 }
 
+// streamIn copies stdin to the process, closing the process's stdin at EOF.
+// If reading stdin fails the process's stdin is left open, as the process
+// cannot tell a failure from the end of its input.
 func (sh *streamHandler) streamIn(processWriter io.WriteCloser, stdin io.Reader) {
 	if stdin == nil {
 		return
 	}
 
+	sh.stdin = processWriter
+
 	go func(processInputStream io.WriteCloser, stdin io.Reader, log lager.Logger) {
-		if _, err := io.Copy(processInputStream, stdin); err == nil {
+		_, err := io.Copy(sh.untilTornDown(processInputStream), stdin)
+		switch err {
+		case nil:
 			processInputStream.Close()
-		} else {
+		case errStreamsTornDown:
+		default:
 			log.Error("streaming-stdin-payload", err)
+			sh.tearDown()
 		}
 	}(processWriter, stdin, sh.log)
 }
 
+// streamOut copies one of the process's output streams to its destination.
+// Once writing to the destination fails, the rest of the stream is read and
+// discarded, so that the server does not hold up the process, and with it
+// its other streams, on output that is never delivered.
 func (sh *streamHandler) streamOut(streamWriter io.Writer, streamReader io.Reader) {
 	sh.wg.Add(1)
 	go func() {
-		io.Copy(streamWriter, streamReader)
-		sh.wg.Done()
+		defer sh.wg.Done()
+
+		_, err := io.Copy(sh.untilTornDown(streamWriter), streamReader)
+		if err == nil {
+			return
+		}
+
+		if err != errStreamsTornDown {
+			sh.log.Error("streaming-output", err)
+			sh.tearDown()
+		}
+
+		io.Copy(ioutil.Discard, streamReader)
 	}()
 }
 
-func (sh *streamHandler) wait(decoder *json.Decoder) (int, error) {
-	for {
-		payload := &transport.ProcessPayload{}
-		err := decoder.Decode(payload)
-		if err != nil {
-			sh.wg.Wait()
-			return 0, fmt.Errorf("connection: decode failed: %s", err)
+// tearDown stops every stream of a process with coupled streams.
+func (sh *streamHandler) tearDown() {
+	if !sh.coupled {
+		return
+	}
+
+	sh.tearOnce.Do(func() {
+		close(sh.torn)
+
+		if sh.stdin != nil {
+			sh.stdin.Close()
 		}
+	})
+}
+
+func (sh *streamHandler) untilTornDown(w io.Writer) io.Writer {
+	return tornDownWriter{w: w, torn: sh.torn}
+}
+
+type tornDownWriter struct {
+	w    io.Writer
+	torn <-chan struct{}
+}
 
-		if payload.Error != nil {
-			sh.wg.Wait()
-			return 0, fmt.Errorf("connection: process error: %s", *payload.Error)
+func (w tornDownWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.torn:
+		return 0, errStreamsTornDown
+	default:
+		return w.w.Write(p)
+	}
+}
+
+func (sh *streamHandler) wait(codec *transport.ProcessStreamCodec, events chan<- garden.ProcessEvent) (int, error) {
+	var notify func(garden.ProcessEvent)
+	if events != nil {
+		notify = func(event garden.ProcessEvent) { events <- event }
+	}
+
+	status, err := codec.DecodeExitStatusWithEvents(notify)
+	sh.wg.Wait()
+
+	if err != nil {
+		if processErr, ok := err.(transport.ProcessError); ok {
+			return 0, fmt.Errorf("connection: process error: %s", processErr.Message)
+		}
+
+		if _, ok := err.(garden.QuotaExceededError); ok {
+			return 0, err
 		}
 
-		if payload.ExitStatus != nil {
-			sh.wg.Wait()
-			status := int(*payload.ExitStatus)
-			return status, nil
+		if _, ok := err.(garden.ProcessRuntimeExceededError); ok {
+			sh.exited(notify, status)
+			return status, err
 		}
 
-		// discard other payloads
+		if _, ok := err.(garden.ProcessOutputLimitExceededError); ok {
+			sh.exited(notify, status)
+			return status, err
+		}
+
+		// the server closed the stream cleanly without ever reporting how the
+		// process exited; anything else is a malformed or truncated payload
+		if err == io.EOF {
+			return 0, ErrDisconnected
+		}
+
+		return 0, fmt.Errorf("connection: decode failed: %s", err)
+	}
+
+	sh.exited(notify, status)
+	return status, nil
+}
+
+// exited reports the exit over the control channel, if there is one. The
+// server does not send it separately, as the exit status is the state change.
+func (sh *streamHandler) exited(notify func(garden.ProcessEvent), status int) {
+	if notify != nil {
+		notify(garden.ProcessEvent{State: garden.ProcessStateExited, ExitStatus: status})
 	}
 }
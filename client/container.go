@@ -30,6 +30,18 @@ func (container *container) Stop(kill bool) error {
 	return container.connection.Stop(container.handle, kill)
 }
 
+func (container *container) Checkpoint(imagePath string) error {
+	return container.connection.Checkpoint(container.handle, imagePath)
+}
+
+func (container *container) Restore(imagePath string) error {
+	return container.connection.Restore(container.handle, imagePath)
+}
+
+func (container *container) Sync() error {
+	return container.connection.Sync(container.handle)
+}
+
 func (container *container) Info() (garden.ContainerInfo, error) {
 	return container.connection.Info(container.handle)
 }
@@ -58,6 +70,14 @@ func (container *container) CurrentMemoryLimits() (garden.MemoryLimits, error) {
 	return container.connection.CurrentMemoryLimits(container.handle)
 }
 
+func (container *container) CurrentIOLimits() (garden.IOLimits, error) {
+	return container.connection.CurrentIOLimits(container.handle)
+}
+
+func (container *container) LimitAll(limits garden.LimitsUpdate) (garden.Limits, error) {
+	return container.connection.LimitAll(container.handle, limits)
+}
+
 func (container *container) Run(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
 	return container.connection.Run(container.handle, spec, io)
 }
@@ -78,10 +98,22 @@ func (container *container) BulkNetOut(netOutRules []garden.NetOutRule) error {
 	return container.connection.BulkNetOut(container.handle, netOutRules)
 }
 
+func (container *container) NetworkPolicy() (garden.NetworkPolicy, error) {
+	return container.connection.NetworkPolicy(container.handle)
+}
+
+func (container *container) SetNetworkPolicy(policy garden.NetworkPolicy) error {
+	return container.connection.SetNetworkPolicy(container.handle, policy)
+}
+
 func (container *container) Metrics() (garden.Metrics, error) {
 	return container.connection.Metrics(container.handle)
 }
 
+func (container *container) ProcessStats() (map[uint32]garden.ProcessStat, error) {
+	return container.connection.ProcessStats(container.handle)
+}
+
 func (container *container) SetGraceTime(graceTime time.Duration) error {
 	return container.connection.SetGraceTime(container.handle, graceTime)
 }
@@ -69,6 +69,76 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("Checkpoint", func() {
+		It("sends a checkpoint request", func() {
+			err := container.Checkpoint("/some/image")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, imagePath := fakeConnection.CheckpointArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(imagePath).Should(Equal("/some/image"))
+		})
+
+		Context("when checkpointing fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.CheckpointReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.Checkpoint("/some/image")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		It("sends a restore request", func() {
+			err := container.Restore("/some/image")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, imagePath := fakeConnection.RestoreArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(imagePath).Should(Equal("/some/image"))
+		})
+
+		Context("when restoring fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.RestoreReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.Restore("/some/image")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Sync", func() {
+		It("sends a sync request", func() {
+			err := container.Sync()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.SyncArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when syncing fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.SyncReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.Sync()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Info", func() {
 		It("sends an info request", func() {
 			infoToReturn := garden.ContainerInfo{
@@ -346,6 +416,69 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("CurrentIOLimits", func() {
+		It("gets the current limits", func() {
+			limitsToReturn := garden.IOLimits{
+				ReadIOPS: 1,
+			}
+
+			fakeConnection.CurrentIOLimitsReturns(limitsToReturn, nil)
+
+			limits, err := container.CurrentIOLimits()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(limits).Should(Equal(limitsToReturn))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.CurrentIOLimitsReturns(garden.IOLimits{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.CurrentIOLimits()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("LimitAll", func() {
+		update := garden.LimitsUpdate{
+			Memory: &garden.MemoryLimits{LimitInBytes: 1},
+		}
+
+		It("sends the update and returns the limits in effect", func() {
+			limitsToReturn := garden.Limits{
+				Memory: garden.MemoryLimits{LimitInBytes: 1},
+			}
+
+			fakeConnection.LimitAllReturns(limitsToReturn, nil)
+
+			limits, err := container.LimitAll(update)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(limits).Should(Equal(limitsToReturn))
+
+			handle, sentUpdate := fakeConnection.LimitAllArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(sentUpdate).Should(Equal(update))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.LimitAllReturns(garden.Limits{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.LimitAll(update)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Run", func() {
 		It("sends a run request and returns the process id and a stream", func() {
 			fakeConnection.RunStub = func(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
@@ -511,6 +644,40 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("NetworkPolicy", func() {
+		policy := garden.NetworkPolicy{
+			DefaultAction: garden.NetworkActionDeny,
+			Rules:         []garden.NetOutRule{{Protocol: garden.ProtocolTCP}},
+		}
+
+		It("fetches the policy over the connection", func() {
+			fakeConnection.NetworkPolicyReturns(policy, nil)
+
+			Ω(container.NetworkPolicy()).Should(Equal(policy))
+			Ω(fakeConnection.NetworkPolicyArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		It("sends the policy to set over the connection", func() {
+			Ω(container.SetNetworkPolicy(policy)).Should(Succeed())
+
+			handle, sentPolicy := fakeConnection.SetNetworkPolicyArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(sentPolicy).Should(Equal(policy))
+		})
+
+		Context("when setting the policy fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.SetNetworkPolicyReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				Ω(container.SetNetworkPolicy(policy)).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe(("GraceTime"), func() {
 		It("send the set grace time request", func() {
 			graceTime := time.Second * 5
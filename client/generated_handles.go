@@ -0,0 +1,49 @@
+package client
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"code.cloudfoundry.org/garden"
+)
+
+type handleGeneratingClient struct {
+	Client
+}
+
+// NewHandleGenerating wraps client so that a Create whose spec has no handle
+// is sent with a random UUID as its handle, so that the caller knows the
+// handle before the container exists, e.g. to log or trace the create by it.
+// The server still rejects a handle that is already in use. Specs with a
+// handle are sent as they are.
+func NewHandleGenerating(client Client) Client {
+	return &handleGeneratingClient{
+		Client: client,
+	}
+}
+
+func (client *handleGeneratingClient) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	if spec.Handle == "" {
+		handle, err := generateHandle()
+		if err != nil {
+			return nil, err
+		}
+
+		spec.Handle = handle
+	}
+
+	return client.Client.Create(spec)
+}
+
+// generateHandle returns a random (version 4) UUID.
+func generateHandle() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generating handle: %s", err)
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
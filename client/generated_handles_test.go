@@ -0,0 +1,55 @@
+package client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/garden"
+	. "code.cloudfoundry.org/garden/client"
+	"code.cloudfoundry.org/garden/client/connection/connectionfakes"
+)
+
+var _ = Describe("HandleGenerating", func() {
+	var (
+		client         Client
+		fakeConnection *connectionfakes.FakeConnection
+	)
+
+	BeforeEach(func() {
+		fakeConnection = new(connectionfakes.FakeConnection)
+		fakeConnection.CreateStub = func(spec garden.ContainerSpec) (string, error) {
+			return spec.Handle, nil
+		}
+
+		client = NewHandleGenerating(New(fakeConnection))
+	})
+
+	Context("when the spec has no handle", func() {
+		It("sends a generated UUID as the handle", func() {
+			container, err := client.Create(garden.ContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			sent := fakeConnection.CreateArgsForCall(0).Handle
+			Expect(sent).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+			Expect(container.Handle()).To(Equal(sent))
+		})
+
+		It("generates a different handle each time", func() {
+			_, err := client.Create(garden.ContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Create(garden.ContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeConnection.CreateArgsForCall(0).Handle).NotTo(Equal(fakeConnection.CreateArgsForCall(1).Handle))
+		})
+	})
+
+	Context("when the spec has a handle", func() {
+		It("sends it as it is", func() {
+			_, err := client.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeConnection.CreateArgsForCall(0).Handle).To(Equal("some-handle"))
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/client/connection"
+)
+
+// maskedEnvValue is what the server reports in place of the value of an
+// environment variable it masks.
+const maskedEnvValue = "********"
+
+// SpecMismatchError is returned by an idempotent Create when a container
+// already has the handle, but was not created with the same spec.
+type SpecMismatchError struct {
+	Handle   string
+	Existing garden.ContainerSpec
+}
+
+func (err SpecMismatchError) Error() string {
+	return fmt.Sprintf("handle already exists with a different spec: %s", err.Handle)
+}
+
+type idempotentCreatingClient struct {
+	Client
+
+	connection connection.Connection
+}
+
+// NewIdempotentCreating returns a client whose Create succeeds when the
+// container already exists as asked for, so that a provisioner which cannot
+// tell whether a timed-out Create went through can simply retry it. When
+// Create fails with garden.HandleConflictError, the existing container's spec
+// is read back and compared with the one given: if they match the existing
+// container is returned, otherwise a SpecMismatchError. A spec without a
+// grace time matches any, as does a masked environment variable value. If
+// the existing spec cannot be read, the HandleConflictError is returned.
+func NewIdempotentCreating(connection connection.Connection) Client {
+	return &idempotentCreatingClient{
+		Client: New(connection),
+
+		connection: connection,
+	}
+}
+
+func (client *idempotentCreatingClient) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	container, err := client.Client.Create(spec)
+	if _, conflicted := err.(garden.HandleConflictError); !conflicted {
+		return container, err
+	}
+
+	existing, specErr := client.connection.Spec(spec.Handle)
+	if specErr != nil {
+		return nil, err
+	}
+
+	if !specsMatch(spec, existing) {
+		return nil, SpecMismatchError{Handle: spec.Handle, Existing: existing}
+	}
+
+	return newContainer(spec.Handle, client.connection), nil
+}
+
+// specsMatch reports whether the existing container was created with the
+// intended spec, as far as can be told from the spec the server reports.
+// They are compared as they are sent, so that fields left empty in one and
+// omitted by the server in the other are still equal. The server never
+// reports the image's password, so it is not compared.
+func specsMatch(intended, existing garden.ContainerSpec) bool {
+	if intended.GraceTime == 0 {
+		existing.GraceTime = 0
+	}
+
+	intended.Image.Password = ""
+
+	existing.Env = unmaskEnv(existing.Env, intended.Env)
+
+	intendedJSON, err := json.Marshal(intended)
+	if err != nil {
+		return false
+	}
+
+	existingJSON, err := json.Marshal(existing)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(intendedJSON, existingJSON)
+}
+
+// unmaskEnv returns env with each masked value replaced by the intended one,
+// where the same variable is intended.
+func unmaskEnv(env, intended []string) []string {
+	if env == nil {
+		return nil
+	}
+
+	values := map[string]string{}
+	for _, variable := range intended {
+		key := strings.SplitN(variable, "=", 2)[0]
+		values[key] = variable
+	}
+
+	unmasked := make([]string, len(env))
+	for i, variable := range env {
+		unmasked[i] = variable
+
+		key := strings.SplitN(variable, "=", 2)[0]
+		if intendedVariable, found := values[key]; found && variable == key+"="+maskedEnvValue {
+			unmasked[i] = intendedVariable
+		}
+	}
+
+	return unmasked
+}
@@ -0,0 +1,142 @@
+package client_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/garden"
+	. "code.cloudfoundry.org/garden/client"
+	"code.cloudfoundry.org/garden/client/connection/connectionfakes"
+)
+
+var _ = Describe("IdempotentCreating", func() {
+	var (
+		client         Client
+		fakeConnection *connectionfakes.FakeConnection
+		spec           garden.ContainerSpec
+	)
+
+	BeforeEach(func() {
+		fakeConnection = new(connectionfakes.FakeConnection)
+		client = NewIdempotentCreating(fakeConnection)
+
+		spec = garden.ContainerSpec{
+			Handle:     "some-handle",
+			Image:      garden.ImageRef{URI: "docker:///busybox"},
+			Env:        []string{"PATH=/bin", "DB_PASSWORD=secret"},
+			Properties: garden.Properties{"owner": "provisioner"},
+		}
+	})
+
+	Context("when the create succeeds", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateReturns("some-handle", nil)
+		})
+
+		It("returns the container without reading its spec", func() {
+			container, err := client.Create(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.Handle()).To(Equal("some-handle"))
+
+			Expect(fakeConnection.SpecCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the create fails with a different error", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateReturns("", errors.New("oh no!"))
+		})
+
+		It("returns the error", func() {
+			_, err := client.Create(spec)
+			Expect(err).To(MatchError("oh no!"))
+
+			Expect(fakeConnection.SpecCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the handle is already in use", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateReturns("", garden.HandleConflictError{Handle: "some-handle"})
+		})
+
+		Context("by a container created with the same spec", func() {
+			BeforeEach(func() {
+				existing := spec
+				existing.GraceTime = 5 * time.Minute
+				existing.Env = []string{"PATH=/bin", "DB_PASSWORD=********"}
+				fakeConnection.SpecReturns(existing, nil)
+			})
+
+			It("returns the existing container", func() {
+				container, err := client.Create(spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(container.Handle()).To(Equal("some-handle"))
+
+				Expect(fakeConnection.SpecArgsForCall(0)).To(Equal("some-handle"))
+			})
+		})
+
+		Context("by a container created with the same spec, from an image which needs a password", func() {
+			BeforeEach(func() {
+				spec.Image.Username = "some-user"
+				spec.Image.Password = "some-password"
+
+				existing := spec
+				existing.Image.Password = ""
+				fakeConnection.SpecReturns(existing, nil)
+			})
+
+			It("returns the existing container", func() {
+				container, err := client.Create(spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(container.Handle()).To(Equal("some-handle"))
+			})
+		})
+
+		Context("by a container created with a different spec", func() {
+			var existing garden.ContainerSpec
+
+			BeforeEach(func() {
+				existing = spec
+				existing.Image = garden.ImageRef{URI: "docker:///alpine"}
+				fakeConnection.SpecReturns(existing, nil)
+			})
+
+			It("returns a SpecMismatchError", func() {
+				_, err := client.Create(spec)
+				Expect(err).To(Equal(SpecMismatchError{Handle: "some-handle", Existing: existing}))
+				Expect(err).To(MatchError("handle already exists with a different spec: some-handle"))
+			})
+		})
+
+		Context("by a container with a different grace time", func() {
+			BeforeEach(func() {
+				spec.GraceTime = time.Minute
+
+				existing := spec
+				existing.GraceTime = time.Hour
+				fakeConnection.SpecReturns(existing, nil)
+			})
+
+			It("returns a SpecMismatchError", func() {
+				_, err := client.Create(spec)
+				Expect(err).To(BeAssignableToTypeOf(SpecMismatchError{}))
+			})
+		})
+
+		Context("when the existing container's spec cannot be read", func() {
+			BeforeEach(func() {
+				fakeConnection.SpecReturns(garden.ContainerSpec{}, errors.New("spec unknown"))
+			})
+
+			It("returns the conflict", func() {
+				_, err := client.Create(spec)
+				Expect(err).To(Equal(garden.HandleConflictError{Handle: "some-handle"}))
+			})
+		})
+	})
+})
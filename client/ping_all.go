@@ -0,0 +1,47 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden/client/connection"
+	"code.cloudfoundry.org/lager"
+)
+
+// maxConcurrentPings bounds how many servers PingAll pings at once, so that
+// sweeping a large fleet does not exhaust file descriptors.
+const maxConcurrentPings = 64
+
+// PingAll pings the garden servers listening on each of the given TCP
+// addresses concurrently, giving each at most timeout to answer. It returns
+// the result for every address: nil if the server is healthy, or the error
+// pinging it.
+func PingAll(addrs []string, timeout time.Duration) map[string]error {
+	results := make(map[string]error, len(addrs))
+	resultsL := new(sync.Mutex)
+
+	slots := make(chan struct{}, maxConcurrentPings)
+	wg := new(sync.WaitGroup)
+
+	logger := lager.NewLogger("garden-ping-all")
+
+	for _, addr := range addrs {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := connection.NewWithOptions("tcp", addr, logger, connection.WithRequestTimeout(timeout)).Ping()
+
+			resultsL.Lock()
+			results[addr] = err
+			resultsL.Unlock()
+		}(addr)
+	}
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,69 @@
+package client_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"code.cloudfoundry.org/garden"
+	. "code.cloudfoundry.org/garden/client"
+)
+
+var _ = Describe("PingAll", func() {
+	var (
+		healthy   *ghttp.Server
+		unhealthy *ghttp.Server
+		hanging   *ghttp.Server
+		release   chan struct{}
+	)
+
+	BeforeEach(func() {
+		release = make(chan struct{})
+
+		healthy = ghttp.NewServer()
+		healthy.RouteToHandler("GET", "/ping", ghttp.RespondWith(200, "{}"))
+
+		unhealthy = ghttp.NewServer()
+		unhealthy.RouteToHandler("GET", "/ping", ghttp.RespondWithJSONEncoded(500, &garden.Error{
+			Err: garden.NewServiceUnavailableError("backend is down"),
+		}))
+
+		hanging = ghttp.NewServer()
+		hanging.RouteToHandler("GET", "/ping", func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		})
+	})
+
+	AfterEach(func() {
+		close(release)
+
+		healthy.Close()
+		unhealthy.Close()
+		hanging.Close()
+	})
+
+	It("reports the health of every server", func() {
+		results := PingAll([]string{
+			healthy.Addr(),
+			unhealthy.Addr(),
+			hanging.Addr(),
+		}, 100*time.Millisecond)
+
+		Expect(results).To(HaveLen(3))
+		Expect(results[healthy.Addr()]).NotTo(HaveOccurred())
+		Expect(results[unhealthy.Addr()]).To(Equal(garden.ServiceUnavailableError{Cause: "backend is down"}))
+		Expect(results[hanging.Addr()]).To(HaveOccurred())
+	})
+
+	It("pings more servers than it pings at once", func() {
+		addrs := []string{}
+		for i := 0; i < 100; i++ {
+			addrs = append(addrs, healthy.Addr())
+		}
+
+		Expect(PingAll(addrs, time.Second)).To(Equal(map[string]error{healthy.Addr(): nil}))
+	})
+})
@@ -26,19 +26,62 @@ type Container interface {
 	// * None.
 	Stop(kill bool) error
 
+	// Checkpoint dumps the state of the container's processes to imagePath, a
+	// directory on the host, and stops them. It requires a backend reporting
+	// FeatureSet.Checkpoint.
+	//
+	// The container's filesystem is not part of the image and is left in place,
+	// so the container can later be restored from it. Files open at the time
+	// of the checkpoint must still exist at the same paths when restoring.
+	// Listening sockets are preserved, but established TCP connections are not:
+	// they appear closed to the processes once restored.
+	//
+	// Errors:
+	// * When imagePath is not an absolute path.
+	// * When the backend does not support checkpointing.
+	Checkpoint(imagePath string) error
+
+	// Restore resumes the processes checkpointed to imagePath in the container.
+	// The container must have the filesystem the checkpoint was taken from:
+	// either the checkpointed container itself, or one cloned from it.
+	//
+	// Errors:
+	// * When imagePath is not an absolute path.
+	// * When imagePath does not hold a checkpoint.
+	// * When the container already has running processes.
+	Restore(imagePath string) error
+
+	// Sync flushes the container's pending writes to disk, e.g. before it is
+	// backed up or snapshotted. Only the filesystems mounted in the
+	// container, its root filesystem and any bind mounts, are synced, as with
+	// syncfs(2); the rest of the host's are not.
+	//
+	// Errors:
+	// * When flushing any of the container's filesystems fails.
+	Sync() error
+
 	// Returns information about a container.
 	Info() (ContainerInfo, error)
 
 	// StreamIn streams data into a file in a container.
 	//
 	// Errors:
-	// *  TODO.
+	// * When spec.User is neither a user name nor a numeric uid:gid pair.
+	// * When spec.User names a user that does not exist in the container.
+	// * When spec.Owner is set but is not a numeric uid:gid pair.
+	// * When the data would exceed one of the container's disk quotas, a
+	//   QuotaExceededError.
+	// * When the tar stream is cut short, e.g. because the upload was
+	//   aborted. Whatever was extracted before then is left in place; a
+	//   partial stream in is not rolled back.
 	StreamIn(spec StreamInSpec) error
 
 	// StreamOut streams a file out of a container.
 	//
 	// Errors:
-	// * TODO.
+	// * When spec.User is neither a user name nor a numeric uid:gid pair.
+	// * When spec.User names a user that does not exist in the container.
+	// * When spec.Path does not exist, which backends should report as a FileNotFoundError.
 	StreamOut(spec StreamOutSpec) (io.ReadCloser, error)
 
 	// Returns the current bandwidth limits set for the container.
@@ -53,6 +96,13 @@ type Container interface {
 	// Returns the current memory limts set for the container.
 	CurrentMemoryLimits() (MemoryLimits, error)
 
+	// Returns the current block IO limits set for the container.
+	CurrentIOLimits() (IOLimits, error)
+
+	// LimitAll applies every limit set in the update at once, leaving those it
+	// does not set unchanged, and returns the limits then in effect.
+	LimitAll(limits LimitsUpdate) (Limits, error)
+
 	// Map a port on the host to a port in the container so that traffic to the
 	// host port is forwarded to the container port. This is deprecated in
 	// favour of passing NetIn configuration in the ContainerSpec at creation
@@ -90,6 +140,19 @@ type Container interface {
 	// * An error is returned if any of the NetOut calls fail.
 	BulkNetOut(netOutRules []NetOutRule) error
 
+	// Returns the container's egress policy, including the rules added with
+	// NetOut and BulkNetOut.
+	NetworkPolicy() (NetworkPolicy, error)
+
+	// Replace the container's egress policy, including any rules added with
+	// NetOut and BulkNetOut, with the given one.
+	//
+	// Errors:
+	// * When policy.DefaultAction is neither NetworkActionAllow nor NetworkActionDeny.
+	// * When the policy cannot be applied, in which case the server restores
+	//   the policy that was in effect before.
+	SetNetworkPolicy(policy NetworkPolicy) error
+
 	// Run a script inside a container.
 	//
 	// The root user will be mapped to a non-root UID in the host unless the container (not this process) was created with 'privileged' true.
@@ -107,6 +170,12 @@ type Container interface {
 	// Metrics returns the current set of metrics for a container
 	Metrics() (Metrics, error)
 
+	// ProcessStats returns the current resource usage of each process running
+	// in the container, keyed by PID. Unlike Metrics it is not aggregated, so
+	// it can be used to find which process is consuming a container's
+	// resources.
+	ProcessStats() (map[uint32]ProcessStat, error)
+
 	// Sets the grace time.
 	SetGraceTime(graceTime time.Duration) error
 
@@ -125,7 +194,9 @@ type Container interface {
 	// * None.
 	SetProperty(name string, value string) error
 
-	// Remove a property with the specified name from a container.
+	// Remove a property with the specified name from a container. Removing a
+	// property that is not set is a no-op, so it is safe to call during
+	// teardown without knowing whether the property was ever set.
 	//
 	// Errors:
 	// * None.
@@ -153,9 +224,25 @@ type ProcessSpec struct {
 	// This must either be a username, or uid:gid.
 	User string `json:"user,omitempty"`
 
+	// Supplementary group IDs the process should belong to, in addition to
+	// the primary group of User.
+	SupplementaryGroups []int `json:"supplementary_groups,omitempty"`
+
 	// Resource limits
 	Limits ResourceLimits `json:"rlimits,omitempty"`
 
+	// Capabilities grants the process Linux capabilities in addition to those
+	// the container's processes have by default, without making it
+	// privileged. They are named without the CAP_ prefix, e.g. "NET_ADMIN".
+	// An error is returned if a name is not a known capability.
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Nice is the scheduling nice value the process starts with, from -20
+	// (highest priority) to 19 (lowest). Unlike Limits.Nice, which only caps
+	// the value the process may raise its priority to, it sets the priority
+	// the process actually runs at. Zero leaves the default priority.
+	Nice int `json:"nice,omitempty"`
+
 	// Limits to be applied to the newly created process
 	OverrideContainerLimits *ProcessLimits `json:"limits,omitempty"`
 
@@ -169,10 +256,135 @@ type ProcessSpec struct {
 	// Bind mounts to be applied to the process's filesystem
 	// An error is returned if ProcessSpec.Image is not also set.
 	BindMounts []BindMount `json:"bind_mounts,omitempty"`
+
+	// AutoDestroyOnExit makes the server destroy the container once this
+	// process exits, whether or not a client is still attached to it. Any
+	// other processes still running in the container are killed along with
+	// it.
+	AutoDestroyOnExit bool `json:"auto_destroy_on_exit,omitempty"`
+
+	// KeepOnFailure preserves the container for inspection if this process
+	// fails, that is if it exits with a non-zero status (including being
+	// killed by a signal or for exceeding its MaxRuntime) or cannot be waited
+	// on. Instead of being destroyed by AutoDestroyOnExit, the container's
+	// grace time is extended to the server's failed container grace time, and
+	// the container is given the FailedProcessProperty so that it can be
+	// found. A process that succeeds follows the normal lifecycle.
+	KeepOnFailure bool `json:"keep_on_failure,omitempty"`
+
+	// MaxRuntime bounds how long the process may run. Once it has elapsed the
+	// server kills the process and Wait returns its exit status along with a
+	// ProcessRuntimeExceededError. Zero means no limit.
+	MaxRuntime time.Duration `json:"max_runtime,omitempty"`
+
+	// OutputLog has the server write the process's stdout and stderr to a log
+	// file on the host instead of streaming them to the client. The log can
+	// be read back with the connection's StreamOutputLog. The server must
+	// have been configured with a directory for output logs.
+	OutputLog *OutputLogSpec `json:"output_log,omitempty"`
+
+	// StdinFile has the server feed the process's stdin from a file on the
+	// host instead of from the client, closing it once the whole file has
+	// been read. It is a path relative to the server's directory for stdin
+	// files, which the server must have been configured with, and must not
+	// lead outside of it. Running fails with a FileNotFoundError if the file
+	// does not exist. The file is opened when the process starts: replacing
+	// it afterwards does not affect the process, but changing it in place
+	// while the process reads it may or may not be seen, so should be
+	// avoided. Stdin sent by the client is discarded.
+	StdinFile string `json:"stdin_file,omitempty"`
+
+	// Syslog has the server forward the process's stdout and stderr to a
+	// syslog endpoint, in place of any given for its container.
+	Syslog *SyslogSpec `json:"syslog,omitempty"`
+
+	// OutputBufferSize is how many bytes of the process's most recent output
+	// the server keeps, as well as streaming it live, so that a client which
+	// reconnects can catch up on what it missed with the connection's
+	// ProcessLogsSince or ProcessLogs. Once the buffer is full the oldest
+	// output is dropped to make room. Each chunk of output the process writes
+	// counts 64 bytes against the buffer on top of its data. Zero keeps the
+	// default of 1MiB; sizes under 4KiB are rounded up to it, and at most
+	// 16MiB may be kept.
+	OutputBufferSize uint64 `json:"output_buffer_size,omitempty"`
+
+	// MaxOutputBytes bounds how much output the process may write, counting
+	// stdout and stderr together. Once it has been written, the server stops
+	// passing on the process's output: to the client which ran it, to its
+	// OutputLog and to the output kept for ProcessLogs. The process is
+	// left running, with the rest of its output dropped, unless
+	// KillOnMaxOutput is set. Either way Wait returns its exit status along
+	// with a ProcessOutputLimitExceededError. Zero means no limit.
+	MaxOutputBytes uint64 `json:"max_output_bytes,omitempty"`
+
+	// KillOnMaxOutput has the server kill the process as soon as it exceeds
+	// its MaxOutputBytes, rather than truncate its output.
+	KillOnMaxOutput bool `json:"kill_on_max_output,omitempty"`
+}
+
+// FailedProcessProperty is set on a container kept by KeepOnFailure to the ID
+// of the process that failed.
+const FailedProcessProperty = "garden.failed_process"
+
+// OutputLogSpec names a host-side log file for a process's output, and bounds
+// how much of it is kept. Logs belong to their container and are removed when
+// it is destroyed.
+type OutputLogSpec struct {
+	// Name of the log file. It must be a plain file name, without any path
+	// separators. Processes run with the same name append to the same log;
+	// while more than one writes to it, it is rotated as the first of them
+	// asked for.
+	Name string `json:"name"`
+
+	// MaxSizeInBytes is the size at which the log is rotated. Zero means
+	// 10MiB.
+	MaxSizeInBytes uint64 `json:"max_size_in_bytes,omitempty"`
+
+	// MaxBackups is the number of rotated logs kept, named <name>.1 (the
+	// most recent) to <name>.<MaxBackups>. With zero, a full log is simply
+	// started afresh.
+	MaxBackups int `json:"max_backups,omitempty"`
+}
+
+// SyslogSpec is a syslog endpoint for process output. Each line of output is
+// sent as an RFC 5424 message, with the tag as its APP-NAME, the process ID as
+// its PROCID and "stdout" or "stderr" as its MSGID; stdout is sent with
+// severity info and stderr with severity err.
+//
+// Output is queued for the endpoint rather than held up for it. While the
+// endpoint cannot be reached, output is dropped, and connecting is retried
+// after a delay which doubles from a second up to 30 seconds. A failed
+// write is retried on a fresh connection straight away. How much output
+// was dropped is logged by the server once it reconnects.
+type SyslogSpec struct {
+	// Network is "udp", the default, or "tcp". Over TCP messages are framed
+	// by octet counting (RFC 6587).
+	Network string `json:"network,omitempty"`
+
+	// Address of the endpoint, as host:port. The server only forwards to
+	// addresses its operator has allowed.
+	Address string `json:"address"`
+
+	// Facility of the messages, by name, e.g. "local0". Empty means "user".
+	Facility string `json:"facility,omitempty"`
+
+	// Tag of the messages, of at most 48 printable characters and no
+	// spaces. Empty means the container's handle, or no tag if the handle
+	// would not be a valid one.
+	Tag string `json:"tag,omitempty"`
+
+	// NoStream has the output only forwarded, rather than also streamed to
+	// the client.
+	NoStream bool `json:"no_stream,omitempty"`
 }
 
 type TTYSpec struct {
 	WindowSize *WindowSize `json:"window_size,omitempty"`
+
+	// Term is the terminal type, e.g. "xterm-256color", that programs in the
+	// process see as $TERM. If empty when running a process, "xterm" is used.
+	// It is ignored by Process.SetTTY.
+	Term string `json:"term,omitempty"`
 }
 
 type WindowSize struct {
@@ -180,10 +392,66 @@ type WindowSize struct {
 	Rows    int `json:"rows,omitempty"`
 }
 
+// ProcessIO connects a process's streams.
+//
+// Stdin is copied to the process until it reaches EOF, at which point the
+// process's stdin is closed; if reading Stdin fails, the process's stdin is
+// left open. Stdout and Stderr receive the process's output until it exits;
+// if writing to one of them fails, the rest of that output is discarded so
+// that the process is not held up. Either way, a failing stream does not
+// affect the others.
 type ProcessIO struct {
 	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
+
+	// CoupledStreams, if set, tears down all of the streams once one of them
+	// fails: the process's stdin is closed, and any further output discarded.
+	CoupledStreams bool
+
+	// Events, if set, opens a control channel alongside the process's streams.
+	// Changes to the process's state are sent on it, as are failures to apply
+	// the signals and TTY resizes sent through the Process, which are
+	// otherwise only logged by the server. Sending blocks the stream, so the
+	// channel should be buffered or drained promptly.
+	Events chan<- ProcessEvent
+
+	// Backpressure, if set, says what the server does with the output of a
+	// process it ran when this client reads it more slowly than it is
+	// written. By default the client which runs a process with a TTY holds
+	// it up, and the output is otherwise dropped, so that a slow client
+	// attached to a process does not hold up everyone streaming it.
+	Backpressure Backpressure
+}
+
+// Backpressure is what the server does with a process's output once a client
+// streaming it has fallen too far behind.
+type Backpressure string
+
+const (
+	// BackpressureBlock holds the process up until the client catches up, so
+	// that the client sees all of its output. The process is only held up
+	// while the client is reading its output, not if it never does, and for
+	// no longer than the server's block timeout, after which the output the
+	// client falls behind on is dropped until it catches up.
+	BackpressureBlock Backpressure = "block"
+
+	// BackpressureDrop drops the output the client has fallen behind on, so
+	// that the process is never held up. The client sees the output written
+	// once it has caught up.
+	BackpressureDrop Backpressure = "drop"
+)
+
+// ProcessEvent is reported over an attached process's control channel.
+type ProcessEvent struct {
+	// State is the state of the process once the event happened.
+	State ProcessState
+
+	// ExitStatus is set once State is ProcessStateExited.
+	ExitStatus int
+
+	// Error describes a signal or TTY resize that could not be applied.
+	Error string
 }
 
 //go:generate counterfeiter . Process
@@ -208,19 +476,42 @@ type PortMapping struct {
 }
 
 type StreamInSpec struct {
-	Path      string
-	User      string
+	Path string
+
+	// User owns the streamed in files. It is either a user name, which is
+	// resolved against the container's /etc/passwd, or a numeric "uid:gid"
+	// pair, which is used as-is without any lookup. If empty, the
+	// container's default user is used.
+	User string
+
+	// Owner, if set, is a numeric "uid:gid" pair given to every extracted
+	// file, regardless of the ownership recorded in the tar's headers.
+	Owner string
+
+	// TarStream is the tar archive to extract at Path. An empty or nil
+	// stream is treated as an empty archive, so Path is still created.
 	TarStream io.Reader
 }
 
 type StreamOutSpec struct {
 	Path string
+
+	// User is the user the files are read as. It accepts the same formats
+	// as StreamInSpec.User.
 	User string
 }
 
+// ContainerState is the state of a container, as reported in its info.
+type ContainerState string
+
+const (
+	ContainerStateActive  ContainerState = "active"
+	ContainerStateStopped ContainerState = "stopped"
+)
+
 // ContainerInfo holds information about a container.
 type ContainerInfo struct {
-	State         string        // Either "active" or "stopped".
+	State         string        // Either "active" or "stopped" (see ContainerState).
 	Events        []string      // List of events that occurred for the container. It currently includes only "oom" (Out Of Memory) event if it occurred.
 	HostIP        string        // The IP address of the gateway which controls the host side of the container's virtual ethernet pair.
 	ContainerIP   string        // The IP address of the container side of the container's virtual ethernet pair.
@@ -229,6 +520,26 @@ type ContainerInfo struct {
 	ProcessIDs    []string      // List of running processes.
 	Properties    Properties    // List of properties defined for the container.
 	MappedPorts   []PortMapping //
+	LastActivity  time.Time     // The last time an API call or process touched the container, as seen by the server; calls which only read about it, such as Info, do not count. Zero if the server does not track the container.
+	Version       string        // An opaque version which changes whenever the rest of the info, or the container's limits, change. Only set by Info.
+
+	// ZombieProcesses counts the container's processes which have exited but
+	// not been reaped, e.g. because its init does not reap orphans. They keep
+	// using up PIDs, so a growing count warns of a container which will hit
+	// its pids limit.
+	ZombieProcesses int
+
+	// NetworkStat holds the traffic counters of the container's network
+	// interfaces. They change with every packet, so are not part of the
+	// Version.
+	NetworkStat ContainerNetworkStat
+
+	// UIDMappings and GIDMappings are the uid and gid mappings in effect in
+	// the container's user namespace, whether they were asked for in its
+	// ContainerSpec or are the backend's default. They relate the owners of
+	// the container's files on the host to its users.
+	UIDMappings []IDMapping
+	GIDMappings []IDMapping
 }
 
 type ContainerInfoEntry struct {
@@ -248,6 +559,38 @@ type ContainerMetricsEntry struct {
 	Err     *Error
 }
 
+// ResourceUsage pairs a container's current usage of each resource with its
+// limit. A zero limit means the resource is not limited, or that the backend
+// cannot limit it.
+type ResourceUsage struct {
+	CPU       CPUUsage       `json:"cpu"`
+	Memory    MemoryUsage    `json:"memory"`
+	Disk      DiskUsage      `json:"disk"`
+	Bandwidth BandwidthUsage `json:"bandwidth"`
+}
+
+type CPUUsage struct {
+	Usage  ContainerCPUStat `json:"usage"`
+	Limits CPULimits        `json:"limits"`
+}
+
+type MemoryUsage struct {
+	Usage  ContainerMemoryStat `json:"usage"`
+	Limits MemoryLimits        `json:"limits"`
+}
+
+type DiskUsage struct {
+	Usage  ContainerDiskStat `json:"usage"`
+	Limits DiskLimits        `json:"limits"`
+}
+
+// BandwidthUsage pairs the bytes a container has sent and received with its
+// bandwidth limit.
+type BandwidthUsage struct {
+	Usage  ContainerNetworkStat `json:"usage"`
+	Limits BandwidthLimits      `json:"limits"`
+}
+
 type ContainerMemoryStat struct {
 	ActiveAnon              uint64 `json:"active_anon"`
 	ActiveFile              uint64 `json:"active_file"`
@@ -288,6 +631,76 @@ type ContainerCPUStat struct {
 	System uint64
 }
 
+// ProcessStat holds the resource usage of a single process in a container.
+type ProcessStat struct {
+	// CPU time consumed by the process, in nanoseconds.
+	CPUUsage  uint64
+	CPUUser   uint64
+	CPUSystem uint64
+
+	// Resident set size of the process, in bytes.
+	MemoryRSS uint64
+
+	// Zombie is set when the process has exited but not yet been reaped by
+	// its parent.
+	Zombie bool
+}
+
+type ProcessState string
+
+const (
+	ProcessStateRunning ProcessState = "running"
+	ProcessStateExited  ProcessState = "exited"
+)
+
+// ProcessStatus reports whether a process is still running and, once it has
+// exited, its exit status.
+type ProcessStatus struct {
+	State      ProcessState `json:"state"`
+	ExitStatus int          `json:"exit_status"`
+}
+
+// ProcessLog is the output kept for a process from a cursor on, stdout and
+// stderr interleaved.
+type ProcessLog struct {
+	Output []byte `json:"output"`
+
+	// Cursor fetches the output written after this when passed to the next
+	// call.
+	Cursor uint64 `json:"cursor"`
+
+	// Truncated reports that output from the cursor on was dropped to make
+	// room for newer output before it could be fetched.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// OutputSource is the stream a chunk of process output was written to.
+type OutputSource string
+
+const (
+	OutputSourceStdout OutputSource = "stdout"
+	OutputSourceStderr OutputSource = "stderr"
+)
+
+// TaggedOutput is a chunk of output from one of a container's processes, or,
+// with Exited set, the end of the process's output.
+type TaggedOutput struct {
+	ProcessID string       `json:"process_id"`
+	Source    OutputSource `json:"source,omitempty"`
+	Data      []byte       `json:"data,omitempty"`
+
+	Exited     bool `json:"exited,omitempty"`
+	ExitStatus int  `json:"exit_status,omitempty"`
+}
+
+// Attachment describes a client connection streaming a process's output.
+type Attachment struct {
+	ProcessID  string    `json:"process_id"`
+	StreamID   string    `json:"stream_id"`
+	RemoteAddr string    `json:"remote_addr"`
+	AttachedAt time.Time `json:"attached_at"`
+}
+
 type ContainerDiskStat struct {
 	TotalBytesUsed      uint64
 	TotalInodesUsed     uint64
@@ -302,9 +715,13 @@ type ContainerBandwidthStat struct {
 	OutBurst uint64
 }
 
+// ContainerNetworkStat holds cumulative counters of the traffic a container
+// has received and sent over its network interfaces, not counting loopback.
 type ContainerNetworkStat struct {
-	RxBytes uint64
-	TxBytes uint64
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
 }
 
 type BandwidthLimits struct {
@@ -334,6 +751,13 @@ type MemoryLimits struct {
 
 type CPULimits struct {
 	LimitInShares uint64 `json:"limit_in_shares,omitempty"`
+
+	// CPUSet pins the container, or process, to the listed host CPUs, given
+	// in the cpuset cgroup's list format, e.g. "0-3,6". Unlike the shares,
+	// which decide how much CPU time it gets, it decides where that time is
+	// spent, for cache locality on NUMA hosts. Every CPU listed must be
+	// online on the host. Empty leaves it on the CPUs it would otherwise use.
+	CPUSet string `json:"cpuset,omitempty"`
 }
 
 type PidLimits struct {
@@ -343,10 +767,26 @@ type PidLimits struct {
 	Max uint64 `json:"max,omitempty"`
 }
 
+// IOLimits throttle a container's block IO, in total across the devices it
+// uses. A zero value leaves that kind of IO unthrottled.
+type IOLimits struct {
+	ReadBytesPerSecond  uint64 `json:"read_bps,omitempty"`
+	WriteBytesPerSecond uint64 `json:"write_bps,omitempty"`
+	ReadIOPS            uint64 `json:"read_iops,omitempty"`
+	WriteIOPS           uint64 `json:"write_iops,omitempty"`
+}
+
 // Resource limits.
 //
 // Please refer to the manual page of getrlimit for a description of the individual fields:
 // http://www.kernel.org/doc/man-pages/online/pages/man2/getrlimit.2.html
+//
+// Values which would stop a process from starting are rejected before it is
+// run, with an InvalidRequestError. When set:
+// * Nofile must be at least 1, and at most the host's fs.nr_open.
+// * Nproc must be at least 1.
+// * As and Data must be at least 1MiB, and Stack at least 64KiB.
+// * Nice must be at most 40, and Rtprio at most 99.
 type ResourceLimits struct {
 	As         *uint64 `json:"as,omitempty"`
 	Core       *uint64 `json:"core,omitempty"`
@@ -5,15 +5,33 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type errType string
 
 const (
-	unrecoverableErrType      = "UnrecoverableError"
-	serviceUnavailableErrType = "ServiceUnavailableError"
-	containerNotFoundErrType  = "ContainerNotFoundError"
-	processNotFoundErrType    = "ProcessNotFoundError"
+	unrecoverableErrType           = "UnrecoverableError"
+	serviceUnavailableErrType      = "ServiceUnavailableError"
+	containerNotFoundErrType       = "ContainerNotFoundError"
+	processNotFoundErrType         = "ProcessNotFoundError"
+	insufficientCapacityErrType    = "InsufficientCapacityError"
+	handleConflictErrType          = "HandleConflictError"
+	handleRecentlyDestroyedErrType = "HandleRecentlyDestroyedError"
+	bindMountErrType               = "BindMountError"
+	rateLimitedErrType             = "RateLimitedError"
+	fileNotFoundErrType            = "FileNotFoundError"
+	isADirectoryErrType            = "IsADirectoryError"
+	unsupportedOperationErrType    = "UnsupportedOperationError"
+	hostPIDNotFoundErrType         = "HostPIDNotFoundError"
+	quotaExceededErrType           = "QuotaExceededError"
+	drainingErrType                = "DrainingError"
+	processesRunningErrType        = "ProcessesRunningError"
+	maxContainersReachedErrType    = "MaxContainersReachedError"
+	backendDegradedErrType         = "BackendDegradedError"
+	invalidRootFSErrType           = "InvalidRootFSError"
+	invalidRequestErrType          = "InvalidRequestError"
 )
 
 type Error struct {
@@ -29,6 +47,21 @@ type marshalledError struct {
 	Message   string
 	Handle    string
 	ProcessID string
+	Path      string          `json:",omitempty"`
+	Operation string          `json:",omitempty"`
+	PID       int             `json:",omitempty"`
+	Quota     DiskQuota       `json:",omitempty"`
+	BindMount *BindMountError `json:",omitempty"`
+
+	ProcessIDs []string `json:",omitempty"`
+
+	RateLimited *RateLimitedError `json:",omitempty"`
+
+	MaxContainersReached *MaxContainersReachedError `json:",omitempty"`
+
+	BackendDegraded *BackendDegradedError `json:",omitempty"`
+
+	InvalidRootFS *InvalidRootFSError `json:",omitempty"`
 }
 
 func (m Error) Error() string {
@@ -41,6 +74,36 @@ func (m Error) StatusCode() int {
 		return http.StatusNotFound
 	case ProcessNotFoundError:
 		return http.StatusNotFound
+	case HandleConflictError:
+		return http.StatusConflict
+	case HandleRecentlyDestroyedError:
+		return http.StatusConflict
+	case BindMountError:
+		return http.StatusBadRequest
+	case RateLimitedError:
+		return http.StatusTooManyRequests
+	case FileNotFoundError:
+		return http.StatusNotFound
+	case IsADirectoryError:
+		return http.StatusBadRequest
+	case UnsupportedOperationError:
+		return http.StatusNotImplemented
+	case HostPIDNotFoundError:
+		return http.StatusNotFound
+	case QuotaExceededError:
+		return http.StatusInsufficientStorage
+	case DrainingError:
+		return http.StatusServiceUnavailable
+	case ProcessesRunningError:
+		return http.StatusRequestTimeout
+	case MaxContainersReachedError:
+		return http.StatusServiceUnavailable
+	case BackendDegradedError:
+		return http.StatusServiceUnavailable
+	case InvalidRootFSError:
+		return http.StatusBadRequest
+	case InvalidRequestError:
+		return http.StatusBadRequest
 	}
 
 	return http.StatusInternalServerError
@@ -50,6 +113,16 @@ func (m Error) MarshalJSON() ([]byte, error) {
 	var errorType errType
 	handle := ""
 	processID := ""
+	path := ""
+	operation := ""
+	pid := 0
+	var quota DiskQuota
+	var bindMount *BindMountError
+	var rateLimited *RateLimitedError
+	var processIDs []string
+	var maxContainersReached *MaxContainersReachedError
+	var backendDegraded *BackendDegradedError
+	var invalidRootFS *InvalidRootFSError
 	switch err := m.Err.(type) {
 	case ContainerNotFoundError:
 		errorType = containerNotFoundErrType
@@ -61,13 +134,73 @@ func (m Error) MarshalJSON() ([]byte, error) {
 		errorType = serviceUnavailableErrType
 	case UnrecoverableError:
 		errorType = unrecoverableErrType
+	case InsufficientCapacityError:
+		errorType = insufficientCapacityErrType
+	case HandleConflictError:
+		errorType = handleConflictErrType
+		handle = err.Handle
+	case HandleRecentlyDestroyedError:
+		errorType = handleRecentlyDestroyedErrType
+		handle = err.Handle
+	case BindMountError:
+		errorType = bindMountErrType
+		bindMount = &err
+	case RateLimitedError:
+		errorType = rateLimitedErrType
+		rateLimited = &err
+	case FileNotFoundError:
+		errorType = fileNotFoundErrType
+		path = err.Path
+	case IsADirectoryError:
+		errorType = isADirectoryErrType
+		path = err.Path
+	case UnsupportedOperationError:
+		errorType = unsupportedOperationErrType
+		operation = err.Operation
+	case HostPIDNotFoundError:
+		errorType = hostPIDNotFoundErrType
+		pid = err.PID
+	case QuotaExceededError:
+		errorType = quotaExceededErrType
+		handle = err.Handle
+		quota = err.Quota
+	case DrainingError:
+		errorType = drainingErrType
+	case ProcessesRunningError:
+		errorType = processesRunningErrType
+		handle = err.Handle
+		processIDs = err.ProcessIDs
+	case MaxContainersReachedError:
+		errorType = maxContainersReachedErrType
+		maxContainersReached = &err
+	case BackendDegradedError:
+		errorType = backendDegradedErrType
+		backendDegraded = &err
+	case InvalidRootFSError:
+		errorType = invalidRootFSErrType
+		invalidRootFS = &err
+	case InvalidRequestError:
+		errorType = invalidRequestErrType
 	}
 
 	return json.Marshal(marshalledError{
-		Type:      errorType,
-		Message:   m.Err.Error(),
-		Handle:    handle,
-		ProcessID: processID,
+		Type:        errorType,
+		Message:     m.Err.Error(),
+		Handle:      handle,
+		ProcessID:   processID,
+		Path:        path,
+		Operation:   operation,
+		PID:         pid,
+		Quota:       quota,
+		BindMount:   bindMount,
+		RateLimited: rateLimited,
+		ProcessIDs:  processIDs,
+
+		MaxContainersReached: maxContainersReached,
+
+		BackendDegraded: backendDegraded,
+
+		InvalidRootFS: invalidRootFS,
 	})
 }
 
@@ -87,6 +220,58 @@ func (m *Error) UnmarshalJSON(data []byte) error {
 		m.Err = ContainerNotFoundError{result.Handle}
 	case processNotFoundErrType:
 		m.Err = ProcessNotFoundError{ProcessID: result.ProcessID}
+	case insufficientCapacityErrType:
+		m.Err = InsufficientCapacityError{result.Message}
+	case handleConflictErrType:
+		m.Err = HandleConflictError{result.Handle}
+	case handleRecentlyDestroyedErrType:
+		m.Err = HandleRecentlyDestroyedError{result.Handle}
+	case bindMountErrType:
+		if result.BindMount == nil {
+			m.Err = errors.New(result.Message)
+		} else {
+			m.Err = *result.BindMount
+		}
+	case rateLimitedErrType:
+		if result.RateLimited == nil {
+			m.Err = errors.New(result.Message)
+		} else {
+			m.Err = *result.RateLimited
+		}
+	case fileNotFoundErrType:
+		m.Err = FileNotFoundError{Path: result.Path}
+	case isADirectoryErrType:
+		m.Err = IsADirectoryError{Path: result.Path}
+	case unsupportedOperationErrType:
+		m.Err = UnsupportedOperationError{Operation: result.Operation}
+	case hostPIDNotFoundErrType:
+		m.Err = HostPIDNotFoundError{PID: result.PID}
+	case quotaExceededErrType:
+		m.Err = QuotaExceededError{Handle: result.Handle, Quota: result.Quota}
+	case drainingErrType:
+		m.Err = DrainingError{}
+	case processesRunningErrType:
+		m.Err = ProcessesRunningError{Handle: result.Handle, ProcessIDs: result.ProcessIDs}
+	case maxContainersReachedErrType:
+		if result.MaxContainersReached == nil {
+			m.Err = errors.New(result.Message)
+		} else {
+			m.Err = *result.MaxContainersReached
+		}
+	case backendDegradedErrType:
+		if result.BackendDegraded == nil {
+			m.Err = errors.New(result.Message)
+		} else {
+			m.Err = *result.BackendDegraded
+		}
+	case invalidRootFSErrType:
+		if result.InvalidRootFS == nil {
+			m.Err = errors.New(result.Message)
+		} else {
+			m.Err = *result.InvalidRootFS
+		}
+	case invalidRequestErrType:
+		m.Err = InvalidRequestError{Reason: result.Message}
 	default:
 		m.Err = errors.New(result.Message)
 	}
@@ -137,3 +322,223 @@ type ProcessNotFoundError struct {
 func (err ProcessNotFoundError) Error() string {
 	return fmt.Sprintf("unknown process: %s", err.ProcessID)
 }
+
+func NewInsufficientCapacityError(cause string) error {
+	return InsufficientCapacityError{
+		Cause: cause,
+	}
+}
+
+// InsufficientCapacityError is returned when a container cannot be created
+// because the host has no headroom left. The condition is often transient,
+// e.g. while other containers are being torn down.
+type InsufficientCapacityError struct {
+	Cause string
+}
+
+func (err InsufficientCapacityError) Error() string {
+	return err.Cause
+}
+
+// ProcessRuntimeExceededError is returned by Process.Wait alongside the exit
+// status when the server killed the process for running longer than its
+// ProcessSpec.MaxRuntime.
+type ProcessRuntimeExceededError struct {
+	ProcessID string
+}
+
+func (err ProcessRuntimeExceededError) Error() string {
+	return fmt.Sprintf("process %s exceeded its maximum runtime", err.ProcessID)
+}
+
+// ProcessOutputLimitExceededError is returned by Process.Wait alongside the
+// exit status when the process wrote more than its ProcessSpec.MaxOutputBytes,
+// so that the output received was truncated.
+type ProcessOutputLimitExceededError struct {
+	ProcessID string
+}
+
+func (err ProcessOutputLimitExceededError) Error() string {
+	return fmt.Sprintf("process %s exceeded its maximum output", err.ProcessID)
+}
+
+// HandleConflictError is returned when a container cannot be given a handle
+// because another container already has it.
+type HandleConflictError struct {
+	Handle string
+}
+
+func (err HandleConflictError) Error() string {
+	return fmt.Sprintf("handle already exists: %s", err.Handle)
+}
+
+// HandleRecentlyDestroyedError is returned by Create when the handle belonged
+// to a container destroyed too recently for it to be reused. Unlike a
+// HandleConflictError, the handle becomes free again once the server's grace
+// window has passed.
+type HandleRecentlyDestroyedError struct {
+	Handle string
+}
+
+func (err HandleRecentlyDestroyedError) Error() string {
+	return fmt.Sprintf("handle was destroyed too recently to be reused: %s", err.Handle)
+}
+
+// BindMountError is returned by Create when one of the requested bind mounts
+// cannot be used, e.g. because its source path does not exist on the host.
+type BindMountError struct {
+	SrcPath string
+	DstPath string
+	Cause   string
+}
+
+func (err BindMountError) Error() string {
+	return fmt.Sprintf("bind mount %s -> %s: %s", err.SrcPath, err.DstPath, err.Cause)
+}
+
+// RateLimitedError is returned when the server refuses a request because the
+// rate limit of its route has been exceeded. The request can be retried after
+// RetryAfter.
+type RateLimitedError struct {
+	Route      string
+	RetryAfter time.Duration
+}
+
+func (err RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s: retry after %s", err.Route, err.RetryAfter)
+}
+
+// FileNotFoundError is returned when a file to be read out of a container
+// does not exist.
+type FileNotFoundError struct {
+	Path string
+}
+
+func (err FileNotFoundError) Error() string {
+	return fmt.Sprintf("file not found: %s", err.Path)
+}
+
+// IsADirectoryError is returned when a single file was to be read out of a
+// container, but the path names a directory.
+type IsADirectoryError struct {
+	Path string
+}
+
+func (err IsADirectoryError) Error() string {
+	return fmt.Sprintf("is a directory: %s", err.Path)
+}
+
+// UnsupportedOperationError is returned when the backend, or the server
+// itself, cannot perform an operation at all. Unlike other errors it says
+// nothing about the container or the request, so feature-probing clients can
+// fall back to doing without the operation.
+type UnsupportedOperationError struct {
+	Operation string
+}
+
+func (err UnsupportedOperationError) Error() string {
+	return fmt.Sprintf("operation not supported: %s", err.Operation)
+}
+
+// HostPIDNotFoundError is returned when a host process ID does not belong to
+// any container.
+type HostPIDNotFoundError struct {
+	PID int
+}
+
+func (err HostPIDNotFoundError) Error() string {
+	return fmt.Sprintf("no container owns host pid: %d", err.PID)
+}
+
+// DiskQuota names one of the disk quotas a container can exceed.
+type DiskQuota string
+
+const (
+	DiskQuotaBlocks DiskQuota = "block"
+	DiskQuotaInodes DiskQuota = "inode"
+	DiskQuotaBytes  DiskQuota = "byte"
+)
+
+// QuotaExceededError is returned when a write into a container fails because
+// it would take the container over one of its disk quotas, whether the write
+// was streamed in or made by a running process. Unlike other write failures,
+// it may succeed if the container's disk limits are raised.
+type QuotaExceededError struct {
+	Handle string
+	Quota  DiskQuota
+}
+
+func (err QuotaExceededError) Error() string {
+	return fmt.Sprintf("container %s exceeded its %s quota", err.Handle, err.Quota)
+}
+
+// DrainingError is returned by Create when the server is being drained for
+// maintenance and is not accepting new containers. Existing containers are
+// unaffected, and the container can be created on another server.
+type DrainingError struct{}
+
+func (err DrainingError) Error() string {
+	return "server is draining: no new containers can be created"
+}
+
+// ProcessesRunningError is returned when waiting for a container's processes
+// to exit timed out, naming those still running.
+type ProcessesRunningError struct {
+	Handle     string
+	ProcessIDs []string
+}
+
+func (err ProcessesRunningError) Error() string {
+	return fmt.Sprintf("container %s still has %d running processes: %s", err.Handle, len(err.ProcessIDs), strings.Join(err.ProcessIDs, ", "))
+}
+
+// MaxContainersReachedError is returned by Create when the server already has
+// as many containers as its configured maximum allows.
+type MaxContainersReachedError struct {
+	MaxContainers int
+	Containers    int
+}
+
+func (err MaxContainersReachedError) Error() string {
+	return fmt.Sprintf("maximum number of containers reached: %d of %d", err.Containers, err.MaxContainers)
+}
+
+// BackendDegradedError is returned by a health check of a server whose
+// backend failed any of its checks, carrying the outcome of each.
+type BackendDegradedError struct {
+	Health HealthStatus
+}
+
+func (err BackendDegradedError) Error() string {
+	failed := []string{}
+	for _, check := range err.Health.Checks {
+		if check.Error != "" {
+			failed = append(failed, check.Name+": "+check.Error)
+		}
+	}
+
+	return fmt.Sprintf("backend is degraded: %s", strings.Join(failed, "; "))
+}
+
+// InvalidRootFSError is returned when a rootfs cannot be used to create a
+// container, with the reason why.
+type InvalidRootFSError struct {
+	RootFS string
+	Reason string
+}
+
+func (err InvalidRootFSError) Error() string {
+	return fmt.Sprintf("invalid rootfs %s: %s", err.RootFS, err.Reason)
+}
+
+// InvalidRequestError is returned when the server refuses a request which
+// cannot succeed as it stands, e.g. because one of its values is malformed or
+// out of range, with the reason why. Retrying the request unchanged fails
+// again.
+type InvalidRequestError struct {
+	Reason string
+}
+
+func (err InvalidRequestError) Error() string {
+	return err.Reason
+}
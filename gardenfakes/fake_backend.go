@@ -22,6 +22,21 @@ type FakeBackend struct {
 		result1 garden.Capacity
 		result2 error
 	}
+	FeaturesStub        func() (garden.FeatureSet, error)
+	featuresMutex       sync.RWMutex
+	featuresArgsForCall []struct{}
+	featuresReturns     struct {
+		result1 garden.FeatureSet
+		result2 error
+	}
+	ValidateRootFSStub        func(rootfs string) error
+	validateRootFSMutex       sync.RWMutex
+	validateRootFSArgsForCall []struct {
+		rootfs string
+	}
+	validateRootFSReturns struct {
+		result1 error
+	}
 	CreateStub        func(garden.ContainerSpec) (garden.Container, error)
 	createMutex       sync.RWMutex
 	createArgsForCall []struct {
@@ -39,6 +54,15 @@ type FakeBackend struct {
 	destroyReturns struct {
 		result1 error
 	}
+	RenameStub        func(oldHandle, newHandle string) error
+	renameMutex       sync.RWMutex
+	renameArgsForCall []struct {
+		oldHandle string
+		newHandle string
+	}
+	renameReturns struct {
+		result1 error
+	}
 	ContainersStub        func(garden.Properties) ([]garden.Container, error)
 	containersMutex       sync.RWMutex
 	containersArgsForCall []struct {
@@ -92,6 +116,22 @@ type FakeBackend struct {
 	graceTimeReturns struct {
 		result1 time.Duration
 	}
+	ContainerForHostPIDStub        func(pid int) (string, error)
+	containerForHostPIDMutex       sync.RWMutex
+	containerForHostPIDArgsForCall []struct {
+		pid int
+	}
+	containerForHostPIDReturns struct {
+		result1 string
+		result2 error
+	}
+	OOMEventsStub        func() (<-chan garden.OOMEvent, error)
+	oOMEventsMutex       sync.RWMutex
+	oOMEventsArgsForCall []struct{}
+	oOMEventsReturns     struct {
+		result1 <-chan garden.OOMEvent
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -147,6 +187,65 @@ func (fake *FakeBackend) CapacityReturns(result1 garden.Capacity, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeBackend) Features() (garden.FeatureSet, error) {
+	fake.featuresMutex.Lock()
+	fake.featuresArgsForCall = append(fake.featuresArgsForCall, struct{}{})
+	fake.recordInvocation("Features", []interface{}{})
+	fake.featuresMutex.Unlock()
+	if fake.FeaturesStub != nil {
+		return fake.FeaturesStub()
+	} else {
+		return fake.featuresReturns.result1, fake.featuresReturns.result2
+	}
+}
+
+func (fake *FakeBackend) FeaturesCallCount() int {
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	return len(fake.featuresArgsForCall)
+}
+
+func (fake *FakeBackend) FeaturesReturns(result1 garden.FeatureSet, result2 error) {
+	fake.FeaturesStub = nil
+	fake.featuresReturns = struct {
+		result1 garden.FeatureSet
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) ValidateRootFS(rootfs string) error {
+	fake.validateRootFSMutex.Lock()
+	fake.validateRootFSArgsForCall = append(fake.validateRootFSArgsForCall, struct {
+		rootfs string
+	}{rootfs})
+	fake.recordInvocation("ValidateRootFS", []interface{}{rootfs})
+	fake.validateRootFSMutex.Unlock()
+	if fake.ValidateRootFSStub != nil {
+		return fake.ValidateRootFSStub(rootfs)
+	} else {
+		return fake.validateRootFSReturns.result1
+	}
+}
+
+func (fake *FakeBackend) ValidateRootFSCallCount() int {
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
+	return len(fake.validateRootFSArgsForCall)
+}
+
+func (fake *FakeBackend) ValidateRootFSArgsForCall(i int) string {
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
+	return fake.validateRootFSArgsForCall[i].rootfs
+}
+
+func (fake *FakeBackend) ValidateRootFSReturns(result1 error) {
+	fake.ValidateRootFSStub = nil
+	fake.validateRootFSReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeBackend) Create(arg1 garden.ContainerSpec) (garden.Container, error) {
 	fake.createMutex.Lock()
 	fake.createArgsForCall = append(fake.createArgsForCall, struct {
@@ -214,6 +313,40 @@ func (fake *FakeBackend) DestroyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeBackend) Rename(oldHandle string, newHandle string) error {
+	fake.renameMutex.Lock()
+	fake.renameArgsForCall = append(fake.renameArgsForCall, struct {
+		oldHandle string
+		newHandle string
+	}{oldHandle, newHandle})
+	fake.recordInvocation("Rename", []interface{}{oldHandle, newHandle})
+	fake.renameMutex.Unlock()
+	if fake.RenameStub != nil {
+		return fake.RenameStub(oldHandle, newHandle)
+	} else {
+		return fake.renameReturns.result1
+	}
+}
+
+func (fake *FakeBackend) RenameCallCount() int {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return len(fake.renameArgsForCall)
+}
+
+func (fake *FakeBackend) RenameArgsForCall(i int) (string, string) {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return fake.renameArgsForCall[i].oldHandle, fake.renameArgsForCall[i].newHandle
+}
+
+func (fake *FakeBackend) RenameReturns(result1 error) {
+	fake.RenameStub = nil
+	fake.renameReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeBackend) Containers(arg1 garden.Properties) ([]garden.Container, error) {
 	fake.containersMutex.Lock()
 	fake.containersArgsForCall = append(fake.containersArgsForCall, struct {
@@ -434,6 +567,66 @@ func (fake *FakeBackend) GraceTimeReturns(result1 time.Duration) {
 	}{result1}
 }
 
+func (fake *FakeBackend) ContainerForHostPID(pid int) (string, error) {
+	fake.containerForHostPIDMutex.Lock()
+	fake.containerForHostPIDArgsForCall = append(fake.containerForHostPIDArgsForCall, struct {
+		pid int
+	}{pid})
+	fake.recordInvocation("ContainerForHostPID", []interface{}{pid})
+	fake.containerForHostPIDMutex.Unlock()
+	if fake.ContainerForHostPIDStub != nil {
+		return fake.ContainerForHostPIDStub(pid)
+	} else {
+		return fake.containerForHostPIDReturns.result1, fake.containerForHostPIDReturns.result2
+	}
+}
+
+func (fake *FakeBackend) ContainerForHostPIDCallCount() int {
+	fake.containerForHostPIDMutex.RLock()
+	defer fake.containerForHostPIDMutex.RUnlock()
+	return len(fake.containerForHostPIDArgsForCall)
+}
+
+func (fake *FakeBackend) ContainerForHostPIDArgsForCall(i int) int {
+	fake.containerForHostPIDMutex.RLock()
+	defer fake.containerForHostPIDMutex.RUnlock()
+	return fake.containerForHostPIDArgsForCall[i].pid
+}
+
+func (fake *FakeBackend) ContainerForHostPIDReturns(result1 string, result2 error) {
+	fake.ContainerForHostPIDStub = nil
+	fake.containerForHostPIDReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) OOMEvents() (<-chan garden.OOMEvent, error) {
+	fake.oOMEventsMutex.Lock()
+	fake.oOMEventsArgsForCall = append(fake.oOMEventsArgsForCall, struct{}{})
+	fake.recordInvocation("OOMEvents", []interface{}{})
+	fake.oOMEventsMutex.Unlock()
+	if fake.OOMEventsStub != nil {
+		return fake.OOMEventsStub()
+	} else {
+		return fake.oOMEventsReturns.result1, fake.oOMEventsReturns.result2
+	}
+}
+
+func (fake *FakeBackend) OOMEventsCallCount() int {
+	fake.oOMEventsMutex.RLock()
+	defer fake.oOMEventsMutex.RUnlock()
+	return len(fake.oOMEventsArgsForCall)
+}
+
+func (fake *FakeBackend) OOMEventsReturns(result1 <-chan garden.OOMEvent, result2 error) {
+	fake.OOMEventsStub = nil
+	fake.oOMEventsReturns = struct {
+		result1 <-chan garden.OOMEvent
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBackend) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -441,10 +634,16 @@ func (fake *FakeBackend) Invocations() map[string][][]interface{} {
 	defer fake.pingMutex.RUnlock()
 	fake.capacityMutex.RLock()
 	defer fake.capacityMutex.RUnlock()
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
 	fake.createMutex.RLock()
 	defer fake.createMutex.RUnlock()
 	fake.destroyMutex.RLock()
 	defer fake.destroyMutex.RUnlock()
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
 	fake.containersMutex.RLock()
 	defer fake.containersMutex.RUnlock()
 	fake.bulkInfoMutex.RLock()
@@ -459,6 +658,10 @@ func (fake *FakeBackend) Invocations() map[string][][]interface{} {
 	defer fake.stopMutex.RUnlock()
 	fake.graceTimeMutex.RLock()
 	defer fake.graceTimeMutex.RUnlock()
+	fake.containerForHostPIDMutex.RLock()
+	defer fake.containerForHostPIDMutex.RUnlock()
+	fake.oOMEventsMutex.RLock()
+	defer fake.oOMEventsMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -21,6 +21,21 @@ type FakeClient struct {
 		result1 garden.Capacity
 		result2 error
 	}
+	FeaturesStub        func() (garden.FeatureSet, error)
+	featuresMutex       sync.RWMutex
+	featuresArgsForCall []struct{}
+	featuresReturns     struct {
+		result1 garden.FeatureSet
+		result2 error
+	}
+	ValidateRootFSStub        func(rootfs string) error
+	validateRootFSMutex       sync.RWMutex
+	validateRootFSArgsForCall []struct {
+		rootfs string
+	}
+	validateRootFSReturns struct {
+		result1 error
+	}
 	CreateStub        func(garden.ContainerSpec) (garden.Container, error)
 	createMutex       sync.RWMutex
 	createArgsForCall []struct {
@@ -38,6 +53,15 @@ type FakeClient struct {
 	destroyReturns struct {
 		result1 error
 	}
+	RenameStub        func(oldHandle, newHandle string) error
+	renameMutex       sync.RWMutex
+	renameArgsForCall []struct {
+		oldHandle string
+		newHandle string
+	}
+	renameReturns struct {
+		result1 error
+	}
 	ContainersStub        func(garden.Properties) ([]garden.Container, error)
 	containersMutex       sync.RWMutex
 	containersArgsForCall []struct {
@@ -129,6 +153,65 @@ func (fake *FakeClient) CapacityReturns(result1 garden.Capacity, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeClient) Features() (garden.FeatureSet, error) {
+	fake.featuresMutex.Lock()
+	fake.featuresArgsForCall = append(fake.featuresArgsForCall, struct{}{})
+	fake.recordInvocation("Features", []interface{}{})
+	fake.featuresMutex.Unlock()
+	if fake.FeaturesStub != nil {
+		return fake.FeaturesStub()
+	} else {
+		return fake.featuresReturns.result1, fake.featuresReturns.result2
+	}
+}
+
+func (fake *FakeClient) FeaturesCallCount() int {
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	return len(fake.featuresArgsForCall)
+}
+
+func (fake *FakeClient) FeaturesReturns(result1 garden.FeatureSet, result2 error) {
+	fake.FeaturesStub = nil
+	fake.featuresReturns = struct {
+		result1 garden.FeatureSet
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ValidateRootFS(rootfs string) error {
+	fake.validateRootFSMutex.Lock()
+	fake.validateRootFSArgsForCall = append(fake.validateRootFSArgsForCall, struct {
+		rootfs string
+	}{rootfs})
+	fake.recordInvocation("ValidateRootFS", []interface{}{rootfs})
+	fake.validateRootFSMutex.Unlock()
+	if fake.ValidateRootFSStub != nil {
+		return fake.ValidateRootFSStub(rootfs)
+	} else {
+		return fake.validateRootFSReturns.result1
+	}
+}
+
+func (fake *FakeClient) ValidateRootFSCallCount() int {
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
+	return len(fake.validateRootFSArgsForCall)
+}
+
+func (fake *FakeClient) ValidateRootFSArgsForCall(i int) string {
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
+	return fake.validateRootFSArgsForCall[i].rootfs
+}
+
+func (fake *FakeClient) ValidateRootFSReturns(result1 error) {
+	fake.ValidateRootFSStub = nil
+	fake.validateRootFSReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeClient) Create(arg1 garden.ContainerSpec) (garden.Container, error) {
 	fake.createMutex.Lock()
 	fake.createArgsForCall = append(fake.createArgsForCall, struct {
@@ -196,6 +279,40 @@ func (fake *FakeClient) DestroyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeClient) Rename(oldHandle string, newHandle string) error {
+	fake.renameMutex.Lock()
+	fake.renameArgsForCall = append(fake.renameArgsForCall, struct {
+		oldHandle string
+		newHandle string
+	}{oldHandle, newHandle})
+	fake.recordInvocation("Rename", []interface{}{oldHandle, newHandle})
+	fake.renameMutex.Unlock()
+	if fake.RenameStub != nil {
+		return fake.RenameStub(oldHandle, newHandle)
+	} else {
+		return fake.renameReturns.result1
+	}
+}
+
+func (fake *FakeClient) RenameCallCount() int {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return len(fake.renameArgsForCall)
+}
+
+func (fake *FakeClient) RenameArgsForCall(i int) (string, string) {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return fake.renameArgsForCall[i].oldHandle, fake.renameArgsForCall[i].newHandle
+}
+
+func (fake *FakeClient) RenameReturns(result1 error) {
+	fake.RenameStub = nil
+	fake.renameReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeClient) Containers(arg1 garden.Properties) ([]garden.Container, error) {
 	fake.containersMutex.Lock()
 	fake.containersArgsForCall = append(fake.containersArgsForCall, struct {
@@ -349,10 +466,16 @@ func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	defer fake.pingMutex.RUnlock()
 	fake.capacityMutex.RLock()
 	defer fake.capacityMutex.RUnlock()
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	fake.validateRootFSMutex.RLock()
+	defer fake.validateRootFSMutex.RUnlock()
 	fake.createMutex.RLock()
 	defer fake.createMutex.RUnlock()
 	fake.destroyMutex.RLock()
 	defer fake.destroyMutex.RUnlock()
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
 	fake.containersMutex.RLock()
 	defer fake.containersMutex.RUnlock()
 	fake.bulkInfoMutex.RLock()
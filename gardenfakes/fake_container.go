@@ -24,6 +24,28 @@ type FakeContainer struct {
 	stopReturns struct {
 		result1 error
 	}
+	CheckpointStub        func(imagePath string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		imagePath string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
+	RestoreStub        func(imagePath string) error
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		imagePath string
+	}
+	restoreReturns struct {
+		result1 error
+	}
+	SyncStub        func() error
+	syncMutex       sync.RWMutex
+	syncArgsForCall []struct{}
+	syncReturns     struct {
+		result1 error
+	}
 	InfoStub        func() (garden.ContainerInfo, error)
 	infoMutex       sync.RWMutex
 	infoArgsForCall []struct{}
@@ -76,6 +98,22 @@ type FakeContainer struct {
 		result1 garden.MemoryLimits
 		result2 error
 	}
+	CurrentIOLimitsStub        func() (garden.IOLimits, error)
+	currentIOLimitsMutex       sync.RWMutex
+	currentIOLimitsArgsForCall []struct{}
+	currentIOLimitsReturns     struct {
+		result1 garden.IOLimits
+		result2 error
+	}
+	LimitAllStub        func(limits garden.LimitsUpdate) (garden.Limits, error)
+	limitAllMutex       sync.RWMutex
+	limitAllArgsForCall []struct {
+		limits garden.LimitsUpdate
+	}
+	limitAllReturns struct {
+		result1 garden.Limits
+		result2 error
+	}
 	NetInStub        func(hostPort, containerPort uint32) (uint32, uint32, error)
 	netInMutex       sync.RWMutex
 	netInArgsForCall []struct {
@@ -103,6 +141,21 @@ type FakeContainer struct {
 	bulkNetOutReturns struct {
 		result1 error
 	}
+	NetworkPolicyStub        func() (garden.NetworkPolicy, error)
+	networkPolicyMutex       sync.RWMutex
+	networkPolicyArgsForCall []struct{}
+	networkPolicyReturns     struct {
+		result1 garden.NetworkPolicy
+		result2 error
+	}
+	SetNetworkPolicyStub        func(policy garden.NetworkPolicy) error
+	setNetworkPolicyMutex       sync.RWMutex
+	setNetworkPolicyArgsForCall []struct {
+		policy garden.NetworkPolicy
+	}
+	setNetworkPolicyReturns struct {
+		result1 error
+	}
 	RunStub        func(garden.ProcessSpec, garden.ProcessIO) (garden.Process, error)
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
@@ -130,6 +183,13 @@ type FakeContainer struct {
 		result1 garden.Metrics
 		result2 error
 	}
+	ProcessStatsStub        func() (map[uint32]garden.ProcessStat, error)
+	processStatsMutex       sync.RWMutex
+	processStatsArgsForCall []struct{}
+	processStatsReturns     struct {
+		result1 map[uint32]garden.ProcessStat
+		result2 error
+	}
 	SetGraceTimeStub        func(graceTime time.Duration) error
 	setGraceTimeMutex       sync.RWMutex
 	setGraceTimeArgsForCall []struct {
@@ -233,6 +293,97 @@ func (fake *FakeContainer) StopReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) Checkpoint(imagePath string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		imagePath string
+	}{imagePath})
+	fake.recordInvocation("Checkpoint", []interface{}{imagePath})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(imagePath)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeContainer) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeContainer) CheckpointArgsForCall(i int) string {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].imagePath
+}
+
+func (fake *FakeContainer) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) Restore(imagePath string) error {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		imagePath string
+	}{imagePath})
+	fake.recordInvocation("Restore", []interface{}{imagePath})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(imagePath)
+	} else {
+		return fake.restoreReturns.result1
+	}
+}
+
+func (fake *FakeContainer) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeContainer) RestoreArgsForCall(i int) string {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].imagePath
+}
+
+func (fake *FakeContainer) RestoreReturns(result1 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) Sync() error {
+	fake.syncMutex.Lock()
+	fake.syncArgsForCall = append(fake.syncArgsForCall, struct{}{})
+	fake.recordInvocation("Sync", []interface{}{})
+	fake.syncMutex.Unlock()
+	if fake.SyncStub != nil {
+		return fake.SyncStub()
+	} else {
+		return fake.syncReturns.result1
+	}
+}
+
+func (fake *FakeContainer) SyncCallCount() int {
+	fake.syncMutex.RLock()
+	defer fake.syncMutex.RUnlock()
+	return len(fake.syncArgsForCall)
+}
+
+func (fake *FakeContainer) SyncReturns(result1 error) {
+	fake.SyncStub = nil
+	fake.syncReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeContainer) Info() (garden.ContainerInfo, error) {
 	fake.infoMutex.Lock()
 	fake.infoArgsForCall = append(fake.infoArgsForCall, struct{}{})
@@ -430,6 +581,66 @@ func (fake *FakeContainer) CurrentMemoryLimitsReturns(result1 garden.MemoryLimit
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) CurrentIOLimits() (garden.IOLimits, error) {
+	fake.currentIOLimitsMutex.Lock()
+	fake.currentIOLimitsArgsForCall = append(fake.currentIOLimitsArgsForCall, struct{}{})
+	fake.recordInvocation("CurrentIOLimits", []interface{}{})
+	fake.currentIOLimitsMutex.Unlock()
+	if fake.CurrentIOLimitsStub != nil {
+		return fake.CurrentIOLimitsStub()
+	} else {
+		return fake.currentIOLimitsReturns.result1, fake.currentIOLimitsReturns.result2
+	}
+}
+
+func (fake *FakeContainer) CurrentIOLimitsCallCount() int {
+	fake.currentIOLimitsMutex.RLock()
+	defer fake.currentIOLimitsMutex.RUnlock()
+	return len(fake.currentIOLimitsArgsForCall)
+}
+
+func (fake *FakeContainer) CurrentIOLimitsReturns(result1 garden.IOLimits, result2 error) {
+	fake.CurrentIOLimitsStub = nil
+	fake.currentIOLimitsReturns = struct {
+		result1 garden.IOLimits
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) LimitAll(limits garden.LimitsUpdate) (garden.Limits, error) {
+	fake.limitAllMutex.Lock()
+	fake.limitAllArgsForCall = append(fake.limitAllArgsForCall, struct {
+		limits garden.LimitsUpdate
+	}{limits})
+	fake.recordInvocation("LimitAll", []interface{}{limits})
+	fake.limitAllMutex.Unlock()
+	if fake.LimitAllStub != nil {
+		return fake.LimitAllStub(limits)
+	} else {
+		return fake.limitAllReturns.result1, fake.limitAllReturns.result2
+	}
+}
+
+func (fake *FakeContainer) LimitAllCallCount() int {
+	fake.limitAllMutex.RLock()
+	defer fake.limitAllMutex.RUnlock()
+	return len(fake.limitAllArgsForCall)
+}
+
+func (fake *FakeContainer) LimitAllArgsForCall(i int) garden.LimitsUpdate {
+	fake.limitAllMutex.RLock()
+	defer fake.limitAllMutex.RUnlock()
+	return fake.limitAllArgsForCall[i].limits
+}
+
+func (fake *FakeContainer) LimitAllReturns(result1 garden.Limits, result2 error) {
+	fake.LimitAllStub = nil
+	fake.limitAllReturns = struct {
+		result1 garden.Limits
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) NetIn(hostPort uint32, containerPort uint32) (uint32, uint32, error) {
 	fake.netInMutex.Lock()
 	fake.netInArgsForCall = append(fake.netInArgsForCall, struct {
@@ -537,6 +748,65 @@ func (fake *FakeContainer) BulkNetOutReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) NetworkPolicy() (garden.NetworkPolicy, error) {
+	fake.networkPolicyMutex.Lock()
+	fake.networkPolicyArgsForCall = append(fake.networkPolicyArgsForCall, struct{}{})
+	fake.recordInvocation("NetworkPolicy", []interface{}{})
+	fake.networkPolicyMutex.Unlock()
+	if fake.NetworkPolicyStub != nil {
+		return fake.NetworkPolicyStub()
+	} else {
+		return fake.networkPolicyReturns.result1, fake.networkPolicyReturns.result2
+	}
+}
+
+func (fake *FakeContainer) NetworkPolicyCallCount() int {
+	fake.networkPolicyMutex.RLock()
+	defer fake.networkPolicyMutex.RUnlock()
+	return len(fake.networkPolicyArgsForCall)
+}
+
+func (fake *FakeContainer) NetworkPolicyReturns(result1 garden.NetworkPolicy, result2 error) {
+	fake.NetworkPolicyStub = nil
+	fake.networkPolicyReturns = struct {
+		result1 garden.NetworkPolicy
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) SetNetworkPolicy(policy garden.NetworkPolicy) error {
+	fake.setNetworkPolicyMutex.Lock()
+	fake.setNetworkPolicyArgsForCall = append(fake.setNetworkPolicyArgsForCall, struct {
+		policy garden.NetworkPolicy
+	}{policy})
+	fake.recordInvocation("SetNetworkPolicy", []interface{}{policy})
+	fake.setNetworkPolicyMutex.Unlock()
+	if fake.SetNetworkPolicyStub != nil {
+		return fake.SetNetworkPolicyStub(policy)
+	} else {
+		return fake.setNetworkPolicyReturns.result1
+	}
+}
+
+func (fake *FakeContainer) SetNetworkPolicyCallCount() int {
+	fake.setNetworkPolicyMutex.RLock()
+	defer fake.setNetworkPolicyMutex.RUnlock()
+	return len(fake.setNetworkPolicyArgsForCall)
+}
+
+func (fake *FakeContainer) SetNetworkPolicyArgsForCall(i int) garden.NetworkPolicy {
+	fake.setNetworkPolicyMutex.RLock()
+	defer fake.setNetworkPolicyMutex.RUnlock()
+	return fake.setNetworkPolicyArgsForCall[i].policy
+}
+
+func (fake *FakeContainer) SetNetworkPolicyReturns(result1 error) {
+	fake.SetNetworkPolicyStub = nil
+	fake.setNetworkPolicyReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeContainer) Run(arg1 garden.ProcessSpec, arg2 garden.ProcessIO) (garden.Process, error) {
 	fake.runMutex.Lock()
 	fake.runArgsForCall = append(fake.runArgsForCall, struct {
@@ -633,6 +903,32 @@ func (fake *FakeContainer) MetricsReturns(result1 garden.Metrics, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) ProcessStats() (map[uint32]garden.ProcessStat, error) {
+	fake.processStatsMutex.Lock()
+	fake.processStatsArgsForCall = append(fake.processStatsArgsForCall, struct{}{})
+	fake.recordInvocation("ProcessStats", []interface{}{})
+	fake.processStatsMutex.Unlock()
+	if fake.ProcessStatsStub != nil {
+		return fake.ProcessStatsStub()
+	} else {
+		return fake.processStatsReturns.result1, fake.processStatsReturns.result2
+	}
+}
+
+func (fake *FakeContainer) ProcessStatsCallCount() int {
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
+	return len(fake.processStatsArgsForCall)
+}
+
+func (fake *FakeContainer) ProcessStatsReturns(result1 map[uint32]garden.ProcessStat, result2 error) {
+	fake.ProcessStatsStub = nil
+	fake.processStatsReturns = struct {
+		result1 map[uint32]garden.ProcessStat
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) SetGraceTime(graceTime time.Duration) error {
 	fake.setGraceTimeMutex.Lock()
 	fake.setGraceTimeArgsForCall = append(fake.setGraceTimeArgsForCall, struct {
@@ -800,6 +1096,12 @@ func (fake *FakeContainer) Invocations() map[string][][]interface{} {
 	defer fake.handleMutex.RUnlock()
 	fake.stopMutex.RLock()
 	defer fake.stopMutex.RUnlock()
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	fake.syncMutex.RLock()
+	defer fake.syncMutex.RUnlock()
 	fake.infoMutex.RLock()
 	defer fake.infoMutex.RUnlock()
 	fake.streamInMutex.RLock()
@@ -814,18 +1116,28 @@ func (fake *FakeContainer) Invocations() map[string][][]interface{} {
 	defer fake.currentDiskLimitsMutex.RUnlock()
 	fake.currentMemoryLimitsMutex.RLock()
 	defer fake.currentMemoryLimitsMutex.RUnlock()
+	fake.currentIOLimitsMutex.RLock()
+	defer fake.currentIOLimitsMutex.RUnlock()
+	fake.limitAllMutex.RLock()
+	defer fake.limitAllMutex.RUnlock()
 	fake.netInMutex.RLock()
 	defer fake.netInMutex.RUnlock()
 	fake.netOutMutex.RLock()
 	defer fake.netOutMutex.RUnlock()
 	fake.bulkNetOutMutex.RLock()
 	defer fake.bulkNetOutMutex.RUnlock()
+	fake.networkPolicyMutex.RLock()
+	defer fake.networkPolicyMutex.RUnlock()
+	fake.setNetworkPolicyMutex.RLock()
+	defer fake.setNetworkPolicyMutex.RUnlock()
 	fake.runMutex.RLock()
 	defer fake.runMutex.RUnlock()
 	fake.attachMutex.RLock()
 	defer fake.attachMutex.RUnlock()
 	fake.metricsMutex.RLock()
 	defer fake.metricsMutex.RUnlock()
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
 	fake.setGraceTimeMutex.RLock()
 	defer fake.setGraceTimeMutex.RUnlock()
 	fake.propertiesMutex.RLock()
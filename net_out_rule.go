@@ -19,6 +19,30 @@ type NetOutRule struct {
 	Log bool `json:"log,omitempty"`
 }
 
+// NetworkAction is what happens to egress traffic.
+type NetworkAction string
+
+const (
+	NetworkActionAllow NetworkAction = "allow"
+	NetworkActionDeny  NetworkAction = "deny"
+)
+
+// NetworkPolicy is the whole egress policy of a container: traffic matching
+// one of the rules is allowed, and any other traffic gets the default
+// action. With a default action of allow, the rules have no effect.
+type NetworkPolicy struct {
+	DefaultAction NetworkAction `json:"default_action"`
+	Rules         []NetOutRule  `json:"rules,omitempty"`
+}
+
+// ContainerEgressRules is the egress policy in effect for a container, along
+// with its IP address for context.
+type ContainerEgressRules struct {
+	ContainerIP   string        `json:"container_ip,omitempty"`
+	DefaultAction NetworkAction `json:"default_action,omitempty"`
+	Rules         []NetOutRule  `json:"rules,omitempty"`
+}
+
 type Protocol uint8
 
 const (
@@ -3,20 +3,43 @@ package routes
 import "github.com/tedsuo/rata"
 
 const (
-	Ping     = "Ping"
-	Capacity = "Capacity"
+	Ping          = "Ping"
+	Capacity      = "Capacity"
+	WatchCapacity = "WatchCapacity"
+	WatchOOMs     = "WatchOOMs"
+	Features      = "Features"
+	Selftest      = "Selftest"
+	Health        = "Health"
+
+	ValidateRootFS = "ValidateRootFS"
+
+	ContainerForHostPID = "ContainerForHostPID"
+
+	SetDrainMode = "SetDrainMode"
 
 	List        = "List"
 	Create      = "Create"
 	Info        = "Info"
+	Spec        = "Spec"
 	BulkInfo    = "BulkInfo"
 	BulkMetrics = "BulkMetrics"
 	Destroy     = "Destroy"
+	Rename      = "Rename"
+
+	DestroyByProperties = "DestroyByProperties"
+
+	ListPortMappings = "ListPortMappings"
 
-	Stop = "Stop"
+	Stop       = "Stop"
+	Checkpoint = "Checkpoint"
+	Restore    = "Restore"
+	Sync       = "Sync"
 
-	StreamIn  = "StreamIn"
-	StreamOut = "StreamOut"
+	StreamIn        = "StreamIn"
+	StreamOut       = "StreamOut"
+	StreamOutputLog = "StreamOutputLog"
+	WriteFile       = "WriteFile"
+	ReadFile        = "ReadFile"
 
 	Stdout = "Stdout"
 	Stderr = "Stderr"
@@ -25,13 +48,32 @@ const (
 	CurrentCPULimits       = "CurrentCPULimits"
 	CurrentDiskLimits      = "CurrentDiskLimits"
 	CurrentMemoryLimits    = "CurrentMemoryLimits"
+	CurrentIOLimits        = "CurrentIOLimits"
+	LimitAll               = "LimitAll"
+	BoostLimits            = "BoostLimits"
 
 	NetIn      = "NetIn"
 	NetOut     = "NetOut"
 	BulkNetOut = "BulkNetOut"
 
-	Run    = "Run"
-	Attach = "Attach"
+	AllEgressRules = "AllEgressRules"
+
+	NetworkPolicy    = "NetworkPolicy"
+	SetNetworkPolicy = "SetNetworkPolicy"
+
+	AllowTraffic = "AllowTraffic"
+	DenyTraffic  = "DenyTraffic"
+
+	Run           = "Run"
+	Attach        = "Attach"
+	AttachAll     = "AttachAll"
+	ProcessStatus = "ProcessStatus"
+	ProcessLogs   = "ProcessLogs"
+	ProcessEnv    = "ProcessEnv"
+
+	WaitForProcesses = "WaitForProcesses"
+
+	ProcessAttachments = "ProcessAttachments"
 
 	SetGraceTime = "SetGraceTime"
 
@@ -39,41 +81,77 @@ const (
 	Property    = "Property"
 	SetProperty = "SetProperty"
 
-	Metrics = "Metrics"
+	Metrics       = "Metrics"
+	ProcessStats  = "ProcessStats"
+	ResourceUsage = "ResourceUsage"
 
-	RemoveProperty = "RemoveProperty"
+	RemoveProperty    = "RemoveProperty"
+	SetPropertyForAll = "SetPropertyForAll"
 )
 
 var Routes = rata.Routes{
 	{Path: "/ping", Method: "GET", Name: Ping},
 	{Path: "/capacity", Method: "GET", Name: Capacity},
+	{Path: "/capacity/watch", Method: "GET", Name: WatchCapacity},
+	{Path: "/ooms/watch", Method: "GET", Name: WatchOOMs},
+	{Path: "/host_pids/:pid/container", Method: "GET", Name: ContainerForHostPID},
+	{Path: "/features", Method: "GET", Name: Features},
+	{Path: "/selftest", Method: "POST", Name: Selftest},
+	{Path: "/health", Method: "GET", Name: Health},
+	{Path: "/rootfs/validate", Method: "GET", Name: ValidateRootFS},
+	{Path: "/drain", Method: "PUT", Name: SetDrainMode},
 
 	{Path: "/containers", Method: "GET", Name: List},
 	{Path: "/containers", Method: "POST", Name: Create},
 
 	{Path: "/containers/:handle/info", Method: "GET", Name: Info},
+	{Path: "/containers/:handle/spec", Method: "GET", Name: Spec},
 	{Path: "/containers/bulk_info", Method: "GET", Name: BulkInfo},
 	{Path: "/containers/bulk_metrics", Method: "GET", Name: BulkMetrics},
+	{Path: "/containers/port_mappings", Method: "GET", Name: ListPortMappings},
+	{Path: "/containers/bulk_properties/:key", Method: "PUT", Name: SetPropertyForAll},
+	{Path: "/containers/bulk_destroy", Method: "POST", Name: DestroyByProperties},
 
 	{Path: "/containers/:handle", Method: "DELETE", Name: Destroy},
 	{Path: "/containers/:handle/stop", Method: "PUT", Name: Stop},
+	{Path: "/containers/:handle/checkpoint", Method: "POST", Name: Checkpoint},
+	{Path: "/containers/:handle/restore", Method: "POST", Name: Restore},
+	{Path: "/containers/:handle/sync", Method: "POST", Name: Sync},
+	{Path: "/containers/:handle/rename", Method: "PUT", Name: Rename},
 
 	{Path: "/containers/:handle/files", Method: "PUT", Name: StreamIn},
 	{Path: "/containers/:handle/files", Method: "GET", Name: StreamOut},
+	{Path: "/containers/:handle/output_logs/:name", Method: "GET", Name: StreamOutputLog},
+	{Path: "/containers/:handle/file", Method: "PUT", Name: WriteFile},
+	{Path: "/containers/:handle/file", Method: "GET", Name: ReadFile},
 
 	{Path: "/containers/:handle/limits/bandwidth", Method: "GET", Name: CurrentBandwidthLimits},
 	{Path: "/containers/:handle/limits/cpu", Method: "GET", Name: CurrentCPULimits},
 	{Path: "/containers/:handle/limits/disk", Method: "GET", Name: CurrentDiskLimits},
 	{Path: "/containers/:handle/limits/memory", Method: "GET", Name: CurrentMemoryLimits},
+	{Path: "/containers/:handle/limits/io", Method: "GET", Name: CurrentIOLimits},
+	{Path: "/containers/:handle/limits", Method: "PUT", Name: LimitAll},
+	{Path: "/containers/:handle/limits/boost", Method: "POST", Name: BoostLimits},
 
 	{Path: "/containers/:handle/net/in", Method: "POST", Name: NetIn},
 	{Path: "/containers/:handle/net/out", Method: "POST", Name: NetOut},
 	{Path: "/containers/:handle/net/out/bulk", Method: "POST", Name: BulkNetOut},
+	{Path: "/net/out", Method: "GET", Name: AllEgressRules},
+	{Path: "/containers/:handle/net/policy", Method: "GET", Name: NetworkPolicy},
+	{Path: "/containers/:handle/net/policy", Method: "PUT", Name: SetNetworkPolicy},
+	{Path: "/containers/:handle/net/peers/:peer", Method: "PUT", Name: AllowTraffic},
+	{Path: "/containers/:handle/net/peers/:peer", Method: "DELETE", Name: DenyTraffic},
 
 	{Path: "/containers/:handle/processes/:pid/attaches/:streamid/stdout", Method: "GET", Name: Stdout},
 	{Path: "/containers/:handle/processes/:pid/attaches/:streamid/stderr", Method: "GET", Name: Stderr},
 	{Path: "/containers/:handle/processes", Method: "POST", Name: Run},
 	{Path: "/containers/:handle/processes/:pid", Method: "GET", Name: Attach},
+	{Path: "/containers/:handle/processes/:pid/status", Method: "GET", Name: ProcessStatus},
+	{Path: "/containers/:handle/processes/:pid/logs", Method: "GET", Name: ProcessLogs},
+	{Path: "/containers/:handle/processes/:pid/env", Method: "GET", Name: ProcessEnv},
+	{Path: "/containers/:handle/attachments", Method: "GET", Name: ProcessAttachments},
+	{Path: "/containers/:handle/output", Method: "GET", Name: AttachAll},
+	{Path: "/containers/:handle/wait", Method: "GET", Name: WaitForProcesses},
 
 	{Path: "/containers/:handle/grace_time", Method: "PUT", Name: SetGraceTime},
 
@@ -83,4 +161,6 @@ var Routes = rata.Routes{
 	{Path: "/containers/:handle/properties/:key", Method: "DELETE", Name: RemoveProperty},
 
 	{Path: "/containers/:handle/metrics", Method: "GET", Name: Metrics},
+	{Path: "/containers/:handle/process_stats", Method: "GET", Name: ProcessStats},
+	{Path: "/containers/:handle/usage", Method: "GET", Name: ResourceUsage},
 }
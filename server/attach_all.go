@@ -0,0 +1,182 @@
+package server
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/transport"
+	"code.cloudfoundry.org/lager"
+)
+
+// handleAttachAll streams the output of every process in a container, tagged
+// with the process it came from. It attaches to the processes running when
+// the stream starts and to those run through the server while it lasts; each
+// process's output ends with a message carrying its exit status. The stream
+// lasts until the client disconnects, even if no processes are left, or until
+// the container is destroyed.
+func (s *GardenServer) handleAttachAll(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("attach-all", lager.Data{
+		"handle": handle,
+	})
+
+	// watch before listing the processes, so that none is missed in between
+	spawned, destroyed, stopWatching := s.processTracker.watch(handle)
+	defer stopWatching()
+
+	s.handleLocks.RLock(handle)
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.handleLocks.RUnlock(handle)
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	info, err := container.Info()
+	s.handleLocks.RUnlock(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	defer conn.Close()
+
+	stream := s.limitOutputRate(conn)
+
+	// the client never sends anything, so a read only returns once it has
+	// gone away
+	disconnected := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, br)
+		close(disconnected)
+	}()
+
+	output := make(chan garden.TaggedOutput, 1000)
+	done := make(chan struct{})
+	defer close(done)
+
+	attached := map[string]bool{}
+	attach := func(processID string) {
+		if attached[processID] {
+			return
+		}
+		attached[processID] = true
+
+		s.attachForOutput(hLog, container, processID, output, done)
+	}
+
+	for _, processID := range info.ProcessIDs {
+		attach(processID)
+	}
+
+	for {
+		select {
+		case chunk := <-output:
+			if err := transport.WriteMessage(stream, chunk); err != nil {
+				hLog.Debug("disconnected")
+				return
+			}
+
+		case processID := <-spawned:
+			attach(processID)
+
+		case <-disconnected:
+			hLog.Debug("disconnected")
+			return
+
+		case <-destroyed:
+			hLog.Debug("container-destroyed")
+			return
+
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// attachForOutput sends a process's output, and then its exit status, to the
+// output channel until done is closed. A process which can no longer be
+// attached to, e.g. because it has already exited, is skipped. Once done is
+// closed, the process's output is refused and its exit no longer waited for,
+// other than by the one wait shared by every stream.
+func (s *GardenServer) attachForOutput(logger lager.Logger, container garden.Container, processID string, output chan<- garden.TaggedOutput, done <-chan struct{}) {
+	process, err := container.Attach(processID, garden.ProcessIO{
+		Stdout: &taggedWriter{processID: processID, source: garden.OutputSourceStdout, output: output, done: done},
+		Stderr: &taggedWriter{processID: processID, source: garden.OutputSourceStderr, output: output, done: done},
+	})
+	if err != nil {
+		logger.Debug("skipping-process", lager.Data{
+			"id":    processID,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	exit := s.processExits.wait(container.Handle(), process)
+
+	go func() {
+		select {
+		case <-exit.exited:
+		case <-done:
+			return
+		}
+
+		if exit.err != nil {
+			logger.Error("wait-failed", exit.err, lager.Data{
+				"id": processID,
+			})
+			return
+		}
+
+		select {
+		case output <- garden.TaggedOutput{ProcessID: processID, Exited: true, ExitStatus: exit.status}:
+		case <-done:
+		}
+	}()
+}
+
+// taggedWriter is a chanWriter for output which is streamed along with that
+// of other processes. Once done is closed, writes fail, so that the backend
+// stops copying the output to it.
+type taggedWriter struct {
+	processID string
+	source    garden.OutputSource
+	output    chan<- garden.TaggedOutput
+	done      <-chan struct{}
+}
+
+func (w *taggedWriter) Write(d []byte) (int, error) {
+	select {
+	case <-w.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	// prevent buffer reuse from clobbering the data
+	data := make([]byte, len(d))
+	copy(data, d)
+
+	select {
+	case w.output <- garden.TaggedOutput{ProcessID: w.processID, Source: w.source, Data: data}:
+	default:
+		// as with chanWriter, writes never block; the channel is buffered to
+		// account for slow consumers
+	}
+
+	return len(d), nil
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+// attachmentTracker remembers which client connections are streaming from
+// each container's processes, so that connections which are holding a
+// process's streams open can be found.
+type attachmentTracker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	attachments map[string]map[uint64]garden.Attachment
+}
+
+func newAttachmentTracker() *attachmentTracker {
+	return &attachmentTracker{
+		attachments: make(map[string]map[uint64]garden.Attachment),
+	}
+}
+
+// add records the attachment until the returned function is called.
+func (t *attachmentTracker) add(handle string, attachment garden.Attachment) func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nextID++
+	id := t.nextID
+
+	if t.attachments[handle] == nil {
+		t.attachments[handle] = make(map[uint64]garden.Attachment)
+	}
+
+	t.attachments[handle][id] = attachment
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// the container may have been renamed since, so the attachment is
+		// looked for under every handle
+		for handle, attachments := range t.attachments {
+			if _, found := attachments[id]; found {
+				delete(attachments, id)
+				if len(attachments) == 0 {
+					delete(t.attachments, handle)
+				}
+			}
+		}
+	}
+}
+
+func (t *attachmentTracker) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attachments, found := t.attachments[oldHandle]
+	if !found {
+		return
+	}
+
+	delete(t.attachments, oldHandle)
+
+	if t.attachments[newHandle] == nil {
+		t.attachments[newHandle] = make(map[uint64]garden.Attachment)
+	}
+
+	for id, attachment := range attachments {
+		t.attachments[newHandle][id] = attachment
+	}
+}
+
+// list returns the container's attachments, oldest first.
+func (t *attachmentTracker) list(handle string) []garden.Attachment {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attachments := []garden.Attachment{}
+	for _, attachment := range t.attachments[handle] {
+		attachments = append(attachments, attachment)
+	}
+
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].AttachedAt.Before(attachments[j].AttachedAt)
+	})
+
+	return attachments
+}
+
+// trackAttachment records a client's connection to a process's streams for
+// as long as the returned function has not been called.
+func (s *GardenServer) trackAttachment(handle string, processID string, streamID string, remoteAddr string) func() {
+	return s.attachments.add(handle, garden.Attachment{
+		ProcessID:  processID,
+		StreamID:   streamID,
+		RemoteAddr: remoteAddr,
+		AttachedAt: time.Now(),
+	})
+}
+
+func (s *GardenServer) handleProcessAttachments(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("get-process-attachments", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	s.writeResponse(w, s.attachments.list(container.Handle()))
+}
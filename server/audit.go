@@ -0,0 +1,213 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/routes"
+)
+
+// auditedRoutes are the operations which change a container's existence or
+// its resources, and so are recorded in the audit trail.
+var auditedRoutes = map[string]bool{
+	routes.Create:              true,
+	routes.Destroy:             true,
+	routes.DestroyByProperties: true,
+	routes.Rename:              true,
+	routes.Stop:                true,
+	routes.LimitAll:            true,
+	routes.BoostLimits:         true,
+}
+
+// auditRedacted replaces the values of request fields which may hold secrets.
+const auditRedacted = "[redacted]"
+
+// auditSecretFields are the request fields, at any depth, whose values are
+// redacted: environment variables and properties commonly carry
+// credentials, and image references may carry a registry password.
+var auditSecretFields = map[string]bool{
+	"env":        true,
+	"properties": true,
+	"password":   true,
+}
+
+// auditCaptureLimit bounds how much of a request or response body is kept to
+// build an audit event.
+const auditCaptureLimit = 64 * 1024
+
+// AuditEvent records a container operation handled by the server.
+type AuditEvent struct {
+	Time      time.Time
+	Operation string // the route name, e.g. routes.Create
+
+	// Handle is the container operated on; for a create without a handle it
+	// is the handle the server chose. It is empty for operations on several
+	// containers.
+	Handle string
+
+	// Params are the request's query parameters and the top-level fields of
+	// its body, as JSON, with any secrets redacted.
+	Params map[string]string
+
+	Status   int
+	Error    string // the error returned to the client, if any
+	Duration time.Duration
+}
+
+// SetAuditSink registers a function called with an event each time the server
+// handles an operation which creates, destroys, renames, stops or limits a
+// container, whether or not it succeeds. It is called synchronously once the
+// response has been written, so it should return promptly. A nil sink, the
+// default, disables the events.
+func (s *GardenServer) SetAuditSink(sink func(AuditEvent)) {
+	s.auditSink.Store(sink)
+}
+
+// audited records an audit event for each request to an audited route, if an
+// audit sink is set.
+func (s *GardenServer) audited(route string, handler http.Handler) http.Handler {
+	if !auditedRoutes[route] {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sink, _ := s.auditSink.Load().(func(AuditEvent))
+		if sink == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		started := time.Now()
+
+		request := &limitedBuffer{limit: auditCaptureLimit}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, request), r.Body}
+
+		aw := &auditingWriter{ResponseWriter: w, status: http.StatusOK, body: limitedBuffer{limit: auditCaptureLimit}}
+		handler.ServeHTTP(aw, r)
+
+		event := AuditEvent{
+			Time:      started,
+			Operation: route,
+			Handle:    r.FormValue(":handle"),
+			Params:    auditParams(r, request),
+			Status:    aw.status,
+			Duration:  time.Since(started),
+		}
+
+		if aw.status >= 300 {
+			var merr garden.Error
+			if json.Unmarshal(aw.body.Bytes(), &merr) == nil && merr.Err != nil {
+				event.Error = merr.Err.Error()
+			}
+		} else if route == routes.Create {
+			var created struct{ Handle string }
+			json.Unmarshal(aw.body.Bytes(), &created)
+			event.Handle = created.Handle
+		}
+
+		sink(event)
+	})
+}
+
+// auditParams returns the request's query parameters and the top-level fields
+// of its JSON body, with secrets redacted.
+func auditParams(r *http.Request, body *limitedBuffer) map[string]string {
+	params := map[string]string{}
+	for name, values := range r.URL.Query() {
+		if strings.HasPrefix(name, ":") || len(values) == 0 {
+			continue
+		}
+
+		params[name] = values[0]
+		if auditSecretFields[strings.ToLower(name)] {
+			params[name] = auditRedacted
+		}
+	}
+
+	if body.truncated {
+		params["body"] = "[truncated]"
+		return params
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal(body.Bytes(), &fields) != nil {
+		return params
+	}
+
+	for name, value := range fields {
+		encoded, _ := json.Marshal(redactAuditSecrets(name, value))
+		params[name] = string(encoded)
+	}
+
+	return params
+}
+
+func redactAuditSecrets(name string, value interface{}) interface{} {
+	if auditSecretFields[strings.ToLower(name)] {
+		return auditRedacted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			v[field] = redactAuditSecrets(field, fieldValue)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactAuditSecrets("", element)
+		}
+	}
+
+	return value
+}
+
+// auditingWriter records the status and the start of the body of a response.
+type auditingWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	body        limitedBuffer
+}
+
+func (w *auditingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditingWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// limitedBuffer keeps up to limit bytes written to it, noting whether any
+// more were dropped.
+type limitedBuffer struct {
+	bytes.Buffer
+
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.Buffer.Write(p[:room])
+		b.truncated = true
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
@@ -1,6 +1,8 @@
 package bomberman
 
 import (
+	"time"
+
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/garden/server/timebomb"
 )
@@ -18,15 +20,28 @@ type bomb struct {
 	DefuseHandle   string
 }
 
+type activityQuery struct {
+	handle string
+	reply  chan time.Time
+}
+
+type activity struct {
+	last time.Time
+	busy int
+}
+
 type Bomberman struct {
 	backend garden.Backend
 
 	detonate func(garden.Container)
 
-	pause   chan string
-	unpause chan string
-	cleanup chan string
-	bomb    chan bomb
+	pause        chan string
+	unpause      chan string
+	observe      chan string
+	unobserve    chan string
+	cleanup      chan string
+	bomb         chan bomb
+	lastActivity chan activityQuery
 }
 
 func New(backend garden.Backend, detonate func(garden.Container)) *Bomberman {
@@ -34,10 +49,14 @@ func New(backend garden.Backend, detonate func(garden.Container)) *Bomberman {
 		backend:  backend,
 		detonate: detonate,
 
-		bomb:    make(chan bomb),
-		pause:   make(chan string),
-		unpause: make(chan string),
-		cleanup: make(chan string),
+		bomb:      make(chan bomb),
+		pause:     make(chan string),
+		unpause:   make(chan string),
+		observe:   make(chan string),
+		unobserve: make(chan string),
+		cleanup:   make(chan string),
+
+		lastActivity: make(chan activityQuery),
 	}
 
 	go b.manageBombs()
@@ -57,12 +76,35 @@ func (b *Bomberman) Unpause(name string) {
 	b.unpause <- name
 }
 
+// Observe pauses the named container's time bomb as Pause does, for a call
+// which only reads about the container, and so is not activity on it.
+func (b *Bomberman) Observe(name string) {
+	b.observe <- name
+}
+
+// Unobserve unpauses the named container's time bomb after Observe, again
+// without counting as activity.
+func (b *Bomberman) Unobserve(name string) {
+	b.unobserve <- name
+}
+
 func (b *Bomberman) Defuse(name string) {
 	b.bomb <- bomb{Action: defuse, DefuseHandle: name}
 }
 
+// LastActivity returns the last time the named container was strapped,
+// paused or unpaused, but not observed. While the container is paused it is
+// in use, so the current time is returned. The zero time is returned for
+// containers the bomberman does not know about.
+func (b *Bomberman) LastActivity(name string) time.Time {
+	reply := make(chan time.Time)
+	b.lastActivity <- activityQuery{handle: name, reply: reply}
+	return <-reply
+}
+
 func (b *Bomberman) manageBombs() {
 	timeBombs := map[string]*timebomb.TimeBomb{}
+	activities := map[string]*activity{}
 
 	for {
 		select {
@@ -71,6 +113,12 @@ func (b *Bomberman) manageBombs() {
 			case strap:
 				container := bombSignal.StrapContainer
 
+				if a, found := activities[container.Handle()]; found {
+					a.last = time.Now()
+				} else {
+					activities[container.Handle()] = &activity{last: time.Now()}
+				}
+
 				if b.backend.GraceTime(container) == 0 {
 					continue
 				}
@@ -87,6 +135,8 @@ func (b *Bomberman) manageBombs() {
 				bomb.Strap()
 
 			case defuse:
+				delete(activities, bombSignal.DefuseHandle)
+
 				bomb, found := timeBombs[bombSignal.DefuseHandle]
 				if !found {
 					continue
@@ -97,6 +147,11 @@ func (b *Bomberman) manageBombs() {
 				delete(timeBombs, bombSignal.DefuseHandle)
 			}
 		case handle := <-b.pause:
+			if a, found := activities[handle]; found {
+				a.last = time.Now()
+				a.busy++
+			}
+
 			bomb, found := timeBombs[handle]
 			if !found {
 				continue
@@ -105,6 +160,13 @@ func (b *Bomberman) manageBombs() {
 			bomb.Pause()
 
 		case handle := <-b.unpause:
+			if a, found := activities[handle]; found {
+				a.last = time.Now()
+				if a.busy > 0 {
+					a.busy--
+				}
+			}
+
 			bomb, found := timeBombs[handle]
 			if !found {
 				continue
@@ -112,8 +174,30 @@ func (b *Bomberman) manageBombs() {
 
 			bomb.Unpause()
 
+		case handle := <-b.observe:
+			if bomb, found := timeBombs[handle]; found {
+				bomb.Pause()
+			}
+
+		case handle := <-b.unobserve:
+			if bomb, found := timeBombs[handle]; found {
+				bomb.Unpause()
+			}
+
 		case handle := <-b.cleanup:
 			delete(timeBombs, handle)
+			delete(activities, handle)
+
+		case query := <-b.lastActivity:
+			a, found := activities[query.handle]
+			switch {
+			case !found:
+				query.reply <- time.Time{}
+			case a.busy > 0:
+				query.reply <- time.Now()
+			default:
+				query.reply <- a.last
+			}
 		}
 	}
 }
@@ -132,6 +132,39 @@ var _ = Describe("Bomberman", func() {
 		})
 	})
 
+	Describe("observing a container", func() {
+		It("prevents its timebomb from detonating", func() {
+			detonated := make(chan garden.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container garden.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			bomberman.Strap(container)
+			bomberman.Observe("doomed")
+
+			select {
+			case <-detonated:
+				Fail("detonated!")
+			case <-time.After(backend.GraceTime(container) * 2):
+			}
+
+			bomberman.Unobserve("doomed")
+
+			select {
+			case <-detonated:
+			case <-time.After(backend.GraceTime(container) * 2):
+				Fail("did not detonate!")
+			}
+		})
+	})
+
 	Describe("defusing a container's timebomb", func() {
 		It("prevents it from detonating", func() {
 			detonated := make(chan garden.Container)
@@ -166,4 +199,98 @@ var _ = Describe("Bomberman", func() {
 			})
 		})
 	})
+
+	Describe("tracking a container's last activity", func() {
+		var (
+			b         *bomberman.Bomberman
+			container *fakes.FakeContainer
+		)
+
+		BeforeEach(func() {
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(time.Minute)
+
+			b = bomberman.New(backend, func(garden.Container) {})
+
+			container = new(fakes.FakeContainer)
+			container.HandleReturns("busy")
+		})
+
+		It("records when the container was strapped", func() {
+			before := time.Now()
+			b.Strap(container)
+
+			Expect(b.LastActivity("busy")).To(BeTemporally(">=", before))
+		})
+
+		It("records when the container was last unpaused", func() {
+			b.Strap(container)
+			b.Pause("busy")
+
+			time.Sleep(10 * time.Millisecond)
+
+			before := time.Now()
+			b.Unpause("busy")
+
+			time.Sleep(100 * time.Millisecond)
+
+			lastActivity := b.LastActivity("busy")
+			Expect(lastActivity).To(BeTemporally(">=", before))
+			Expect(lastActivity).To(BeTemporally("<", time.Now().Add(-50*time.Millisecond)))
+		})
+
+		It("does not record the container being observed", func() {
+			b.Strap(container)
+
+			time.Sleep(100 * time.Millisecond)
+
+			b.Observe("busy")
+			Expect(b.LastActivity("busy")).To(BeTemporally("<", time.Now().Add(-50*time.Millisecond)))
+
+			b.Unobserve("busy")
+			Expect(b.LastActivity("busy")).To(BeTemporally("<", time.Now().Add(-50*time.Millisecond)))
+		})
+
+		It("reports the current time while the container is paused", func() {
+			b.Strap(container)
+			b.Pause("busy")
+
+			time.Sleep(10 * time.Millisecond)
+
+			before := time.Now()
+			Expect(b.LastActivity("busy")).To(BeTemporally(">=", before))
+		})
+
+		Context("when the container has a grace time of 0", func() {
+			It("still records its activity", func() {
+				backend := new(fakes.FakeBackend)
+				backend.GraceTimeReturns(0)
+
+				b = bomberman.New(backend, func(garden.Container) {})
+
+				before := time.Now()
+				b.Strap(container)
+
+				Expect(b.LastActivity("busy")).To(BeTemporally(">=", before))
+			})
+		})
+
+		Context("when the container is defused", func() {
+			It("forgets its activity", func() {
+				b.Strap(container)
+				b.Defuse("busy")
+				b.Unpause("busy")
+
+				Expect(b.LastActivity("busy")).To(BeZero())
+			})
+		})
+
+		Context("when the handle is invalid", func() {
+			It("reports the zero time", func() {
+				b.Pause("BOOM?!")
+
+				Expect(b.LastActivity("BOOM?!")).To(BeZero())
+			})
+		})
+	})
 })
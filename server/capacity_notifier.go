@@ -0,0 +1,47 @@
+package server
+
+import "sync"
+
+// capacityNotifier tells watchers that the host's capacity may have changed,
+// i.e. that a container has been created or destroyed. Notifications are
+// coalesced: a watcher that has not yet handled the previous one is not
+// notified again.
+type capacityNotifier struct {
+	mu       sync.Mutex
+	watchers map[chan struct{}]struct{}
+}
+
+func newCapacityNotifier() *capacityNotifier {
+	return &capacityNotifier{
+		watchers: make(map[chan struct{}]struct{}),
+	}
+}
+
+func (n *capacityNotifier) watch() chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	n.watchers[ch] = struct{}{}
+
+	return ch
+}
+
+func (n *capacityNotifier) unwatch(ch chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.watchers, ch)
+}
+
+func (n *capacityNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
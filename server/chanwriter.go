@@ -1,7 +1,10 @@
 package server
 
+import "sync/atomic"
+
 type chanWriter struct {
-	ch chan<- []byte
+	ch    chan<- []byte
+	stats *outputStats
 }
 
 func (w *chanWriter) Write(d []byte) (int, error) {
@@ -14,6 +17,7 @@ func (w *chanWriter) Write(d []byte) (int, error) {
 	default:
 		// assumption is that writes never block; channel should have buffer to
 		// account for slow consumers
+		atomic.AddUint64(&w.stats.dropped, uint64(len(d)))
 	}
 
 	return len(d), nil
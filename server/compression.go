@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// SetResponseCompressionThreshold gzips responses of at least the given number
+// of bytes for clients that accept gzip, leaving smaller responses as they
+// are. Streaming routes are never compressed. Zero, the default, disables
+// compression.
+func (s *GardenServer) SetResponseCompressionThreshold(bytes int) {
+	atomic.StoreInt64(&s.compressionThreshold, int64(bytes))
+}
+
+// compressed gzips the handler's response once it reaches the compression
+// threshold, if the client accepts gzip.
+func (s *GardenServer) compressed(route string, handler http.Handler) http.Handler {
+	if streamingRoutes[route] {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		threshold := int(atomic.LoadInt64(&s.compressionThreshold))
+		if threshold <= 0 || !acceptsGzip(r) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		cw := &compressingWriter{ResponseWriter: w, threshold: threshold}
+		defer cw.finish()
+
+		handler.ServeHTTP(cw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows a gzipped
+// response, honouring an explicit q=0.
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header["Accept-Encoding"] {
+		for _, coding := range strings.Split(header, ",") {
+			params := strings.Split(coding, ";")
+			if strings.TrimSpace(params[0]) != "gzip" {
+				continue
+			}
+
+			quality := 1.0
+			for _, param := range params[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					quality, _ = strconv.ParseFloat(param[2:], 64)
+				}
+			}
+
+			return quality > 0
+		}
+	}
+
+	return false
+}
+
+// compressingWriter holds a response back until it is known whether it will
+// reach the threshold, then sends it either gzipped or as it is.
+type compressingWriter struct {
+	http.ResponseWriter
+
+	threshold int
+	status    int
+	buffered  bytes.Buffer
+	gzipped   *gzip.Writer
+}
+
+func (w *compressingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressingWriter) Write(p []byte) (int, error) {
+	if w.gzipped != nil {
+		return w.gzipped.Write(p)
+	}
+
+	w.buffered.Write(p)
+	if w.buffered.Len() < w.threshold {
+		return len(p), nil
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.writeHeader()
+
+	w.gzipped = gzip.NewWriter(w.ResponseWriter)
+	if _, err := w.gzipped.Write(w.buffered.Bytes()); err != nil {
+		return 0, err
+	}
+
+	w.buffered.Reset()
+
+	return len(p), nil
+}
+
+func (w *compressingWriter) writeHeader() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *compressingWriter) finish() {
+	if w.gzipped != nil {
+		w.gzipped.Close()
+		return
+	}
+
+	w.writeHeader()
+	if w.buffered.Len() > 0 {
+		w.ResponseWriter.Write(w.buffered.Bytes())
+	}
+}
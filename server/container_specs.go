@@ -0,0 +1,211 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrContainerSpecUnknown = errors.New("the spec this container was created with is not known to the server")
+
+// SetContainerSpecDir keeps the spec each container was created with in a
+// file named after its handle under dir, so that it can still be had once the
+// server has restarted. An empty dir, the default, keeps specs only in memory.
+func (s *GardenServer) SetContainerSpecDir(dir string) {
+	s.containerSpecDir.Store(dir)
+}
+
+func (s *GardenServer) containerSpecRoot() string {
+	dir, _ := s.containerSpecDir.Load().(string)
+	return dir
+}
+
+// containerSpecTracker remembers the spec each container was created with,
+// as it was requested rather than as the backend applied it.
+type containerSpecTracker struct {
+	mu    sync.Mutex
+	specs map[string]garden.ContainerSpec
+}
+
+func newContainerSpecTracker() *containerSpecTracker {
+	return &containerSpecTracker{
+		specs: make(map[string]garden.ContainerSpec),
+	}
+}
+
+// created remembers the container's spec, less the image's password, which
+// is never given back out, and saves it under dir unless dir is empty.
+func (t *containerSpecTracker) created(dir, handle string, spec garden.ContainerSpec) error {
+	spec.Handle = handle
+	spec.Image.Password = ""
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.specs[handle] = spec
+
+	if dir == "" {
+		return nil
+	}
+
+	return saveContainerSpec(dir, spec)
+}
+
+func (t *containerSpecTracker) renamed(dir, oldHandle, newHandle string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spec, found := t.lookup(dir, oldHandle)
+	if !found {
+		return nil
+	}
+
+	spec.Handle = newHandle
+	t.specs[newHandle] = spec
+	delete(t.specs, oldHandle)
+
+	if dir == "" {
+		return nil
+	}
+
+	if err := saveContainerSpec(dir, spec); err != nil {
+		return err
+	}
+
+	return removeContainerSpec(dir, oldHandle)
+}
+
+func (t *containerSpecTracker) destroyed(dir, handle string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.specs, handle)
+
+	if dir == "" {
+		return nil
+	}
+
+	return removeContainerSpec(dir, handle)
+}
+
+func (t *containerSpecTracker) spec(dir, handle string) (garden.ContainerSpec, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.lookup(dir, handle)
+}
+
+// lookup returns the spec remembered for the container, reading it from dir
+// if it was saved before the server last started.
+func (t *containerSpecTracker) lookup(dir, handle string) (garden.ContainerSpec, bool) {
+	if spec, found := t.specs[handle]; found {
+		return spec, true
+	}
+
+	if dir == "" || !isPlainFileName(handle) {
+		return garden.ContainerSpec{}, false
+	}
+
+	contents, err := ioutil.ReadFile(containerSpecPath(dir, handle))
+	if err != nil {
+		return garden.ContainerSpec{}, false
+	}
+
+	var spec garden.ContainerSpec
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		return garden.ContainerSpec{}, false
+	}
+
+	t.specs[handle] = spec
+
+	return spec, true
+}
+
+func containerSpecPath(dir, handle string) string {
+	return filepath.Join(dir, handle+".json")
+}
+
+// saveContainerSpec writes the spec to a temporary file first, so that a
+// spec is never left half written. It may hold secrets, e.g. in its
+// environment, so only the server's user can read it.
+func saveContainerSpec(dir string, spec garden.ContainerSpec) error {
+	if !isPlainFileName(spec.Handle) {
+		return errors.New("handle cannot be used to name a spec file")
+	}
+
+	contents, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".spec-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), containerSpecPath(dir, spec.Handle))
+}
+
+func removeContainerSpec(dir, handle string) error {
+	if !isPlainFileName(handle) {
+		return nil
+	}
+
+	err := os.Remove(containerSpecPath(dir, handle))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// handleSpec responds with the spec the container was created with. The
+// values of sensitive environment variables are masked as they are by
+// handleProcessEnv.
+func (s *GardenServer) handleSpec(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("get-spec", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	spec, found := s.containerSpecs.spec(s.containerSpecRoot(), container.Handle())
+	if !found {
+		s.writeError(w, ErrContainerSpecUnknown, hLog)
+		return
+	}
+
+	spec.Env = s.processEnvs.mask(spec.Env)
+
+	s.writeResponse(w, spec)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// onlineCPUsPath lists the host's online CPUs, in the same list format as a
+// cpuset.
+const onlineCPUsPath = "/sys/devices/system/cpu/online"
+
+// validateCPUSet checks that a cpuset is a well-formed list of CPUs and
+// ranges of them, such as "0-3,6", and that every CPU in it is online on the
+// host, so that the backend is not left to fail writing it to the cgroup.
+// Where the host's online CPUs cannot be read, that is left to the backend.
+// An empty cpuset leaves the container on the CPUs it would otherwise use.
+func validateCPUSet(cpuset string) error {
+	if cpuset == "" {
+		return nil
+	}
+
+	cpus, err := parseCPUSet(cpuset)
+	if err != nil {
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("invalid cpuset %q: %s", cpuset, err),
+		}
+	}
+
+	online, ok := hostCPUs()
+	if !ok {
+		return nil
+	}
+
+	for _, cpu := range cpus {
+		if !online[cpu] {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("invalid cpuset %q: cpu %d is not online on this host", cpuset, cpu),
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseCPUSet returns the CPUs in a cpuset list, such as "0-3,6".
+func parseCPUSet(list string) ([]int, error) {
+	var cpus []int
+
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		first, last := part, part
+		if dash := strings.Index(part, "-"); dash >= 0 {
+			first, last = part[:dash], part[dash+1:]
+		}
+
+		from, err := strconv.ParseUint(first, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a cpu or range of cpus", part)
+		}
+
+		to, err := strconv.ParseUint(last, 10, 16)
+		if err != nil || to < from {
+			return nil, fmt.Errorf("%q is not a cpu or range of cpus", part)
+		}
+
+		for cpu := from; cpu <= to; cpu++ {
+			cpus = append(cpus, int(cpu))
+		}
+	}
+
+	return cpus, nil
+}
+
+// hostCPUs returns the CPUs online on the host, reporting false if they
+// cannot be read.
+func hostCPUs() (map[int]bool, bool) {
+	contents, err := ioutil.ReadFile(onlineCPUsPath)
+	if err != nil {
+		return nil, false
+	}
+
+	cpus, err := parseCPUSet(string(contents))
+	if err != nil {
+		return nil, false
+	}
+
+	online := map[int]bool{}
+	for _, cpu := range cpus {
+		online[cpu] = true
+	}
+
+	return online, true
+}
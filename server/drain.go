@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// SetDrainMode stops the server creating containers while draining is true,
+// failing creates with a garden.DrainingError so that schedulers place them
+// elsewhere. Every other operation, including destroying containers, carries
+// on as normal.
+func (s *GardenServer) SetDrainMode(draining bool) {
+	var value int32
+	if draining {
+		value = 1
+	}
+
+	atomic.StoreInt32(&s.draining, value)
+}
+
+// Draining reports whether the server is in drain mode.
+func (s *GardenServer) Draining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+func (s *GardenServer) handleSetDrainMode(w http.ResponseWriter, r *http.Request) {
+	var draining bool
+	if !s.readRequest(&draining, w, r) {
+		return
+	}
+
+	hLog := s.logger.Session("set-drain-mode", lager.Data{
+		"draining": draining,
+	})
+
+	s.SetDrainMode(draining)
+
+	hLog.Info("set")
+
+	s.writeSuccess(w)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// handleAllEgressRules reports the egress policy of every container, as its
+// backend reports it, so that rules however they were applied are included.
+func (s *GardenServer) handleAllEgressRules(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("all-egress-rules")
+
+	containers, err := s.backend.Containers(nil)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	handles := make([]string, 0, len(containers))
+	for _, container := range containers {
+		handles = append(handles, container.Handle())
+	}
+
+	bulkInfo := map[string]garden.ContainerInfoEntry{}
+	if len(handles) > 0 {
+		bulkInfo, err = s.backend.BulkInfo(handles)
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+	}
+
+	egressRules := map[string]garden.ContainerEgressRules{}
+	for _, container := range containers {
+		// the container may have been destroyed since it was listed
+		entry, found := bulkInfo[container.Handle()]
+		if !found || entry.Err != nil {
+			continue
+		}
+
+		policy, err := container.NetworkPolicy()
+		if _, ok := err.(garden.ContainerNotFoundError); ok {
+			continue
+		}
+
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+
+		egressRules[container.Handle()] = garden.ContainerEgressRules{
+			ContainerIP:   entry.Info.ContainerIP,
+			DefaultAction: policy.DefaultAction,
+			Rules:         policy.Rules,
+		}
+	}
+
+	s.writeResponse(w, egressRules)
+}
@@ -0,0 +1,86 @@
+package handlelock
+
+import "sync"
+
+// Locker provides a read/write lock per container handle. Operations that
+// must not observe a container being torn down take a shared lock, while
+// operations such as destroying a container take an exclusive one.
+// Operations on different handles never contend.
+type Locker struct {
+	mu    sync.Mutex
+	locks map[string]*handleLock
+}
+
+type handleLock struct {
+	sync.RWMutex
+
+	// number of holders and waiters; the lock is discarded when it drops to 0
+	refs int
+}
+
+func New() *Locker {
+	return &Locker{
+		locks: make(map[string]*handleLock),
+	}
+}
+
+// Lock acquires an exclusive lock on the handle.
+func (l *Locker) Lock(handle string) {
+	l.acquire(handle).Lock()
+}
+
+// Unlock releases an exclusive lock acquired with Lock.
+func (l *Locker) Unlock(handle string) {
+	lock := l.lookup(handle)
+	lock.Unlock()
+	l.release(handle, lock)
+}
+
+// RLock acquires a shared lock on the handle.
+func (l *Locker) RLock(handle string) {
+	l.acquire(handle).RLock()
+}
+
+// RUnlock releases a shared lock acquired with RLock.
+func (l *Locker) RUnlock(handle string) {
+	lock := l.lookup(handle)
+	lock.RUnlock()
+	l.release(handle, lock)
+}
+
+func (l *Locker) acquire(handle string) *handleLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, found := l.locks[handle]
+	if !found {
+		lock = &handleLock{}
+		l.locks[handle] = lock
+	}
+
+	lock.refs++
+
+	return lock
+}
+
+func (l *Locker) lookup(handle string) *handleLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, found := l.locks[handle]
+	if !found {
+		panic("handlelock: unlock of unlocked handle " + handle)
+	}
+
+	return lock
+}
+
+func (l *Locker) release(handle string, lock *handleLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, handle)
+	}
+}
@@ -0,0 +1,13 @@
+package handlelock_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHandleLock(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HandleLock Suite")
+}
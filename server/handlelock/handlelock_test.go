@@ -0,0 +1,92 @@
+package handlelock_test
+
+import (
+	"code.cloudfoundry.org/garden/server/handlelock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locker", func() {
+	var locker *handlelock.Locker
+
+	BeforeEach(func() {
+		locker = handlelock.New()
+	})
+
+	It("allows concurrent shared locks on the same handle", func() {
+		locker.RLock("some-handle")
+
+		acquired := make(chan struct{})
+		go func() {
+			locker.RLock("some-handle")
+			close(acquired)
+		}()
+
+		Eventually(acquired).Should(BeClosed())
+
+		locker.RUnlock("some-handle")
+		locker.RUnlock("some-handle")
+	})
+
+	It("blocks an exclusive lock until shared locks are released", func() {
+		locker.RLock("some-handle")
+
+		acquired := make(chan struct{})
+		go func() {
+			locker.Lock("some-handle")
+			close(acquired)
+		}()
+
+		Consistently(acquired).ShouldNot(BeClosed())
+
+		locker.RUnlock("some-handle")
+		Eventually(acquired).Should(BeClosed())
+
+		locker.Unlock("some-handle")
+	})
+
+	It("blocks shared locks while an exclusive lock is held", func() {
+		locker.Lock("some-handle")
+
+		acquired := make(chan struct{})
+		go func() {
+			locker.RLock("some-handle")
+			close(acquired)
+		}()
+
+		Consistently(acquired).ShouldNot(BeClosed())
+
+		locker.Unlock("some-handle")
+		Eventually(acquired).Should(BeClosed())
+
+		locker.RUnlock("some-handle")
+	})
+
+	It("does not block operations on other handles", func() {
+		locker.Lock("some-handle")
+
+		acquired := make(chan struct{})
+		go func() {
+			locker.Lock("other-handle")
+			close(acquired)
+		}()
+
+		Eventually(acquired).Should(BeClosed())
+
+		locker.Unlock("other-handle")
+		locker.Unlock("some-handle")
+	})
+
+	It("can lock a handle again after it has been released", func() {
+		locker.Lock("some-handle")
+		locker.Unlock("some-handle")
+
+		locker.Lock("some-handle")
+		locker.Unlock("some-handle")
+	})
+
+	It("panics when unlocking a handle that is not locked", func() {
+		Expect(func() { locker.Unlock("some-handle") }).To(Panic())
+	})
+})
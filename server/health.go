@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+// healthCheckTimeout bounds each health check, so that a backend which hangs,
+// e.g. on an unresponsive runtime, is reported as degraded rather than
+// holding up whoever is checking.
+const healthCheckTimeout = 5 * time.Second
+
+// healthChecks runs each health check once at a time, so that probes which
+// arrive while a check is in flight wait for its result rather than start
+// another. A check which hangs past its timeout is then left running alone,
+// rather than once for every probe which has timed out on it.
+type healthChecks struct {
+	mu       sync.Mutex
+	inFlight map[string]*healthCheckRun
+}
+
+// healthCheckRun is closed once the check has finished, after which its
+// error is set.
+type healthCheckRun struct {
+	done chan struct{}
+	err  error
+}
+
+func newHealthChecks() *healthChecks {
+	return &healthChecks{
+		inFlight: make(map[string]*healthCheckRun),
+	}
+}
+
+// start runs the check, unless it is already running.
+func (h *healthChecks) start(name string, check func() error) *healthCheckRun {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if run, found := h.inFlight[name]; found {
+		return run
+	}
+
+	run := &healthCheckRun{done: make(chan struct{})}
+	h.inFlight[name] = run
+
+	go func() {
+		run.err = check()
+
+		h.mu.Lock()
+		delete(h.inFlight, name)
+		h.mu.Unlock()
+
+		close(run.done)
+	}()
+
+	return run
+}
+
+func (h *healthChecks) run(name string, check func() error) garden.HealthCheck {
+	started := time.Now()
+
+	run := h.start(name, check)
+
+	result := garden.HealthCheck{Name: name}
+
+	select {
+	case <-run.done:
+		if run.err != nil {
+			result.Error = run.err.Error()
+		}
+	case <-time.After(healthCheckTimeout):
+		result.Error = fmt.Sprintf("timed out after %s", healthCheckTimeout)
+	}
+
+	result.Duration = time.Since(started)
+
+	return result
+}
+
+// checkHealth runs cheap, read-only checks of the backend concurrently:
+//
+// * ping: the backend's own liveness check.
+// * capacity: the backend can report the host's capacity.
+// * list-containers: the backend can list its containers.
+//
+// Unlike the self-test, it creates nothing, so it can be run as often as a
+// load balancer likes.
+func (s *GardenServer) checkHealth(logger lager.Logger) garden.HealthStatus {
+	checks := []struct {
+		name  string
+		check func() error
+	}{
+		{"ping", s.backend.Ping},
+		{"capacity", func() error {
+			_, err := s.backend.Capacity()
+			return err
+		}},
+		{"list-containers", func() error {
+			_, err := s.backend.Containers(nil)
+			return err
+		}},
+	}
+
+	status := garden.HealthStatus{
+		State:  garden.HealthStateHealthy,
+		Checks: make([]garden.HealthCheck, len(checks)),
+	}
+
+	wg := new(sync.WaitGroup)
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, name string, check func() error) {
+			defer wg.Done()
+
+			status.Checks[i] = s.healthChecks.run(name, check)
+		}(i, c.name, c.check)
+	}
+
+	wg.Wait()
+
+	for _, check := range status.Checks {
+		if check.Error != "" {
+			logger.Info("check-failed", lager.Data{
+				"check": check.Name,
+				"error": check.Error,
+			})
+
+			status.State = garden.HealthStateDegraded
+		}
+	}
+
+	return status
+}
+
+// handleHealth responds with the outcome of the health checks, with a
+// BackendDegradedError if any failed, so that a load balancer can tell a
+// degraded server by its status code alone.
+func (s *GardenServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("health")
+
+	status := s.checkHealth(hLog)
+	if status.State != garden.HealthStateHealthy {
+		s.writeError(w, garden.BackendDegradedError{Health: status}, hLog)
+		return
+	}
+
+	s.writeResponse(w, status)
+}
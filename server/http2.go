@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+)
+
+// http2PrefaceRemainder is the rest of the preface an HTTP/2 client opens a
+// connection with, after the "PRI * HTTP/2.0" request line and empty headers
+// which the HTTP/1 server reads as a request.
+const http2PrefaceRemainder = "SM\r\n\r\n"
+
+// servingHTTP2 lets clients which know the server speaks HTTP/2 multiplex
+// their unary calls over one connection. There is no TLS, so a client opens
+// the connection in HTTP/2 with prior knowledge; the HTTP/1 server hands it
+// over as a PRI request, and the connection is taken over and served in
+// HTTP/2 from then on. Streaming calls, which take over their connection,
+// are still made over HTTP/1.
+//
+// The connection is counted as being handled, and closed when idle on Stop,
+// just as an HTTP/1 one is. It starts out idle, and is then kept track of as
+// it goes active and idle again by the HTTP/2 server, which reports those
+// states to the HTTP/1 server's ConnState hook.
+func (s *GardenServer) servingHTTP2(handler http.Handler) http.Handler {
+	h2 := &http2.Server{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PRI" || r.URL.Path != "*" || r.Proto != "HTTP/2.0" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "cannot serve HTTP/2", http.StatusHTTPVersionNotSupported)
+			return
+		}
+
+		conn, buf, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+
+		remainder := make([]byte, len(http2PrefaceRemainder))
+		if _, err := io.ReadFull(buf, remainder); err != nil || string(remainder) != http2PrefaceRemainder {
+			conn.Close()
+			return
+		}
+
+		h2Conn := &bufferedConn{Conn: conn, r: buf.Reader}
+
+		// the hijacked connection is no longer counted, but is still
+		// being handled
+		s.handling.Add(1)
+		defer s.handling.Done()
+
+		select {
+		case <-s.stopping:
+			h2Conn.Close()
+			return
+		default:
+			s.mu.Lock()
+			s.conns[h2Conn] = h2Conn
+			s.mu.Unlock()
+		}
+
+		defer func() {
+			s.mu.Lock()
+			delete(s.conns, h2Conn)
+			s.mu.Unlock()
+		}()
+
+		h2.ServeConn(h2Conn, &http2.ServeConnOpts{
+			BaseConfig:       s.server,
+			Handler:          handler,
+			SawClientPreface: true,
+		})
+	})
+}
+
+// bufferedConn is a connection whose reads are from a reader buffering it,
+// so that what has been read into the buffer is not lost.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// infoVersionTracker keeps a revision counter per container which is bumped
+// whenever the container's info is seen to change, or its limits are
+// changed through the server, so that clients can tell whether anything has
+// changed since they last asked.
+type infoVersionTracker struct {
+	// epoch tells apart the revisions of different runs of the server, as
+	// the counters start again from scratch
+	epoch string
+
+	mu        sync.Mutex
+	revisions map[string]*infoRevision
+}
+
+type infoRevision struct {
+	revision uint64
+	digest   [sha256.Size]byte
+}
+
+func newInfoVersionTracker() *infoVersionTracker {
+	return &infoVersionTracker{
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		revisions: make(map[string]*infoRevision),
+	}
+}
+
+// changed bumps the revision of a container whose limits have changed,
+// which its info does not show.
+func (t *infoVersionTracker) changed(handle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.revisionOf(handle).revision++
+}
+
+// version returns the current version of the container's info, bumping its
+// revision if the info differs from the last seen.
+func (t *infoVersionTracker) version(handle string, info garden.ContainerInfo) string {
+	// the last activity changes with every request, and the network stats
+	// with every packet, so neither is part of the version
+	info.LastActivity = time.Time{}
+	info.NetworkStat = garden.ContainerNetworkStat{}
+	info.Version = ""
+
+	encoded, _ := json.Marshal(info)
+	digest := sha256.Sum256(encoded)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rev := t.revisionOf(handle)
+	if rev.digest != digest {
+		rev.digest = digest
+		rev.revision++
+	}
+
+	return t.epoch + "." + strconv.FormatUint(rev.revision, 10)
+}
+
+func (t *infoVersionTracker) revisionOf(handle string) *infoRevision {
+	rev, found := t.revisions[handle]
+	if !found {
+		rev = &infoRevision{}
+		t.revisions[handle] = rev
+	}
+
+	return rev
+}
+
+func (t *infoVersionTracker) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if rev, found := t.revisions[oldHandle]; found {
+		t.revisions[newHandle] = rev
+		delete(t.revisions, oldHandle)
+	}
+}
+
+func (t *infoVersionTracker) destroyed(handle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.revisions, handle)
+}
+
+// etagMatches reports whether an If-None-Match header names the given
+// version, either as one of its entity tags or with "*".
+func etagMatches(ifNoneMatch, version string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == strconv.Quote(version) {
+			return true
+		}
+	}
+
+	return false
+}
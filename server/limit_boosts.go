@@ -0,0 +1,325 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/server/handlelock"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrInvalidBoost = garden.InvalidRequestError{Reason: "a boost must raise the cpu or memory limit, and no others"}
+var ErrInvalidBoostDuration = garden.InvalidRequestError{Reason: "boost duration must be positive"}
+
+type boostLimitsRequest struct {
+	Limits   garden.Limits `json:"limits"`
+	Duration time.Duration `json:"duration"`
+}
+
+// limitBoost is a container's boosted limits, and what to restore them to
+// once the boost is over.
+type limitBoost struct {
+	handle   string
+	previous garden.LimitsUpdate
+	timer    *time.Timer
+}
+
+// limitBoostTracker keeps the boost in force on each container, so that it
+// can be extended, ended early, or forgotten when the container goes.
+type limitBoostTracker struct {
+	// applying is held on a container while a boost is applied to it or
+	// ended, so that each finds the limits to restore left by the one
+	// before, without holding up boosts of other containers
+	applying *handlelock.Locker
+
+	mu     sync.Mutex
+	boosts map[string]*limitBoost
+}
+
+func newLimitBoostTracker() *limitBoostTracker {
+	return &limitBoostTracker{
+		applying: handlelock.New(),
+		boosts:   make(map[string]*limitBoost),
+	}
+}
+
+// current returns the boost in force on the container, if any.
+func (t *limitBoostTracker) current(handle string) (*limitBoost, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	boost, found := t.boosts[handle]
+	return boost, found
+}
+
+// replace puts the boost in force on its container, in place of any before.
+func (t *limitBoostTracker) replace(boost *limitBoost) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if current, found := t.boosts[boost.handle]; found {
+		current.timer.Stop()
+	}
+
+	t.boosts[boost.handle] = boost
+}
+
+// overridden drops the limits set explicitly during a boost from those to
+// be restored, so that the explicit values stand once the boost is over. A
+// boost with nothing left to restore is ended.
+func (t *limitBoostTracker) overridden(handle string, update garden.LimitsUpdate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	boost, found := t.boosts[handle]
+	if !found {
+		return
+	}
+
+	if update.CPU != nil {
+		boost.previous.CPU = nil
+	}
+
+	if update.Memory != nil {
+		boost.previous.Memory = nil
+	}
+
+	if boost.previous.CPU == nil && boost.previous.Memory == nil {
+		boost.timer.Stop()
+		delete(t.boosts, handle)
+	}
+}
+
+func (t *limitBoostTracker) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if boost, found := t.boosts[oldHandle]; found {
+		boost.handle = newHandle
+		t.boosts[newHandle] = boost
+		delete(t.boosts, oldHandle)
+	}
+}
+
+func (t *limitBoostTracker) destroyed(handle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if boost, found := t.boosts[handle]; found {
+		boost.timer.Stop()
+		delete(t.boosts, handle)
+	}
+}
+
+// handleOf returns the handle of the container the boost is in force on,
+// which may have been renamed since, reporting whether it still is.
+func (t *limitBoostTracker) handleOf(boost *limitBoost) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.boosts[boost.handle] != boost {
+		return "", false
+	}
+
+	return boost.handle, true
+}
+
+// take removes the boost, if it is still the one in force on its container,
+// reporting whether it was.
+func (t *limitBoostTracker) take(boost *limitBoost) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.boosts[boost.handle] != boost {
+		return false
+	}
+
+	boost.timer.Stop()
+	delete(t.boosts, boost.handle)
+
+	return true
+}
+
+func (t *limitBoostTracker) all() []*limitBoost {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	boosts := make([]*limitBoost, 0, len(t.boosts))
+	for _, boost := range t.boosts {
+		boosts = append(boosts, boost)
+	}
+
+	return boosts
+}
+
+// handleBoostLimits raises a container's CPU or memory limits for a while,
+// restoring their previous values once it has passed. Boosting a container
+// which is already boosted replaces the boost, and its duration, but still
+// restores the limits from before the first one.
+func (s *GardenServer) handleBoostLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("boost-limits", lager.Data{
+		"handle": handle,
+	})
+
+	var request boostLimitsRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	boosted, err := boostUpdate(request.Limits)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if err := validateCPUSet(request.Limits.CPU.CPUSet); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if request.Duration <= 0 {
+		s.writeError(w, ErrInvalidBoostDuration, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	s.limitBoosts.applying.Lock(container.Handle())
+	defer s.limitBoosts.applying.Unlock(container.Handle())
+
+	boost := &limitBoost{handle: container.Handle()}
+	if current, found := s.limitBoosts.current(container.Handle()); found {
+		boost.previous = current.previous
+	}
+
+	if boosted.CPU != nil && boost.previous.CPU == nil {
+		limits, err := container.CurrentCPULimits()
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+
+		boost.previous.CPU = &limits
+	}
+
+	if boosted.Memory != nil && boost.previous.Memory == nil {
+		limits, err := container.CurrentMemoryLimits()
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+
+		boost.previous.Memory = &limits
+	}
+
+	hLog.Debug("boosting", lager.Data{
+		"update":   boosted,
+		"previous": boost.previous,
+		"duration": request.Duration.String(),
+	})
+
+	_, err = container.LimitAll(boosted)
+	s.infoVersions.changed(container.Handle())
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	boost.timer = time.AfterFunc(request.Duration, func() {
+		s.endBoost(boost)
+	})
+	s.limitBoosts.replace(boost)
+
+	hLog.Info("boosted")
+
+	s.writeSuccess(w)
+}
+
+// boostUpdate returns the update which applies a boost, refusing one which
+// raises neither the CPU nor the memory limit, or changes any other.
+func boostUpdate(limits garden.Limits) (garden.LimitsUpdate, error) {
+	others := limits
+	others.CPU = garden.CPULimits{}
+	others.Memory = garden.MemoryLimits{}
+
+	if others != (garden.Limits{}) {
+		return garden.LimitsUpdate{}, ErrInvalidBoost
+	}
+
+	update := garden.LimitsUpdate{}
+	if limits.CPU != (garden.CPULimits{}) {
+		update.CPU = &limits.CPU
+	}
+
+	if limits.Memory != (garden.MemoryLimits{}) {
+		update.Memory = &limits.Memory
+	}
+
+	if update.CPU == nil && update.Memory == nil {
+		return garden.LimitsUpdate{}, ErrInvalidBoost
+	}
+
+	return update, nil
+}
+
+// endBoost restores the limits a boost replaced, unless it has since been
+// replaced or ended itself.
+func (s *GardenServer) endBoost(boost *limitBoost) {
+	handle, found := s.limitBoosts.handleOf(boost)
+	if !found {
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	s.limitBoosts.applying.Lock(handle)
+	defer s.limitBoosts.applying.Unlock(handle)
+
+	if !s.limitBoosts.take(boost) {
+		return
+	}
+
+	log := s.logger.Session("end-boost", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		log.Error("failed-to-lookup", err)
+		return
+	}
+
+	_, err = container.LimitAll(boost.previous)
+	s.infoVersions.changed(container.Handle())
+	if err != nil {
+		log.Error("failed-to-restore-limits", err)
+		return
+	}
+
+	log.Info("restored", lager.Data{
+		"limits": boost.previous,
+	})
+}
+
+// endAllBoosts restores the limits of every boosted container, so that no
+// boost outlives the server.
+func (s *GardenServer) endAllBoosts() {
+	for _, boost := range s.limitBoosts.all() {
+		s.endBoost(boost)
+	}
+}
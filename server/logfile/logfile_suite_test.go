@@ -0,0 +1,13 @@
+package logfile_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogFile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LogFile Suite")
+}
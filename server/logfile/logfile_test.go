@@ -0,0 +1,132 @@
+package logfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/garden/server/logfile"
+)
+
+var _ = Describe("Writer", func() {
+	var (
+		dir     string
+		logPath string
+		writer  *logfile.Writer
+	)
+
+	BeforeEach(func() {
+		writer = nil
+
+		var err error
+		dir, err = ioutil.TempDir("", "logfile")
+		Expect(err).ToNot(HaveOccurred())
+
+		logPath = filepath.Join(dir, "output.log")
+	})
+
+	AfterEach(func() {
+		if writer != nil {
+			writer.Close()
+		}
+
+		os.RemoveAll(dir)
+	})
+
+	contentsOf := func(path string) string {
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		return string(contents)
+	}
+
+	write := func(s string) {
+		n, err := writer.Write([]byte(s))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(len(s)))
+	}
+
+	It("appends to an existing log", func() {
+		Expect(ioutil.WriteFile(logPath, []byte("old\n"), 0644)).To(Succeed())
+
+		var err error
+		writer, err = logfile.NewWriter(logPath, 100, 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		write("new\n")
+
+		Expect(contentsOf(logPath)).To(Equal("old\nnew\n"))
+	})
+
+	It("rotates the log once it would exceed the maximum size", func() {
+		var err error
+		writer, err = logfile.NewWriter(logPath, 10, 2)
+		Expect(err).ToNot(HaveOccurred())
+
+		write("aaaaaaaa")
+		write("bbbbbbbb")
+		write("cccccccc")
+
+		Expect(contentsOf(logPath)).To(Equal("cccccccc"))
+		Expect(contentsOf(logPath + ".1")).To(Equal("bbbbbbbb"))
+		Expect(contentsOf(logPath + ".2")).To(Equal("aaaaaaaa"))
+	})
+
+	It("keeps no more than the maximum number of rotated logs", func() {
+		var err error
+		writer, err = logfile.NewWriter(logPath, 10, 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		write("aaaaaaaa")
+		write("bbbbbbbb")
+		write("cccccccc")
+
+		Expect(contentsOf(logPath)).To(Equal("cccccccc"))
+		Expect(contentsOf(logPath + ".1")).To(Equal("bbbbbbbb"))
+		Expect(logPath + ".2").ToNot(BeAnExistingFile())
+	})
+
+	Context("when no rotated logs are kept", func() {
+		It("starts the log afresh", func() {
+			var err error
+			writer, err = logfile.NewWriter(logPath, 10, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			write("aaaaaaaa")
+			write("bbbbbbbb")
+
+			Expect(contentsOf(logPath)).To(Equal("bbbbbbbb"))
+			Expect(logPath + ".1").ToNot(BeAnExistingFile())
+		})
+	})
+
+	It("writes a chunk larger than the maximum size whole", func() {
+		var err error
+		writer, err = logfile.NewWriter(logPath, 4, 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		write("aaaaaaaa")
+
+		Expect(contentsOf(logPath)).To(Equal("aaaaaaaa"))
+	})
+
+	It("fails to write once closed", func() {
+		var err error
+		writer, err = logfile.NewWriter(logPath, 10, 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(writer.Close()).To(Succeed())
+
+		_, err = writer.Write([]byte("x"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when the log cannot be opened", func() {
+		It("returns an error", func() {
+			_, err := logfile.NewWriter(filepath.Join(dir, "missing", "output.log"), 10, 1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
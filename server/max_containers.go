@@ -0,0 +1,54 @@
+package server
+
+import (
+	"sync/atomic"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// SetMaxContainers caps the number of containers the server will create,
+// counting those being created. Creates beyond it fail early with a
+// garden.MaxContainersReachedError rather than with whatever the backend
+// fails with once it runs out. Zero, the default, means no limit. Lowering it
+// below the current number of containers destroys none of them.
+func (s *GardenServer) SetMaxContainers(max int) {
+	atomic.StoreInt64(&s.maxContainers, int64(max))
+}
+
+// MaxContainers returns the maximum number of containers, or zero if there is
+// none.
+func (s *GardenServer) MaxContainers() int {
+	return int(atomic.LoadInt64(&s.maxContainers))
+}
+
+// admitCreate checks that creating another container stays within the
+// maximum number of containers, counting it as pending until release is
+// called.
+func (s *GardenServer) admitCreate() (func(), error) {
+	max := s.MaxContainers()
+	if max <= 0 {
+		return func() {}, nil
+	}
+
+	// creates are admitted one at a time, so that concurrent creates cannot
+	// all see room for one more
+	s.pendingCreatesL.Lock()
+	defer s.pendingCreatesL.Unlock()
+
+	containers, err := s.backend.Containers(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if count := len(containers) + s.pendingCreates; count >= max {
+		return nil, garden.MaxContainersReachedError{MaxContainers: max, Containers: count}
+	}
+
+	s.pendingCreates++
+
+	return func() {
+		s.pendingCreatesL.Lock()
+		s.pendingCreates--
+		s.pendingCreatesL.Unlock()
+	}, nil
+}
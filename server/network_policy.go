@@ -0,0 +1,133 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+func (s *GardenServer) handleNetworkPolicy(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("network-policy", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	policy, err := container.NetworkPolicy()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, policy)
+}
+
+// handleSetNetworkPolicy replaces a container's whole egress policy.
+func (s *GardenServer) handleSetNetworkPolicy(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var policy garden.NetworkPolicy
+	if !s.readRequest(&policy, w, r) {
+		return
+	}
+
+	hLog := s.logger.Session("set-network-policy", lager.Data{
+		"handle":         handle,
+		"default-action": policy.DefaultAction,
+		"rules":          len(policy.Rules),
+	})
+
+	if err := validateNetworkPolicy(policy); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("setting")
+
+	err = s.updateNetworkPolicy(hLog, container, func(garden.NetworkPolicy) (garden.NetworkPolicy, error) {
+		return policy, nil
+	})
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("set")
+
+	s.writeSuccess(w)
+}
+
+// updateNetworkPolicy replaces a container's egress policy with one derived
+// from its current policy. Backends apply a policy rule by rule, so if they
+// fail part way through the policy that was in effect before is put back.
+// Policy changes to the same container, including the rules NetOut and
+// BulkNetOut add to it, are serialized so that a rollback cannot undo a
+// concurrent change.
+func (s *GardenServer) updateNetworkPolicy(hLog lager.Logger, container garden.Container, update func(garden.NetworkPolicy) (garden.NetworkPolicy, error)) error {
+	s.networkPolicyLocks.Lock(container.Handle())
+	defer s.networkPolicyLocks.Unlock(container.Handle())
+
+	return s.updateNetworkPolicyLocked(hLog, container, update)
+}
+
+// updateNetworkPolicyLocked is updateNetworkPolicy for a caller already
+// holding the container's network policy lock.
+func (s *GardenServer) updateNetworkPolicyLocked(hLog lager.Logger, container garden.Container, update func(garden.NetworkPolicy) (garden.NetworkPolicy, error)) error {
+	previous, err := container.NetworkPolicy()
+	if err != nil {
+		return err
+	}
+
+	policy, err := update(previous)
+	if err != nil {
+		return err
+	}
+
+	err = container.SetNetworkPolicy(policy)
+	if err != nil {
+		if rollbackErr := container.SetNetworkPolicy(previous); rollbackErr != nil {
+			hLog.Error("failed-to-roll-back", rollbackErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func validateNetworkPolicy(policy garden.NetworkPolicy) error {
+	switch policy.DefaultAction {
+	case garden.NetworkActionAllow, garden.NetworkActionDeny:
+		return nil
+	}
+
+	return garden.InvalidRequestError{
+		Reason: fmt.Sprintf("invalid network policy default action %q: must be %q or %q", policy.DefaultAction, garden.NetworkActionAllow, garden.NetworkActionDeny),
+	}
+}
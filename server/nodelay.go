@@ -0,0 +1,59 @@
+package server
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"code.cloudfoundry.org/garden/server/streamer"
+)
+
+// setNoDelay turns Nagle's algorithm off on a TCP connection carrying the
+// streams of an interactive process, one with a TTY, so that each keystroke
+// and its echo is sent at once, and on for any other, so that bulk output is
+// sent in fewer, fuller segments. Connections which are not TCP, e.g. over a
+// unix socket, are left as they are.
+func setNoDelay(conn io.Writer, interactive bool) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetNoDelay(interactive)
+	}
+}
+
+// interactiveStreamTracker remembers whether each stream carries the output
+// of an interactive process, for as long as it is being streamed, so that
+// the connections it is served on can be set up for it. Streams of processes
+// the server did not run are not known to be either, so their connections
+// are left as they are.
+type interactiveStreamTracker struct {
+	mu      sync.Mutex
+	streams map[streamer.StreamID]bool
+}
+
+func newInteractiveStreamTracker() *interactiveStreamTracker {
+	return &interactiveStreamTracker{
+		streams: make(map[streamer.StreamID]bool),
+	}
+}
+
+// add records whether the stream is interactive until the returned function
+// is called.
+func (t *interactiveStreamTracker) add(streamID streamer.StreamID, interactive bool) func() {
+	t.mu.Lock()
+	t.streams[streamID] = interactive
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		delete(t.streams, streamID)
+	}
+}
+
+func (t *interactiveStreamTracker) interactive(streamID streamer.StreamID) (bool, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	interactive, found := t.streams[streamID]
+	return interactive, found
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/transport"
+	"code.cloudfoundry.org/lager"
+)
+
+// oomEventBuffer is how many OOM events are kept for a watcher which has not
+// yet sent them on; any more are dropped rather than hold up the others.
+const oomEventBuffer = 64
+
+// oomNotifier hands the OOM kills reported by the backend to each watcher.
+type oomNotifier struct {
+	mu       sync.Mutex
+	watchers map[chan garden.OOMEvent]struct{}
+
+	// subscribed is set once the backend's OOM kills are being watched
+	subscribed bool
+}
+
+func newOOMNotifier() *oomNotifier {
+	return &oomNotifier{
+		watchers: make(map[chan garden.OOMEvent]struct{}),
+	}
+}
+
+func (n *oomNotifier) watch() chan garden.OOMEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ch := make(chan garden.OOMEvent, oomEventBuffer)
+	n.watchers[ch] = struct{}{}
+
+	return ch
+}
+
+func (n *oomNotifier) unwatch(ch chan garden.OOMEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.watchers, ch)
+}
+
+// ended ends every watch, for the backend has stopped reporting OOM kills,
+// and has the next watcher subscribe to them again.
+func (n *oomNotifier) ended() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.watchers {
+		close(ch)
+	}
+
+	n.watchers = make(map[chan garden.OOMEvent]struct{})
+	n.subscribed = false
+}
+
+// notify returns how many watchers the event was dropped for.
+func (n *oomNotifier) notify(event garden.OOMEvent) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	dropped := 0
+	for ch := range n.watchers {
+		select {
+		case ch <- event:
+		default:
+			dropped++
+		}
+	}
+
+	return dropped
+}
+
+// subscribeToOOMs subscribes to the backend's OOM kills, unless it already
+// has, and passes them on to the watchers until the server stops. The backend
+// is only subscribed to once a client first watches, so kills before then are
+// not reported. If the backend stops reporting them, the watches end, and
+// the next one subscribes again.
+func (s *GardenServer) subscribeToOOMs(log lager.Logger) error {
+	s.oomNotifier.mu.Lock()
+	defer s.oomNotifier.mu.Unlock()
+
+	if s.oomNotifier.subscribed {
+		return nil
+	}
+
+	events, err := s.backend.OOMEvents()
+	if err != nil {
+		return err
+	}
+
+	s.oomNotifier.subscribed = true
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					log.Info("backend-stopped-reporting")
+					s.oomNotifier.ended()
+					return
+				}
+
+				log.Info("oom", lager.Data{
+					"handle":     event.Handle,
+					"process-id": event.ProcessID,
+				})
+
+				if dropped := s.oomNotifier.notify(event); dropped > 0 {
+					log.Info("dropped-for-slow-watchers", lager.Data{
+						"handle":   event.Handle,
+						"watchers": dropped,
+					})
+				}
+			case <-s.stopping:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *GardenServer) handleWatchOOMs(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("watch-ooms")
+
+	events := s.oomNotifier.watch()
+	defer s.oomNotifier.unwatch(events)
+
+	if err := s.subscribeToOOMs(s.logger.Session("oom-events")); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	defer conn.Close()
+
+	hLog.Debug("watching")
+
+	// the client never sends anything, so a read only returns once it has
+	// gone away
+	disconnected := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, br)
+		close(disconnected)
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				hLog.Debug("backend-stopped-reporting")
+				return
+			}
+
+			if err := transport.WriteMessage(conn, event); err != nil {
+				hLog.Debug("disconnected")
+				return
+			}
+		case <-disconnected:
+			hLog.Debug("disconnected")
+			return
+		case <-s.stopping:
+			return
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package server
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/server/streamer"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrInvalidBackpressure = garden.InvalidRequestError{Reason: "backpressure must be block or drop"}
+
+// outputQueueSize is how many chunks of a process's output are queued for
+// each of a client's streams. What happens once a client falls further
+// behind than that is up to its backpressure.
+const outputQueueSize = 1000
+
+// defaultOutputBlockTimeout is how long a process is held up for a client
+// which asked for it to be, unless configured otherwise.
+const defaultOutputBlockTimeout = 30 * time.Second
+
+// SetOutputBlockTimeout caps how long a process is held up by a client which
+// asked for it to be, but has stopped keeping up with its output. Once it has
+// been held up that long, the output the client falls behind on is dropped
+// until it catches up. Zero holds the process up for as long as it takes. The
+// default is 30 seconds.
+func (s *GardenServer) SetOutputBlockTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&s.outputBlockTimeout, int64(timeout))
+}
+
+// BufferedOutputBytes returns how many bytes of the output of processes run
+// through the server are queued for clients which have yet to read them.
+func (s *GardenServer) BufferedOutputBytes() uint64 {
+	return uint64(atomic.LoadInt64(&s.outputStats.buffered))
+}
+
+// DroppedOutputBytes returns the total number of process output bytes dropped
+// because the client streaming them had fallen too far behind.
+func (s *GardenServer) DroppedOutputBytes() uint64 {
+	return atomic.LoadUint64(&s.outputStats.dropped)
+}
+
+// outputStats counts the process output queued for clients, and dropped.
+type outputStats struct {
+	buffered int64
+	dropped  uint64
+}
+
+func validateBackpressure(backpressure garden.Backpressure) error {
+	switch backpressure {
+	case "", garden.BackpressureBlock, garden.BackpressureDrop:
+		return nil
+	default:
+		return ErrInvalidBackpressure
+	}
+}
+
+// outputBroadcast hands the output of a process run through the server to
+// every client streaming it, so that each one attached sees all of it.
+type outputBroadcast struct {
+	logger lager.Logger
+	stats  *outputStats
+
+	// interactive is set when the process has a TTY
+	interactive bool
+	// blockTimeout is how long a subscriber which blocks may hold up the
+	// process, or zero for as long as it takes
+	blockTimeout time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*outputSubscriber]struct{}
+}
+
+func newOutputBroadcast(logger lager.Logger, stats *outputStats, interactive bool, blockTimeout time.Duration) *outputBroadcast {
+	return &outputBroadcast{
+		logger:       logger,
+		stats:        stats,
+		interactive:  interactive,
+		blockTimeout: blockTimeout,
+		subscribers:  make(map[*outputSubscriber]struct{}),
+	}
+}
+
+// subscribe queues the output written from now on for a client, until it is
+// unsubscribed. A client which does not ask for any backpressure holds up a
+// process with a TTY, and drops the output of any other; attachers ask for
+// drop unless they ask otherwise.
+func (b *outputBroadcast) subscribe(remoteAddr string, backpressure garden.Backpressure) *outputSubscriber {
+	if backpressure == "" {
+		backpressure = garden.BackpressureDrop
+		if b.interactive {
+			backpressure = garden.BackpressureBlock
+		}
+	}
+
+	subscriber := &outputSubscriber{
+		logger: b.logger.Session("output", lager.Data{
+			"remote-addr":  remoteAddr,
+			"backpressure": backpressure,
+		}),
+		stats:        b.stats,
+		backpressure: backpressure,
+		stdout:       make(chan []byte, outputQueueSize),
+		stderr:       make(chan []byte, outputQueueSize),
+		unsubscribed: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subscribers[subscriber] = struct{}{}
+	b.mu.Unlock()
+
+	return subscriber
+}
+
+func (b *outputBroadcast) unsubscribe(subscriber *outputSubscriber) {
+	b.mu.Lock()
+	delete(b.subscribers, subscriber)
+	b.mu.Unlock()
+
+	subscriber.close()
+}
+
+func (b *outputBroadcast) stdout() io.Writer {
+	return &broadcastWriter{broadcast: b}
+}
+
+func (b *outputBroadcast) stderr() io.Writer {
+	return &broadcastWriter{broadcast: b, stderr: true}
+}
+
+// send queues the data for every subscriber. Each one with room for it, or
+// which drops what it has fallen behind on, has it straight away, before the
+// process is held up until those which block have room for it too, so that a
+// client which blocks never holds up the output to the others. One which has
+// no room by the end of the block timeout has stalled: the data is dropped for
+// it, as is any more it falls behind on until it catches up.
+func (b *outputBroadcast) send(data []byte, stderr bool) {
+	b.mu.Lock()
+	subscribers := make([]*outputSubscriber, 0, len(b.subscribers))
+	for subscriber := range b.subscribers {
+		subscribers = append(subscribers, subscriber)
+	}
+	b.mu.Unlock()
+
+	var blocked []*outputSubscriber
+	for _, subscriber := range subscribers {
+		if subscriber.offer(data, stderr) {
+			blocked = append(blocked, subscriber)
+		}
+	}
+
+	if len(blocked) == 0 {
+		return
+	}
+
+	var expired <-chan struct{}
+	if b.blockTimeout > 0 {
+		timedOut := make(chan struct{})
+		timer := time.AfterFunc(b.blockTimeout, func() { close(timedOut) })
+		defer timer.Stop()
+
+		expired = timedOut
+	}
+
+	var wg sync.WaitGroup
+	for _, subscriber := range blocked {
+		wg.Add(1)
+		go func(subscriber *outputSubscriber) {
+			defer wg.Done()
+			subscriber.wait(data, stderr, expired)
+		}(subscriber)
+	}
+
+	wg.Wait()
+}
+
+type broadcastWriter struct {
+	broadcast *outputBroadcast
+	stderr    bool
+}
+
+func (w *broadcastWriter) Write(d []byte) (int, error) {
+	// prevent buffer reuse from clobbering the data
+	data := make([]byte, len(d))
+	copy(data, d)
+
+	w.broadcast.send(data, w.stderr)
+
+	return len(d), nil
+}
+
+// outputSubscriber is a client's queue of a process's output.
+type outputSubscriber struct {
+	logger       lager.Logger
+	stats        *outputStats
+	backpressure garden.Backpressure
+
+	stdout chan []byte
+	stderr chan []byte
+
+	unsubscribed chan struct{}
+
+	mu sync.Mutex
+	// buffered counts the bytes queued, but not yet read
+	buffered int64
+	closed   bool
+	// reading has, for each of stdout and stderr being read by the client, a
+	// channel which is closed once it stops
+	reading [2]chan struct{}
+	// dropped counts the bytes dropped since the client fell behind
+	dropped uint64
+	// stalled is set once the client has held up the process for the whole
+	// block timeout, until it catches up
+	stalled bool
+}
+
+func (s *outputSubscriber) queue(stderr bool) (chan []byte, int) {
+	if stderr {
+		return s.stderr, 1
+	}
+
+	return s.stdout, 0
+}
+
+// offer queues the data unless the client has fallen too far behind, in
+// which case it is dropped, unless the client wants to block, is reading and
+// has not stalled. offer reports whether the data is left to wait for room.
+func (s *outputSubscriber) offer(data []byte, stderr bool) bool {
+	queue, stream := s.queue(stderr)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+
+	s.buffered += int64(len(data))
+	atomic.AddInt64(&s.stats.buffered, int64(len(data)))
+	reading := s.reading[stream]
+	stalled := s.stalled
+	s.mu.Unlock()
+
+	select {
+	case queue <- data:
+		s.caughtUp()
+		return false
+	default:
+	}
+
+	if s.backpressure == garden.BackpressureBlock && reading != nil && !stalled {
+		return true
+	}
+
+	s.drop(len(data))
+	return false
+}
+
+// wait queues the data offered once the client catches up, dropping it if
+// the client stops reading or is unsubscribed first, or has stalled by the
+// time expired is closed.
+func (s *outputSubscriber) wait(data []byte, stderr bool, expired <-chan struct{}) {
+	queue, stream := s.queue(stderr)
+
+	s.mu.Lock()
+	reading := s.reading[stream]
+	s.mu.Unlock()
+
+	if reading != nil {
+		select {
+		case queue <- data:
+			s.caughtUp()
+			return
+		case <-reading:
+		case <-s.unsubscribed:
+		case <-expired:
+			s.stall()
+		}
+	}
+
+	s.drop(len(data))
+}
+
+// stall stops the client holding up the process until it catches up.
+func (s *outputSubscriber) stall() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.stalled {
+		s.logger.Info("stalled-slow-client-output")
+	}
+
+	s.stalled = true
+}
+
+func (s *outputSubscriber) drop(n int) {
+	s.read(n)
+	atomic.AddUint64(&s.stats.dropped, uint64(n))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dropped == 0 {
+		s.logger.Info("dropping-slow-client-output")
+	}
+
+	s.dropped += uint64(n)
+}
+
+// caughtUp logs how much output was dropped once the client has caught up,
+// which lets a stalled client hold up the process again.
+func (s *outputSubscriber) caughtUp() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stalled = false
+
+	if s.dropped > 0 {
+		s.logger.Info("caught-up", lager.Data{
+			"dropped-bytes": s.dropped,
+		})
+
+		s.dropped = 0
+	}
+}
+
+// read stops counting n bytes as queued.
+func (s *outputSubscriber) read(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.buffered -= int64(n)
+		atomic.AddInt64(&s.stats.buffered, -int64(n))
+	}
+}
+
+// startReading records that the client is reading stdout or stderr until the
+// returned function is called.
+func (s *outputSubscriber) startReading(stderr bool) func() {
+	_, stream := s.queue(stderr)
+
+	s.mu.Lock()
+	reading := make(chan struct{})
+	s.reading[stream] = reading
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		close(reading)
+		if s.reading[stream] == reading {
+			s.reading[stream] = nil
+		}
+	}
+}
+
+// close stops queueing output, and forgets what was queued but never read.
+func (s *outputSubscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	close(s.unsubscribed)
+	atomic.AddInt64(&s.stats.buffered, -s.buffered)
+	s.buffered = 0
+}
+
+// subscriberWriter writes out the output read from a subscriber's queue.
+type subscriberWriter struct {
+	io.Writer
+	subscriber *outputSubscriber
+}
+
+func (w *subscriberWriter) Write(b []byte) (int, error) {
+	w.subscriber.read(len(b))
+	return w.Writer.Write(b)
+}
+
+// outputStreamTracker remembers which subscriber each stream is read from,
+// for as long as it is being streamed.
+type outputStreamTracker struct {
+	mu      sync.Mutex
+	streams map[streamer.StreamID]*outputSubscriber
+}
+
+func newOutputStreamTracker() *outputStreamTracker {
+	return &outputStreamTracker{
+		streams: make(map[streamer.StreamID]*outputSubscriber),
+	}
+}
+
+// add records the stream's subscriber until the returned function is called.
+func (t *outputStreamTracker) add(streamID streamer.StreamID, subscriber *outputSubscriber) func() {
+	t.mu.Lock()
+	t.streams[streamID] = subscriber
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		delete(t.streams, streamID)
+	}
+}
+
+func (t *outputStreamTracker) subscriber(streamID streamer.StreamID) (*outputSubscriber, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	subscriber, found := t.streams[streamID]
+	return subscriber, found
+}
+
+func (s *GardenServer) serveStdout(streamID streamer.StreamID, conn io.Writer) {
+	s.serveOutput(streamID, conn, false)
+}
+
+func (s *GardenServer) serveStderr(streamID streamer.StreamID, conn io.Writer) {
+	s.serveOutput(streamID, conn, true)
+}
+
+func (s *GardenServer) serveOutput(streamID streamer.StreamID, conn io.Writer, stderr bool) {
+	if interactive, found := s.interactiveStreams.interactive(streamID); found {
+		setNoDelay(conn, interactive)
+	}
+
+	writer := s.limitOutputRate(conn)
+	if subscriber, found := s.outputStreams.subscriber(streamID); found {
+		defer subscriber.startReading(stderr)()
+		writer = &subscriberWriter{Writer: writer, subscriber: subscriber}
+	}
+
+	if stderr {
+		s.streamer.ServeStderr(streamID, writer)
+	} else {
+		s.streamer.ServeStdout(streamID, writer)
+	}
+}
+
+// outputBroadcasts holds the output broadcast of each process run through the
+// server, for as long as it runs, so that clients attaching to it join it.
+type outputBroadcasts struct {
+	mu         sync.Mutex
+	broadcasts map[processKey]*outputBroadcast
+}
+
+func newOutputBroadcasts() *outputBroadcasts {
+	return &outputBroadcasts{
+		broadcasts: make(map[processKey]*outputBroadcast),
+	}
+}
+
+func (t *outputBroadcasts) track(handle string, process garden.Process, broadcast *outputBroadcast) {
+	t.mu.Lock()
+	t.broadcasts[processKey{handle: handle, processID: process.ID()}] = broadcast
+	t.mu.Unlock()
+
+	go func() {
+		process.Wait()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// the container may have been renamed since, so the broadcast is
+		// looked for rather than its key
+		for key, tracked := range t.broadcasts {
+			if tracked == broadcast {
+				delete(t.broadcasts, key)
+			}
+		}
+	}()
+}
+
+func (t *outputBroadcasts) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	renamed := map[processKey]*outputBroadcast{}
+	for key, broadcast := range t.broadcasts {
+		if key.handle == oldHandle {
+			renamed[processKey{handle: newHandle, processID: key.processID}] = broadcast
+			delete(t.broadcasts, key)
+		}
+	}
+
+	for key, broadcast := range renamed {
+		t.broadcasts[key] = broadcast
+	}
+}
+
+func (t *outputBroadcasts) get(handle, processID string) (*outputBroadcast, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	broadcast, found := t.broadcasts[processKey{handle: handle, processID: processID}]
+	return broadcast, found
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"io"
+	"sync"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+// outputLimit stops passing on a process's output once it has written its
+// MaxOutputBytes, counting stdout and stderr together, and records whether
+// it did so, so that the exit can be reported as such.
+type outputLimit struct {
+	max uint64
+
+	mu       sync.Mutex
+	written  uint64
+	exceeded chan struct{}
+}
+
+func newOutputLimit(max uint64) *outputLimit {
+	return &outputLimit{
+		max:      max,
+		exceeded: make(chan struct{}),
+	}
+}
+
+// attachment returns a limit to the output the backend hands an attacher of
+// the process, which it does separately from that which the process was run
+// with. It allows as much output as this one has still to allow.
+func (l *outputLimit) attachment() *outputLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return newOutputLimit(l.max - l.written)
+}
+
+// writer returns a writer which passes on to w as much of the output as is
+// still within the limit. Output beyond it is dropped without error, so that
+// the process is not held up.
+func (l *outputLimit) writer(w io.Writer) io.Writer {
+	return &outputLimitWriter{limit: l, w: w}
+}
+
+// take counts n bytes of output against the limit, returning how many of
+// them are within it.
+func (l *outputLimit) take(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	remaining := l.max - l.written
+	if uint64(n) <= remaining {
+		l.written += uint64(n)
+		return n
+	}
+
+	l.written = l.max
+	if !l.wasExceededLocked() {
+		close(l.exceeded)
+	}
+
+	return int(remaining)
+}
+
+// enforce logs the process exceeding the limit, and kills it if kill is set.
+// It gives up once the process exits.
+func (l *outputLimit) enforce(logger lager.Logger, process garden.Process, kill bool) {
+	exited := make(chan struct{})
+	go func() {
+		process.Wait()
+		close(exited)
+	}()
+
+	go func() {
+		select {
+		case <-l.exceeded:
+		case <-exited:
+			return
+		}
+
+		logger.Info("max-output-bytes-exceeded", lager.Data{
+			"id":               process.ID(),
+			"max-output-bytes": l.max,
+			"kill":             kill,
+		})
+
+		if !kill {
+			return
+		}
+
+		if err := process.Signal(garden.SignalKill); err != nil {
+			logger.Error("max-output-bytes-kill-failed", err, lager.Data{
+				"id": process.ID(),
+			})
+		}
+	}()
+}
+
+func (l *outputLimit) wasExceeded() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.wasExceededLocked()
+}
+
+func (l *outputLimit) wasExceededLocked() bool {
+	select {
+	case <-l.exceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+type outputLimitWriter struct {
+	limit *outputLimit
+	w     io.Writer
+}
+
+func (w *outputLimitWriter) Write(p []byte) (int, error) {
+	allowed := w.limit.take(len(p))
+	if allowed > 0 {
+		if n, err := w.w.Write(p[:allowed]); err != nil {
+			return n, err
+		}
+	}
+
+	return len(p), nil
+}
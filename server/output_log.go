@@ -0,0 +1,184 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/server/logfile"
+	"code.cloudfoundry.org/lager"
+)
+
+const defaultOutputLogMaxSize = 10 * 1024 * 1024
+
+var ErrOutputLogsDisabled = garden.InvalidRequestError{Reason: "output logs are not enabled on this server"}
+var ErrInvalidOutputLogName = garden.InvalidRequestError{Reason: "output log name must be a plain file name"}
+
+// SetOutputLogDir enables ProcessSpec.OutputLog, keeping each container's
+// output logs in a directory named after its handle under dir. An empty dir,
+// the default, disables output logs.
+func (s *GardenServer) SetOutputLogDir(dir string) {
+	s.outputLogDir.Store(dir)
+}
+
+func (s *GardenServer) outputLogRoot() string {
+	dir, _ := s.outputLogDir.Load().(string)
+	return dir
+}
+
+// openOutputLog opens the named output log of the container for a process
+// to write to, until the returned log is closed. Processes writing to the
+// same log at once share the writer, so that it is rotated once they have
+// written its maximum size between them; the log is rotated as the first of
+// them asked for.
+func (s *GardenServer) openOutputLog(handle string, spec garden.OutputLogSpec) (*sharedOutputLog, error) {
+	path, err := s.outputLogPath(handle, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.outputLogs.open(path, spec)
+}
+
+func (s *GardenServer) outputLogPath(handle, name string) (string, error) {
+	if s.outputLogRoot() == "" {
+		return "", ErrOutputLogsDisabled
+	}
+
+	if !isPlainFileName(name) {
+		return "", ErrInvalidOutputLogName
+	}
+
+	dir, err := s.outputLogsOf(handle)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+func (s *GardenServer) outputLogsOf(handle string) (string, error) {
+	if !isPlainFileName(handle) {
+		return "", garden.InvalidRequestError{
+			Reason: fmt.Sprintf("handle %q cannot be used to name an output log directory", handle),
+		}
+	}
+
+	return filepath.Join(s.outputLogRoot(), handle), nil
+}
+
+// removeOutputLogs removes the output logs of a container that has been
+// destroyed.
+func (s *GardenServer) removeOutputLogs(logger lager.Logger, handle string) {
+	if s.outputLogRoot() == "" {
+		return
+	}
+
+	dir, err := s.outputLogsOf(handle)
+	if err != nil {
+		return
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Error("failed-to-remove-output-logs", err, lager.Data{
+			"handle": handle,
+		})
+	}
+}
+
+// renameOutputLogs keeps a renamed container's output logs with it.
+func (s *GardenServer) renameOutputLogs(logger lager.Logger, oldHandle, newHandle string) {
+	if s.outputLogRoot() == "" {
+		return
+	}
+
+	oldDir, err := s.outputLogsOf(oldHandle)
+	if err != nil {
+		return
+	}
+
+	newDir, err := s.outputLogsOf(newHandle)
+	if err != nil {
+		return
+	}
+
+	if err := os.Rename(oldDir, newDir); err != nil && !os.IsNotExist(err) {
+		logger.Error("failed-to-rename-output-logs", err, lager.Data{
+			"handle":     oldHandle,
+			"new-handle": newHandle,
+		})
+	}
+}
+
+// outputLogs holds the writers of the output logs being written to, each with
+// the number of processes writing to it.
+type outputLogs struct {
+	mu   sync.Mutex
+	logs map[string]*sharedOutputLog
+}
+
+// sharedOutputLog is an output log shared by the processes writing to it.
+type sharedOutputLog struct {
+	*logfile.Writer
+
+	logs *outputLogs
+	path string
+	refs int
+}
+
+func newOutputLogs() *outputLogs {
+	return &outputLogs{
+		logs: make(map[string]*sharedOutputLog),
+	}
+}
+
+func (l *outputLogs) open(path string, spec garden.OutputLogSpec) (*sharedOutputLog, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if log, found := l.logs[path]; found {
+		log.refs++
+		return log, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	maxSize := int64(spec.MaxSizeInBytes)
+	if maxSize == 0 {
+		maxSize = defaultOutputLogMaxSize
+	}
+
+	writer, err := logfile.NewWriter(path, maxSize, spec.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &sharedOutputLog{Writer: writer, logs: l, path: path, refs: 1}
+	l.logs[path] = log
+
+	return log, nil
+}
+
+// Close stops the process from writing to the log, closing it once no other
+// process is.
+func (log *sharedOutputLog) Close() error {
+	log.logs.mu.Lock()
+	defer log.logs.mu.Unlock()
+
+	log.refs--
+	if log.refs > 0 {
+		return nil
+	}
+
+	delete(log.logs.logs, log.path)
+
+	return log.Writer.Close()
+}
+
+func isPlainFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
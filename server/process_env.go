@@ -0,0 +1,201 @@
+package server
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+const maskedEnvValue = "********"
+
+// SetProcessEnvMaskedKeys masks the values of environment variables whose
+// names match any of the given patterns, in the syntax of path.Match, in the
+// environments returned by ProcessEnv. By default nothing is masked.
+func (s *GardenServer) SetProcessEnvMaskedKeys(patterns []string) {
+	s.processEnvs.setMaskedKeys(patterns)
+}
+
+// processEnvTracker remembers the effective environment of each process run
+// through the server for as long as its status is kept. The environment of
+// its container is taken from the container's spec, so that it survives the
+// server restarting.
+type processEnvTracker struct {
+	retention time.Duration
+
+	mu          sync.Mutex
+	maskedKeys  []string
+	processEnvs map[processKey]*processEnv
+}
+
+// processEnv is the environment of a process, under the key it is tracked
+// by, which changes if its container is renamed.
+type processEnv struct {
+	key processKey
+	env []string
+}
+
+func newProcessEnvTracker(retention time.Duration) *processEnvTracker {
+	return &processEnvTracker{
+		retention:   retention,
+		processEnvs: make(map[processKey]*processEnv),
+	}
+}
+
+func (t *processEnvTracker) setMaskedKeys(patterns []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.maskedKeys = patterns
+}
+
+func (t *processEnvTracker) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, tracked := range t.processEnvs {
+		if key.handle != oldHandle {
+			continue
+		}
+
+		delete(t.processEnvs, key)
+
+		tracked.key.handle = newHandle
+		t.processEnvs[tracked.key] = tracked
+	}
+}
+
+// track records the environment the process runs with: that of its
+// container, overridden by the process's own. A process later run with the
+// same ID replaces it, and is not forgotten along with it.
+func (t *processEnvTracker) track(handle string, process garden.Process, containerEnv, env []string) {
+	tracked := &processEnv{
+		key: processKey{handle: handle, processID: process.ID()},
+		env: mergeEnv(containerEnv, env),
+	}
+
+	t.mu.Lock()
+	t.processEnvs[tracked.key] = tracked
+	t.mu.Unlock()
+
+	go func() {
+		process.Wait()
+
+		time.AfterFunc(t.retention, func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			if t.processEnvs[tracked.key] == tracked {
+				delete(t.processEnvs, tracked.key)
+			}
+		})
+	}()
+}
+
+func (t *processEnvTracker) env(handle, processID string) ([]string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, found := t.processEnvs[processKey{handle: handle, processID: processID}]
+	if !found {
+		return nil, false
+	}
+
+	return t.maskLocked(tracked.env), true
+}
+
+// mask returns a copy of env with the values of the variables whose names
+// match any of the masked keys replaced.
+func (t *processEnvTracker) mask(env []string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.maskLocked(env)
+}
+
+func (t *processEnvTracker) maskLocked(env []string) []string {
+	if env == nil {
+		return nil
+	}
+
+	masked := make([]string, len(env))
+	for i, variable := range env {
+		masked[i] = variable
+		if t.masks(envKey(variable)) {
+			masked[i] = envKey(variable) + "=" + maskedEnvValue
+		}
+	}
+
+	return masked
+}
+
+func (t *processEnvTracker) masks(key string) bool {
+	for _, pattern := range t.maskedKeys {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeEnv returns base with each variable in overrides replacing the one of
+// the same name, or appended if there is none.
+func mergeEnv(base, overrides []string) []string {
+	merged := append([]string{}, base...)
+
+	indices := map[string]int{}
+	for i, variable := range merged {
+		indices[envKey(variable)] = i
+	}
+
+	for _, variable := range overrides {
+		if i, found := indices[envKey(variable)]; found {
+			merged[i] = variable
+			continue
+		}
+
+		indices[envKey(variable)] = len(merged)
+		merged = append(merged, variable)
+	}
+
+	return merged
+}
+
+func envKey(variable string) string {
+	return strings.SplitN(variable, "=", 2)[0]
+}
+
+func (s *GardenServer) handleProcessEnv(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	processID := r.FormValue(":pid")
+
+	hLog := s.logger.Session("get-process-env", lager.Data{
+		"handle": handle,
+		"id":     processID,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	env, found := s.processEnvs.env(container.Handle(), processID)
+	if !found {
+		s.writeError(w, garden.ProcessNotFoundError{ProcessID: processID}, hLog)
+		return
+	}
+
+	s.writeResponse(w, env)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// processExits waits for processes to exit on behalf of requests which
+// report or await their exits. Each process is waited on once, however many
+// requests ask, so a request which ends leaves nothing behind but the one
+// wait, which ends with the process.
+type processExits struct {
+	mu      sync.Mutex
+	waiting map[processKey]*processExit
+}
+
+// processExit is closed once the process exits, or can no longer be waited
+// on, after which its status and error are set.
+type processExit struct {
+	exited chan struct{}
+	status int
+	err    error
+}
+
+func newProcessExits() *processExits {
+	return &processExits{
+		waiting: make(map[processKey]*processExit),
+	}
+}
+
+// wait returns the exit of the process, waiting on it unless it is already
+// being waited on.
+func (p *processExits) wait(handle string, process garden.Process) *processExit {
+	key := processKey{handle: handle, processID: process.ID()}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if exit, found := p.waiting[key]; found {
+		return exit
+	}
+
+	exit := &processExit{exited: make(chan struct{})}
+	p.waiting[key] = exit
+
+	go func() {
+		exit.status, exit.err = process.Wait()
+
+		p.mu.Lock()
+		// the container may have been renamed since, so the exit is looked
+		// for rather than its key
+		for key, waiting := range p.waiting {
+			if waiting == exit {
+				delete(p.waiting, key)
+			}
+		}
+		p.mu.Unlock()
+
+		close(exit.exited)
+	}()
+
+	return exit
+}
+
+func (p *processExits) renamed(oldHandle, newHandle string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	renamed := map[processKey]*processExit{}
+	for key, exit := range p.waiting {
+		if key.handle == oldHandle {
+			renamed[processKey{handle: newHandle, processID: key.processID}] = exit
+			delete(p.waiting, key)
+		}
+	}
+
+	for key, exit := range renamed {
+		p.waiting[key] = exit
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// processLimit is what a process was run with that its clients are told of
+// when it is enforced, so that a client attaching later is told too.
+type processLimit struct {
+	runtime *runtimeLimit
+	output  *outputLimit
+}
+
+// processLimits holds the limits of the processes run through the server
+// while they run, and for the retention period after they exit.
+type processLimits struct {
+	retention time.Duration
+
+	mu     sync.Mutex
+	limits map[processKey]*processLimit
+}
+
+func newProcessLimits(retention time.Duration) *processLimits {
+	return &processLimits{
+		retention: retention,
+		limits:    make(map[processKey]*processLimit),
+	}
+}
+
+func (p *processLimits) track(handle string, process garden.Process, limit *processLimit) {
+	p.mu.Lock()
+	p.limits[processKey{handle: handle, processID: process.ID()}] = limit
+	p.mu.Unlock()
+
+	go func() {
+		process.Wait()
+
+		time.AfterFunc(p.retention, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			// the container may have been renamed since, so the limit is
+			// looked for rather than its key
+			for key, tracked := range p.limits {
+				if tracked == limit {
+					delete(p.limits, key)
+				}
+			}
+		})
+	}()
+}
+
+func (p *processLimits) renamed(oldHandle, newHandle string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	renamed := map[processKey]*processLimit{}
+	for key, limit := range p.limits {
+		if key.handle == oldHandle {
+			renamed[processKey{handle: newHandle, processID: key.processID}] = limit
+			delete(p.limits, key)
+		}
+	}
+
+	for key, limit := range renamed {
+		p.limits[key] = limit
+	}
+}
+
+// get returns the limits of the process, which are none for one not run
+// through the server.
+func (p *processLimits) get(handle, processID string) processLimit {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if limit, found := p.limits[processKey{handle: handle, processID: processID}]; found {
+		return *limit
+	}
+
+	return processLimit{}
+}
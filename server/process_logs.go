@@ -0,0 +1,243 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+// defaultProcessLogSize is how much of its most recent output is kept for a
+// process which does not ask for a size with ProcessSpec.OutputBufferSize.
+const defaultProcessLogSize = 1024 * 1024
+
+// minProcessLogSize is the least output kept for a process; smaller sizes
+// are rounded up to it.
+const minProcessLogSize = 4 * 1024
+
+// maxProcessLogSize bounds ProcessSpec.OutputBufferSize, as the buffer is held
+// in the server's memory.
+const maxProcessLogSize = 16 * 1024 * 1024
+
+// processLogChunkOverhead is counted against a process log's size for each
+// chunk of output on top of its data, for the memory holding the chunk, so
+// that a process writing many tiny chunks cannot hold more than its buffer
+// size.
+const processLogChunkOverhead = 64
+
+var ErrInvalidLogsCursor = garden.InvalidRequestError{Reason: "cursor must be a non-negative integer"}
+var ErrInvalidLogsSince = garden.InvalidRequestError{Reason: "since must be an RFC 3339 timestamp"}
+var ErrOutputBufferTooLarge = garden.InvalidRequestError{Reason: "output buffer size must be at most 16MiB"}
+
+// processLogSize returns how much output to keep for the process.
+func processLogSize(spec garden.ProcessSpec) (int, error) {
+	if spec.OutputBufferSize == 0 {
+		return defaultProcessLogSize, nil
+	}
+
+	if spec.OutputBufferSize > maxProcessLogSize {
+		return 0, ErrOutputBufferTooLarge
+	}
+
+	if spec.OutputBufferSize < minProcessLogSize {
+		return minProcessLogSize, nil
+	}
+
+	return int(spec.OutputBufferSize), nil
+}
+
+// processLog keeps the most recent output of a process, in the chunks it was
+// written in, stamped with when they were written. Chunks are numbered in the
+// order they were written, and a cursor is the number of the next chunk to
+// return.
+type processLog struct {
+	mu      sync.Mutex
+	maxSize int
+	size    int
+	chunks  []processLogChunk
+	next    uint64
+	// droppedAt is when the most recent chunk dropped to make room was
+	// written
+	droppedAt time.Time
+}
+
+type processLogChunk struct {
+	writtenAt time.Time
+	data      []byte
+}
+
+func newProcessLog(maxSize int) *processLog {
+	return &processLog{maxSize: maxSize}
+}
+
+func (l *processLog) Write(p []byte) (int, error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	if max := l.maxSize - processLogChunkOverhead; len(data) > max {
+		data = data[len(data)-max:]
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.chunks = append(l.chunks, processLogChunk{writtenAt: time.Now(), data: data})
+	l.size += len(data) + processLogChunkOverhead
+	l.next++
+
+	for l.size > l.maxSize {
+		l.size -= len(l.chunks[0].data) + processLogChunkOverhead
+		l.droppedAt = l.chunks[0].writtenAt
+		l.chunks = l.chunks[1:]
+	}
+
+	return len(p), nil
+}
+
+// after returns the output kept from the cursor on, written after since,
+// and the cursor to fetch what is written next with.
+func (l *processLog) after(cursor uint64, since time.Time) garden.ProcessLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	first := l.next - uint64(len(l.chunks))
+
+	log := garden.ProcessLog{
+		Output:    []byte{},
+		Cursor:    l.next,
+		Truncated: cursor < first && l.droppedAt.After(since),
+	}
+
+	if cursor < first {
+		cursor = first
+	}
+
+	if cursor < l.next {
+		for _, chunk := range l.chunks[cursor-first:] {
+			if chunk.writtenAt.After(since) {
+				log.Output = append(log.Output, chunk.data...)
+			}
+		}
+	}
+
+	return log
+}
+
+// processLogs holds the logs of the processes run through the server while
+// they run, and for the retention period after they exit.
+type processLogs struct {
+	retention time.Duration
+
+	mu   sync.Mutex
+	logs map[processKey]*processLog
+}
+
+func newProcessLogs(retention time.Duration) *processLogs {
+	return &processLogs{
+		retention: retention,
+		logs:      make(map[processKey]*processLog),
+	}
+}
+
+func (p *processLogs) track(handle string, process garden.Process, log *processLog) {
+	p.mu.Lock()
+	p.logs[processKey{handle: handle, processID: process.ID()}] = log
+	p.mu.Unlock()
+
+	go func() {
+		process.Wait()
+
+		time.AfterFunc(p.retention, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			// the container may have been renamed since, so the log is looked
+			// for rather than its key
+			for key, tracked := range p.logs {
+				if tracked == log {
+					delete(p.logs, key)
+				}
+			}
+		})
+	}()
+}
+
+func (p *processLogs) renamed(oldHandle, newHandle string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	renamed := map[processKey]*processLog{}
+	for key, log := range p.logs {
+		if key.handle == oldHandle {
+			renamed[processKey{handle: newHandle, processID: key.processID}] = log
+			delete(p.logs, key)
+		}
+	}
+
+	for key, log := range renamed {
+		p.logs[key] = log
+	}
+}
+
+func (p *processLogs) get(handle, processID string) (*processLog, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	log, found := p.logs[processKey{handle: handle, processID: processID}]
+	return log, found
+}
+
+// handleProcessLogs returns the output kept for a process from the given
+// cursor on, and written after the given time.
+func (s *GardenServer) handleProcessLogs(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	processID := r.FormValue(":pid")
+
+	hLog := s.logger.Session("get-process-logs", lager.Data{
+		"handle": handle,
+		"id":     processID,
+	})
+
+	var cursor uint64
+	if r.FormValue("cursor") != "" {
+		var err error
+		cursor, err = strconv.ParseUint(r.FormValue("cursor"), 10, 64)
+		if err != nil {
+			s.writeError(w, ErrInvalidLogsCursor, hLog)
+			return
+		}
+	}
+
+	var since time.Time
+	if r.FormValue("since") != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339Nano, r.FormValue("since"))
+		if err != nil {
+			s.writeError(w, ErrInvalidLogsSince, hLog)
+			return
+		}
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	log, found := s.processLogs.get(container.Handle(), processID)
+	if !found {
+		s.writeError(w, garden.ProcessNotFoundError{ProcessID: processID}, hLog)
+		return
+	}
+
+	s.writeResponse(w, log.after(cursor, since))
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// processStatusRetention is how long the exit status of a process is kept
+// after it exits, for clients polling for it.
+const processStatusRetention = 5 * time.Minute
+
+type processKey struct {
+	handle    string
+	processID string
+}
+
+// processTracker remembers the processes spawned through the server so that
+// their status can be queried without attaching to them, and tells watchers
+// of a container about the processes spawned in it and its destruction.
+type processTracker struct {
+	retention time.Duration
+
+	mu       sync.Mutex
+	statuses map[processKey]*garden.ProcessStatus
+	watchers map[string]map[*processWatcher]struct{}
+}
+
+// processWatcher receives the IDs of the processes spawned in a container
+// until it is stopped, or the container is destroyed.
+type processWatcher struct {
+	spawned   chan string
+	destroyed chan struct{}
+	stopped   chan struct{}
+}
+
+func newProcessTracker(retention time.Duration) *processTracker {
+	return &processTracker{
+		retention: retention,
+		statuses:  make(map[processKey]*garden.ProcessStatus),
+		watchers:  make(map[string]map[*processWatcher]struct{}),
+	}
+}
+
+// watch returns a channel of the IDs of processes spawned in the container
+// from now on, a channel closed if the container is destroyed, and a
+// function to stop watching.
+func (t *processTracker) watch(handle string) (<-chan string, <-chan struct{}, func()) {
+	watcher := &processWatcher{
+		spawned:   make(chan string),
+		destroyed: make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+
+	t.mu.Lock()
+	if t.watchers[handle] == nil {
+		t.watchers[handle] = make(map[*processWatcher]struct{})
+	}
+	t.watchers[handle][watcher] = struct{}{}
+	t.mu.Unlock()
+
+	return watcher.spawned, watcher.destroyed, func() {
+		t.mu.Lock()
+		// the container may have been renamed since, so the watcher is looked
+		// for under every handle
+		for handle, watchers := range t.watchers {
+			if _, found := watchers[watcher]; found {
+				delete(watchers, watcher)
+				if len(watchers) == 0 {
+					delete(t.watchers, handle)
+				}
+			}
+		}
+		t.mu.Unlock()
+
+		close(watcher.stopped)
+	}
+}
+
+// track records the process as running until it exits, and its exit status
+// for the retention period afterwards. A process that cannot be waited on is
+// forgotten, as its status is unknown. A process later run with the same ID
+// replaces it, and is not forgotten along with it.
+func (t *processTracker) track(handle string, process garden.Process) {
+	key := processKey{handle: handle, processID: process.ID()}
+	tracked := &garden.ProcessStatus{State: garden.ProcessStateRunning}
+
+	t.mu.Lock()
+	t.statuses[key] = tracked
+	for watcher := range t.watchers[handle] {
+		go func(watcher *processWatcher) {
+			select {
+			case watcher.spawned <- key.processID:
+			case <-watcher.stopped:
+			}
+		}(watcher)
+	}
+	t.mu.Unlock()
+
+	go func() {
+		status, err := process.Wait()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// the container may have been renamed since, so the status is looked
+		// for rather than its key
+		key, found := t.keyOf(tracked)
+		if !found {
+			return
+		}
+
+		if err != nil {
+			delete(t.statuses, key)
+			return
+		}
+
+		tracked.State = garden.ProcessStateExited
+		tracked.ExitStatus = status
+
+		time.AfterFunc(t.retention, func() {
+			t.forget(tracked)
+		})
+	}()
+}
+
+// renamed moves the container's processes, and those watching for more, to
+// its new handle.
+func (t *processTracker) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	renamed := map[processKey]*garden.ProcessStatus{}
+	for key, status := range t.statuses {
+		if key.handle == oldHandle {
+			renamed[processKey{handle: newHandle, processID: key.processID}] = status
+			delete(t.statuses, key)
+		}
+	}
+
+	for key, status := range renamed {
+		t.statuses[key] = status
+	}
+
+	if watchers, found := t.watchers[oldHandle]; found {
+		delete(t.watchers, oldHandle)
+		t.watchers[newHandle] = watchers
+	}
+}
+
+// destroyed tells the container's watchers that it has been destroyed, and
+// stops them watching.
+func (t *processTracker) destroyed(handle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for watcher := range t.watchers[handle] {
+		close(watcher.destroyed)
+	}
+
+	delete(t.watchers, handle)
+}
+
+func (t *processTracker) status(handle, processID string) (garden.ProcessStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, found := t.statuses[processKey{handle: handle, processID: processID}]
+	if !found {
+		return garden.ProcessStatus{}, false
+	}
+
+	return *status, true
+}
+
+func (t *processTracker) forget(tracked *garden.ProcessStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if key, found := t.keyOf(tracked); found {
+		delete(t.statuses, key)
+	}
+}
+
+// keyOf returns the key the status is tracked under, if it has not been
+// forgotten or replaced by that of a process run with the same ID.
+func (t *processTracker) keyOf(tracked *garden.ProcessStatus) (processKey, bool) {
+	for key, status := range t.statuses {
+		if status == tracked {
+			return key, true
+		}
+	}
+
+	return processKey{}, false
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Writer limits the rate at which bytes are written to an underlying writer
+// using a token bucket. The bucket holds up to one second's worth of bytes,
+// so short bursts pass straight through; a Write that exceeds the rate blocks
+// until enough tokens have accumulated, applying backpressure to whoever is
+// writing rather than dropping data.
+type Writer struct {
+	dest       io.Writer
+	rate       float64
+	burst      int
+	onThrottle func(int)
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewWriter returns a Writer that writes to dest at no more than
+// bytesPerSecond. If onThrottle is not nil it is called with the number of
+// bytes in each chunk whose write had to wait.
+func NewWriter(dest io.Writer, bytesPerSecond int, onThrottle func(int)) *Writer {
+	return &Writer{
+		dest:       dest,
+		rate:       float64(bytesPerSecond),
+		burst:      bytesPerSecond,
+		onThrottle: onThrottle,
+
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	written := 0
+
+	for written < len(p) {
+		chunk := len(p) - written
+		if chunk > w.burst {
+			chunk = w.burst
+		}
+
+		if wait := w.reserve(chunk); wait > 0 {
+			if w.onThrottle != nil {
+				w.onThrottle(chunk)
+			}
+
+			time.Sleep(wait)
+		}
+
+		n, err := w.dest.Write(p[written : written+chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// reserve takes n tokens from the bucket and returns how long the caller
+// must wait before they are actually available.
+func (w *Writer) reserve(n int) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+
+	w.tokens += now.Sub(w.last).Seconds() * w.rate
+	if w.tokens > float64(w.burst) {
+		w.tokens = float64(w.burst)
+	}
+
+	w.last = now
+	w.tokens -= float64(n)
+
+	if w.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-w.tokens / w.rate * float64(time.Second))
+}
+
+// Limiter admits events at a steady rate using a token bucket that holds up
+// to burst events. Unlike Writer it never waits: an event that would exceed
+// the rate is refused, and the caller is told how long until it would be
+// admitted.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter admitting eventsPerSecond events, with bursts
+// of up to burst events.
+func NewLimiter(eventsPerSecond float64, burst int) *Limiter {
+	return &Limiter{
+		rate:  eventsPerSecond,
+		burst: burst,
+
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow reports whether an event may happen now, taking a token if so. If not,
+// it returns how long until a token is available.
+func (l *Limiter) Allow() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Idle reports whether the bucket is full, i.e. whether the Limiter is in the
+// same state as a new one.
+func (l *Limiter) Idle() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+
+	return l.tokens >= float64(l.burst)
+}
+
+func (l *Limiter) refill() {
+	now := time.Now()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+
+	l.last = now
+}
@@ -0,0 +1,123 @@
+package ratelimit_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/garden/server/ratelimit"
+)
+
+var _ = Describe("Writer", func() {
+	var (
+		dest      *bytes.Buffer
+		throttled int
+		writer    *ratelimit.Writer
+	)
+
+	BeforeEach(func() {
+		dest = new(bytes.Buffer)
+		throttled = 0
+		writer = ratelimit.NewWriter(dest, 1000, func(n int) {
+			throttled += n
+		})
+	})
+
+	It("passes a burst of up to a second's worth of bytes straight through", func() {
+		before := time.Now()
+
+		n, err := writer.Write(bytes.Repeat([]byte("x"), 1000))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(1000))
+
+		Expect(time.Since(before)).To(BeNumerically("<", 100*time.Millisecond))
+		Expect(throttled).To(Equal(0))
+		Expect(dest.Len()).To(Equal(1000))
+	})
+
+	It("blocks writes that exceed the rate instead of dropping them", func() {
+		before := time.Now()
+
+		n, err := writer.Write(bytes.Repeat([]byte("x"), 1500))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(1500))
+
+		Expect(time.Since(before)).To(BeNumerically(">=", 400*time.Millisecond))
+		Expect(throttled).To(Equal(500))
+		Expect(dest.String()).To(Equal(string(bytes.Repeat([]byte("x"), 1500))))
+	})
+
+	It("refills the bucket over time", func() {
+		_, err := writer.Write(bytes.Repeat([]byte("x"), 1000))
+		Expect(err).ToNot(HaveOccurred())
+
+		time.Sleep(300 * time.Millisecond)
+
+		before := time.Now()
+		_, err = writer.Write(bytes.Repeat([]byte("x"), 200))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(time.Since(before)).To(BeNumerically("<", 100*time.Millisecond))
+		Expect(throttled).To(Equal(0))
+	})
+
+	Context("when the underlying writer fails", func() {
+		It("returns the error and the number of bytes written", func() {
+			writer = ratelimit.NewWriter(failingWriter{}, 1000, nil)
+
+			n, err := writer.Write([]byte("hello"))
+			Expect(err).To(MatchError("boom"))
+			Expect(n).To(Equal(0))
+		})
+	})
+})
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("boom")
+}
+
+var _ = Describe("Limiter", func() {
+	var limiter *ratelimit.Limiter
+
+	BeforeEach(func() {
+		limiter = ratelimit.NewLimiter(10, 2)
+	})
+
+	It("admits a burst and then refuses, saying when to retry", func() {
+		allowed, _ := limiter.Allow()
+		Expect(allowed).To(BeTrue())
+
+		allowed, _ = limiter.Allow()
+		Expect(allowed).To(BeTrue())
+
+		allowed, retryAfter := limiter.Allow()
+		Expect(allowed).To(BeFalse())
+		Expect(retryAfter).To(BeNumerically(">", 0))
+		Expect(retryAfter).To(BeNumerically("<=", 100*time.Millisecond))
+	})
+
+	It("admits events again once the retry time has passed", func() {
+		limiter.Allow()
+		limiter.Allow()
+
+		_, retryAfter := limiter.Allow()
+		time.Sleep(retryAfter)
+
+		allowed, _ := limiter.Allow()
+		Expect(allowed).To(BeTrue())
+	})
+
+	It("is idle only once its bucket has refilled", func() {
+		Expect(limiter.Idle()).To(BeTrue())
+
+		limiter.Allow()
+		Expect(limiter.Idle()).To(BeFalse())
+
+		Eventually(limiter.Idle).Should(BeTrue())
+	})
+})
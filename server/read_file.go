@@ -0,0 +1,94 @@
+package server
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrNotARegularFile = garden.InvalidRequestError{Reason: "file path must name a regular file"}
+
+// handleReadFile streams the raw contents of a single file out of a
+// container. The backend can only stream out tar archives, so the file is
+// unwrapped from the archive here; a directory, any other entry which is not
+// a regular file, such as a symlink, or an empty archive is reported as an
+// error before any content is written.
+func (s *GardenServer) handleReadFile(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	path := r.URL.Query().Get("path")
+
+	hLog := s.logger.Session("read-file", lager.Data{
+		"handle": handle,
+		"path":   path,
+	})
+
+	if !filepath.IsAbs(path) {
+		s.writeError(w, ErrRelativeFilePath, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("reading")
+
+	archive, err := container.StreamOut(garden.StreamOutSpec{Path: path})
+	if errors.Is(err, os.ErrNotExist) {
+		s.writeError(w, garden.FileNotFoundError{Path: path}, hLog)
+		return
+	} else if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	defer archive.Close()
+
+	tr := tar.NewReader(archive)
+
+	header, err := tr.Next()
+	if err == io.EOF {
+		s.writeError(w, garden.FileNotFoundError{Path: path}, hLog)
+		return
+	}
+
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if header.Typeflag == tar.TypeDir {
+		s.writeError(w, garden.IsADirectoryError{Path: path}, hLog)
+		return
+	}
+
+	if header.Typeflag != tar.TypeReg {
+		s.writeError(w, ErrNotARegularFile, hLog)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, tr); err != nil {
+		hLog.Error("failed-to-stream", err)
+		return
+	}
+
+	hLog.Info("read")
+}
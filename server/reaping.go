@@ -0,0 +1,53 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ReapReason says why the server destroyed a container of its own accord.
+type ReapReason string
+
+const (
+	// ReapReasonGraceTimeExpired is given when a container went unused for
+	// longer than its grace time.
+	ReapReasonGraceTimeExpired ReapReason = "grace-time-expired"
+
+	// ReapReasonProcessExited is given when the process a container was
+	// created to run exited.
+	ReapReasonProcessExited ReapReason = "process-exited"
+)
+
+// ReapEvent describes a container reaped by the server.
+type ReapEvent struct {
+	Handle    string
+	GraceTime time.Duration
+	Reason    ReapReason
+
+	// Err is set if destroying the container failed.
+	Err error
+}
+
+// SetReapObserver registers a function called with an event each time the
+// server reaps a container. It is called synchronously as the reap
+// completes, so it should return promptly. A nil observer, the default,
+// disables the events.
+func (s *GardenServer) SetReapObserver(observer func(ReapEvent)) {
+	s.reapObserver.Store(observer)
+}
+
+// ReapedContainers returns the number of containers the server has
+// successfully reaped.
+func (s *GardenServer) ReapedContainers() uint64 {
+	return atomic.LoadUint64(&s.reapedContainers)
+}
+
+func (s *GardenServer) reaped(event ReapEvent) {
+	if event.Err == nil {
+		atomic.AddUint64(&s.reapedContainers, 1)
+	}
+
+	if observer, _ := s.reapObserver.Load().(func(ReapEvent)); observer != nil {
+		observer(event)
+	}
+}
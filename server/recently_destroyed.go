@@ -0,0 +1,66 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// SetHandleReuseGraceWindow refuses to create a container with the handle of
+// one destroyed less than window ago, with a HandleRecentlyDestroyedError,
+// giving the backend time to finish cleaning up after it. Zero, the default,
+// allows handles to be reused straight away.
+func (s *GardenServer) SetHandleReuseGraceWindow(window time.Duration) {
+	s.recentlyDestroyed.setWindow(window)
+}
+
+// recentlyDestroyedHandles remembers when handles were destroyed for as long
+// as they are within the grace window.
+type recentlyDestroyedHandles struct {
+	mu      sync.Mutex
+	window  time.Duration
+	handles map[string]time.Time
+}
+
+func newRecentlyDestroyedHandles() *recentlyDestroyedHandles {
+	return &recentlyDestroyedHandles{
+		handles: make(map[string]time.Time),
+	}
+}
+
+func (r *recentlyDestroyedHandles) setWindow(window time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.window = window
+	r.expire(time.Now())
+}
+
+func (r *recentlyDestroyedHandles) add(handle string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.window <= 0 {
+		return
+	}
+
+	now := time.Now()
+	r.expire(now)
+	r.handles[handle] = now
+}
+
+func (r *recentlyDestroyedHandles) contains(handle string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(time.Now())
+	_, found := r.handles[handle]
+	return found
+}
+
+func (r *recentlyDestroyedHandles) expire(now time.Time) {
+	for handle, destroyedAt := range r.handles {
+		if now.Sub(destroyedAt) >= r.window {
+			delete(r.handles, handle)
+		}
+	}
+}
@@ -1,38 +1,84 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/server/ratelimit"
+	"code.cloudfoundry.org/garden/server/streamer"
 	"code.cloudfoundry.org/garden/transport"
 	"code.cloudfoundry.org/lager"
 )
 
+const defaultTerm = "xterm"
+
 type processDebugInfo struct {
-	Path   string
-	Dir    string
-	User   string
-	Limits garden.ResourceLimits
-	TTY    *garden.TTYSpec
+	Path                string
+	Dir                 string
+	User                string
+	SupplementaryGroups []int
+	Limits              garden.ResourceLimits
+	Capabilities        []string
+	Nice                int
+	TTY                 *garden.TTYSpec
+	StdinFile           string
 }
 
 type containerDebugInfo struct {
-	Handle     string
-	GraceTime  time.Duration
-	RootFSPath string
-	BindMounts []garden.BindMount
-	Network    string
-	Privileged bool
-	Limits     garden.Limits
+	Handle      string
+	GraceTime   time.Duration
+	RootFSPath  string
+	CloneFrom   string
+	Layers      *garden.RootFSLayers
+	BindMounts  []garden.BindMount
+	Scratch     []garden.ScratchVolume
+	Sysctls     map[string]string
+	DNSServers  []string
+	DNSSearch   []string
+	Cgroup      string
+	Network     string
+	Privileged  bool
+	UIDMappings []garden.IDMapping
+	GIDMappings []garden.IDMapping
+	Limits      garden.Limits
 }
 
 var ErrConcurrentDestroy = errors.New("container already being destroyed")
+var ErrNoDestroyProperties = garden.InvalidRequestError{Reason: "at least one property must be given to destroy containers by"}
+var ErrPrivilegedIDMappings = garden.InvalidRequestError{Reason: "uid and gid mappings cannot be used with a privileged container"}
+var ErrScratchVolumesNotSupported = garden.InvalidRequestError{Reason: "scratch volumes are not supported by the backend"}
+var ErrDNSNotSupported = garden.InvalidRequestError{Reason: "dns settings are not supported by the backend"}
+var ErrCgroupParentNotSupported = garden.InvalidRequestError{Reason: "cgroup parents are not supported by the backend"}
+var ErrCloneWithRootFS = garden.InvalidRequestError{Reason: "a cloned container cannot also be given a rootfs or image"}
+var ErrLayersWithRootFS = garden.InvalidRequestError{Reason: "a container with rootfs layers cannot also be given a rootfs, image or clone"}
+var ErrRootFSLayersNotSupported = garden.InvalidRequestError{Reason: "rootfs layers are not supported by the backend"}
+var ErrRelativeCheckpointPath = garden.InvalidRequestError{Reason: "checkpoint image path must be absolute"}
+var ErrInvalidHostPID = garden.InvalidRequestError{Reason: "host pid must be a positive integer"}
+var ErrInvalidNice = garden.InvalidRequestError{Reason: "nice value must be between -20 and 19"}
+var ErrInvalidListLimit = garden.InvalidRequestError{Reason: "list limit must be a non-negative integer"}
+var ErrInvalidListCursor = garden.InvalidRequestError{Reason: "list cursor is not one returned by the server"}
+
+const (
+	minNice = -20
+	maxNice = 19
+)
 
 func (s *GardenServer) handlePing(w http.ResponseWriter, r *http.Request) {
 	hLog := s.logger.Session("ping")
@@ -58,280 +104,1824 @@ func (s *GardenServer) handleCapacity(w http.ResponseWriter, r *http.Request) {
 	s.writeResponse(w, capacity)
 }
 
-func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
-	var spec garden.ContainerSpec
-	if !s.readRequest(&spec, w, r) {
+func (s *GardenServer) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("features")
+
+	features, err := s.backend.Features()
+	if err != nil {
+		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog := s.logger.Session("create", lager.Data{
-		"request": containerDebugInfo{
-			Handle:     spec.Handle,
-			GraceTime:  spec.GraceTime,
-			RootFSPath: spec.RootFSPath,
-			BindMounts: spec.BindMounts,
-			Network:    spec.Network,
-			Privileged: spec.Privileged,
-			Limits:     spec.Limits,
-		},
+	// features of the server itself, whatever the backend
+	features.ListByState = true
+	features.ListPaging = true
+
+	s.writeResponse(w, features)
+}
+
+func (s *GardenServer) handleContainerForHostPID(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("container-for-host-pid", lager.Data{
+		"pid": r.FormValue(":pid"),
 	})
 
-	if spec.GraceTime == 0 {
-		spec.GraceTime = s.containerGraceTime
+	pid, err := strconv.Atoi(r.FormValue(":pid"))
+	if err != nil || pid <= 0 {
+		s.writeError(w, ErrInvalidHostPID, hLog)
+		return
 	}
 
-	hLog.Debug("creating")
-
-	container, err := s.backend.Create(spec)
+	handle, err := s.backend.ContainerForHostPID(pid)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("created")
+	s.writeResponse(w, &struct{ Handle string }{
+		Handle: handle,
+	})
+}
 
-	s.bomberman.Strap(container)
+func (s *GardenServer) handleSelftest(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("selftest")
 
-	s.writeResponse(w, &struct{ Handle string }{
-		Handle: container.Handle(),
+	hLog.Info("started")
+
+	result := s.selftest(hLog)
+
+	hLog.Info("finished", lager.Data{
+		"succeeded": result.Succeeded,
 	})
+
+	s.writeResponse(w, result)
 }
 
-func (s *GardenServer) handleList(w http.ResponseWriter, r *http.Request) {
-	properties := garden.Properties{}
-	for name, vals := range r.URL.Query() {
-		if len(vals) > 0 {
-			properties[name] = vals[0]
-		}
+func (s *GardenServer) handleWatchCapacity(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("watch-capacity")
+
+	capacity, err := s.backend.Capacity()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
 	}
 
-	hLog := s.logger.Session("list")
-	hLog.Debug("started")
+	changed := s.capacityNotifier.watch()
+	defer s.capacityNotifier.unwatch(changed)
 
-	containers, err := s.backend.Containers(properties)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	handles := []string{}
+	defer conn.Close()
 
-	for _, container := range containers {
-		handles = append(handles, container.Handle())
-	}
+	hLog.Debug("watching")
 
-	hLog.Debug("ending", lager.Data{"handles": handles})
+	// the client never sends anything, so a read only returns once it has
+	// gone away
+	disconnected := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, br)
+		close(disconnected)
+	}()
+
+	var sent garden.Capacity
+	for first := true; ; first = false {
+		// a container created or destroyed need not change the capacity
+		if first || capacity != sent {
+			if err := transport.WriteMessage(conn, capacity); err != nil {
+				hLog.Debug("disconnected")
+				return
+			}
+
+			sent = capacity
+		}
+
+		select {
+		case <-changed:
+		case <-disconnected:
+			hLog.Debug("disconnected")
+			return
+		case <-s.stopping:
+			return
+		}
 
-	s.writeResponse(w, &struct{ Handles []string }{handles})
+		capacity, err = s.backend.Capacity()
+		if err != nil {
+			hLog.Error("failed-to-get-capacity", err)
+			return
+		}
+	}
 }
 
-func (s *GardenServer) handleDestroy(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var spec garden.ContainerSpec
+	if !s.readRequest(&spec, w, r) {
+		return
+	}
 
-	hLog := s.logger.Session("destroy", lager.Data{
-		"handle": handle,
+	hLog := s.logger.Session("create", lager.Data{
+		"request": containerDebugInfo{
+			Handle:      spec.Handle,
+			GraceTime:   spec.GraceTime,
+			RootFSPath:  spec.RootFSPath,
+			CloneFrom:   spec.CloneFrom,
+			Layers:      spec.RootFSLayers,
+			BindMounts:  spec.BindMounts,
+			Scratch:     spec.ScratchVolumes,
+			Sysctls:     spec.Sysctls,
+			DNSServers:  spec.DNSServers,
+			DNSSearch:   spec.DNSSearch,
+			Cgroup:      spec.CgroupParent,
+			Network:     spec.Network,
+			Privileged:  spec.Privileged,
+			UIDMappings: spec.UIDMappings,
+			GIDMappings: spec.GIDMappings,
+			Limits:      spec.Limits,
+		},
 	})
 
-	s.destroysL.Lock()
+	if s.Draining() {
+		s.writeError(w, garden.DrainingError{}, hLog)
+		return
+	}
 
-	_, alreadyDestroying := s.destroys[handle]
-	if !alreadyDestroying {
-		s.destroys[handle] = struct{}{}
+	if spec.GraceTime == 0 {
+		spec.GraceTime = s.containerGraceTime
 	}
 
-	s.destroysL.Unlock()
+	if spec.Privileged && (len(spec.UIDMappings) > 0 || len(spec.GIDMappings) > 0) {
+		s.writeError(w, ErrPrivilegedIDMappings, hLog)
+		return
+	}
 
-	if alreadyDestroying {
-		s.writeError(w, ErrConcurrentDestroy, hLog)
+	if spec.Handle != "" && s.recentlyDestroyed.contains(spec.Handle) {
+		s.writeError(w, garden.HandleRecentlyDestroyedError{Handle: spec.Handle}, hLog)
 		return
 	}
 
-	hLog.Debug("destroying")
+	if err := validateBindMounts(spec.BindMounts); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	err := s.backend.Destroy(handle)
+	if err := validateScratchVolumes(spec.ScratchVolumes, spec.BindMounts); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	if !alreadyDestroying {
-		s.destroysL.Lock()
-		delete(s.destroys, handle)
-		s.destroysL.Unlock()
+	if err := validateSysctls(spec.Sysctls); err != nil {
+		s.writeError(w, err, hLog)
+		return
 	}
 
-	if err != nil {
+	if err := validateDNS(spec.DNSServers, spec.DNSSearch); err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("destroyed")
+	if err := validateCgroupParent(spec.CgroupParent); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	s.bomberman.Defuse(handle)
+	if err := validateCPUSet(spec.Limits.CPU.CPUSet); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	s.writeSuccess(w)
-}
+	if err := validateSyslog(spec.Syslog); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-func (s *GardenServer) handleStop(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+	if err := s.syslogs.check(spec.Syslog); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	hLog := s.logger.Session("stop", lager.Data{
-		"handle": handle,
-	})
+	if err := s.checkReadOnlyBindMounts(spec.BindMounts); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	var request struct {
-		Kill bool `json:"kill"`
+	if err := s.checkScratchVolumes(spec.ScratchVolumes); err != nil {
+		s.writeError(w, err, hLog)
+		return
 	}
-	if !s.readRequest(&request, w, r) {
+
+	if err := s.checkDNS(spec.DNSServers, spec.DNSSearch); err != nil {
+		s.writeError(w, err, hLog)
 		return
 	}
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
+	if err := s.checkCgroupParent(spec.CgroupParent); err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	if err := s.checkRootFSLayers(spec.RootFSLayers); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	hLog.Debug("stopping")
+	if err := validateRootFSLayers(spec); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	err = container.Stop(request.Kill)
+	release, err := s.admitCreate()
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
+	defer release()
 
-	hLog.Info("stopped")
+	if spec.CloneFrom != "" {
+		if spec.RootFSPath != "" || spec.Image.URI != "" {
+			s.writeError(w, ErrCloneWithRootFS, hLog)
+			return
+		}
 
-	s.writeSuccess(w)
-}
+		// the source must outlive the clone being taken, so keep it from
+		// being destroyed or reaped until the new container exists
+		s.handleLocks.RLock(spec.CloneFrom)
+		defer s.handleLocks.RUnlock(spec.CloneFrom)
 
-func (s *GardenServer) handleStreamIn(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+		source, err := s.backend.Lookup(spec.CloneFrom)
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
 
-	user := r.URL.Query().Get("user")
-	dstPath := r.URL.Query().Get("destination")
+		s.bomberman.Pause(source.Handle())
+		defer s.bomberman.Unpause(source.Handle())
+	}
 
-	hLog := s.logger.Session("stream-in", lager.Data{
-		"handle":      handle,
-		"user":        user,
-		"destination": dstPath,
-	})
+	hLog.Debug("creating")
 
-	container, err := s.backend.Lookup(handle)
+	container, err := s.backend.Create(spec)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	hLog.Info("created")
 
-	hLog.Debug("streaming-in")
+	s.syslogs.created(container.Handle(), spec.Syslog)
 
-	err = container.StreamIn(garden.StreamInSpec{
-		User:      user,
-		Path:      dstPath,
-		TarStream: r.Body,
-	})
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+	if err := s.containerSpecs.created(s.containerSpecRoot(), container.Handle(), spec); err != nil {
+		hLog.Error("failed-to-save-spec", err)
 	}
 
-	hLog.Info("streamed-in")
+	s.capacityNotifier.notify()
 
-	s.writeSuccess(w)
-}
+	s.bomberman.Strap(container)
 
-func (s *GardenServer) writeSuccess(w http.ResponseWriter) {
-	s.writeResponse(w, &struct{}{})
+	s.writeResponse(w, &struct{ Handle string }{
+		Handle: container.Handle(),
+	})
 }
 
-func (s *GardenServer) handleStreamOut(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+// validateBindMounts checks that every bind mount's destination is an
+// absolute path which cannot climb out of the container's rootfs, and that the
+// source of every host bind mount exists and, for read-only mounts, can be
+// read, so that a bad mount is reported by name rather than as whatever the
+// backend fails with.
+func validateBindMounts(mounts []garden.BindMount) error {
+	for _, mount := range mounts {
+		mountErr := func(cause string) error {
+			return garden.BindMountError{
+				SrcPath: mount.SrcPath,
+				DstPath: mount.DstPath,
+				Cause:   cause,
+			}
+		}
 
-	user := r.URL.Query().Get("user")
-	srcPath := r.URL.Query().Get("source")
+		if !filepath.IsAbs(mount.DstPath) {
+			return mountErr("destination path must be absolute")
+		}
 
-	hLog := s.logger.Session("stream-out", lager.Data{
-		"handle": handle,
-		"user":   user,
-		"source": srcPath,
-	})
+		for _, element := range strings.Split(mount.DstPath, "/") {
+			if element == ".." {
+				return mountErr("destination path must not contain ..")
+			}
+		}
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
-	}
+		switch mount.Propagation {
+		case garden.BindMountPropagationPrivate, garden.BindMountPropagationShared, garden.BindMountPropagationSlave:
+		default:
+			return mountErr("unknown propagation")
+		}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+		if mount.Origin != garden.BindMountOriginHost {
+			continue
+		}
 
-	hLog.Debug("streaming-out")
+		if _, err := os.Stat(mount.SrcPath); err != nil {
+			if os.IsNotExist(err) {
+				return mountErr("source path does not exist")
+			}
 
-	reader, err := container.StreamOut(garden.StreamOutSpec{
-		User: user,
-		Path: srcPath,
-	})
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+			return mountErr(err.Error())
+		}
+
+		if mount.Mode == garden.BindMountModeRO {
+			f, err := os.Open(mount.SrcPath)
+			if err != nil {
+				return mountErr("source path is not readable")
+			}
+			f.Close()
+		}
 	}
 
-	n, err := io.Copy(w, reader)
-	if err != nil {
-		if err := reader.Close(); err != nil {
-			hLog.Error("failed-to-close", err)
+	return nil
+}
+
+// checkReadOnlyBindMounts refuses read-only bind mounts unless the backend
+// guarantees to enforce them, as silently mounting them read-write would let
+// a container write to host directories it was meant only to read.
+func (s *GardenServer) checkReadOnlyBindMounts(mounts []garden.BindMount) error {
+	for _, mount := range mounts {
+		if mount.Mode != garden.BindMountModeRO {
+			continue
 		}
 
-		if n == 0 {
-			s.writeError(w, err, hLog)
+		features, err := s.backend.Features()
+		if err != nil {
+			return err
 		}
 
-		return
+		if features.ReadOnlyBindMounts {
+			return nil
+		}
+
+		return garden.BindMountError{
+			SrcPath: mount.SrcPath,
+			DstPath: mount.DstPath,
+			Cause:   "read-only bind mounts are not enforced by the backend",
+		}
 	}
 
-	hLog.Info("streamed-out")
+	return nil
 }
 
-func (s *GardenServer) handleCurrentBandwidthLimits(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
-
-	hLog := s.logger.Session("current-bandwidth-limits", lager.Data{
-		"handle": handle,
-	})
+// checkScratchVolumes refuses scratch volumes unless the backend supports
+// them, as a backend which does not would create the container without them.
+func (s *GardenServer) checkScratchVolumes(volumes []garden.ScratchVolume) error {
+	if len(volumes) == 0 {
+		return nil
+	}
 
-	container, err := s.backend.Lookup(handle)
+	features, err := s.backend.Features()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return err
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	if !features.ScratchVolumes {
+		return ErrScratchVolumesNotSupported
+	}
 
-	hLog.Debug("getting")
+	return nil
+}
+
+// checkRootFSLayers refuses rootfs layers unless the backend supports them.
+func (s *GardenServer) checkRootFSLayers(layers *garden.RootFSLayers) error {
+	if layers == nil {
+		return nil
+	}
+
+	features, err := s.backend.Features()
+	if err != nil {
+		return err
+	}
+
+	if !features.RootFSLayers {
+		return ErrRootFSLayersNotSupported
+	}
+
+	return nil
+}
+
+// checkDNS refuses DNS settings unless the backend supports them.
+func (s *GardenServer) checkDNS(servers, search []string) error {
+	if len(servers) == 0 && len(search) == 0 {
+		return nil
+	}
+
+	features, err := s.backend.Features()
+	if err != nil {
+		return err
+	}
+
+	if !features.DNS {
+		return ErrDNSNotSupported
+	}
+
+	return nil
+}
+
+// checkCgroupParent refuses a cgroup parent unless the backend supports them.
+func (s *GardenServer) checkCgroupParent(parent string) error {
+	if parent == "" {
+		return nil
+	}
+
+	features, err := s.backend.Features()
+	if err != nil {
+		return err
+	}
+
+	if !features.CgroupParent {
+		return ErrCgroupParentNotSupported
+	}
+
+	return nil
+}
+
+// validateScratchVolumes checks that every scratch volume has a size limit
+// and a mount point of its own.
+func validateScratchVolumes(volumes []garden.ScratchVolume, mounts []garden.BindMount) error {
+	used := map[string]bool{}
+	for _, mount := range mounts {
+		used[filepath.Clean(mount.DstPath)] = true
+	}
+
+	for _, volume := range volumes {
+		volumeErr := func(reason string) error {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("invalid scratch volume %s: %s", volume.Path, reason),
+			}
+		}
+
+		if !filepath.IsAbs(volume.Path) {
+			return volumeErr("path must be absolute")
+		}
+
+		if volume.SizeInBytes == 0 {
+			return volumeErr("size must be given")
+		}
+
+		if volume.Medium != garden.ScratchVolumeMediumDisk && volume.Medium != garden.ScratchVolumeMediumMemory {
+			return volumeErr("unknown medium")
+		}
+
+		path := filepath.Clean(volume.Path)
+		if used[path] {
+			return volumeErr("path is already mounted")
+		}
+		used[path] = true
+	}
+
+	return nil
+}
+
+// validateRootFSLayers checks that a layered root file system is made of
+// existing host directories, none of which overlaps another, as the overlay
+// could otherwise write into one of its own read-only layers.
+func validateRootFSLayers(spec garden.ContainerSpec) error {
+	layers := spec.RootFSLayers
+	if layers == nil {
+		return nil
+	}
+
+	if spec.RootFSPath != "" || spec.Image.URI != "" || spec.CloneFrom != "" {
+		return ErrLayersWithRootFS
+	}
+
+	if len(layers.Lower) == 0 {
+		return garden.InvalidRequestError{Reason: "rootfs layers must include at least one lower layer"}
+	}
+
+	paths := append([]string{}, layers.Lower...)
+	if layers.Upper != "" {
+		paths = append(paths, layers.Upper)
+	}
+
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("invalid rootfs layer %s: path must be absolute", path),
+			}
+		}
+
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("invalid rootfs layer %s: not an existing directory", path),
+			}
+		}
+
+		for _, other := range paths[:i] {
+			if pathsOverlap(path, other) {
+				return garden.InvalidRequestError{
+					Reason: fmt.Sprintf("rootfs layers %s and %s overlap", other, path),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// pathsOverlap reports whether two paths are the same, or one is inside the
+// other.
+func pathsOverlap(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if a == b {
+		return true
+	}
+
+	return strings.HasPrefix(a, strings.TrimSuffix(b, "/")+"/") || strings.HasPrefix(b, strings.TrimSuffix(a, "/")+"/")
+}
+
+// namespacedSysctls are the kernel parameters outside of the net. and
+// fs.mqueue. trees which belong to a container's namespaces.
+var namespacedSysctls = map[string]bool{
+	"kernel.shmall":          true,
+	"kernel.shmmax":          true,
+	"kernel.shmmni":          true,
+	"kernel.shm_rmid_forced": true,
+	"kernel.msgmax":          true,
+	"kernel.msgmnb":          true,
+	"kernel.msgmni":          true,
+	"kernel.sem":             true,
+}
+
+// validateSysctls checks that every sysctl is namespaced, so that setting it
+// for a container cannot change the host.
+func validateSysctls(sysctls map[string]string) error {
+	for name := range sysctls {
+		if strings.HasPrefix(name, "net.") || strings.HasPrefix(name, "fs.mqueue.") || namespacedSysctls[name] {
+			continue
+		}
+
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("sysctl %s is not namespaced, so cannot be set for a container", name),
+		}
+	}
+
+	return nil
+}
+
+// validateDNS checks that every DNS server is an IP address and every search
+// domain a well-formed domain name, so that nothing unexpected ends up in the
+// container's resolv.conf.
+func validateDNS(servers, search []string) error {
+	for _, addr := range servers {
+		if net.ParseIP(addr) == nil {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("dns server %q is not an IP address", addr),
+			}
+		}
+	}
+
+	for _, domain := range search {
+		if !validDomainName(domain) {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("dns search domain %q is not a valid domain name", domain),
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateCgroupParent checks that a cgroup parent is either a systemd slice
+// name or a clean absolute cgroupfs path, so that it cannot name a cgroup
+// outside of the hierarchy or be mistaken for the other kind.
+func validateCgroupParent(parent string) error {
+	if parent == "" {
+		return nil
+	}
+
+	if strings.HasSuffix(parent, ".slice") {
+		if !validSliceName(parent) {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("cgroup parent %q is not a valid systemd slice name", parent),
+			}
+		}
+
+		return nil
+	}
+
+	if !filepath.IsAbs(parent) || filepath.Clean(parent) != parent || parent == "/" {
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("cgroup parent %q must be a systemd slice or a clean absolute path below the root cgroup", parent),
+		}
+	}
+
+	return nil
+}
+
+// validSliceName reports whether name is a systemd slice name: dash-separated
+// parts, none of them empty, of letters, digits, colons, underscores and
+// dots, followed by ".slice".
+func validSliceName(name string) bool {
+	name = strings.TrimSuffix(name, ".slice")
+	if name == "" {
+		return false
+	}
+
+	for _, part := range strings.Split(name, "-") {
+		if part == "" {
+			return false
+		}
+
+		for _, c := range part {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == ':', c == '_', c == '.':
+			default:
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// validDomainName reports whether name is made up of dot-separated labels of
+// letters, digits and hyphens, each at most 63 characters long and neither
+// starting nor ending with a hyphen. A single trailing dot is allowed.
+func validDomainName(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, c := range label {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-':
+			default:
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (s *GardenServer) handleList(w http.ResponseWriter, r *http.Request) {
+	properties := garden.Properties{}
+	state := ""
+	limit := ""
+	cursor := ""
+	for name, vals := range r.URL.Query() {
+		if len(vals) == 0 {
+			continue
+		}
+
+		switch name {
+		case transport.ListStateFilter:
+			state = vals[0]
+		case transport.ListLimit:
+			limit = vals[0]
+		case transport.ListCursor:
+			cursor = vals[0]
+		default:
+			properties[name] = vals[0]
+		}
+	}
+
+	hLog := s.logger.Session("list")
+	hLog.Debug("started")
+
+	page, err := parseListPage(limit, cursor)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	containers, err := s.backend.Containers(properties)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	handles := []string{}
+
+	for _, container := range containers {
+		handles = append(handles, container.Handle())
+	}
+
+	// backends may list containers in any order; sorting lets clients diff
+	// successive lists
+	sort.Strings(handles)
+
+	if state != "" {
+		handles, err = s.filterByState(handles, garden.ContainerState(state))
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+	}
+
+	handles, nextCursor := page.of(handles)
+
+	hLog.Debug("ending", lager.Data{"handles": handles})
+
+	s.writeResponse(w, &transport.ListResponse{
+		Handles:    handles,
+		NextCursor: nextCursor,
+	})
+}
+
+// listPage selects a page of a sorted list of handles: at most limit of the
+// handles after the one encoded in the cursor. A limit of zero selects the
+// rest of the list.
+type listPage struct {
+	limit int
+	after string
+}
+
+func parseListPage(limit, cursor string) (listPage, error) {
+	var page listPage
+
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return listPage{}, ErrInvalidListLimit
+		}
+
+		page.limit = n
+	}
+
+	if cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(after) == 0 {
+			return listPage{}, ErrInvalidListCursor
+		}
+
+		page.after = string(after)
+	}
+
+	return page, nil
+}
+
+// of returns the page of the handles, and the cursor of the next page if
+// there are handles after it. The cursor is the last handle returned, so
+// that containers created or destroyed between pages do not shift the rest.
+func (p listPage) of(handles []string) ([]string, string) {
+	if p.after != "" {
+		start := sort.SearchStrings(handles, p.after)
+		if start < len(handles) && handles[start] == p.after {
+			start++
+		}
+
+		handles = handles[start:]
+	}
+
+	if p.limit == 0 || len(handles) <= p.limit {
+		return handles, ""
+	}
+
+	handles = handles[:p.limit]
+
+	return handles, base64.RawURLEncoding.EncodeToString([]byte(handles[len(handles)-1]))
+}
+
+// filterByState keeps the handles of the containers in the given state.
+func (s *GardenServer) filterByState(handles []string, state garden.ContainerState) ([]string, error) {
+	if len(handles) == 0 {
+		return handles, nil
+	}
+
+	bulkInfo, err := s.backend.BulkInfo(handles)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []string{}
+	for _, handle := range handles {
+		// the container may have been destroyed since it was listed
+		entry, found := bulkInfo[handle]
+		if !found || entry.Err != nil {
+			continue
+		}
+
+		if garden.ContainerState(entry.Info.State) == state {
+			matching = append(matching, handle)
+		}
+	}
+
+	return matching, nil
+}
+
+func (s *GardenServer) handleDestroy(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("destroy", lager.Data{
+		"handle": handle,
+	})
+
+	if err := s.destroyContainer(hLog, handle); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeSuccess(w)
+}
+
+// destroyContainer destroys a container through the backend, refusing to
+// destroy it twice concurrently, and forgets everything the server keeps
+// about it.
+func (s *GardenServer) destroyContainer(hLog lager.Logger, handle string) error {
+	s.destroysL.Lock()
+
+	_, alreadyDestroying := s.destroys[handle]
+	if !alreadyDestroying {
+		s.destroys[handle] = struct{}{}
+	}
+
+	s.destroysL.Unlock()
+
+	if alreadyDestroying {
+		return ErrConcurrentDestroy
+	}
+
+	hLog.Debug("destroying", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.Lock(handle)
+	err := s.backend.Destroy(handle)
+	s.handleLocks.Unlock(handle)
+
+	if !alreadyDestroying {
+		s.destroysL.Lock()
+		delete(s.destroys, handle)
+		s.destroysL.Unlock()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	hLog.Info("destroyed", lager.Data{
+		"handle": handle,
+	})
+
+	s.forgetContainer(hLog, handle)
+
+	s.capacityNotifier.notify()
+
+	s.bomberman.Defuse(handle)
+
+	return nil
+}
+
+func (s *GardenServer) handleDestroyByProperties(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Properties garden.Properties `json:"properties"`
+	}
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	hLog := s.logger.Session("destroy-by-properties", lager.Data{
+		"properties": request.Properties,
+	})
+
+	if len(request.Properties) == 0 {
+		s.writeError(w, ErrNoDestroyProperties, hLog)
+		return
+	}
+
+	containers, err := s.backend.Containers(request.Properties)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	response := struct {
+		Destroyed []string                 `json:"destroyed"`
+		Errors    map[string]*garden.Error `json:"errors"`
+	}{
+		Destroyed: []string{},
+		Errors:    map[string]*garden.Error{},
+	}
+
+	for _, container := range containers {
+		handle := container.Handle()
+
+		if err := s.destroyContainer(hLog, handle); err != nil {
+			hLog.Error("destroy-failed", err, lager.Data{
+				"handle": handle,
+			})
+
+			response.Errors[handle] = &garden.Error{Err: err}
+			continue
+		}
+
+		response.Destroyed = append(response.Destroyed, handle)
+	}
+
+	hLog.Info("destroyed-by-properties", lager.Data{
+		"destroyed": len(response.Destroyed),
+		"failed":    len(response.Errors),
+	})
+
+	s.writeResponse(w, response)
+}
+
+func (s *GardenServer) handleRename(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var request struct {
+		Handle string `json:"handle"`
+	}
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	newHandle := request.Handle
+
+	hLog := s.logger.Session("rename", lager.Data{
+		"handle":     handle,
+		"new-handle": newHandle,
+	})
+
+	if newHandle == handle {
+		s.writeError(w, garden.HandleConflictError{Handle: newHandle}, hLog)
+		return
+	}
+
+	// always take the locks in the same order so that two renames swapping
+	// the same pair of handles cannot deadlock
+	first, second := handle, newHandle
+	if second < first {
+		first, second = second, first
+	}
+
+	s.handleLocks.Lock(first)
+	defer s.handleLocks.Unlock(first)
+
+	s.handleLocks.Lock(second)
+	defer s.handleLocks.Unlock(second)
+
+	if _, err := s.backend.Lookup(handle); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if _, err := s.backend.Lookup(newHandle); err == nil {
+		s.writeError(w, garden.HandleConflictError{Handle: newHandle}, hLog)
+		return
+	}
+
+	hLog.Debug("renaming")
+
+	err := s.backend.Rename(handle, newHandle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Defuse(handle)
+
+	s.renameOutputLogs(hLog, handle, newHandle)
+	s.processTracker.renamed(handle, newHandle)
+	s.processLogs.renamed(handle, newHandle)
+	s.processLimits.renamed(handle, newHandle)
+	s.processExits.renamed(handle, newHandle)
+	s.attachments.renamed(handle, newHandle)
+	s.processEnvs.renamed(handle, newHandle)
+	s.outputs.renamed(handle, newHandle)
+	s.syslogs.renamed(handle, newHandle)
+	s.trafficRules.renamed(handle, newHandle)
+	s.infoVersions.renamed(handle, newHandle)
+	s.limitBoosts.renamed(handle, newHandle)
+
+	if err := s.containerSpecs.renamed(s.containerSpecRoot(), handle, newHandle); err != nil {
+		hLog.Error("failed-to-rename-spec", err)
+	}
+
+	container, err := s.backend.Lookup(newHandle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Strap(container)
+
+	hLog.Info("renamed")
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) handleStop(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("stop", lager.Data{
+		"handle": handle,
+	})
+
+	var request struct {
+		Kill bool `json:"kill"`
+	}
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("stopping")
+
+	err = container.Stop(request.Kill)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("stopped")
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var request struct {
+		ImagePath string `json:"image_path"`
+	}
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	hLog := s.logger.Session("checkpoint", lager.Data{
+		"handle":     handle,
+		"image-path": request.ImagePath,
+	})
+
+	if !filepath.IsAbs(request.ImagePath) {
+		s.writeError(w, ErrRelativeCheckpointPath, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("checkpointing")
+
+	err = container.Checkpoint(request.ImagePath)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("checkpointed")
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) handleRestore(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var request struct {
+		ImagePath string `json:"image_path"`
+	}
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	hLog := s.logger.Session("restore", lager.Data{
+		"handle":     handle,
+		"image-path": request.ImagePath,
+	})
+
+	if !filepath.IsAbs(request.ImagePath) {
+		s.writeError(w, ErrRelativeCheckpointPath, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("restoring")
+
+	err = container.Restore(request.ImagePath)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("restored")
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) handleSync(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("sync", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("syncing")
+
+	err = container.Sync()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("synced")
+
+	s.writeSuccess(w)
+}
+
+// validateUser checks that a stream user is either a user name or a numeric
+// uid:gid pair. Resolving names is left to the backend, which has access to
+// the container's /etc/passwd.
+func validateUser(user string) error {
+	if !strings.Contains(user, ":") {
+		return nil
+	}
+
+	ids := strings.Split(user, ":")
+	if len(ids) == 2 {
+		_, uidErr := strconv.ParseUint(ids[0], 10, 32)
+		_, gidErr := strconv.ParseUint(ids[1], 10, 32)
+		if uidErr == nil && gidErr == nil {
+			return nil
+		}
+	}
+
+	return garden.InvalidRequestError{
+		Reason: fmt.Sprintf("invalid user %q: must be a user name or a numeric uid:gid pair", user),
+	}
+}
+
+// validateOwner checks that a stream-in owner, if given, is a numeric uid:gid
+// pair. Unlike a user, it is never resolved by name.
+func validateOwner(owner string) error {
+	if owner == "" {
+		return nil
+	}
+
+	if strings.Contains(owner, ":") && validateUser(owner) == nil {
+		return nil
+	}
+
+	return garden.InvalidRequestError{
+		Reason: fmt.Sprintf("invalid owner %q: must be a numeric uid:gid pair", owner),
+	}
+}
+
+const (
+	minMemoryLimit = 1 << 20
+	minStackLimit  = 64 << 10
+	maxNiceLimit   = 40
+	maxRtprioLimit = 99
+)
+
+// validateResourceLimits rejects rlimits that would stop a process from
+// starting at all, which would otherwise surface as an opaque exec failure
+// from the backend.
+func validateResourceLimits(limits garden.ResourceLimits) error {
+	nofileOK, nofileWant := func(v uint64) bool { return v >= 1 }, "at least 1"
+	if max, found := maxNofileLimit(); found {
+		nofileOK = func(v uint64) bool { return v >= 1 && v <= max }
+		nofileWant = fmt.Sprintf("between 1 and %d", max)
+	}
+
+	checks := []struct {
+		name  string
+		value *uint64
+		ok    func(uint64) bool
+		want  string
+	}{
+		{"nofile", limits.Nofile, nofileOK, nofileWant},
+		{"nproc", limits.Nproc, func(v uint64) bool { return v >= 1 }, "at least 1"},
+		{"as", limits.As, func(v uint64) bool { return v >= minMemoryLimit }, "at least 1048576 bytes"},
+		{"data", limits.Data, func(v uint64) bool { return v >= minMemoryLimit }, "at least 1048576 bytes"},
+		{"stack", limits.Stack, func(v uint64) bool { return v >= minStackLimit }, "at least 65536 bytes"},
+		{"nice", limits.Nice, func(v uint64) bool { return v <= maxNiceLimit }, "at most 40"},
+		{"rtprio", limits.Rtprio, func(v uint64) bool { return v <= maxRtprioLimit }, "at most 99"},
+	}
+
+	for _, check := range checks {
+		if check.value != nil && !check.ok(*check.value) {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("invalid rlimit %s %d: must be %s", check.name, *check.value, check.want),
+			}
+		}
+	}
+
+	return nil
+}
+
+// procRoot is where the host's procfs is mounted.
+const procRoot = "/proc"
+
+// maxNofileLimit returns the kernel's ceiling on the open file limit of a
+// process, fs.nr_open. Where it cannot be read it is left to the backend to
+// refuse a limit above it.
+func maxNofileLimit() (uint64, bool) {
+	contents, err := ioutil.ReadFile(filepath.Join(procRoot, "sys", "fs", "nr_open"))
+	if err != nil {
+		return 0, false
+	}
+
+	max, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return max, true
+}
+
+// capabilities are the Linux capabilities a process can be granted.
+var capabilities = map[string]bool{
+	"CHOWN": true, "DAC_OVERRIDE": true, "DAC_READ_SEARCH": true, "FOWNER": true,
+	"FSETID": true, "KILL": true, "SETGID": true, "SETUID": true,
+	"SETPCAP": true, "LINUX_IMMUTABLE": true, "NET_BIND_SERVICE": true, "NET_BROADCAST": true,
+	"NET_ADMIN": true, "NET_RAW": true, "IPC_LOCK": true, "IPC_OWNER": true,
+	"SYS_MODULE": true, "SYS_RAWIO": true, "SYS_CHROOT": true, "SYS_PTRACE": true,
+	"SYS_PACCT": true, "SYS_ADMIN": true, "SYS_BOOT": true, "SYS_NICE": true,
+	"SYS_RESOURCE": true, "SYS_TIME": true, "SYS_TTY_CONFIG": true, "MKNOD": true,
+	"LEASE": true, "AUDIT_WRITE": true, "AUDIT_CONTROL": true, "SETFCAP": true,
+	"MAC_OVERRIDE": true, "MAC_ADMIN": true, "SYSLOG": true, "WAKE_ALARM": true,
+	"BLOCK_SUSPEND": true, "AUDIT_READ": true, "PERFMON": true, "BPF": true,
+	"CHECKPOINT_RESTORE": true,
+}
+
+func validateCapabilities(caps []string) error {
+	for _, capability := range caps {
+		if !capabilities[capability] {
+			return garden.InvalidRequestError{
+				Reason: fmt.Sprintf("unknown capability %q", capability),
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *GardenServer) handleStreamIn(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	user := r.URL.Query().Get("user")
+	owner := r.URL.Query().Get("owner")
+	dstPath := r.URL.Query().Get("destination")
+
+	hLog := s.logger.Session("stream-in", lager.Data{
+		"handle":      handle,
+		"user":        user,
+		"owner":       owner,
+		"destination": dstPath,
+	})
+
+	if err := validateUser(user); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if err := validateOwner(owner); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("streaming-in")
+
+	body := &abortableBody{Reader: r.Body}
+
+	err = container.StreamIn(garden.StreamInSpec{
+		User:      user,
+		Owner:     owner,
+		Path:      dstPath,
+		TarStream: tarStreamOrEmpty(body),
+	})
+	if aborted := body.abortedBy(); err != nil && aborted != nil {
+		// anything already extracted is left in place; the client has most
+		// likely gone away, but is told in case it has not
+		hLog.Info("aborted-by-client", lager.Data{"cause": aborted.Error()})
+	}
+
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("streamed-in")
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) writeSuccess(w http.ResponseWriter) {
+	s.writeResponse(w, &struct{}{})
+}
+
+// abortableBody records whether reading a request body failed other than by
+// reaching its end, which is how a client aborting an upload shows up. The
+// backend may read it from a goroutine of its own.
+type abortableBody struct {
+	io.Reader
+
+	mu      sync.Mutex
+	aborted error
+}
+
+func (b *abortableBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		b.mu.Lock()
+		b.aborted = err
+		b.mu.Unlock()
+	}
+
+	return n, err
+}
+
+// abortedBy returns the error reading the body failed with, if it did.
+func (b *abortableBody) abortedBy() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.aborted
+}
+
+// An empty tar archive is just its end-of-archive marker: two zeroed blocks.
+var emptyTarArchive = make([]byte, 2*512)
+
+// tarStreamOrEmpty returns a stream of the body, or of an empty tar archive
+// if the body has no bytes at all, so that backends never have to make
+// sense of a zero-length tar stream.
+func tarStreamOrEmpty(body io.Reader) io.Reader {
+	buffered := bufio.NewReader(body)
+	if _, err := buffered.Peek(1); err == io.EOF {
+		return bytes.NewReader(emptyTarArchive)
+	}
+
+	return buffered
+}
+
+func (s *GardenServer) handleStreamOut(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	user := r.URL.Query().Get("user")
+	srcPath := r.URL.Query().Get("source")
+	include := r.URL.Query()["include"]
+
+	hLog := s.logger.Session("stream-out", lager.Data{
+		"handle":  handle,
+		"user":    user,
+		"source":  srcPath,
+		"include": include,
+	})
+
+	if err := validateUser(user); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if err := validateIncludePatterns(include); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("streaming-out")
+
+	reader, err := container.StreamOut(garden.StreamOutSpec{
+		User: user,
+		Path: srcPath,
+	})
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	var n int64
+	if len(include) == 0 {
+		n, err = io.Copy(w, reader)
+	} else {
+		n, err = filterTar(w, reader, include)
+	}
+
+	if err != nil {
+		if err := reader.Close(); err != nil {
+			hLog.Error("failed-to-close", err)
+		}
+
+		if n == 0 {
+			s.writeError(w, err, hLog)
+		}
+
+		return
+	}
+
+	hLog.Info("streamed-out")
+}
+
+func (s *GardenServer) handleCurrentBandwidthLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("current-bandwidth-limits", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentBandwidthLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("current-memory-limits", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentMemoryLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleLimitAll(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("limit-all", lager.Data{
+		"handle": handle,
+	})
+
+	var request garden.LimitsUpdate
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	if request.CPU != nil {
+		if err := validateCPUSet(request.CPU.CPUSet); err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("limiting", lager.Data{
+		"update": request,
+	})
+
+	limits, err := container.LimitAll(request)
+
+	// even a failed update may have changed some of the limits
+	s.infoVersions.changed(container.Handle())
+	s.limitBoosts.overridden(container.Handle(), request)
+
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("limited", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleCurrentDiskLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("current-disk-limits", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentDiskLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("current-cpu-limits", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentCPULimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleCurrentIOLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("current-io-limits", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentIOLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("net-in", lager.Data{
+		"handle": handle,
+	})
+
+	var request transport.NetInRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	hostPort := request.HostPort
+	containerPort := request.ContainerPort
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("port-mapping", lager.Data{
+		"host-port":      hostPort,
+		"container-port": containerPort,
+	})
+
+	hostPort, containerPort, err = container.NetIn(hostPort, containerPort)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("port-mapped", lager.Data{
+		"host-port":      hostPort,
+		"container-port": containerPort,
+	})
+
+	s.writeResponse(w, &transport.NetInResponse{
+		HostPort:      hostPort,
+		ContainerPort: containerPort,
+	})
+}
+
+func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("net-out", lager.Data{
+		"handle": handle,
+	})
+
+	var rule garden.NetOutRule
+	if !s.readRequest(&rule, w, r) {
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	// the rule must not be undone by a concurrent policy change rolling back
+	s.networkPolicyLocks.Lock(container.Handle())
+	defer s.networkPolicyLocks.Unlock(container.Handle())
+
+	hLog.Debug("allowing-out", lager.Data{
+		"rule": rule,
+	})
+
+	err = container.NetOut(rule)
 
-	limits, err := container.CurrentBandwidthLimits()
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
+	hLog.Debug("allowed", lager.Data{
+		"rule": rule,
 	})
 
-	s.writeResponse(w, limits)
+	s.writeSuccess(w)
 }
 
-func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleBulkNetOut(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-memory-limits", lager.Data{
+	hLog := s.logger.Session("bulk-net-out", lager.Data{
 		"handle": handle,
 	})
 
+	var rules []garden.NetOutRule
+	if !s.readRequest(&rules, w, r) {
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -341,28 +1931,270 @@ func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	// the rules must not be undone by a concurrent policy change rolling back
+	s.networkPolicyLocks.Lock(container.Handle())
+	defer s.networkPolicyLocks.Unlock(container.Handle())
+
+	hLog.Debug("allowing-bulk-out", lager.Data{
+		"rules": rules,
+	})
+
+	err = container.BulkNetOut(rules)
 
-	limits, err := container.CurrentMemoryLimits()
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
+	hLog.Debug("allowed", lager.Data{
+		"rules": rules,
 	})
 
-	s.writeResponse(w, limits)
+	s.writeSuccess(w)
 }
 
-func (s *GardenServer) handleCurrentDiskLimits(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-disk-limits", lager.Data{
+	hLog := s.logger.Session("get-metrics", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	metrics, err := container.Metrics()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, metrics)
+}
+
+// handleResourceUsage responds with the container's metrics alongside its
+// current limits. A limit the backend does not support is reported as zero,
+// rather than failing the whole response.
+func (s *GardenServer) handleResourceUsage(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("get-resource-usage", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	metrics, err := container.Metrics()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	usage := garden.ResourceUsage{
+		CPU:       garden.CPUUsage{Usage: metrics.CPUStat},
+		Memory:    garden.MemoryUsage{Usage: metrics.MemoryStat},
+		Disk:      garden.DiskUsage{Usage: metrics.DiskStat},
+		Bandwidth: garden.BandwidthUsage{Usage: metrics.NetworkStat},
+	}
+
+	usage.CPU.Limits, err = container.CurrentCPULimits()
+	if err := ignoreUnsupported(err); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	usage.Memory.Limits, err = container.CurrentMemoryLimits()
+	if err := ignoreUnsupported(err); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	usage.Disk.Limits, err = container.CurrentDiskLimits()
+	if err := ignoreUnsupported(err); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	usage.Bandwidth.Limits, err = container.CurrentBandwidthLimits()
+	if err := ignoreUnsupported(err); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, usage)
+}
+
+// ignoreUnsupported returns err unless it is an UnsupportedOperationError.
+func ignoreUnsupported(err error) error {
+	if _, ok := err.(garden.UnsupportedOperationError); ok {
+		return nil
+	}
+
+	return err
+}
+
+func (s *GardenServer) handleProcessStats(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("get-process-stats", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	stats, err := container.ProcessStats()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, stats)
+}
+
+func (s *GardenServer) handleProcessStatus(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	processID := r.FormValue(":pid")
+
+	hLog := s.logger.Session("get-process-status", lager.Data{
+		"handle": handle,
+		"id":     processID,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	status, found := s.processTracker.status(container.Handle(), processID)
+	if !found {
+		s.writeError(w, garden.ProcessNotFoundError{ProcessID: processID}, hLog)
+		return
+	}
+
+	s.writeResponse(w, status)
+}
+
+func (s *GardenServer) handleProperties(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("get-properties", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	properties, err := container.Properties()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got-properties")
+
+	s.writeResponse(w, properties)
+}
+
+func (s *GardenServer) handleProperty(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	key := r.FormValue(":key")
+
+	hLog := s.logger.Session("get-property", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
+
+	hLog.Debug("get-property", lager.Data{})
+
+	value, err := container.Property(key)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Debug("got-property", lager.Data{})
+
+	s.writeResponse(w, map[string]string{
+		"value": value,
+	})
+}
+
+func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	key := r.FormValue(":key")
+
+	hLog := s.logger.Session("set-property", lager.Data{
 		"handle": handle,
 	})
 
+	var request struct {
+		Value string `json:"value"`
+	}
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	value := request.Value
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -372,28 +2204,82 @@ func (s *GardenServer) handleCurrentDiskLimits(w http.ResponseWriter, r *http.Re
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	hLog.Debug("set-property", lager.Data{})
 
-	limits, err := container.CurrentDiskLimits()
+	err = container.SetProperty(key, value)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
+	hLog.Debug("set-property-complete", lager.Data{})
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) handleSetPropertyForAll(w http.ResponseWriter, r *http.Request) {
+	key := r.FormValue(":key")
+
+	var request struct {
+		Value   string   `json:"value"`
+		Handles []string `json:"handles"`
+	}
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	hLog := s.logger.Session("set-property-for-all", lager.Data{
+		"handles": request.Handles,
+		"key":     key,
 	})
 
-	s.writeResponse(w, limits)
+	hLog.Debug("set-property")
+
+	errs := map[string]*garden.Error{}
+	for _, handle := range request.Handles {
+		err := s.setProperty(handle, key, request.Value)
+		if err != nil {
+			hLog.Error("set-property-failed", err, lager.Data{
+				"handle": handle,
+			})
+
+			errs[handle] = &garden.Error{Err: err}
+		}
+	}
+
+	hLog.Debug("set-property-complete")
+
+	s.writeResponse(w, errs)
 }
 
-func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Request) {
+// setProperty sets a property on a single container, keeping it from being
+// reaped while it does so.
+func (s *GardenServer) setProperty(handle, key, value string) error {
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		return err
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	return container.SetProperty(key, value)
+}
+
+func (s *GardenServer) handleRemoveProperty(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
+	key := r.FormValue(":key")
 
-	hLog := s.logger.Session("current-cpu-limits", lager.Data{
+	hLog := s.logger.Session("remove-property", lager.Data{
 		"handle": handle,
 	})
 
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -403,35 +2289,44 @@ func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Req
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	hLog.Debug("remove-property", lager.Data{})
 
-	limits, err := container.CurrentCPULimits()
+	err = container.RemoveProperty(key)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		// backends differ in whether removing an unset property is an error;
+		// it never is to clients
+		if properties, propErr := container.Properties(); propErr != nil || hasProperty(properties, key) {
+			s.writeError(w, err, hLog)
+			return
+		}
+
+		hLog.Debug("property-not-set")
 	}
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
-	})
+	hLog.Info("removed-property", lager.Data{})
 
-	s.writeResponse(w, limits)
+	s.writeSuccess(w)
 }
 
-func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+func hasProperty(properties garden.Properties, key string) bool {
+	_, found := properties[key]
+	return found
+}
 
-	hLog := s.logger.Session("net-in", lager.Data{
-		"handle": handle,
-	})
+func (s *GardenServer) handleSetGraceTime(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
 
-	var request transport.NetInRequest
-	if !s.readRequest(&request, w, r) {
+	var graceTime time.Duration
+	if !s.readRequest(&graceTime, w, r) {
 		return
 	}
 
-	hostPort := request.HostPort
-	containerPort := request.ContainerPort
+	hLog := s.logger.Session("set-grace-time", lager.Data{
+		"handle": handle,
+	})
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
 
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
@@ -439,82 +2334,110 @@ func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
-
-	hLog.Debug("port-mapping", lager.Data{
-		"host-port":      hostPort,
-		"container-port": containerPort,
-	})
+	container.SetGraceTime(graceTime)
 
-	hostPort, containerPort, err = container.NetIn(hostPort, containerPort)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
-	}
+	s.bomberman.Defuse(container.Handle())
+	s.bomberman.Strap(container)
 
-	hLog.Info("port-mapped", lager.Data{
-		"host-port":      hostPort,
-		"container-port": containerPort,
-	})
+	s.writeSuccess(w)
+}
 
-	s.writeResponse(w, &transport.NetInResponse{
-		HostPort:      hostPort,
-		ContainerPort: containerPort,
-	})
+// unlockOnce returns a func releasing a shared lock on the handle the first
+// time it is called, so that a handler can release the lock part way through
+// and still defer releasing it on its early returns.
+func (s *GardenServer) unlockOnce(handle string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.handleLocks.RUnlock(handle)
+		})
+	}
 }
 
-func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("net-out", lager.Data{
+	hLog := s.logger.Session("run", lager.Data{
 		"handle": handle,
 	})
 
-	var rule garden.NetOutRule
-	if !s.readRequest(&rule, w, r) {
+	var request garden.ProcessSpec
+	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
+	if err := validateResourceLimits(request.Limits); err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
-
-	hLog.Debug("allowing-out", lager.Data{
-		"rule": rule,
-	})
+	if err := validateCapabilities(request.Capabilities); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	err = container.NetOut(rule)
+	if request.OverrideContainerLimits != nil {
+		if err := validateCPUSet(request.OverrideContainerLimits.CPU.CPUSet); err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+	}
 
-	if err != nil {
+	if err := validateSyslog(request.Syslog); err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Debug("allowed", lager.Data{
-		"rule": rule,
-	})
+	if err := s.syslogs.check(request.Syslog); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	s.writeSuccess(w)
-}
+	if request.Nice < minNice || request.Nice > maxNice {
+		s.writeError(w, ErrInvalidNice, hLog)
+		return
+	}
 
-func (s *GardenServer) handleBulkNetOut(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+	runnerBackpressure := garden.Backpressure(r.URL.Query().Get("backpressure"))
+	if err := validateBackpressure(runnerBackpressure); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
 
-	hLog := s.logger.Session("bulk-net-out", lager.Data{
-		"handle": handle,
-	})
+	// a process whose output is paced is held up by it, rather than having
+	// what its client cannot keep up with dropped
+	if runnerBackpressure == "" && s.outputRateLimited() {
+		runnerBackpressure = garden.BackpressureBlock
+	}
 
-	var rules []garden.NetOutRule
-	if !s.readRequest(&rules, w, r) {
+	logSize, err := processLogSize(request)
+	if err != nil {
+		s.writeError(w, err, hLog)
 		return
 	}
 
+	if request.TTY != nil {
+		setTerm(&request)
+	}
+
+	info := processDebugInfo{
+		Path:                request.Path,
+		Dir:                 request.Dir,
+		User:                request.User,
+		SupplementaryGroups: request.SupplementaryGroups,
+		Limits:              request.Limits,
+		Capabilities:        request.Capabilities,
+		Nice:                request.Nice,
+		TTY:                 request.TTY,
+		StdinFile:           request.StdinFile,
+	}
+
+	// the container must not be destroyed while the process is being set up,
+	// but streaming its output must not keep it from being destroyed
+	s.handleLocks.RLock(handle)
+	unlock := s.unlockOnce(handle)
+	defer unlock()
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -524,223 +2447,320 @@ func (s *GardenServer) handleBulkNetOut(w http.ResponseWriter, r *http.Request)
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("allowing-bulk-out", lager.Data{
-		"rules": rules,
+	hLog.Debug("running", lager.Data{
+		"spec": info,
 	})
 
-	err = container.BulkNetOut(rules)
+	// the output is broadcast, so that clients attaching later see it too
+	blockTimeout := time.Duration(atomic.LoadInt64(&s.outputBlockTimeout))
+	broadcast := newOutputBroadcast(hLog, s.outputStats, request.TTY != nil, blockTimeout)
+	runner := broadcast.subscribe(r.RemoteAddr, runnerBackpressure)
+	defer broadcast.unsubscribe(runner)
 
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+	stdout := runner.stdout
+	stderr := runner.stderr
+
+	stdinR, stdinW := io.Pipe()
+
+	processIO := garden.ProcessIO{
+		Stdin:  stdinR,
+		Stdout: broadcast.stdout(),
+		Stderr: broadcast.stderr(),
 	}
 
-	hLog.Debug("allowed", lager.Data{
-		"rules": rules,
-	})
+	var outputLog *sharedOutputLog
+	if request.OutputLog != nil {
+		outputLog, err = s.openOutputLog(container.Handle(), *request.OutputLog)
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
 
-	s.writeSuccess(w)
-}
+		processIO.Stdout = outputLog
+		processIO.Stderr = outputLog
+	}
 
-func (s *GardenServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+	var stdinFile *stdinFile
+	if request.StdinFile != "" {
+		stdinFile, err = s.openStdinFile(hLog, request.StdinFile)
+		if err != nil {
+			if outputLog != nil {
+				outputLog.Close()
+			}
 
-	hLog := s.logger.Session("get-metrics", lager.Data{
-		"handle": handle,
-	})
+			s.writeError(w, err, hLog)
+			return
+		}
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		processIO.Stdin = stdinFile
+
+		// the client's stdin goes nowhere, but must still be read so that
+		// its signals and TTY resizes get through
+		go io.Copy(ioutil.Discard, stdinR)
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	var syslogStdout, syslogStderr *syslogWriter
+	syslogSpec := s.syslogs.spec(container.Handle(), request.Syslog)
+	if syslogSpec != nil {
+		syslogStdout, syslogStderr = s.syslogs.writers(s.logger, container.Handle(), *syslogSpec)
 
-	metrics, err := container.Metrics()
+		if syslogSpec.NoStream && outputLog == nil {
+			processIO.Stdout = syslogStdout
+			processIO.Stderr = syslogStderr
+		} else {
+			processIO.Stdout = io.MultiWriter(processIO.Stdout, syslogStdout)
+			processIO.Stderr = io.MultiWriter(processIO.Stderr, syslogStderr)
+		}
+	}
+
+	log := newProcessLog(logSize)
+	processIO.Stdout = io.MultiWriter(log, processIO.Stdout)
+	processIO.Stderr = io.MultiWriter(log, processIO.Stderr)
+
+	var maxOutput *outputLimit
+	if request.MaxOutputBytes > 0 {
+		maxOutput = newOutputLimit(request.MaxOutputBytes)
+		processIO.Stdout = maxOutput.writer(processIO.Stdout)
+		processIO.Stderr = maxOutput.writer(processIO.Stderr)
+	}
+
+	process, err := container.Run(request, processIO)
 	if err != nil {
+		if outputLog != nil {
+			outputLog.Close()
+		}
+
+		if stdinFile != nil {
+			stdinFile.Close()
+		}
+
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.writeResponse(w, metrics)
-}
+	if outputLog != nil {
+		go func() {
+			process.Wait()
+			outputLog.Close()
+		}()
+	}
 
-func (s *GardenServer) handleProperties(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+	if syslogSpec != nil {
+		syslogStdout.setProcessID(process.ID())
+		syslogStderr.setProcessID(process.ID())
 
-	hLog := s.logger.Session("get-properties", lager.Data{
-		"handle": handle,
+		go func() {
+			process.Wait()
+			syslogStdout.flush()
+			syslogStderr.flush()
+		}()
+	}
+
+	if stdinFile != nil {
+		go func() {
+			process.Wait()
+			stdinFile.Close()
+		}()
+	}
+	hLog.Info("spawned", lager.Data{
+		"spec": info,
+		"id":   process.ID(),
 	})
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+	s.processTracker.track(container.Handle(), process)
+	s.processLogs.track(container.Handle(), process, log)
+	containerSpec, _ := s.containerSpecs.spec(s.containerSpecRoot(), container.Handle())
+	s.processEnvs.track(container.Handle(), process, containerSpec.Env, request.Env)
+
+	if outputLog == nil && (syslogSpec == nil || !syslogSpec.NoStream) {
+		s.outputs.track(container.Handle(), process, broadcast)
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	if request.AutoDestroyOnExit || request.KeepOnFailure {
+		go s.handleExit(hLog, container, process, request)
+	}
 
-	properties, err := container.Properties()
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+	var limit *runtimeLimit
+	if request.MaxRuntime > 0 {
+		limit = limitRuntime(hLog, process, request.MaxRuntime)
 	}
 
-	hLog.Info("got-properties")
+	if maxOutput != nil {
+		maxOutput.enforce(hLog, process, request.KillOnMaxOutput)
+	}
 
-	s.writeResponse(w, properties)
-}
+	if limit != nil || maxOutput != nil {
+		s.processLimits.track(container.Handle(), process, &processLimit{runtime: limit, output: maxOutput})
+	}
 
-func (s *GardenServer) handleProperty(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
-	key := r.FormValue(":key")
+	unlock()
 
-	hLog := s.logger.Session("get-property", lager.Data{
-		"handle": handle,
-	})
+	streamID := s.streamer.Stream(stdout, stderr)
+	defer s.streamer.Stop(streamID)
 
-	container, err := s.backend.Lookup(handle)
+	defer s.interactiveStreams.add(streamID, request.TTY != nil)()
+	defer s.outputStreams.add(streamID, runner)()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
 	if err != nil {
 		s.writeError(w, err, hLog)
+		stdinW.Close()
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	defer conn.Close()
 
-	hLog.Debug("get-property", lager.Data{})
+	setNoDelay(conn, request.TTY != nil)
 
-	value, err := container.Property(key)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+	defer s.trackAttachment(container.Handle(), process.ID(), string(streamID), r.RemoteAddr)()
+
+	codec := transport.NewProcessStreamCodec(br, conn)
+	codec.EncodeStreamInfo(process.ID(), string(streamID))
+
+	control := r.URL.Query().Get("control") == "true"
+	if control {
+		codec.EncodeState(process.ID(), garden.ProcessStateRunning)
 	}
 
-	hLog.Debug("got-property", lager.Data{})
+	connCloseCh := make(chan struct{}, 1)
 
-	s.writeResponse(w, map[string]string{
-		"value": value,
-	})
+	go s.streamInput(codec, stdinW, process, connCloseCh, control)
+
+	s.streamProcess(hLog, codec, process, streamID, stdinW, connCloseCh, limit, maxOutput)
 }
 
-func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
-	key := r.FormValue(":key")
+// runtimeLimit kills a process once its MaxRuntime has elapsed, and records
+// whether it did so, so that the exit can be reported as such.
+type runtimeLimit struct {
+	exceeded int32
+}
 
-	hLog := s.logger.Session("set-property", lager.Data{
-		"handle": handle,
-	})
+// limitRuntime arms a runtimeLimit for the process. The timer is independent
+// of the client's connection, so a detached process is still killed on time.
+func limitRuntime(logger lager.Logger, process garden.Process, max time.Duration) *runtimeLimit {
+	limit := &runtimeLimit{}
 
-	var request struct {
-		Value string `json:"value"`
-	}
-	if !s.readRequest(&request, w, r) {
-		return
-	}
+	timer := time.AfterFunc(max, func() {
+		atomic.StoreInt32(&limit.exceeded, 1)
 
-	value := request.Value
+		logger.Info("max-runtime-exceeded", lager.Data{
+			"id":          process.ID(),
+			"max-runtime": max.String(),
+		})
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
-	}
+		if err := process.Signal(garden.SignalKill); err != nil {
+			logger.Error("max-runtime-kill-failed", err, lager.Data{
+				"id": process.ID(),
+			})
+		}
+	})
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	go func() {
+		process.Wait()
+		timer.Stop()
+	}()
 
-	hLog.Debug("set-property", lager.Data{})
+	return limit
+}
 
-	err = container.SetProperty(key, value)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+func (l *runtimeLimit) wasExceeded() bool {
+	return l != nil && atomic.LoadInt32(&l.exceeded) == 1
+}
+
+// setTerm defaults the terminal type of a process with a TTY and exports it
+// as $TERM, unless the process's environment already sets it.
+func setTerm(spec *garden.ProcessSpec) {
+	if spec.TTY.Term == "" {
+		spec.TTY.Term = defaultTerm
 	}
 
-	hLog.Debug("set-property-complete", lager.Data{})
+	for _, env := range spec.Env {
+		if strings.HasPrefix(env, "TERM=") {
+			return
+		}
+	}
 
-	s.writeSuccess(w)
+	spec.Env = append(spec.Env, "TERM="+spec.TTY.Term)
 }
 
-func (s *GardenServer) handleRemoveProperty(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
-	key := r.FormValue(":key")
-
-	hLog := s.logger.Session("remove-property", lager.Data{
-		"handle": handle,
-	})
+// handleExit keeps the container for inspection if the process failed and
+// KeepOnFailure was asked for, and otherwise destroys it if AutoDestroyOnExit
+// was.
+func (s *GardenServer) handleExit(logger lager.Logger, container garden.Container, process garden.Process, spec garden.ProcessSpec) {
+	status, err := process.Wait()
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
+	if spec.KeepOnFailure && (err != nil || status != 0) {
+		s.keepFailedContainer(logger, container, process, status, err)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
-
-	hLog.Debug("remove-property", lager.Data{})
-
-	err = container.RemoveProperty(key)
-	if err != nil {
-		s.writeError(w, err, hLog)
+	if !spec.AutoDestroyOnExit {
 		return
 	}
 
-	hLog.Info("removed-property", lager.Data{})
+	logger.Info("auto-destroying", lager.Data{
+		"id": process.ID(),
+	})
 
-	s.writeSuccess(w)
+	s.bomberman.Defuse(container.Handle())
+	s.reapContainer(container, ReapReasonProcessExited)
 }
 
-func (s *GardenServer) handleSetGraceTime(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+func (s *GardenServer) keepFailedContainer(logger lager.Logger, container garden.Container, process garden.Process, status int, waitErr error) {
+	graceTime := time.Duration(atomic.LoadInt64(&s.failedContainerGraceTime))
 
-	var graceTime time.Duration
-	if !s.readRequest(&graceTime, w, r) {
-		return
+	data := lager.Data{
+		"id":          process.ID(),
+		"exit-status": status,
+		"grace-time":  graceTime.String(),
+	}
+	if waitErr != nil {
+		data["wait-error"] = waitErr.Error()
 	}
 
-	hLog := s.logger.Session("set-grace-time", lager.Data{
-		"handle": handle,
-	})
+	logger.Info("keeping-failed-container", data)
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+	s.handleLocks.RLock(container.Handle())
+	defer s.handleLocks.RUnlock(container.Handle())
+
+	if err := container.SetProperty(garden.FailedProcessProperty, process.ID()); err != nil {
+		logger.Error("failed-to-mark-failed-container", err)
 	}
 
 	container.SetGraceTime(graceTime)
 
 	s.bomberman.Defuse(container.Handle())
 	s.bomberman.Strap(container)
-
-	s.writeSuccess(w)
 }
 
-func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("run", lager.Data{
+	hLog := s.logger.Session("attach", lager.Data{
 		"handle": handle,
 	})
 
-	var request garden.ProcessSpec
-	if !s.readRequest(&request, w, r) {
+	processID := r.FormValue(":pid")
+
+	attacherBackpressure := garden.Backpressure(r.URL.Query().Get("backpressure"))
+	if err := validateBackpressure(attacherBackpressure); err != nil {
+		s.writeError(w, err, hLog)
 		return
 	}
 
-	info := processDebugInfo{
-		Path:   request.Path,
-		Dir:    request.Dir,
-		User:   request.User,
-		Limits: request.Limits,
-		TTY:    request.TTY,
+	// the output is sent to each client in turn, so one which blocks holds up
+	// every other client streaming it too; an attacher only blocks if it asks
+	if attacherBackpressure == "" {
+		attacherBackpressure = garden.BackpressureDrop
 	}
 
+	s.handleLocks.RLock(handle)
+	unlock := s.unlockOnce(handle)
+	defer unlock()
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -750,36 +2770,70 @@ func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("running", lager.Data{
-		"spec": info,
-	})
-
-	stdout := make(chan []byte, 1000)
-	stderr := make(chan []byte, 1000)
-
 	stdinR, stdinW := io.Pipe()
 
 	processIO := garden.ProcessIO{
-		Stdin:  stdinR,
-		Stdout: &chanWriter{stdout},
-		Stderr: &chanWriter{stderr},
+		Stdin: stdinR,
+	}
+
+	// an attacher is told of the limits the process was run with being
+	// enforced, just as the client which ran it is
+	limits := s.processLimits.get(container.Handle(), processID)
+
+	// a process run through the server has its output shared by every client
+	// streaming it; that of any other is left to the backend to hand out
+	var stdout, stderr chan []byte
+	var subscriber *outputSubscriber
+	broadcast, broadcasted := s.outputs.get(container.Handle(), processID)
+	if broadcasted {
+		subscriber = broadcast.subscribe(r.RemoteAddr, attacherBackpressure)
+		defer broadcast.unsubscribe(subscriber)
+
+		stdout = subscriber.stdout
+		stderr = subscriber.stderr
+	} else {
+		stdout = make(chan []byte, outputQueueSize)
+		stderr = make(chan []byte, outputQueueSize)
+
+		processIO.Stdout = &chanWriter{ch: stdout, stats: s.outputStats}
+		processIO.Stderr = &chanWriter{ch: stderr, stats: s.outputStats}
+
+		// output shared by the server is limited before it is shared, but
+		// the backend hands each attacher its own, so it is limited here
+		if limits.output != nil {
+			attached := limits.output.attachment()
+			processIO.Stdout = attached.writer(processIO.Stdout)
+			processIO.Stderr = attached.writer(processIO.Stderr)
+		}
 	}
 
-	process, err := container.Run(request, processIO)
+	hLog.Debug("attaching", lager.Data{
+		"id": processID,
+	})
+
+	process, err := container.Attach(processID, processIO)
 	if err != nil {
 		s.writeError(w, err, hLog)
+		stdinW.Close()
 		return
 	}
-	hLog.Info("spawned", lager.Data{
-		"spec": info,
-		"id":   process.ID(),
+
+	unlock()
+
+	hLog.Info("attached", lager.Data{
+		"id": process.ID(),
 	})
 
 	streamID := s.streamer.Stream(stdout, stderr)
 	defer s.streamer.Stop(streamID)
 
+	if broadcasted {
+		defer s.interactiveStreams.add(streamID, broadcast.interactive)()
+		defer s.outputStreams.add(streamID, subscriber)()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
 
 	conn, br, err := w.(http.Hijacker).Hijack()
 	if err != nil {
@@ -790,26 +2844,36 @@ func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
 
 	defer conn.Close()
 
-	transport.WriteMessage(conn, &transport.ProcessPayload{
-		ProcessID: process.ID(),
-		StreamID:  string(streamID),
-	})
+	if broadcasted {
+		setNoDelay(conn, broadcast.interactive)
+	}
+
+	defer s.trackAttachment(container.Handle(), process.ID(), string(streamID), r.RemoteAddr)()
+
+	codec := transport.NewProcessStreamCodec(br, conn)
+	codec.EncodeStreamInfo(process.ID(), string(streamID))
+
+	control := r.URL.Query().Get("control") == "true"
+	if control {
+		codec.EncodeState(process.ID(), garden.ProcessStateRunning)
+	}
 
 	connCloseCh := make(chan struct{}, 1)
 
-	go s.streamInput(json.NewDecoder(br), stdinW, process, connCloseCh)
+	go s.streamInput(codec, stdinW, process, connCloseCh, control)
 
-	s.streamProcess(hLog, conn, process, stdinW, connCloseCh)
+	s.streamProcess(hLog, codec, process, streamID, stdinW, connCloseCh, limits.runtime, limits.output)
 }
 
-func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("attach", lager.Data{
+	hLog := s.logger.Session("info", lager.Data{
 		"handle": handle,
 	})
 
-	processID := r.FormValue(":pid")
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
 
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
@@ -817,69 +2881,123 @@ func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
-
-	stdout := make(chan []byte, 1000)
-	stderr := make(chan []byte, 1000)
-
-	stdinR, stdinW := io.Pipe()
+	lastActivity := s.bomberman.LastActivity(container.Handle())
 
-	processIO := garden.ProcessIO{
-		Stdin:  stdinR,
-		Stdout: &chanWriter{stdout},
-		Stderr: &chanWriter{stderr},
-	}
+	s.bomberman.Observe(container.Handle())
+	defer s.bomberman.Unobserve(container.Handle())
 
-	hLog.Debug("attaching", lager.Data{
-		"id": processID,
-	})
+	hLog.Debug("getting-info")
 
-	process, err := container.Attach(processID, processIO)
+	info, err := container.Info()
 	if err != nil {
 		s.writeError(w, err, hLog)
-		stdinW.Close()
 		return
 	}
 
-	hLog.Info("attached", lager.Data{
-		"id": process.ID(),
-	})
+	info.LastActivity = lastActivity
 
-	streamID := s.streamer.Stream(stdout, stderr)
-	defer s.streamer.Stop(streamID)
+	info.Version = s.infoVersions.version(container.Handle(), info)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	hLog.Info("got-info")
 
-	conn, br, err := w.(http.Hijacker).Hijack()
+	w.Header().Set("ETag", strconv.Quote(info.Version))
+
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch == "" && r.URL.Query().Get("if_none_match") != "" {
+		ifNoneMatch = strconv.Quote(r.URL.Query().Get("if_none_match"))
+	}
+
+	if ifNoneMatch != "" && etagMatches(ifNoneMatch, info.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	fields := r.URL.Query().Get("fields")
+	if fields == "" {
+		s.writeResponse(w, info)
+		return
+	}
+
+	projected, err := projectInfo(info, strings.Split(fields, ","))
 	if err != nil {
 		s.writeError(w, err, hLog)
-		stdinW.Close()
 		return
 	}
 
-	defer conn.Close()
+	s.writeResponse(w, projected)
+}
+
+// projectInfo returns the JSON representation of info restricted to the
+// given field names.
+func projectInfo(info garden.ContainerInfo, fields []string) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[string]json.RawMessage{}
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+
+	projected := map[string]json.RawMessage{}
+	for _, field := range fields {
+		value, ok := all[field]
+		if !ok {
+			return nil, garden.InvalidRequestError{Reason: fmt.Sprintf("unknown info field: %s", field)}
+		}
+
+		projected[field] = value
+	}
 
-	transport.WriteMessage(conn, &transport.ProcessPayload{
-		ProcessID: process.ID(),
-		StreamID:  string(streamID),
+	return projected, nil
+}
+
+func (s *GardenServer) handleBulkInfo(w http.ResponseWriter, r *http.Request) {
+	handles := splitHandles(r.URL.Query()["handles"][0])
+
+	hLog := s.logger.Session("bulk_info", lager.Data{
+		"handles": handles,
 	})
+	hLog.Debug("getting-bulkinfo")
 
-	connCloseCh := make(chan struct{}, 1)
+	bulkInfo, err := s.backend.BulkInfo(handles)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got-bulkinfo")
 
-	go s.streamInput(json.NewDecoder(br), stdinW, process, connCloseCh)
+	for handle, entry := range bulkInfo {
+		if entry.Err == nil {
+			entry.Info.LastActivity = s.bomberman.LastActivity(handle)
+			entry.Info.Version = s.infoVersions.version(handle, entry.Info)
+			bulkInfo[handle] = entry
+		}
+	}
 
-	s.streamProcess(hLog, conn, process, stdinW, connCloseCh)
+	s.writeResponse(w, bulkInfo)
 }
 
-func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleStreamOutputLog(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
+	name := r.FormValue(":name")
 
-	hLog := s.logger.Session("info", lager.Data{
+	hLog := s.logger.Session("stream-output-log", lager.Data{
 		"handle": handle,
+		"name":   name,
 	})
 
+	path, err := s.outputLogPath(handle, name)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -889,26 +3007,38 @@ func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting-info")
-
-	info, err := container.Info()
+	file, err := os.Open(path)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("got-info")
+	defer file.Close()
+
+	hLog.Debug("streaming-out")
+
+	if _, err := io.Copy(w, file); err != nil {
+		hLog.Error("failed-to-stream", err)
+		return
+	}
 
-	s.writeResponse(w, info)
+	hLog.Info("streamed-out")
 }
 
-func (s *GardenServer) handleBulkInfo(w http.ResponseWriter, r *http.Request) {
-	handles := splitHandles(r.URL.Query()["handles"][0])
+func (s *GardenServer) handleListPortMappings(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("list-port-mappings")
+	hLog.Debug("started")
 
-	hLog := s.logger.Session("bulk_info", lager.Data{
-		"handles": handles,
-	})
-	hLog.Debug("getting-bulkinfo")
+	containers, err := s.backend.Containers(nil)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	handles := make([]string, 0, len(containers))
+	for _, container := range containers {
+		handles = append(handles, container.Handle())
+	}
 
 	bulkInfo, err := s.backend.BulkInfo(handles)
 	if err != nil {
@@ -916,9 +3046,19 @@ func (s *GardenServer) handleBulkInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hLog.Info("got-bulkinfo")
+	mappings := map[string][]garden.PortMapping{}
+	for handle, entry := range bulkInfo {
+		// the container may have been destroyed since it was listed
+		if entry.Err != nil {
+			continue
+		}
 
-	s.writeResponse(w, bulkInfo)
+		mappings[handle] = entry.Info.MappedPorts
+	}
+
+	hLog.Debug("ending")
+
+	s.writeResponse(w, mappings)
 }
 
 func (s *GardenServer) handleBulkMetrics(w http.ResponseWriter, r *http.Request) {
@@ -945,6 +3085,59 @@ func isClientError(err error) bool {
 		return true
 	}
 
+	if _, ok := err.(garden.BindMountError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.RateLimitedError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.FileNotFoundError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.IsADirectoryError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.UnsupportedOperationError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.HostPIDNotFoundError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.QuotaExceededError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.DrainingError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.ProcessesRunningError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.MaxContainersReachedError); ok {
+		return true
+	}
+
+	// the handler logs which checks failed
+	if _, ok := err.(garden.BackendDegradedError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.InvalidRootFSError); ok {
+		return true
+	}
+
+	if _, ok := err.(garden.InvalidRequestError); ok {
+		return true
+	}
+
 	return false
 }
 
@@ -975,10 +3168,18 @@ func (s *GardenServer) readRequest(msg interface{}, w http.ResponseWriter, r *ht
 	return true
 }
 
-func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, process garden.Process, connCloseCh chan struct{}) {
+// streamInput applies the stdin, signal and TTY payloads sent by the client.
+// If the client opened a control channel, failures to signal or resize the
+// process are reported back over it.
+func (s *GardenServer) streamInput(codec *transport.ProcessStreamCodec, in *io.PipeWriter, process garden.Process, connCloseCh chan struct{}, control bool) {
+	controlFailed := func(err error) {
+		if control {
+			codec.EncodeControlError(process.ID(), err)
+		}
+	}
+
 	for {
-		var payload transport.ProcessPayload
-		err := decoder.Decode(&payload)
+		payload, err := codec.Decode()
 		if err != nil {
 			close(connCloseCh)
 			in.CloseWithError(errors.New("Connection closed"))
@@ -987,7 +3188,10 @@ func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, pro
 
 		switch {
 		case payload.TTY != nil:
-			process.SetTTY(*payload.TTY)
+			if err := process.SetTTY(*payload.TTY); err != nil {
+				s.logger.Error("stream-input-process-set-tty-failed", err, lager.Data{"payload": payload})
+				controlFailed(err)
+			}
 
 		case payload.Source != nil:
 			if payload.Data == nil {
@@ -1008,11 +3212,13 @@ func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, pro
 				err = process.Signal(garden.SignalKill)
 				if err != nil {
 					s.logger.Error("stream-input-process-signal-kill-failed", err, lager.Data{"payload": payload})
+					controlFailed(err)
 				}
 			case garden.SignalTerminate:
 				err = process.Signal(garden.SignalTerminate)
 				if err != nil {
 					s.logger.Error("stream-input-process-signal-terminate-failed", err, lager.Data{"payload": payload})
+					controlFailed(err)
 				}
 			default:
 				s.logger.Error("stream-input-unknown-process-payload-signal", nil, lager.Data{"payload": payload})
@@ -1028,7 +3234,23 @@ func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, pro
 	}
 }
 
-func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process garden.Process, stdinPipe *io.PipeWriter, connCloseCh chan struct{}) {
+func (s *GardenServer) outputRateLimited() bool {
+	return atomic.LoadInt64(&s.outputRateLimit) > 0
+}
+
+// limitOutputRate paces the writes of output to a client's stream.
+func (s *GardenServer) limitOutputRate(w io.Writer) io.Writer {
+	limit := atomic.LoadInt64(&s.outputRateLimit)
+	if limit <= 0 {
+		return w
+	}
+
+	return ratelimit.NewWriter(w, int(limit), func(n int) {
+		atomic.AddUint64(&s.throttledOutputBytes, uint64(n))
+	})
+}
+
+func (s *GardenServer) streamProcess(logger lager.Logger, codec *transport.ProcessStreamCodec, process garden.Process, streamID streamer.StreamID, stdinPipe *io.PipeWriter, connCloseCh chan struct{}, limit *runtimeLimit, maxOutput *outputLimit) {
 	statusCh := make(chan int, 1)
 	errCh := make(chan error, 1)
 
@@ -1054,20 +3276,23 @@ func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process
 		select {
 
 		case status := <-statusCh:
-			transport.WriteMessage(conn, &transport.ProcessPayload{
-				ProcessID:  process.ID(),
-				ExitStatus: &status,
-			})
+			// clients treat the exit status as the end of the process, so any
+			// output still buffered must reach them first
+			s.streamer.Flush(streamID)
+			if limit.wasExceeded() {
+				codec.EncodeRuntimeExceeded(process.ID(), status)
+			} else if maxOutput.wasExceeded() {
+				codec.EncodeOutputLimitExceeded(process.ID(), status)
+			} else {
+				codec.EncodeExitStatus(process.ID(), status)
+			}
 
 			stdinPipe.Close()
 			return
 
 		case err := <-errCh:
-			e := err.Error()
-			transport.WriteMessage(conn, &transport.ProcessPayload{
-				ProcessID: process.ID(),
-				Error:     &e,
-			})
+			s.streamer.Flush(streamID)
+			codec.EncodeError(process.ID(), err)
 
 			stdinPipe.Close()
 			return
@@ -1,7 +1,11 @@
 package server_test
 
 import (
+	"archive/tar"
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -10,8 +14,12 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"code.cloudfoundry.org/lager/lagertest"
@@ -19,11 +27,13 @@ import (
 	"github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/http2"
 
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/garden/client"
 	"code.cloudfoundry.org/garden/client/connection"
 	fakes "code.cloudfoundry.org/garden/gardenfakes"
+	"code.cloudfoundry.org/garden/routes"
 	"code.cloudfoundry.org/garden/server"
 )
 
@@ -66,6 +76,355 @@ var _ = Describe("When connecting directly to the server", func() {
 		apiServer.Stop()
 	})
 
+	Context("when a route's rate limit is exceeded", func() {
+		BeforeEach(func() {
+			Expect(apiServer.SetRouteRateLimit(routes.List, server.RouteRateLimit{
+				RequestsPerSecond: 0.5,
+				Burst:             1,
+			})).To(Succeed())
+		})
+
+		It("responds with 429 and when to retry", func() {
+			response, err := client.Get(fmt.Sprintf("http://localhost:%d/containers", port))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+			response, err = client.Get(fmt.Sprintf("http://localhost:%d/containers", port))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(response.Header.Get("Retry-After")).To(Equal("2"))
+		})
+	})
+
+	Context("when a process's logs are fetched from a malformed cursor", func() {
+		It("responds with 400", func() {
+			response, err := client.Get(fmt.Sprintf("http://localhost:%d/containers/some-handle/processes/some-process/logs?cursor=-1", port))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("when a process's logs are fetched since a malformed time", func() {
+		It("responds with 400", func() {
+			response, err := client.Get(fmt.Sprintf("http://localhost:%d/containers/some-handle/processes/some-process/logs?since=yesterday", port))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("when processes are waited for with a negative timeout", func() {
+		It("responds with 400", func() {
+			response, err := client.Get(fmt.Sprintf("http://localhost:%d/containers/some-handle/wait?timeout=-1s", port))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("when a client speaks HTTP/2", func() {
+		var h2Client *http.Client
+
+		BeforeEach(func() {
+			h2Client = &http.Client{
+				Transport: &http2.Transport{
+					AllowHTTP: true,
+					DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+						return net.Dial(network, addr)
+					},
+				},
+			}
+		})
+
+		It("serves its requests over HTTP/2", func() {
+			pings := fakeBackend.PingCallCount()
+
+			for i := 0; i < 3; i++ {
+				response, err := h2Client.Get(fmt.Sprintf("http://localhost:%d/ping", port))
+				Expect(err).NotTo(HaveOccurred())
+				response.Body.Close()
+
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
+				Expect(response.ProtoMajor).To(Equal(2))
+			}
+
+			Expect(fakeBackend.PingCallCount()).To(Equal(pings + 3))
+		})
+
+		It("serves a connection's unary calls over HTTP/2", func() {
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			conn := connection.NewWithOptions("tcp", fmt.Sprintf("127.0.0.1:%d", port), logger, connection.WithHTTP2())
+			for i := 0; i < 3; i++ {
+				Expect(conn.SetProperty("some-handle", "some-property", "some-value")).To(Succeed())
+			}
+
+			Expect(fakeContainer.SetPropertyCallCount()).To(Equal(3))
+		})
+
+		It("closes the idle connection when stopped", func() {
+			response, err := h2Client.Get(fmt.Sprintf("http://localhost:%d/ping", port))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+
+			stopped := make(chan struct{})
+			go func() {
+				apiServer.Stop()
+				close(stopped)
+			}()
+
+			Eventually(stopped).Should(BeClosed())
+		})
+
+		It("closes a connection's idle HTTP/2 connection when stopped", func() {
+			conn := connection.NewWithOptions("tcp", fmt.Sprintf("127.0.0.1:%d", port), logger, connection.WithHTTP2())
+			Expect(conn.Ping()).To(Succeed())
+
+			stopped := make(chan struct{})
+			go func() {
+				apiServer.Stop()
+				close(stopped)
+			}()
+
+			Eventually(stopped).Should(BeClosed())
+		})
+
+		It("closes a connection taken over for HTTP/2 before its first request when stopped", func() {
+			conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			_, err = conn.Write([]byte(http2.ClientPreface))
+			Expect(err).NotTo(HaveOccurred())
+
+			// the server answers the preface with its settings once it has
+			// taken the connection over
+			framer := http2.NewFramer(nil, conn)
+			frame, err := framer.ReadFrame()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(frame).To(BeAssignableToTypeOf(&http2.SettingsFrame{}))
+
+			stopped := make(chan struct{})
+			go func() {
+				apiServer.Stop()
+				close(stopped)
+			}()
+
+			Eventually(stopped).Should(BeClosed())
+		})
+	})
+
+	Context("when a response compression threshold is set", func() {
+		get := func(path string) *http.Response {
+			request, err := http.NewRequest("GET", fmt.Sprintf("http://localhost:%d%s", port, path), nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Accept-Encoding", "gzip")
+
+			response, err := client.Do(request)
+			Expect(err).NotTo(HaveOccurred())
+			return response
+		}
+
+		BeforeEach(func() {
+			apiServer.SetResponseCompressionThreshold(1024)
+		})
+
+		It("gzips responses over the threshold", func() {
+			handles := []garden.Container{}
+			for i := 0; i < 100; i++ {
+				container := new(fakes.FakeContainer)
+				container.HandleReturns(fmt.Sprintf("container-with-a-long-handle-%d", i))
+				handles = append(handles, container)
+			}
+			fakeBackend.ContainersReturns(handles, nil)
+
+			response := get("/containers")
+			defer response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header.Get("Content-Encoding")).To(Equal("gzip"))
+
+			reader, err := gzip.NewReader(response.Body)
+			Expect(err).NotTo(HaveOccurred())
+			body, err := ioutil.ReadAll(reader)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring("container-with-a-long-handle-99"))
+		})
+
+		It("does not gzip responses under the threshold", func() {
+			response := get("/ping")
+			defer response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header.Get("Content-Encoding")).To(BeEmpty())
+
+			body, err := ioutil.ReadAll(response.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(Equal("{}\n"))
+		})
+
+		It("keeps the status of compressed error responses", func() {
+			fakeBackend.LookupReturns(nil, garden.ContainerNotFoundError{Handle: strings.Repeat("x", 2048)})
+
+			response := get("/containers/" + strings.Repeat("x", 2048) + "/info")
+			defer response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(response.Header.Get("Content-Encoding")).To(Equal("gzip"))
+		})
+
+		It("does not gzip responses for clients that do not accept it", func() {
+			response, err := http.Get(fmt.Sprintf("http://localhost:%d/containers", port))
+			Expect(err).NotTo(HaveOccurred())
+			defer response.Body.Close()
+			Expect(response.Header.Get("Content-Encoding")).To(BeEmpty())
+		})
+	})
+
+	Context("when getting info with an entity tag", func() {
+		BeforeEach(func() {
+			fakeBackend.LookupReturns(fakeContainer, nil)
+			fakeContainer.InfoReturns(garden.ContainerInfo{State: "active"}, nil)
+		})
+
+		getInfo := func(ifNoneMatch string) *http.Response {
+			request, err := http.NewRequest("GET", fmt.Sprintf("http://localhost:%d/containers/some-handle/info", port), nil)
+			Expect(err).NotTo(HaveOccurred())
+			if ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", ifNoneMatch)
+			}
+
+			response, err := client.Do(request)
+			Expect(err).NotTo(HaveOccurred())
+			return response
+		}
+
+		It("responds with 304 when the tag still matches", func() {
+			response := getInfo("")
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+			etag := response.Header.Get("ETag")
+			Expect(etag).ToNot(BeEmpty())
+
+			response = getInfo(`"some-other-tag", ` + etag)
+			defer response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusNotModified))
+
+			body, err := ioutil.ReadAll(response.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(BeEmpty())
+		})
+
+		It("responds with the info when the tag no longer matches", func() {
+			response := getInfo("")
+			response.Body.Close()
+			etag := response.Header.Get("ETag")
+
+			fakeContainer.InfoReturns(garden.ContainerInfo{State: "stopped"}, nil)
+
+			response = getInfo(etag)
+			defer response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header.Get("ETag")).ToNot(Equal(etag))
+		})
+	})
+
+	Context("when a client aborts a stream in", func() {
+		var streamedIn chan error
+
+		BeforeEach(func() {
+			streamedIn = make(chan error, 1)
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+			fakeContainer.StreamInStub = func(spec garden.StreamInSpec) error {
+				_, err := ioutil.ReadAll(spec.TarStream)
+				streamedIn <- err
+				return err
+			}
+		})
+
+		It("logs that the upload was aborted", func() {
+			conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			Expect(err).NotTo(HaveOccurred())
+
+			fmt.Fprintf(conn, "PUT /containers/some-handle/files?destination=%%2Fsome%%2Fpath HTTP/1.1\r\n")
+			fmt.Fprintf(conn, "Host: localhost\r\nContent-Length: 4096\r\n\r\n")
+			fmt.Fprintf(conn, "partial-tar-stream")
+			Expect(conn.Close()).To(Succeed())
+
+			Eventually(streamedIn).Should(Receive(HaveOccurred()))
+			Eventually(sink.Buffer).Should(gbytes.Say("stream-in.aborted-by-client"))
+		})
+
+		It("still responds with the error, in case the client is listening", func() {
+			conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			fmt.Fprintf(conn, "PUT /containers/some-handle/files?destination=%%2Fsome%%2Fpath HTTP/1.1\r\n")
+			fmt.Fprintf(conn, "Host: localhost\r\nContent-Length: 4096\r\n\r\n")
+			fmt.Fprintf(conn, "partial-tar-stream")
+			Expect(conn.(*net.TCPConn).CloseWrite()).To(Succeed())
+
+			response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	Context("when a process is run", func() {
+		var exited chan struct{}
+
+		BeforeEach(func() {
+			exited = make(chan struct{})
+
+			process := new(fakes.FakeProcess)
+			process.IDReturns("process-handle")
+			process.WaitStub = func() (int, error) {
+				<-exited
+				return 0, nil
+			}
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+			fakeContainer.RunReturns(process, nil)
+		})
+
+		AfterEach(func() {
+			close(exited)
+		})
+
+		run := func(spec garden.ProcessSpec) {
+			conn := connection.New("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			_, err := conn.Run("some-handle", spec, garden.ProcessIO{
+				Stdout: gbytes.NewBuffer(),
+				Stderr: gbytes.NewBuffer(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Context("with a TTY", func() {
+			It("disables Nagle's algorithm on its process and output connections", func() {
+				run(garden.ProcessSpec{Path: "/bin/sh", TTY: &garden.TTYSpec{}})
+
+				Eventually(func() []bool { return serverNoDelay(port) }).Should(ConsistOf(true, true, true))
+			})
+		})
+
+		Context("without a TTY", func() {
+			It("leaves Nagle's algorithm enabled on its process and output connections", func() {
+				run(garden.ProcessSpec{Path: "/some/script"})
+
+				Eventually(func() []bool { return serverNoDelay(port) }).Should(ConsistOf(false, false, false))
+			})
+		})
+	})
+
 	Context("when not specifing the content type", func() {
 		It("handles the request", func() {
 			request, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:%d/containers", port), strings.NewReader("{}"))
@@ -134,6 +493,136 @@ var _ = Describe("When a client connects", func() {
 		}
 	})
 
+	Context("when routes are rate limited", func() {
+		BeforeEach(func() {
+			Expect(apiServer.SetRouteRateLimit(routes.List, server.RouteRateLimit{
+				RequestsPerSecond: 1,
+				Burst:             2,
+			})).To(Succeed())
+		})
+
+		It("refuses requests over the limit with a RateLimitedError", func() {
+			containersCalls := serverBackend.ContainersCallCount()
+
+			_, err := apiClient.Containers(nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = apiClient.Containers(nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = apiClient.Containers(nil)
+			Expect(err).To(BeAssignableToTypeOf(garden.RateLimitedError{}))
+			Expect(err.(garden.RateLimitedError).Route).To(Equal(routes.List))
+			Expect(err.(garden.RateLimitedError).RetryAfter).To(BeNumerically(">", 0))
+
+			Expect(serverBackend.ContainersCallCount()).To(Equal(containersCalls + 2))
+		})
+
+		It("does not limit other routes", func() {
+			for i := 0; i < 5; i++ {
+				Expect(apiClient.Ping()).To(Succeed())
+			}
+		})
+
+		It("serves requests again once the limit is removed", func() {
+			for i := 0; i < 2; i++ {
+				_, err := apiClient.Containers(nil)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(apiServer.SetRouteRateLimit(routes.List, server.RouteRateLimit{})).To(Succeed())
+
+			_, err := apiClient.Containers(nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not allow streaming routes to be limited", func() {
+			err := apiServer.SetRouteRateLimit(routes.Run, server.RouteRateLimit{RequestsPerSecond: 1})
+			Expect(err).To(Equal(server.ErrStreamingRouteRateLimit))
+		})
+	})
+
+	Context("when responses are compressed", func() {
+		BeforeEach(func() {
+			apiServer.SetResponseCompressionThreshold(1)
+		})
+
+		It("decodes them transparently", func() {
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("some-handle")
+			serverBackend.ContainersReturns([]garden.Container{container}, nil)
+
+			containers, err := apiClient.Containers(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(containers).To(HaveLen(1))
+			Expect(containers[0].Handle()).To(Equal("some-handle"))
+		})
+	})
+
+	Context("when an audit sink is set", func() {
+		var events chan server.AuditEvent
+
+		BeforeEach(func() {
+			events = make(chan server.AuditEvent, 10)
+			apiServer.SetAuditSink(func(event server.AuditEvent) {
+				events <- event
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("server-chosen-handle")
+			serverBackend.CreateReturns(container, nil)
+		})
+
+		It("records creates with their secrets redacted", func() {
+			before := time.Now()
+
+			_, err := apiClient.Create(garden.ContainerSpec{
+				Env:        []string{"PASSWORD=MY_SECRET"},
+				Properties: garden.Properties{"api-key": "ANOTHER_SECRET"},
+				Image:      garden.ImageRef{URI: "docker:///some/image", Username: "alice", Password: "REGISTRY_SECRET"},
+				GraceTime:  time.Minute,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			var event server.AuditEvent
+			Expect(events).To(Receive(&event))
+			Expect(event.Operation).To(Equal(routes.Create))
+			Expect(event.Handle).To(Equal("server-chosen-handle"))
+			Expect(event.Status).To(Equal(http.StatusOK))
+			Expect(event.Error).To(BeEmpty())
+			Expect(event.Time).To(BeTemporally(">=", before))
+
+			Expect(event.Params).To(HaveKeyWithValue("grace_time", "60000000000"))
+			Expect(event.Params).To(HaveKeyWithValue("env", `"[redacted]"`))
+			Expect(event.Params).To(HaveKeyWithValue("properties", `"[redacted]"`))
+			Expect(event.Params["image"]).To(ContainSubstring("docker:///some/image"))
+			for _, value := range event.Params {
+				Expect(value).ToNot(ContainSubstring("SECRET"))
+			}
+		})
+
+		It("records failed operations with their error", func() {
+			serverBackend.DestroyReturns(garden.ContainerNotFoundError{Handle: "missing-handle"})
+
+			err := apiClient.Destroy("missing-handle")
+			Expect(err).To(HaveOccurred())
+
+			var event server.AuditEvent
+			Expect(events).To(Receive(&event))
+			Expect(event.Operation).To(Equal(routes.Destroy))
+			Expect(event.Handle).To(Equal("missing-handle"))
+			Expect(event.Status).To(Equal(http.StatusNotFound))
+			Expect(event.Error).To(Equal("unknown handle: missing-handle"))
+		})
+
+		It("does not record operations which change nothing", func() {
+			_, err := apiClient.Containers(nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(events).ShouldNot(Receive())
+		})
+	})
+
 	Context("and the client sends a PingRequest", func() {
 		Context("and the backend ping succeeds", func() {
 			It("does not error", func() {
@@ -173,538 +662,2802 @@ var _ = Describe("When a client connects", func() {
 		})
 	})
 
-	Context("and the client sends a CapacityRequest", func() {
+	Context("and the client sends a FeaturesRequest", func() {
 		BeforeEach(func() {
-			serverBackend.CapacityReturns(garden.Capacity{
-				MemoryInBytes: 1111,
-				DiskInBytes:   2222,
-				MaxContainers: 42,
+			serverBackend.FeaturesReturns(garden.FeatureSet{
+				PidsLimit: true,
+				Freezer:   true,
 			}, nil)
 		})
 
-		It("returns the backend's reported capacity", func() {
-			capacity, err := apiClient.Capacity()
+		It("returns the backend's reported features, with the server's own", func() {
+			features, err := apiClient.Features()
 			Expect(err).ToNot(HaveOccurred())
-
-			Expect(capacity.MemoryInBytes).To(Equal(uint64(1111)))
-			Expect(capacity.DiskInBytes).To(Equal(uint64(2222)))
-			Expect(capacity.MaxContainers).To(Equal(uint64(42)))
+			Expect(features).To(Equal(garden.FeatureSet{
+				PidsLimit:   true,
+				Freezer:     true,
+				ListByState: true,
+				ListPaging:  true,
+			}))
 		})
 
-		Context("when getting the capacity fails", func() {
+		Context("when getting the features fails", func() {
 			BeforeEach(func() {
-				serverBackend.CapacityReturns(garden.Capacity{}, errors.New("oh no!"))
+				serverBackend.FeaturesReturns(garden.FeatureSet{}, errors.New("oh no!"))
 			})
 
 			It("returns an error", func() {
-				_, err := apiClient.Capacity()
+				_, err := apiClient.Features()
 				Expect(err).To(HaveOccurred())
 			})
 		})
 	})
 
-	Context("and the client sends a CreateRequest", func() {
-		var fakeContainer *fakes.FakeContainer
-
+	Context("and the client looks up the container of a host pid", func() {
 		BeforeEach(func() {
-			fakeContainer = new(fakes.FakeContainer)
-			fakeContainer.HandleReturns("some-handle")
+			serverBackend.ContainerForHostPIDStub = func(pid int) (string, error) {
+				if pid == 1234 {
+					return "some-handle", nil
+				}
 
-			serverBackend.CreateReturns(fakeContainer, nil)
+				return "", garden.HostPIDNotFoundError{PID: pid}
+			}
 		})
 
-		It("returns a container with the created handle", func() {
-			container, err := apiClient.Create(garden.ContainerSpec{
-				Handle: "some-handle",
-			})
+		It("returns the handle from the backend", func() {
+			handle, err := connection.New("unix", socketPath).ContainerForHostPID(1234)
 			Expect(err).ToNot(HaveOccurred())
-
-			Expect(container.Handle()).To(Equal("some-handle"))
+			Expect(handle).To(Equal("some-handle"))
 		})
 
-		It("should not log any container spec properties", func() {
-			_, err := apiClient.Create(garden.ContainerSpec{
-				Handle:     "some-handle",
-				Properties: garden.Properties{"CONTAINER_PROPERTY": "CONTAINER_SECRET"},
+		Context("when no container owns the pid", func() {
+			It("returns a HostPIDNotFoundError", func() {
+				_, err := connection.New("unix", socketPath).ContainerForHostPID(42)
+				Expect(err).To(Equal(garden.HostPIDNotFoundError{PID: 42}))
 			})
-			Expect(err).ToNot(HaveOccurred())
-
-			buffer := sink.Buffer()
-			Expect(buffer).ToNot(gbytes.Say("CONTAINER_PROPERTY"))
-			Expect(buffer).ToNot(gbytes.Say("CONTAINER_SECRET"))
 		})
 
-		It("should not log any environment variables", func() {
-			_, err := apiClient.Create(garden.ContainerSpec{
-				Handle: "some-handle",
-				Env:    []string{"PASSWORD=MY_SECRET"},
-			})
-			Expect(err).ToNot(HaveOccurred())
+		Context("when the pid is not valid", func() {
+			It("returns an error without asking the backend", func() {
+				_, err := connection.New("unix", socketPath).ContainerForHostPID(-1)
+				Expect(err).To(MatchError(server.ErrInvalidHostPID.Error()))
+				Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
 
-			buffer := sink.Buffer()
-			Expect(buffer).ToNot(gbytes.Say("PASSWORD"))
-			Expect(buffer).ToNot(gbytes.Say("MY_SECRET"))
+				Expect(serverBackend.ContainerForHostPIDCallCount()).To(Equal(0))
+			})
 		})
+	})
 
-		It("creates the container with the spec from the request", func() {
-			_, err := apiClient.Create(garden.ContainerSpec{
-				Handle:     "some-handle",
-				GraceTime:  42 * time.Second,
-				Network:    "some-network",
-				RootFSPath: "/path/to/rootfs",
-				BindMounts: []garden.BindMount{
-					{
-						SrcPath: "/bind/mount/src",
-						DstPath: "/bind/mount/dst",
-						Mode:    garden.BindMountModeRW,
-						Origin:  garden.BindMountOriginContainer,
-					},
-				},
-				Properties: garden.Properties{
-					"prop-a": "val-a",
-					"prop-b": "val-b",
-				},
-				Env: []string{"env1=env1Value", "env2=env2Value"},
-				Limits: garden.Limits{
-					Bandwidth: garden.BandwidthLimits{
-						RateInBytesPerSecond:      42,
-						BurstRateInBytesPerSecond: 68,
-					},
-					Disk: garden.DiskLimits{
-						InodeSoft: 1,
-						InodeHard: 2,
-						ByteSoft:  3,
-						ByteHard:  4,
-						Scope:     garden.DiskLimitScopeExclusive,
-					},
-					Memory: garden.MemoryLimits{
-						LimitInBytes: 1024,
-					},
-					CPU: garden.CPULimits{
-						LimitInShares: 5,
-					},
-				},
-			})
+	Context("and the client checks the backend's health", func() {
+		checkNames := func(status garden.HealthStatus) []string {
+			names := []string{}
+			for _, check := range status.Checks {
+				names = append(names, check.Name)
+			}
+			return names
+		}
+
+		It("runs each check and reports the backend healthy", func() {
+			status, err := connection.New("unix", socketPath).Health()
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(serverBackend.CreateArgsForCall(0)).To(Equal(garden.ContainerSpec{
-				Handle:     "some-handle",
-				GraceTime:  time.Duration(42 * time.Second),
-				Network:    "some-network",
-				RootFSPath: "/path/to/rootfs",
-				BindMounts: []garden.BindMount{
-					{
-						SrcPath: "/bind/mount/src",
-						DstPath: "/bind/mount/dst",
-						Mode:    garden.BindMountModeRW,
-						Origin:  garden.BindMountOriginContainer,
-					},
-				},
-				Properties: map[string]string{
-					"prop-a": "val-a",
-					"prop-b": "val-b",
-				},
-				Env: []string{"env1=env1Value", "env2=env2Value"},
-				Limits: garden.Limits{
-					Bandwidth: garden.BandwidthLimits{
-						RateInBytesPerSecond:      42,
-						BurstRateInBytesPerSecond: 68,
-					},
-					Disk: garden.DiskLimits{
-						InodeSoft: 1,
-						InodeHard: 2,
-						ByteSoft:  3,
-						ByteHard:  4,
-						Scope:     garden.DiskLimitScopeExclusive,
-					},
-					Memory: garden.MemoryLimits{
-						LimitInBytes: 1024,
-					},
-					CPU: garden.CPULimits{
-						LimitInShares: 5,
-					},
-				},
-			}))
+			Expect(status.State).To(Equal(garden.HealthStateHealthy))
+			Expect(checkNames(status)).To(Equal([]string{"ping", "capacity", "list-containers"}))
+			for _, check := range status.Checks {
+				Expect(check.Error).To(BeEmpty())
+			}
 		})
 
-		Context("when a grace time is given", func() {
-			var graceTime time.Duration
+		Context("when a check is still running as the backend is checked again", func() {
+			var release chan struct{}
 
 			BeforeEach(func() {
-				graceTime = time.Second
-
-				fakeContainer = new(fakes.FakeContainer)
-				fakeContainer.HandleReturns("doomed-handle")
-				serverBackend.CreateReturns(fakeContainer, nil)
-				serverBackend.LookupReturns(fakeContainer, nil)
+				release = make(chan struct{})
+				serverBackend.PingStub = func() error {
+					<-release
+					return nil
+				}
 			})
 
-			JustBeforeEach(func() {
-				serverBackend.GraceTimeReturns(graceTime)
+			It("waits for that check, rather than run another", func() {
+				pings := serverBackend.PingCallCount()
+				capacities := serverBackend.CapacityCallCount()
+
+				statuses := make(chan garden.HealthStatus, 2)
+				for i := 0; i < 2; i++ {
+					go func() {
+						defer GinkgoRecover()
+
+						status, err := connection.New("unix", socketPath).Health()
+						Expect(err).ToNot(HaveOccurred())
+						statuses <- status
+					}()
+				}
+
+				Eventually(serverBackend.CapacityCallCount).Should(Equal(capacities + 2))
+				Expect(serverBackend.PingCallCount()).To(Equal(pings + 1))
+
+				close(release)
+
+				for i := 0; i < 2; i++ {
+					var status garden.HealthStatus
+					Eventually(statuses).Should(Receive(&status))
+					Expect(status.State).To(Equal(garden.HealthStateHealthy))
+				}
+
+				Expect(serverBackend.PingCallCount()).To(Equal(pings + 1))
 			})
+		})
 
-			It("destroys the container after it has been idle for the grace time", func() {
-				before := time.Now()
+		Context("when a check fails", func() {
+			BeforeEach(func() {
+				serverBackend.ContainersReturns(nil, errors.New("runc is not responding"))
+			})
 
-				_, err := apiClient.Create(garden.ContainerSpec{})
+			It("reports the backend degraded, with the failure", func() {
+				status, err := connection.New("unix", socketPath).Health()
 				Expect(err).ToNot(HaveOccurred())
 
-				Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
-				Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("doomed-handle"))
+				Expect(status.State).To(Equal(garden.HealthStateDegraded))
+				Expect(status.Checks[0].Error).To(BeEmpty())
+				Expect(status.Checks[2].Error).To(Equal("runc is not responding"))
+			})
 
-				Expect(time.Since(before)).To(BeNumerically(">", graceTime), "should not destroy before the grace time expires")
+			It("responds with a 503, so that a load balancer can tell", func() {
+				resp, err := connection.New("unix", socketPath).DoRequest(routes.Health, nil, nil, nil)
+				Expect(err).To(BeAssignableToTypeOf(garden.BackendDegradedError{}))
+				Expect(resp).To(BeNil())
+
+				Expect(garden.Error{Err: err}.StatusCode()).To(Equal(http.StatusServiceUnavailable))
 			})
 
-			Context("and a process is running", func() {
-				It("destroys the container after it has been idle for the grace time", func() {
-					fakeProcess := new(fakes.FakeProcess)
-					fakeProcess.IDReturns("doomed-handle")
-					fakeProcess.WaitStub = func() (int, error) {
-						select {}
-					}
-					fakeContainer.RunReturns(fakeProcess, nil)
+			It("does not log the degraded backend as a server failure", func() {
+				connection.New("unix", socketPath).Health()
 
-					var clientConnection net.Conn
+				Expect(logger.LogMessages()).ToNot(ContainElement(HaveSuffix(".failed")))
+			})
+		})
+	})
 
-					apiConnection := connection.NewWithDialerAndLogger(func(string, string) (net.Conn, error) {
-						var err error
-						clientConnection, err = net.DialTimeout("unix", socketPath, 2*time.Second)
-						return clientConnection, err
-					}, lagertest.NewTestLogger("api-conn-dialer"))
+	Context("and the client validates a rootfs", func() {
+		var rootfs string
 
-					apiClient = client.New(apiConnection)
+		BeforeEach(func() {
+			var err error
+			rootfs, err = ioutil.TempDir("", "rootfs")
+			Expect(err).ToNot(HaveOccurred())
 
-					container, err := apiClient.Create(garden.ContainerSpec{})
-					Expect(err).ToNot(HaveOccurred())
+			Expect(os.Mkdir(filepath.Join(rootfs, "etc"), 0755)).To(Succeed())
+			Expect(os.Symlink("/dev", filepath.Join(rootfs, "dev"))).To(Succeed())
 
-					before := time.Now()
+			serverBackend.ValidateRootFSReturns(garden.UnsupportedOperationError{Operation: "ValidateRootFS"})
+		})
 
-					_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
-					Expect(err).ToNot(HaveOccurred())
+		AfterEach(func() {
+			os.RemoveAll(rootfs)
+		})
 
-					clientConnection.Close()
+		validate := func(rootfs string) error {
+			return connection.New("unix", socketPath).ValidateRootFS(rootfs)
+		}
 
-					Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
-					Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("doomed-handle"))
+		It("accepts a directory with the structure of a root filesystem", func() {
+			Expect(validate(rootfs)).To(Succeed())
 
-					duration := time.Since(before)
-					Expect(duration).To(BeNumerically(">=", graceTime))
-					Expect(duration).To(BeNumerically("<", graceTime+time.Second))
-				})
-			})
+			Expect(serverBackend.ValidateRootFSCallCount()).To(Equal(1))
+			Expect(serverBackend.ValidateRootFSArgsForCall(0)).To(Equal(rootfs))
+		})
 
-			Context("but it expires during an API destroy request", func() {
-				BeforeEach(func() {
-					// increase the grace time so that we can API create/destroy before expiration
-					graceTime = time.Second * 3
+		It("accepts the default rootfs without asking the backend", func() {
+			Expect(validate("")).To(Succeed())
 
-					serverBackend.DestroyStub = func(string) error {
-						// sleep for longer than the grace time
-						time.Sleep(graceTime * 2)
-						return nil
-					}
-				})
+			Expect(serverBackend.ValidateRootFSCallCount()).To(Equal(0))
+		})
 
-				It("does not reap the container", func() {
-					_, err := apiClient.Create(garden.ContainerSpec{})
-					Expect(err).ToNot(HaveOccurred())
+		It("rejects a path which does not exist", func() {
+			err := validate(filepath.Join(rootfs, "missing"))
+			Expect(err).To(Equal(garden.InvalidRootFSError{RootFS: filepath.Join(rootfs, "missing"), Reason: "does not exist"}))
+			Expect(garden.Error{Err: err}.StatusCode()).To(Equal(http.StatusBadRequest))
+		})
 
-					err = apiClient.Destroy("doomed-handle")
-					Expect(err).ToNot(HaveOccurred())
+		It("rejects a relative path", func() {
+			Expect(validate("some/rootfs")).To(MatchError(HaveSuffix("path must be absolute")))
+		})
 
-					Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("doomed-handle"))
-					Expect(serverBackend.DestroyCallCount()).To(Equal(1))
-				})
-			})
+		It("rejects a file", func() {
+			file := filepath.Join(rootfs, "some-file")
+			Expect(ioutil.WriteFile(file, []byte("hello"), 0644)).To(Succeed())
+
+			Expect(validate(file)).To(MatchError(HaveSuffix("is not a directory")))
 		})
 
-		Context("when a grace time is not given", func() {
-			It("defaults it to the server's grace time", func() {
-				_, err := apiClient.Create(garden.ContainerSpec{
-					Handle: "some-handle",
-				})
-				Expect(err).ToNot(HaveOccurred())
+		It("rejects an empty directory", func() {
+			empty := filepath.Join(rootfs, "etc")
 
-				spec := serverBackend.CreateArgsForCall(0)
-				Expect(spec.GraceTime).To(Equal(serverContainerGraceTime))
-			})
+			Expect(validate(empty)).To(MatchError(HaveSuffix("is empty")))
 		})
 
-		Context("when creating the container fails", func() {
-			BeforeEach(func() {
-				serverBackend.CreateReturns(nil, errors.New("oh no!"))
-			})
+		It("rejects a rootfs whose mount points are not directories", func() {
+			Expect(ioutil.WriteFile(filepath.Join(rootfs, "proc"), []byte("hello"), 0644)).To(Succeed())
 
-			It("returns an error", func() {
-				_, err := apiClient.Create(garden.ContainerSpec{
-					Handle: "some-handle",
-				})
-				Expect(err).To(HaveOccurred())
-			})
+			Expect(validate(rootfs)).To(MatchError(HaveSuffix("/proc is not a directory")))
 		})
 
-		Context("when creating the container fails with a ServiceUnavailableError", func() {
-			var err error
+		Context("when the rootfs has a scheme the server does not know", func() {
+			It("leaves it to the backend", func() {
+				serverBackend.ValidateRootFSReturns(nil)
 
-			BeforeEach(func() {
-				serverBackend.CreateReturns(nil, garden.NewServiceUnavailableError("special error"))
+				Expect(validate("oci:///var/vcap/images/busybox")).To(Succeed())
 
-				_, err = apiClient.Create(garden.ContainerSpec{
-					Handle: "some-handle",
-				})
+				Expect(serverBackend.ValidateRootFSArgsForCall(0)).To(Equal("oci:///var/vcap/images/busybox"))
 			})
 
-			It("client returns an error with a well formed error msg", func() {
-				Expect(err).To(MatchError("special error"))
+			It("fails if the backend cannot tell whether it can be used", func() {
+				Expect(validate("oci:///var/vcap/images/busybox")).To(Equal(garden.UnsupportedOperationError{Operation: "ValidateRootFS"}))
 			})
 
-			It("client returns an error of type ServiceUnavailableError", func() {
-				_, ok := err.(garden.ServiceUnavailableError)
-				Expect(ok).To(BeTrue())
+			It("returns the backend's error", func() {
+				serverBackend.ValidateRootFSReturns(garden.InvalidRootFSError{RootFS: "ftp://example.com/rootfs", Reason: "unsupported scheme"})
+
+				Expect(validate("ftp://example.com/rootfs")).To(Equal(garden.InvalidRootFSError{RootFS: "ftp://example.com/rootfs", Reason: "unsupported scheme"}))
 			})
 		})
-	})
 
-	Context("and the client sends a destroy request", func() {
-		It("destroys the container", func() {
-			err := apiClient.Destroy("some-handle")
-			Expect(err).ToNot(HaveOccurred())
+		It("rejects a malformed image repository without asking the backend", func() {
+			Expect(validate("docker:///Busybox")).To(MatchError(ContainSubstring("is not a valid image repository")))
 
-			Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
+			Expect(serverBackend.ValidateRootFSCallCount()).To(Equal(0))
 		})
 
-		Context("concurrent with other destroy requests", func() {
-			var destroying chan struct{}
-
+		Context("when the path is rejected by the backend", func() {
 			BeforeEach(func() {
-				destroying = make(chan struct{})
-
-				serverBackend.DestroyStub = func(string) error {
-					close(destroying)
-					time.Sleep(time.Second)
-					return nil
-				}
+				serverBackend.ValidateRootFSReturns(garden.InvalidRootFSError{RootFS: rootfs, Reason: "is on a noexec mount"})
 			})
 
-			It("only destroys once", func() {
-				go apiClient.Destroy("some-handle")
+			It("returns the backend's error", func() {
+				Expect(validate(rootfs)).To(Equal(garden.InvalidRootFSError{RootFS: rootfs, Reason: "is on a noexec mount"}))
+			})
+		})
 
-				<-destroying
+		Context("when the rootfs is an image", func() {
+			It("asks the backend to resolve it", func() {
+				serverBackend.ValidateRootFSReturns(nil)
 
-				err := apiClient.Destroy("some-handle")
-				Expect(err).To(HaveOccurred())
+				Expect(validate("docker://registry.example.com/library/busybox#1.36")).To(Succeed())
 
-				Expect(serverBackend.DestroyCallCount()).To(Equal(1))
+				Expect(serverBackend.ValidateRootFSArgsForCall(0)).To(Equal("docker://registry.example.com/library/busybox#1.36"))
 			})
-		})
 
-		Context("when the container cannot be found", func() {
-			var theError = garden.ContainerNotFoundError{Handle: "some-handle"}
+			It("returns the backend's error", func() {
+				serverBackend.ValidateRootFSReturns(garden.InvalidRootFSError{RootFS: "docker:///busybox", Reason: "manifest unknown"})
 
-			BeforeEach(func() {
-				serverBackend.DestroyReturns(theError)
+				Expect(validate("docker:///busybox")).To(MatchError("invalid rootfs docker:///busybox: manifest unknown"))
 			})
 
-			It("returns an ContainerNotFoundError", func() {
-				err := apiClient.Destroy("some-handle")
-				Expect(err).To(MatchError(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			It("fails when the backend cannot resolve images", func() {
+				Expect(validate("docker:///busybox")).To(BeAssignableToTypeOf(garden.UnsupportedOperationError{}))
 			})
 		})
+	})
 
-		Context("when destroying the container fails", func() {
-			var theError = errors.New("o no")
+	Context("and the client requests a self-test", func() {
+		var (
+			selftestContainer *fakes.FakeContainer
+			selftestProcess   *fakes.FakeProcess
+		)
 
+		stepNames := func(result garden.SelftestResult) []string {
+			names := []string{}
+			for _, step := range result.Steps {
+				names = append(names, step.Name)
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			selftestProcess = new(fakes.FakeProcess)
+			selftestProcess.WaitReturns(0, nil)
+
+			selftestContainer = new(fakes.FakeContainer)
+			selftestContainer.RunReturns(selftestProcess, nil)
+
+			serverBackend.CreateReturns(selftestContainer, nil)
+		})
+
+		It("creates a uniquely named container, runs a process in it and destroys it", func() {
+			result, err := connection.New("unix", socketPath).Selftest()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Succeeded).To(BeTrue())
+			Expect(stepNames(result)).To(Equal([]string{"create", "run", "destroy"}))
+			for _, step := range result.Steps {
+				Expect(step.Error).To(BeEmpty())
+			}
+
+			Expect(serverBackend.CreateCallCount()).To(Equal(1))
+			spec := serverBackend.CreateArgsForCall(0)
+			Expect(spec.Handle).To(HavePrefix("garden-selftest-"))
+
+			ranSpec, _ := selftestContainer.RunArgsForCall(0)
+			Expect(ranSpec.Path).To(Equal("/bin/true"))
+
+			Expect(serverBackend.DestroyCallCount()).To(Equal(1))
+			Expect(serverBackend.DestroyArgsForCall(0)).To(Equal(spec.Handle))
+
+			_, err = connection.New("unix", socketPath).Selftest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serverBackend.CreateArgsForCall(1).Handle).ToNot(Equal(spec.Handle))
+		})
+
+		Context("when the process fails", func() {
 			BeforeEach(func() {
-				serverBackend.DestroyReturns(theError)
+				selftestProcess.WaitReturns(1, nil)
 			})
 
-			It("returns an error with the same message", func() {
-				err := apiClient.Destroy("some-handle")
-				Expect(err).To(MatchError("o no"))
+			It("reports the failed step and still destroys the container", func() {
+				result, err := connection.New("unix", socketPath).Selftest()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(result.Succeeded).To(BeFalse())
+				Expect(stepNames(result)).To(Equal([]string{"create", "run", "destroy"}))
+				Expect(result.Steps[1].Error).To(ContainSubstring("exited with status 1"))
+				Expect(result.Steps[2].Error).To(BeEmpty())
+
+				Expect(serverBackend.DestroyCallCount()).To(Equal(1))
 			})
+		})
 
-			Context("and destroying is attempted again", func() {
-				BeforeEach(func() {
-					err := apiClient.Destroy("some-handle")
-					Expect(err).To(HaveOccurred())
+		Context("when the container cannot be created", func() {
+			BeforeEach(func() {
+				serverBackend.CreateReturns(nil, errors.New("oh no!"))
+			})
 
-					serverBackend.DestroyReturns(nil)
-				})
+			It("reports the failed step and stops", func() {
+				result, err := connection.New("unix", socketPath).Selftest()
+				Expect(err).ToNot(HaveOccurred())
 
-				It("tries to destroy again", func() {
-					err := apiClient.Destroy("some-handle")
-					Expect(err).ToNot(HaveOccurred())
+				Expect(result.Succeeded).To(BeFalse())
+				Expect(stepNames(result)).To(Equal([]string{"create"}))
+				Expect(result.Steps[0].Error).To(Equal("oh no!"))
 
-					Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
-				})
+				Expect(serverBackend.DestroyCallCount()).To(Equal(0))
 			})
 		})
 	})
 
-	Context("and the client sends a ListRequest", func() {
+	Context("and the client sends a CapacityRequest", func() {
 		BeforeEach(func() {
-			c1 := new(fakes.FakeContainer)
-			c1.HandleReturns("some-handle")
-
-			c2 := new(fakes.FakeContainer)
-			c2.HandleReturns("another-handle")
-
-			c3 := new(fakes.FakeContainer)
-			c3.HandleReturns("super-handle")
-
-			serverBackend.ContainersReturns([]garden.Container{c1, c2, c3}, nil)
+			serverBackend.CapacityReturns(garden.Capacity{
+				MemoryInBytes: 1111,
+				DiskInBytes:   2222,
+				MaxContainers: 42,
+			}, nil)
 		})
 
-		It("returns the containers from the backend", func() {
-			containers, err := apiClient.Containers(nil)
+		It("returns the backend's reported capacity", func() {
+			capacity, err := apiClient.Capacity()
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(containers).To(HaveLen(3))
-
-			handles := make([]string, 3)
-			for i, c := range containers {
-				handles[i] = c.Handle()
-			}
-
-			Expect(handles).To(ContainElement("some-handle"))
-			Expect(handles).To(ContainElement("another-handle"))
-			Expect(handles).To(ContainElement("super-handle"))
+			Expect(capacity.MemoryInBytes).To(Equal(uint64(1111)))
+			Expect(capacity.DiskInBytes).To(Equal(uint64(2222)))
+			Expect(capacity.MaxContainers).To(Equal(uint64(42)))
 		})
 
-		Context("when getting the containers fails", func() {
+		Context("when getting the capacity fails", func() {
 			BeforeEach(func() {
-				serverBackend.ContainersReturns(nil, errors.New("oh no!"))
+				serverBackend.CapacityReturns(garden.Capacity{}, errors.New("oh no!"))
 			})
 
 			It("returns an error", func() {
-				_, err := apiClient.Containers(nil)
+				_, err := apiClient.Capacity()
 				Expect(err).To(HaveOccurred())
 			})
 		})
+	})
 
-		Context("and the client sends a ListRequest with a property filter", func() {
-			It("forwards the filter to the backend", func() {
-				_, err := apiClient.Containers(garden.Properties{
-					"foo": "bar",
-				})
-				Expect(err).ToNot(HaveOccurred())
+	Context("and the client watches the capacity", func() {
+		var (
+			capacities   <-chan garden.Capacity
+			stopWatching func()
+		)
 
-				Expect(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).To(Equal(
-					garden.Properties{
-						"foo": "bar",
-					},
-				))
-			})
+		BeforeEach(func() {
+			serverBackend.CapacityReturns(garden.Capacity{MaxContainers: 42}, nil)
 
-			It("should not log the properties", func() {
-				_, err := apiClient.Containers(garden.Properties{
-					"hello": "banana",
-				})
-				Expect(err).ToNot(HaveOccurred())
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			serverBackend.CreateReturns(fakeContainer, nil)
 
-				buffer := sink.Buffer()
-				Expect(buffer).ToNot(gbytes.Say("hello"))
-				Expect(buffer).ToNot(gbytes.Say("banana"))
-			})
+			var err error
+			capacities, stopWatching, err = connection.New("unix", socketPath).WatchCapacity()
+			Expect(err).ToNot(HaveOccurred())
+		})
 
-			Context("when getting the containers fails", func() {
-				BeforeEach(func() {
-					serverBackend.ContainersReturns(nil, errors.New("oh no!"))
-				})
+		AfterEach(func() {
+			stopWatching()
+		})
 
-				It("should not log the properties", func() {
-					apiClient.Containers(garden.Properties{
-						"hello": "banana",
-					})
+		It("streams the current capacity", func() {
+			Eventually(capacities).Should(Receive(Equal(garden.Capacity{MaxContainers: 42})))
+		})
 
-					buffer := sink.Buffer()
-					Expect(buffer).ToNot(gbytes.Say("hello"))
-					Expect(buffer).ToNot(gbytes.Say("banana"))
-				})
+		It("streams the capacity again when a container is created or destroyed", func() {
+			Eventually(capacities).Should(Receive())
+			Consistently(capacities).ShouldNot(Receive())
+
+			serverBackend.CapacityReturns(garden.Capacity{MaxContainers: 43}, nil)
+			_, err := apiClient.Create(garden.ContainerSpec{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(capacities).Should(Receive(Equal(garden.Capacity{MaxContainers: 43})))
+
+			serverBackend.CapacityReturns(garden.Capacity{MaxContainers: 42}, nil)
+			Expect(apiClient.Destroy("some-handle")).To(Succeed())
+
+			Eventually(capacities).Should(Receive(Equal(garden.Capacity{MaxContainers: 42})))
+		})
+
+		It("does not stream the capacity again when it has not changed", func() {
+			Eventually(capacities).Should(Receive())
+
+			_, err := apiClient.Create(garden.ContainerSpec{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(capacities).ShouldNot(Receive())
+		})
+
+		It("closes the stream when the client stops watching", func() {
+			Eventually(capacities).Should(Receive())
+
+			stopWatching()
+
+			Eventually(capacities).Should(BeClosed())
+		})
+
+		It("closes the stream when the server stops", func() {
+			Eventually(capacities).Should(Receive())
+
+			isRunning = false
+			apiServer.Stop()
+
+			Eventually(capacities).Should(BeClosed())
+		})
+
+		Context("when getting the capacity fails", func() {
+			BeforeEach(func() {
+				serverBackend.CapacityReturns(garden.Capacity{}, errors.New("oh no!"))
 			})
 
+			It("returns an error", func() {
+				_, _, err := connection.New("unix", socketPath).WatchCapacity()
+				Expect(err).To(MatchError("oh no!"))
+			})
 		})
 	})
 
-	Context("when a container has been created", func() {
-		var (
-			container garden.Container
-			graceTime time.Duration
-
-			fakeContainer *fakes.FakeContainer
-		)
+	Context("and the client watches for OOM kills", func() {
+		var backendEvents chan garden.OOMEvent
 
 		BeforeEach(func() {
-			fakeContainer = new(fakes.FakeContainer)
-			fakeContainer.HandleReturns("some-handle")
+			backendEvents = make(chan garden.OOMEvent)
+			serverBackend.OOMEventsReturns(backendEvents, nil)
+		})
 
-			serverBackend.CreateReturns(fakeContainer, nil)
-			serverBackend.LookupReturns(fakeContainer, nil)
+		It("streams each OOM kill the backend reports", func() {
+			events, _, err := connection.New("unix", socketPath).WatchOOMs()
+			Expect(err).ToNot(HaveOccurred())
+
+			killed := garden.OOMEvent{Handle: "some-handle", ProcessID: "some-process", Time: time.Unix(123, 0).UTC()}
+			backendEvents <- killed
+
+			Eventually(events).Should(Receive(Equal(killed)))
 		})
 
-		JustBeforeEach(func() {
-			var err error
+		It("only subscribes to the backend once", func() {
+			first, _, err := connection.New("unix", socketPath).WatchOOMs()
+			Expect(err).ToNot(HaveOccurred())
 
-			container, err = apiClient.Create(garden.ContainerSpec{})
+			second, _, err := connection.New("unix", socketPath).WatchOOMs()
 			Expect(err).ToNot(HaveOccurred())
+
+			backendEvents <- garden.OOMEvent{Handle: "some-handle"}
+
+			Eventually(first).Should(Receive(Equal(garden.OOMEvent{Handle: "some-handle"})))
+			Eventually(second).Should(Receive(Equal(garden.OOMEvent{Handle: "some-handle"})))
+			Expect(serverBackend.OOMEventsCallCount()).To(Equal(1))
 		})
 
-		itResetsGraceTimeWhenHandling := func(fn func(time.Duration)) {
-			graceTime := 500 * time.Millisecond
+		Context("when the backend stops reporting OOM kills", func() {
+			It("closes the stream, and subscribes again for the next watch", func() {
+				events, _, err := connection.New("unix", socketPath).WatchOOMs()
+				Expect(err).ToNot(HaveOccurred())
 
-			BeforeEach(func() {
-				serverBackend.GraceTimeReturns(graceTime)
-			})
+				close(backendEvents)
+				Eventually(events).Should(BeClosed())
 
-			It("resets grace time when handling", func() {
-				fn(graceTime * 2)
-				Expect(serverBackend.DestroyCallCount()).To(Equal(0))
-				Eventually(serverBackend.DestroyCallCount, graceTime+(1000*time.Millisecond)).Should(Equal(1))
-			})
-		}
+				nextBackendEvents := make(chan garden.OOMEvent)
+				serverBackend.OOMEventsReturns(nextBackendEvents, nil)
 
-		itFailsWhenTheContainerIsNotFound := func(example func() error) {
-			Context("when the container is not found", func() {
-				It("fails", func() {
-					serverBackend.LookupReturns(nil, errors.New("not found"))
-					Expect(example()).To(MatchError("not found"))
-				})
+				next, _, err := connection.New("unix", socketPath).WatchOOMs()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(serverBackend.OOMEventsCallCount()).To(Equal(2))
+
+				nextBackendEvents <- garden.OOMEvent{Handle: "some-handle"}
+				Eventually(next).Should(Receive(Equal(garden.OOMEvent{Handle: "some-handle"})))
 			})
-		}
+		})
 
-		Describe("stopping", func() {
-			It("stops the container and sends a StopResponse", func() {
-				err := container.Stop(true)
-				Expect(err).ToNot(HaveOccurred())
+		It("closes the stream when the server stops", func() {
+			events, _, err := connection.New("unix", socketPath).WatchOOMs()
+			Expect(err).ToNot(HaveOccurred())
 
-				Expect(fakeContainer.StopArgsForCall(0)).To(Equal(true))
+			isRunning = false
+			apiServer.Stop()
+
+			Eventually(events).Should(BeClosed())
+		})
+
+		Context("when the backend cannot watch for OOM kills", func() {
+			BeforeEach(func() {
+				serverBackend.OOMEventsReturns(nil, garden.UnsupportedOperationError{Operation: "oom-events"})
 			})
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				return container.Stop(true)
+			It("returns the error", func() {
+				_, _, err := connection.New("unix", socketPath).WatchOOMs()
+				Expect(err).To(Equal(garden.UnsupportedOperationError{Operation: "oom-events"}))
 			})
+		})
+	})
 
-			Context("when stopping the container fails", func() {
-				BeforeEach(func() {
-					fakeContainer.StopReturns(errors.New("oh no!"))
-				})
+	Context("and the client sends a CreateRequest", func() {
+		var fakeContainer *fakes.FakeContainer
 
-				It("returns an error", func() {
-					err := container.Stop(true)
-					Expect(err).To(HaveOccurred())
-				})
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+		})
+
+		It("returns a container with the created handle", func() {
+			container, err := apiClient.Create(garden.ContainerSpec{
+				Handle: "some-handle",
 			})
+			Expect(err).ToNot(HaveOccurred())
 
-			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-				fakeContainer.StopStub = func(_ bool) error { time.Sleep(timeToSleep); return nil }
-				container.Stop(false)
+			Expect(container.Handle()).To(Equal("some-handle"))
+		})
+
+		It("should not log any container spec properties", func() {
+			_, err := apiClient.Create(garden.ContainerSpec{
+				Handle:     "some-handle",
+				Properties: garden.Properties{"CONTAINER_PROPERTY": "CONTAINER_SECRET"},
 			})
+			Expect(err).ToNot(HaveOccurred())
+
+			buffer := sink.Buffer()
+			Expect(buffer).ToNot(gbytes.Say("CONTAINER_PROPERTY"))
+			Expect(buffer).ToNot(gbytes.Say("CONTAINER_SECRET"))
 		})
 
-		Describe("metrics", func() {
+		It("should not log any environment variables", func() {
+			_, err := apiClient.Create(garden.ContainerSpec{
+				Handle: "some-handle",
+				Env:    []string{"PASSWORD=MY_SECRET"},
+			})
+			Expect(err).ToNot(HaveOccurred())
 
-			containerMetrics := garden.Metrics{
-				MemoryStat: garden.ContainerMemoryStat{
+			buffer := sink.Buffer()
+			Expect(buffer).ToNot(gbytes.Say("PASSWORD"))
+			Expect(buffer).ToNot(gbytes.Say("MY_SECRET"))
+		})
+
+		It("creates the container with the spec from the request", func() {
+			_, err := apiClient.Create(garden.ContainerSpec{
+				Handle:     "some-handle",
+				GraceTime:  42 * time.Second,
+				Network:    "some-network",
+				RootFSPath: "/path/to/rootfs",
+				BindMounts: []garden.BindMount{
+					{
+						SrcPath: "/bind/mount/src",
+						DstPath: "/bind/mount/dst",
+						Mode:    garden.BindMountModeRW,
+						Origin:  garden.BindMountOriginContainer,
+					},
+				},
+				UIDMappings: []garden.IDMapping{
+					{ContainerID: 0, HostID: 100000, Size: 65536},
+				},
+				GIDMappings: []garden.IDMapping{
+					{ContainerID: 0, HostID: 200000, Size: 65536},
+				},
+				Properties: garden.Properties{
+					"prop-a": "val-a",
+					"prop-b": "val-b",
+				},
+				Env: []string{"env1=env1Value", "env2=env2Value"},
+				Limits: garden.Limits{
+					Bandwidth: garden.BandwidthLimits{
+						RateInBytesPerSecond:      42,
+						BurstRateInBytesPerSecond: 68,
+					},
+					Disk: garden.DiskLimits{
+						InodeSoft: 1,
+						InodeHard: 2,
+						ByteSoft:  3,
+						ByteHard:  4,
+						Scope:     garden.DiskLimitScopeExclusive,
+					},
+					Memory: garden.MemoryLimits{
+						LimitInBytes: 1024,
+					},
+					CPU: garden.CPULimits{
+						LimitInShares: 5,
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(serverBackend.CreateArgsForCall(0)).To(Equal(garden.ContainerSpec{
+				Handle:     "some-handle",
+				GraceTime:  time.Duration(42 * time.Second),
+				Network:    "some-network",
+				RootFSPath: "/path/to/rootfs",
+				BindMounts: []garden.BindMount{
+					{
+						SrcPath: "/bind/mount/src",
+						DstPath: "/bind/mount/dst",
+						Mode:    garden.BindMountModeRW,
+						Origin:  garden.BindMountOriginContainer,
+					},
+				},
+				UIDMappings: []garden.IDMapping{
+					{ContainerID: 0, HostID: 100000, Size: 65536},
+				},
+				GIDMappings: []garden.IDMapping{
+					{ContainerID: 0, HostID: 200000, Size: 65536},
+				},
+				Properties: map[string]string{
+					"prop-a": "val-a",
+					"prop-b": "val-b",
+				},
+				Env: []string{"env1=env1Value", "env2=env2Value"},
+				Limits: garden.Limits{
+					Bandwidth: garden.BandwidthLimits{
+						RateInBytesPerSecond:      42,
+						BurstRateInBytesPerSecond: 68,
+					},
+					Disk: garden.DiskLimits{
+						InodeSoft: 1,
+						InodeHard: 2,
+						ByteSoft:  3,
+						ByteHard:  4,
+						Scope:     garden.DiskLimitScopeExclusive,
+					},
+					Memory: garden.MemoryLimits{
+						LimitInBytes: 1024,
+					},
+					CPU: garden.CPULimits{
+						LimitInShares: 5,
+					},
+				},
+			}))
+		})
+
+		Context("when a grace time is given", func() {
+			var graceTime time.Duration
+
+			BeforeEach(func() {
+				graceTime = time.Second
+
+				fakeContainer = new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("doomed-handle")
+				serverBackend.CreateReturns(fakeContainer, nil)
+				serverBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			JustBeforeEach(func() {
+				serverBackend.GraceTimeReturns(graceTime)
+			})
+
+			It("destroys the container after it has been idle for the grace time", func() {
+				before := time.Now()
+
+				_, err := apiClient.Create(garden.ContainerSpec{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
+				Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("doomed-handle"))
+
+				Expect(time.Since(before)).To(BeNumerically(">", graceTime), "should not destroy before the grace time expires")
+			})
+
+			It("counts the reap and reports it to the reap observer", func() {
+				reaps := make(chan server.ReapEvent, 1)
+				apiServer.SetReapObserver(func(event server.ReapEvent) {
+					reaps <- event
+				})
+
+				_, err := apiClient.Create(garden.ContainerSpec{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(reaps, 2*time.Second).Should(Receive(Equal(server.ReapEvent{
+					Handle:    "doomed-handle",
+					GraceTime: graceTime,
+					Reason:    server.ReapReasonGraceTimeExpired,
+				})))
+
+				Expect(apiServer.ReapedContainers()).To(Equal(uint64(1)))
+			})
+
+			Context("and destroying the reaped container fails", func() {
+				BeforeEach(func() {
+					serverBackend.DestroyReturns(errors.New("oh no!"))
+				})
+
+				It("reports the failure without counting the reap", func() {
+					reaps := make(chan server.ReapEvent, 1)
+					apiServer.SetReapObserver(func(event server.ReapEvent) {
+						reaps <- event
+					})
+
+					_, err := apiClient.Create(garden.ContainerSpec{})
+					Expect(err).ToNot(HaveOccurred())
+
+					var event server.ReapEvent
+					Eventually(reaps, 2*time.Second).Should(Receive(&event))
+					Expect(event.Err).To(MatchError("oh no!"))
+
+					Expect(apiServer.ReapedContainers()).To(BeZero())
+				})
+			})
+
+			Context("and a process is running", func() {
+				It("destroys the container after it has been idle for the grace time", func() {
+					fakeProcess := new(fakes.FakeProcess)
+					fakeProcess.IDReturns("doomed-handle")
+					fakeProcess.WaitStub = func() (int, error) {
+						select {}
+					}
+					fakeContainer.RunReturns(fakeProcess, nil)
+
+					var clientConnection net.Conn
+
+					apiConnection := connection.NewWithDialerAndLogger(func(string, string) (net.Conn, error) {
+						var err error
+						clientConnection, err = net.DialTimeout("unix", socketPath, 2*time.Second)
+						return clientConnection, err
+					}, lagertest.NewTestLogger("api-conn-dialer"))
+
+					apiClient = client.New(apiConnection)
+
+					container, err := apiClient.Create(garden.ContainerSpec{})
+					Expect(err).ToNot(HaveOccurred())
+
+					before := time.Now()
+
+					_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					clientConnection.Close()
+
+					Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
+					Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("doomed-handle"))
+
+					duration := time.Since(before)
+					Expect(duration).To(BeNumerically(">=", graceTime))
+					Expect(duration).To(BeNumerically("<", graceTime+time.Second))
+				})
+			})
+
+			Context("but it expires during an API destroy request", func() {
+				BeforeEach(func() {
+					// increase the grace time so that we can API create/destroy before expiration
+					graceTime = time.Second * 3
+
+					serverBackend.DestroyStub = func(string) error {
+						// sleep for longer than the grace time
+						time.Sleep(graceTime * 2)
+						return nil
+					}
+				})
+
+				It("does not reap the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{})
+					Expect(err).ToNot(HaveOccurred())
+
+					err = apiClient.Destroy("doomed-handle")
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("doomed-handle"))
+					Expect(serverBackend.DestroyCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("when the server is draining", func() {
+			BeforeEach(func() {
+				Expect(connection.New("unix", socketPath).SetDrainMode(true)).To(Succeed())
+			})
+
+			It("returns a DrainingError without creating the container", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{})
+				Expect(err).To(Equal(garden.DrainingError{}))
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				Expect(apiServer.Draining()).To(BeTrue())
+			})
+
+			It("still destroys containers", func() {
+				Expect(apiClient.Destroy("some-handle")).To(Succeed())
+				Expect(serverBackend.DestroyCallCount()).To(Equal(1))
+			})
+
+			It("creates containers again once draining stops", func() {
+				Expect(connection.New("unix", socketPath).SetDrainMode(false)).To(Succeed())
+
+				_, err := apiClient.Create(garden.ContainerSpec{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when a maximum number of containers is set", func() {
+			BeforeEach(func() {
+				apiServer.SetMaxContainers(2)
+				serverBackend.ContainersReturns([]garden.Container{new(fakes.FakeContainer)}, nil)
+			})
+
+			It("creates containers while there is room", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(serverBackend.CreateCallCount()).To(Equal(1))
+			})
+
+			Context("when the server has reached it", func() {
+				BeforeEach(func() {
+					serverBackend.ContainersReturns([]garden.Container{new(fakes.FakeContainer), new(fakes.FakeContainer)}, nil)
+				})
+
+				It("returns a MaxContainersReachedError without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{})
+					Expect(err).To(Equal(garden.MaxContainersReachedError{MaxContainers: 2, Containers: 2}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+
+				It("creates containers again once the maximum is raised", func() {
+					apiServer.SetMaxContainers(3)
+
+					_, err := apiClient.Create(garden.ContainerSpec{})
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("when another create is still in progress", func() {
+				var unblock chan struct{}
+
+				BeforeEach(func() {
+					unblock = make(chan struct{})
+					serverBackend.CreateStub = func(garden.ContainerSpec) (garden.Container, error) {
+						<-unblock
+						return fakeContainer, nil
+					}
+				})
+
+				It("counts it towards the maximum", func() {
+					created := make(chan error)
+					go func() {
+						_, err := apiClient.Create(garden.ContainerSpec{})
+						created <- err
+					}()
+
+					Eventually(serverBackend.CreateCallCount).Should(Equal(1))
+
+					_, err := apiClient.Create(garden.ContainerSpec{})
+					Expect(err).To(Equal(garden.MaxContainersReachedError{MaxContainers: 2, Containers: 2}))
+
+					close(unblock)
+					Eventually(created).Should(Receive(BeNil()))
+				})
+			})
+		})
+
+		Context("when uid or gid mappings are given for a privileged container", func() {
+			It("returns an error without creating the container", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Privileged: true,
+					UIDMappings: []garden.IDMapping{
+						{ContainerID: 0, HostID: 100000, Size: 1},
+					},
+				})
+				Expect(err).To(MatchError(server.ErrPrivilegedIDMappings.Error()))
+				Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the handle belonged to a container destroyed within the reuse grace window", func() {
+			BeforeEach(func() {
+				apiServer.SetHandleReuseGraceWindow(100 * time.Millisecond)
+				Expect(apiClient.Destroy("reused-handle")).To(Succeed())
+			})
+
+			It("returns an error without creating the container", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{Handle: "reused-handle"})
+				Expect(err).To(Equal(garden.HandleRecentlyDestroyedError{Handle: "reused-handle"}))
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+			})
+
+			It("allows other handles to be created", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{Handle: "other-handle"})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("allows the handle to be reused once the window has passed", func() {
+				Eventually(func() error {
+					_, err := apiClient.Create(garden.ContainerSpec{Handle: "reused-handle"})
+					return err
+				}).Should(Succeed())
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when cloning an existing container", func() {
+			var (
+				source *fakes.FakeContainer
+				clone  *fakes.FakeContainer
+			)
+
+			BeforeEach(func() {
+				source = new(fakes.FakeContainer)
+				source.HandleReturns("source-handle")
+
+				clone = new(fakes.FakeContainer)
+				clone.HandleReturns("clone-handle")
+
+				serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+					if handle == "source-handle" {
+						return source, nil
+					}
+
+					return nil, garden.ContainerNotFoundError{Handle: handle}
+				}
+				serverBackend.CreateReturns(clone, nil)
+			})
+
+			It("passes the source to the backend", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Handle:    "clone-handle",
+					CloneFrom: "source-handle",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(1))
+				Expect(serverBackend.CreateArgsForCall(0).CloneFrom).To(Equal("source-handle"))
+			})
+
+			It("does not let the source be destroyed while it is being cloned", func() {
+				cloning := make(chan struct{})
+				release := make(chan struct{})
+				serverBackend.CreateStub = func(garden.ContainerSpec) (garden.Container, error) {
+					close(cloning)
+					<-release
+					return clone, nil
+				}
+
+				created := make(chan error, 1)
+				go func() {
+					defer GinkgoRecover()
+
+					_, err := apiClient.Create(garden.ContainerSpec{CloneFrom: "source-handle"})
+					created <- err
+				}()
+
+				Eventually(cloning).Should(BeClosed())
+
+				destroyed := make(chan error, 1)
+				go func() {
+					destroyed <- apiClient.Destroy("source-handle")
+				}()
+
+				Consistently(serverBackend.DestroyCallCount).Should(Equal(0))
+
+				close(release)
+
+				Eventually(created).Should(Receive(BeNil()))
+				Eventually(destroyed).Should(Receive(BeNil()))
+				Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("source-handle"))
+			})
+
+			Context("when the source does not exist", func() {
+				It("returns a ContainerNotFoundError without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{CloneFrom: "missing-handle"})
+					Expect(err).To(Equal(garden.ContainerNotFoundError{Handle: "missing-handle"}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when a rootfs is also given", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						CloneFrom: "source-handle",
+						Image:     garden.ImageRef{URI: "docker:///busybox"},
+					})
+					Expect(err).To(MatchError(server.ErrCloneWithRootFS.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when rootfs layers are given", func() {
+			var lower, upper string
+
+			BeforeEach(func() {
+				lower = filepath.Join(tmpdir, "lower")
+				upper = filepath.Join(tmpdir, "upper")
+				Expect(os.MkdirAll(filepath.Join(lower, "inner"), 0755)).To(Succeed())
+				Expect(os.Mkdir(upper, 0755)).To(Succeed())
+
+				serverBackend.FeaturesReturns(garden.FeatureSet{RootFSLayers: true}, nil)
+			})
+
+			It("passes them to the backend", func() {
+				layers := &garden.RootFSLayers{Lower: []string{lower}, Upper: upper}
+
+				_, err := apiClient.Create(garden.ContainerSpec{RootFSLayers: layers})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateArgsForCall(0).RootFSLayers).To(Equal(layers))
+			})
+
+			It("allows the upper layer to be left to the backend", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					RootFSLayers: &garden.RootFSLayers{Lower: []string{lower}},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("when a rootfs is also given", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						Image:        garden.ImageRef{URI: "docker:///busybox"},
+						RootFSLayers: &garden.RootFSLayers{Lower: []string{lower}},
+					})
+					Expect(err).To(MatchError(server.ErrLayersWithRootFS.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when no lower layer is given", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						RootFSLayers: &garden.RootFSLayers{Upper: upper},
+					})
+					Expect(err).To(MatchError("rootfs layers must include at least one lower layer"))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when a layer does not exist", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						RootFSLayers: &garden.RootFSLayers{Lower: []string{"/path/does/not/exist"}},
+					})
+					Expect(err).To(MatchError("invalid rootfs layer /path/does/not/exist: not an existing directory"))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when layers overlap", func() {
+				It("returns an error without creating the container", func() {
+					inner := filepath.Join(lower, "inner")
+
+					_, err := apiClient.Create(garden.ContainerSpec{
+						RootFSLayers: &garden.RootFSLayers{Lower: []string{lower}, Upper: inner},
+					})
+					Expect(err).To(MatchError(fmt.Sprintf("rootfs layers %s and %s overlap", lower, inner)))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+
+				It("rejects the same layer given twice", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						RootFSLayers: &garden.RootFSLayers{Lower: []string{lower, lower + "/"}},
+					})
+					Expect(err).To(HaveOccurred())
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend does not support rootfs layers", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, nil)
+				})
+
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						RootFSLayers: &garden.RootFSLayers{Lower: []string{lower}},
+					})
+					Expect(err).To(MatchError(server.ErrRootFSLayersNotSupported.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend's features cannot be read", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, errors.New("oh no"))
+				})
+
+				It("returns the error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						RootFSLayers: &garden.RootFSLayers{Lower: []string{lower}},
+					})
+					Expect(err).To(MatchError("oh no"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a host bind mount's source does not exist", func() {
+			It("returns a BindMountError naming the mount without creating the container", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					BindMounts: []garden.BindMount{
+						{
+							SrcPath: os.TempDir(),
+							DstPath: "/good/dst",
+							Origin:  garden.BindMountOriginHost,
+						},
+						{
+							SrcPath: "/path/does/not/exist",
+							DstPath: "/bad/dst",
+							Mode:    garden.BindMountModeRW,
+							Origin:  garden.BindMountOriginHost,
+						},
+					},
+				})
+				Expect(err).To(MatchError(garden.BindMountError{
+					SrcPath: "/path/does/not/exist",
+					DstPath: "/bad/dst",
+					Cause:   "source path does not exist",
+				}))
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a bind mount's destination is not absolute", func() {
+			It("returns a BindMountError naming the mount without creating the container", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					BindMounts: []garden.BindMount{
+						{
+							SrcPath: os.TempDir(),
+							DstPath: "relative/dst",
+							Origin:  garden.BindMountOriginHost,
+						},
+					},
+				})
+				Expect(err).To(MatchError(garden.BindMountError{
+					SrcPath: os.TempDir(),
+					DstPath: "relative/dst",
+					Cause:   "destination path must be absolute",
+				}))
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a bind mount's destination climbs out with ..", func() {
+			It("returns a BindMountError naming the mount without creating the container", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					BindMounts: []garden.BindMount{
+						{
+							SrcPath: "/in/the/container",
+							DstPath: "/var/../../etc",
+							Origin:  garden.BindMountOriginContainer,
+						},
+					},
+				})
+				Expect(err).To(MatchError(garden.BindMountError{
+					SrcPath: "/in/the/container",
+					DstPath: "/var/../../etc",
+					Cause:   "destination path must not contain ..",
+				}))
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+			})
+
+			It("accepts names which merely contain dots", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					BindMounts: []garden.BindMount{
+						{
+							SrcPath: os.TempDir(),
+							DstPath: "/var/..data",
+							Mode:    garden.BindMountModeRW,
+							Origin:  garden.BindMountOriginHost,
+						},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when a bind mount's propagation is given", func() {
+			It("passes it to the backend", func() {
+				mount := garden.BindMount{
+					SrcPath:     os.TempDir(),
+					DstPath:     "/shared",
+					Mode:        garden.BindMountModeRW,
+					Origin:      garden.BindMountOriginHost,
+					Propagation: garden.BindMountPropagationSlave,
+				}
+
+				_, err := apiClient.Create(garden.ContainerSpec{BindMounts: []garden.BindMount{mount}})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateArgsForCall(0).BindMounts).To(Equal([]garden.BindMount{mount}))
+			})
+
+			Context("when it is unknown", func() {
+				It("returns a BindMountError without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						BindMounts: []garden.BindMount{
+							{
+								SrcPath:     "/in/the/container",
+								DstPath:     "/shared",
+								Mode:        garden.BindMountModeRW,
+								Origin:      garden.BindMountOriginContainer,
+								Propagation: garden.BindMountPropagation(7),
+							},
+						},
+					})
+					Expect(err).To(MatchError(garden.BindMountError{
+						SrcPath: "/in/the/container",
+						DstPath: "/shared",
+						Cause:   "unknown propagation",
+					}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a bind mount is read-only", func() {
+			mount := garden.BindMount{
+				SrcPath: os.TempDir(),
+				DstPath: "/shared",
+				Mode:    garden.BindMountModeRO,
+				Origin:  garden.BindMountOriginHost,
+			}
+
+			Context("and the backend enforces read-only bind mounts", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{ReadOnlyBindMounts: true}, nil)
+				})
+
+				It("creates the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{BindMounts: []garden.BindMount{mount}})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(serverBackend.CreateArgsForCall(0).BindMounts).To(Equal([]garden.BindMount{mount}))
+				})
+			})
+
+			Context("and the backend cannot enforce read-only bind mounts", func() {
+				It("returns a BindMountError without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{BindMounts: []garden.BindMount{mount}})
+					Expect(err).To(MatchError(garden.BindMountError{
+						SrcPath: os.TempDir(),
+						DstPath: "/shared",
+						Cause:   "read-only bind mounts are not enforced by the backend",
+					}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+
+				It("still creates containers with read-write bind mounts", func() {
+					rwMount := mount
+					rwMount.Mode = garden.BindMountModeRW
+
+					_, err := apiClient.Create(garden.ContainerSpec{BindMounts: []garden.BindMount{rwMount}})
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("and the backend's features cannot be determined", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, errors.New("oh no"))
+				})
+
+				It("returns the error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{BindMounts: []garden.BindMount{mount}})
+					Expect(err).To(MatchError("oh no"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when scratch volumes are given", func() {
+			BeforeEach(func() {
+				serverBackend.FeaturesReturns(garden.FeatureSet{ScratchVolumes: true}, nil)
+			})
+
+			It("passes them to the backend", func() {
+				volumes := []garden.ScratchVolume{
+					{Path: "/scratch", SizeInBytes: 1024 * 1024},
+					{Path: "/tmp", SizeInBytes: 4096, Medium: garden.ScratchVolumeMediumMemory},
+				}
+
+				_, err := apiClient.Create(garden.ContainerSpec{ScratchVolumes: volumes})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateArgsForCall(0).ScratchVolumes).To(Equal(volumes))
+			})
+
+			Context("when a volume has no size limit", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						ScratchVolumes: []garden.ScratchVolume{{Path: "/scratch"}},
+					})
+					Expect(err).To(MatchError("invalid scratch volume /scratch: size must be given"))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when a volume's path is relative", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						ScratchVolumes: []garden.ScratchVolume{{Path: "scratch", SizeInBytes: 4096}},
+					})
+					Expect(err).To(MatchError("invalid scratch volume scratch: path must be absolute"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when a volume's medium is unknown", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						ScratchVolumes: []garden.ScratchVolume{{Path: "/scratch", SizeInBytes: 4096, Medium: 7}},
+					})
+					Expect(err).To(MatchError("invalid scratch volume /scratch: unknown medium"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when a volume's path is used by a bind mount", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						BindMounts: []garden.BindMount{
+							{SrcPath: os.TempDir(), DstPath: "/scratch/", Origin: garden.BindMountOriginHost},
+						},
+						ScratchVolumes: []garden.ScratchVolume{{Path: "/scratch", SizeInBytes: 4096}},
+					})
+					Expect(err).To(MatchError("invalid scratch volume /scratch: path is already mounted"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when two volumes share a path", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						ScratchVolumes: []garden.ScratchVolume{
+							{Path: "/scratch", SizeInBytes: 4096},
+							{Path: "/scratch", SizeInBytes: 4096, Medium: garden.ScratchVolumeMediumMemory},
+						},
+					})
+					Expect(err).To(MatchError("invalid scratch volume /scratch: path is already mounted"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend does not support scratch volumes", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, nil)
+				})
+
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						ScratchVolumes: []garden.ScratchVolume{{Path: "/scratch", SizeInBytes: 4096}},
+					})
+					Expect(err).To(MatchError(server.ErrScratchVolumesNotSupported.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend's features cannot be read", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, errors.New("oh no"))
+				})
+
+				It("returns the error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						ScratchVolumes: []garden.ScratchVolume{{Path: "/scratch", SizeInBytes: 4096}},
+					})
+					Expect(err).To(MatchError("oh no"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when sysctls are given", func() {
+			It("passes them to the backend", func() {
+				sysctls := map[string]string{
+					"net.core.somaxconn": "1024",
+					"fs.mqueue.msg_max":  "100",
+					"kernel.shmmax":      "68719476736",
+				}
+
+				_, err := apiClient.Create(garden.ContainerSpec{Sysctls: sysctls})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateArgsForCall(0).Sysctls).To(Equal(sysctls))
+			})
+
+			Context("when a sysctl is not namespaced", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						Sysctls: map[string]string{"kernel.panic": "10"},
+					})
+					Expect(err).To(MatchError("sysctl kernel.panic is not namespaced, so cannot be set for a container"))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a cgroup parent is given", func() {
+			BeforeEach(func() {
+				serverBackend.FeaturesReturns(garden.FeatureSet{CgroupParent: true}, nil)
+			})
+
+			It("passes a systemd slice to the backend", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{CgroupParent: "tenants-acme.slice"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateArgsForCall(0).CgroupParent).To(Equal("tenants-acme.slice"))
+			})
+
+			It("passes a cgroupfs path to the backend", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{CgroupParent: "/tenants/acme"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateArgsForCall(0).CgroupParent).To(Equal("/tenants/acme"))
+			})
+
+			Context("when it is not a valid slice name", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{CgroupParent: "tenants--acme.slice"})
+					Expect(err).To(MatchError(`cgroup parent "tenants--acme.slice" is not a valid systemd slice name`))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when it is a relative or unclean path", func() {
+				It("returns an error without creating the container", func() {
+					for _, parent := range []string{"tenants/acme", "/tenants/../../acme", "/"} {
+						_, err := apiClient.Create(garden.ContainerSpec{CgroupParent: parent})
+						Expect(err).To(MatchError(ContainSubstring("must be a systemd slice or a clean absolute path")))
+						Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+					}
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend does not support cgroup parents", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, nil)
+				})
+
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{CgroupParent: "tenants-acme.slice"})
+					Expect(err).To(MatchError(server.ErrCgroupParentNotSupported.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend's features cannot be read", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, errors.New("oh no"))
+				})
+
+				It("returns the error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{CgroupParent: "/tenants/acme"})
+					Expect(err).To(MatchError("oh no"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a cpuset is given", func() {
+			It("passes it to the backend", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Limits: garden.Limits{CPU: garden.CPULimits{CPUSet: "0"}},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.CreateArgsForCall(0).Limits.CPU.CPUSet).To(Equal("0"))
+			})
+
+			Context("when it is malformed", func() {
+				It("returns an error without creating the container", func() {
+					for _, cpuset := range []string{"0-", "3-1", "a", "0,,1"} {
+						_, err := apiClient.Create(garden.ContainerSpec{
+							Limits: garden.Limits{CPU: garden.CPULimits{CPUSet: cpuset}},
+						})
+						Expect(err).To(MatchError(ContainSubstring("is not a cpu or range of cpus")))
+						Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+					}
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when a cpu in it is not online on the host", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						Limits: garden.Limits{CPU: garden.CPULimits{CPUSet: "0,65000"}},
+					})
+					Expect(err).To(MatchError(`invalid cpuset "0,65000": cpu 65000 is not online on this host`))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a syslog endpoint is given", func() {
+			It("returns an error without creating the container if it is invalid", func() {
+				for _, spec := range []garden.SyslogSpec{
+					{Network: "unix", Address: "127.0.0.1:514"},
+					{Address: "127.0.0.1"},
+					{Address: "127.0.0.1:514", Facility: "nope"},
+					{Address: "127.0.0.1:514", Tag: "my app"},
+				} {
+					spec := spec
+					_, err := apiClient.Create(garden.ContainerSpec{Syslog: &spec})
+					Expect(err).To(MatchError(HavePrefix("syslog ")))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+				}
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+			})
+
+			It("returns an error without creating the container if syslog forwarding is not enabled", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{Syslog: &garden.SyslogSpec{Address: "127.0.0.1:514"}})
+				Expect(err).To(MatchError(server.ErrSyslogDisabled.Error()))
+				Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+				Expect(serverBackend.CreateCallCount()).To(Equal(0))
+			})
+
+			Context("when the server forwards to the endpoint", func() {
+				BeforeEach(func() {
+					apiServer.SetSyslogEndpoints([]string{"127.0.0.1:514"})
+				})
+
+				It("creates the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{Syslog: &garden.SyslogSpec{Address: "127.0.0.1:514"}})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(1))
+				})
+
+				It("returns an error without creating the container for any other endpoint", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{Syslog: &garden.SyslogSpec{Address: "127.0.0.1:515"}})
+					Expect(err).To(MatchError(`syslog address "127.0.0.1:515" is not one this server forwards to`))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when DNS settings are given", func() {
+			BeforeEach(func() {
+				serverBackend.FeaturesReturns(garden.FeatureSet{DNS: true}, nil)
+			})
+
+			It("passes them to the backend", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					DNSServers: []string{"10.0.0.2", "fd00::53"},
+					DNSSearch:  []string{"service.internal", "example.com."},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				spec := serverBackend.CreateArgsForCall(0)
+				Expect(spec.DNSServers).To(Equal([]string{"10.0.0.2", "fd00::53"}))
+				Expect(spec.DNSSearch).To(Equal([]string{"service.internal", "example.com."}))
+			})
+
+			Context("when a DNS server is not an IP address", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						DNSServers: []string{"ns1.example.com"},
+					})
+					Expect(err).To(MatchError(`dns server "ns1.example.com" is not an IP address`))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when a search domain is malformed", func() {
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						DNSSearch: []string{"bad domain\nnameserver 1.2.3.4"},
+					})
+					Expect(err).To(MatchError(`dns search domain "bad domain\nnameserver 1.2.3.4" is not a valid domain name`))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+
+				It("rejects labels starting with a hyphen", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						DNSSearch: []string{"-bad.example.com"},
+					})
+					Expect(err).To(HaveOccurred())
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend does not support DNS settings", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, nil)
+				})
+
+				It("returns an error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						DNSServers: []string{"10.0.0.2"},
+					})
+					Expect(err).To(MatchError(server.ErrDNSNotSupported.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend's features cannot be read", func() {
+				BeforeEach(func() {
+					serverBackend.FeaturesReturns(garden.FeatureSet{}, errors.New("oh no"))
+				})
+
+				It("returns the error without creating the container", func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						DNSSearch: []string{"service.internal"},
+					})
+					Expect(err).To(MatchError("oh no"))
+
+					Expect(serverBackend.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a grace time is not given", func() {
+			It("defaults it to the server's grace time", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Handle: "some-handle",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				spec := serverBackend.CreateArgsForCall(0)
+				Expect(spec.GraceTime).To(Equal(serverContainerGraceTime))
+			})
+		})
+
+		Context("when creating the container fails", func() {
+			BeforeEach(func() {
+				serverBackend.CreateReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Handle: "some-handle",
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when creating the container fails with a ServiceUnavailableError", func() {
+			var err error
+
+			BeforeEach(func() {
+				serverBackend.CreateReturns(nil, garden.NewServiceUnavailableError("special error"))
+
+				_, err = apiClient.Create(garden.ContainerSpec{
+					Handle: "some-handle",
+				})
+			})
+
+			It("client returns an error with a well formed error msg", func() {
+				Expect(err).To(MatchError("special error"))
+			})
+
+			It("client returns an error of type ServiceUnavailableError", func() {
+				_, ok := err.(garden.ServiceUnavailableError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+	})
+
+	Context("and the client sends a destroy request", func() {
+		It("destroys the container", func() {
+			err := apiClient.Destroy("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
+		})
+
+		Context("concurrent with other destroy requests", func() {
+			var destroying chan struct{}
+
+			BeforeEach(func() {
+				destroying = make(chan struct{})
+
+				serverBackend.DestroyStub = func(string) error {
+					close(destroying)
+					time.Sleep(time.Second)
+					return nil
+				}
+			})
+
+			It("only destroys once", func() {
+				go apiClient.Destroy("some-handle")
+
+				<-destroying
+
+				err := apiClient.Destroy("some-handle")
+				Expect(err).To(HaveOccurred())
+
+				Expect(serverBackend.DestroyCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("concurrent with another operation on the same container", func() {
+			var (
+				inInfo      chan struct{}
+				releaseInfo chan struct{}
+			)
+
+			BeforeEach(func() {
+				inInfo = make(chan struct{})
+				releaseInfo = make(chan struct{})
+
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+				fakeContainer.InfoStub = func() (garden.ContainerInfo, error) {
+					close(inInfo)
+					<-releaseInfo
+					return garden.ContainerInfo{}, nil
+				}
+
+				serverBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			It("waits for the operation to complete before destroying", func() {
+				go connection.New("unix", socketPath).Info("some-handle")
+				<-inInfo
+
+				destroyed := make(chan error)
+				go func() {
+					destroyed <- apiClient.Destroy("some-handle")
+				}()
+
+				Consistently(serverBackend.DestroyCallCount).Should(Equal(0))
+
+				close(releaseInfo)
+
+				Eventually(destroyed).Should(Receive(BeNil()))
+				Expect(serverBackend.DestroyCallCount()).To(Equal(1))
+			})
+
+			It("does not wait for operations on other containers", func() {
+				go connection.New("unix", socketPath).Info("some-handle")
+				<-inInfo
+
+				Expect(apiClient.Destroy("other-handle")).To(Succeed())
+
+				close(releaseInfo)
+			})
+		})
+
+		Context("concurrent with a process being run or attached to", func() {
+			var (
+				inLookup      chan struct{}
+				releaseLookup chan struct{}
+			)
+
+			BeforeEach(func() {
+				inLookup = make(chan struct{})
+				releaseLookup = make(chan struct{})
+
+				process := new(fakes.FakeProcess)
+				process.IDReturns("some-process-id")
+				process.WaitStub = func() (int, error) {
+					select {}
+				}
+
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+				fakeContainer.RunReturns(process, nil)
+				fakeContainer.AttachReturns(process, nil)
+
+				var once sync.Once
+				serverBackend.LookupStub = func(string) (garden.Container, error) {
+					once.Do(func() { close(inLookup) })
+					<-releaseLookup
+					return fakeContainer, nil
+				}
+			})
+
+			It("waits for the process to be run before destroying", func() {
+				go connection.New("unix", socketPath).Run("some-handle", garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+				<-inLookup
+
+				destroyed := make(chan error)
+				go func() {
+					destroyed <- apiClient.Destroy("some-handle")
+				}()
+
+				Consistently(serverBackend.DestroyCallCount).Should(Equal(0))
+
+				close(releaseLookup)
+
+				Eventually(destroyed).Should(Receive(BeNil()))
+			})
+
+			It("waits for the process to be attached to before destroying", func() {
+				go connection.New("unix", socketPath).Attach("some-handle", "some-process-id", garden.ProcessIO{})
+				<-inLookup
+
+				destroyed := make(chan error)
+				go func() {
+					destroyed <- apiClient.Destroy("some-handle")
+				}()
+
+				Consistently(serverBackend.DestroyCallCount).Should(Equal(0))
+
+				close(releaseLookup)
+
+				Eventually(destroyed).Should(Receive(BeNil()))
+			})
+		})
+
+		Context("when the container cannot be found", func() {
+			var theError = garden.ContainerNotFoundError{Handle: "some-handle"}
+
+			BeforeEach(func() {
+				serverBackend.DestroyReturns(theError)
+			})
+
+			It("returns an ContainerNotFoundError", func() {
+				err := apiClient.Destroy("some-handle")
+				Expect(err).To(MatchError(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+		})
+
+		Context("when destroying the container fails", func() {
+			var theError = errors.New("o no")
+
+			BeforeEach(func() {
+				serverBackend.DestroyReturns(theError)
+			})
+
+			It("returns an error with the same message", func() {
+				err := apiClient.Destroy("some-handle")
+				Expect(err).To(MatchError("o no"))
+			})
+
+			Context("and destroying is attempted again", func() {
+				BeforeEach(func() {
+					err := apiClient.Destroy("some-handle")
+					Expect(err).To(HaveOccurred())
+
+					serverBackend.DestroyReturns(nil)
+				})
+
+				It("tries to destroy again", func() {
+					err := apiClient.Destroy("some-handle")
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
+				})
+			})
+		})
+	})
+
+	Context("and the client sends a rename request", func() {
+		var renamed *fakes.FakeContainer
+
+		BeforeEach(func() {
+			original := new(fakes.FakeContainer)
+			original.HandleReturns("some-handle")
+
+			renamed = new(fakes.FakeContainer)
+			renamed.HandleReturns("new-handle")
+
+			serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+				switch {
+				case handle == "some-handle" && serverBackend.RenameCallCount() == 0:
+					return original, nil
+				case handle == "new-handle" && serverBackend.RenameCallCount() > 0:
+					return renamed, nil
+				}
+
+				return nil, garden.ContainerNotFoundError{Handle: handle}
+			}
+		})
+
+		It("renames the container in the backend", func() {
+			err := apiClient.Rename("some-handle", "new-handle")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(serverBackend.RenameCallCount()).To(Equal(1))
+			oldHandle, newHandle := serverBackend.RenameArgsForCall(0)
+			Expect(oldHandle).To(Equal("some-handle"))
+			Expect(newHandle).To(Equal("new-handle"))
+		})
+
+		Context("when the container has a grace time", func() {
+			BeforeEach(func() {
+				serverBackend.GraceTimeReturns(100 * time.Millisecond)
+			})
+
+			It("reaps the container by its new handle", func() {
+				err := apiClient.Rename("some-handle", "new-handle")
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(serverBackend.DestroyCallCount).Should(Equal(1))
+				Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("new-handle"))
+			})
+		})
+
+		Context("when the new handle is already taken", func() {
+			It("returns a HandleConflictError without renaming", func() {
+				err := apiClient.Rename("some-handle", "some-handle")
+				Expect(err).To(MatchError(garden.HandleConflictError{Handle: "some-handle"}))
+
+				Expect(serverBackend.RenameCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a different container has the new handle", func() {
+			BeforeEach(func() {
+				serverBackend.LookupReturns(new(fakes.FakeContainer), nil)
+				serverBackend.LookupStub = nil
+			})
+
+			It("returns a HandleConflictError without renaming", func() {
+				err := apiClient.Rename("some-handle", "new-handle")
+				Expect(err).To(MatchError(garden.HandleConflictError{Handle: "new-handle"}))
+
+				Expect(serverBackend.RenameCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the container cannot be found", func() {
+			It("returns a ContainerNotFoundError", func() {
+				err := apiClient.Rename("missing-handle", "new-handle")
+				Expect(err).To(MatchError(garden.ContainerNotFoundError{Handle: "missing-handle"}))
+
+				Expect(serverBackend.RenameCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when renaming fails", func() {
+			BeforeEach(func() {
+				serverBackend.RenameReturns(errors.New("o no"))
+			})
+
+			It("returns an error with the same message", func() {
+				err := apiClient.Rename("some-handle", "new-handle")
+				Expect(err).To(MatchError("o no"))
+			})
+		})
+	})
+
+	Context("and the client lists port mappings", func() {
+		BeforeEach(func() {
+			c1 := new(fakes.FakeContainer)
+			c1.HandleReturns("some-handle")
+
+			c2 := new(fakes.FakeContainer)
+			c2.HandleReturns("another-handle")
+
+			c3 := new(fakes.FakeContainer)
+			c3.HandleReturns("destroyed-handle")
+
+			serverBackend.ContainersReturns([]garden.Container{c1, c2, c3}, nil)
+			serverBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+				"some-handle": {
+					Info: garden.ContainerInfo{
+						MappedPorts: []garden.PortMapping{
+							{HostPort: 61001, ContainerPort: 8080},
+							{HostPort: 61002, ContainerPort: 2222},
+						},
+					},
+				},
+				"another-handle": {
+					Info: garden.ContainerInfo{},
+				},
+				"destroyed-handle": {
+					Err: garden.NewError("unknown handle: destroyed-handle"),
+				},
+			}, nil)
+		})
+
+		It("returns the port mappings of every container from a single bulk info", func() {
+			mappings, err := connection.New("unix", socketPath).ListPortMappings()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mappings).To(Equal(map[string][]garden.PortMapping{
+				"some-handle": {
+					{HostPort: 61001, ContainerPort: 8080},
+					{HostPort: 61002, ContainerPort: 2222},
+				},
+				"another-handle": nil,
+			}))
+
+			Expect(serverBackend.BulkInfoCallCount()).To(Equal(1))
+			Expect(serverBackend.BulkInfoArgsForCall(0)).To(ConsistOf("some-handle", "another-handle", "destroyed-handle"))
+		})
+
+		Context("when listing the containers fails", func() {
+			BeforeEach(func() {
+				serverBackend.ContainersReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, err := connection.New("unix", socketPath).ListPortMappings()
+				Expect(err).To(MatchError("oh no!"))
+			})
+		})
+	})
+
+	Context("and the client sets a property on several containers", func() {
+		var c1, c2 *fakes.FakeContainer
+
+		BeforeEach(func() {
+			c1 = new(fakes.FakeContainer)
+			c1.HandleReturns("some-handle")
+
+			c2 = new(fakes.FakeContainer)
+			c2.HandleReturns("another-handle")
+			c2.SetPropertyReturns(errors.New("oh no!"))
+
+			serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+				switch handle {
+				case "some-handle":
+					return c1, nil
+				case "another-handle":
+					return c2, nil
+				default:
+					return nil, garden.ContainerNotFoundError{Handle: handle}
+				}
+			}
+		})
+
+		It("sets the property on each container and reports the failures", func() {
+			errs, err := connection.New("unix", socketPath).SetPropertyForAll(
+				"deployment_id", "some-deployment",
+				[]string{"some-handle", "another-handle", "missing-handle"},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(errs).To(HaveLen(2))
+			Expect(errs["another-handle"]).To(MatchError("oh no!"))
+			Expect(errs["missing-handle"]).To(Equal(garden.ContainerNotFoundError{Handle: "missing-handle"}))
+
+			Expect(c1.SetPropertyCallCount()).To(Equal(1))
+			name, value := c1.SetPropertyArgsForCall(0)
+			Expect(name).To(Equal("deployment_id"))
+			Expect(value).To(Equal("some-deployment"))
+
+			Expect(c2.SetPropertyCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("and the client destroys containers by property", func() {
+		var c1, c2 *fakes.FakeContainer
+
+		BeforeEach(func() {
+			c1 = new(fakes.FakeContainer)
+			c1.HandleReturns("some-handle")
+
+			c2 = new(fakes.FakeContainer)
+			c2.HandleReturns("another-handle")
+
+			serverBackend.ContainersReturns([]garden.Container{c1, c2}, nil)
+			serverBackend.DestroyStub = func(handle string) error {
+				if handle == "another-handle" {
+					return errors.New("oh no!")
+				}
+
+				return nil
+			}
+		})
+
+		It("destroys every matching container and reports the failures", func() {
+			destroyed, errs, err := connection.New("unix", socketPath).DestroyByProperties(garden.Properties{"app": "some-app"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(destroyed).To(Equal([]string{"some-handle"}))
+			Expect(errs).To(HaveLen(1))
+			Expect(errs["another-handle"]).To(MatchError("oh no!"))
+
+			last := serverBackend.ContainersCallCount() - 1
+			Expect(serverBackend.ContainersArgsForCall(last)).To(Equal(garden.Properties{"app": "some-app"}))
+
+			Expect(serverBackend.DestroyCallCount()).To(Equal(2))
+			Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
+			Expect(serverBackend.DestroyArgsForCall(1)).To(Equal("another-handle"))
+		})
+
+		Context("when no properties are given", func() {
+			It("returns an error without destroying anything", func() {
+				_, _, err := connection.New("unix", socketPath).DestroyByProperties(garden.Properties{})
+				Expect(err).To(MatchError(server.ErrNoDestroyProperties.Error()))
+				Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+				Expect(serverBackend.DestroyCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when listing the containers fails", func() {
+			BeforeEach(func() {
+				serverBackend.ContainersReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, _, err := connection.New("unix", socketPath).DestroyByProperties(garden.Properties{"app": "some-app"})
+				Expect(err).To(MatchError("oh no!"))
+			})
+		})
+	})
+
+	Context("and the client sends a ListRequest", func() {
+		BeforeEach(func() {
+			c1 := new(fakes.FakeContainer)
+			c1.HandleReturns("some-handle")
+
+			c2 := new(fakes.FakeContainer)
+			c2.HandleReturns("another-handle")
+
+			c3 := new(fakes.FakeContainer)
+			c3.HandleReturns("super-handle")
+
+			serverBackend.ContainersReturns([]garden.Container{c1, c2, c3}, nil)
+		})
+
+		It("returns the containers from the backend", func() {
+			containers, err := apiClient.Containers(nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(containers).To(HaveLen(3))
+
+			handles := make([]string, 3)
+			for i, c := range containers {
+				handles[i] = c.Handle()
+			}
+
+			Expect(handles).To(ContainElement("some-handle"))
+			Expect(handles).To(ContainElement("another-handle"))
+			Expect(handles).To(ContainElement("super-handle"))
+		})
+
+		It("returns the containers sorted by handle, whatever order the backend lists them in", func() {
+			listHandles := func() []string {
+				containers, err := apiClient.Containers(nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				handles := []string{}
+				for _, c := range containers {
+					handles = append(handles, c.Handle())
+				}
+
+				return handles
+			}
+
+			first := listHandles()
+			Expect(first).To(Equal([]string{"another-handle", "some-handle", "super-handle"}))
+
+			reordered := []garden.Container{}
+			for _, handle := range []string{"super-handle", "some-handle", "another-handle"} {
+				c := new(fakes.FakeContainer)
+				c.HandleReturns(handle)
+				reordered = append(reordered, c)
+			}
+			serverBackend.ContainersReturns(reordered, nil)
+
+			Expect(listHandles()).To(Equal(first))
+		})
+
+		Context("when listing a page at a time", func() {
+			var conn connection.Connection
+
+			BeforeEach(func() {
+				conn = connection.New("unix", socketPath)
+			})
+
+			It("pages through the containers in order", func() {
+				handles, next, err := conn.ListPage(nil, "", 2)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handles).To(Equal([]string{"another-handle", "some-handle"}))
+				Expect(next).ToNot(BeEmpty())
+
+				handles, next, err = conn.ListPage(nil, next, 2)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handles).To(Equal([]string{"super-handle"}))
+				Expect(next).To(BeEmpty())
+			})
+
+			It("resumes after the last handle returned even if it has since been destroyed", func() {
+				_, next, err := conn.ListPage(nil, "", 2)
+				Expect(err).ToNot(HaveOccurred())
+
+				remaining := []garden.Container{}
+				for _, handle := range []string{"another-handle", "super-handle"} {
+					c := new(fakes.FakeContainer)
+					c.HandleReturns(handle)
+					remaining = append(remaining, c)
+				}
+				serverBackend.ContainersReturns(remaining, nil)
+
+				handles, _, err := conn.ListPage(nil, next, 2)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handles).To(Equal([]string{"super-handle"}))
+			})
+
+			It("returns every container when there is no limit", func() {
+				handles, next, err := conn.ListPage(nil, "", 0)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handles).To(HaveLen(3))
+				Expect(next).To(BeEmpty())
+			})
+
+			It("rejects a cursor the server did not return", func() {
+				_, _, err := conn.ListPage(nil, "not a cursor!", 2)
+				Expect(err).To(MatchError(server.ErrInvalidListCursor.Error()))
+				Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+			})
+
+			It("rejects a negative limit", func() {
+				_, _, err := conn.ListPage(nil, "", -1)
+				Expect(err).To(MatchError(server.ErrInvalidListLimit.Error()))
+				Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+			})
+		})
+
+		Context("when getting the containers fails", func() {
+			BeforeEach(func() {
+				serverBackend.ContainersReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, err := apiClient.Containers(nil)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("and the client sends a ListRequest with a state filter", func() {
+			BeforeEach(func() {
+				serverBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+					"some-handle":    {Info: garden.ContainerInfo{State: "stopped"}},
+					"another-handle": {Info: garden.ContainerInfo{State: "active"}},
+					"super-handle":   {Err: garden.NewError("container went away")},
+				}, nil)
+			})
+
+			It("returns only the containers in that state", func() {
+				handles, err := connection.New("unix", socketPath).ListByState(garden.ContainerStateStopped)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handles).To(Equal([]string{"some-handle"}))
+
+				Expect(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).To(BeEmpty())
+				Expect(serverBackend.BulkInfoArgsForCall(0)).To(ConsistOf("some-handle", "another-handle", "super-handle"))
+			})
+
+			Context("when getting the containers' info fails", func() {
+				BeforeEach(func() {
+					serverBackend.BulkInfoReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					_, err := connection.New("unix", socketPath).ListByState(garden.ContainerStateStopped)
+					Expect(err).To(MatchError("oh no!"))
+				})
+			})
+		})
+
+		Context("and the client sends a ListRequest with a property filter", func() {
+			It("forwards the filter to the backend", func() {
+				_, err := apiClient.Containers(garden.Properties{
+					"foo": "bar",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).To(Equal(
+					garden.Properties{
+						"foo": "bar",
+					},
+				))
+			})
+
+			It("should not log the properties", func() {
+				_, err := apiClient.Containers(garden.Properties{
+					"hello": "banana",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				buffer := sink.Buffer()
+				Expect(buffer).ToNot(gbytes.Say("hello"))
+				Expect(buffer).ToNot(gbytes.Say("banana"))
+			})
+
+			Context("when getting the containers fails", func() {
+				BeforeEach(func() {
+					serverBackend.ContainersReturns(nil, errors.New("oh no!"))
+				})
+
+				It("should not log the properties", func() {
+					apiClient.Containers(garden.Properties{
+						"hello": "banana",
+					})
+
+					buffer := sink.Buffer()
+					Expect(buffer).ToNot(gbytes.Say("hello"))
+					Expect(buffer).ToNot(gbytes.Say("banana"))
+				})
+			})
+
+		})
+	})
+
+	Describe("getting a container's spec", func() {
+		var (
+			fakeContainer *fakes.FakeContainer
+			spec          garden.ContainerSpec
+		)
+
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+			serverBackend.LookupReturns(fakeContainer, nil)
+
+			spec = garden.ContainerSpec{
+				RootFSPath: "docker:///busybox",
+				Image:      garden.ImageRef{URI: "docker:///busybox", Username: "some-user", Password: "some-password"},
+				Network:    "10.0.0.0/24",
+				BindMounts: []garden.BindMount{
+					{SrcPath: tmpdir, DstPath: "/var/data", Mode: garden.BindMountModeRW},
+				},
+				Properties: garden.Properties{"app": "web"},
+				Env:        []string{"PORT=8080", "API_TOKEN=secret"},
+				GraceTime:  time.Minute,
+			}
+		})
+
+		rename := func() {
+			renamed := new(fakes.FakeContainer)
+			renamed.HandleReturns("new-handle")
+
+			serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+				switch {
+				case handle == "some-handle" && serverBackend.RenameCallCount() == 0:
+					return fakeContainer, nil
+				case handle == "new-handle" && serverBackend.RenameCallCount() > 0:
+					return renamed, nil
+				}
+
+				return nil, garden.ContainerNotFoundError{Handle: handle}
+			}
+
+			Expect(apiClient.Rename("some-handle", "new-handle")).To(Succeed())
+		}
+
+		It("returns the spec the container was created with, without the image's password", func() {
+			_, err := apiClient.Create(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			created, err := connection.New("unix", socketPath).Spec("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+
+			spec.Handle = "some-handle"
+			spec.Image.Password = ""
+			Expect(created).To(Equal(spec))
+		})
+
+		It("fills in the default grace time", func() {
+			spec.GraceTime = 0
+
+			_, err := apiClient.Create(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			created, err := connection.New("unix", socketPath).Spec("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.GraceTime).To(Equal(serverContainerGraceTime))
+		})
+
+		It("masks the environment as for processes", func() {
+			apiServer.SetProcessEnvMaskedKeys([]string{"*_TOKEN"})
+
+			_, err := apiClient.Create(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			created, err := connection.New("unix", socketPath).Spec("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.Env).To(Equal([]string{"PORT=8080", "API_TOKEN=********"}))
+		})
+
+		It("follows the container when it is renamed", func() {
+			_, err := apiClient.Create(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			rename()
+
+			created, err := connection.New("unix", socketPath).Spec("new-handle")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.Handle).To(Equal("new-handle"))
+			Expect(created.Network).To(Equal("10.0.0.0/24"))
+		})
+
+		It("keeps the environments of the container's processes when it is renamed", func() {
+			_, err := apiClient.Create(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			process := new(fakes.FakeProcess)
+			process.IDReturns("process-handle")
+			fakeContainer.RunReturns(process, nil)
+
+			conn := connection.New("unix", socketPath)
+			_, err = conn.Run("some-handle", garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+			Expect(err).ToNot(HaveOccurred())
+
+			rename()
+
+			env, err := conn.ProcessEnv("new-handle", "process-handle")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(env).To(Equal([]string{"PORT=8080", "API_TOKEN=secret"}))
+		})
+
+		Context("when the container was not created through the server", func() {
+			It("fails", func() {
+				_, err := connection.New("unix", socketPath).Spec("some-handle")
+				Expect(err).To(MatchError(server.ErrContainerSpecUnknown.Error()))
+			})
+		})
+
+		Context("when the container has been destroyed", func() {
+			It("forgets the spec", func() {
+				_, err := apiClient.Create(spec)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(apiClient.Destroy("some-handle")).To(Succeed())
+
+				_, err = connection.New("unix", socketPath).Spec("some-handle")
+				Expect(err).To(MatchError(server.ErrContainerSpecUnknown.Error()))
+			})
+		})
+
+		Context("when a spec directory is set", func() {
+			var specDir string
+
+			BeforeEach(func() {
+				specDir = filepath.Join(tmpdir, "specs")
+				Expect(os.Mkdir(specDir, 0700)).To(Succeed())
+
+				apiServer.SetContainerSpecDir(specDir)
+			})
+
+			It("saves the spec where only the server's user can read it", func() {
+				_, err := apiClient.Create(spec)
+				Expect(err).ToNot(HaveOccurred())
+
+				info, err := os.Stat(filepath.Join(specDir, "some-handle.json"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+				contents, err := ioutil.ReadFile(filepath.Join(specDir, "some-handle.json"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).ToNot(ContainSubstring("some-password"))
+			})
+
+			It("reads back a spec saved before the server started", func() {
+				Expect(ioutil.WriteFile(
+					filepath.Join(specDir, "some-handle.json"),
+					[]byte(`{"handle":"some-handle","network":"10.0.0.0/24"}`),
+					0600,
+				)).To(Succeed())
+
+				created, err := connection.New("unix", socketPath).Spec("some-handle")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(created).To(Equal(garden.ContainerSpec{Handle: "some-handle", Network: "10.0.0.0/24"}))
+			})
+
+			It("gives processes the environment of a container created before the server started", func() {
+				Expect(ioutil.WriteFile(
+					filepath.Join(specDir, "some-handle.json"),
+					[]byte(`{"handle":"some-handle","env":["PATH=/bin","LANG=C"]}`),
+					0600,
+				)).To(Succeed())
+
+				process := new(fakes.FakeProcess)
+				process.IDReturns("process-handle")
+				fakeContainer.RunReturns(process, nil)
+
+				conn := connection.New("unix", socketPath)
+				_, err := conn.Run("some-handle", garden.ProcessSpec{Path: "/some/script", Env: []string{"LANG=en_GB.UTF-8"}}, garden.ProcessIO{})
+				Expect(err).ToNot(HaveOccurred())
+
+				env, err := conn.ProcessEnv("some-handle", "process-handle")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(env).To(Equal([]string{"PATH=/bin", "LANG=en_GB.UTF-8"}))
+			})
+
+			It("moves the spec when the container is renamed", func() {
+				_, err := apiClient.Create(spec)
+				Expect(err).ToNot(HaveOccurred())
+
+				rename()
+
+				Expect(filepath.Join(specDir, "some-handle.json")).ToNot(BeAnExistingFile())
+				Expect(filepath.Join(specDir, "new-handle.json")).To(BeAnExistingFile())
+			})
+
+			It("removes the spec when the container is destroyed", func() {
+				_, err := apiClient.Create(spec)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(apiClient.Destroy("some-handle")).To(Succeed())
+
+				Expect(filepath.Join(specDir, "some-handle.json")).ToNot(BeAnExistingFile())
+			})
+		})
+	})
+
+	Context("when a container has been created", func() {
+		var (
+			container garden.Container
+			graceTime time.Duration
+
+			fakeContainer *fakes.FakeContainer
+		)
+
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+			serverBackend.LookupReturns(fakeContainer, nil)
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			container, err = apiClient.Create(garden.ContainerSpec{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		// renameContainer renames the container, after which the backend only
+		// finds it by its new handle
+		renameContainer := func(newHandle string) {
+			serverBackend.RenameStub = func(string, string) error {
+				fakeContainer.HandleReturns(newHandle)
+				return nil
+			}
+
+			serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+				if handle == fakeContainer.Handle() {
+					return fakeContainer, nil
+				}
+
+				return nil, garden.ContainerNotFoundError{Handle: handle}
+			}
+
+			Expect(apiClient.Rename(fakeContainer.Handle(), newHandle)).To(Succeed())
+		}
+
+		itResetsGraceTimeWhenHandling := func(fn func(time.Duration)) {
+			graceTime := 500 * time.Millisecond
+
+			BeforeEach(func() {
+				serverBackend.GraceTimeReturns(graceTime)
+			})
+
+			It("resets grace time when handling", func() {
+				fn(graceTime * 2)
+				Expect(serverBackend.DestroyCallCount()).To(Equal(0))
+				Eventually(serverBackend.DestroyCallCount, graceTime+(1000*time.Millisecond)).Should(Equal(1))
+			})
+		}
+
+		itFailsWhenTheContainerIsNotFound := func(example func() error) {
+			Context("when the container is not found", func() {
+				It("fails", func() {
+					serverBackend.LookupReturns(nil, errors.New("not found"))
+					Expect(example()).To(MatchError("not found"))
+				})
+			})
+		}
+
+		Describe("stopping", func() {
+			It("stops the container and sends a StopResponse", func() {
+				err := container.Stop(true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.StopArgsForCall(0)).To(Equal(true))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.Stop(true)
+			})
+
+			Context("when stopping the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StopReturns(errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					err := container.Stop(true)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.StopStub = func(_ bool) error { time.Sleep(timeToSleep); return nil }
+				container.Stop(false)
+			})
+		})
+
+		Describe("checkpointing", func() {
+			It("checkpoints the container to the image path", func() {
+				err := container.Checkpoint("/var/checkpoints/some-handle")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.CheckpointCallCount()).To(Equal(1))
+				Expect(fakeContainer.CheckpointArgsForCall(0)).To(Equal("/var/checkpoints/some-handle"))
+			})
+
+			Context("when the image path is relative", func() {
+				It("fails without checkpointing", func() {
+					err := container.Checkpoint("checkpoints/some-handle")
+					Expect(err).To(MatchError(server.ErrRelativeCheckpointPath.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.CheckpointCallCount()).To(Equal(0))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.Checkpoint("/var/checkpoints/some-handle")
+			})
+
+			Context("when checkpointing the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CheckpointReturns(errors.New("criu dump failed"))
+				})
+
+				It("returns an error", func() {
+					err := container.Checkpoint("/var/checkpoints/some-handle")
+					Expect(err).To(MatchError("criu dump failed"))
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.CheckpointStub = func(string) error { time.Sleep(timeToSleep); return nil }
+				container.Checkpoint("/var/checkpoints/some-handle")
+			})
+		})
+
+		Describe("restoring", func() {
+			It("restores the container from the image path", func() {
+				err := container.Restore("/var/checkpoints/some-handle")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.RestoreCallCount()).To(Equal(1))
+				Expect(fakeContainer.RestoreArgsForCall(0)).To(Equal("/var/checkpoints/some-handle"))
+			})
+
+			Context("when the image path is relative", func() {
+				It("fails without restoring", func() {
+					err := container.Restore("checkpoints/some-handle")
+					Expect(err).To(MatchError(server.ErrRelativeCheckpointPath.Error()))
+
+					Expect(fakeContainer.RestoreCallCount()).To(Equal(0))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.Restore("/var/checkpoints/some-handle")
+			})
+
+			Context("when restoring the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.RestoreReturns(errors.New("criu restore failed"))
+				})
+
+				It("returns an error", func() {
+					err := container.Restore("/var/checkpoints/some-handle")
+					Expect(err).To(MatchError("criu restore failed"))
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.RestoreStub = func(string) error { time.Sleep(timeToSleep); return nil }
+				container.Restore("/var/checkpoints/some-handle")
+			})
+		})
+
+		Describe("syncing", func() {
+			It("syncs the container's filesystems", func() {
+				err := container.Sync()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.SyncCallCount()).To(Equal(1))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.Sync()
+			})
+
+			Context("when syncing the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.SyncReturns(errors.New("syncfs failed"))
+				})
+
+				It("returns an error", func() {
+					err := container.Sync()
+					Expect(err).To(MatchError("syncfs failed"))
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.SyncStub = func() error { time.Sleep(timeToSleep); return nil }
+				container.Sync()
+			})
+		})
+
+		Describe("metrics", func() {
+
+			containerMetrics := garden.Metrics{
+				MemoryStat: garden.ContainerMemoryStat{
 					Cache:                   1,
 					Rss:                     2,
 					MappedFile:              3,
@@ -735,1125 +3488,4468 @@ var _ = Describe("When a client connects", func() {
 					TotalUnevictable:        28,
 					TotalUsageTowardLimit:   7, // TotalRss+(TotalCache-TotalInactiveFile)
 				},
-				CPUStat: garden.ContainerCPUStat{
-					Usage:  1,
-					User:   2,
-					System: 3,
+				CPUStat: garden.ContainerCPUStat{
+					Usage:  1,
+					User:   2,
+					System: 3,
+				},
+				DiskStat: garden.ContainerDiskStat{
+					TotalBytesUsed:  1,
+					TotalInodesUsed: 2,
+				},
+			}
+
+			Context("when getting the metrics succeeds", func() {
+				BeforeEach(func() {
+					fakeContainer.MetricsReturns(
+						containerMetrics,
+						nil,
+					)
+				})
+
+				It("returns the metrics from the container", func() {
+					value, err := container.Metrics()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(value).To(Equal(containerMetrics))
+				})
+
+				Context("when the backend reports network stats", func() {
+					backendNetworkStat := garden.ContainerNetworkStat{RxBytes: 100, TxBytes: 200, RxPackets: 2, TxPackets: 3}
+
+					BeforeEach(func() {
+						metrics := containerMetrics
+						metrics.NetworkStat = backendNetworkStat
+						fakeContainer.MetricsReturns(metrics, nil)
+					})
+
+					It("reports them", func() {
+						value, err := container.Metrics()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(value.NetworkStat).To(Equal(backendNetworkStat))
+					})
+
+					It("reports them in bulk metrics too, without looking up the container", func() {
+						serverBackend.BulkMetricsReturns(map[string]garden.ContainerMetricsEntry{
+							"some-handle": {Metrics: garden.Metrics{NetworkStat: backendNetworkStat}},
+						}, nil)
+						lookups := serverBackend.LookupCallCount()
+
+						bulkMetrics, err := apiClient.BulkMetrics([]string{"some-handle"})
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(bulkMetrics["some-handle"].Metrics.NetworkStat).To(Equal(backendNetworkStat))
+						Expect(serverBackend.LookupCallCount()).To(Equal(lookups))
+					})
+				})
+
+				itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+					fakeContainer.MetricsStub = func() (garden.Metrics, error) { time.Sleep(timeToSleep); return garden.Metrics{}, nil }
+					_, err := container.Metrics()
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				itFailsWhenTheContainerIsNotFound(func() error {
+					_, err := container.Metrics()
+					return err
+				})
+			})
+
+			Context("when getting the metrics fails", func() {
+				BeforeEach(func() {
+					fakeContainer.MetricsReturns(garden.Metrics{}, errors.New("o no"))
+				})
+
+				It("returns an error", func() {
+					metrics, err := container.Metrics()
+					Expect(err).To(HaveOccurred())
+					Expect(metrics).To(Equal(garden.Metrics{}))
+				})
+			})
+		})
+
+		Describe("resource usage", func() {
+			BeforeEach(func() {
+				fakeContainer.MetricsReturns(garden.Metrics{
+					MemoryStat:  garden.ContainerMemoryStat{TotalRss: 1024},
+					CPUStat:     garden.ContainerCPUStat{Usage: 1, User: 2, System: 3},
+					DiskStat:    garden.ContainerDiskStat{TotalBytesUsed: 4096},
+					NetworkStat: garden.ContainerNetworkStat{RxBytes: 100, TxBytes: 200},
+				}, nil)
+
+				fakeContainer.CurrentCPULimitsReturns(garden.CPULimits{LimitInShares: 512}, nil)
+				fakeContainer.CurrentMemoryLimitsReturns(garden.MemoryLimits{LimitInBytes: 2048}, nil)
+				fakeContainer.CurrentDiskLimitsReturns(garden.DiskLimits{ByteHard: 8192}, nil)
+				fakeContainer.CurrentBandwidthLimitsReturns(garden.BandwidthLimits{RateInBytesPerSecond: 1000}, nil)
+			})
+
+			It("pairs each resource's usage with its limit", func() {
+				usage, err := connection.New("unix", socketPath).ResourceUsage("some-handle")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(usage).To(Equal(garden.ResourceUsage{
+					CPU: garden.CPUUsage{
+						Usage:  garden.ContainerCPUStat{Usage: 1, User: 2, System: 3},
+						Limits: garden.CPULimits{LimitInShares: 512},
+					},
+					Memory: garden.MemoryUsage{
+						Usage:  garden.ContainerMemoryStat{TotalRss: 1024},
+						Limits: garden.MemoryLimits{LimitInBytes: 2048},
+					},
+					Disk: garden.DiskUsage{
+						Usage:  garden.ContainerDiskStat{TotalBytesUsed: 4096},
+						Limits: garden.DiskLimits{ByteHard: 8192},
+					},
+					Bandwidth: garden.BandwidthUsage{
+						Usage:  garden.ContainerNetworkStat{RxBytes: 100, TxBytes: 200},
+						Limits: garden.BandwidthLimits{RateInBytesPerSecond: 1000},
+					},
+				}))
+			})
+
+			Context("when the backend does not support one of the limits", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentBandwidthLimitsReturns(garden.BandwidthLimits{}, garden.UnsupportedOperationError{Operation: "bandwidth-limits"})
+				})
+
+				It("reports it as zero", func() {
+					usage, err := connection.New("unix", socketPath).ResourceUsage("some-handle")
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(usage.Bandwidth.Limits).To(BeZero())
+					Expect(usage.CPU.Limits).To(Equal(garden.CPULimits{LimitInShares: 512}))
+				})
+			})
+
+			Context("when getting one of the limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentMemoryLimitsReturns(garden.MemoryLimits{}, errors.New("o no"))
+				})
+
+				It("returns the error", func() {
+					_, err := connection.New("unix", socketPath).ResourceUsage("some-handle")
+					Expect(err).To(MatchError("o no"))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := connection.New("unix", socketPath).ResourceUsage("some-handle")
+				return err
+			})
+		})
+
+		Describe("process stats", func() {
+			Context("when getting the process stats succeeds", func() {
+				BeforeEach(func() {
+					fakeContainer.ProcessStatsReturns(map[uint32]garden.ProcessStat{
+						1:  {CPUUsage: 10, CPUUser: 6, CPUSystem: 4, MemoryRSS: 1024},
+						42: {CPUUsage: 20, MemoryRSS: 2048},
+					}, nil)
+				})
+
+				It("returns the stats of each process keyed by pid", func() {
+					stats, err := connection.New("unix", socketPath).ProcessStats("some-handle")
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(stats).To(Equal(map[uint32]garden.ProcessStat{
+						1:  {CPUUsage: 10, CPUUser: 6, CPUSystem: 4, MemoryRSS: 1024},
+						42: {CPUUsage: 20, MemoryRSS: 2048},
+					}))
+				})
+
+				itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+					fakeContainer.ProcessStatsStub = func() (map[uint32]garden.ProcessStat, error) {
+						time.Sleep(timeToSleep)
+						return nil, nil
+					}
+					_, err := container.ProcessStats()
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				itFailsWhenTheContainerIsNotFound(func() error {
+					_, err := container.ProcessStats()
+					return err
+				})
+			})
+
+			Context("when the backend reports a zombie", func() {
+				BeforeEach(func() {
+					fakeContainer.ProcessStatsReturns(map[uint32]garden.ProcessStat{
+						1:  {CPUUsage: 10},
+						42: {CPUUsage: 20, Zombie: true},
+					}, nil)
+					fakeContainer.InfoReturns(garden.ContainerInfo{ZombieProcesses: 1}, nil)
+				})
+
+				It("marks it as a zombie", func() {
+					stats, err := connection.New("unix", socketPath).ProcessStats("some-handle")
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(stats).To(Equal(map[uint32]garden.ProcessStat{
+						1:  {CPUUsage: 10},
+						42: {CPUUsage: 20, Zombie: true},
+					}))
+				})
+
+				It("counts it in the container's info", func() {
+					info, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(info.ZombieProcesses).To(Equal(1))
+				})
+			})
+
+			Context("when getting the process stats fails", func() {
+				BeforeEach(func() {
+					fakeContainer.ProcessStatsReturns(nil, errors.New("o no"))
+				})
+
+				It("returns an error", func() {
+					_, err := container.ProcessStats()
+					Expect(err).To(MatchError("o no"))
+				})
+
+				It("still reports the container's info", func() {
+					info, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(info.ZombieProcesses).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("the uid and gid mappings in effect", func() {
+			mappedInfo := garden.ContainerInfo{
+				UIDMappings: []garden.IDMapping{{ContainerID: 0, HostID: 4294967294, Size: 1}},
+				GIDMappings: []garden.IDMapping{{ContainerID: 0, HostID: 4294967294, Size: 1}},
+			}
+
+			BeforeEach(func() {
+				fakeContainer.InfoReturns(mappedInfo, nil)
+			})
+
+			It("reports the mappings the backend reports in its info", func() {
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(info.UIDMappings).To(Equal(mappedInfo.UIDMappings))
+				Expect(info.GIDMappings).To(Equal(mappedInfo.GIDMappings))
+			})
+
+			It("does not look up the container's processes", func() {
+				_, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.ProcessStatsCallCount()).To(Equal(0))
+			})
+		})
+
+		Describe("properties", func() {
+			Describe("getting all", func() {
+				Context("when getting the properties succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.PropertiesReturns(garden.Properties{"foo": "bar"}, nil)
+					})
+
+					It("returns the properties from the container", func() {
+						value, err := container.Properties()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(value).To(Equal(garden.Properties{"foo": "bar"}))
+					})
+
+					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+						fakeContainer.PropertiesStub = func() (garden.Properties, error) { time.Sleep(timeToSleep); return nil, nil }
+						_, err := container.Properties()
+						Expect(err).ToNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() error {
+						_, err := container.Properties()
+						return err
+					})
+
+					It("should not log any properties", func() {
+						_, err := container.Properties()
+						Expect(err).ToNot(HaveOccurred())
+
+						buffer := sink.Buffer()
+						Expect(buffer).ToNot(gbytes.Say("foo"))
+						Expect(buffer).ToNot(gbytes.Say("bar"))
+					})
+				})
+
+				Context("when getting the properties fails", func() {
+					BeforeEach(func() {
+						fakeContainer.PropertiesReturns(nil, errors.New("o no"))
+					})
+
+					It("returns an error", func() {
+						properties, err := container.Properties()
+						Expect(err).To(HaveOccurred())
+						Expect(properties).To(BeEmpty())
+					})
+				})
+			})
+
+			Describe("getting", func() {
+				Context("when getting the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.PropertyReturns("some-property-value", nil)
+					})
+
+					It("returns the property from the container", func() {
+						value, err := container.Property("some-property")
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(value).To(Equal("some-property-value"))
+
+						Expect(fakeContainer.PropertyCallCount()).To(Equal(1))
+
+						name := fakeContainer.PropertyArgsForCall(0)
+						Expect(name).To(Equal("some-property"))
+					})
+
+					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+						fakeContainer.PropertyStub = func(string) (string, error) { time.Sleep(timeToSleep); return "", nil }
+						_, err := container.Property("some-property")
+						Expect(err).ToNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() error {
+						_, err := container.Property("some-property")
+						return err
+					})
+
+					It("should not log any properties", func() {
+						_, err := container.Property("some-property")
+						Expect(err).ToNot(HaveOccurred())
+
+						buffer := sink.Buffer()
+						Expect(buffer).ToNot(gbytes.Say("some-property"))
+						Expect(buffer).ToNot(gbytes.Say("some-property-value"))
+					})
+				})
+
+				Context("when getting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.PropertyReturns("", errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						value, err := container.Property("some-property")
+						Expect(err).To(HaveOccurred())
+						Expect(value).To(BeZero())
+					})
+				})
+			})
+
+			Describe("setting", func() {
+				Context("when setting the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertyReturns(nil)
+					})
+
+					It("sets the property on the container", func() {
+						err := container.SetProperty("some-property", "some-value")
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fakeContainer.SetPropertyCallCount()).To(Equal(1))
+
+						name, value := fakeContainer.SetPropertyArgsForCall(0)
+						Expect(name).To(Equal("some-property"))
+						Expect(value).To(Equal("some-value"))
+					})
+
+					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+						fakeContainer.SetPropertyStub = func(string, string) error { time.Sleep(timeToSleep); return nil }
+						err := container.SetProperty("some-property", "some-value")
+						Expect(err).ToNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() error {
+						return container.SetProperty("some-property", "some-value")
+					})
+
+					It("should not log any properties", func() {
+						err := container.SetProperty("some-property", "some-value")
+						Expect(err).ToNot(HaveOccurred())
+
+						buffer := sink.Buffer()
+						Expect(buffer).ToNot(gbytes.Say("some-property"))
+						Expect(buffer).ToNot(gbytes.Say("some-value"))
+					})
+				})
+
+				Context("when setting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertyReturns(errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						err := container.SetProperty("some-property", "some-value")
+						Expect(err).To(HaveOccurred())
+					})
+				})
+			})
+
+			Describe("removing", func() {
+				Context("when removing the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(nil)
+					})
+
+					It("returns the property from the container", func() {
+						err := container.RemoveProperty("some-property")
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fakeContainer.RemovePropertyCallCount()).To(Equal(1))
+
+						name := fakeContainer.RemovePropertyArgsForCall(0)
+						Expect(name).To(Equal("some-property"))
+					})
+
+					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+						fakeContainer.RemovePropertyStub = func(string) error { time.Sleep(timeToSleep); return nil }
+						err := container.RemoveProperty("some-property")
+						Expect(err).ToNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() error {
+						return container.RemoveProperty("some-property")
+					})
+
+					It("should not log any properties", func() {
+						err := container.RemoveProperty("some-property")
+						Expect(err).ToNot(HaveOccurred())
+
+						buffer := sink.Buffer()
+						Expect(buffer).ToNot(gbytes.Say("some-property"))
+						Expect(buffer).ToNot(gbytes.Say("some-value"))
+					})
+				})
+
+				Context("when removing the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(errors.New("oh no!"))
+						fakeContainer.PropertiesReturns(garden.Properties{"some-property": "some-value"}, nil)
+					})
+
+					It("returns an error", func() {
+						err := container.RemoveProperty("some-property")
+						Expect(err).To(HaveOccurred())
+					})
+				})
+
+				Context("when the property is not set", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(errors.New("no such property"))
+						fakeContainer.PropertiesReturns(garden.Properties{"other-property": "some-value"}, nil)
+					})
+
+					It("succeeds", func() {
+						err := container.RemoveProperty("some-property")
+						Expect(err).ToNot(HaveOccurred())
+					})
+				})
+
+				Context("when removing the property fails and the properties cannot be checked", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(errors.New("oh no!"))
+						fakeContainer.PropertiesReturns(nil, errors.New("oh no, again!"))
+					})
+
+					It("returns the original error", func() {
+						err := container.RemoveProperty("some-property")
+						Expect(err).To(MatchError("oh no!"))
+					})
+				})
+			})
+		})
+
+		Describe("streaming in", func() {
+			It("streams the file in, waits for completion, and succeeds", func() {
+				data := bytes.NewBufferString("chunk-1;chunk-2;chunk-3;")
+
+				fakeContainer.StreamInStub = func(spec garden.StreamInSpec) error {
+					Expect(spec.Path).To(Equal("/dst/path"))
+					Expect(spec.User).To(Equal("frank"))
+					Expect(ioutil.ReadAll(spec.TarStream)).To(Equal([]byte("chunk-1;chunk-2;chunk-3;")))
+					return nil
+				}
+
+				err := container.StreamIn(garden.StreamInSpec{User: "frank", Path: "/dst/path", TarStream: data})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.StreamInCallCount()).To(Equal(1))
+			})
+
+			Context("when the stream is empty", func() {
+				var streamedIn []byte
+
+				BeforeEach(func() {
+					streamedIn = nil
+					fakeContainer.StreamInStub = func(spec garden.StreamInSpec) error {
+						var err error
+						streamedIn, err = ioutil.ReadAll(spec.TarStream)
+						return err
+					}
+				})
+
+				It("streams an empty tar archive in to the destination", func() {
+					err := container.StreamIn(garden.StreamInSpec{User: "frank", Path: "/dst/path", TarStream: new(bytes.Buffer)})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeContainer.StreamInCallCount()).To(Equal(1))
+					Expect(fakeContainer.StreamInArgsForCall(0).Path).To(Equal("/dst/path"))
+
+					_, err = tar.NewReader(bytes.NewReader(streamedIn)).Next()
+					Expect(err).To(Equal(io.EOF))
+				})
+
+				Context("because there is no stream at all", func() {
+					It("streams an empty tar archive in to the destination", func() {
+						err := container.StreamIn(garden.StreamInSpec{User: "frank", Path: "/dst/path"})
+						Expect(err).ToNot(HaveOccurred())
+
+						_, err = tar.NewReader(bytes.NewReader(streamedIn)).Next()
+						Expect(err).To(Equal(io.EOF))
+					})
+				})
+			})
+
+			Context("when the container's disk quota is exceeded", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamInReturns(garden.QuotaExceededError{Handle: "some-handle", Quota: garden.DiskQuotaBlocks})
+				})
+
+				It("returns a QuotaExceededError", func() {
+					err := container.StreamIn(garden.StreamInSpec{User: "frank", Path: "/dst/path", TarStream: new(bytes.Buffer)})
+					Expect(err).To(Equal(garden.QuotaExceededError{Handle: "some-handle", Quota: garden.DiskQuotaBlocks}))
+				})
+			})
+
+			It("passes a uid:gid pair through to the backend", func() {
+				err := container.StreamIn(garden.StreamInSpec{User: "1000:1001", Path: "/dst/path", TarStream: new(bytes.Buffer)})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.StreamInArgsForCall(0).User).To(Equal("1000:1001"))
+			})
+
+			It("passes the owner through to the backend", func() {
+				err := container.StreamIn(garden.StreamInSpec{User: "frank", Owner: "1000:1001", Path: "/dst/path", TarStream: new(bytes.Buffer)})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.StreamInArgsForCall(0).Owner).To(Equal("1000:1001"))
+			})
+
+			Context("when the owner is not a numeric uid:gid pair", func() {
+				It("fails without streaming in", func() {
+					err := container.StreamIn(garden.StreamInSpec{Owner: "frank", Path: "/dst/path", TarStream: new(bytes.Buffer)})
+					Expect(err).To(MatchError(ContainSubstring(`invalid owner "frank": must be a numeric uid:gid pair`)))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.StreamInCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the user is neither a name nor a uid:gid pair", func() {
+				It("fails without streaming in", func() {
+					err := container.StreamIn(garden.StreamInSpec{User: "1000:staff", Path: "/dst/path", TarStream: new(bytes.Buffer)})
+					Expect(err).To(MatchError(ContainSubstring(`invalid user "1000:staff"`)))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.StreamInCallCount()).To(Equal(0))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.StreamIn(garden.StreamInSpec{Path: "/dst/path"})
+			})
+
+			Context("when copying in to the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamInReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.StreamIn(garden.StreamInSpec{User: "bob", Path: "/dst/path"})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("writing a single file", func() {
+			var conn connection.Connection
+
+			BeforeEach(func() {
+				conn = connection.New("unix", socketPath)
+			})
+
+			It("streams the file in to its directory as a one-entry tar", func() {
+				var (
+					header  *tar.Header
+					content []byte
+				)
+
+				fakeContainer.StreamInStub = func(spec garden.StreamInSpec) error {
+					Expect(spec.Path).To(Equal("/etc/app"))
+
+					tr := tar.NewReader(spec.TarStream)
+
+					var err error
+					header, err = tr.Next()
+					Expect(err).ToNot(HaveOccurred())
+
+					content, err = ioutil.ReadAll(tr)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = tr.Next()
+					Expect(err).To(Equal(io.EOF))
+					return nil
+				}
+
+				err := conn.WriteFile("some-handle", "/etc/app/config.yml", 0640, strings.NewReader("key: value\n"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(header.Name).To(Equal("config.yml"))
+				Expect(header.Mode).To(Equal(int64(0640)))
+				Expect(header.Size).To(Equal(int64(11)))
+				Expect(string(content)).To(Equal("key: value\n"))
+			})
+
+			Context("when the path is relative", func() {
+				It("fails without streaming in", func() {
+					err := conn.WriteFile("some-handle", "etc/app/config.yml", 0640, strings.NewReader("key: value\n"))
+					Expect(err).To(MatchError(server.ErrRelativeFilePath.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.StreamInCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the content is larger than the server allows", func() {
+				BeforeEach(func() {
+					apiServer.SetMaxWriteFileSize(10)
+				})
+
+				It("fails without streaming in", func() {
+					err := conn.WriteFile("some-handle", "/etc/app/config.yml", 0640, strings.NewReader("key: value\n"))
+					Expect(err).To(MatchError("file content exceeds the maximum of 10 bytes"))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.StreamInCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the backend stops reading the tar early", func() {
+				It("stops writing it", func() {
+					fakeContainer.StreamInReturns(errors.New("disk full"))
+
+					err := conn.WriteFile("some-handle", "/etc/app/config.yml", 0640, strings.NewReader("key: value\n"))
+					Expect(err).To(MatchError("disk full"))
+
+					_, err = ioutil.ReadAll(fakeContainer.StreamInArgsForCall(0).TarStream)
+					Expect(err).To(Equal(io.ErrClosedPipe))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return conn.WriteFile("some-handle", "/etc/app/config.yml", 0640, strings.NewReader("key: value\n"))
+			})
+
+			Context("when streaming in fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamInReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := conn.WriteFile("some-handle", "/etc/app/config.yml", 0640, strings.NewReader("key: value\n"))
+					Expect(err).To(MatchError("oh no!"))
+				})
+			})
+		})
+
+		Describe("reading a single file", func() {
+			var conn connection.Connection
+
+			tarOf := func(headers ...*tar.Header) io.ReadCloser {
+				buffer := new(bytes.Buffer)
+				tw := tar.NewWriter(buffer)
+				for _, header := range headers {
+					Expect(tw.WriteHeader(header)).To(Succeed())
+					_, err := tw.Write(make([]byte, header.Size))
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(tw.Close()).To(Succeed())
+				return ioutil.NopCloser(buffer)
+			}
+
+			BeforeEach(func() {
+				conn = connection.New("unix", socketPath)
+			})
+
+			It("streams the raw contents of the file", func() {
+				buffer := new(bytes.Buffer)
+				tw := tar.NewWriter(buffer)
+				Expect(tw.WriteHeader(&tar.Header{Name: "config.yml", Mode: 0644, Size: 11, Typeflag: tar.TypeReg})).To(Succeed())
+				_, err := tw.Write([]byte("key: value\n"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tw.Close()).To(Succeed())
+
+				fakeContainer.StreamOutReturns(ioutil.NopCloser(buffer), nil)
+
+				content, err := conn.ReadFile("some-handle", "/etc/app/config.yml")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ioutil.ReadAll(content)).To(Equal([]byte("key: value\n")))
+				Expect(content.Close()).To(Succeed())
+
+				Expect(fakeContainer.StreamOutArgsForCall(0).Path).To(Equal("/etc/app/config.yml"))
+			})
+
+			Context("when the file does not exist", func() {
+				It("returns a FileNotFoundError", func() {
+					fakeContainer.StreamOutReturns(nil, garden.FileNotFoundError{Path: "/etc/app/config.yml"})
+
+					_, err := conn.ReadFile("some-handle", "/etc/app/config.yml")
+					Expect(err).To(Equal(garden.FileNotFoundError{Path: "/etc/app/config.yml"}))
+				})
+
+				It("returns a FileNotFoundError when the backend streams out nothing", func() {
+					fakeContainer.StreamOutReturns(tarOf(), nil)
+
+					_, err := conn.ReadFile("some-handle", "/etc/app/config.yml")
+					Expect(err).To(Equal(garden.FileNotFoundError{Path: "/etc/app/config.yml"}))
+				})
+
+				It("returns a FileNotFoundError when the backend reports the path does not exist", func() {
+					fakeContainer.StreamOutReturns(nil, fmt.Errorf("streaming out: %w", &os.PathError{Op: "stat", Path: "/etc/app/config.yml", Err: syscall.ENOENT}))
+
+					_, err := conn.ReadFile("some-handle", "/etc/app/config.yml")
+					Expect(err).To(Equal(garden.FileNotFoundError{Path: "/etc/app/config.yml"}))
+				})
+			})
+
+			Context("when the path is a directory", func() {
+				It("returns an IsADirectoryError", func() {
+					fakeContainer.StreamOutReturns(tarOf(&tar.Header{Name: "app/", Mode: 0755, Typeflag: tar.TypeDir}), nil)
+
+					_, err := conn.ReadFile("some-handle", "/etc/app")
+					Expect(err).To(Equal(garden.IsADirectoryError{Path: "/etc/app"}))
+				})
+			})
+
+			Context("when the path is not a regular file", func() {
+				It("fails without streaming its contents", func() {
+					fakeContainer.StreamOutReturns(tarOf(&tar.Header{Name: "config.yml", Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}), nil)
+
+					_, err := conn.ReadFile("some-handle", "/etc/app/config.yml")
+					Expect(err).To(MatchError(server.ErrNotARegularFile.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+				})
+			})
+
+			Context("when the path is relative", func() {
+				It("fails without streaming out", func() {
+					_, err := conn.ReadFile("some-handle", "etc/app/config.yml")
+					Expect(err).To(MatchError(server.ErrRelativeFilePath.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.StreamOutCallCount()).To(Equal(0))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := conn.ReadFile("some-handle", "/etc/app/config.yml")
+				return err
+			})
+		})
+
+		Describe("streaming out", func() {
+			var streamOut io.ReadCloser
+
+			BeforeEach(func() {
+				streamOut = ioutil.NopCloser(bytes.NewBuffer([]byte("hello-world!")))
+			})
+
+			JustBeforeEach(func() {
+				fakeContainer.StreamOutReturns(streamOut, nil)
+			})
+
+			It("streams the bits out and succeeds", func() {
+				reader, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(reader).ToNot(BeZero())
+
+				streamedContent, err := ioutil.ReadAll(reader)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(string(streamedContent)).To(Equal("hello-world!"))
+
+				Expect(fakeContainer.StreamOutArgsForCall(0)).To(Equal(garden.StreamOutSpec{User: "frank", Path: "/src/path"}))
+			})
+
+			It("passes a uid:gid pair through to the backend", func() {
+				_, err := container.StreamOut(garden.StreamOutSpec{User: "0:0", Path: "/src/path"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.StreamOutArgsForCall(0)).To(Equal(garden.StreamOutSpec{User: "0:0", Path: "/src/path"}))
+			})
+
+			Context("when the user is neither a name nor a uid:gid pair", func() {
+				It("fails without streaming out", func() {
+					_, err := container.StreamOut(garden.StreamOutSpec{User: "1000:", Path: "/src/path"})
+					Expect(err).To(MatchError(ContainSubstring(`invalid user "1000:"`)))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.StreamOutCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the connection dies as we're streaming", func() {
+				var closer *closeChecker
+
+				BeforeEach(func() {
+					closer = &closeChecker{}
+
+					streamOut = closer
+				})
+
+				It("closes the backend's stream", func() {
+					reader, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
+					Expect(err).ToNot(HaveOccurred())
+
+					err = reader.Close()
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(closer.Closed).Should(BeTrue())
+				})
+			})
+
+			Context("when include patterns are given", func() {
+				entryNames := func(reader io.Reader) []string {
+					names := []string{}
+					tr := tar.NewReader(reader)
+					for {
+						header, err := tr.Next()
+						if err == io.EOF {
+							return names
+						}
+						Expect(err).ToNot(HaveOccurred())
+						names = append(names, header.Name)
+					}
+				}
+
+				BeforeEach(func() {
+					buffer := new(bytes.Buffer)
+					tw := tar.NewWriter(buffer)
+					for _, name := range []string{"./", "./app.log", "./app/", "./app/debug.txt", "./other.txt"} {
+						header := &tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}
+						if strings.HasSuffix(name, "/") {
+							header.Typeflag = tar.TypeDir
+						}
+						Expect(tw.WriteHeader(header)).To(Succeed())
+					}
+					Expect(tw.Close()).To(Succeed())
+
+					streamOut = ioutil.NopCloser(buffer)
+				})
+
+				It("streams out only the matching entries and what is under them", func() {
+					reader, err := connection.New("unix", socketPath).StreamOutFiltered("some-handle", "/var/log/", []string{"*.log", "app"})
+					Expect(err).ToNot(HaveOccurred())
+					defer reader.Close()
+
+					Expect(entryNames(reader)).To(Equal([]string{"./app.log", "./app/", "./app/debug.txt"}))
+
+					Expect(fakeContainer.StreamOutArgsForCall(0).Path).To(Equal("/var/log/"))
+				})
+
+				It("streams out an empty tar when nothing matches", func() {
+					reader, err := connection.New("unix", socketPath).StreamOutFiltered("some-handle", "/var/log/", []string{"*.gz"})
+					Expect(err).ToNot(HaveOccurred())
+					defer reader.Close()
+
+					Expect(entryNames(reader)).To(BeEmpty())
+				})
+
+				Context("when a pattern is malformed", func() {
+					It("fails without streaming out", func() {
+						_, err := connection.New("unix", socketPath).StreamOutFiltered("some-handle", "/var/log/", []string{"[app"})
+						Expect(err).To(MatchError(server.ErrInvalidIncludePattern.Error()))
+						Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+						Expect(fakeContainer.StreamOutCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.StreamOutStub = func(garden.StreamOutSpec) (io.ReadCloser, error) {
+					time.Sleep(timeToSleep)
+					return gbytes.NewBuffer(), nil
+				}
+				reader, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = ioutil.ReadAll(reader)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
+				return err
+			})
+
+			Context("when streaming out of the container fails", func() {
+				JustBeforeEach(func() {
+					fakeContainer.StreamOutReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					_, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("getting the current bandwidth limits", func() {
+			It("returns the limits returned by the backend", func() {
+				effectiveLimits := garden.BandwidthLimits{
+					RateInBytesPerSecond:      1230,
+					BurstRateInBytesPerSecond: 4560,
+				}
+
+				fakeContainer.CurrentBandwidthLimitsReturns(effectiveLimits, nil)
+
+				limits, err := container.CurrentBandwidthLimits()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(limits).To(Equal(effectiveLimits))
+			})
+
+			Context("when getting the current limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentBandwidthLimitsReturns(garden.BandwidthLimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.CurrentBandwidthLimits()
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("getting memory limits", func() {
+			It("obtains the current limits", func() {
+				effectiveLimits := garden.MemoryLimits{LimitInBytes: 2048}
+				fakeContainer.CurrentMemoryLimitsReturns(effectiveLimits, nil)
+
+				limits, err := container.CurrentMemoryLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits).ToNot(BeZero())
+
+				Expect(limits).To(Equal(effectiveLimits))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := container.CurrentMemoryLimits()
+				return err
+			})
+
+			Context("when getting the current memory limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentMemoryLimitsReturns(garden.MemoryLimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.CurrentMemoryLimits()
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("getting IO limits", func() {
+			It("obtains the current limits", func() {
+				effectiveLimits := garden.IOLimits{ReadBytesPerSecond: 1048576, WriteIOPS: 100}
+				fakeContainer.CurrentIOLimitsReturns(effectiveLimits, nil)
+
+				limits, err := container.CurrentIOLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits).ToNot(BeZero())
+
+				Expect(limits).To(Equal(effectiveLimits))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := container.CurrentIOLimits()
+				return err
+			})
+
+			Context("when getting the current IO limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentIOLimitsReturns(garden.IOLimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.CurrentIOLimits()
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("setting several limits at once", func() {
+			update := garden.LimitsUpdate{
+				CPU:    &garden.CPULimits{LimitInShares: 10},
+				Memory: &garden.MemoryLimits{LimitInBytes: 1024},
+				IO:     &garden.IOLimits{WriteBytesPerSecond: 1048576},
+			}
+
+			It("applies the update in one call and returns the limits in effect", func() {
+				effectiveLimits := garden.Limits{
+					CPU:    garden.CPULimits{LimitInShares: 10},
+					Disk:   garden.DiskLimits{ByteHard: 4096},
+					Memory: garden.MemoryLimits{LimitInBytes: 1024},
+					IO:     garden.IOLimits{WriteBytesPerSecond: 1048576},
+				}
+				fakeContainer.LimitAllReturns(effectiveLimits, nil)
+
+				limits, err := container.LimitAll(update)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits).To(Equal(effectiveLimits))
+
+				Expect(fakeContainer.LimitAllCallCount()).To(Equal(1))
+				Expect(fakeContainer.LimitAllArgsForCall(0)).To(Equal(update))
+			})
+
+			itResetsGraceTimeWhenHandling(func(graceTime time.Duration) {
+				fakeContainer.LimitAllStub = func(garden.LimitsUpdate) (garden.Limits, error) {
+					time.Sleep(graceTime)
+					return garden.Limits{}, nil
+				}
+
+				_, err := container.LimitAll(update)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := container.LimitAll(update)
+				return err
+			})
+
+			Context("when applying the limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.LimitAllReturns(garden.Limits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.LimitAll(update)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			Context("when the cpuset is not online on the host", func() {
+				It("fails without changing any limits", func() {
+					_, err := container.LimitAll(garden.LimitsUpdate{
+						CPU:    &garden.CPULimits{CPUSet: "65000"},
+						Memory: &garden.MemoryLimits{LimitInBytes: 1024},
+					})
+					Expect(err).To(MatchError(ContainSubstring("not online on this host")))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.LimitAllCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("boosting limits", func() {
+			var boostConnection connection.Connection
+
+			boost := garden.Limits{CPU: garden.CPULimits{LimitInShares: 1024}}
+			previous := garden.CPULimits{LimitInShares: 10}
+
+			BeforeEach(func() {
+				boostConnection = connection.New("unix", socketPath)
+				fakeContainer.CurrentCPULimitsReturns(previous, nil)
+			})
+
+			It("applies the boost, and restores the previous limits once it has passed", func() {
+				err := boostConnection.BoostLimits("some-handle", boost, 100*time.Millisecond)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeContainer.LimitAllCallCount()).To(Equal(1))
+				Expect(fakeContainer.LimitAllArgsForCall(0)).To(Equal(garden.LimitsUpdate{CPU: &boost.CPU}))
+
+				Eventually(fakeContainer.LimitAllCallCount).Should(Equal(2))
+				Expect(fakeContainer.LimitAllArgsForCall(1)).To(Equal(garden.LimitsUpdate{CPU: &previous}))
+			})
+
+			Context("when the container is boosted again", func() {
+				It("restores the limits from before the first boost", func() {
+					err := boostConnection.BoostLimits("some-handle", boost, time.Hour)
+					Expect(err).ToNot(HaveOccurred())
+
+					fakeContainer.CurrentCPULimitsReturns(boost.CPU, nil)
+
+					err = boostConnection.BoostLimits("some-handle", boost, 100*time.Millisecond)
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(fakeContainer.LimitAllCallCount).Should(Equal(3))
+					Expect(fakeContainer.LimitAllArgsForCall(2)).To(Equal(garden.LimitsUpdate{CPU: &previous}))
+				})
+			})
+
+			Context("when the limits are set while boosted", func() {
+				It("does not restore the limits which were set", func() {
+					err := boostConnection.BoostLimits("some-handle", boost, 100*time.Millisecond)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = container.LimitAll(garden.LimitsUpdate{CPU: &garden.CPULimits{LimitInShares: 512}})
+					Expect(err).ToNot(HaveOccurred())
+
+					Consistently(fakeContainer.LimitAllCallCount, 300*time.Millisecond).Should(Equal(2))
+				})
+			})
+
+			Context("when the container is destroyed while boosted", func() {
+				It("does not restore the limits", func() {
+					err := boostConnection.BoostLimits("some-handle", boost, 100*time.Millisecond)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(apiClient.Destroy("some-handle")).To(Succeed())
+
+					Consistently(fakeContainer.LimitAllCallCount, 300*time.Millisecond).Should(Equal(1))
+				})
+			})
+
+			Context("when another container is slow to boost", func() {
+				var (
+					otherContainer *fakes.FakeContainer
+					unblock        chan struct{}
+				)
+
+				BeforeEach(func() {
+					unblock = make(chan struct{})
+
+					otherContainer = new(fakes.FakeContainer)
+					otherContainer.HandleReturns("other-handle")
+					otherContainer.CurrentCPULimitsStub = func() (garden.CPULimits, error) {
+						<-unblock
+						return previous, nil
+					}
+
+					serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+						if handle == "other-handle" {
+							return otherContainer, nil
+						}
+
+						return fakeContainer, nil
+					}
+				})
+
+				AfterEach(func() {
+					close(unblock)
+				})
+
+				It("does not hold up boosting this one", func() {
+					go boostConnection.BoostLimits("other-handle", boost, time.Minute)
+					Eventually(otherContainer.CurrentCPULimitsCallCount).Should(Equal(1))
+
+					err := boostConnection.BoostLimits("some-handle", boost, time.Minute)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeContainer.LimitAllCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the boost changes limits other than cpu and memory", func() {
+				It("fails without changing any limits", func() {
+					err := boostConnection.BoostLimits("some-handle", garden.Limits{
+						CPU:  garden.CPULimits{LimitInShares: 1024},
+						Disk: garden.DiskLimits{ByteHard: 4096},
+					}, time.Minute)
+					Expect(err).To(MatchError(server.ErrInvalidBoost.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.LimitAllCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the boost changes no limits", func() {
+				It("fails without changing any limits", func() {
+					err := boostConnection.BoostLimits("some-handle", garden.Limits{}, time.Minute)
+					Expect(err).To(MatchError(server.ErrInvalidBoost.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.LimitAllCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the duration is not positive", func() {
+				It("fails without changing any limits", func() {
+					err := boostConnection.BoostLimits("some-handle", boost, 0)
+					Expect(err).To(MatchError(server.ErrInvalidBoostDuration.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.LimitAllCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when applying the boost fails", func() {
+				BeforeEach(func() {
+					fakeContainer.LimitAllReturns(garden.Limits{}, errors.New("oh no!"))
+				})
+
+				It("fails, and does not restore the limits", func() {
+					err := boostConnection.BoostLimits("some-handle", boost, 100*time.Millisecond)
+					Expect(err).To(HaveOccurred())
+
+					Consistently(fakeContainer.LimitAllCallCount, 300*time.Millisecond).Should(Equal(1))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return boostConnection.BoostLimits("some-handle", boost, time.Minute)
+			})
+		})
+
+		Describe("when the backend does not support an operation", func() {
+			BeforeEach(func() {
+				fakeContainer.LimitAllReturns(garden.Limits{}, garden.UnsupportedOperationError{Operation: "pids limit"})
+			})
+
+			It("returns a distinguishable UnsupportedOperationError", func() {
+				_, err := container.LimitAll(garden.LimitsUpdate{Pid: &garden.PidLimits{Max: 10}})
+				Expect(err).To(Equal(garden.UnsupportedOperationError{Operation: "pids limit"}))
+			})
+
+			It("does not log it as a server failure", func() {
+				container.LimitAll(garden.LimitsUpdate{Pid: &garden.PidLimits{Max: 10}})
+
+				Expect(logger.LogMessages()).ToNot(ContainElement(HaveSuffix(".failed")))
+			})
+		})
+
+		Describe("getting the current disk limits", func() {
+			currentLimits := garden.DiskLimits{
+				InodeSoft: 3333,
+				InodeHard: 4444,
+
+				ByteSoft: 5555,
+				ByteHard: 6666,
+			}
+
+			It("returns the limits returned by the backend", func() {
+				fakeContainer.CurrentDiskLimitsReturns(currentLimits, nil)
+
+				limits, err := container.CurrentDiskLimits()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(limits).To(Equal(currentLimits))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := container.CurrentDiskLimits()
+				return err
+			})
+
+			Context("when getting the current disk limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentDiskLimitsReturns(garden.DiskLimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.CurrentDiskLimits()
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("get the current cpu limits", func() {
+			effectiveLimits := garden.CPULimits{LimitInShares: 456}
+
+			It("gets the current limits", func() {
+				fakeContainer.CurrentCPULimitsReturns(effectiveLimits, nil)
+
+				limits, err := container.CurrentCPULimits()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(limits).To(Equal(effectiveLimits))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := container.CurrentCPULimits()
+				return err
+			})
+
+			Context("when getting the current CPU limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentCPULimitsReturns(garden.CPULimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.CurrentCPULimits()
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("setting the grace time", func() {
+			BeforeEach(func() {
+				graceTime = time.Second
+				serverBackend.GraceTimeReturns(graceTime)
+			})
+
+			It("destroys the container after it has been idle for the grace time", func() {
+				before := time.Now()
+				Expect(container.SetGraceTime(graceTime)).To(Succeed())
+
+				Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
+				Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
+
+				Expect(time.Since(before)).To(BeNumerically(">=", graceTime))
+				Expect(time.Since(before)).To(BeNumerically("<", graceTime+time.Second))
+			})
+		})
+
+		Describe("net in", func() {
+			It("maps the ports and returns them", func() {
+				fakeContainer.NetInReturns(111, 222, nil)
+
+				hostPort, containerPort, err := container.NetIn(123, 456)
+				Expect(err).ToNot(HaveOccurred())
+
+				hp, cp := fakeContainer.NetInArgsForCall(0)
+				Expect(hp).To(Equal(uint32(123)))
+				Expect(cp).To(Equal(uint32(456)))
+
+				Expect(hostPort).To(Equal(uint32(111)))
+				Expect(containerPort).To(Equal(uint32(222)))
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.NetInStub = func(uint32, uint32) (uint32, uint32, error) { time.Sleep(timeToSleep); return 0, 0, nil }
+				_, _, err := container.NetIn(123, 456)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, _, err := container.NetIn(123, 456)
+				return err
+			})
+
+			Context("when mapping the port fails", func() {
+				BeforeEach(func() {
+					fakeContainer.NetInReturns(0, 0, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, _, err := container.NetIn(123, 456)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("net out", func() {
+			Context("when a zero-value NetOutRule is supplied", func() {
+				It("permits all TCP traffic to everywhere, with logging not enabled", func() {
+					Expect(container.NetOut(garden.NetOutRule{})).To(Succeed())
+					rule := fakeContainer.NetOutArgsForCall(0)
+
+					Expect(rule.Protocol).To(Equal(garden.ProtocolAll))
+					Expect(rule.Networks).To(BeNil())
+					Expect(rule.Ports).To(BeNil())
+					Expect(rule.ICMPs).To(BeNil())
+					Expect(rule.Log).To(Equal(false))
+				})
+			})
+
+			Context("when protocol is specified", func() {
+				Context("as TCP", func() {
+					It("permits TCP traffic", func() {
+						Expect(container.NetOut(garden.NetOutRule{
+							Protocol: garden.ProtocolTCP,
+						})).To(Succeed())
+						rule := fakeContainer.NetOutArgsForCall(0)
+						Expect(rule.Protocol).To(Equal(garden.ProtocolTCP))
+					})
+				})
+
+				Context("as UDP", func() {
+					It("permits UDP traffic", func() {
+						Expect(container.NetOut(garden.NetOutRule{
+							Protocol: garden.ProtocolUDP,
+						})).To(Succeed())
+						rule := fakeContainer.NetOutArgsForCall(0)
+						Expect(rule.Protocol).To(Equal(garden.ProtocolUDP))
+					})
+				})
+
+				Context("as ICMP", func() {
+					It("permits ICMP traffic", func() {
+						Expect(container.NetOut(garden.NetOutRule{
+							Protocol: garden.ProtocolICMP,
+						})).To(Succeed())
+						rule := fakeContainer.NetOutArgsForCall(0)
+						Expect(rule.Protocol).To(Equal(garden.ProtocolICMP))
+					})
+				})
+
+				Context("as ALL", func() {
+					It("permits ALL traffic", func() {
+						Expect(container.NetOut(garden.NetOutRule{
+							Protocol: garden.ProtocolAll,
+						})).To(Succeed())
+
+						rule := fakeContainer.NetOutArgsForCall(0)
+						Expect(rule.Protocol).To(Equal(garden.ProtocolAll))
+					})
+				})
+			})
+
+			Context("when network is specified", func() {
+				It("permits traffic to that network", func() {
+					Expect(container.NetOut(garden.NetOutRule{
+						Networks: []garden.IPRange{
+							{Start: net.ParseIP("1.3.5.7"), End: net.ParseIP("9.9.7.6")},
+						},
+					})).To(Succeed())
+
+					rule := fakeContainer.NetOutArgsForCall(0)
+					Expect(rule.Networks).To(Equal([]garden.IPRange{
+						{
+							Start: net.ParseIP("1.3.5.7"),
+							End:   net.ParseIP("9.9.7.6"),
+						},
+					}))
+				})
+			})
+
+			Context("when multiple networks are specified", func() {
+				It("permits traffic to those networks", func() {
+					Expect(container.NetOut(garden.NetOutRule{
+						Networks: []garden.IPRange{
+							{Start: net.ParseIP("1.3.5.7"), End: net.ParseIP("9.9.7.6")},
+							{Start: net.ParseIP("2.4.6.8"), End: net.ParseIP("8.6.4.2")},
+						},
+					})).To(Succeed())
+
+					rule := fakeContainer.NetOutArgsForCall(0)
+					Expect(rule.Networks).To(ConsistOf(
+						garden.IPRange{
+							Start: net.ParseIP("1.3.5.7"),
+							End:   net.ParseIP("9.9.7.6"),
+						},
+						garden.IPRange{
+							Start: net.ParseIP("2.4.6.8"),
+							End:   net.ParseIP("8.6.4.2"),
+						},
+					))
+				})
+			})
+
+			Context("when ports are specified", func() {
+				It("permits traffic to those ports", func() {
+					Expect(container.NetOut(garden.NetOutRule{
+						Ports: []garden.PortRange{
+							{Start: 4, End: 44},
+						},
+					})).To(Succeed())
+
+					rule := fakeContainer.NetOutArgsForCall(0)
+					Expect(rule.Ports).To(Equal([]garden.PortRange{
+						{
+							Start: 4,
+							End:   44,
+						},
+					}))
+				})
+			})
+
+			Context("when multiple ports are specified", func() {
+				It("permits traffic to those ports", func() {
+					Expect(container.NetOut(garden.NetOutRule{
+						Ports: []garden.PortRange{
+							{Start: 4, End: 44},
+							{Start: 563, End: 3944},
+						},
+					})).To(Succeed())
+
+					rule := fakeContainer.NetOutArgsForCall(0)
+					Expect(rule.Ports).To(ConsistOf(
+						garden.PortRange{
+							Start: 4,
+							End:   44,
+						},
+						garden.PortRange{
+							Start: 563,
+							End:   3944,
+						},
+					))
+				})
+			})
+
+			Context("when icmps are specified without a code", func() {
+				It("permits traffic matching those icmps", func() {
+					Expect(container.NetOut(garden.NetOutRule{
+						ICMPs: &garden.ICMPControl{Type: 4},
+					})).To(Succeed())
+
+					rule := fakeContainer.NetOutArgsForCall(0)
+					Expect(rule.ICMPs).To(Equal(&garden.ICMPControl{Type: 4}))
+				})
+			})
+
+			Context("when icmps are specified with a code", func() {
+				It("permits traffic matching those icmps", func() {
+					var code garden.ICMPCode = 34
+					Expect(container.NetOut(garden.NetOutRule{
+						ICMPs: &garden.ICMPControl{Type: 4, Code: &code},
+					})).To(Succeed())
+
+					rule := fakeContainer.NetOutArgsForCall(0)
+					Expect(rule.ICMPs).To(Equal(&garden.ICMPControl{Type: 4, Code: &code}))
+				})
+			})
+
+			Context("when log is true", func() {
+				It("requests that the rule logs", func() {
+					Expect(container.NetOut(garden.NetOutRule{Log: true})).To(Succeed())
+
+					rule := fakeContainer.NetOutArgsForCall(0)
+					Expect(rule.Log).To(BeTrue())
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.NetOutStub = func(garden.NetOutRule) error { time.Sleep(timeToSleep); return nil }
+				err := container.NetOut(garden.NetOutRule{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.NetOut(garden.NetOutRule{})
+			})
+
+			Context("when permitting traffic fails", func() {
+				BeforeEach(func() {
+					fakeContainer.NetOutReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.NetOut(garden.NetOutRule{})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("bulk net out", func() {
+			It("calls bulk net out with rules provided", func() {
+				rules := []garden.NetOutRule{
+					garden.NetOutRule{Protocol: garden.ProtocolTCP},
+					garden.NetOutRule{Protocol: garden.ProtocolUDP},
+				}
+
+				Expect(container.BulkNetOut(rules)).To(Succeed())
+				Expect(fakeContainer.BulkNetOutCallCount()).To(Equal(1))
+				Expect(fakeContainer.BulkNetOutArgsForCall(0)).To(Equal(rules))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.BulkNetOut([]garden.NetOutRule{})
+			})
+
+			Context("when permitting traffic fails", func() {
+				BeforeEach(func() {
+					fakeContainer.BulkNetOutReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.BulkNetOut([]garden.NetOutRule{})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("listing the egress rules of every container", func() {
+			tcpRule := garden.NetOutRule{Protocol: garden.ProtocolTCP, Ports: []garden.PortRange{garden.PortRangeFromPort(443)}}
+			udpRule := garden.NetOutRule{Protocol: garden.ProtocolUDP, Ports: []garden.PortRange{garden.PortRangeFromPort(53)}}
+
+			var otherContainer *fakes.FakeContainer
+
+			BeforeEach(func() {
+				otherContainer = new(fakes.FakeContainer)
+				otherContainer.HandleReturns("other-handle")
+				otherContainer.NetworkPolicyReturns(garden.NetworkPolicy{DefaultAction: garden.NetworkActionAllow}, nil)
+
+				goneContainer := new(fakes.FakeContainer)
+				goneContainer.HandleReturns("gone-handle")
+
+				serverBackend.ContainersReturns([]garden.Container{fakeContainer, otherContainer, goneContainer}, nil)
+				serverBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+					"some-handle":  {Info: garden.ContainerInfo{ContainerIP: "10.0.0.2"}},
+					"other-handle": {Info: garden.ContainerInfo{ContainerIP: "10.0.0.3"}},
+					"gone-handle":  {Err: garden.NewError("unknown handle: gone-handle")},
+				}, nil)
+
+				fakeContainer.NetworkPolicyReturns(garden.NetworkPolicy{
+					DefaultAction: garden.NetworkActionDeny,
+					Rules:         []garden.NetOutRule{tcpRule, udpRule},
+				}, nil)
+			})
+
+			It("returns the policy the backend reports for each container, with its IP", func() {
+				rules, err := connection.New("unix", socketPath).AllEgressRules()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rules).To(Equal(map[string]garden.ContainerEgressRules{
+					"some-handle": {
+						ContainerIP:   "10.0.0.2",
+						DefaultAction: garden.NetworkActionDeny,
+						Rules:         []garden.NetOutRule{tcpRule, udpRule},
+					},
+					"other-handle": {
+						ContainerIP:   "10.0.0.3",
+						DefaultAction: garden.NetworkActionAllow,
+					},
+				}))
+			})
+
+			It("reports a policy set with SetNetworkPolicy", func() {
+				var current garden.NetworkPolicy
+				fakeContainer.NetworkPolicyStub = func() (garden.NetworkPolicy, error) {
+					return current, nil
+				}
+				fakeContainer.SetNetworkPolicyStub = func(policy garden.NetworkPolicy) error {
+					current = policy
+					return nil
+				}
+
+				policy := garden.NetworkPolicy{
+					DefaultAction: garden.NetworkActionDeny,
+					Rules:         []garden.NetOutRule{udpRule},
+				}
+				Expect(container.SetNetworkPolicy(policy)).To(Succeed())
+
+				rules, err := connection.New("unix", socketPath).AllEgressRules()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rules["some-handle"]).To(Equal(garden.ContainerEgressRules{
+					ContainerIP:   "10.0.0.2",
+					DefaultAction: garden.NetworkActionDeny,
+					Rules:         []garden.NetOutRule{udpRule},
+				}))
+			})
+
+			Context("when a container is destroyed before its policy is read", func() {
+				BeforeEach(func() {
+					otherContainer.NetworkPolicyReturns(garden.NetworkPolicy{}, garden.ContainerNotFoundError{Handle: "other-handle"})
+				})
+
+				It("leaves it out", func() {
+					rules, err := connection.New("unix", socketPath).AllEgressRules()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(rules).To(HaveKey("some-handle"))
+					Expect(rules).ToNot(HaveKey("other-handle"))
+				})
+			})
+
+			Context("when a container's policy cannot be read", func() {
+				BeforeEach(func() {
+					otherContainer.NetworkPolicyReturns(garden.NetworkPolicy{}, errors.New("oh no"))
+				})
+
+				It("fails", func() {
+					_, err := connection.New("unix", socketPath).AllEgressRules()
+					Expect(err).To(MatchError("oh no"))
+				})
+			})
+		})
+
+		Describe("network policy", func() {
+			previousPolicy := garden.NetworkPolicy{
+				DefaultAction: garden.NetworkActionAllow,
+			}
+
+			policy := garden.NetworkPolicy{
+				DefaultAction: garden.NetworkActionDeny,
+				Rules: []garden.NetOutRule{
+					{Protocol: garden.ProtocolTCP, Ports: []garden.PortRange{garden.PortRangeFromPort(443)}},
+				},
+			}
+
+			BeforeEach(func() {
+				fakeContainer.NetworkPolicyReturns(previousPolicy, nil)
+			})
+
+			It("returns the container's policy", func() {
+				Expect(container.NetworkPolicy()).To(Equal(previousPolicy))
+			})
+
+			It("replaces the container's policy", func() {
+				Expect(container.SetNetworkPolicy(policy)).To(Succeed())
+
+				Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(1))
+				Expect(fakeContainer.SetNetworkPolicyArgsForCall(0)).To(Equal(policy))
+			})
+
+			Context("when the default action is invalid", func() {
+				It("fails without changing the policy", func() {
+					err := container.SetNetworkPolicy(garden.NetworkPolicy{DefaultAction: "maybe"})
+					Expect(err).To(MatchError(ContainSubstring(`invalid network policy default action "maybe"`)))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when applying the policy fails", func() {
+				BeforeEach(func() {
+					fakeContainer.SetNetworkPolicyStub = func(p garden.NetworkPolicy) error {
+						if p.DefaultAction == garden.NetworkActionDeny {
+							return errors.New("iptables failed")
+						}
+
+						return nil
+					}
+				})
+
+				It("restores the previous policy and fails", func() {
+					err := container.SetNetworkPolicy(policy)
+					Expect(err).To(MatchError("iptables failed"))
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(2))
+					Expect(fakeContainer.SetNetworkPolicyArgsForCall(1)).To(Equal(previousPolicy))
+				})
+			})
+
+			Context("when a rule is added while the policy is being replaced", func() {
+				It("waits for the policy to be replaced", func() {
+					replacing := make(chan struct{})
+					release := make(chan struct{})
+					fakeContainer.SetNetworkPolicyStub = func(garden.NetworkPolicy) error {
+						close(replacing)
+						<-release
+						return nil
+					}
+
+					go container.SetNetworkPolicy(policy)
+					Eventually(replacing).Should(BeClosed())
+
+					netOut := make(chan error, 1)
+					go func() {
+						netOut <- container.NetOut(garden.NetOutRule{Protocol: garden.ProtocolTCP})
+					}()
+
+					Consistently(fakeContainer.NetOutCallCount).Should(Equal(0))
+
+					close(release)
+
+					Eventually(netOut).Should(Receive(BeNil()))
+					Expect(fakeContainer.NetOutCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the previous policy cannot be read", func() {
+				BeforeEach(func() {
+					fakeContainer.NetworkPolicyReturns(garden.NetworkPolicy{}, errors.New("oh no!"))
+				})
+
+				It("fails without changing the policy", func() {
+					err := container.SetNetworkPolicy(policy)
+					Expect(err).To(MatchError("oh no!"))
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(0))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				return container.SetNetworkPolicy(policy)
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.SetNetworkPolicyStub = func(garden.NetworkPolicy) error { time.Sleep(timeToSleep); return nil }
+				container.SetNetworkPolicy(policy)
+			})
+		})
+
+		Describe("traffic between containers", func() {
+			var (
+				conn     connection.Connection
+				peer     *fakes.FakeContainer
+				peerRule garden.NetOutRule
+				other    garden.NetOutRule
+			)
+
+			BeforeEach(func() {
+				conn = connection.New("unix", socketPath)
+
+				peer = new(fakes.FakeContainer)
+				peer.HandleReturns("peer-handle")
+				peer.InfoReturns(garden.ContainerInfo{ContainerIP: "10.0.0.5"}, nil)
+
+				serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+					switch handle {
+					case "some-handle":
+						return fakeContainer, nil
+					case "peer-handle":
+						return peer, nil
+					default:
+						return nil, garden.ContainerNotFoundError{Handle: handle}
+					}
+				}
+
+				peerRule = garden.NetOutRule{
+					Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("10.0.0.5"))},
+				}
+				other = garden.NetOutRule{
+					Protocol: garden.ProtocolTCP,
+					Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("1.2.3.4"))},
+				}
+
+				var (
+					policyL sync.Mutex
+					policy  = garden.NetworkPolicy{
+						DefaultAction: garden.NetworkActionDeny,
+						Rules:         []garden.NetOutRule{other},
+					}
+				)
+
+				fakeContainer.NetworkPolicyStub = func() (garden.NetworkPolicy, error) {
+					policyL.Lock()
+					defer policyL.Unlock()
+
+					return policy, nil
+				}
+				fakeContainer.SetNetworkPolicyStub = func(p garden.NetworkPolicy) error {
+					policyL.Lock()
+					defer policyL.Unlock()
+
+					policy = p
+					return nil
+				}
+			})
+
+			It("allows traffic by adding a rule for the destination's IP to the source's policy", func() {
+				Expect(conn.AllowTraffic("some-handle", "peer-handle")).To(Succeed())
+
+				Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(1))
+				Expect(fakeContainer.SetNetworkPolicyArgsForCall(0)).To(Equal(garden.NetworkPolicy{
+					DefaultAction: garden.NetworkActionDeny,
+					Rules:         []garden.NetOutRule{other, peerRule},
+				}))
+				Expect(peer.SetNetworkPolicyCallCount()).To(Equal(0))
+			})
+
+			It("does not add the rule twice", func() {
+				Expect(conn.AllowTraffic("some-handle", "peer-handle")).To(Succeed())
+				Expect(conn.AllowTraffic("some-handle", "peer-handle")).To(Succeed())
+
+				Expect(fakeContainer.SetNetworkPolicyArgsForCall(1).Rules).To(Equal([]garden.NetOutRule{other, peerRule}))
+			})
+
+			It("denies traffic by removing the rule again", func() {
+				Expect(conn.AllowTraffic("some-handle", "peer-handle")).To(Succeed())
+				Expect(conn.DenyTraffic("some-handle", "peer-handle")).To(Succeed())
+
+				Expect(fakeContainer.SetNetworkPolicyArgsForCall(1)).To(Equal(garden.NetworkPolicy{
+					DefaultAction: garden.NetworkActionDeny,
+					Rules:         []garden.NetOutRule{other},
+				}))
+			})
+
+			It("leaves alone a rule it did not add", func() {
+				Expect(container.SetNetworkPolicy(garden.NetworkPolicy{
+					DefaultAction: garden.NetworkActionDeny,
+					Rules:         []garden.NetOutRule{peerRule, other},
+				})).To(Succeed())
+
+				Expect(conn.DenyTraffic("some-handle", "peer-handle")).To(Succeed())
+
+				Expect(fakeContainer.SetNetworkPolicyArgsForCall(1).Rules).To(Equal([]garden.NetOutRule{peerRule, other}))
+			})
+
+			Context("when the destination is destroyed", func() {
+				It("removes the rule from the source's policy", func() {
+					Expect(conn.AllowTraffic("some-handle", "peer-handle")).To(Succeed())
+
+					Expect(apiClient.Destroy("peer-handle")).To(Succeed())
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(2))
+					Expect(fakeContainer.SetNetworkPolicyArgsForCall(1).Rules).To(Equal([]garden.NetOutRule{other}))
+				})
+			})
+
+			Context("when the source is destroyed", func() {
+				It("forgets its rules", func() {
+					Expect(conn.AllowTraffic("some-handle", "peer-handle")).To(Succeed())
+
+					Expect(apiClient.Destroy("some-handle")).To(Succeed())
+					Expect(apiClient.Destroy("peer-handle")).To(Succeed())
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the source's policy allows all traffic by default", func() {
+				BeforeEach(func() {
+					fakeContainer.NetworkPolicyStub = nil
+					fakeContainer.NetworkPolicyReturns(garden.NetworkPolicy{DefaultAction: garden.NetworkActionAllow}, nil)
+				})
+
+				It("refuses to deny traffic", func() {
+					err := conn.DenyTraffic("some-handle", "peer-handle")
+					Expect(err).To(MatchError(server.ErrDenyWithDefaultAllow.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the destination has no IP address", func() {
+				BeforeEach(func() {
+					peer.InfoReturns(garden.ContainerInfo{}, nil)
+				})
+
+				It("returns an error", func() {
+					err := conn.AllowTraffic("some-handle", "peer-handle")
+					Expect(err).To(MatchError("container peer-handle has no IP address"))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the source and destination are the same", func() {
+				It("returns an error", func() {
+					err := conn.AllowTraffic("some-handle", "some-handle")
+					Expect(err).To(MatchError(server.ErrTrafficToSelf.Error()))
+				})
+			})
+
+			Context("when the destination is not found", func() {
+				It("returns a ContainerNotFoundError", func() {
+					err := conn.AllowTraffic("some-handle", "missing-handle")
+					Expect(err).To(Equal(garden.ContainerNotFoundError{Handle: "missing-handle"}))
+				})
+			})
+
+			Context("when setting the policy fails", func() {
+				BeforeEach(func() {
+					fakeContainer.SetNetworkPolicyStub = func(p garden.NetworkPolicy) error {
+						if len(p.Rules) == 2 {
+							return errors.New("oh no!")
+						}
+
+						return nil
+					}
+				})
+
+				It("puts the previous policy back", func() {
+					err := conn.AllowTraffic("some-handle", "peer-handle")
+					Expect(err).To(MatchError("oh no!"))
+
+					Expect(fakeContainer.SetNetworkPolicyCallCount()).To(Equal(2))
+					Expect(fakeContainer.SetNetworkPolicyArgsForCall(1).Rules).To(Equal([]garden.NetOutRule{other}))
+				})
+			})
+		})
+
+		Describe("info", func() {
+			containerInfo := garden.ContainerInfo{
+				State:         "active",
+				Events:        []string{"oom", "party"},
+				HostIP:        "host-ip",
+				ContainerIP:   "container-ip",
+				ExternalIP:    "external-ip",
+				ContainerPath: "/path/to/container",
+				ProcessIDs:    []string{"process-handle-1", "process-handle-2"},
+				Properties: garden.Properties{
+					"foo": "bar",
+					"a":   "b",
+				},
+				MappedPorts: []garden.PortMapping{
+					{HostPort: 1234, ContainerPort: 5678},
+					{HostPort: 1235, ContainerPort: 5679},
+				},
+			}
+
+			It("reports information about the container", func() {
+				fakeContainer.InfoReturns(containerInfo, nil)
+
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				info.LastActivity = time.Time{}
+				info.Version = ""
+				Expect(info).To(Equal(containerInfo))
+			})
+
+			It("does not include the network stats in the version, as they change with every packet", func() {
+				fakeContainer.InfoReturns(containerInfo, nil)
+
+				first, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				trafficked := containerInfo
+				trafficked.NetworkStat = garden.ContainerNetworkStat{RxBytes: 100, RxPackets: 1}
+				fakeContainer.InfoReturns(trafficked, nil)
+
+				second, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(second.NetworkStat).To(Equal(trafficked.NetworkStat))
+				Expect(second.Version).To(Equal(first.Version))
+			})
+
+			It("includes the id mappings in the version", func() {
+				fakeContainer.InfoReturns(containerInfo, nil)
+
+				first, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				mapped := containerInfo
+				mapped.UIDMappings = []garden.IDMapping{{ContainerID: 0, HostID: 4294967294, Size: 1}}
+				fakeContainer.InfoReturns(mapped, nil)
+
+				second, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(second.UIDMappings).To(Equal(mapped.UIDMappings))
+				Expect(second.Version).ToNot(Equal(first.Version))
+			})
+
+			It("reports when the container was last active", func() {
+				fakeContainer.InfoReturns(containerInfo, nil)
+
+				before := time.Now()
+				Expect(container.SetProperty("some", "property")).To(Succeed())
+
+				time.Sleep(100 * time.Millisecond)
+
+				infoRequested := time.Now()
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(info.LastActivity).To(BeTemporally(">=", before))
+				Expect(info.LastActivity).To(BeTemporally("<", infoRequested.Add(-50*time.Millisecond)))
+			})
+
+			It("does not count calls which only read about the container as activity", func() {
+				fakeContainer.InfoReturns(containerInfo, nil)
+
+				Expect(container.SetProperty("some", "property")).To(Succeed())
+
+				time.Sleep(100 * time.Millisecond)
+
+				_, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = container.CurrentMemoryLimits()
+				Expect(err).ToNot(HaveOccurred())
+
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(info.LastActivity).To(BeTemporally("<", time.Now().Add(-50*time.Millisecond)))
+			})
+
+			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+				fakeContainer.InfoStub = func() (garden.ContainerInfo, error) { time.Sleep(timeToSleep); return garden.ContainerInfo{}, nil }
+				_, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() error {
+				_, err := container.Info()
+				return err
+			})
+
+			Context("when getting container info fails", func() {
+				BeforeEach(func() {
+					fakeContainer.InfoReturns(garden.ContainerInfo{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.Info()
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			Context("when only some fields are requested", func() {
+				var conn connection.Connection
+
+				BeforeEach(func() {
+					conn = connection.New("unix", socketPath)
+					fakeContainer.InfoReturns(containerInfo, nil)
+				})
+
+				It("returns only the requested fields", func() {
+					info, err := conn.InfoFields("some-handle", []string{"State", "ContainerIP", "ProcessIDs"})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(info).To(Equal(garden.ContainerInfo{
+						State:       "active",
+						ContainerIP: "container-ip",
+						ProcessIDs:  []string{"process-handle-1", "process-handle-2"},
+					}))
+				})
+
+				It("fails when an unknown field is requested", func() {
+					_, err := conn.InfoFields("some-handle", []string{"State", "Bogus"})
+					Expect(err).To(MatchError("unknown info field: Bogus"))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+				})
+			})
+
+			Describe("versions", func() {
+				var conn connection.Connection
+
+				BeforeEach(func() {
+					conn = connection.New("unix", socketPath)
+					fakeContainer.InfoReturns(containerInfo, nil)
+				})
+
+				It("keeps the version while nothing changes", func() {
+					first, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(first.Version).ToNot(BeEmpty())
+
+					second, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(second.Version).To(Equal(first.Version))
+				})
+
+				It("changes the version when the info changes", func() {
+					first, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+
+					changed := containerInfo
+					changed.State = "stopped"
+					fakeContainer.InfoReturns(changed, nil)
+
+					second, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(second.Version).ToNot(Equal(first.Version))
+				})
+
+				It("changes the version when the limits change", func() {
+					first, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = conn.LimitAll("some-handle", garden.LimitsUpdate{
+						Memory: &garden.MemoryLimits{LimitInBytes: 1024},
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					second, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(second.Version).ToNot(Equal(first.Version))
+				})
+
+				It("does not return the info when the version has not changed", func() {
+					first, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+
+					info, changed, err := conn.InfoIfChanged("some-handle", first.Version)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(changed).To(BeFalse())
+					Expect(info).To(BeZero())
+				})
+
+				It("returns the info when the version has changed", func() {
+					first, err := container.Info()
+					Expect(err).ToNot(HaveOccurred())
+
+					stopped := containerInfo
+					stopped.State = "stopped"
+					fakeContainer.InfoReturns(stopped, nil)
+
+					info, changed, err := conn.InfoIfChanged("some-handle", first.Version)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(changed).To(BeTrue())
+					Expect(info.State).To(Equal("stopped"))
+					Expect(info.Version).ToNot(Equal(first.Version))
+				})
+			})
+		})
+
+		Describe("BulkInfo", func() {
+
+			handles := []string{"handle1", "handle2"}
+
+			expectedBulkInfo := map[string]garden.ContainerInfoEntry{
+				"handle1": garden.ContainerInfoEntry{
+					Info: garden.ContainerInfo{
+						State: "container1state",
+					},
+				},
+				"handle2": garden.ContainerInfoEntry{
+					Info: garden.ContainerInfo{
+						State: "container2state",
+					},
+				},
+			}
+
+			It("calls BulkInfo with empty slice when handles is empty", func() {
+				handles = nil
+				serverBackend.BulkInfoReturns(nil, nil)
+				apiClient.BulkInfo(handles)
+				Expect(serverBackend.BulkInfoArgsForCall(0)).To(BeEmpty())
+			})
+
+			It("reports information about containers by list of handles", func() {
+				// the server fills in the entries it is given, so it is given a copy
+				backendBulkInfo := map[string]garden.ContainerInfoEntry{}
+				for handle, entry := range expectedBulkInfo {
+					backendBulkInfo[handle] = entry
+				}
+				serverBackend.BulkInfoReturns(backendBulkInfo, nil)
+
+				bulkInfo, err := apiClient.BulkInfo(handles)
+				Expect(err).ToNot(HaveOccurred())
+
+				for handle, entry := range bulkInfo {
+					Expect(entry.Info.Version).ToNot(BeEmpty())
+					entry.Info.Version = ""
+					bulkInfo[handle] = entry
+				}
+				Expect(bulkInfo).To(Equal(expectedBulkInfo))
+			})
+
+			It("versions each container's info as reporting it on its own does", func() {
+				fakeContainer.InfoReturns(garden.ContainerInfo{State: "active"}, nil)
+				serverBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+					"some-handle": {Info: garden.ContainerInfo{State: "active"}},
+				}, nil)
+
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				bulkInfo, err := apiClient.BulkInfo([]string{"some-handle"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bulkInfo["some-handle"].Info.Version).To(Equal(info.Version))
+
+				serverBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+					"some-handle": {Info: garden.ContainerInfo{State: "stopped"}},
+				}, nil)
+
+				bulkInfo, err = apiClient.BulkInfo([]string{"some-handle"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bulkInfo["some-handle"].Info.Version).ToNot(Equal(info.Version))
+			})
+
+			Context("when retrieving bulk info fails", func() {
+				It("returns the error", func() {
+					serverBackend.BulkInfoReturns(
+						make(map[string]garden.ContainerInfoEntry),
+						errors.New("Oh noes!"),
+					)
+
+					_, err := apiClient.BulkInfo(handles)
+					Expect(err).To(MatchError("Oh noes!"))
+				})
+			})
+
+			Context("when a container is in error state", func() {
+				It("return single container error", func() {
+
+					expectedBulkInfo := map[string]garden.ContainerInfoEntry{
+						"error": garden.ContainerInfoEntry{
+							Err: &garden.Error{Err: errors.New("Oopps!")},
+						},
+						"success": garden.ContainerInfoEntry{
+							Info: garden.ContainerInfo{
+								State: "container2state",
+							},
+						},
+					}
+
+					serverBackend.BulkInfoReturns(expectedBulkInfo, nil)
+
+					bulkInfo, err := apiClient.BulkInfo(handles)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(bulkInfo).To(Equal(expectedBulkInfo))
+				})
+			})
+		})
+
+		Describe("BulkMetrics", func() {
+
+			handles := []string{"handle1", "handle2"}
+
+			expectedBulkMetrics := map[string]garden.ContainerMetricsEntry{
+				"handle1": garden.ContainerMetricsEntry{
+					Metrics: garden.Metrics{
+						DiskStat: garden.ContainerDiskStat{
+							TotalInodesUsed: 1,
+						},
+					},
+				},
+				"handle2": garden.ContainerMetricsEntry{
+					Metrics: garden.Metrics{
+						DiskStat: garden.ContainerDiskStat{
+							TotalInodesUsed: 2,
+						},
+					},
+				},
+			}
+
+			It("reports information about containers by list of handles", func() {
+				serverBackend.BulkMetricsReturns(expectedBulkMetrics, nil)
+
+				bulkMetrics, err := apiClient.BulkMetrics(handles)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bulkMetrics).To(Equal(expectedBulkMetrics))
+			})
+
+			It("calls BulkMetrics with empty slice when handles is empty", func() {
+				handles = nil
+				serverBackend.BulkMetricsReturns(nil, nil)
+				apiClient.BulkMetrics(handles)
+				Expect(serverBackend.BulkMetricsArgsForCall(0)).To(BeEmpty())
+			})
+
+			Context("when retrieving bulk info fails", func() {
+				It("returns the error", func() {
+					serverBackend.BulkMetricsReturns(
+						make(map[string]garden.ContainerMetricsEntry),
+						errors.New("Oh noes!"),
+					)
+
+					_, err := apiClient.BulkMetrics(handles)
+					Expect(err).To(MatchError("Oh noes!"))
+				})
+			})
+
+			Context("when a container has an error", func() {
+				It("returns the error for the container", func() {
+
+					errorBulkMetrics := map[string]garden.ContainerMetricsEntry{
+						"error": garden.ContainerMetricsEntry{
+							Err: &garden.Error{Err: errors.New("Oh noes!")},
+						},
+						"success": garden.ContainerMetricsEntry{
+							Metrics: garden.Metrics{
+								DiskStat: garden.ContainerDiskStat{
+									TotalInodesUsed: 1,
+								},
+							},
+						},
+					}
+
+					serverBackend.BulkMetricsReturns(errorBulkMetrics, nil)
+
+					bulkMetrics, err := apiClient.BulkMetrics(handles)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(bulkMetrics).To(Equal(errorBulkMetrics))
+				})
+			})
+		})
+
+		Describe("attaching", func() {
+			Context("when attaching succeeds", func() {
+				BeforeEach(func() {
+					fakeContainer.AttachStub = func(processID string, io garden.ProcessIO) (garden.Process, error) {
+						writing := new(sync.WaitGroup)
+						writing.Add(1)
+
+						go func() {
+							defer writing.Done()
+							defer GinkgoRecover()
+
+							_, err := fmt.Fprintf(io.Stdout, "stdout data")
+							Expect(err).ToNot(HaveOccurred())
+
+							in, err := ioutil.ReadAll(io.Stdin)
+							Expect(err).ToNot(HaveOccurred())
+
+							_, err = fmt.Fprintf(io.Stdout, "mirrored %s", string(in))
+							Expect(err).ToNot(HaveOccurred())
+
+							_, err = fmt.Fprintf(io.Stderr, "stderr data")
+							Expect(err).ToNot(HaveOccurred())
+						}()
+
+						process := new(fakes.FakeProcess)
+
+						process.IDReturns("process-handle")
+
+						process.WaitStub = func() (int, error) {
+							writing.Wait()
+							return 123, nil
+						}
+
+						return process, nil
+					}
+				})
+
+				It("responds with a ProcessPayload for every chunk", func() {
+					stdout := gbytes.NewBuffer()
+					stderr := gbytes.NewBuffer()
+
+					processIO := garden.ProcessIO{
+						Stdin:  bytes.NewBufferString("stdin data"),
+						Stdout: stdout,
+						Stderr: stderr,
+					}
+
+					process, err := container.Attach("process-handle", processIO)
+					Expect(err).ToNot(HaveOccurred())
+
+					pid, _ := fakeContainer.AttachArgsForCall(0)
+					Expect(pid).To(Equal("process-handle"))
+
+					Eventually(stdout).Should(gbytes.Say("stdout data"))
+					Eventually(stdout).Should(gbytes.Say("mirrored stdin data"))
+					Eventually(stderr).Should(gbytes.Say("stderr data"))
+
+					status, err := process.Wait()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(status).To(Equal(123))
+				})
+
+				itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
+					fakeContainer.AttachStub = func(string, garden.ProcessIO) (garden.Process, error) {
+						time.Sleep(timeToSleep)
+						return nil, errors.New("blam")
+					}
+					container.Attach("process-handle", garden.ProcessIO{})
+				})
+			})
+
+			Context("when the process was run through the server", func() {
+				var (
+					processIO chan garden.ProcessIO
+					exited    chan struct{}
+					runSpec   garden.ProcessSpec
+					runIO     garden.ProcessIO
+				)
+
+				BeforeEach(func() {
+					processIO = make(chan garden.ProcessIO, 1)
+					exited = make(chan struct{})
+					runSpec = garden.ProcessSpec{Path: "/some/script"}
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return 0, nil
+					}
+
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						processIO <- io
+						return process, nil
+					}
+
+					fakeContainer.AttachReturns(process, nil)
+				})
+
+				JustBeforeEach(func() {
+					_, err := container.Run(runSpec, garden.ProcessIO{Stdout: gbytes.NewBuffer()})
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(processIO).Should(Receive(&runIO))
+				})
+
+				AfterEach(func() {
+					close(exited)
+				})
+
+				It("streams its output to every client attached to it", func() {
+					first := gbytes.NewBuffer()
+					_, err := container.Attach("process-handle", garden.ProcessIO{Stdout: first})
+					Expect(err).ToNot(HaveOccurred())
+
+					second := gbytes.NewBuffer()
+					secondErr := gbytes.NewBuffer()
+					_, err = container.Attach("process-handle", garden.ProcessIO{Stdout: second, Stderr: secondErr})
+					Expect(err).ToNot(HaveOccurred())
+
+					fmt.Fprint(runIO.Stdout, "shared output\n")
+					fmt.Fprint(runIO.Stderr, "shared error\n")
+
+					Eventually(first).Should(gbytes.Say("shared output"))
+					Eventually(second).Should(gbytes.Say("shared output"))
+					Eventually(secondErr).Should(gbytes.Say("shared error"))
+
+					By("not asking the backend for the output again")
+					_, attachIO := fakeContainer.AttachArgsForCall(0)
+					Expect(attachIO.Stdout).To(BeNil())
+					Expect(attachIO.Stderr).To(BeNil())
+				})
+
+				It("drops the output a client falls behind on, without holding up the others", func() {
+					unblock := make(chan struct{})
+					defer close(unblock)
+
+					_, err := container.Attach("process-handle", garden.ProcessIO{Stdout: &blockingWriter{unblock: unblock}})
+					Expect(err).ToNot(HaveOccurred())
+
+					fast := gbytes.NewBuffer()
+					_, err = container.Attach("process-handle", garden.ProcessIO{Stdout: fast})
+					Expect(err).ToNot(HaveOccurred())
+
+					chunk := bytes.Repeat([]byte("x"), 4096)
+					for batch := 1; batch <= 20; batch++ {
+						for i := 0; i < 100; i++ {
+							runIO.Stdout.Write(chunk)
+						}
+
+						Eventually(func() int { return len(fast.Contents()) }).Should(Equal(batch * 100 * len(chunk)))
+					}
+
+					Expect(logger.LogMessages()).To(ContainElement(HaveSuffix("dropping-slow-client-output")))
+					Expect(apiServer.DroppedOutputBytes()).To(BeNumerically(">", 0))
+				})
+
+				Context("when the process has a TTY", func() {
+					BeforeEach(func() {
+						runSpec.TTY = &garden.TTYSpec{}
+					})
+
+					It("does not hold up the process for an attached client which falls behind, unless it asks to", func() {
+						unblock := make(chan struct{})
+						defer close(unblock)
+
+						slow := &blockingWriter{unblock: unblock, writing: make(chan struct{}, 1)}
+						_, err := container.Attach("process-handle", garden.ProcessIO{Stdout: slow})
+						Expect(err).ToNot(HaveOccurred())
+
+						runIO.Stdout.Write([]byte("x"))
+						Eventually(slow.writing).Should(Receive())
+
+						chunk := bytes.Repeat([]byte("x"), 4096)
+
+						written := make(chan struct{})
+						go func() {
+							defer GinkgoRecover()
+							defer close(written)
+
+							for i := 0; i < 2000; i++ {
+								runIO.Stdout.Write(chunk)
+							}
+						}()
+
+						Eventually(written, 10*time.Second).Should(BeClosed())
+						Expect(apiServer.DroppedOutputBytes()).To(BeNumerically(">", 0))
+					})
+				})
+
+				Context("when a client asks to hold up the process while it falls behind", func() {
+					var (
+						unblock chan struct{}
+						slow    *blockingWriter
+					)
+
+					BeforeEach(func() {
+						unblock = make(chan struct{})
+						slow = &blockingWriter{unblock: unblock, writing: make(chan struct{}, 1)}
+					})
+
+					JustBeforeEach(func() {
+						_, err := container.Attach("process-handle", garden.ProcessIO{
+							Stdout:       slow,
+							Backpressure: garden.BackpressureBlock,
+						})
+						Expect(err).ToNot(HaveOccurred())
+
+						// once the client has some output, it is reading it
+						runIO.Stdout.Write([]byte("x"))
+						Eventually(slow.writing).Should(Receive())
+					})
+
+					It("holds up the process until the client catches up, so that it sees all of the output", func() {
+						chunk := bytes.Repeat([]byte("x"), 4096)
+
+						written := make(chan struct{})
+						go func() {
+							defer GinkgoRecover()
+							defer close(written)
+
+							for i := 0; i < 2000; i++ {
+								runIO.Stdout.Write(chunk)
+							}
+						}()
+
+						Consistently(written, 500*time.Millisecond).ShouldNot(BeClosed())
+						Expect(apiServer.BufferedOutputBytes()).To(BeNumerically(">", 0))
+
+						close(unblock)
+
+						Eventually(written, 10*time.Second).Should(BeClosed())
+						Eventually(slow.written, 10*time.Second).Should(Equal(int64(1 + 2000*len(chunk))))
+					})
+
+					Context("when it stalls", func() {
+						BeforeEach(func() {
+							apiServer.SetOutputBlockTimeout(200 * time.Millisecond)
+						})
+
+						AfterEach(func() {
+							close(unblock)
+						})
+
+						It("holds up the process for no longer than the block timeout, without holding up other clients", func() {
+							fast := gbytes.NewBuffer()
+							_, err := container.Attach("process-handle", garden.ProcessIO{Stdout: fast})
+							Expect(err).ToNot(HaveOccurred())
+
+							chunk := bytes.Repeat([]byte("x"), 4096)
+
+							written := make(chan struct{})
+							go func() {
+								defer GinkgoRecover()
+								defer close(written)
+
+								for batch := 1; batch <= 20; batch++ {
+									for i := 0; i < 100; i++ {
+										runIO.Stdout.Write(chunk)
+									}
+
+									Eventually(func() int { return len(fast.Contents()) }).Should(Equal(batch * 100 * len(chunk)))
+								}
+							}()
+
+							Eventually(written, 10*time.Second).Should(BeClosed())
+							Expect(logger.LogMessages()).To(ContainElement(HaveSuffix("stalled-slow-client-output")))
+							Expect(apiServer.DroppedOutputBytes()).To(BeNumerically(">", 0))
+						})
+					})
+				})
+
+				It("does not hold up the process for a client which never reads its output", func() {
+					_, err := container.Attach("process-handle", garden.ProcessIO{Backpressure: garden.BackpressureBlock})
+					Expect(err).ToNot(HaveOccurred())
+
+					chunk := bytes.Repeat([]byte("x"), 4096)
+
+					written := make(chan struct{})
+					go func() {
+						defer GinkgoRecover()
+						defer close(written)
+
+						for i := 0; i < 2000; i++ {
+							runIO.Stdout.Write(chunk)
+						}
+					}()
+
+					Eventually(written, 10*time.Second).Should(BeClosed())
+				})
+
+				Context("when a client asks for an unknown backpressure", func() {
+					It("fails", func() {
+						_, err := container.Attach("process-handle", garden.ProcessIO{Backpressure: "bounce"})
+						Expect(err).To(MatchError(server.ErrInvalidBackpressure.Error()))
+						Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+					})
+				})
+			})
+
+			Context("when the container is not found", func() {
+				It("fails", func() {
+					serverBackend.LookupReturns(nil, errors.New("not found"))
+					_, err := container.Attach("process-handle", garden.ProcessIO{})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			Context("when waiting on the process fails server-side", func() {
+				BeforeEach(func() {
+					fakeContainer.AttachStub = func(id string, io garden.ProcessIO) (garden.Process, error) {
+						process := new(fakes.FakeProcess)
+
+						process.IDReturns("process-handle")
+						process.WaitReturns(0, errors.New("oh no!"))
+
+						return process, nil
+					}
+				})
+
+				It("bubbles the error up", func() {
+					process, err := container.Attach("process-handle", garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = process.Wait()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("oh no!"))
+				})
+			})
+
+			Context("when the process is not found", func() {
+				BeforeEach(func() {
+					fakeContainer.AttachReturns(nil, garden.ProcessNotFoundError{
+						ProcessID: "not-existing-process"},
+					)
+				})
+
+				It("returns a ProcessNotFoundError", func() {
+					_, err := container.Attach("not-existing-process", garden.ProcessIO{})
+					Expect(err).To(MatchError(garden.ProcessNotFoundError{
+						ProcessID: "not-existing-process",
+					}))
+				})
+			})
+
+			Context("when attaching fails", func() {
+				BeforeEach(func() {
+					fakeContainer.AttachReturns(nil, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.Attach("process-handle", garden.ProcessIO{})
+					Expect(err).To(HaveOccurred())
+				})
+
+				It("closes the stdin writer", func() {
+					container.Attach("process-handle", garden.ProcessIO{})
+
+					_, processIO := fakeContainer.AttachArgsForCall(0)
+					_, err := processIO.Stdin.Read([]byte{})
+					Expect(err).To(Equal(io.EOF))
+				})
+			})
+		})
+
+		Describe("running", func() {
+			processSpec := garden.ProcessSpec{
+				ID:   "some-process-id",
+				Path: "/some/script",
+				Args: []string{"arg1", "arg2"},
+				Dir:  "/some/dir",
+				Env: []string{
+					"FLAVOR=chocolate",
+					"TOPPINGS=sprinkles",
+					"TERM=xterm-256color",
+				},
+				User:                "root",
+				SupplementaryGroups: []int{10, 44},
+				Limits: garden.ResourceLimits{
+					As:         uint64ptr(1 << 21),
+					Core:       uint64ptr(2),
+					Cpu:        uint64ptr(3),
+					Data:       uint64ptr(4 << 20),
+					Fsize:      uint64ptr(5),
+					Locks:      uint64ptr(6),
+					Memlock:    uint64ptr(7),
+					Msgqueue:   uint64ptr(8),
+					Nice:       uint64ptr(9),
+					Nofile:     uint64ptr(10),
+					Nproc:      uint64ptr(11),
+					Rss:        uint64ptr(12),
+					Rtprio:     uint64ptr(13),
+					Sigpending: uint64ptr(14),
+					Stack:      uint64ptr(15 << 20),
 				},
-				DiskStat: garden.ContainerDiskStat{
-					TotalBytesUsed:  1,
-					TotalInodesUsed: 2,
+				TTY: &garden.TTYSpec{
+					WindowSize: &garden.WindowSize{
+						Columns: 80,
+						Rows:    24,
+					},
+					Term: "xterm-256color",
+				},
+				Image: garden.ImageRef{
+					URI:      "some-uri",
+					Username: "registry-username",
+					Password: "registry-password",
 				},
 			}
 
-			Context("when getting the metrics succeeds", func() {
+			Describe("auto-destroying the container on exit", func() {
+				var exit chan int
+
 				BeforeEach(func() {
-					fakeContainer.MetricsReturns(
-						containerMetrics,
-						nil,
-					)
+					exit = make(chan int)
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						return <-exit, nil
+					}
+					fakeContainer.RunReturns(process, nil)
+				})
+
+				It("destroys the container once the process exits", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:              "/some/script",
+						AutoDestroyOnExit: true,
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					Consistently(serverBackend.DestroyCallCount).Should(Equal(0))
+
+					close(exit)
+
+					Eventually(serverBackend.DestroyCallCount).Should(Equal(1))
+					Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
+				})
+
+				It("reports the reap as caused by the process exiting", func() {
+					reaps := make(chan server.ReapEvent, 1)
+					apiServer.SetReapObserver(func(event server.ReapEvent) {
+						reaps <- event
+					})
+
+					_, err := container.Run(garden.ProcessSpec{
+						Path:              "/some/script",
+						AutoDestroyOnExit: true,
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					close(exit)
+
+					var event server.ReapEvent
+					Eventually(reaps).Should(Receive(&event))
+					Expect(event.Handle).To(Equal("some-handle"))
+					Expect(event.Reason).To(Equal(server.ReapReasonProcessExited))
+				})
+
+				It("does not destroy the container when not asked to", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path: "/some/script",
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					close(exit)
+
+					Consistently(serverBackend.DestroyCallCount).Should(Equal(0))
+				})
+
+				Context("when asked to keep the container if the process fails", func() {
+					spec := garden.ProcessSpec{
+						Path:              "/some/script",
+						AutoDestroyOnExit: true,
+						KeepOnFailure:     true,
+					}
+
+					BeforeEach(func() {
+						apiServer.SetFailedContainerGraceTime(time.Hour)
+					})
+
+					It("keeps the container for inspection when the process exits non-zero", func() {
+						_, err := container.Run(spec, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
+
+						exit <- 137
+
+						Eventually(fakeContainer.SetGraceTimeCallCount).Should(Equal(1))
+						Expect(fakeContainer.SetGraceTimeArgsForCall(0)).To(Equal(time.Hour))
+
+						Expect(fakeContainer.SetPropertyCallCount()).To(Equal(1))
+						name, value := fakeContainer.SetPropertyArgsForCall(0)
+						Expect(name).To(Equal(garden.FailedProcessProperty))
+						Expect(value).To(Equal("process-handle"))
+
+						Consistently(serverBackend.DestroyCallCount).Should(Equal(0))
+					})
+
+					It("destroys the container as usual when the process succeeds", func() {
+						_, err := container.Run(spec, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
+
+						close(exit)
+
+						Eventually(serverBackend.DestroyCallCount).Should(Equal(1))
+						Expect(fakeContainer.SetGraceTimeCallCount()).To(Equal(0))
+						Expect(fakeContainer.SetPropertyCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			Describe("reporting a process's status", func() {
+				var exited chan struct{}
+
+				BeforeEach(func() {
+					exited = make(chan struct{})
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return 3, nil
+					}
+					fakeContainer.RunReturns(process, nil)
 				})
 
-				It("returns the metrics from the container", func() {
-					value, err := container.Metrics()
+				AfterEach(func() {
+					select {
+					case <-exited:
+					default:
+						close(exited)
+					}
+				})
+
+				It("reports the process as running until it exits, and then its exit status", func() {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
 					Expect(err).ToNot(HaveOccurred())
 
-					Expect(value).To(Equal(containerMetrics))
+					conn := connection.New("unix", socketPath)
+
+					status, err := conn.ProcessStatus("some-handle", "process-handle")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(status).To(Equal(garden.ProcessStatus{State: garden.ProcessStateRunning}))
+
+					close(exited)
+
+					Eventually(func() (garden.ProcessStatus, error) {
+						return conn.ProcessStatus("some-handle", "process-handle")
+					}).Should(Equal(garden.ProcessStatus{
+						State:      garden.ProcessStateExited,
+						ExitStatus: 3,
+					}))
 				})
 
-				itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-					fakeContainer.MetricsStub = func() (garden.Metrics, error) { time.Sleep(timeToSleep); return garden.Metrics{}, nil }
-					_, err := container.Metrics()
+				It("reports the status of the process by its container's new handle once renamed", func() {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					renameContainer("new-handle")
+
+					conn := connection.New("unix", socketPath)
+
+					status, err := conn.ProcessStatus("new-handle", "process-handle")
 					Expect(err).ToNot(HaveOccurred())
+					Expect(status).To(Equal(garden.ProcessStatus{State: garden.ProcessStateRunning}))
+
+					close(exited)
+
+					Eventually(func() (garden.ProcessStatus, error) {
+						return conn.ProcessStatus("new-handle", "process-handle")
+					}).Should(Equal(garden.ProcessStatus{
+						State:      garden.ProcessStateExited,
+						ExitStatus: 3,
+					}))
+				})
+
+				Context("when the process was not run through the server", func() {
+					It("returns a ProcessNotFoundError", func() {
+						_, err := connection.New("unix", socketPath).ProcessStatus("some-handle", "other-process")
+						Expect(err).To(MatchError(garden.ProcessNotFoundError{ProcessID: "other-process"}))
+					})
 				})
 
 				itFailsWhenTheContainerIsNotFound(func() error {
-					_, err := container.Metrics()
+					_, err := connection.New("unix", socketPath).ProcessStatus("some-handle", "process-handle")
 					return err
 				})
 			})
 
-			Context("when getting the metrics fails", func() {
+			Describe("inspecting a process's environment", func() {
+				var exited chan struct{}
+
 				BeforeEach(func() {
-					fakeContainer.MetricsReturns(garden.Metrics{}, errors.New("o no"))
+					exited = make(chan struct{})
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return 0, nil
+					}
+					fakeContainer.RunReturns(process, nil)
 				})
 
-				It("returns an error", func() {
-					metrics, err := container.Metrics()
-					Expect(err).To(HaveOccurred())
-					Expect(metrics).To(Equal(garden.Metrics{}))
+				AfterEach(func() {
+					close(exited)
 				})
-			})
-		})
 
-		Describe("properties", func() {
-			Describe("getting all", func() {
-				Context("when getting the properties succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.PropertiesReturns(garden.Properties{"foo": "bar"}, nil)
+				JustBeforeEach(func() {
+					_, err := apiClient.Create(garden.ContainerSpec{
+						Env: []string{"PATH=/bin", "SECRET_TOKEN=hunter2", "LANG=C"},
 					})
+					Expect(err).ToNot(HaveOccurred())
 
-					It("returns the properties from the container", func() {
-						value, err := container.Properties()
-						Expect(err).ToNot(HaveOccurred())
+					_, err = container.Run(garden.ProcessSpec{
+						Path: "/some/script",
+						Env:  []string{"LANG=en_GB.UTF-8", "DEBUG=1"},
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+				})
 
-						Expect(value).To(Equal(garden.Properties{"foo": "bar"}))
+				It("returns the container's environment overridden by the process's", func() {
+					env, err := connection.New("unix", socketPath).ProcessEnv("some-handle", "process-handle")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(env).To(Equal([]string{"PATH=/bin", "SECRET_TOKEN=hunter2", "LANG=en_GB.UTF-8", "DEBUG=1"}))
+				})
+
+				Context("when sensitive keys are masked", func() {
+					BeforeEach(func() {
+						apiServer.SetProcessEnvMaskedKeys([]string{"*_TOKEN"})
 					})
 
-					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-						fakeContainer.PropertiesStub = func() (garden.Properties, error) { time.Sleep(timeToSleep); return nil, nil }
-						_, err := container.Properties()
+					It("masks their values", func() {
+						env, err := connection.New("unix", socketPath).ProcessEnv("some-handle", "process-handle")
 						Expect(err).ToNot(HaveOccurred())
+						Expect(env).To(Equal([]string{"PATH=/bin", "SECRET_TOKEN=********", "LANG=en_GB.UTF-8", "DEBUG=1"}))
 					})
+				})
 
-					itFailsWhenTheContainerIsNotFound(func() error {
-						_, err := container.Properties()
-						return err
+				Context("when the process was not run through the server", func() {
+					It("returns a ProcessNotFoundError", func() {
+						_, err := connection.New("unix", socketPath).ProcessEnv("some-handle", "other-process")
+						Expect(err).To(MatchError(garden.ProcessNotFoundError{ProcessID: "other-process"}))
 					})
+				})
+			})
 
-					It("should not log any properties", func() {
-						_, err := container.Properties()
-						Expect(err).ToNot(HaveOccurred())
+			Describe("fetching a process's logs", func() {
+				var (
+					processIO chan garden.ProcessIO
+					exited    chan struct{}
+				)
 
-						buffer := sink.Buffer()
-						Expect(buffer).ToNot(gbytes.Say("foo"))
-						Expect(buffer).ToNot(gbytes.Say("bar"))
-					})
+				BeforeEach(func() {
+					processIO = make(chan garden.ProcessIO, 1)
+					exited = make(chan struct{})
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return 0, nil
+					}
+
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						processIO <- io
+						return process, nil
+					}
 				})
 
-				Context("when getting the properties fails", func() {
-					BeforeEach(func() {
-						fakeContainer.PropertiesReturns(nil, errors.New("o no"))
+				AfterEach(func() {
+					close(exited)
+				})
+
+				run := func(bufferSize uint64) garden.ProcessIO {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script", OutputBufferSize: bufferSize}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					var pio garden.ProcessIO
+					Eventually(processIO).Should(Receive(&pio))
+					return pio
+				}
+
+				readLogs := func(cursor uint64) garden.ProcessLog {
+					log, err := connection.New("unix", socketPath).ProcessLogs("some-handle", "process-handle", cursor)
+					Expect(err).ToNot(HaveOccurred())
+					return log
+				}
+
+				It("returns the output written from the cursor on, and the cursor to read on from", func() {
+					pio := run(64 * 1024)
+
+					fmt.Fprint(pio.Stdout, "before\n")
+
+					first := readLogs(0)
+					Expect(first).To(Equal(garden.ProcessLog{Output: []byte("before\n"), Cursor: 1}))
+
+					fmt.Fprint(pio.Stdout, "after\n")
+					fmt.Fprint(pio.Stderr, "error\n")
+
+					Expect(readLogs(first.Cursor)).To(Equal(garden.ProcessLog{Output: []byte("after\nerror\n"), Cursor: 3}))
+					Expect(readLogs(3)).To(Equal(garden.ProcessLog{Output: []byte{}, Cursor: 3}))
+					Expect(readLogs(0).Output).To(Equal([]byte("before\nafter\nerror\n")))
+				})
+
+				It("keeps only as much of the most recent output as the buffer size, reporting what was dropped", func() {
+					pio := run(4096)
+
+					fmt.Fprint(pio.Stdout, strings.Repeat("a", 2000))
+					fmt.Fprint(pio.Stdout, strings.Repeat("b", 2000))
+					fmt.Fprint(pio.Stderr, strings.Repeat("c", 2000))
+
+					log := readLogs(0)
+					Expect(string(log.Output)).To(Equal(strings.Repeat("c", 2000)))
+					Expect(log.Truncated).To(BeTrue())
+					Expect(log.Cursor).To(Equal(uint64(3)))
+
+					Expect(readLogs(2).Truncated).To(BeFalse())
+				})
+
+				It("counts each chunk of output against the buffer size, as well as its data", func() {
+					pio := run(4096)
+
+					for i := 0; i < 1000; i++ {
+						pio.Stdout.Write([]byte("x"))
+					}
+
+					Expect(readLogs(0).Output).To(HaveLen(4096 / 65))
+				})
+
+				It("truncates a single write larger than the buffer to its end", func() {
+					pio := run(4096)
+
+					pio.Stdout.Write([]byte(strings.Repeat("a", 4096) + "end"))
+
+					output := readLogs(0).Output
+					Expect(output).To(HaveLen(4096 - 64))
+					Expect(string(output)).To(HaveSuffix("aend"))
+				})
+
+				It("rounds up a small buffer size", func() {
+					pio := run(8)
+
+					fmt.Fprint(pio.Stdout, "first\n")
+					fmt.Fprint(pio.Stdout, "second\n")
+
+					Expect(readLogs(0).Output).To(Equal([]byte("first\nsecond\n")))
+				})
+
+				It("rejects a buffer larger than the server allows", func() {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script", OutputBufferSize: 17 * 1024 * 1024}, garden.ProcessIO{})
+					Expect(err).To(MatchError(server.ErrOutputBufferTooLarge.Error()))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
+				})
+
+				It("returns the output written after a given time", func() {
+					pio := run(64 * 1024)
+
+					fmt.Fprint(pio.Stdout, "before\n")
+					time.Sleep(10 * time.Millisecond)
+					since := time.Now()
+					time.Sleep(10 * time.Millisecond)
+					fmt.Fprint(pio.Stdout, "after\n")
+					fmt.Fprint(pio.Stderr, "error\n")
+
+					conn := connection.New("unix", socketPath)
+
+					logs, err := conn.ProcessLogsSince("some-handle", "process-handle", since)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ioutil.ReadAll(logs)).To(Equal([]byte("after\nerror\n")))
+
+					logs, err = conn.ProcessLogsSince("some-handle", "process-handle", time.Time{})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ioutil.ReadAll(logs)).To(Equal([]byte("before\nafter\nerror\n")))
+				})
+
+				It("keeps the process's logs when its container is renamed", func() {
+					pio := run(64 * 1024)
+
+					fmt.Fprint(pio.Stdout, "hello\n")
+
+					renameContainer("new-handle")
+
+					log, err := connection.New("unix", socketPath).ProcessLogs("new-handle", "process-handle", 0)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(log.Output).To(Equal([]byte("hello\n")))
+				})
+
+				Context("when the process was run without a buffer size", func() {
+					It("keeps the most recent 1MiB of its output", func() {
+						pio := run(0)
+
+						pio.Stdout.Write(bytes.Repeat([]byte("a"), 1024*1024))
+						fmt.Fprint(pio.Stdout, "hello\n")
+
+						log := readLogs(0)
+						Expect(log.Truncated).To(BeTrue())
+						Expect(string(log.Output)).To(Equal("hello\n"))
 					})
+				})
 
-					It("returns an error", func() {
-						properties, err := container.Properties()
-						Expect(err).To(HaveOccurred())
-						Expect(properties).To(BeEmpty())
+				Context("when the process was not run through the server", func() {
+					It("returns a ProcessNotFoundError", func() {
+						_, err := connection.New("unix", socketPath).ProcessLogs("some-handle", "other-process", 0)
+						Expect(err).To(MatchError(garden.ProcessNotFoundError{ProcessID: "other-process"}))
 					})
 				})
+
+				itFailsWhenTheContainerIsNotFound(func() error {
+					_, err := connection.New("unix", socketPath).ProcessLogs("some-handle", "process-handle", 0)
+					return err
+				})
 			})
 
-			Describe("getting", func() {
-				Context("when getting the property succeeds", func() {
+			Describe("attaching to the output of every process", func() {
+				var (
+					exits map[string]chan struct{}
+					conn  connection.Connection
+				)
+
+				newProcess := func(id string) *fakes.FakeProcess {
+					exit := make(chan struct{})
+					exits[id] = exit
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns(id)
+					process.WaitStub = func() (int, error) {
+						<-exit
+						return 7, nil
+					}
+
+					return process
+				}
+
+				BeforeEach(func() {
+					exits = map[string]chan struct{}{}
+					conn = connection.New("unix", socketPath)
+
+					running := newProcess("running-process")
+					spawned := newProcess("spawned-process")
+
+					fakeContainer.InfoReturns(garden.ContainerInfo{ProcessIDs: []string{"running-process"}}, nil)
+					fakeContainer.RunReturns(spawned, nil)
+					fakeContainer.AttachStub = func(processID string, io garden.ProcessIO) (garden.Process, error) {
+						switch processID {
+						case "running-process":
+							io.Stdout.Write([]byte("hello from running"))
+							return running, nil
+						case "spawned-process":
+							io.Stderr.Write([]byte("hello from spawned"))
+							return spawned, nil
+						default:
+							return nil, garden.ProcessNotFoundError{ProcessID: processID}
+						}
+					}
+				})
+
+				AfterEach(func() {
+					for _, exit := range exits {
+						select {
+						case <-exit:
+						default:
+							close(exit)
+						}
+					}
+				})
+
+				It("streams the tagged output of running and newly spawned processes, and their exits", func() {
+					output, _, err := conn.AttachAll("some-handle")
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(output).Should(Receive(Equal(garden.TaggedOutput{
+						ProcessID: "running-process",
+						Source:    garden.OutputSourceStdout,
+						Data:      []byte("hello from running"),
+					})))
+
+					_, err = container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(output).Should(Receive(Equal(garden.TaggedOutput{
+						ProcessID: "spawned-process",
+						Source:    garden.OutputSourceStderr,
+						Data:      []byte("hello from spawned"),
+					})))
+
+					close(exits["running-process"])
+
+					Eventually(output).Should(Receive(Equal(garden.TaggedOutput{
+						ProcessID:  "running-process",
+						Exited:     true,
+						ExitStatus: 7,
+					})))
+				})
+
+				It("ends the stream when the container is destroyed", func() {
+					output, _, err := conn.AttachAll("some-handle")
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(output).Should(Receive())
+
+					Expect(apiClient.Destroy("some-handle")).To(Succeed())
+
+					Eventually(output).Should(BeClosed())
+				})
+
+				Context("when the client stops streaming", func() {
+					var (
+						running *fakes.FakeProcess
+						stdout  io.Writer
+					)
+
 					BeforeEach(func() {
-						fakeContainer.PropertyReturns("some-property-value", nil)
+						running = newProcess("running-process")
+
+						fakeContainer.AttachStub = func(processID string, io garden.ProcessIO) (garden.Process, error) {
+							stdout = io.Stdout
+							return running, nil
+						}
 					})
 
-					It("returns the property from the container", func() {
-						value, err := container.Property("some-property")
+					It("refuses the output of the processes it was attached to", func() {
+						output, stop, err := conn.AttachAll("some-handle")
 						Expect(err).ToNot(HaveOccurred())
 
-						Expect(value).To(Equal("some-property-value"))
+						Eventually(fakeContainer.AttachCallCount).Should(Equal(1))
 
-						Expect(fakeContainer.PropertyCallCount()).To(Equal(1))
+						stop()
+						Eventually(output).Should(BeClosed())
 
-						name := fakeContainer.PropertyArgsForCall(0)
-						Expect(name).To(Equal("some-property"))
+						Eventually(func() error {
+							_, err := stdout.Write([]byte("hello"))
+							return err
+						}).Should(HaveOccurred())
 					})
 
-					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-						fakeContainer.PropertyStub = func(string) (string, error) { time.Sleep(timeToSleep); return "", nil }
-						_, err := container.Property("some-property")
-						Expect(err).ToNot(HaveOccurred())
-					})
+					It("waits for each process once, however many streams there have been", func() {
+						for i := 0; i < 3; i++ {
+							output, stop, err := conn.AttachAll("some-handle")
+							Expect(err).ToNot(HaveOccurred())
 
-					itFailsWhenTheContainerIsNotFound(func() error {
-						_, err := container.Property("some-property")
-						return err
-					})
+							Eventually(fakeContainer.AttachCallCount).Should(Equal(i + 1))
 
-					It("should not log any properties", func() {
-						_, err := container.Property("some-property")
-						Expect(err).ToNot(HaveOccurred())
+							stop()
+							Eventually(output).Should(BeClosed())
+						}
 
-						buffer := sink.Buffer()
-						Expect(buffer).ToNot(gbytes.Say("some-property"))
-						Expect(buffer).ToNot(gbytes.Say("some-property-value"))
+						Consistently(running.WaitCallCount).Should(Equal(1))
 					})
 				})
 
-				Context("when getting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.PropertyReturns("", errors.New("oh no!"))
-					})
-
-					It("returns an error", func() {
-						value, err := container.Property("some-property")
-						Expect(err).To(HaveOccurred())
-						Expect(value).To(BeZero())
-					})
+				itFailsWhenTheContainerIsNotFound(func() error {
+					_, _, err := conn.AttachAll("some-handle")
+					return err
 				})
 			})
 
-			Describe("setting", func() {
-				Context("when setting the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.SetPropertyReturns(nil)
-					})
+			Describe("waiting for every process to exit", func() {
+				var (
+					exits     map[string]chan struct{}
+					processes map[string]*fakes.FakeProcess
+					conn      connection.Connection
+				)
+
+				newProcess := func(id string) *fakes.FakeProcess {
+					exit := make(chan struct{})
+					exits[id] = exit
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns(id)
+					process.WaitStub = func() (int, error) {
+						<-exit
+						return 0, nil
+					}
 
-					It("sets the property on the container", func() {
-						err := container.SetProperty("some-property", "some-value")
-						Expect(err).ToNot(HaveOccurred())
+					return process
+				}
 
-						Expect(fakeContainer.SetPropertyCallCount()).To(Equal(1))
+				waitForProcesses := func(timeout time.Duration) <-chan error {
+					waited := make(chan error, 1)
+					go func() {
+						defer GinkgoRecover()
+						waited <- conn.WaitForProcesses("some-handle", timeout)
+					}()
+
+					return waited
+				}
+
+				BeforeEach(func() {
+					exits = map[string]chan struct{}{}
+					conn = connection.New("unix", socketPath)
+
+					processes = map[string]*fakes.FakeProcess{
+						"first-process":   newProcess("first-process"),
+						"second-process":  newProcess("second-process"),
+						"spawned-process": newProcess("spawned-process"),
+					}
+
+					fakeContainer.InfoReturns(garden.ContainerInfo{ProcessIDs: []string{"first-process", "second-process"}}, nil)
+					fakeContainer.RunReturns(processes["spawned-process"], nil)
+					fakeContainer.AttachStub = func(processID string, io garden.ProcessIO) (garden.Process, error) {
+						if process, found := processes[processID]; found {
+							return process, nil
+						}
+
+						return nil, garden.ProcessNotFoundError{ProcessID: processID}
+					}
+				})
+
+				AfterEach(func() {
+					for _, exit := range exits {
+						select {
+						case <-exit:
+						default:
+							close(exit)
+						}
+					}
+				})
+
+				It("returns once every process has exited", func() {
+					waited := waitForProcesses(0)
+
+					close(exits["first-process"])
+					Consistently(waited).ShouldNot(Receive())
+
+					close(exits["second-process"])
+					Eventually(waited).Should(Receive(BeNil()))
+				})
+
+				It("also waits for processes started while it waits", func() {
+					waited := waitForProcesses(0)
+					Eventually(fakeContainer.AttachCallCount).Should(Equal(2))
+
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					close(exits["first-process"])
+					close(exits["second-process"])
+					Consistently(waited).ShouldNot(Receive())
+
+					close(exits["spawned-process"])
+					Eventually(waited).Should(Receive(BeNil()))
+				})
+
+				It("treats processes which cannot be attached to as exited", func() {
+					fakeContainer.InfoReturns(garden.ContainerInfo{ProcessIDs: []string{"gone-process"}}, nil)
+
+					Eventually(waitForProcesses(0)).Should(Receive(BeNil()))
+				})
+
+				Context("when the timeout expires first", func() {
+					It("returns a ProcessesRunningError naming the processes still running", func() {
+						waited := waitForProcesses(0)
+						close(exits["first-process"])
+
+						err := conn.WaitForProcesses("some-handle", 100*time.Millisecond)
+						Expect(err).To(Equal(garden.ProcessesRunningError{
+							Handle:     "some-handle",
+							ProcessIDs: []string{"second-process"},
+						}))
 
-						name, value := fakeContainer.SetPropertyArgsForCall(0)
-						Expect(name).To(Equal("some-property"))
-						Expect(value).To(Equal("some-value"))
+						Consistently(waited).ShouldNot(Receive())
 					})
 
-					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-						fakeContainer.SetPropertyStub = func(string, string) error { time.Sleep(timeToSleep); return nil }
-						err := container.SetProperty("some-property", "some-value")
-						Expect(err).ToNot(HaveOccurred())
-					})
+					It("waits on each process once, however many waits time out", func() {
+						for i := 0; i < 3; i++ {
+							err := conn.WaitForProcesses("some-handle", 50*time.Millisecond)
+							Expect(err).To(BeAssignableToTypeOf(garden.ProcessesRunningError{}))
+						}
 
-					itFailsWhenTheContainerIsNotFound(func() error {
-						return container.SetProperty("some-property", "some-value")
+						Consistently(processes["first-process"].WaitCallCount).Should(Equal(1))
+						Consistently(processes["second-process"].WaitCallCount).Should(Equal(1))
 					})
 
-					It("should not log any properties", func() {
-						err := container.SetProperty("some-property", "some-value")
-						Expect(err).ToNot(HaveOccurred())
+					It("waits on each process once when its container is renamed between waits", func() {
+						err := conn.WaitForProcesses("some-handle", 50*time.Millisecond)
+						Expect(err).To(BeAssignableToTypeOf(garden.ProcessesRunningError{}))
 
-						buffer := sink.Buffer()
-						Expect(buffer).ToNot(gbytes.Say("some-property"))
-						Expect(buffer).ToNot(gbytes.Say("some-value"))
-					})
-				})
+						renameContainer("new-handle")
 
-				Context("when setting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.SetPropertyReturns(errors.New("oh no!"))
-					})
+						err = conn.WaitForProcesses("new-handle", 50*time.Millisecond)
+						Expect(err).To(BeAssignableToTypeOf(garden.ProcessesRunningError{}))
 
-					It("returns an error", func() {
-						err := container.SetProperty("some-property", "some-value")
-						Expect(err).To(HaveOccurred())
+						Consistently(processes["first-process"].WaitCallCount).Should(Equal(1))
+						Consistently(processes["second-process"].WaitCallCount).Should(Equal(1))
 					})
 				})
+
+				itFailsWhenTheContainerIsNotFound(func() error {
+					return conn.WaitForProcesses("some-handle", time.Second)
+				})
 			})
 
-			Describe("removing", func() {
-				Context("when removing the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.RemovePropertyReturns(nil)
-					})
+			Describe("listing process attachments", func() {
+				var exited chan struct{}
 
-					It("returns the property from the container", func() {
-						err := container.RemoveProperty("some-property")
-						Expect(err).ToNot(HaveOccurred())
+				BeforeEach(func() {
+					exited = make(chan struct{})
 
-						Expect(fakeContainer.RemovePropertyCallCount()).To(Equal(1))
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return 0, nil
+					}
+					fakeContainer.RunReturns(process, nil)
+					fakeContainer.AttachReturns(process, nil)
+				})
 
-						name := fakeContainer.RemovePropertyArgsForCall(0)
-						Expect(name).To(Equal("some-property"))
-					})
+				AfterEach(func() {
+					select {
+					case <-exited:
+					default:
+						close(exited)
+					}
+				})
 
-					itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-						fakeContainer.RemovePropertyStub = func(string) error { time.Sleep(timeToSleep); return nil }
-						err := container.RemoveProperty("some-property")
-						Expect(err).ToNot(HaveOccurred())
-					})
+				It("lists every connection streaming from the process until it exits", func() {
+					before := time.Now()
 
-					itFailsWhenTheContainerIsNotFound(func() error {
-						return container.RemoveProperty("some-property")
-					})
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-					It("should not log any properties", func() {
-						err := container.RemoveProperty("some-property")
+					_, err = container.Attach("process-handle", garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					conn := connection.New("unix", socketPath)
+
+					var attachments []garden.Attachment
+					Eventually(func() []garden.Attachment {
+						attachments, err = conn.ProcessAttachments("some-handle")
 						Expect(err).ToNot(HaveOccurred())
+						return attachments
+					}).Should(HaveLen(2))
 
-						buffer := sink.Buffer()
-						Expect(buffer).ToNot(gbytes.Say("some-property"))
-						Expect(buffer).ToNot(gbytes.Say("some-value"))
-					})
-				})
+					for _, attachment := range attachments {
+						Expect(attachment.ProcessID).To(Equal("process-handle"))
+						Expect(attachment.AttachedAt).To(BeTemporally(">=", before))
+					}
+					Expect(attachments[0].StreamID).ToNot(Equal(attachments[1].StreamID))
 
-				Context("when setting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.RemovePropertyReturns(errors.New("oh no!"))
-					})
+					close(exited)
 
-					It("returns an error", func() {
-						err := container.RemoveProperty("some-property")
-						Expect(err).To(HaveOccurred())
-					})
+					Eventually(func() ([]garden.Attachment, error) {
+						return conn.ProcessAttachments("some-handle")
+					}).Should(BeEmpty())
 				})
-			})
-		})
 
-		Describe("streaming in", func() {
-			It("streams the file in, waits for completion, and succeeds", func() {
-				data := bytes.NewBufferString("chunk-1;chunk-2;chunk-3;")
+				It("lists the connections by the container's new handle once it is renamed", func() {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-				fakeContainer.StreamInStub = func(spec garden.StreamInSpec) error {
-					Expect(spec.Path).To(Equal("/dst/path"))
-					Expect(spec.User).To(Equal("frank"))
-					Expect(ioutil.ReadAll(spec.TarStream)).To(Equal([]byte("chunk-1;chunk-2;chunk-3;")))
-					return nil
-				}
+					conn := connection.New("unix", socketPath)
+					Eventually(func() ([]garden.Attachment, error) {
+						return conn.ProcessAttachments("some-handle")
+					}).Should(HaveLen(1))
 
-				err := container.StreamIn(garden.StreamInSpec{User: "frank", Path: "/dst/path", TarStream: data})
-				Expect(err).ToNot(HaveOccurred())
+					renameContainer("new-handle")
 
-				Expect(fakeContainer.StreamInCallCount()).To(Equal(1))
-			})
+					attachments, err := conn.ProcessAttachments("new-handle")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(attachments).To(HaveLen(1))
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				return container.StreamIn(garden.StreamInSpec{Path: "/dst/path"})
-			})
+					close(exited)
 
-			Context("when copying in to the container fails", func() {
-				BeforeEach(func() {
-					fakeContainer.StreamInReturns(errors.New("oh no!"))
+					Eventually(func() ([]garden.Attachment, error) {
+						return conn.ProcessAttachments("new-handle")
+					}).Should(BeEmpty())
 				})
 
-				It("fails", func() {
-					err := container.StreamIn(garden.StreamInSpec{User: "bob", Path: "/dst/path"})
-					Expect(err).To(HaveOccurred())
+				itFailsWhenTheContainerIsNotFound(func() error {
+					_, err := connection.New("unix", socketPath).ProcessAttachments("some-handle")
+					return err
 				})
 			})
-		})
 
-		Describe("streaming out", func() {
-			var streamOut io.ReadCloser
+			Describe("limiting the runtime", func() {
+				var (
+					process *fakes.FakeProcess
+					exited  chan struct{}
+					status  int32
+				)
 
-			BeforeEach(func() {
-				streamOut = ioutil.NopCloser(bytes.NewBuffer([]byte("hello-world!")))
-			})
+				BeforeEach(func() {
+					exited = make(chan struct{})
+					status = 0
+
+					process = new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return int(atomic.LoadInt32(&status)), nil
+					}
+					process.SignalStub = func(garden.Signal) error {
+						atomic.StoreInt32(&status, 137)
+						close(exited)
+						return nil
+					}
+					fakeContainer.RunReturns(process, nil)
+				})
 
-			JustBeforeEach(func() {
-				fakeContainer.StreamOutReturns(streamOut, nil)
-			})
+				It("kills the process and reports that its runtime was exceeded", func() {
+					ranProcess, err := container.Run(garden.ProcessSpec{
+						Path:       "/some/script",
+						MaxRuntime: 100 * time.Millisecond,
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-			It("streams the bits out and succeeds", func() {
-				reader, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
-				Expect(err).ToNot(HaveOccurred())
-				Expect(reader).ToNot(BeZero())
+					exitStatus, err := ranProcess.Wait()
+					Expect(exitStatus).To(Equal(137))
+					Expect(err).To(Equal(garden.ProcessRuntimeExceededError{ProcessID: "process-handle"}))
 
-				streamedContent, err := ioutil.ReadAll(reader)
-				Expect(err).ToNot(HaveOccurred())
+					Expect(process.SignalCallCount()).To(Equal(1))
+					Expect(process.SignalArgsForCall(0)).To(Equal(garden.SignalKill))
+				})
 
-				Expect(string(streamedContent)).To(Equal("hello-world!"))
+				It("reports that its runtime was exceeded to clients attached to it", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:       "/some/script",
+						MaxRuntime: 100 * time.Millisecond,
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-				Expect(fakeContainer.StreamOutArgsForCall(0)).To(Equal(garden.StreamOutSpec{User: "frank", Path: "/src/path"}))
-			})
+					fakeContainer.AttachReturns(process, nil)
 
-			Context("when the connection dies as we're streaming", func() {
-				var closer *closeChecker
+					attachedProcess, err := container.Attach("process-handle", garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-				BeforeEach(func() {
-					closer = &closeChecker{}
+					_, err = attachedProcess.Wait()
+					Expect(err).To(Equal(garden.ProcessRuntimeExceededError{ProcessID: "process-handle"}))
+				})
 
-					streamOut = closer
+				It("reports that its runtime was exceeded to clients attached to it once its container is renamed", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:       "/some/script",
+						MaxRuntime: 100 * time.Millisecond,
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					renameContainer("new-handle")
+
+					fakeContainer.AttachReturns(process, nil)
+
+					attachedProcess, err := connection.New("unix", socketPath).Attach("new-handle", "process-handle", garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = attachedProcess.Wait()
+					Expect(err).To(Equal(garden.ProcessRuntimeExceededError{ProcessID: "process-handle"}))
 				})
 
-				It("closes the backend's stream", func() {
-					reader, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
+				It("leaves a process that exits in time alone", func() {
+					ranProcess, err := container.Run(garden.ProcessSpec{
+						Path:       "/some/script",
+						MaxRuntime: 100 * time.Millisecond,
+					}, garden.ProcessIO{})
 					Expect(err).ToNot(HaveOccurred())
 
-					err = reader.Close()
+					close(exited)
+
+					exitStatus, err := ranProcess.Wait()
 					Expect(err).ToNot(HaveOccurred())
+					Expect(exitStatus).To(Equal(0))
 
-					Eventually(closer.Closed).Should(BeTrue())
+					Consistently(process.SignalCallCount, 200*time.Millisecond).Should(Equal(0))
 				})
 			})
 
-			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-				fakeContainer.StreamOutStub = func(garden.StreamOutSpec) (io.ReadCloser, error) {
-					time.Sleep(timeToSleep)
-					return gbytes.NewBuffer(), nil
-				}
-				reader, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
-				Expect(err).ToNot(HaveOccurred())
+			Describe("limiting the output", func() {
+				var (
+					process *fakes.FakeProcess
+					exited  chan struct{}
+					status  int32
+				)
 
-				_, err = ioutil.ReadAll(reader)
-				Expect(err).ToNot(HaveOccurred())
-			})
+				BeforeEach(func() {
+					exited = make(chan struct{})
+					status = 0
+
+					process = new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return int(atomic.LoadInt32(&status)), nil
+					}
+					process.SignalStub = func(garden.Signal) error {
+						atomic.StoreInt32(&status, 137)
+						close(exited)
+						return nil
+					}
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				_, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
-				return err
-			})
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						io.Stdout.Write([]byte("0123456789"))
+						io.Stderr.Write([]byte("abcdefghij"))
 
-			Context("when streaming out of the container fails", func() {
-				JustBeforeEach(func() {
-					fakeContainer.StreamOutReturns(nil, errors.New("oh no!"))
+						return process, nil
+					}
 				})
 
-				It("returns an error", func() {
-					_, err := container.StreamOut(garden.StreamOutSpec{User: "frank", Path: "/src/path"})
-					Expect(err).To(HaveOccurred())
-				})
-			})
-		})
+				It("truncates the output once it exceeds the limit, and reports so", func() {
+					stdout := gbytes.NewBuffer()
+					stderr := gbytes.NewBuffer()
 
-		Describe("getting the current bandwidth limits", func() {
-			It("returns the limits returned by the backend", func() {
-				effectiveLimits := garden.BandwidthLimits{
-					RateInBytesPerSecond:      1230,
-					BurstRateInBytesPerSecond: 4560,
-				}
+					ranProcess, err := container.Run(garden.ProcessSpec{
+						Path:           "/some/script",
+						MaxOutputBytes: 15,
+					}, garden.ProcessIO{Stdout: stdout, Stderr: stderr})
+					Expect(err).ToNot(HaveOccurred())
 
-				fakeContainer.CurrentBandwidthLimitsReturns(effectiveLimits, nil)
+					close(exited)
 
-				limits, err := container.CurrentBandwidthLimits()
-				Expect(err).ToNot(HaveOccurred())
+					exitStatus, err := ranProcess.Wait()
+					Expect(exitStatus).To(Equal(0))
+					Expect(err).To(Equal(garden.ProcessOutputLimitExceededError{ProcessID: "process-handle"}))
 
-				Expect(limits).To(Equal(effectiveLimits))
-			})
+					Expect(stdout.Contents()).To(Equal([]byte("0123456789")))
+					Expect(stderr.Contents()).To(Equal([]byte("abcde")))
 
-			Context("when getting the current limits fails", func() {
-				BeforeEach(func() {
-					fakeContainer.CurrentBandwidthLimitsReturns(garden.BandwidthLimits{}, errors.New("oh no!"))
+					Expect(process.SignalCallCount()).To(Equal(0))
 				})
 
-				It("fails", func() {
-					_, err := container.CurrentBandwidthLimits()
-					Expect(err).To(HaveOccurred())
+				Context("when the process is to be killed once it exceeds the limit", func() {
+					It("kills it, and reports so", func() {
+						ranProcess, err := container.Run(garden.ProcessSpec{
+							Path:            "/some/script",
+							MaxOutputBytes:  15,
+							KillOnMaxOutput: true,
+						}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
+
+						exitStatus, err := ranProcess.Wait()
+						Expect(exitStatus).To(Equal(137))
+						Expect(err).To(Equal(garden.ProcessOutputLimitExceededError{ProcessID: "process-handle"}))
+
+						Expect(process.SignalCallCount()).To(Equal(1))
+						Expect(process.SignalArgsForCall(0)).To(Equal(garden.SignalKill))
+					})
 				})
-			})
-		})
 
-		Describe("getting memory limits", func() {
-			It("obtains the current limits", func() {
-				effectiveLimits := garden.MemoryLimits{LimitInBytes: 2048}
-				fakeContainer.CurrentMemoryLimitsReturns(effectiveLimits, nil)
+				It("reports that its output was truncated to clients attached to it", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:           "/some/script",
+						MaxOutputBytes: 15,
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-				limits, err := container.CurrentMemoryLimits()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(limits).ToNot(BeZero())
+					fakeContainer.AttachReturns(process, nil)
 
-				Expect(limits).To(Equal(effectiveLimits))
-			})
+					attachedProcess, err := container.Attach("process-handle", garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				_, err := container.CurrentMemoryLimits()
-				return err
-			})
+					close(exited)
 
-			Context("when getting the current memory limits fails", func() {
-				BeforeEach(func() {
-					fakeContainer.CurrentMemoryLimitsReturns(garden.MemoryLimits{}, errors.New("oh no!"))
+					_, err = attachedProcess.Wait()
+					Expect(err).To(Equal(garden.ProcessOutputLimitExceededError{ProcessID: "process-handle"}))
 				})
 
-				It("fails", func() {
-					_, err := container.CurrentMemoryLimits()
-					Expect(err).To(HaveOccurred())
+				It("reports that its output was truncated to clients attached to it once its container is renamed", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:           "/some/script",
+						MaxOutputBytes: 15,
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					renameContainer("new-handle")
+
+					fakeContainer.AttachReturns(process, nil)
+
+					attachedProcess, err := connection.New("unix", socketPath).Attach("new-handle", "process-handle", garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					close(exited)
+
+					_, err = attachedProcess.Wait()
+					Expect(err).To(Equal(garden.ProcessOutputLimitExceededError{ProcessID: "process-handle"}))
 				})
-			})
-		})
 
-		Describe("getting the current disk limits", func() {
-			currentLimits := garden.DiskLimits{
-				InodeSoft: 3333,
-				InodeHard: 4444,
+				Context("when the backend hands out the output to clients attached to it", func() {
+					BeforeEach(func() {
+						apiServer.SetOutputLogDir(filepath.Join(tmpdir, "output-logs"))
 
-				ByteSoft: 5555,
-				ByteHard: 6666,
-			}
+						fakeContainer.AttachStub = func(processID string, io garden.ProcessIO) (garden.Process, error) {
+							io.Stdout.Write([]byte("klmnopqrst"))
+							return process, nil
+						}
+					})
+
+					It("only passes on as much as the process has still to write", func() {
+						_, err := container.Run(garden.ProcessSpec{
+							Path:           "/some/script",
+							MaxOutputBytes: 25,
+							OutputLog:      &garden.OutputLogSpec{Name: "job.log"},
+						}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
+
+						stdout := gbytes.NewBuffer()
+
+						attachedProcess, err := container.Attach("process-handle", garden.ProcessIO{Stdout: stdout})
+						Expect(err).ToNot(HaveOccurred())
+
+						close(exited)
+
+						_, err = attachedProcess.Wait()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(stdout.Contents()).To(Equal([]byte("klmno")))
+					})
+				})
+
+				It("leaves a process whose output is within the limit alone", func() {
+					stdout := gbytes.NewBuffer()
 
-			It("returns the limits returned by the backend", func() {
-				fakeContainer.CurrentDiskLimitsReturns(currentLimits, nil)
+					ranProcess, err := container.Run(garden.ProcessSpec{
+						Path:            "/some/script",
+						MaxOutputBytes:  20,
+						KillOnMaxOutput: true,
+					}, garden.ProcessIO{Stdout: stdout})
+					Expect(err).ToNot(HaveOccurred())
 
-				limits, err := container.CurrentDiskLimits()
-				Expect(err).ToNot(HaveOccurred())
+					close(exited)
 
-				Expect(limits).To(Equal(currentLimits))
-			})
+					exitStatus, err := ranProcess.Wait()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(exitStatus).To(Equal(0))
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				_, err := container.CurrentDiskLimits()
-				return err
+					Expect(stdout.Contents()).To(Equal([]byte("0123456789")))
+					Expect(process.SignalCallCount()).To(Equal(0))
+				})
 			})
 
-			Context("when getting the current disk limits fails", func() {
+			Describe("writing output to a log on the host", func() {
+				var logDir string
+
 				BeforeEach(func() {
-					fakeContainer.CurrentDiskLimitsReturns(garden.DiskLimits{}, errors.New("oh no!"))
-				})
+					logDir = filepath.Join(tmpdir, "output-logs")
 
-				It("fails", func() {
-					_, err := container.CurrentDiskLimits()
-					Expect(err).To(HaveOccurred())
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						io.Stdout.Write([]byte("hello\n"))
+						io.Stderr.Write([]byte("oops\n"))
+
+						process := new(fakes.FakeProcess)
+						process.IDReturns("process-handle")
+						process.WaitReturns(0, nil)
+
+						return process, nil
+					}
 				})
-			})
-		})
 
-		Describe("get the current cpu limits", func() {
-			effectiveLimits := garden.CPULimits{LimitInShares: 456}
+				Context("when the server has a directory for output logs", func() {
+					BeforeEach(func() {
+						apiServer.SetOutputLogDir(logDir)
+					})
 
-			It("gets the current limits", func() {
-				fakeContainer.CurrentCPULimitsReturns(effectiveLimits, nil)
+					It("writes the output to the log instead of streaming it", func() {
+						stdout := new(bytes.Buffer)
 
-				limits, err := container.CurrentCPULimits()
-				Expect(err).ToNot(HaveOccurred())
+						process, err := container.Run(garden.ProcessSpec{
+							Path:      "/some/script",
+							OutputLog: &garden.OutputLogSpec{Name: "job.log"},
+						}, garden.ProcessIO{
+							Stdout: stdout,
+						})
+						Expect(err).ToNot(HaveOccurred())
 
-				Expect(limits).To(Equal(effectiveLimits))
-			})
+						status, err := process.Wait()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(status).To(Equal(0))
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				_, err := container.CurrentCPULimits()
-				return err
-			})
+						Expect(stdout.String()).To(BeEmpty())
+						Expect(ioutil.ReadFile(filepath.Join(logDir, "some-handle", "job.log"))).To(Equal([]byte("hello\noops\n")))
+					})
 
-			Context("when getting the current CPU limits fails", func() {
-				BeforeEach(func() {
-					fakeContainer.CurrentCPULimitsReturns(garden.CPULimits{}, errors.New("oh no!"))
-				})
+					It("can stream the log back out", func() {
+						process, err := container.Run(garden.ProcessSpec{
+							Path:      "/some/script",
+							OutputLog: &garden.OutputLogSpec{Name: "job.log"},
+						}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
 
-				It("fails", func() {
-					_, err := container.CurrentCPULimits()
-					Expect(err).To(HaveOccurred())
-				})
-			})
-		})
+						_, err = process.Wait()
+						Expect(err).ToNot(HaveOccurred())
 
-		Describe("setting the grace time", func() {
-			BeforeEach(func() {
-				graceTime = time.Second
-				serverBackend.GraceTimeReturns(graceTime)
-			})
+						reader, err := connection.New("unix", socketPath).StreamOutputLog("some-handle", "job.log")
+						Expect(err).ToNot(HaveOccurred())
+						defer reader.Close()
 
-			It("destroys the container after it has been idle for the grace time", func() {
-				before := time.Now()
-				Expect(container.SetGraceTime(graceTime)).To(Succeed())
+						Expect(ioutil.ReadAll(reader)).To(Equal([]byte("hello\noops\n")))
+					})
 
-				Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
-				Expect(serverBackend.DestroyArgsForCall(0)).To(Equal("some-handle"))
+					It("removes the logs when the container is destroyed", func() {
+						process, err := container.Run(garden.ProcessSpec{
+							Path:      "/some/script",
+							OutputLog: &garden.OutputLogSpec{Name: "job.log"},
+						}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
 
-				Expect(time.Since(before)).To(BeNumerically(">=", graceTime))
-				Expect(time.Since(before)).To(BeNumerically("<", graceTime+time.Second))
-			})
-		})
+						_, err = process.Wait()
+						Expect(err).ToNot(HaveOccurred())
 
-		Describe("net in", func() {
-			It("maps the ports and returns them", func() {
-				fakeContainer.NetInReturns(111, 222, nil)
+						Expect(apiClient.Destroy("some-handle")).To(Succeed())
 
-				hostPort, containerPort, err := container.NetIn(123, 456)
-				Expect(err).ToNot(HaveOccurred())
+						Expect(filepath.Join(logDir, "some-handle")).ToNot(BeADirectory())
+					})
 
-				hp, cp := fakeContainer.NetInArgsForCall(0)
-				Expect(hp).To(Equal(uint32(123)))
-				Expect(cp).To(Equal(uint32(456)))
+					Context("when processes write to the same log at once", func() {
+						var (
+							outputs []io.Writer
+							exited  chan struct{}
+						)
 
-				Expect(hostPort).To(Equal(uint32(111)))
-				Expect(containerPort).To(Equal(uint32(222)))
-			})
+						BeforeEach(func() {
+							outputs = nil
+							exited = make(chan struct{})
 
-			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-				fakeContainer.NetInStub = func(uint32, uint32) (uint32, uint32, error) { time.Sleep(timeToSleep); return 0, 0, nil }
-				_, _, err := container.NetIn(123, 456)
-				Expect(err).ToNot(HaveOccurred())
-			})
+							fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+								outputs = append(outputs, io.Stdout)
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				_, _, err := container.NetIn(123, 456)
-				return err
-			})
+								process := new(fakes.FakeProcess)
+								process.IDReturns(fmt.Sprintf("process-%d", len(outputs)))
+								process.WaitStub = func() (int, error) {
+									<-exited
+									return 0, nil
+								}
 
-			Context("when mapping the port fails", func() {
-				BeforeEach(func() {
-					fakeContainer.NetInReturns(0, 0, errors.New("oh no!"))
-				})
+								return process, nil
+							}
+						})
+
+						It("rotates the log once they have written its maximum size between them", func() {
+							spec := garden.ProcessSpec{
+								Path: "/some/script",
+								OutputLog: &garden.OutputLogSpec{
+									Name:           "job.log",
+									MaxSizeInBytes: 10,
+									MaxBackups:     1,
+								},
+							}
 
-				It("fails", func() {
-					_, _, err := container.NetIn(123, 456)
-					Expect(err).To(HaveOccurred())
-				})
-			})
-		})
+							_, err := container.Run(spec, garden.ProcessIO{})
+							Expect(err).ToNot(HaveOccurred())
 
-		Describe("net out", func() {
-			Context("when a zero-value NetOutRule is supplied", func() {
-				It("permits all TCP traffic to everywhere, with logging not enabled", func() {
-					Expect(container.NetOut(garden.NetOutRule{})).To(Succeed())
-					rule := fakeContainer.NetOutArgsForCall(0)
+							_, err = container.Run(spec, garden.ProcessIO{})
+							Expect(err).ToNot(HaveOccurred())
 
-					Expect(rule.Protocol).To(Equal(garden.ProtocolAll))
-					Expect(rule.Networks).To(BeNil())
-					Expect(rule.Ports).To(BeNil())
-					Expect(rule.ICMPs).To(BeNil())
-					Expect(rule.Log).To(Equal(false))
-				})
-			})
+							Expect(outputs).To(HaveLen(2))
+							outputs[0].Write([]byte("0123456\n"))
+							outputs[1].Write([]byte("abcdefg\n"))
 
-			Context("when protocol is specified", func() {
-				Context("as TCP", func() {
-					It("permits TCP traffic", func() {
-						Expect(container.NetOut(garden.NetOutRule{
-							Protocol: garden.ProtocolTCP,
-						})).To(Succeed())
-						rule := fakeContainer.NetOutArgsForCall(0)
-						Expect(rule.Protocol).To(Equal(garden.ProtocolTCP))
-					})
-				})
+							close(exited)
 
-				Context("as UDP", func() {
-					It("permits UDP traffic", func() {
-						Expect(container.NetOut(garden.NetOutRule{
-							Protocol: garden.ProtocolUDP,
-						})).To(Succeed())
-						rule := fakeContainer.NetOutArgsForCall(0)
-						Expect(rule.Protocol).To(Equal(garden.ProtocolUDP))
+							Expect(ioutil.ReadFile(filepath.Join(logDir, "some-handle", "job.log.1"))).To(Equal([]byte("0123456\n")))
+							Expect(ioutil.ReadFile(filepath.Join(logDir, "some-handle", "job.log"))).To(Equal([]byte("abcdefg\n")))
+						})
 					})
-				})
 
-				Context("as ICMP", func() {
-					It("permits ICMP traffic", func() {
-						Expect(container.NetOut(garden.NetOutRule{
-							Protocol: garden.ProtocolICMP,
-						})).To(Succeed())
-						rule := fakeContainer.NetOutArgsForCall(0)
-						Expect(rule.Protocol).To(Equal(garden.ProtocolICMP))
+					Context("when the log name is not a plain file name", func() {
+						It("fails without running the process", func() {
+							_, err := container.Run(garden.ProcessSpec{
+								Path:      "/some/script",
+								OutputLog: &garden.OutputLogSpec{Name: "../job.log"},
+							}, garden.ProcessIO{})
+							Expect(err).To(MatchError(server.ErrInvalidOutputLogName.Error()))
+							Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+							Expect(fakeContainer.RunCallCount()).To(Equal(0))
+						})
 					})
 				})
 
-				Context("as ALL", func() {
-					It("permits ALL traffic", func() {
-						Expect(container.NetOut(garden.NetOutRule{
-							Protocol: garden.ProtocolAll,
-						})).To(Succeed())
+				Context("when the server has no directory for output logs", func() {
+					It("fails without running the process", func() {
+						_, err := container.Run(garden.ProcessSpec{
+							Path:      "/some/script",
+							OutputLog: &garden.OutputLogSpec{Name: "job.log"},
+						}, garden.ProcessIO{})
+						Expect(err).To(MatchError(server.ErrOutputLogsDisabled.Error()))
 
-						rule := fakeContainer.NetOutArgsForCall(0)
-						Expect(rule.Protocol).To(Equal(garden.ProtocolAll))
+						Expect(fakeContainer.RunCallCount()).To(Equal(0))
 					})
 				})
 			})
 
-			Context("when network is specified", func() {
-				It("permits traffic to that network", func() {
-					Expect(container.NetOut(garden.NetOutRule{
-						Networks: []garden.IPRange{
-							{Start: net.ParseIP("1.3.5.7"), End: net.ParseIP("9.9.7.6")},
-						},
-					})).To(Succeed())
-
-					rule := fakeContainer.NetOutArgsForCall(0)
-					Expect(rule.Networks).To(Equal([]garden.IPRange{
-						{
-							Start: net.ParseIP("1.3.5.7"),
-							End:   net.ParseIP("9.9.7.6"),
-						},
-					}))
-				})
-			})
+			Describe("reading stdin from a file on the host", func() {
+				var stdinDir string
+				var stdinReceived chan string
 
-			Context("when multiple networks are specified", func() {
-				It("permits traffic to those networks", func() {
-					Expect(container.NetOut(garden.NetOutRule{
-						Networks: []garden.IPRange{
-							{Start: net.ParseIP("1.3.5.7"), End: net.ParseIP("9.9.7.6")},
-							{Start: net.ParseIP("2.4.6.8"), End: net.ParseIP("8.6.4.2")},
-						},
-					})).To(Succeed())
+				BeforeEach(func() {
+					stdinDir = filepath.Join(tmpdir, "stdin")
+					Expect(os.MkdirAll(filepath.Join(stdinDir, "jobs"), 0755)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(stdinDir, "jobs", "input"), []byte("some input"), 0644)).To(Succeed())
 
-					rule := fakeContainer.NetOutArgsForCall(0)
-					Expect(rule.Networks).To(ConsistOf(
-						garden.IPRange{
-							Start: net.ParseIP("1.3.5.7"),
-							End:   net.ParseIP("9.9.7.6"),
-						},
-						garden.IPRange{
-							Start: net.ParseIP("2.4.6.8"),
-							End:   net.ParseIP("8.6.4.2"),
-						},
-					))
-				})
-			})
+					stdinReceived = make(chan string, 1)
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						input, err := ioutil.ReadAll(io.Stdin)
+						Expect(err).ToNot(HaveOccurred())
+						stdinReceived <- string(input)
 
-			Context("when ports are specified", func() {
-				It("permits traffic to those ports", func() {
-					Expect(container.NetOut(garden.NetOutRule{
-						Ports: []garden.PortRange{
-							{Start: 4, End: 44},
-						},
-					})).To(Succeed())
+						process := new(fakes.FakeProcess)
+						process.IDReturns("process-handle")
+						process.WaitReturns(0, nil)
 
-					rule := fakeContainer.NetOutArgsForCall(0)
-					Expect(rule.Ports).To(Equal([]garden.PortRange{
-						{
-							Start: 4,
-							End:   44,
-						},
-					}))
+						return process, nil
+					}
 				})
-			})
-
-			Context("when multiple ports are specified", func() {
-				It("permits traffic to those ports", func() {
-					Expect(container.NetOut(garden.NetOutRule{
-						Ports: []garden.PortRange{
-							{Start: 4, End: 44},
-							{Start: 563, End: 3944},
-						},
-					})).To(Succeed())
 
-					rule := fakeContainer.NetOutArgsForCall(0)
-					Expect(rule.Ports).To(ConsistOf(
-						garden.PortRange{
-							Start: 4,
-							End:   44,
-						},
-						garden.PortRange{
-							Start: 563,
-							End:   3944,
-						},
-					))
-				})
-			})
+				Context("when the server has a directory for stdin files", func() {
+					BeforeEach(func() {
+						apiServer.SetStdinFileDir(stdinDir)
+					})
 
-			Context("when icmps are specified without a code", func() {
-				It("permits traffic matching those icmps", func() {
-					Expect(container.NetOut(garden.NetOutRule{
-						ICMPs: &garden.ICMPControl{Type: 4},
-					})).To(Succeed())
+					It("feeds the file to the process instead of the client's stdin", func() {
+						process, err := container.Run(garden.ProcessSpec{
+							Path:      "/some/script",
+							StdinFile: "jobs/input",
+						}, garden.ProcessIO{
+							Stdin: bytes.NewBufferString("ignored"),
+						})
+						Expect(err).ToNot(HaveOccurred())
 
-					rule := fakeContainer.NetOutArgsForCall(0)
-					Expect(rule.ICMPs).To(Equal(&garden.ICMPControl{Type: 4}))
-				})
-			})
+						Eventually(stdinReceived).Should(Receive(Equal("some input")))
 
-			Context("when icmps are specified with a code", func() {
-				It("permits traffic matching those icmps", func() {
-					var code garden.ICMPCode = 34
-					Expect(container.NetOut(garden.NetOutRule{
-						ICMPs: &garden.ICMPControl{Type: 4, Code: &code},
-					})).To(Succeed())
+						status, err := process.Wait()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(status).To(Equal(0))
+					})
 
-					rule := fakeContainer.NetOutArgsForCall(0)
-					Expect(rule.ICMPs).To(Equal(&garden.ICMPControl{Type: 4, Code: &code}))
-				})
-			})
+					Context("when the file does not exist", func() {
+						It("fails with a FileNotFoundError without running the process", func() {
+							_, err := container.Run(garden.ProcessSpec{
+								Path:      "/some/script",
+								StdinFile: "jobs/missing",
+							}, garden.ProcessIO{})
+							Expect(err).To(Equal(garden.FileNotFoundError{Path: "jobs/missing"}))
 
-			Context("when log is true", func() {
-				It("requests that the rule logs", func() {
-					Expect(container.NetOut(garden.NetOutRule{Log: true})).To(Succeed())
+							Expect(fakeContainer.RunCallCount()).To(Equal(0))
+						})
+					})
 
-					rule := fakeContainer.NetOutArgsForCall(0)
-					Expect(rule.Log).To(BeTrue())
+					Context("when the path leads outside of the directory", func() {
+						It("fails without running the process", func() {
+							_, err := container.Run(garden.ProcessSpec{
+								Path:      "/some/script",
+								StdinFile: "../outside",
+							}, garden.ProcessIO{})
+							Expect(err).To(MatchError(server.ErrInvalidStdinFile.Error()))
+							Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+							Expect(fakeContainer.RunCallCount()).To(Equal(0))
+						})
+					})
+
+					Context("when a symlink leads outside of the directory", func() {
+						BeforeEach(func() {
+							Expect(ioutil.WriteFile(filepath.Join(tmpdir, "secret"), []byte("secret"), 0644)).To(Succeed())
+							Expect(os.Symlink(filepath.Join(tmpdir, "secret"), filepath.Join(stdinDir, "link"))).To(Succeed())
+						})
+
+						It("fails without running the process", func() {
+							_, err := container.Run(garden.ProcessSpec{
+								Path:      "/some/script",
+								StdinFile: "link",
+							}, garden.ProcessIO{})
+							Expect(err).To(MatchError(server.ErrInvalidStdinFile.Error()))
+							Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+							Expect(fakeContainer.RunCallCount()).To(Equal(0))
+						})
+					})
 				})
-			})
 
-			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-				fakeContainer.NetOutStub = func(garden.NetOutRule) error { time.Sleep(timeToSleep); return nil }
-				err := container.NetOut(garden.NetOutRule{})
-				Expect(err).ToNot(HaveOccurred())
-			})
+				Context("when the server has no directory for stdin files", func() {
+					It("fails without running the process", func() {
+						_, err := container.Run(garden.ProcessSpec{
+							Path:      "/some/script",
+							StdinFile: "jobs/input",
+						}, garden.ProcessIO{})
+						Expect(err).To(MatchError(server.ErrStdinFilesDisabled.Error()))
+						Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				return container.NetOut(garden.NetOutRule{})
+						Expect(fakeContainer.RunCallCount()).To(Equal(0))
+					})
+				})
 			})
 
-			Context("when permitting traffic fails", func() {
+			Describe("setting the terminal type", func() {
 				BeforeEach(func() {
-					fakeContainer.NetOutReturns(errors.New("oh no!"))
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					fakeContainer.RunReturns(process, nil)
 				})
 
-				It("fails", func() {
-					err := container.NetOut(garden.NetOutRule{})
-					Expect(err).To(HaveOccurred())
+				Context("when a TTY is requested without a terminal type", func() {
+					It("defaults the terminal type and exports it as $TERM", func() {
+						_, err := container.Run(garden.ProcessSpec{
+							Path: "/some/script",
+							Env:  []string{"FLAVOR=chocolate"},
+							TTY:  &garden.TTYSpec{},
+						}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
+
+						ranSpec, _ := fakeContainer.RunArgsForCall(0)
+						Expect(ranSpec.TTY.Term).To(Equal("xterm"))
+						Expect(ranSpec.Env).To(Equal([]string{"FLAVOR=chocolate", "TERM=xterm"}))
+					})
 				})
-			})
-		})
 
-		Describe("bulk net out", func() {
-			It("calls bulk net out with rules provided", func() {
-				rules := []garden.NetOutRule{
-					garden.NetOutRule{Protocol: garden.ProtocolTCP},
-					garden.NetOutRule{Protocol: garden.ProtocolUDP},
-				}
+				Context("when the environment already sets $TERM", func() {
+					It("leaves it alone", func() {
+						_, err := container.Run(garden.ProcessSpec{
+							Path: "/some/script",
+							Env:  []string{"TERM=screen"},
+							TTY:  &garden.TTYSpec{Term: "vt100"},
+						}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
 
-				Expect(container.BulkNetOut(rules)).To(Succeed())
-				Expect(fakeContainer.BulkNetOutCallCount()).To(Equal(1))
-				Expect(fakeContainer.BulkNetOutArgsForCall(0)).To(Equal(rules))
-			})
+						ranSpec, _ := fakeContainer.RunArgsForCall(0)
+						Expect(ranSpec.TTY.Term).To(Equal("vt100"))
+						Expect(ranSpec.Env).To(Equal([]string{"TERM=screen"}))
+					})
+				})
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				return container.BulkNetOut([]garden.NetOutRule{})
+				Context("when no TTY is requested", func() {
+					It("does not set $TERM", func() {
+						_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
+
+						ranSpec, _ := fakeContainer.RunArgsForCall(0)
+						Expect(ranSpec.Env).To(BeEmpty())
+					})
+				})
 			})
 
-			Context("when permitting traffic fails", func() {
+			Context("when capabilities are requested", func() {
 				BeforeEach(func() {
-					fakeContainer.BulkNetOutReturns(errors.New("oh no!"))
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					fakeContainer.RunReturns(process, nil)
 				})
 
-				It("fails", func() {
-					err := container.BulkNetOut([]garden.NetOutRule{})
-					Expect(err).To(HaveOccurred())
+				It("passes them to the backend", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:         "/some/script",
+						Capabilities: []string{"NET_ADMIN", "SYS_PTRACE"},
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					ranSpec, _ := fakeContainer.RunArgsForCall(0)
+					Expect(ranSpec.Capabilities).To(Equal([]string{"NET_ADMIN", "SYS_PTRACE"}))
+				})
+
+				It("rejects an unknown capability", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:         "/some/script",
+						Capabilities: []string{"NET_ADMIN", "CAP_SYS_ADMIN"},
+					}, garden.ProcessIO{})
+					Expect(err).To(MatchError(ContainSubstring(`unknown capability "CAP_SYS_ADMIN"`)))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
 				})
 			})
-		})
 
-		Describe("info", func() {
-			containerInfo := garden.ContainerInfo{
-				State:         "active",
-				Events:        []string{"oom", "party"},
-				HostIP:        "host-ip",
-				ContainerIP:   "container-ip",
-				ExternalIP:    "external-ip",
-				ContainerPath: "/path/to/container",
-				ProcessIDs:    []string{"process-handle-1", "process-handle-2"},
-				Properties: garden.Properties{
-					"foo": "bar",
-					"a":   "b",
-				},
-				MappedPorts: []garden.PortMapping{
-					{HostPort: 1234, ContainerPort: 5678},
-					{HostPort: 1235, ContainerPort: 5679},
-				},
-			}
+			Context("when a nice value is given", func() {
+				BeforeEach(func() {
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					fakeContainer.RunReturns(process, nil)
+				})
 
-			It("reports information about the container", func() {
-				fakeContainer.InfoReturns(containerInfo, nil)
+				It("passes it to the backend", func() {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script", Nice: 10}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
 
-				info, err := container.Info()
-				Expect(err).ToNot(HaveOccurred())
+					ranSpec, _ := fakeContainer.RunArgsForCall(0)
+					Expect(ranSpec.Nice).To(Equal(10))
+				})
 
-				Expect(info).To(Equal(containerInfo))
-			})
+				It("accepts the ends of the range", func() {
+					for _, nice := range []int{-20, 19} {
+						_, err := container.Run(garden.ProcessSpec{Path: "/some/script", Nice: nice}, garden.ProcessIO{})
+						Expect(err).ToNot(HaveOccurred())
+					}
+				})
 
-			itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-				fakeContainer.InfoStub = func() (garden.ContainerInfo, error) { time.Sleep(timeToSleep); return garden.ContainerInfo{}, nil }
-				_, err := container.Info()
-				Expect(err).ToNot(HaveOccurred())
-			})
+				It("rejects values outside the range", func() {
+					for _, nice := range []int{-21, 20} {
+						_, err := container.Run(garden.ProcessSpec{Path: "/some/script", Nice: nice}, garden.ProcessIO{})
+						Expect(err).To(MatchError(server.ErrInvalidNice.Error()))
+						Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+					}
 
-			itFailsWhenTheContainerIsNotFound(func() error {
-				_, err := container.Info()
-				return err
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
+				})
 			})
 
-			Context("when getting container info fails", func() {
+			Context("when the process's limits pin it to a cpuset", func() {
 				BeforeEach(func() {
-					fakeContainer.InfoReturns(garden.ContainerInfo{}, errors.New("oh no!"))
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					fakeContainer.RunReturns(process, nil)
 				})
 
-				It("fails", func() {
-					_, err := container.Info()
-					Expect(err).To(HaveOccurred())
+				It("passes it to the backend", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:                    "/some/script",
+						OverrideContainerLimits: &garden.ProcessLimits{CPU: garden.CPULimits{CPUSet: "0"}},
+					}, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					ranSpec, _ := fakeContainer.RunArgsForCall(0)
+					Expect(ranSpec.OverrideContainerLimits.CPU.CPUSet).To(Equal("0"))
 				})
-			})
-		})
 
-		Describe("BulkInfo", func() {
+				It("rejects a cpuset which is not online on the host", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:                    "/some/script",
+						OverrideContainerLimits: &garden.ProcessLimits{CPU: garden.CPULimits{CPUSet: "0-65000"}},
+					}, garden.ProcessIO{})
+					Expect(err).To(MatchError(ContainSubstring("not online on this host")))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
 
-			handles := []string{"handle1", "handle2"}
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
+				})
+			})
 
-			expectedBulkInfo := map[string]garden.ContainerInfoEntry{
-				"handle1": garden.ContainerInfoEntry{
-					Info: garden.ContainerInfo{
-						State: "container1state",
-					},
-				},
-				"handle2": garden.ContainerInfoEntry{
-					Info: garden.ContainerInfo{
-						State: "container2state",
-					},
-				},
-			}
+			Context("when the process's output is forwarded to syslog", func() {
+				var (
+					endpoint  net.PacketConn
+					processIO chan garden.ProcessIO
+					exited    chan struct{}
+				)
 
-			It("calls BulkInfo with empty slice when handles is empty", func() {
-				handles = nil
-				serverBackend.BulkInfoReturns(nil, nil)
-				apiClient.BulkInfo(handles)
-				Expect(serverBackend.BulkInfoArgsForCall(0)).To(BeEmpty())
-			})
+				BeforeEach(func() {
+					var err error
+					endpoint, err = net.ListenPacket("udp", "127.0.0.1:0")
+					Expect(err).ToNot(HaveOccurred())
 
-			It("reports information about containers by list of handles", func() {
-				serverBackend.BulkInfoReturns(expectedBulkInfo, nil)
+					apiServer.SetSyslogEndpoints([]string{endpoint.LocalAddr().String()})
 
-				bulkInfo, err := apiClient.BulkInfo(handles)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(bulkInfo).To(Equal(expectedBulkInfo))
-			})
+					processIO = make(chan garden.ProcessIO, 1)
+					exited = make(chan struct{})
 
-			Context("when retrieving bulk info fails", func() {
-				It("returns the error", func() {
-					serverBackend.BulkInfoReturns(
-						make(map[string]garden.ContainerInfoEntry),
-						errors.New("Oh noes!"),
-					)
+					process := new(fakes.FakeProcess)
+					process.IDReturns("process-handle")
+					process.WaitStub = func() (int, error) {
+						<-exited
+						return 0, nil
+					}
 
-					_, err := apiClient.BulkInfo(handles)
-					Expect(err).To(MatchError("Oh noes!"))
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						processIO <- io
+						return process, nil
+					}
 				})
-			})
 
-			Context("when a container is in error state", func() {
-				It("return single container error", func() {
+				AfterEach(func() {
+					close(exited)
+					endpoint.Close()
+				})
 
-					expectedBulkInfo := map[string]garden.ContainerInfoEntry{
-						"error": garden.ContainerInfoEntry{
-							Err: &garden.Error{Err: errors.New("Oopps!")},
-						},
-						"success": garden.ContainerInfoEntry{
-							Info: garden.ContainerInfo{
-								State: "container2state",
-							},
-						},
-					}
+				run := func(spec garden.ProcessSpec, stdout io.Writer) garden.ProcessIO {
+					spec.Path = "/some/script"
+					_, err := container.Run(spec, garden.ProcessIO{Stdout: stdout})
+					Expect(err).ToNot(HaveOccurred())
 
-					serverBackend.BulkInfoReturns(expectedBulkInfo, nil)
+					var pio garden.ProcessIO
+					Eventually(processIO).Should(Receive(&pio))
+					return pio
+				}
 
-					bulkInfo, err := apiClient.BulkInfo(handles)
+				readMessage := func() string {
+					buf := make([]byte, 65536)
+					endpoint.SetReadDeadline(time.Now().Add(5 * time.Second))
+					n, _, err := endpoint.ReadFrom(buf)
 					Expect(err).ToNot(HaveOccurred())
-					Expect(bulkInfo).To(Equal(expectedBulkInfo))
-				})
-			})
-		})
+					return string(buf[:n])
+				}
 
-		Describe("BulkMetrics", func() {
+				It("sends each line of stdout and stderr as a message", func() {
+					pio := run(garden.ProcessSpec{
+						Syslog: &garden.SyslogSpec{Address: endpoint.LocalAddr().String(), Facility: "local0", Tag: "my-app"},
+					}, nil)
 
-			handles := []string{"handle1", "handle2"}
+					fmt.Fprint(pio.Stdout, "hello\nwor")
+					fmt.Fprint(pio.Stdout, "ld\n")
+					Expect(readMessage()).To(MatchRegexp(`^<134>1 \S+ \S+ my-app process-handle stdout - hello$`))
+					Expect(readMessage()).To(MatchRegexp(`^<134>1 \S+ \S+ my-app process-handle stdout - world$`))
 
-			expectedBulkMetrics := map[string]garden.ContainerMetricsEntry{
-				"handle1": garden.ContainerMetricsEntry{
-					Metrics: garden.Metrics{
-						DiskStat: garden.ContainerDiskStat{
-							TotalInodesUsed: 1,
-						},
-					},
-				},
-				"handle2": garden.ContainerMetricsEntry{
-					Metrics: garden.Metrics{
-						DiskStat: garden.ContainerDiskStat{
-							TotalInodesUsed: 2,
-						},
-					},
-				},
-			}
+					fmt.Fprint(pio.Stderr, "oops\n")
+					Expect(readMessage()).To(MatchRegexp(`^<131>1 \S+ \S+ my-app process-handle stderr - oops$`))
+				})
 
-			It("reports information about containers by list of handles", func() {
-				serverBackend.BulkMetricsReturns(expectedBulkMetrics, nil)
+				It("also streams the output to the client", func() {
+					stdout := gbytes.NewBuffer()
+					pio := run(garden.ProcessSpec{
+						Syslog: &garden.SyslogSpec{Address: endpoint.LocalAddr().String()},
+					}, stdout)
 
-				bulkMetrics, err := apiClient.BulkMetrics(handles)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(bulkMetrics).To(Equal(expectedBulkMetrics))
-			})
+					fmt.Fprint(pio.Stdout, "hello\n")
+					Expect(readMessage()).To(HaveSuffix(" stdout - hello"))
+					Eventually(stdout).Should(gbytes.Say("hello"))
+				})
 
-			It("calls BulkMetrics with empty slice when handles is empty", func() {
-				handles = nil
-				serverBackend.BulkMetricsReturns(nil, nil)
-				apiClient.BulkMetrics(handles)
-				Expect(serverBackend.BulkMetricsArgsForCall(0)).To(BeEmpty())
-			})
+				It("tags the output with a nil value when it has no tag and the handle is too long for one", func() {
+					fakeContainer.HandleReturns(strings.Repeat("h", 49))
 
-			Context("when retrieving bulk info fails", func() {
-				It("returns the error", func() {
-					serverBackend.BulkMetricsReturns(
-						make(map[string]garden.ContainerMetricsEntry),
-						errors.New("Oh noes!"),
-					)
+					pio := run(garden.ProcessSpec{
+						Syslog: &garden.SyslogSpec{Address: endpoint.LocalAddr().String()},
+					}, nil)
 
-					_, err := apiClient.BulkMetrics(handles)
-					Expect(err).To(MatchError("Oh noes!"))
+					fmt.Fprint(pio.Stdout, "hello\n")
+					Expect(readMessage()).To(MatchRegexp(`^<14>1 \S+ \S+ - process-handle stdout - hello$`))
 				})
-			})
 
-			Context("when a container has an error", func() {
-				It("returns the error for the container", func() {
+				Context("when the output is not to be streamed", func() {
+					It("only forwards it", func() {
+						stdout := gbytes.NewBuffer()
+						pio := run(garden.ProcessSpec{
+							Syslog: &garden.SyslogSpec{Address: endpoint.LocalAddr().String(), NoStream: true},
+						}, stdout)
 
-					errorBulkMetrics := map[string]garden.ContainerMetricsEntry{
-						"error": garden.ContainerMetricsEntry{
-							Err: &garden.Error{Err: errors.New("Oh noes!")},
-						},
-						"success": garden.ContainerMetricsEntry{
-							Metrics: garden.Metrics{
-								DiskStat: garden.ContainerDiskStat{
-									TotalInodesUsed: 1,
-								},
-							},
-						},
-					}
+						fmt.Fprint(pio.Stdout, "hello\n")
+						Expect(readMessage()).To(HaveSuffix(" stdout - hello"))
+						Consistently(stdout, 200*time.Millisecond).ShouldNot(gbytes.Say("hello"))
+					})
+				})
 
-					serverBackend.BulkMetricsReturns(errorBulkMetrics, nil)
+				Context("when the endpoint was given for the container", func() {
+					JustBeforeEach(func() {
+						_, err := apiClient.Create(garden.ContainerSpec{
+							Handle: "some-handle",
+							Syslog: &garden.SyslogSpec{Address: endpoint.LocalAddr().String()},
+						})
+						Expect(err).ToNot(HaveOccurred())
+					})
 
-					bulkMetrics, err := apiClient.BulkMetrics(handles)
-					Expect(err).ToNot(HaveOccurred())
-					Expect(bulkMetrics).To(Equal(errorBulkMetrics))
-				})
-			})
-		})
+					It("forwards the output of processes which don't name their own, tagged with the handle", func() {
+						pio := run(garden.ProcessSpec{}, nil)
 
-		Describe("attaching", func() {
-			Context("when attaching succeeds", func() {
-				BeforeEach(func() {
-					fakeContainer.AttachStub = func(processID string, io garden.ProcessIO) (garden.Process, error) {
-						writing := new(sync.WaitGroup)
-						writing.Add(1)
+						fmt.Fprint(pio.Stdout, "hello\n")
+						Expect(readMessage()).To(MatchRegexp(`^<14>1 \S+ \S+ some-handle process-handle stdout - hello$`))
+					})
+				})
 
-						go func() {
-							defer writing.Done()
-							defer GinkgoRecover()
+				Context("when the endpoint is reached over TCP", func() {
+					var (
+						listener net.Listener
+						accepted chan net.Conn
+					)
 
-							_, err := fmt.Fprintf(io.Stdout, "stdout data")
-							Expect(err).ToNot(HaveOccurred())
+					BeforeEach(func() {
+						var err error
+						listener, err = net.Listen("tcp", "127.0.0.1:0")
+						Expect(err).ToNot(HaveOccurred())
 
-							in, err := ioutil.ReadAll(io.Stdin)
-							Expect(err).ToNot(HaveOccurred())
+						apiServer.SetSyslogEndpoints([]string{endpoint.LocalAddr().String(), listener.Addr().String()})
 
-							_, err = fmt.Fprintf(io.Stdout, "mirrored %s", string(in))
-							Expect(err).ToNot(HaveOccurred())
+						accepted = make(chan net.Conn, 10)
+						go func() {
+							for {
+								conn, err := listener.Accept()
+								if err != nil {
+									return
+								}
 
-							_, err = fmt.Fprintf(io.Stderr, "stderr data")
-							Expect(err).ToNot(HaveOccurred())
+								accepted <- conn
+							}
 						}()
+					})
 
-						process := new(fakes.FakeProcess)
+					AfterEach(func() {
+						listener.Close()
+					})
 
-						process.IDReturns("process-handle")
+					readFramed := func(conn net.Conn) string {
+						conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+						reader := bufio.NewReader(conn)
 
-						process.WaitStub = func() (int, error) {
-							writing.Wait()
-							return 123, nil
-						}
+						var length int
+						_, err := fmt.Fscanf(reader, "%d ", &length)
+						Expect(err).ToNot(HaveOccurred())
 
-						return process, nil
+						message := make([]byte, length)
+						_, err = io.ReadFull(reader, message)
+						Expect(err).ToNot(HaveOccurred())
+						return string(message)
 					}
-				})
 
-				It("responds with a ProcessPayload for every chunk", func() {
-					stdout := gbytes.NewBuffer()
-					stderr := gbytes.NewBuffer()
+					It("frames each message by its length, and reconnects once the connection is lost", func() {
+						pio := run(garden.ProcessSpec{
+							Syslog: &garden.SyslogSpec{Network: "tcp", Address: listener.Addr().String()},
+						}, nil)
 
-					processIO := garden.ProcessIO{
-						Stdin:  bytes.NewBufferString("stdin data"),
-						Stdout: stdout,
-						Stderr: stderr,
-					}
+						fmt.Fprint(pio.Stdout, "one\n")
 
-					process, err := container.Attach("process-handle", processIO)
-					Expect(err).ToNot(HaveOccurred())
+						var conn net.Conn
+						Eventually(accepted).Should(Receive(&conn))
+						Expect(readFramed(conn)).To(HaveSuffix(" stdout - one"))
+						conn.Close()
 
-					pid, _ := fakeContainer.AttachArgsForCall(0)
-					Expect(pid).To(Equal("process-handle"))
+						Eventually(func() bool {
+							fmt.Fprint(pio.Stdout, "again\n")
 
-					Eventually(stdout).Should(gbytes.Say("stdout data"))
-					Eventually(stdout).Should(gbytes.Say("mirrored stdin data"))
-					Eventually(stderr).Should(gbytes.Say("stderr data"))
+							select {
+							case conn = <-accepted:
+								return true
+							default:
+								return false
+							}
+						}).Should(BeTrue())
 
-					status, err := process.Wait()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(status).To(Equal(123))
+						defer conn.Close()
+						Expect(readFramed(conn)).To(HaveSuffix(" stdout - again"))
+
+						Expect(logger.LogMessages()).To(ContainElement(HaveSuffix("syslog.disconnected")))
+					})
 				})
 
-				itResetsGraceTimeWhenHandling(func(timeToSleep time.Duration) {
-					fakeContainer.AttachStub = func(string, garden.ProcessIO) (garden.Process, error) {
-						time.Sleep(timeToSleep)
-						return nil, errors.New("blam")
-					}
-					container.Attach("process-handle", garden.ProcessIO{})
+				It("rejects an invalid endpoint without running the process", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:   "/some/script",
+						Syslog: &garden.SyslogSpec{Address: "nowhere"},
+					}, garden.ProcessIO{})
+					Expect(err).To(MatchError(`syslog address "nowhere" must be host:port`))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
 				})
-			})
 
-			Context("when the container is not found", func() {
-				It("fails", func() {
-					serverBackend.LookupReturns(nil, errors.New("not found"))
-					_, err := container.Attach("process-handle", garden.ProcessIO{})
-					Expect(err).To(HaveOccurred())
+				It("rejects an endpoint the server does not forward to without running the process", func() {
+					_, err := container.Run(garden.ProcessSpec{
+						Path:   "/some/script",
+						Syslog: &garden.SyslogSpec{Address: "logs.example.com:514"},
+					}, garden.ProcessIO{})
+					Expect(err).To(MatchError(`syslog address "logs.example.com:514" is not one this server forwards to`))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
+				})
+
+				Context("when the server forwards to no endpoints", func() {
+					BeforeEach(func() {
+						apiServer.SetSyslogEndpoints(nil)
+					})
+
+					It("rejects any endpoint without running the process", func() {
+						_, err := container.Run(garden.ProcessSpec{
+							Path:   "/some/script",
+							Syslog: &garden.SyslogSpec{Address: endpoint.LocalAddr().String()},
+						}, garden.ProcessIO{})
+						Expect(err).To(MatchError(server.ErrSyslogDisabled.Error()))
+						Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
+
+						Expect(fakeContainer.RunCallCount()).To(Equal(0))
+					})
 				})
 			})
 
-			Context("when waiting on the process fails server-side", func() {
-				BeforeEach(func() {
-					fakeContainer.AttachStub = func(id string, io garden.ProcessIO) (garden.Process, error) {
-						process := new(fakes.FakeProcess)
+			Context("when an rlimit would stop the process from starting", func() {
+				run := func(limits garden.ResourceLimits) error {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script", Limits: limits}, garden.ProcessIO{})
+					return err
+				}
 
-						process.IDReturns("process-handle")
-						process.WaitReturns(0, errors.New("oh no!"))
+				It("rejects an open file limit of zero", func() {
+					err := run(garden.ResourceLimits{Nofile: uint64ptr(0)})
+					Expect(err).To(MatchError(ContainSubstring("invalid rlimit nofile 0: must be")))
+					Expect(err).To(BeAssignableToTypeOf(garden.InvalidRequestError{}))
 
-						return process, nil
-					}
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
 				})
 
-				It("bubbles the error up", func() {
-					process, err := container.Attach("process-handle", garden.ProcessIO{})
+				It("rejects an open file limit above the kernel maximum", func() {
+					contents, err := ioutil.ReadFile("/proc/sys/fs/nr_open")
 					Expect(err).ToNot(HaveOccurred())
 
-					_, err = process.Wait()
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(ContainSubstring("oh no!"))
-				})
-			})
+					max, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 64)
+					Expect(err).ToNot(HaveOccurred())
 
-			Context("when the process is not found", func() {
-				BeforeEach(func() {
-					fakeContainer.AttachReturns(nil, garden.ProcessNotFoundError{
-						ProcessID: "not-existing-process"},
-					)
-				})
+					err = run(garden.ResourceLimits{Nofile: uint64ptr(max + 1)})
+					Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("invalid rlimit nofile %d: must be between 1 and %d", max+1, max))))
 
-				It("returns a ProcessNotFoundError", func() {
-					_, err := container.Attach("not-existing-process", garden.ProcessIO{})
-					Expect(err).To(MatchError(garden.ProcessNotFoundError{
-						ProcessID: "not-existing-process",
-					}))
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
 				})
-			})
 
-			Context("when attaching fails", func() {
-				BeforeEach(func() {
-					fakeContainer.AttachReturns(nil, errors.New("oh no!"))
-				})
+				It("rejects memory limits too small to exec anything", func() {
+					err := run(garden.ResourceLimits{As: uint64ptr(1024)})
+					Expect(err).To(MatchError(ContainSubstring("invalid rlimit as 1024: must be at least 1048576 bytes")))
 
-				It("fails", func() {
-					_, err := container.Attach("process-handle", garden.ProcessIO{})
-					Expect(err).To(HaveOccurred())
+					err = run(garden.ResourceLimits{Stack: uint64ptr(1024)})
+					Expect(err).To(MatchError(ContainSubstring("invalid rlimit stack 1024: must be at least 65536 bytes")))
+
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
 				})
 
-				It("closes the stdin writer", func() {
-					container.Attach("process-handle", garden.ProcessIO{})
+				It("rejects out of range priorities", func() {
+					err := run(garden.ResourceLimits{Nice: uint64ptr(41)})
+					Expect(err).To(MatchError(ContainSubstring("invalid rlimit nice 41: must be at most 40")))
 
-					_, processIO := fakeContainer.AttachArgsForCall(0)
-					_, err := processIO.Stdin.Read([]byte{})
-					Expect(err).To(Equal(io.EOF))
+					err = run(garden.ResourceLimits{Rtprio: uint64ptr(100)})
+					Expect(err).To(MatchError(ContainSubstring("invalid rlimit rtprio 100: must be at most 99")))
+
+					Expect(fakeContainer.RunCallCount()).To(Equal(0))
 				})
 			})
-		})
-
-		Describe("running", func() {
-			processSpec := garden.ProcessSpec{
-				ID:   "some-process-id",
-				Path: "/some/script",
-				Args: []string{"arg1", "arg2"},
-				Dir:  "/some/dir",
-				Env: []string{
-					"FLAVOR=chocolate",
-					"TOPPINGS=sprinkles",
-				},
-				User: "root",
-				Limits: garden.ResourceLimits{
-					As:         uint64ptr(1),
-					Core:       uint64ptr(2),
-					Cpu:        uint64ptr(3),
-					Data:       uint64ptr(4),
-					Fsize:      uint64ptr(5),
-					Locks:      uint64ptr(6),
-					Memlock:    uint64ptr(7),
-					Msgqueue:   uint64ptr(8),
-					Nice:       uint64ptr(9),
-					Nofile:     uint64ptr(10),
-					Nproc:      uint64ptr(11),
-					Rss:        uint64ptr(12),
-					Rtprio:     uint64ptr(13),
-					Sigpending: uint64ptr(14),
-					Stack:      uint64ptr(15),
-				},
-				TTY: &garden.TTYSpec{
-					WindowSize: &garden.WindowSize{
-						Columns: 80,
-						Rows:    24,
-					},
-				},
-				Image: garden.ImageRef{
-					URI:      "some-uri",
-					Username: "registry-username",
-					Password: "registry-password",
-				},
-			}
 
 			Context("when running succeeds", func() {
 				BeforeEach(func() {
@@ -1916,6 +8012,131 @@ var _ = Describe("When a client connects", func() {
 					Expect(buffer).ToNot(gbytes.Say("banana"))
 				})
 
+				Context("when the process writes a large chunk of output just before exiting", func() {
+					var finalWrite []byte
+
+					BeforeEach(func() {
+						finalWrite = bytes.Repeat([]byte("x"), 1024*1024)
+
+						fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+							process := new(fakes.FakeProcess)
+							process.IDReturns("process-handle")
+
+							// the process is waited on more than once, but only
+							// exits once
+							var exit sync.Once
+							process.WaitStub = func() (int, error) {
+								exit.Do(func() {
+									io.Stdout.Write([]byte("header\n"))
+									io.Stdout.Write(finalWrite)
+								})
+								return 0, nil
+							}
+
+							return process, nil
+						}
+					})
+
+					It("delivers all of the output before the exit status", func() {
+						stdout := new(bytes.Buffer)
+
+						process, err := container.Run(processSpec, garden.ProcessIO{
+							Stdout: stdout,
+						})
+						Expect(err).ToNot(HaveOccurred())
+
+						status, err := process.Wait()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(status).To(Equal(0))
+
+						Expect(stdout.Len()).To(Equal(len("header\n") + len(finalWrite)))
+					})
+				})
+
+				Context("when an output rate limit is set", func() {
+					BeforeEach(func() {
+						apiServer.SetOutputRateLimit(10 * 1024)
+
+						fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+							process := new(fakes.FakeProcess)
+							process.IDReturns("process-handle")
+
+							var exit sync.Once
+							process.WaitStub = func() (int, error) {
+								exit.Do(func() {
+									io.Stdout.Write(bytes.Repeat([]byte("x"), 20*1024))
+								})
+								return 0, nil
+							}
+
+							return process, nil
+						}
+					})
+
+					It("throttles the process's output without dropping any of it", func() {
+						stdout := new(bytes.Buffer)
+						before := time.Now()
+
+						process, err := container.Run(processSpec, garden.ProcessIO{
+							Stdout: stdout,
+						})
+						Expect(err).ToNot(HaveOccurred())
+
+						_, err = process.Wait()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(time.Since(before)).To(BeNumerically(">=", 500*time.Millisecond))
+						Expect(stdout.Len()).To(Equal(20 * 1024))
+						Expect(apiServer.ThrottledOutputBytes()).To(BeNumerically(">", 0))
+					})
+
+					Context("and the process writes more chunks than can be queued", func() {
+						BeforeEach(func() {
+							fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+								process := new(fakes.FakeProcess)
+								process.IDReturns("process-handle")
+
+								written := make(chan struct{})
+								go func() {
+									defer close(written)
+
+									// give the client time to start reading
+									time.Sleep(200 * time.Millisecond)
+
+									for i := 0; i < 1500; i++ {
+										io.Stdout.Write([]byte("0123456789"))
+									}
+								}()
+
+								process.WaitStub = func() (int, error) {
+									<-written
+									return 0, nil
+								}
+
+								return process, nil
+							}
+						})
+
+						It("holds the process up rather than dropping the output", func() {
+							spec := processSpec
+							spec.TTY = nil
+
+							stdout := new(bytes.Buffer)
+
+							process, err := container.Run(spec, garden.ProcessIO{
+								Stdout: stdout,
+							})
+							Expect(err).ToNot(HaveOccurred())
+
+							_, err = process.Wait()
+							Expect(err).ToNot(HaveOccurred())
+
+							Expect(stdout.Len()).To(Equal(1500 * 10))
+							Expect(apiServer.DroppedOutputBytes()).To(BeZero())
+						})
+					})
+				})
+
 				It("runs the process and streams the output", func() {
 					stdout := gbytes.NewBuffer()
 					stderr := gbytes.NewBuffer()
@@ -2094,6 +8315,76 @@ var _ = Describe("When a client connects", func() {
 				})
 			})
 
+			Context("when a control channel is requested", func() {
+				var (
+					fakeProcess *fakes.FakeProcess
+					exit        chan struct{}
+					events      chan garden.ProcessEvent
+				)
+
+				BeforeEach(func() {
+					exit = make(chan struct{})
+					events = make(chan garden.ProcessEvent, 10)
+
+					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.IDReturns("process-handle")
+					fakeProcess.WaitStub = func() (int, error) {
+						<-exit
+						return 3, nil
+					}
+
+					fakeContainer.RunReturns(fakeProcess, nil)
+				})
+
+				It("reports the process's state changes", func() {
+					process, err := container.Run(processSpec, garden.ProcessIO{Events: events})
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(events).Should(Receive(Equal(garden.ProcessEvent{State: garden.ProcessStateRunning})))
+
+					close(exit)
+
+					Expect(process.Wait()).To(Equal(3))
+					Expect(events).To(Receive(Equal(garden.ProcessEvent{State: garden.ProcessStateExited, ExitStatus: 3})))
+				})
+
+				It("reports signals and TTY resizes which could not be applied", func() {
+					fakeProcess.SignalReturns(errors.New("no such process"))
+					fakeProcess.SetTTYReturns(errors.New("not a tty"))
+
+					process, err := container.Run(processSpec, garden.ProcessIO{Events: events})
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(events).Should(Receive(Equal(garden.ProcessEvent{State: garden.ProcessStateRunning})))
+
+					Expect(process.Signal(garden.SignalTerminate)).To(Succeed())
+					Eventually(events).Should(Receive(Equal(garden.ProcessEvent{State: garden.ProcessStateRunning, Error: "no such process"})))
+
+					Expect(process.SetTTY(garden.TTYSpec{})).To(Succeed())
+					Eventually(events).Should(Receive(Equal(garden.ProcessEvent{State: garden.ProcessStateRunning, Error: "not a tty"})))
+
+					close(exit)
+					Expect(process.Wait()).To(Equal(3))
+				})
+
+				It("reports the same over an attach", func() {
+					_, err := container.Run(processSpec, garden.ProcessIO{})
+					Expect(err).ToNot(HaveOccurred())
+
+					fakeContainer.AttachReturns(fakeProcess, nil)
+
+					process, err := container.Attach("process-handle", garden.ProcessIO{Events: events})
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(events).Should(Receive(Equal(garden.ProcessEvent{State: garden.ProcessStateRunning})))
+
+					close(exit)
+
+					Expect(process.Wait()).To(Equal(3))
+					Expect(events).To(Receive(Equal(garden.ProcessEvent{State: garden.ProcessStateExited, ExitStatus: 3})))
+				})
+			})
+
 			Context("when waiting on the process fails server-side", func() {
 				BeforeEach(func() {
 					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
@@ -2160,3 +8451,79 @@ func (checker *closeChecker) Closed() bool {
 	defer checker.Unlock()
 	return checker.closed
 }
+
+// blockingWriter is the output of a client which stops reading it.
+type blockingWriter struct {
+	unblock chan struct{}
+
+	// writing, if set, is sent to when a write starts
+	writing chan struct{}
+
+	n int64
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	if w.writing != nil {
+		select {
+		case w.writing <- struct{}{}:
+		default:
+		}
+	}
+
+	<-w.unblock
+	atomic.AddInt64(&w.n, int64(len(b)))
+	return len(b), nil
+}
+
+// written returns how many bytes have been written.
+func (w *blockingWriter) written() int64 {
+	return atomic.LoadInt64(&w.n)
+}
+
+// serverNoDelay returns whether TCP_NODELAY is set on each connection this
+// process has accepted on the port.
+func serverNoDelay(port int) []bool {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	Expect(err).NotTo(HaveOccurred())
+
+	noDelay := []bool{}
+	for _, fd := range fds {
+		var n int
+		if _, err := fmt.Sscan(fd.Name(), &n); err != nil {
+			continue
+		}
+
+		local, err := syscall.Getsockname(n)
+		if err != nil {
+			continue
+		}
+
+		// the server listens on every address, so may accept over IPv6
+		switch addr := local.(type) {
+		case *syscall.SockaddrInet4:
+			if addr.Port != port {
+				continue
+			}
+		case *syscall.SockaddrInet6:
+			if addr.Port != port {
+				continue
+			}
+		default:
+			continue
+		}
+
+		// the listener has no peer
+		if _, err := syscall.Getpeername(n); err != nil {
+			continue
+		}
+
+		value, err := syscall.GetsockoptInt(n, syscall.IPPROTO_TCP, syscall.TCP_NODELAY)
+		if err != nil {
+			continue
+		}
+
+		noDelay = append(noDelay, value != 0)
+	}
+
+	return noDelay
+}
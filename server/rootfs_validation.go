@@ -0,0 +1,132 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+// rootFSMountPoints are the directories a container's root filesystem has
+// mounted over it. A rootfs need not have them, but where it does they must
+// be directories, or links to them.
+var rootFSMountPoints = []string{"dev", "proc", "sys", "tmp", "etc"}
+
+// imageRepositoryPattern matches the repository path of a docker image URI,
+// e.g. /library/busybox. Its tag, if any, is the URI's fragment.
+var imageRepositoryPattern = regexp.MustCompile(`^/[a-z0-9]+([._/-][a-z0-9]+)*$`)
+
+func (s *GardenServer) handleValidateRootFS(w http.ResponseWriter, r *http.Request) {
+	rootfs := r.URL.Query().Get("rootfs")
+
+	hLog := s.logger.Session("validate-rootfs", lager.Data{
+		"rootfs": rootfs,
+	})
+
+	if err := s.validateRootFS(rootfs); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeSuccess(w)
+}
+
+// validateRootFS checks what the server can of a rootfs itself, and leaves
+// the rest to the backend. Only the backend can tell whether an image can be
+// resolved, or what a scheme the server does not know means, so a backend
+// which cannot fails the validation of one; a path the server has checked is
+// valid unless the backend has more to say.
+func (s *GardenServer) validateRootFS(rootfs string) error {
+	if rootfs == "" {
+		return nil
+	}
+
+	rootfsURL, err := url.Parse(rootfs)
+	if err != nil {
+		return garden.InvalidRootFSError{RootFS: rootfs, Reason: err.Error()}
+	}
+
+	switch rootfsURL.Scheme {
+	case "":
+		if err := validateRootFSPath(rootfs); err != nil {
+			return err
+		}
+
+		err := s.backend.ValidateRootFS(rootfs)
+		if _, unsupported := err.(garden.UnsupportedOperationError); unsupported {
+			return nil
+		}
+
+		return err
+
+	case "docker":
+		if !imageRepositoryPattern.MatchString(rootfsURL.Path) {
+			return garden.InvalidRootFSError{
+				RootFS: rootfs,
+				Reason: fmt.Sprintf("%q is not a valid image repository", rootfsURL.Path),
+			}
+		}
+
+		return s.backend.ValidateRootFS(rootfs)
+
+	default:
+		return s.backend.ValidateRootFS(rootfs)
+	}
+}
+
+// validateRootFSPath checks that the path is a directory with the structure
+// of a root filesystem.
+func validateRootFSPath(rootfs string) error {
+	invalid := func(reason string, args ...interface{}) error {
+		return garden.InvalidRootFSError{RootFS: rootfs, Reason: fmt.Sprintf(reason, args...)}
+	}
+
+	if !filepath.IsAbs(rootfs) {
+		return invalid("path must be absolute")
+	}
+
+	info, err := os.Stat(rootfs)
+	if os.IsNotExist(err) {
+		return invalid("does not exist")
+	}
+
+	if err != nil {
+		return invalid("cannot be read: %s", err)
+	}
+
+	if !info.IsDir() {
+		return invalid("is not a directory")
+	}
+
+	dir, err := os.Open(rootfs)
+	if err != nil {
+		return invalid("cannot be read: %s", err)
+	}
+	defer dir.Close()
+
+	if _, err := dir.Readdirnames(1); err != nil {
+		return invalid("is empty")
+	}
+
+	for _, mountPoint := range rootFSMountPoints {
+		info, err := os.Lstat(filepath.Join(rootfs, mountPoint))
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return invalid("/%s cannot be read: %s", mountPoint, err)
+		}
+
+		if !info.IsDir() && info.Mode()&os.ModeSymlink == 0 {
+			return invalid("/%s is not a directory", mountPoint)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/routes"
+	"code.cloudfoundry.org/garden/server/ratelimit"
+	"code.cloudfoundry.org/lager"
+)
+
+// maxIdleClientLimiters bounds how many per-client limiters a route keeps
+// before those of clients that have gone quiet are dropped.
+const maxIdleClientLimiters = 1024
+
+var ErrStreamingRouteRateLimit = errors.New("streaming routes cannot be rate limited")
+
+// streamingRoutes hold their connection for as long as the stream lasts, so
+// limiting how often they are called would not bound their load.
+var streamingRoutes = map[string]bool{
+	routes.Run:             true,
+	routes.Attach:          true,
+	routes.AttachAll:       true,
+	routes.Stdout:          true,
+	routes.Stderr:          true,
+	routes.StreamIn:        true,
+	routes.StreamOut:       true,
+	routes.StreamOutputLog: true,
+	routes.WriteFile:       true,
+	routes.ReadFile:        true,
+	routes.WatchCapacity:   true,
+	routes.WatchOOMs:       true,
+
+	routes.WaitForProcesses: true,
+}
+
+// RouteRateLimit limits how often a route may be called.
+type RouteRateLimit struct {
+	// RequestsPerSecond is the sustained rate at which requests are served.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests that may be served at once after a quiet
+	// period. It is at least one.
+	Burst int
+
+	// PerClient applies the limit to each client address separately rather
+	// than to all clients together.
+	PerClient bool
+}
+
+type routeLimiter struct {
+	limit   RouteRateLimit
+	shared  *ratelimit.Limiter
+	clients map[string]*ratelimit.Limiter
+}
+
+func (l *routeLimiter) allow(client string) (bool, time.Duration) {
+	if !l.limit.PerClient {
+		return l.shared.Allow()
+	}
+
+	limiter, found := l.clients[client]
+	if !found {
+		if len(l.clients) >= maxIdleClientLimiters {
+			for c, cl := range l.clients {
+				if cl.Idle() {
+					delete(l.clients, c)
+				}
+			}
+		}
+
+		limiter = ratelimit.NewLimiter(l.limit.RequestsPerSecond, l.limit.Burst)
+		l.clients[client] = limiter
+	}
+
+	return limiter.Allow()
+}
+
+// SetRouteRateLimit limits the rate at which the named route is served.
+// Requests over the limit are refused with a garden.RateLimitedError. A limit
+// of zero requests per second removes any limit on the route. Streaming
+// routes cannot be limited.
+func (s *GardenServer) SetRouteRateLimit(route string, limit RouteRateLimit) error {
+	if streamingRoutes[route] {
+		return ErrStreamingRouteRateLimit
+	}
+
+	s.routeLimitsL.Lock()
+	defer s.routeLimitsL.Unlock()
+
+	if limit.RequestsPerSecond <= 0 {
+		delete(s.routeLimits, route)
+		return nil
+	}
+
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	s.routeLimits[route] = &routeLimiter{
+		limit:   limit,
+		shared:  ratelimit.NewLimiter(limit.RequestsPerSecond, limit.Burst),
+		clients: make(map[string]*ratelimit.Limiter),
+	}
+
+	return nil
+}
+
+func (s *GardenServer) allowRequest(route string, r *http.Request) (bool, time.Duration) {
+	s.routeLimitsL.Lock()
+	defer s.routeLimitsL.Unlock()
+
+	limiter, found := s.routeLimits[route]
+	if !found {
+		return true, 0
+	}
+
+	return limiter.allow(clientAddr(r))
+}
+
+// rateLimited refuses requests to the route that exceed its rate limit, if
+// it has one.
+func (s *GardenServer) rateLimited(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := s.allowRequest(route, r)
+		if !allowed {
+			hLog := s.logger.Session("rate-limited", lager.Data{
+				"route":       route,
+				"remote-addr": r.RemoteAddr,
+			})
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			s.writeError(w, garden.RateLimitedError{Route: route, RetryAfter: retryAfter}, hLog)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+const selftestPath = "/bin/true"
+
+// selftest exercises the create, run and destroy path of the backend with a
+// container of its own. The container gets a handle no client would choose,
+// so it is safe to run against a host with live containers.
+func (s *GardenServer) selftest(logger lager.Logger) garden.SelftestResult {
+	result := garden.SelftestResult{Succeeded: true}
+
+	step := func(name string, f func() error) bool {
+		started := time.Now()
+		err := f()
+
+		st := garden.SelftestStep{
+			Name:     name,
+			Duration: time.Since(started),
+		}
+
+		if err != nil {
+			logger.Error("step-failed", err, lager.Data{
+				"step": name,
+			})
+
+			st.Error = err.Error()
+			result.Succeeded = false
+		}
+
+		result.Steps = append(result.Steps, st)
+
+		return err == nil
+	}
+
+	handle, err := selftestHandle()
+	if err != nil {
+		step("create", func() error { return err })
+		return result
+	}
+
+	var container garden.Container
+	created := step("create", func() error {
+		container, err = s.backend.Create(garden.ContainerSpec{
+			Handle: handle,
+			Properties: garden.Properties{
+				"garden.selftest": "true",
+			},
+		})
+
+		return err
+	})
+	if !created {
+		return result
+	}
+
+	step("run", func() error {
+		stderr := new(bytes.Buffer)
+
+		process, err := container.Run(garden.ProcessSpec{
+			Path: selftestPath,
+		}, garden.ProcessIO{
+			Stdout: ioutil.Discard,
+			Stderr: stderr,
+		})
+		if err != nil {
+			return err
+		}
+
+		status, err := process.Wait()
+		if err != nil {
+			return err
+		}
+
+		if status != 0 {
+			return fmt.Errorf("%s exited with status %d: %s", selftestPath, status, stderr.String())
+		}
+
+		return nil
+	})
+
+	step("destroy", func() error {
+		s.handleLocks.Lock(handle)
+		defer s.handleLocks.Unlock(handle)
+
+		return s.backend.Destroy(handle)
+	})
+
+	return result
+}
+
+func selftestHandle() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "garden-selftest-" + hex.EncodeToString(b), nil
+}
@@ -6,17 +6,34 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/garden/routes"
 	"code.cloudfoundry.org/garden/server/bomberman"
+	"code.cloudfoundry.org/garden/server/handlelock"
 	"code.cloudfoundry.org/garden/server/streamer"
 	"code.cloudfoundry.org/lager"
 	"github.com/tedsuo/rata"
 )
 
+// defaultFailedContainerGraceTime is how long a container kept by
+// KeepOnFailure lingers unless configured otherwise.
+const defaultFailedContainerGraceTime = 24 * time.Hour
+
 type GardenServer struct {
+	// accessed atomically; kept first so they are 64-bit aligned
+	outputRateLimit          int64
+	throttledOutputBytes     uint64
+	reapedContainers         uint64
+	compressionThreshold     int64
+	maxWriteFileSize         int64
+	failedContainerGraceTime int64 // time.Duration
+	outputBlockTimeout       int64 // time.Duration
+	maxContainers            int64
+	draining                 int32
+
 	logger lager.Logger
 
 	server        *http.Server
@@ -42,6 +59,55 @@ type GardenServer struct {
 
 	destroys  map[string]struct{}
 	destroysL *sync.Mutex
+
+	recentlyDestroyed *recentlyDestroyedHandles
+
+	// destroys take an exclusive lock on the handle so that they cannot race
+	// with other operations on the same container
+	handleLocks *handlelock.Locker
+
+	networkPolicyLocks *handlelock.Locker
+
+	capacityNotifier *capacityNotifier
+	oomNotifier      *oomNotifier
+
+	// pendingCreates counts the creates admitted under the maximum number of
+	// containers which have not yet finished
+	pendingCreates  int
+	pendingCreatesL sync.Mutex
+
+	processTracker *processTracker
+	processLogs    *processLogs
+	processEnvs    *processEnvTracker
+	processLimits  *processLimits
+	processExits   *processExits
+	outputs        *outputBroadcasts
+	attachments    *attachmentTracker
+
+	interactiveStreams *interactiveStreamTracker
+	outputStreams      *outputStreamTracker
+	outputStats        *outputStats
+
+	syslogs *syslogTracker
+
+	trafficRules *trafficRuleTracker
+	limitBoosts  *limitBoostTracker
+	infoVersions *infoVersionTracker
+
+	healthChecks *healthChecks
+
+	outputLogDir atomic.Value // string
+	outputLogs   *outputLogs
+	stdinFileDir atomic.Value // string
+
+	containerSpecs   *containerSpecTracker
+	containerSpecDir atomic.Value // string
+
+	reapObserver atomic.Value // func(ReapEvent)
+	auditSink    atomic.Value // func(AuditEvent)
+
+	routeLimits  map[string]*routeLimiter
+	routeLimitsL sync.Mutex
 }
 
 func New(
@@ -59,6 +125,10 @@ func New(
 		containerGraceTime: containerGraceTime,
 		backend:            backend,
 
+		failedContainerGraceTime: int64(defaultFailedContainerGraceTime),
+		outputBlockTimeout:       int64(defaultOutputBlockTimeout),
+		maxWriteFileSize:         defaultMaxWriteFileSize,
+
 		stopping: make(chan bool),
 
 		handling: new(sync.WaitGroup),
@@ -69,38 +139,111 @@ func New(
 		destroys:  make(map[string]struct{}),
 		destroysL: new(sync.Mutex),
 
+		recentlyDestroyed: newRecentlyDestroyedHandles(),
+
+		handleLocks: handlelock.New(),
+
+		networkPolicyLocks: handlelock.New(),
+
+		capacityNotifier: newCapacityNotifier(),
+		oomNotifier:      newOOMNotifier(),
+
+		processTracker: newProcessTracker(processStatusRetention),
+		processLogs:    newProcessLogs(processStatusRetention),
+		processEnvs:    newProcessEnvTracker(processStatusRetention),
+		processLimits:  newProcessLimits(processStatusRetention),
+		processExits:   newProcessExits(),
+		outputLogs:     newOutputLogs(),
+		outputs:        newOutputBroadcasts(),
+		attachments:    newAttachmentTracker(),
+
+		interactiveStreams: newInteractiveStreamTracker(),
+		outputStreams:      newOutputStreamTracker(),
+		outputStats:        new(outputStats),
+
+		syslogs: newSyslogTracker(),
+
+		trafficRules: newTrafficRuleTracker(),
+		limitBoosts:  newLimitBoostTracker(),
+		infoVersions: newInfoVersionTracker(),
+
+		healthChecks: newHealthChecks(),
+
+		containerSpecs: newContainerSpecTracker(),
+
+		routeLimits: make(map[string]*routeLimiter),
+
 		startMutex: new(sync.Mutex),
 	}
 
 	handlers := map[string]http.Handler{
 		routes.Ping:                   http.HandlerFunc(s.handlePing),
 		routes.Capacity:               http.HandlerFunc(s.handleCapacity),
+		routes.WatchCapacity:          http.HandlerFunc(s.handleWatchCapacity),
+		routes.WatchOOMs:              http.HandlerFunc(s.handleWatchOOMs),
+		routes.Features:               http.HandlerFunc(s.handleFeatures),
+		routes.Selftest:               http.HandlerFunc(s.handleSelftest),
+		routes.Health:                 http.HandlerFunc(s.handleHealth),
+		routes.ValidateRootFS:         http.HandlerFunc(s.handleValidateRootFS),
+		routes.ContainerForHostPID:    http.HandlerFunc(s.handleContainerForHostPID),
 		routes.Create:                 http.HandlerFunc(s.handleCreate),
 		routes.Destroy:                http.HandlerFunc(s.handleDestroy),
+		routes.DestroyByProperties:    http.HandlerFunc(s.handleDestroyByProperties),
+		routes.Rename:                 http.HandlerFunc(s.handleRename),
 		routes.List:                   http.HandlerFunc(s.handleList),
 		routes.Stop:                   http.HandlerFunc(s.handleStop),
+		routes.Checkpoint:             http.HandlerFunc(s.handleCheckpoint),
+		routes.Restore:                http.HandlerFunc(s.handleRestore),
+		routes.Sync:                   http.HandlerFunc(s.handleSync),
 		routes.StreamIn:               http.HandlerFunc(s.handleStreamIn),
 		routes.StreamOut:              http.HandlerFunc(s.handleStreamOut),
+		routes.WriteFile:              http.HandlerFunc(s.handleWriteFile),
+		routes.ReadFile:               http.HandlerFunc(s.handleReadFile),
+		routes.StreamOutputLog:        http.HandlerFunc(s.handleStreamOutputLog),
 		routes.CurrentBandwidthLimits: http.HandlerFunc(s.handleCurrentBandwidthLimits),
 		routes.CurrentCPULimits:       http.HandlerFunc(s.handleCurrentCPULimits),
 		routes.CurrentDiskLimits:      http.HandlerFunc(s.handleCurrentDiskLimits),
 		routes.CurrentMemoryLimits:    http.HandlerFunc(s.handleCurrentMemoryLimits),
+		routes.CurrentIOLimits:        http.HandlerFunc(s.handleCurrentIOLimits),
+		routes.LimitAll:               http.HandlerFunc(s.handleLimitAll),
+		routes.BoostLimits:            http.HandlerFunc(s.handleBoostLimits),
 		routes.NetIn:                  http.HandlerFunc(s.handleNetIn),
 		routes.NetOut:                 http.HandlerFunc(s.handleNetOut),
+		routes.AllEgressRules:         http.HandlerFunc(s.handleAllEgressRules),
 		routes.BulkNetOut:             http.HandlerFunc(s.handleBulkNetOut),
+		routes.NetworkPolicy:          http.HandlerFunc(s.handleNetworkPolicy),
+		routes.SetNetworkPolicy:       http.HandlerFunc(s.handleSetNetworkPolicy),
+		routes.AllowTraffic:           http.HandlerFunc(s.handleAllowTraffic),
+		routes.DenyTraffic:            http.HandlerFunc(s.handleDenyTraffic),
 		routes.Info:                   http.HandlerFunc(s.handleInfo),
 		routes.BulkInfo:               http.HandlerFunc(s.handleBulkInfo),
 		routes.BulkMetrics:            http.HandlerFunc(s.handleBulkMetrics),
+		routes.ListPortMappings:       http.HandlerFunc(s.handleListPortMappings),
 		routes.Run:                    http.HandlerFunc(s.handleRun),
-		routes.Stdout:                 streamer.HandlerFunc(s.streamer.ServeStdout),
-		routes.Stderr:                 streamer.HandlerFunc(s.streamer.ServeStderr),
+		routes.Stdout:                 streamer.HandlerFunc(s.serveStdout),
+		routes.Stderr:                 streamer.HandlerFunc(s.serveStderr),
 		routes.Attach:                 http.HandlerFunc(s.handleAttach),
+		routes.ProcessStatus:          http.HandlerFunc(s.handleProcessStatus),
+		routes.ProcessLogs:            http.HandlerFunc(s.handleProcessLogs),
+		routes.ProcessEnv:             http.HandlerFunc(s.handleProcessEnv),
+		routes.Spec:                   http.HandlerFunc(s.handleSpec),
+		routes.ProcessAttachments:     http.HandlerFunc(s.handleProcessAttachments),
+		routes.AttachAll:              http.HandlerFunc(s.handleAttachAll),
+		routes.WaitForProcesses:       http.HandlerFunc(s.handleWaitForProcesses),
 		routes.Metrics:                http.HandlerFunc(s.handleMetrics),
+		routes.ProcessStats:           http.HandlerFunc(s.handleProcessStats),
+		routes.ResourceUsage:          http.HandlerFunc(s.handleResourceUsage),
 		routes.Properties:             http.HandlerFunc(s.handleProperties),
 		routes.Property:               http.HandlerFunc(s.handleProperty),
 		routes.SetProperty:            http.HandlerFunc(s.handleSetProperty),
 		routes.RemoveProperty:         http.HandlerFunc(s.handleRemoveProperty),
+		routes.SetPropertyForAll:      http.HandlerFunc(s.handleSetPropertyForAll),
 		routes.SetGraceTime:           http.HandlerFunc(s.handleSetGraceTime),
+		routes.SetDrainMode:           http.HandlerFunc(s.handleSetDrainMode),
+	}
+
+	for route, handler := range handlers {
+		handlers[route] = s.rateLimited(route, s.compressed(route, s.audited(route, handler)))
 	}
 
 	mux, err := rata.NewRouter(routes.Routes, handlers)
@@ -111,9 +254,9 @@ func New(
 	conLogger := logger.Session("connection")
 
 	s.server = &http.Server{
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Handler: s.servingHTTP2(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			mux.ServeHTTP(w, r)
-		}),
+		})),
 
 		ConnState: func(conn net.Conn, state http.ConnState) {
 			switch state {
@@ -190,13 +333,37 @@ func (s *GardenServer) Start() error {
 	return nil
 }
 
+// SetOutputRateLimit caps the rate, in bytes per second, at which each
+// client's stream of a process's stdout or stderr is written out. A process
+// writing faster than this blocks on its output once its client falls behind,
+// unless the client asked for the output it is behind on to be dropped
+// instead. Zero, the default, means no limit.
+func (s *GardenServer) SetOutputRateLimit(bytesPerSecond int) {
+	atomic.StoreInt64(&s.outputRateLimit, int64(bytesPerSecond))
+}
+
+// SetFailedContainerGraceTime sets the grace time given to a container kept
+// because a process run with KeepOnFailure failed. Zero keeps the container
+// until it is destroyed. The default is a day.
+func (s *GardenServer) SetFailedContainerGraceTime(graceTime time.Duration) {
+	atomic.StoreInt64(&s.failedContainerGraceTime, int64(graceTime))
+}
+
+// ThrottledOutputBytes returns the total number of process output bytes whose
+// delivery to a client has been delayed by the output rate limit.
+func (s *GardenServer) ThrottledOutputBytes() uint64 {
+	return atomic.LoadUint64(&s.throttledOutputBytes)
+}
+
 func (s *GardenServer) SetupBomberman() error {
 	containers, err := s.backend.Containers(nil)
 	if err != nil {
 		return err
 	}
 
-	s.bomberman = bomberman.New(s.backend, s.reapContainer)
+	s.bomberman = bomberman.New(s.backend, func(container garden.Container) {
+		s.reapContainer(container, ReapReasonGraceTimeExpired)
+	})
 
 	for _, container := range containers {
 		s.bomberman.Strap(container)
@@ -257,6 +424,9 @@ func (s *GardenServer) Stop() {
 	s.logger.Info("waiting-for-connections-to-close")
 	s.handling.Wait()
 
+	s.logger.Info("ending-limit-boosts")
+	s.endAllBoosts()
+
 	s.logger.Info("stopping-backend")
 	s.backend.Stop()
 
@@ -281,10 +451,32 @@ func (s *GardenServer) removeExistingSocket() error {
 	return nil
 }
 
-func (s *GardenServer) reapContainer(container garden.Container) {
+// forgetContainer forgets everything the server keeps about a container once
+// it has been destroyed, whether by a client or by reaping.
+func (s *GardenServer) forgetContainer(logger lager.Logger, handle string) {
+	s.recentlyDestroyed.add(handle)
+	s.syslogs.destroyed(handle)
+	s.infoVersions.destroyed(handle)
+	s.limitBoosts.destroyed(handle)
+	s.processTracker.destroyed(handle)
+
+	s.forgetTraffic(logger, handle)
+	s.removeOutputLogs(logger, handle)
+
+	if err := s.containerSpecs.destroyed(s.containerSpecRoot(), handle); err != nil {
+		logger.Error("failed-to-remove-spec", err, lager.Data{
+			"handle": handle,
+		})
+	}
+}
+
+func (s *GardenServer) reapContainer(container garden.Container, reason ReapReason) {
+	graceTime := s.backend.GraceTime(container)
+
 	s.logger.Info("reaping", lager.Data{
 		"handle":     container.Handle(),
-		"grace-time": s.backend.GraceTime(container).String(),
+		"grace-time": graceTime.String(),
+		"reason":     reason,
 	})
 
 	s.destroysL.Lock()
@@ -297,14 +489,29 @@ func (s *GardenServer) reapContainer(container garden.Container) {
 	if alreadyDestroying {
 		s.logger.Info("skipping reap due to concurrent delete request", lager.Data{
 			"handle":     container.Handle(),
-			"grace-time": s.backend.GraceTime(container).String(),
+			"grace-time": graceTime.String(),
 		})
 		return
 	}
 
-	s.backend.Destroy(container.Handle())
+	s.handleLocks.Lock(container.Handle())
+	err := s.backend.Destroy(container.Handle())
+	s.handleLocks.Unlock(container.Handle())
+
+	if err == nil {
+		s.forgetContainer(s.logger, container.Handle())
+	}
+
+	s.capacityNotifier.notify()
 
 	s.destroysL.Lock()
 	delete(s.destroys, container.Handle())
 	s.destroysL.Unlock()
+
+	s.reaped(ReapEvent{
+		Handle:    container.Handle(),
+		GraceTime: graceTime,
+		Reason:    reason,
+		Err:       err,
+	})
 }
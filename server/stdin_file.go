@@ -0,0 +1,109 @@
+package server
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrStdinFilesDisabled = garden.InvalidRequestError{Reason: "stdin files are not enabled on this server"}
+var ErrInvalidStdinFile = garden.InvalidRequestError{Reason: "stdin file must be a relative path within the server's stdin directory"}
+
+// SetStdinFileDir enables ProcessSpec.StdinFile, which names files under dir.
+// An empty dir, the default, disables stdin files.
+func (s *GardenServer) SetStdinFileDir(dir string) {
+	s.stdinFileDir.Store(dir)
+}
+
+func (s *GardenServer) stdinFileRoot() string {
+	dir, _ := s.stdinFileDir.Load().(string)
+	return dir
+}
+
+// openStdinFile opens the named file under the stdin directory, refusing any
+// name, or symlink, which leads outside of it.
+func (s *GardenServer) openStdinFile(logger lager.Logger, name string) (*stdinFile, error) {
+	root := s.stdinFileRoot()
+	if root == "" {
+		return nil, ErrStdinFilesDisabled
+	}
+
+	if !filepath.IsLocal(name) {
+		return nil, ErrInvalidStdinFile
+	}
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := filepath.EvalSymlinks(filepath.Join(resolvedRoot, name))
+	if os.IsNotExist(err) {
+		return nil, garden.FileNotFoundError{Path: name}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if rel, err := filepath.Rel(resolvedRoot, path); err != nil || !filepath.IsLocal(rel) {
+		return nil, ErrInvalidStdinFile
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	opened, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if opened.IsDir() {
+		file.Close()
+		return nil, garden.IsADirectoryError{Path: name}
+	}
+
+	return &stdinFile{file: file, logger: logger, name: name, opened: opened}, nil
+}
+
+// stdinFile feeds a process's stdin from a file opened when the process
+// started. The process reads the file as it is at the time, so changes made
+// to it while the process runs may or may not be seen; if the file was
+// modified by the time it has been read to the end, that is logged.
+type stdinFile struct {
+	file *os.File
+
+	logger lager.Logger
+	name   string
+	opened os.FileInfo
+}
+
+func (f *stdinFile) Read(p []byte) (int, error) {
+	n, err := f.file.Read(p)
+	if err == io.EOF {
+		f.checkUnmodified()
+	}
+
+	return n, err
+}
+
+func (f *stdinFile) Close() error {
+	return f.file.Close()
+}
+
+func (f *stdinFile) checkUnmodified() {
+	current, err := f.file.Stat()
+	if err != nil {
+		return
+	}
+
+	if !current.ModTime().Equal(f.opened.ModTime()) || current.Size() != f.opened.Size() {
+		f.logger.Info("stdin-file-modified-while-running", lager.Data{
+			"stdin-file": f.name,
+		})
+	}
+}
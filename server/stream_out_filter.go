@@ -0,0 +1,91 @@
+package server
+
+import (
+	"archive/tar"
+	"io"
+	"path"
+	"strings"
+
+	"code.cloudfoundry.org/garden"
+)
+
+var ErrInvalidIncludePattern = garden.InvalidRequestError{Reason: "include pattern is not a valid glob"}
+
+func validateIncludePatterns(include []string) error {
+	for _, pattern := range include {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ErrInvalidIncludePattern
+		}
+	}
+
+	return nil
+}
+
+// filterTar copies the entries of archive to w which match any of the include
+// patterns, returning how many bytes it wrote. If none match, an empty
+// archive is written.
+func filterTar(w io.Writer, archive io.Reader, include []string) (int64, error) {
+	counter := &byteCounter{Writer: w}
+
+	tr := tar.NewReader(archive)
+	tw := tar.NewWriter(counter)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return counter.written, err
+		}
+
+		if !tarEntryIncluded(header.Name, include) {
+			continue
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return counter.written, err
+		}
+
+		if _, err := io.Copy(tw, tr); err != nil {
+			return counter.written, err
+		}
+	}
+
+	err := tw.Close()
+	return counter.written, err
+}
+
+// tarEntryIncluded reports whether a pattern matches the entry's name, or one
+// of the directories it is in, so that including a directory includes
+// everything under it. Names are matched without any leading "./".
+func tarEntryIncluded(name string, include []string) bool {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "./"), "/")
+
+	for {
+		for _, pattern := range include {
+			if matched, _ := path.Match(strings.TrimPrefix(pattern, "./"), name); matched {
+				return true
+			}
+		}
+
+		slash := strings.LastIndex(name, "/")
+		if slash < 0 {
+			return false
+		}
+
+		name = name[:slash]
+	}
+}
+
+type byteCounter struct {
+	io.Writer
+	written int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	c.written += int64(n)
+	return n, err
+}
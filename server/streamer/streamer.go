@@ -28,6 +28,18 @@ type Streamer struct {
 type stream struct {
 	ch   [2]chan []byte
 	done chan struct{}
+
+	stopOnce sync.Once
+
+	servingL sync.Mutex
+	serving  sync.WaitGroup
+	flushed  bool
+}
+
+func (s *stream) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
 }
 
 type stdoutOrErr int
@@ -67,6 +79,15 @@ func (m *Streamer) ServeStderr(streamID StreamID, writer io.Writer) {
 func (m *Streamer) serve(streamID StreamID, writer io.Writer, chanIndex stdoutOrErr) {
 	strm := m.streamFromID(streamID)
 
+	// readers that connect once the stream has been flushed only drain what
+	// is left, so there is nothing for Flush to wait on
+	strm.servingL.Lock()
+	if !strm.flushed {
+		strm.serving.Add(1)
+		defer strm.serving.Done()
+	}
+	strm.servingL.Unlock()
+
 	ch := strm.ch[chanIndex]
 	for {
 		select {
@@ -92,10 +113,25 @@ func drain(ch chan []byte, writer io.Writer) {
 	}
 }
 
+// Flush stops streaming from the specified pair of channels and blocks until
+// every connected reader has written out the output remaining in them. It is
+// used to make sure a process's output has been delivered before its exit
+// status is.
+func (m *Streamer) Flush(streamID StreamID) {
+	strm := m.streamFromID(streamID)
+
+	strm.servingL.Lock()
+	strm.flushed = true
+	strm.servingL.Unlock()
+
+	strm.stop()
+	strm.serving.Wait()
+}
+
 // Stop stops streaming from the specified pair of channels.
 func (m *Streamer) Stop(streamID StreamID) {
 	strm := m.streamFromID(streamID)
-	close(strm.done)
+	strm.stop()
 
 	go func() {
 		// wait some time to ensure clients have connected, once they've
@@ -84,6 +84,46 @@ var _ = Describe("Streamer", func() {
 		Consistently(w.String).Should(Equal(testString))
 	})
 
+	Describe("flushing", func() {
+		It("waits for connected readers to write out the remaining output", func() {
+			sid := str.Stream(stdoutChan, stderrChan)
+
+			w := &blockingWriter{
+				syncBuffer: syncBuffer{Buffer: new(bytes.Buffer)},
+				writing:    make(chan struct{}),
+				release:    make(chan struct{}),
+			}
+			go str.ServeStdout(sid, w)
+
+			stdoutChan <- testByteSlice
+			Eventually(w.writing).Should(BeClosed())
+
+			flushed := make(chan struct{})
+			go func() {
+				str.Flush(sid)
+				close(flushed)
+			}()
+
+			Consistently(flushed).ShouldNot(BeClosed())
+
+			close(w.release)
+
+			Eventually(flushed).Should(BeClosed())
+			Expect(w.String()).To(Equal(testString))
+		})
+
+		It("does not wait when no reader is connected", func() {
+			sid := str.Stream(stdoutChan, stderrChan)
+			str.Flush(sid)
+		})
+
+		It("can still be stopped afterwards", func() {
+			sid := str.Stream(stdoutChan, stderrChan)
+			str.Flush(sid)
+			Expect(func() { str.Stop(sid) }).NotTo(Panic())
+		})
+	})
+
 	Context("when a grace time has been set", func() {
 		BeforeEach(func() {
 			graceTime = 100 * time.Millisecond
@@ -158,3 +198,15 @@ func (sb *syncBuffer) String() string {
 	defer sb.mu.Unlock()
 	return sb.Buffer.String()
 }
+
+type blockingWriter struct {
+	syncBuffer
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (bw *blockingWriter) Write(p []byte) (int, error) {
+	close(bw.writing)
+	<-bw.release
+	return bw.syncBuffer.Write(p)
+}
@@ -0,0 +1,465 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+// syslogQueueSize is how many messages are queued for each syslog endpoint.
+// Any more are dropped rather than hold up the processes writing them.
+const syslogQueueSize = 1000
+
+// syslogTimeout bounds connecting to a syslog endpoint, and each write to it.
+const syslogTimeout = 5 * time.Second
+
+// syslogMinRetryInterval and syslogMaxRetryInterval bound how long a syslog
+// endpoint which cannot be connected to is left before trying again.
+const syslogMinRetryInterval = time.Second
+const syslogMaxRetryInterval = 30 * time.Second
+
+// maxSyslogLine is the longest line sent as a single message; longer lines
+// are split.
+const maxSyslogLine = 8 * 1024
+
+const (
+	syslogSeverityErr  = 3
+	syslogSeverityInfo = 6
+)
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+var ErrSyslogDisabled = garden.InvalidRequestError{Reason: "syslog forwarding is not enabled on this server"}
+
+// SetSyslogEndpoints enables forwarding process output to syslog, to the
+// given addresses, as host:port, only. Forwarding to anywhere else would let
+// a client have the server send traffic on its behalf. By default no
+// endpoints are allowed, so forwarding is disabled.
+func (s *GardenServer) SetSyslogEndpoints(addresses []string) {
+	s.syslogs.setAllowed(addresses)
+}
+
+func validateSyslog(spec *garden.SyslogSpec) error {
+	if spec == nil {
+		return nil
+	}
+
+	if spec.Network != "" && spec.Network != "udp" && spec.Network != "tcp" {
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("syslog network %q must be udp or tcp", spec.Network),
+		}
+	}
+
+	if _, port, err := net.SplitHostPort(spec.Address); err != nil || port == "" {
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("syslog address %q must be host:port", spec.Address),
+		}
+	}
+
+	if _, found := syslogFacilities[syslogFacility(spec)]; !found {
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("syslog facility %q is not a known facility", spec.Facility),
+		}
+	}
+
+	if !validSyslogName(spec.Tag, 48) {
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("syslog tag %q must be at most 48 printable characters, without spaces", spec.Tag),
+		}
+	}
+
+	return nil
+}
+
+// validSyslogName reports whether name may be used as a field of an RFC 5424
+// header: printable ASCII, without spaces, and not too long.
+func validSyslogName(name string, max int) bool {
+	if len(name) > max {
+		return false
+	}
+
+	for _, c := range name {
+		if c < 33 || c > 126 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func syslogFacility(spec *garden.SyslogSpec) string {
+	if spec.Facility == "" {
+		return "user"
+	}
+
+	return spec.Facility
+}
+
+type syslogEndpoint struct {
+	network string
+	address string
+}
+
+// syslogTracker remembers the syslog endpoint each container's processes
+// forward their output to by default, and holds each container's connections
+// to the endpoints its processes forward to, until it is destroyed.
+type syslogTracker struct {
+	mu         sync.Mutex
+	allowed    map[string]bool
+	specs      map[string]garden.SyslogSpec
+	forwarders map[string]map[syslogEndpoint]*syslogForwarder
+}
+
+func newSyslogTracker() *syslogTracker {
+	return &syslogTracker{
+		specs:      make(map[string]garden.SyslogSpec),
+		forwarders: make(map[string]map[syslogEndpoint]*syslogForwarder),
+	}
+}
+
+func (t *syslogTracker) setAllowed(addresses []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.allowed = make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		t.allowed[address] = true
+	}
+}
+
+// check refuses an endpoint the server does not forward to.
+func (t *syslogTracker) check(spec *garden.SyslogSpec) error {
+	if spec == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.allowed) == 0 {
+		return ErrSyslogDisabled
+	}
+
+	if !t.allowed[spec.Address] {
+		return garden.InvalidRequestError{
+			Reason: fmt.Sprintf("syslog address %q is not one this server forwards to", spec.Address),
+		}
+	}
+
+	return nil
+}
+
+func (t *syslogTracker) created(handle string, spec *garden.SyslogSpec) {
+	if spec == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.specs[handle] = *spec
+}
+
+func (t *syslogTracker) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if spec, found := t.specs[oldHandle]; found {
+		t.specs[newHandle] = spec
+		delete(t.specs, oldHandle)
+	}
+
+	if forwarders, found := t.forwarders[oldHandle]; found {
+		t.forwarders[newHandle] = forwarders
+		delete(t.forwarders, oldHandle)
+	}
+}
+
+func (t *syslogTracker) destroyed(handle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, forwarder := range t.forwarders[handle] {
+		forwarder.close()
+	}
+
+	delete(t.specs, handle)
+	delete(t.forwarders, handle)
+}
+
+// spec returns the endpoint a process forwards its output to: its own, if it
+// has one, or else its container's.
+func (t *syslogTracker) spec(handle string, processSpec *garden.SyslogSpec) *garden.SyslogSpec {
+	if processSpec != nil {
+		return processSpec
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spec, found := t.specs[handle]
+	if !found {
+		return nil
+	}
+
+	return &spec
+}
+
+// writers returns the writers forwarding a process's stdout and stderr to the
+// endpoint, connecting the container to it if it is not already.
+func (t *syslogTracker) writers(logger lager.Logger, handle string, spec garden.SyslogSpec) (*syslogWriter, *syslogWriter) {
+	endpoint := syslogEndpoint{network: spec.Network, address: spec.Address}
+	if endpoint.network == "" {
+		endpoint.network = "udp"
+	}
+
+	t.mu.Lock()
+	if t.forwarders[handle] == nil {
+		t.forwarders[handle] = make(map[syslogEndpoint]*syslogForwarder)
+	}
+
+	forwarder, found := t.forwarders[handle][endpoint]
+	if !found {
+		forwarder = newSyslogForwarder(logger.Session("syslog", lager.Data{
+			"handle":  handle,
+			"network": endpoint.network,
+			"address": endpoint.address,
+		}), endpoint)
+		t.forwarders[handle][endpoint] = forwarder
+	}
+	t.mu.Unlock()
+
+	// the tag falls back to the handle, unless that is no valid APP-NAME
+	tag := spec.Tag
+	if tag == "" {
+		tag = "-"
+		if validSyslogName(handle, 48) {
+			tag = handle
+		}
+	}
+
+	facility := syslogFacilities[syslogFacility(&spec)]
+
+	stdout := &syslogWriter{forwarder: forwarder, priority: facility*8 + syslogSeverityInfo, tag: tag, msgID: "stdout", processID: "-"}
+	stderr := &syslogWriter{forwarder: forwarder, priority: facility*8 + syslogSeverityErr, tag: tag, msgID: "stderr", processID: "-"}
+
+	return stdout, stderr
+}
+
+// syslogForwarder sends the messages queued for a syslog endpoint, connecting
+// to it as it needs to.
+type syslogForwarder struct {
+	logger   lager.Logger
+	endpoint syslogEndpoint
+	hostname string
+
+	messages chan []byte
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// dropped counts the messages dropped since last connected
+	dropped uint64
+}
+
+func newSyslogForwarder(logger lager.Logger, endpoint syslogEndpoint) *syslogForwarder {
+	hostname, err := os.Hostname()
+	if err != nil || !validSyslogName(hostname, 255) || hostname == "" {
+		hostname = "-"
+	}
+
+	forwarder := &syslogForwarder{
+		logger:   logger,
+		endpoint: endpoint,
+		hostname: hostname,
+		messages: make(chan []byte, syslogQueueSize),
+		done:     make(chan struct{}),
+	}
+
+	go forwarder.run()
+
+	return forwarder
+}
+
+func (f *syslogForwarder) send(message []byte) {
+	select {
+	case f.messages <- message:
+	default:
+		atomic.AddUint64(&f.dropped, 1)
+	}
+}
+
+func (f *syslogForwarder) close() {
+	f.stopOnce.Do(func() {
+		close(f.done)
+	})
+}
+
+func (f *syslogForwarder) run() {
+	var conn net.Conn
+	var retryAt time.Time
+	retryInterval := syslogMinRetryInterval
+
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		var message []byte
+		select {
+		case message = <-f.messages:
+		case <-f.done:
+			return
+		}
+
+		if conn == nil {
+			if time.Now().Before(retryAt) {
+				atomic.AddUint64(&f.dropped, 1)
+				continue
+			}
+
+			var err error
+			conn, err = net.DialTimeout(f.endpoint.network, f.endpoint.address, syslogTimeout)
+			if err != nil {
+				f.logger.Error("failed-to-connect", err, lager.Data{
+					"retry-in": retryInterval.String(),
+				})
+
+				atomic.AddUint64(&f.dropped, 1)
+				retryAt = time.Now().Add(retryInterval)
+				retryInterval *= 2
+				if retryInterval > syslogMaxRetryInterval {
+					retryInterval = syslogMaxRetryInterval
+				}
+
+				continue
+			}
+
+			f.logger.Info("connected", lager.Data{
+				"dropped": atomic.SwapUint64(&f.dropped, 0),
+			})
+			retryInterval = syslogMinRetryInterval
+		}
+
+		if f.endpoint.network == "tcp" {
+			message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+		if _, err := conn.Write(message); err != nil {
+			f.logger.Error("disconnected", err)
+
+			atomic.AddUint64(&f.dropped, 1)
+			conn.Close()
+			conn = nil
+			retryAt = time.Time{}
+		}
+	}
+}
+
+// syslogWriter sends each line written to it as a message. A line which is
+// still incomplete is held until it is, or until the writer is flushed.
+type syslogWriter struct {
+	forwarder *syslogForwarder
+	priority  int
+	tag       string
+	msgID     string
+
+	mu        sync.Mutex
+	processID string
+	partial   []byte
+}
+
+var _ io.Writer = &syslogWriter{}
+
+func (w *syslogWriter) setProcessID(processID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if validSyslogName(processID, 128) && processID != "" {
+		w.processID = processID
+	}
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+
+	for {
+		newline := bytes.IndexByte(w.partial, '\n')
+		if newline < 0 {
+			break
+		}
+
+		w.sendLocked(w.partial[:newline])
+		w.partial = w.partial[newline+1:]
+	}
+
+	for len(w.partial) >= maxSyslogLine {
+		w.sendLocked(w.partial[:maxSyslogLine])
+		w.partial = w.partial[maxSyslogLine:]
+	}
+
+	// don't hold on to the backing array of output already sent
+	w.partial = append([]byte(nil), w.partial...)
+
+	return len(p), nil
+}
+
+// flush sends any incomplete line.
+func (w *syslogWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.sendLocked(w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *syslogWriter) sendLocked(line []byte) {
+	message := fmt.Sprintf("<%d>1 %s %s %s %s %s - %s",
+		w.priority,
+		time.Now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.forwarder.hostname,
+		w.tag,
+		w.processID,
+		w.msgID,
+		bytes.TrimSuffix(line, []byte("\r")),
+	)
+
+	w.forwarder.send([]byte(message))
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrTrafficToSelf = garden.InvalidRequestError{Reason: "a container cannot be given a traffic rule to itself"}
+var ErrDenyWithDefaultAllow = garden.InvalidRequestError{Reason: "traffic cannot be denied while the source container's network policy allows all traffic by default"}
+
+func (s *GardenServer) handleAllowTraffic(w http.ResponseWriter, r *http.Request) {
+	s.setTraffic(w, r, garden.NetworkActionAllow)
+}
+
+func (s *GardenServer) handleDenyTraffic(w http.ResponseWriter, r *http.Request) {
+	s.setTraffic(w, r, garden.NetworkActionDeny)
+}
+
+// setTraffic allows or denies traffic from one container to another by
+// adding or removing a rule for the destination container's IP in the
+// source container's network policy. Only a rule the server added is ever
+// removed, so one with the same effect given by the client is left alone.
+func (s *GardenServer) setTraffic(w http.ResponseWriter, r *http.Request, action garden.NetworkAction) {
+	srcHandle := r.FormValue(":handle")
+	dstHandle := r.FormValue(":peer")
+
+	hLog := s.logger.Session("set-traffic", lager.Data{
+		"src-handle": srcHandle,
+		"dst-handle": dstHandle,
+		"action":     action,
+	})
+
+	if srcHandle == dstHandle {
+		s.writeError(w, ErrTrafficToSelf, hLog)
+		return
+	}
+
+	// always lock the two handles in the same order, so that a waiting
+	// destroy of one cannot deadlock this against a request the other way
+	for _, handle := range sortedHandles(srcHandle, dstHandle) {
+		s.handleLocks.RLock(handle)
+		defer s.handleLocks.RUnlock(handle)
+	}
+
+	src, err := s.backend.Lookup(srcHandle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	dst, err := s.backend.Lookup(dstHandle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(src.Handle())
+	defer s.bomberman.Unpause(src.Handle())
+
+	s.bomberman.Pause(dst.Handle())
+	defer s.bomberman.Unpause(dst.Handle())
+
+	dstInfo, err := dst.Info()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	dstIP := net.ParseIP(dstInfo.ContainerIP)
+	if dstIP == nil {
+		s.writeError(w, garden.InvalidRequestError{
+			Reason: fmt.Sprintf("container %s has no IP address", dst.Handle()),
+		}, hLog)
+		return
+	}
+
+	hLog.Debug("setting", lager.Data{
+		"dst-ip": dstInfo.ContainerIP,
+	})
+
+	s.networkPolicyLocks.Lock(src.Handle())
+	defer s.networkPolicyLocks.Unlock(src.Handle())
+
+	added, allowed := s.trafficRules.to(src.Handle(), dst.Handle())
+
+	err = s.updateNetworkPolicyLocked(hLog, src, func(policy garden.NetworkPolicy) (garden.NetworkPolicy, error) {
+		if action == garden.NetworkActionDeny && policy.DefaultAction != garden.NetworkActionDeny {
+			return policy, ErrDenyWithDefaultAllow
+		}
+
+		if allowed {
+			policy.Rules = withoutTrafficRule(policy.Rules, added)
+		}
+
+		if action == garden.NetworkActionAllow {
+			policy.Rules = append(policy.Rules, trafficRule(dstIP))
+		}
+
+		return policy, nil
+	})
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if action == garden.NetworkActionAllow {
+		s.trafficRules.allowed(src.Handle(), dst.Handle(), dstIP)
+	} else {
+		s.trafficRules.denied(src.Handle(), dst.Handle())
+	}
+
+	hLog.Info("set")
+
+	s.writeSuccess(w)
+}
+
+// forgetTraffic removes the rules the server added to other containers'
+// policies for traffic to a destroyed container, whose IP may be given to
+// another.
+func (s *GardenServer) forgetTraffic(logger lager.Logger, handle string) {
+	for src, dstIP := range s.trafficRules.destroyed(handle) {
+		s.denyDestroyedTraffic(logger, src, dstIP)
+	}
+}
+
+func (s *GardenServer) denyDestroyedTraffic(logger lager.Logger, srcHandle string, dstIP net.IP) {
+	s.handleLocks.RLock(srcHandle)
+	defer s.handleLocks.RUnlock(srcHandle)
+
+	src, err := s.backend.Lookup(srcHandle)
+	if err != nil {
+		return
+	}
+
+	err = s.updateNetworkPolicy(logger, src, func(policy garden.NetworkPolicy) (garden.NetworkPolicy, error) {
+		policy.Rules = withoutTrafficRule(policy.Rules, dstIP)
+		return policy, nil
+	})
+	if err != nil {
+		logger.Error("failed-to-remove-traffic-rule", err, lager.Data{
+			"handle": srcHandle,
+			"dst-ip": dstIP.String(),
+		})
+	}
+}
+
+// trafficRule is the rule allowing all traffic to a container's IP.
+func trafficRule(ip net.IP) garden.NetOutRule {
+	return garden.NetOutRule{
+		Protocol: garden.ProtocolAll,
+		Networks: []garden.IPRange{garden.IPRangeFromIP(ip)},
+	}
+}
+
+// withoutTrafficRule returns rules without the first rule allowing all
+// traffic to ip and nothing else.
+func withoutTrafficRule(rules []garden.NetOutRule, ip net.IP) []garden.NetOutRule {
+	for i, rule := range rules {
+		if isTrafficRule(rule, ip) {
+			return append(append([]garden.NetOutRule{}, rules[:i]...), rules[i+1:]...)
+		}
+	}
+
+	return rules
+}
+
+func isTrafficRule(rule garden.NetOutRule, ip net.IP) bool {
+	if rule.Protocol != garden.ProtocolAll || len(rule.Ports) != 0 || rule.ICMPs != nil || rule.Log {
+		return false
+	}
+
+	if len(rule.Networks) != 1 {
+		return false
+	}
+
+	return rule.Networks[0].Start.Equal(ip) && rule.Networks[0].End.Equal(ip)
+}
+
+// trafficRuleTracker remembers the rules the server added to each container's
+// network policy to allow traffic to other containers, by the IP they allow
+// traffic to.
+type trafficRuleTracker struct {
+	mu    sync.Mutex
+	rules map[string]map[string]net.IP
+}
+
+func newTrafficRuleTracker() *trafficRuleTracker {
+	return &trafficRuleTracker{
+		rules: make(map[string]map[string]net.IP),
+	}
+}
+
+func (t *trafficRuleTracker) allowed(src, dst string, ip net.IP) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rules[src] == nil {
+		t.rules[src] = make(map[string]net.IP)
+	}
+
+	t.rules[src][dst] = ip
+}
+
+func (t *trafficRuleTracker) denied(src, dst string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.rules[src], dst)
+	if len(t.rules[src]) == 0 {
+		delete(t.rules, src)
+	}
+}
+
+func (t *trafficRuleTracker) to(src, dst string) (net.IP, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ip, found := t.rules[src][dst]
+	return ip, found
+}
+
+func (t *trafficRuleTracker) renamed(oldHandle, newHandle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if rules, found := t.rules[oldHandle]; found {
+		t.rules[newHandle] = rules
+		delete(t.rules, oldHandle)
+	}
+
+	for _, rules := range t.rules {
+		if ip, found := rules[oldHandle]; found {
+			rules[newHandle] = ip
+			delete(rules, oldHandle)
+		}
+	}
+}
+
+// destroyed forgets the rules involving a destroyed container, returning
+// the IP each other container was allowed to send it traffic at.
+func (t *trafficRuleTracker) destroyed(handle string) map[string]net.IP {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.rules, handle)
+
+	sources := map[string]net.IP{}
+	for src, rules := range t.rules {
+		if ip, found := rules[handle]; found {
+			sources[src] = ip
+
+			delete(rules, handle)
+			if len(rules) == 0 {
+				delete(t.rules, src)
+			}
+		}
+	}
+
+	return sources
+}
+
+func sortedHandles(handles ...string) []string {
+	sort.Strings(handles)
+	return handles
+}
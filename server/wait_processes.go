@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrInvalidWaitTimeout = garden.InvalidRequestError{Reason: "wait timeout must be a non-negative duration"}
+
+// handleWaitForProcesses responds once every process in a container has
+// exited. Processes started while it waits are waited for too, so it only
+// responds once the container has no processes at all. If the timeout, when
+// given, expires first it responds with a ProcessesRunningError naming those
+// still running.
+func (s *GardenServer) handleWaitForProcesses(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("wait-for-processes", lager.Data{
+		"handle": handle,
+	})
+
+	var timeout time.Duration
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		var err error
+		timeout, err = time.ParseDuration(t)
+		if err != nil || timeout < 0 {
+			s.writeError(w, ErrInvalidWaitTimeout, hLog)
+			return
+		}
+	}
+
+	// watch before listing the processes, so that none is missed in between
+	spawned, _, stopWatching := s.processTracker.watch(handle)
+	defer stopWatching()
+
+	s.handleLocks.RLock(handle)
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.handleLocks.RUnlock(handle)
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	info, err := container.Info()
+	s.handleLocks.RUnlock(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	exited := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+
+	running := map[string]bool{}
+	wait := func(processID string) {
+		if running[processID] {
+			return
+		}
+		running[processID] = true
+
+		go func() {
+			// a process which cannot be attached to has already gone; one
+			// which can is waited on once however many requests wait for
+			// it, so that nothing is left waiting once this one ends
+			if process, err := container.Attach(processID, garden.ProcessIO{}); err == nil {
+				select {
+				case <-s.processExits.wait(container.Handle(), process).exited:
+				case <-done:
+					return
+				}
+			}
+
+			select {
+			case exited <- processID:
+			case <-done:
+			}
+		}()
+	}
+
+	for _, processID := range info.ProcessIDs {
+		wait(processID)
+	}
+
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+
+	hLog.Debug("waiting", lager.Data{
+		"processes": len(running),
+		"timeout":   timeout.String(),
+	})
+
+	for len(running) > 0 {
+		select {
+		case processID := <-spawned:
+			wait(processID)
+		case processID := <-exited:
+			delete(running, processID)
+		case <-timedOut:
+			processIDs := make([]string, 0, len(running))
+			for processID := range running {
+				processIDs = append(processIDs, processID)
+			}
+			sort.Strings(processIDs)
+
+			s.writeError(w, garden.ProcessesRunningError{Handle: container.Handle(), ProcessIDs: processIDs}, hLog)
+			return
+		case <-r.Context().Done():
+			hLog.Info("client-went-away")
+			return
+		}
+	}
+
+	hLog.Info("all-exited")
+
+	s.writeSuccess(w)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrRelativeFilePath = garden.InvalidRequestError{Reason: "file path must be absolute"}
+var ErrInvalidWriteFileMode = garden.InvalidRequestError{Reason: "file mode must be octal permission bits between 0 and 0777"}
+
+const defaultMaxWriteFileSize = 100 * 1024 * 1024
+
+// SetMaxWriteFileSize bounds the size of the content of a file written with
+// WriteFile, which is spooled to the host's disk before being streamed in.
+// Larger content is refused. The default is 100MiB.
+func (s *GardenServer) SetMaxWriteFileSize(bytes int64) {
+	atomic.StoreInt64(&s.maxWriteFileSize, bytes)
+}
+
+func writeFileTooLarge(max int64) error {
+	return garden.InvalidRequestError{
+		Reason: fmt.Sprintf("file content exceeds the maximum of %d bytes", max),
+	}
+}
+
+// handleWriteFile writes a single file into a container without the caller
+// having to build a tar stream. The content is spooled to a temporary file so
+// that its size is known, then streamed in to the file's parent directory as
+// a one-entry tar, which the backend extracts like any other.
+func (s *GardenServer) handleWriteFile(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	path := r.URL.Query().Get("path")
+
+	hLog := s.logger.Session("write-file", lager.Data{
+		"handle": handle,
+		"path":   path,
+	})
+
+	if !filepath.IsAbs(path) || filepath.Base(path) == string(filepath.Separator) {
+		s.writeError(w, ErrRelativeFilePath, hLog)
+		return
+	}
+
+	mode, err := strconv.ParseUint(r.URL.Query().Get("mode"), 8, 32)
+	if err != nil || mode > 0777 {
+		s.writeError(w, ErrInvalidWriteFileMode, hLog)
+		return
+	}
+
+	maxSize := atomic.LoadInt64(&s.maxWriteFileSize)
+	if r.ContentLength > maxSize {
+		s.writeError(w, writeFileTooLarge(maxSize), hLog)
+		return
+	}
+
+	s.handleLocks.RLock(handle)
+	defer s.handleLocks.RUnlock(handle)
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	content, err := ioutil.TempFile("", "garden-write-file")
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	defer os.Remove(content.Name())
+	defer content.Close()
+
+	size, err := io.Copy(content, io.LimitReader(r.Body, maxSize+1))
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	if size > maxSize {
+		s.writeError(w, writeFileTooLarge(maxSize), hLog)
+		return
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Debug("writing")
+
+	tarStream := singleFileTar(filepath.Base(path), int64(mode), size, content)
+
+	err = container.StreamIn(garden.StreamInSpec{
+		Path:      filepath.Dir(path),
+		TarStream: tarStream,
+	})
+
+	// the backend may not have read all of it, which would leave the tar
+	// being written forever
+	tarStream.CloseWithError(err)
+
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("written")
+
+	s.writeSuccess(w)
+}
+
+// singleFileTar streams a tar archive holding just the named file.
+func singleFileTar(name string, mode, size int64, content io.Reader) *io.PipeReader {
+	r, w := io.Pipe()
+
+	go func() {
+		tw := tar.NewWriter(w)
+
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     mode,
+			Size:     size,
+			Typeflag: tar.TypeReg,
+		})
+		if err == nil {
+			_, err = io.Copy(tw, content)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+
+		w.CloseWithError(err)
+	}()
+
+	return r
+}
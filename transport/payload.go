@@ -19,6 +19,43 @@ type ProcessPayload struct {
 	Error      *string         `json:"error,omitempty"`
 	TTY        *garden.TTYSpec `json:"tty,omitempty"`
 	Signal     *garden.Signal  `json:"signal,omitempty"`
+
+	// RuntimeExceeded accompanies ExitStatus when the process was killed for
+	// running longer than its MaxRuntime.
+	RuntimeExceeded bool `json:"runtime_exceeded,omitempty"`
+
+	// OutputLimitExceeded accompanies ExitStatus when the process wrote more
+	// than its MaxOutputBytes.
+	OutputLimitExceeded bool `json:"output_limit_exceeded,omitempty"`
+
+	// QuotaExceeded accompanies Error when waiting on the process failed
+	// because it exceeded one of its container's disk quotas.
+	QuotaExceeded *garden.QuotaExceededError `json:"quota_exceeded,omitempty"`
+
+	// State and ControlError are only sent to clients which asked for a
+	// control channel.
+	State        *garden.ProcessState `json:"state,omitempty"`
+	ControlError *string              `json:"control_error,omitempty"`
+}
+
+// ListStateFilter is the query parameter of a list request which filters
+// containers by state. Every other parameter filters by property, so no
+// property of this name can be filtered on.
+const ListStateFilter = "garden.state"
+
+// ListLimit and ListCursor are the reserved query parameters of a paginated
+// list request: the greatest number of handles to return, and the cursor
+// returned with the previous page.
+const (
+	ListLimit  = "garden.limit"
+	ListCursor = "garden.cursor"
+)
+
+// ListResponse is the response to a list request. NextCursor is only set
+// when a paginated list has more handles to return.
+type ListResponse struct {
+	Handles    []string
+	NextCursor string `json:",omitempty"`
 }
 
 type NetInRequest struct {
@@ -0,0 +1,224 @@
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// ProcessError is returned by DecodeExitStatus when the remote end reports
+// that it failed to wait on the process.
+type ProcessError struct {
+	Message string
+}
+
+func (err ProcessError) Error() string {
+	return err.Message
+}
+
+// ProcessStreamCodec frames the ProcessPayload messages exchanged over a
+// hijacked process connection. It is shared by the client and the server so
+// that both ends agree on how each kind of payload is encoded and decoded.
+//
+// Encoding is safe for concurrent use; decoding is not.
+type ProcessStreamCodec struct {
+	decoder *json.Decoder
+
+	writer io.Writer
+	writeL sync.Mutex
+}
+
+func NewProcessStreamCodec(reader io.Reader, writer io.Writer) *ProcessStreamCodec {
+	return &ProcessStreamCodec{
+		decoder: json.NewDecoder(reader),
+		writer:  writer,
+	}
+}
+
+// EncodeStreamInfo writes the first payload of a process stream, identifying
+// the process and the stream ID from which its output can be attached.
+func (c *ProcessStreamCodec) EncodeStreamInfo(processID, streamID string) error {
+	return c.encode(&ProcessPayload{
+		ProcessID: processID,
+		StreamID:  streamID,
+	})
+}
+
+func (c *ProcessStreamCodec) EncodeStdin(processID string, data []byte) error {
+	d := string(data)
+	source := Stdin
+	return c.encode(&ProcessPayload{
+		ProcessID: processID,
+		Source:    &source,
+		Data:      &d,
+	})
+}
+
+// EncodeStdinEOF signals that there is no more input for the process.
+func (c *ProcessStreamCodec) EncodeStdinEOF(processID string) error {
+	source := Stdin
+	return c.encode(&ProcessPayload{
+		ProcessID: processID,
+		Source:    &source,
+	})
+}
+
+// EncodeOutput writes a chunk of process output for the given source, which
+// must be Stdout or Stderr.
+func (c *ProcessStreamCodec) EncodeOutput(processID string, source Source, data []byte) error {
+	d := string(data)
+	return c.encode(&ProcessPayload{
+		ProcessID: processID,
+		Source:    &source,
+		Data:      &d,
+	})
+}
+
+func (c *ProcessStreamCodec) EncodeTTY(processID string, spec garden.TTYSpec) error {
+	return c.encode(&ProcessPayload{
+		ProcessID: processID,
+		TTY:       &spec,
+	})
+}
+
+func (c *ProcessStreamCodec) EncodeSignal(processID string, signal garden.Signal) error {
+	return c.encode(&ProcessPayload{
+		ProcessID: processID,
+		Signal:    &signal,
+	})
+}
+
+func (c *ProcessStreamCodec) EncodeExitStatus(processID string, status int) error {
+	return c.encode(&ProcessPayload{
+		ProcessID:  processID,
+		ExitStatus: &status,
+	})
+}
+
+// EncodeRuntimeExceeded writes the exit status of a process that was killed
+// for running longer than its MaxRuntime.
+func (c *ProcessStreamCodec) EncodeRuntimeExceeded(processID string, status int) error {
+	return c.encode(&ProcessPayload{
+		ProcessID:       processID,
+		ExitStatus:      &status,
+		RuntimeExceeded: true,
+	})
+}
+
+// EncodeOutputLimitExceeded writes the exit status of a process whose output
+// was truncated, or which was killed, for exceeding its MaxOutputBytes.
+func (c *ProcessStreamCodec) EncodeOutputLimitExceeded(processID string, status int) error {
+	return c.encode(&ProcessPayload{
+		ProcessID:           processID,
+		ExitStatus:          &status,
+		OutputLimitExceeded: true,
+	})
+}
+
+// EncodeState reports a change in the state of the process over its control
+// channel.
+func (c *ProcessStreamCodec) EncodeState(processID string, state garden.ProcessState) error {
+	return c.encode(&ProcessPayload{
+		ProcessID: processID,
+		State:     &state,
+	})
+}
+
+// EncodeControlError reports over the process's control channel that a
+// signal or TTY resize could not be applied.
+func (c *ProcessStreamCodec) EncodeControlError(processID string, err error) error {
+	e := err.Error()
+	return c.encode(&ProcessPayload{
+		ProcessID:    processID,
+		ControlError: &e,
+	})
+}
+
+// EncodeError reports that waiting on the process failed. A
+// garden.QuotaExceededError keeps its type across the stream.
+func (c *ProcessStreamCodec) EncodeError(processID string, err error) error {
+	e := err.Error()
+	payload := &ProcessPayload{
+		ProcessID: processID,
+		Error:     &e,
+	}
+
+	if quotaErr, ok := err.(garden.QuotaExceededError); ok {
+		payload.QuotaExceeded = &quotaErr
+	}
+
+	return c.encode(payload)
+}
+
+// Decode reads the next payload from the stream.
+func (c *ProcessStreamCodec) Decode() (*ProcessPayload, error) {
+	payload := &ProcessPayload{}
+	if err := c.decoder.Decode(payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// DecodeExitStatus reads payloads until one carrying an exit status or an
+// error is found. Any other payloads are discarded. A reported error is
+// returned as a ProcessError, or as a garden.QuotaExceededError if it was
+// one, and an exit status reported by
+// EncodeRuntimeExceeded comes with a garden.ProcessRuntimeExceededError, and
+// one reported by EncodeOutputLimitExceeded with a
+// garden.ProcessOutputLimitExceededError; any other error is a failure to
+// decode.
+func (c *ProcessStreamCodec) DecodeExitStatus() (int, error) {
+	return c.DecodeExitStatusWithEvents(nil)
+}
+
+// DecodeExitStatusWithEvents is DecodeExitStatus, but passes the control
+// channel payloads it reads along the way to events as they arrive, rather
+// than discarding them. events may be nil.
+func (c *ProcessStreamCodec) DecodeExitStatusWithEvents(events func(garden.ProcessEvent)) (int, error) {
+	for {
+		payload, err := c.Decode()
+		if err != nil {
+			return 0, err
+		}
+
+		if events != nil {
+			if payload.State != nil {
+				events(garden.ProcessEvent{State: *payload.State})
+			}
+
+			if payload.ControlError != nil {
+				events(garden.ProcessEvent{State: garden.ProcessStateRunning, Error: *payload.ControlError})
+			}
+		}
+
+		if payload.QuotaExceeded != nil {
+			return 0, *payload.QuotaExceeded
+		}
+
+		if payload.Error != nil {
+			return 0, ProcessError{Message: *payload.Error}
+		}
+
+		if payload.ExitStatus != nil {
+			if payload.RuntimeExceeded {
+				return *payload.ExitStatus, garden.ProcessRuntimeExceededError{ProcessID: payload.ProcessID}
+			}
+
+			if payload.OutputLimitExceeded {
+				return *payload.ExitStatus, garden.ProcessOutputLimitExceededError{ProcessID: payload.ProcessID}
+			}
+
+			return *payload.ExitStatus, nil
+		}
+	}
+}
+
+func (c *ProcessStreamCodec) encode(payload *ProcessPayload) error {
+	c.writeL.Lock()
+	defer c.writeL.Unlock()
+
+	return WriteMessage(c.writer, payload)
+}
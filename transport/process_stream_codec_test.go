@@ -0,0 +1,172 @@
+package transport_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/garden/transport"
+)
+
+var _ = Describe("ProcessStreamCodec", func() {
+	var (
+		buffer *bytes.Buffer
+		codec  *transport.ProcessStreamCodec
+	)
+
+	BeforeEach(func() {
+		buffer = new(bytes.Buffer)
+		codec = transport.NewProcessStreamCodec(buffer, buffer)
+	})
+
+	It("round-trips the stream info", func() {
+		Expect(codec.EncodeStreamInfo("some-process", "42")).To(Succeed())
+
+		payload, err := codec.Decode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(payload.ProcessID).To(Equal("some-process"))
+		Expect(payload.StreamID).To(Equal("42"))
+	})
+
+	It("round-trips stdin data and EOF", func() {
+		Expect(codec.EncodeStdin("some-process", []byte("hello"))).To(Succeed())
+		Expect(codec.EncodeStdinEOF("some-process")).To(Succeed())
+
+		payload, err := codec.Decode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*payload.Source).To(Equal(transport.Stdin))
+		Expect(*payload.Data).To(Equal("hello"))
+
+		payload, err = codec.Decode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*payload.Source).To(Equal(transport.Stdin))
+		Expect(payload.Data).To(BeNil())
+	})
+
+	It("round-trips stdout and stderr data", func() {
+		Expect(codec.EncodeOutput("some-process", transport.Stdout, []byte("out"))).To(Succeed())
+		Expect(codec.EncodeOutput("some-process", transport.Stderr, []byte("err"))).To(Succeed())
+
+		payload, err := codec.Decode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*payload.Source).To(Equal(transport.Stdout))
+		Expect(*payload.Data).To(Equal("out"))
+
+		payload, err = codec.Decode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*payload.Source).To(Equal(transport.Stderr))
+		Expect(*payload.Data).To(Equal("err"))
+	})
+
+	It("round-trips tty and signal payloads", func() {
+		Expect(codec.EncodeTTY("some-process", garden.TTYSpec{
+			WindowSize: &garden.WindowSize{Columns: 80, Rows: 24},
+		})).To(Succeed())
+		Expect(codec.EncodeSignal("some-process", garden.SignalKill)).To(Succeed())
+
+		payload, err := codec.Decode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(payload.TTY.WindowSize.Columns).To(Equal(80))
+
+		payload, err = codec.Decode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*payload.Signal).To(Equal(garden.SignalKill))
+	})
+
+	Describe("DecodeExitStatus", func() {
+		It("returns the exit status", func() {
+			Expect(codec.EncodeExitStatus("some-process", 42)).To(Succeed())
+
+			status, err := codec.DecodeExitStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(42))
+		})
+
+		It("returns a zero exit status", func() {
+			Expect(codec.EncodeExitStatus("some-process", 0)).To(Succeed())
+
+			status, err := codec.DecodeExitStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(0))
+		})
+
+		It("returns a reported error as a ProcessError", func() {
+			Expect(codec.EncodeError("some-process", errors.New("oh no"))).To(Succeed())
+
+			_, err := codec.DecodeExitStatus()
+			Expect(err).To(MatchError(transport.ProcessError{Message: "oh no"}))
+		})
+
+		It("keeps the type of a reported QuotaExceededError", func() {
+			quotaErr := garden.QuotaExceededError{Handle: "some-handle", Quota: garden.DiskQuotaInodes}
+			Expect(codec.EncodeError("some-process", quotaErr)).To(Succeed())
+
+			_, err := codec.DecodeExitStatus()
+			Expect(err).To(Equal(quotaErr))
+		})
+
+		It("returns the exit status of a process that exceeded its runtime with a ProcessRuntimeExceededError", func() {
+			Expect(codec.EncodeRuntimeExceeded("some-process", 137)).To(Succeed())
+
+			status, err := codec.DecodeExitStatus()
+			Expect(status).To(Equal(137))
+			Expect(err).To(Equal(garden.ProcessRuntimeExceededError{ProcessID: "some-process"}))
+		})
+
+		It("returns the exit status of a process that exceeded its output limit with a ProcessOutputLimitExceededError", func() {
+			Expect(codec.EncodeOutputLimitExceeded("some-process", 0)).To(Succeed())
+
+			status, err := codec.DecodeExitStatus()
+			Expect(status).To(Equal(0))
+			Expect(err).To(Equal(garden.ProcessOutputLimitExceededError{ProcessID: "some-process"}))
+		})
+
+		It("discards output and unknown payloads preceding the exit status", func() {
+			Expect(codec.EncodeOutput("some-process", transport.Stdout, []byte("out"))).To(Succeed())
+			Expect(codec.EncodeOutput("some-process", transport.Stderr, []byte("err"))).To(Succeed())
+			Expect(transport.WriteMessage(buffer, map[string]string{"unknown": "field"})).To(Succeed())
+			Expect(codec.EncodeExitStatus("some-process", 3)).To(Succeed())
+
+			status, err := codec.DecodeExitStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(3))
+		})
+
+		It("passes control channel payloads to the events callback", func() {
+			Expect(codec.EncodeState("some-process", garden.ProcessStateRunning)).To(Succeed())
+			Expect(codec.EncodeControlError("some-process", errors.New("no such process"))).To(Succeed())
+			Expect(codec.EncodeExitStatus("some-process", 3)).To(Succeed())
+
+			var events []garden.ProcessEvent
+			status, err := codec.DecodeExitStatusWithEvents(func(event garden.ProcessEvent) {
+				events = append(events, event)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(3))
+
+			Expect(events).To(Equal([]garden.ProcessEvent{
+				{State: garden.ProcessStateRunning},
+				{State: garden.ProcessStateRunning, Error: "no such process"},
+			}))
+		})
+
+		It("returns the decode error when the stream ends first", func() {
+			Expect(codec.EncodeOutput("some-process", transport.Stdout, []byte("out"))).To(Succeed())
+
+			_, err := codec.DecodeExitStatus()
+			Expect(err).To(Equal(io.EOF))
+		})
+
+		It("returns the decode error when the stream is malformed", func() {
+			buffer.WriteString("{not json")
+
+			_, err := codec.DecodeExitStatus()
+			Expect(err).To(HaveOccurred())
+			Expect(err).NotTo(BeAssignableToTypeOf(transport.ProcessError{}))
+		})
+	})
+})